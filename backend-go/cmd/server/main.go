@@ -1,24 +1,74 @@
 package main
 
 import (
-	"log"
+	"context"
+	"errors"
+	"flag"
 	"net/http"
 	"os"
+	"strconv"
+	"time"
 
 	"backend-go/internal/analysis"
 	"backend-go/internal/api"
+	"backend-go/internal/auth"
+	"backend-go/internal/config"
 	"backend-go/internal/llm"
+	"backend-go/internal/logging"
 	"backend-go/internal/service"
 	"backend-go/internal/state"
+	"backend-go/internal/tracing"
 
 	"github.com/go-chi/chi/v5"
 	"github.com/go-chi/chi/v5/middleware"
 	"github.com/go-chi/cors"
 )
 
+var logger = logging.Component("server")
+
 func main() {
+	// Settings: flags over environment over config file over defaults
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		logger.Error("Invalid configuration", "error", err)
+		os.Exit(1)
+	}
+	if err := logging.Configure(cfg.Log.Format, cfg.Log.Level); err != nil {
+		logger.Error("Invalid logging configuration", "error", err)
+		os.Exit(1)
+	}
+	state.State.OllamaBaseURL = cfg.Ollama.BaseURL
+	state.State.OllamaModel = cfg.Ollama.Model
+
+	// API keys; a bad users file must not leave the server open
+	if err := auth.Reload(); err != nil {
+		logger.Error("Failed to load API keys", "error", err)
+		os.Exit(1)
+	}
+
 	// Initialize Services
 	llmService := llm.NewService(state.State.OllamaBaseURL, state.State.OllamaModel)
+	llmService.SetEmbedModel(cfg.Ollama.EmbedModel)
+	llmService.SetTokenBudget(cfg.Ollama.ContextWindow, cfg.Ollama.OutputTokens)
+	if err := llmService.SetTaskModels(cfg.Ollama.Tasks); err != nil {
+		logger.Error("Invalid configuration", "setting", "ollama.tasks", "error", err)
+		os.Exit(1)
+	}
+	// Every LLM call is logged as ollama.call_log says
+	service.GetLLMCallStore().SetMode(cfg.Ollama.CallLog)
+	llmService.SetCallRecorder(service.GetLLMCallStore())
+	// Load the model in the background, so the first user doesn't wait for it
+	go func() {
+		health := llmService.WarmUp(context.Background())
+		if !health.Available {
+			logger.Warn("LLM warm-up failed", "model", health.Model, "error", health.Error)
+			return
+		}
+		logger.Info("LLM warmed up", "model", health.Model, "latency_ms", health.LatencyMS, "load_ms", health.LoadMS)
+	}()
 	ctxService := service.NewContextService()
 	qgService := service.NewQuestionGenerator(llmService)
 	csvService := analysis.NewCSVService()
@@ -28,21 +78,41 @@ func main() {
 	// Initialize Handler
 	handler := api.NewHandler(ctxService, qgService, csvService, simService, exportService, llmService)
 
+	// Saved queries run through the handler's query pipeline
+	service.GetSavedQueryStore().SetRunner(handler.RunSavedQuery)
+	service.GetSavedQueryStore().StartScheduler()
+
+	// Batch comparisons parse files through the handler's upload pipeline
+	service.GetBatchStore().SetRunner(handler.RunBatchPair)
+
+	// New files of the hot folder are ingested and checked against the reference
+	service.GetHotFolder().SetRunner(handler.RunHotFolderFile, handler.AcceptsHotFolderFile)
+	service.GetHotFolder().Start()
+
+	// Uploads older than upload.retention are deleted
+	handler.StartUploadSweeper()
+
 	// Router Setup
 	r := chi.NewRouter()
 
-	// Middleware
-	r.Use(middleware.Logger)
+	// Middleware; forwarded client addresses only count from trusted proxies
+	proxies, _ := cfg.Server.Proxies() // Validated by config.Load
+	r.Use(api.RealIP(proxies))
+	r.Use(logging.Middleware)
 	r.Use(middleware.Recoverer)
-	r.Use(middleware.RealIP)
+	r.Use(tracing.Middleware)
 
-	// CORS - Allow frontend
+	// CORS - Allow the frontend origins of the environment
+	if cfg.CORS.AllowsAny() {
+		logger.Warn("CORS allows any origin", "environment", cfg.Server.Environment)
+	}
 	r.Use(cors.Handler(cors.Options{
-		AllowedOrigins: []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:3002", "http://127.0.0.1:3000"},
-
+		AllowOriginFunc: func(_ *http.Request, origin string) bool {
+			return cfg.CORS.AllowOrigin(origin)
+		},
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-CSRF-Token"},
-		ExposedHeaders:   []string{"Link"},
+		AllowedHeaders:   []string{"Accept", "Authorization", "Content-Type", "X-API-Key", "X-CSRF-Token", "X-Request-ID", "traceparent"},
+		ExposedHeaders:   []string{"Link", "X-Total-Count", "Deprecation", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-Request-ID"},
 		AllowCredentials: true,
 		MaxAge:           300,
 	}))
@@ -55,17 +125,41 @@ func main() {
 	// Register all API Routes
 	handler.RegisterRoutes(r)
 
-	// Get port from environment or default
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "8001"
+	// Directly exposed, so slow clients must not hold connections open
+	// before sending their headers; bodies stay unbounded for large uploads
+	srv := &http.Server{
+		Addr:              ":" + strconv.Itoa(cfg.Server.Port),
+		Handler:           r,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	scheme := "http"
+	if cfg.TLS.Enabled() {
+		tlsCfg, err := tlsConfig(cfg.TLS)
+		if err != nil {
+			logger.Error("Invalid TLS configuration", "error", err)
+			os.Exit(1)
+		}
+		srv.TLSConfig = tlsCfg
+		scheme = "https"
 	}
 
-	log.Printf("🚀 Starting Go Backend on http://localhost:%s", port)
-	log.Printf("📡 CORS enabled for: http://localhost:3000")
-	log.Printf("📁 Upload directory: ./uploads")
+	logger.Info("Starting Go Backend",
+		"url", scheme+"://localhost"+srv.Addr,
+		"config_file", config.File(),
+		"environment", cfg.Server.Environment,
+		"cors_origins", cfg.CORS.Origins,
+		"upload_dir", cfg.Upload.Dir,
+		"data_dir", cfg.Data.Dir,
+		"log_level", logging.Level())
 
-	if err := http.ListenAndServe(":"+port, r); err != nil {
-		log.Fatalf("Server failed to start: %v", err)
+	if srv.TLSConfig != nil {
+		// The certificate comes from TLSConfig.GetCertificate
+		err = srv.ListenAndServeTLS("", "")
+	} else {
+		err = srv.ListenAndServe()
+	}
+	if err != nil {
+		logger.Error("Server failed to start", "error", err)
+		os.Exit(1)
 	}
 }
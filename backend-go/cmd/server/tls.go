@@ -0,0 +1,83 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"backend-go/internal/config"
+)
+
+// certReloader serves the certificate from its files, reloading it when
+// they change so a renewed certificate is picked up without a restart
+type certReloader struct {
+	certFile, keyFile string
+
+	mutex   sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time
+	checked time.Time
+}
+
+// certCheckInterval is how often the files are checked for changes
+const certCheckInterval = time.Minute
+
+func newCertReloader(certFile, keyFile string) (*certReloader, error) {
+	c := &certReloader{certFile: certFile, keyFile: keyFile}
+	if err := c.load(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+func (c *certReloader) load() error {
+	info, err := os.Stat(c.certFile)
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.cert, c.modTime = &cert, info.ModTime()
+	return nil
+}
+
+// GetCertificate returns the current certificate, for tls.Config
+func (c *certReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if time.Since(c.checked) > certCheckInterval {
+		c.checked = time.Now()
+		if info, err := os.Stat(c.certFile); err == nil && !info.ModTime().Equal(c.modTime) {
+			if err := c.load(); err != nil {
+				// Keep serving the old certificate until the new pair is complete
+				logger.Warn("Failed to reload TLS certificate", "cert_file", c.certFile, "error", err)
+			} else {
+				logger.Info("Reloaded TLS certificate", "cert_file", c.certFile)
+			}
+		}
+	}
+	return c.cert, nil
+}
+
+// tlsConfig builds the server's TLS configuration; net/http negotiates
+// HTTP/2 over it
+func tlsConfig(cfg config.TLSConfig) (*tls.Config, error) {
+	certs, err := newCertReloader(cfg.CertFile, cfg.KeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("loading TLS certificate: %w", err)
+	}
+	minVersion := uint16(tls.VersionTLS12)
+	if cfg.MinVersion == "1.3" {
+		minVersion = tls.VersionTLS13
+	}
+	return &tls.Config{
+		MinVersion:     minVersion,
+		GetCertificate: certs.GetCertificate,
+		NextProtos:     []string{"h2", "http/1.1"},
+	}, nil
+}
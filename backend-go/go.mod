@@ -7,4 +7,19 @@ require (
 	github.com/go-chi/cors v1.2.2
 )
 
-require github.com/lib/pq v1.10.9
+require (
+	github.com/lib/pq v1.10.9
+	github.com/parquet-go/parquet-go v0.25.1
+)
+
+require (
+	github.com/andybalholm/brotli v1.1.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/klauspost/compress v1.17.9 // indirect
+	github.com/pierrec/lz4/v4 v4.1.21 // indirect
+)
+
+require (
+	go.starlark.net v0.0.0-20231121155337-90ade8b19d09
+	golang.org/x/sys v0.21.0 // indirect
+)
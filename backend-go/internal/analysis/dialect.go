@@ -0,0 +1,338 @@
+package analysis
+
+import (
+	"backend-go/internal/dateformat"
+	"backend-go/internal/models"
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// SniffBytes is how much of a file the dialect sniffer inspects
+const SniffBytes = 64 * 1024
+
+// sniffMaxLines bounds the lines used for delimiter and header detection
+const sniffMaxLines = 100
+
+// delimiterCandidates are tried in order; earlier ones win ties
+var delimiterCandidates = []rune{',', ';', '\t', '|'}
+
+// RecordReader reads one CSV record at a time
+type RecordReader interface {
+	Read() ([]string, error)
+}
+
+// SniffFile detects the dialect of a CSV file from its first SniffBytes
+func SniffFile(filePath string) (models.CSVDialect, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return models.CSVDialect{}, err
+	}
+	defer file.Close()
+
+	sample := make([]byte, SniffBytes)
+	n, err := io.ReadFull(file, sample)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return models.CSVDialect{}, err
+	}
+	return SniffDialect(sample[:n], n == SniffBytes), nil
+}
+
+// SniffDialect detects delimiter, quote character and header presence from a
+// sample. When truncated is true the last (possibly partial) line is ignored.
+func SniffDialect(sample []byte, truncated bool) models.CSVDialect {
+	if truncated {
+		if i := bytes.LastIndexByte(sample, '\n'); i > 0 {
+			sample = sample[:i]
+		}
+	}
+	text := strings.ReplaceAll(string(sample), "\r\n", "\n")
+
+	quote := sniffQuote(text)
+	delimiter := sniffDelimiter(text, quote)
+
+	dialect := models.CSVDialect{
+		Delimiter: string(delimiter),
+		Quote:     string(quote),
+		HasHeader: true,
+		Source:    "sniffed",
+	}
+
+	reader := NewRecordReader(strings.NewReader(text), dialect)
+	records := [][]string{}
+	for len(records) < sniffMaxLines {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			continue
+		}
+		records = append(records, record)
+	}
+	dialect.HasHeader = sniffHeader(records)
+
+	return dialect
+}
+
+// ParseDelimiter accepts a delimiter character or a name (comma, semicolon, tab, pipe)
+func ParseDelimiter(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case ",", "comma":
+		return ",", nil
+	case ";", "semicolon":
+		return ";", nil
+	case "\t", "\\t", "tab":
+		return "\t", nil
+	case "|", "pipe":
+		return "|", nil
+	}
+	if r := []rune(value); len(r) == 1 && r[0] != '"' && r[0] != '\'' && r[0] != '\n' && r[0] != '\r' {
+		return value, nil
+	}
+	return "", fmt.Errorf("unsupported delimiter %q", value)
+}
+
+// ParseQuote accepts a double or single quote (or its name)
+func ParseQuote(value string) (string, error) {
+	switch strings.ToLower(value) {
+	case "\"", "double":
+		return "\"", nil
+	case "'", "single":
+		return "'", nil
+	}
+	return "", fmt.Errorf("unsupported quote character %q", value)
+}
+
+// NewRecordReader returns a reader for the dialect. Double-quoted files use
+// encoding/csv; other quote characters use a small compatible parser.
+func NewRecordReader(r io.Reader, dialect models.CSVDialect) RecordReader {
+	delimiter := ','
+	if d := []rune(dialect.Delimiter); len(d) == 1 {
+		delimiter = d[0]
+	}
+
+	if dialect.Quote == "" || dialect.Quote == "\"" {
+		reader := csv.NewReader(r)
+		reader.Comma = delimiter
+		reader.FieldsPerRecord = -1 // Allow variable fields
+		reader.LazyQuotes = true    // Allow bare quotes in non-quoted fields
+		reader.TrimLeadingSpace = true
+		return reader
+	}
+
+	return &quoteReader{
+		r:         bufio.NewReader(r),
+		delimiter: delimiter,
+		quote:     []rune(dialect.Quote)[0],
+	}
+}
+
+// sniffQuote picks the quote character that most often wraps a field
+func sniffQuote(text string) rune {
+	best, bestCount := '"', 0
+	for _, q := range []rune{'"', '\''} {
+		count := 0
+		for _, line := range firstLines(text, sniffMaxLines) {
+			fields := strings.FieldsFunc(line, func(r rune) bool {
+				return r == ',' || r == ';' || r == '\t' || r == '|'
+			})
+			for _, f := range fields {
+				f = strings.TrimSpace(f)
+				if len(f) >= 2 && rune(f[0]) == q && rune(f[len(f)-1]) == q {
+					count++
+				}
+			}
+		}
+		if count > bestCount {
+			best, bestCount = q, count
+		}
+	}
+	return best
+}
+
+// sniffDelimiter picks the candidate that splits lines into the most
+// consistent number of fields (more than one)
+func sniffDelimiter(text string, quote rune) rune {
+	lines := firstLines(text, sniffMaxLines)
+	if len(lines) == 0 {
+		return ','
+	}
+
+	best := ','
+	bestScore := 0.0
+	for _, d := range delimiterCandidates {
+		counts := make(map[int]int)
+		for _, line := range lines {
+			counts[countOutsideQuotes(line, d, quote)]++
+		}
+
+		// Most common per-line count (the mode) and how many lines share it
+		mode, modeLines := 0, 0
+		for c, n := range counts {
+			if n > modeLines || (n == modeLines && c > mode) {
+				mode, modeLines = c, n
+			}
+		}
+		if mode == 0 {
+			continue
+		}
+
+		// Consistency dominates; more fields break ties
+		score := float64(modeLines)/float64(len(lines)) + float64(mode)*1e-4
+		if score > bestScore {
+			best, bestScore = d, score
+		}
+	}
+	return best
+}
+
+// sniffHeader votes per column: where the body of a column is consistently
+// numeric or dates, a first row of a different kind looks like a header and
+// one of the same kind looks like data. All-text files can't be told apart
+// and are assumed to have headers.
+func sniffHeader(records [][]string) bool {
+	if len(records) < 2 {
+		return true
+	}
+	header := records[0]
+	body := records[1:]
+
+	votes := 0
+	for col, h := range header {
+		bodyType := ""
+		consistent := true
+		for _, row := range body {
+			if col >= len(row) || strings.TrimSpace(row[col]) == "" {
+				continue
+			}
+			t := valueKind(row[col])
+			if bodyType == "" {
+				bodyType = t
+			} else if bodyType != t {
+				consistent = false
+				break
+			}
+		}
+		if !consistent || bodyType == "" || bodyType == "text" || strings.TrimSpace(h) == "" {
+			continue
+		}
+
+		if valueKind(h) != bodyType {
+			votes++
+		} else {
+			votes--
+		}
+	}
+
+	return votes >= 0
+}
+
+// valueKind classifies a value as number, date or text
+func valueKind(v string) string {
+	v = strings.TrimSpace(v)
+	if _, err := strconv.ParseFloat(v, 64); err == nil {
+		return "number"
+	}
+	if dateformat.IsDate(v) {
+		return "date"
+	}
+	return "text"
+}
+
+// countOutsideQuotes counts delimiter occurrences that aren't inside quotes
+func countOutsideQuotes(line string, delimiter, quote rune) int {
+	count := 0
+	inQuotes := false
+	for _, r := range line {
+		switch {
+		case r == quote:
+			inQuotes = !inQuotes
+		case r == delimiter && !inQuotes:
+			count++
+		}
+	}
+	return count
+}
+
+// firstLines returns up to n non-empty lines
+func firstLines(text string, n int) []string {
+	lines := []string{}
+	for _, line := range strings.Split(text, "\n") {
+		if len(lines) >= n {
+			break
+		}
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
+
+// quoteReader parses delimited records quoted with a character other than
+// '"'. Quotes are escaped by doubling and quoted fields may span lines.
+type quoteReader struct {
+	r         *bufio.Reader
+	delimiter rune
+	quote     rune
+}
+
+func (q *quoteReader) Read() ([]string, error) {
+	var (
+		fields   []string
+		field    strings.Builder
+		inQuotes bool
+		started  bool
+	)
+
+	for {
+		r, _, err := q.r.ReadRune()
+		if err == io.EOF {
+			if !started {
+				return nil, io.EOF
+			}
+			return append(fields, strings.TrimSpace(field.String())), nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch {
+		case inQuotes && r == q.quote:
+			// Doubled quote is a literal quote; otherwise the quoted part ends
+			if next, _, err := q.r.ReadRune(); err == nil && next == q.quote {
+				field.WriteRune(q.quote)
+			} else {
+				if err == nil {
+					q.r.UnreadRune()
+				}
+				inQuotes = false
+			}
+		case inQuotes:
+			field.WriteRune(r)
+		case r == q.quote && strings.TrimSpace(field.String()) == "":
+			field.Reset()
+			inQuotes = true
+			started = true
+		case r == q.delimiter:
+			fields = append(fields, strings.TrimSpace(field.String()))
+			field.Reset()
+			started = true
+		case r == '\r':
+			// Handled with the following '\n'
+		case r == '\n':
+			if !started && field.Len() == 0 {
+				continue // Skip blank lines
+			}
+			return append(fields, strings.TrimSpace(field.String())), nil
+		default:
+			field.WriteRune(r)
+			started = true
+		}
+	}
+}
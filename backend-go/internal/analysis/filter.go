@@ -0,0 +1,218 @@
+package analysis
+
+import (
+	"backend-go/internal/dateformat"
+	"backend-go/internal/models"
+	"backend-go/internal/nulltoken"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter operators besides the query plan ones
+const (
+	OpIEquals    = "iequals"     // Trimmed, ignoring case
+	OpNotIEquals = "not_iequals" // Trimmed, ignoring case
+	OpIn         = "in"
+	OpNotIn      = "not_in"
+	OpIsNull     = "is_null"
+	OpNotNull    = "not_null"
+	OpStartsWith = "starts_with"
+	OpRegex      = "regex"
+	OpBetween    = "between" // Inclusive; numbers or dates
+	OpBefore     = "before"  // Dates
+	OpAfter      = "after"   // Dates
+)
+
+// Filter group logic
+const (
+	LogicAnd = "and"
+	LogicOr  = "or"
+)
+
+// filterMaxDepth bounds the nesting of filter groups
+const filterMaxDepth = 8
+
+// RowFilter reports whether a row passes a filter
+type RowFilter func(row []string) bool
+
+// CompileFilter validates a filter group against the headers and returns
+// its row test. equals, not_equals, in and not_in compare cells exactly;
+// the other text comparisons trim values and ignore case.
+func CompileFilter(group models.FilterGroup, headers []string) (RowFilter, error) {
+	index := make(map[string]int, len(headers))
+	for i, h := range headers {
+		index[h] = i
+	}
+	return compileGroup(group, index, 0)
+}
+
+func compileGroup(group models.FilterGroup, index map[string]int, depth int) (RowFilter, error) {
+	if depth > filterMaxDepth {
+		return nil, fmt.Errorf("filter groups are nested deeper than %d levels", filterMaxDepth)
+	}
+	logic := strings.ToLower(group.Logic)
+	if logic == "" {
+		logic = LogicAnd
+	}
+	if logic != LogicAnd && logic != LogicOr {
+		return nil, fmt.Errorf("unknown logic %q (use and or or)", group.Logic)
+	}
+
+	parts := make([]RowFilter, 0, len(group.Conditions)+len(group.Groups))
+	for _, c := range group.Conditions {
+		f, err := compileCondition(c, index)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, f)
+	}
+	for _, g := range group.Groups {
+		f, err := compileGroup(g, index, depth+1)
+		if err != nil {
+			return nil, err
+		}
+		parts = append(parts, f)
+	}
+
+	// An empty group matches every row
+	if len(parts) == 0 {
+		return func([]string) bool { return true }, nil
+	}
+	if logic == LogicOr {
+		return func(row []string) bool {
+			for _, f := range parts {
+				if f(row) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	}
+	return func(row []string) bool {
+		for _, f := range parts {
+			if !f(row) {
+				return false
+			}
+		}
+		return true
+	}, nil
+}
+
+// compileCondition builds the test of one condition
+func compileCondition(c models.FilterCondition, index map[string]int) (RowFilter, error) {
+	col, ok := index[c.Column]
+	if !ok {
+		return nil, fmt.Errorf("filter column %q not found", c.Column)
+	}
+	cell := func(row []string) string {
+		if col < len(row) {
+			return strings.TrimSpace(row[col])
+		}
+		return ""
+	}
+	raw := func(row []string) string {
+		if col < len(row) {
+			return row[col]
+		}
+		return ""
+	}
+	target := strings.TrimSpace(c.Value)
+	lower := strings.ToLower(target)
+
+	switch c.Operator {
+	case OpEquals:
+		return func(row []string) bool { return raw(row) == c.Value }, nil
+	case OpNotEquals:
+		return func(row []string) bool { return raw(row) != c.Value }, nil
+	case OpIEquals:
+		return func(row []string) bool { return strings.EqualFold(cell(row), target) }, nil
+	case OpNotIEquals:
+		return func(row []string) bool { return !strings.EqualFold(cell(row), target) }, nil
+	case OpContains:
+		return func(row []string) bool { return strings.Contains(strings.ToLower(cell(row)), lower) }, nil
+	case OpStartsWith:
+		return func(row []string) bool { return strings.HasPrefix(strings.ToLower(cell(row)), lower) }, nil
+
+	case OpIn, OpNotIn:
+		if len(c.Values) == 0 {
+			return nil, fmt.Errorf("operator %s needs values", c.Operator)
+		}
+		set := make(map[string]bool, len(c.Values))
+		for _, v := range c.Values {
+			set[v] = true
+		}
+		want := c.Operator == OpIn
+		return func(row []string) bool { return set[raw(row)] == want }, nil
+
+	case OpIsNull, OpNotNull:
+		isNull := nulltoken.ForColumn(c.Column)
+		want := c.Operator == OpIsNull
+		return func(row []string) bool { return isNull(cell(row)) == want }, nil
+
+	case OpRegex:
+		re, err := regexp.Compile(c.Value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regex %q: %v", c.Value, err)
+		}
+		return func(row []string) bool { return re.MatchString(cell(row)) }, nil
+
+	case OpGreaterThan, OpLessThan:
+		t, err := strconv.ParseFloat(target, 64)
+		if err != nil {
+			return nil, fmt.Errorf("operator %s needs a number, got %q", c.Operator, c.Value)
+		}
+		greater := c.Operator == OpGreaterThan
+		return func(row []string) bool {
+			v, err := strconv.ParseFloat(cell(row), 64)
+			return err == nil && (greater && v > t || !greater && v < t)
+		}, nil
+
+	case OpBefore, OpAfter:
+		t, _, ok := dateformat.Parse(target)
+		if !ok {
+			return nil, fmt.Errorf("operator %s needs a date, got %q", c.Operator, c.Value)
+		}
+		after := c.Operator == OpAfter
+		return func(row []string) bool {
+			v, _, ok := dateformat.Parse(cell(row))
+			return ok && (after && v.After(t) || !after && v.Before(t))
+		}, nil
+
+	case OpBetween:
+		if len(c.Values) != 2 {
+			return nil, fmt.Errorf("operator between needs values [low, high]")
+		}
+		low, high := strings.TrimSpace(c.Values[0]), strings.TrimSpace(c.Values[1])
+		lo, err1 := strconv.ParseFloat(low, 64)
+		hi, err2 := strconv.ParseFloat(high, 64)
+		if err1 == nil && err2 == nil {
+			return func(row []string) bool {
+				v, err := strconv.ParseFloat(cell(row), 64)
+				return err == nil && v >= lo && v <= hi
+			}, nil
+		}
+		from, _, ok1 := dateformat.Parse(low)
+		to, _, ok2 := dateformat.Parse(high)
+		if !ok1 || !ok2 {
+			return nil, fmt.Errorf("operator between needs two numbers or two dates")
+		}
+		to = endOfDay(to, high)
+		return func(row []string) bool {
+			v, _, ok := dateformat.Parse(cell(row))
+			return ok && !v.Before(from) && !v.After(to)
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown filter operator %q", c.Operator)
+}
+
+// endOfDay extends a date-only upper bound to the end of its day, so
+// "between 2024-01-01 and 2024-01-31" includes times on the 31st
+func endOfDay(t time.Time, raw string) time.Time {
+	if t.Hour() == 0 && t.Minute() == 0 && t.Second() == 0 && t.Nanosecond() == 0 && !strings.Contains(raw, ":") {
+		return t.Add(24*time.Hour - time.Nanosecond)
+	}
+	return t
+}
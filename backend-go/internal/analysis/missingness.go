@@ -0,0 +1,203 @@
+package analysis
+
+import (
+	"backend-go/internal/nulltoken"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Missingness analysis limits
+const (
+	coMissingMinPhi     = 0.5 // Correlation of null indicators reported as "null together"
+	missingPatternsMax  = 10  // Row patterns reported
+	missingSegmentsMax  = 20  // Most frequent segment values reported
+	segmentRateDeviance = 0.2 // Segment null rate above overall that counts as systematic
+)
+
+// ColumnMissingness is the null count of one column
+type ColumnMissingness struct {
+	Column    string  `json:"column"`
+	NullCount int     `json:"null_count"`
+	NullRate  float64 `json:"null_rate"`
+}
+
+// CoMissing is a pair of columns that tend to be null in the same rows
+type CoMissing struct {
+	Column1 string  `json:"column1"`
+	Column2 string  `json:"column2"`
+	Both    int     `json:"both"`    // Rows where both are null
+	Jaccard float64 `json:"jaccard"` // Overlap of the two columns' null rows
+	Phi     float64 `json:"phi"`     // Correlation of the null indicators
+}
+
+// MissingPattern is a set of columns null together in a number of rows
+type MissingPattern struct {
+	Columns []string `json:"columns"`
+	Rows    int      `json:"rows"`
+	Share   float64  `json:"share"`
+}
+
+// SegmentMissingness is the null rate of each incomplete column within one
+// value of a segment column
+type SegmentMissingness struct {
+	Segment    string             `json:"segment"`
+	Rows       int                `json:"rows"`
+	NullRates  map[string]float64 `json:"null_rates"`
+	Systematic []string           `json:"systematic"` // Columns far more often null in this segment
+}
+
+// Missingness describes how missing values are distributed over a table
+type Missingness struct {
+	Rows         int                  `json:"rows"`
+	CompleteRows int                  `json:"complete_rows"`
+	Columns      []ColumnMissingness  `json:"columns"`
+	CoMissing    []CoMissing          `json:"co_missing"`
+	Patterns     []MissingPattern     `json:"patterns"` // Most common sets of null columns in incomplete rows
+	Segment      string               `json:"segment,omitempty"`
+	Segments     []SegmentMissingness `json:"segments,omitempty"`
+}
+
+// AnalyzeMissingness finds columns null together, recurring row patterns of
+// missing values and, when segmentCol >= 0, null rates per segment value
+func AnalyzeMissingness(headers []string, rows [][]string, segmentCol int) Missingness {
+	m := Missingness{
+		Rows:      len(rows),
+		Columns:   make([]ColumnMissingness, len(headers)),
+		CoMissing: []CoMissing{},
+		Patterns:  []MissingPattern{},
+	}
+	nulls := make([][]bool, len(rows))
+	counts := make([]int, len(headers))
+	both := make(map[[2]int]int)
+	patterns := make(map[string]int)
+	isNull := make([]func(string) bool, len(headers))
+	for c, h := range headers {
+		isNull[c] = nulltoken.ForColumn(h)
+	}
+	for r, row := range rows {
+		nulls[r] = make([]bool, len(headers))
+		nullCols := []int{}
+		for c := range headers {
+			if c >= len(row) || isNull[c](row[c]) {
+				nulls[r][c] = true
+				counts[c]++
+				nullCols = append(nullCols, c)
+			}
+		}
+		if len(nullCols) == 0 {
+			m.CompleteRows++
+			continue
+		}
+		key := make([]string, len(nullCols))
+		for i, a := range nullCols {
+			key[i] = headers[a]
+			for _, b := range nullCols[i+1:] {
+				both[[2]int{a, b}]++
+			}
+		}
+		patterns[strings.Join(key, "\x1f")]++
+	}
+	for c, h := range headers {
+		m.Columns[c] = ColumnMissingness{Column: h, NullCount: counts[c]}
+		if len(rows) > 0 {
+			m.Columns[c].NullRate = float64(counts[c]) / float64(len(rows))
+		}
+	}
+
+	// Pairs of incomplete columns whose null indicators correlate
+	n := float64(len(rows))
+	for pair, together := range both {
+		a, b := pair[0], pair[1]
+		if counts[a] == len(rows) || counts[b] == len(rows) {
+			continue
+		}
+		na, nb := float64(counts[a]), float64(counts[b])
+		phi := (n*float64(together) - na*nb) / math.Sqrt(na*nb*(n-na)*(n-nb))
+		if phi < coMissingMinPhi {
+			continue
+		}
+		m.CoMissing = append(m.CoMissing, CoMissing{
+			Column1: headers[a],
+			Column2: headers[b],
+			Both:    together,
+			Jaccard: float64(together) / (na + nb - float64(together)),
+			Phi:     phi,
+		})
+	}
+	sort.Slice(m.CoMissing, func(i, j int) bool {
+		if m.CoMissing[i].Phi != m.CoMissing[j].Phi {
+			return m.CoMissing[i].Phi > m.CoMissing[j].Phi
+		}
+		return m.CoMissing[i].Column1+m.CoMissing[i].Column2 < m.CoMissing[j].Column1+m.CoMissing[j].Column2
+	})
+
+	for key, count := range patterns {
+		m.Patterns = append(m.Patterns, MissingPattern{Columns: strings.Split(key, "\x1f"), Rows: count, Share: float64(count) / n})
+	}
+	sort.Slice(m.Patterns, func(i, j int) bool {
+		if m.Patterns[i].Rows != m.Patterns[j].Rows {
+			return m.Patterns[i].Rows > m.Patterns[j].Rows
+		}
+		return strings.Join(m.Patterns[i].Columns, ",") < strings.Join(m.Patterns[j].Columns, ",")
+	})
+	if len(m.Patterns) > missingPatternsMax {
+		m.Patterns = m.Patterns[:missingPatternsMax]
+	}
+
+	if segmentCol >= 0 && segmentCol < len(headers) {
+		m.Segment = headers[segmentCol]
+		m.Segments = segmentMissingness(headers, rows, nulls, m.Columns, segmentCol)
+	}
+	return m
+}
+
+// segmentMissingness computes the null rates of incomplete columns within the
+// most frequent values of the segment column
+func segmentMissingness(headers []string, rows [][]string, nulls [][]bool, columns []ColumnMissingness, segmentCol int) []SegmentMissingness {
+	groups := make(map[string][]int)
+	isNull := nulltoken.ForColumn(headers[segmentCol])
+	for r, row := range rows {
+		value := ""
+		if segmentCol < len(row) && !isNull(row[segmentCol]) {
+			value = strings.TrimSpace(row[segmentCol])
+		}
+		groups[value] = append(groups[value], r)
+	}
+	values := make([]string, 0, len(groups))
+	for v := range groups {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if len(groups[values[i]]) != len(groups[values[j]]) {
+			return len(groups[values[i]]) > len(groups[values[j]])
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > missingSegmentsMax {
+		values = values[:missingSegmentsMax]
+	}
+
+	segments := make([]SegmentMissingness, 0, len(values))
+	for _, v := range values {
+		s := SegmentMissingness{Segment: v, Rows: len(groups[v]), NullRates: map[string]float64{}, Systematic: []string{}}
+		for c, h := range headers {
+			if c == segmentCol || columns[c].NullCount == 0 {
+				continue
+			}
+			null := 0
+			for _, r := range groups[v] {
+				if nulls[r][c] {
+					null++
+				}
+			}
+			rate := float64(null) / float64(len(groups[v]))
+			s.NullRates[h] = rate
+			if rate-columns[c].NullRate >= segmentRateDeviance {
+				s.Systematic = append(s.Systematic, h)
+			}
+		}
+		segments = append(segments, s)
+	}
+	return segments
+}
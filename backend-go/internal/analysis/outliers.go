@@ -0,0 +1,254 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sort"
+)
+
+// Outlier detection methods
+const (
+	OutlierIQR       = "iqr"       // Outside the Tukey fences Q1 - k*IQR, Q3 + k*IQR
+	OutlierZScore    = "zscore"    // More than z standard deviations from the mean
+	OutlierIsolation = "isolation" // Isolation forest anomaly score (1-D, lite)
+)
+
+// OutlierOptions configures DetectOutliers; zero values take the defaults
+type OutlierOptions struct {
+	Methods        []string // Defaults to iqr and zscore
+	IQRMultiplier  float64  // Default 1.5
+	ZThreshold     float64  // Default 3
+	IsolationScore float64  // Anomaly score above which a value is flagged, default 0.65
+	Trees          int      // Isolation trees, default 100
+}
+
+// Outlier is a flagged value of a column
+type Outlier struct {
+	Row     int      `json:"row"` // Data row index
+	Value   float64  `json:"value"`
+	ZScore  float64  `json:"z_score"`
+	Score   float64  `json:"isolation_score,omitempty"`
+	Methods []string `json:"methods"` // Methods that flagged the value
+}
+
+// ColumnOutliers summarizes the outliers of one numeric column
+type ColumnOutliers struct {
+	Column      string    `json:"column"`
+	Count       int       `json:"count"` // Numeric values examined
+	Mean        float64   `json:"mean"`
+	StdDev      float64   `json:"std_dev"`
+	Q1          float64   `json:"q1"`
+	Q3          float64   `json:"q3"`
+	LowerFence  float64   `json:"lower_fence"`
+	UpperFence  float64   `json:"upper_fence"`
+	OutlierRate float64   `json:"outlier_rate"`
+	Outliers    []Outlier `json:"outliers"`
+}
+
+// DetectOutliers flags outlying values of a numeric column. rows holds the
+// data row index of each value.
+func DetectOutliers(column string, values []float64, rows []int, opts OutlierOptions) (ColumnOutliers, error) {
+	result := ColumnOutliers{Column: column, Count: len(values), Outliers: []Outlier{}}
+	if len(values) == 0 {
+		return result, fmt.Errorf("no numeric values")
+	}
+	if len(rows) != len(values) {
+		return result, fmt.Errorf("got %d row indices for %d values", len(rows), len(values))
+	}
+	if len(opts.Methods) == 0 {
+		opts.Methods = []string{OutlierIQR, OutlierZScore}
+	}
+	if opts.IQRMultiplier <= 0 {
+		opts.IQRMultiplier = 1.5
+	}
+	if opts.ZThreshold <= 0 {
+		opts.ZThreshold = 3
+	}
+	if opts.IsolationScore <= 0 {
+		opts.IsolationScore = 0.65
+	}
+	if opts.Trees <= 0 {
+		opts.Trees = 100
+	}
+
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	result.Q1, result.Q3 = Quantile(sorted, 0.25), Quantile(sorted, 0.75)
+	result.LowerFence, result.UpperFence = IQRFences(sorted, opts.IQRMultiplier)
+	for _, v := range values {
+		result.Mean += v
+	}
+	result.Mean /= float64(len(values))
+	for _, v := range values {
+		result.StdDev += (v - result.Mean) * (v - result.Mean)
+	}
+	result.StdDev = math.Sqrt(result.StdDev / float64(len(values)))
+
+	var scores []float64
+	for _, m := range opts.Methods {
+		switch m {
+		case OutlierIQR, OutlierZScore:
+		case OutlierIsolation:
+			scores = isolationScores(values, opts.Trees)
+		default:
+			return result, fmt.Errorf("unknown outlier method %q", m)
+		}
+	}
+
+	for i, v := range values {
+		o := Outlier{Row: rows[i], Value: v, Methods: []string{}}
+		if result.StdDev > 0 {
+			o.ZScore = (v - result.Mean) / result.StdDev
+		}
+		if scores != nil {
+			o.Score = scores[i]
+		}
+		for _, m := range opts.Methods {
+			flagged := false
+			switch m {
+			case OutlierIQR:
+				flagged = v < result.LowerFence || v > result.UpperFence
+			case OutlierZScore:
+				flagged = math.Abs(o.ZScore) > opts.ZThreshold
+			case OutlierIsolation:
+				flagged = o.Score > opts.IsolationScore
+			}
+			if flagged {
+				o.Methods = append(o.Methods, m)
+			}
+		}
+		if len(o.Methods) > 0 {
+			result.Outliers = append(result.Outliers, o)
+		}
+	}
+	result.OutlierRate = float64(len(result.Outliers)) / float64(len(values))
+
+	// Most extreme first
+	sort.SliceStable(result.Outliers, func(i, j int) bool {
+		return math.Abs(result.Outliers[i].ZScore) > math.Abs(result.Outliers[j].ZScore)
+	})
+	return result, nil
+}
+
+// Quantile returns the q-th quantile (0-1) of sorted values by linear interpolation
+func Quantile(sorted []float64, q float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := q * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	if lo >= len(sorted)-1 {
+		return sorted[len(sorted)-1]
+	}
+	frac := pos - float64(lo)
+	if diff := sorted[lo+1] - sorted[lo]; !math.IsInf(diff, 0) {
+		return sorted[lo] + frac*diff
+	}
+	return sorted[lo]*(1-frac) + sorted[lo+1]*frac // The difference overflows
+}
+
+// IQRFences returns the Tukey fences of sorted values
+func IQRFences(sorted []float64, k float64) (lower, upper float64) {
+	q1, q3 := Quantile(sorted, 0.25), Quantile(sorted, 0.75)
+	iqr := q3 - q1
+	return q1 - k*iqr, q3 + k*iqr
+}
+
+// isolationSampleSize is the subsample each isolation tree is grown on
+const isolationSampleSize = 256
+
+// isolationScores computes the isolation forest anomaly score (0-1, higher
+// is more anomalous) of every value
+func isolationScores(values []float64, trees int) []float64 {
+	rng := rand.New(rand.NewSource(1)) // Deterministic scores for the same data
+	sampleSize := isolationSampleSize
+	if len(values) < sampleSize {
+		sampleSize = len(values)
+	}
+	heightLimit := int(math.Ceil(math.Log2(float64(sampleSize))))
+
+	forest := make([]*isolationNode, trees)
+	for t := range forest {
+		sample := make([]float64, sampleSize)
+		for i := range sample {
+			sample[i] = values[rng.Intn(len(values))]
+		}
+		forest[t] = growIsolationTree(sample, 0, heightLimit, rng)
+	}
+
+	norm := averagePathLength(sampleSize)
+	scores := make([]float64, len(values))
+	if norm == 0 {
+		return scores
+	}
+	for i, v := range values {
+		total := 0.0
+		for _, tree := range forest {
+			total += tree.pathLength(v)
+		}
+		scores[i] = math.Pow(2, -total/float64(trees)/norm)
+	}
+	return scores
+}
+
+// isolationNode is a node of a one-dimensional isolation tree; leaves have
+// no children and remember how many sample values reached them
+type isolationNode struct {
+	split       float64
+	left, right *isolationNode
+	size        int
+}
+
+// growIsolationTree splits sample at random points between its bounds until
+// values are isolated or the height limit is reached
+func growIsolationTree(sample []float64, depth, heightLimit int, rng *rand.Rand) *isolationNode {
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, s := range sample {
+		lo, hi = math.Min(lo, s), math.Max(hi, s)
+	}
+	if depth >= heightLimit || len(sample) <= 1 || hi <= lo {
+		return &isolationNode{size: len(sample)}
+	}
+	split := lo + rng.Float64()*(hi-lo)
+	left, right := []float64{}, []float64{}
+	for _, s := range sample {
+		if s < split {
+			left = append(left, s)
+		} else {
+			right = append(right, s)
+		}
+	}
+	return &isolationNode{
+		split: split,
+		left:  growIsolationTree(left, depth+1, heightLimit, rng),
+		right: growIsolationTree(right, depth+1, heightLimit, rng),
+	}
+}
+
+// pathLength is the depth at which v lands, plus the expected remaining depth
+// of the unsplit values in its leaf
+func (n *isolationNode) pathLength(v float64) float64 {
+	depth := 0.0
+	for n.left != nil {
+		if v < n.split {
+			n = n.left
+		} else {
+			n = n.right
+		}
+		depth++
+	}
+	return depth + averagePathLength(n.size)
+}
+
+// averagePathLength is the mean path length of an unsuccessful binary search
+// tree lookup among n values, c(n) in the isolation forest paper
+func averagePathLength(n int) float64 {
+	if n <= 1 {
+		return 0
+	}
+	if n == 2 {
+		return 1
+	}
+	return 2*(math.Log(float64(n-1))+0.5772156649) - 2*float64(n-1)/float64(n)
+}
@@ -0,0 +1,293 @@
+package analysis
+
+import (
+	"backend-go/internal/nulltoken"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Pivot aggregations
+const (
+	AggCount    = "count"
+	AggDistinct = "distinct"
+	AggSum      = "sum"
+	AggMean     = "mean"
+	AggMin      = "min"
+	AggMax      = "max"
+)
+
+// Pivot size limits; the most frequent keys are kept
+const (
+	PivotMaxRowKeys    = 500
+	PivotMaxColumnKeys = 50
+	pivotMaxFields     = 3 // Fields per axis
+)
+
+// PivotSpec describes a pivot over a table
+type PivotSpec struct {
+	Rows        []string `json:"rows"`
+	Columns     []string `json:"columns"`
+	Value       string   `json:"value"`       // Required except for count
+	Aggregation string   `json:"aggregation"` // Defaults to count
+}
+
+// PivotTable is a cross-tab. Cells[i][j] aggregates RowKeys[i] x ColumnKeys[j];
+// nil cells have no rows. Totals cover every row, including truncated keys.
+type PivotTable struct {
+	Spec            PivotSpec    `json:"spec"`
+	RowKeys         [][]string   `json:"row_keys"`
+	ColumnKeys      [][]string   `json:"column_keys"`
+	Cells           [][]*float64 `json:"cells"`
+	RowTotals       []*float64   `json:"row_totals"`
+	ColumnTotals    []*float64   `json:"column_totals"`
+	GrandTotal      *float64     `json:"grand_total"`
+	TotalRowKeys    int          `json:"total_row_keys"`
+	TotalColumnKeys int          `json:"total_column_keys"`
+	Truncated       bool         `json:"truncated"`
+	SkippedValues   int          `json:"skipped_values"` // Non-numeric values left out of sum, mean, min and max
+	OverflowCells   int          `json:"overflow_cells"` // Cells and totals left null as their sum overflows
+}
+
+// pivotAcc accumulates one cell
+type pivotAcc struct {
+	count    int
+	sum      float64
+	min, max float64
+	distinct map[string]bool
+}
+
+func (a *pivotAcc) add(raw string, v float64, numeric bool) {
+	if a.count == 0 {
+		a.min, a.max = math.Inf(1), math.Inf(-1)
+	}
+	a.count++
+	if a.distinct != nil {
+		a.distinct[raw] = true
+	}
+	if numeric {
+		a.sum += v
+		a.min = math.Min(a.min, v)
+		a.max = math.Max(a.max, v)
+	}
+}
+
+func (a *pivotAcc) result(agg string) *float64 {
+	if a == nil || a.count == 0 {
+		return nil
+	}
+	var v float64
+	switch agg {
+	case AggCount:
+		v = float64(a.count)
+	case AggDistinct:
+		v = float64(len(a.distinct))
+	case AggSum:
+		v = a.sum
+	case AggMean:
+		v = a.sum / float64(a.count)
+	case AggMin:
+		v = a.min
+	case AggMax:
+		v = a.max
+	}
+	return &v
+}
+
+// Pivot aggregates rows into a cross-tab of the row and column fields.
+// Rows with a null value are left out of the aggregation; numeric
+// aggregations also skip values that aren't finite numbers.
+func Pivot(headers []string, rows [][]string, spec PivotSpec) (*PivotTable, error) {
+	if spec.Aggregation == "" {
+		spec.Aggregation = AggCount
+	}
+	numericAgg := false
+	switch spec.Aggregation {
+	case AggCount, AggDistinct:
+	case AggSum, AggMean, AggMin, AggMax:
+		numericAgg = true
+	default:
+		return nil, fmt.Errorf("unknown aggregation %q", spec.Aggregation)
+	}
+	if len(spec.Rows) == 0 && len(spec.Columns) == 0 {
+		return nil, fmt.Errorf("at least one row or column field is required")
+	}
+	if len(spec.Rows) > pivotMaxFields || len(spec.Columns) > pivotMaxFields {
+		return nil, fmt.Errorf("at most %d fields per axis", pivotMaxFields)
+	}
+
+	index := func(name string) (int, error) {
+		for i, h := range headers {
+			if h == name {
+				return i, nil
+			}
+		}
+		return -1, fmt.Errorf("column %q not found", name)
+	}
+	fields := func(names []string) ([]int, error) {
+		idx := make([]int, len(names))
+		for i, n := range names {
+			var err error
+			if idx[i], err = index(n); err != nil {
+				return nil, err
+			}
+		}
+		return idx, nil
+	}
+	rowIdx, err := fields(spec.Rows)
+	if err != nil {
+		return nil, err
+	}
+	colIdx, err := fields(spec.Columns)
+	if err != nil {
+		return nil, err
+	}
+	valueIdx := -1
+	if spec.Value != "" {
+		if valueIdx, err = index(spec.Value); err != nil {
+			return nil, err
+		}
+	} else if spec.Aggregation != AggCount {
+		return nil, fmt.Errorf("aggregation %q needs a value column", spec.Aggregation)
+	}
+
+	table := &PivotTable{Spec: spec}
+	newAcc := func() *pivotAcc {
+		a := &pivotAcc{}
+		if spec.Aggregation == AggDistinct {
+			a.distinct = map[string]bool{}
+		}
+		return a
+	}
+	cells := make(map[[2]string]*pivotAcc)
+	rowAccs := make(map[string]*pivotAcc)
+	colAccs := make(map[string]*pivotAcc)
+	grand := newAcc()
+	rowFreq := make(map[string]int)
+	colFreq := make(map[string]int)
+	var valueNull func(string) bool
+	if valueIdx >= 0 {
+		valueNull = nulltoken.ForColumn(spec.Value)
+	}
+
+	for _, row := range rows {
+		rk, ck := pivotKey(row, rowIdx), pivotKey(row, colIdx)
+		rowFreq[rk]++
+		colFreq[ck]++
+
+		raw, v, numeric := "", 0.0, false
+		if valueIdx >= 0 {
+			if valueIdx >= len(row) || valueNull(row[valueIdx]) {
+				continue
+			}
+			raw = strings.TrimSpace(row[valueIdx])
+			if f, err := strconv.ParseFloat(raw, 64); err == nil && !math.IsNaN(f) && !math.IsInf(f, 0) {
+				v, numeric = f, true
+			} else if numericAgg {
+				table.SkippedValues++
+				continue
+			}
+		}
+
+		for _, acc := range []struct {
+			m   map[string]*pivotAcc
+			key string
+		}{{rowAccs, rk}, {colAccs, ck}} {
+			if acc.m[acc.key] == nil {
+				acc.m[acc.key] = newAcc()
+			}
+			acc.m[acc.key].add(raw, v, numeric)
+		}
+		cell := [2]string{rk, ck}
+		if cells[cell] == nil {
+			cells[cell] = newAcc()
+		}
+		cells[cell].add(raw, v, numeric)
+		grand.add(raw, v, numeric)
+	}
+
+	// JSON has no infinity; a sum that overflows leaves its cell null
+	result := func(a *pivotAcc) *float64 {
+		v := a.result(spec.Aggregation)
+		if v != nil && math.IsInf(*v, 0) {
+			table.OverflowCells++
+			return nil
+		}
+		return v
+	}
+
+	rowKeys := topPivotKeys(rowFreq, PivotMaxRowKeys)
+	colKeys := topPivotKeys(colFreq, PivotMaxColumnKeys)
+	table.TotalRowKeys, table.TotalColumnKeys = len(rowFreq), len(colFreq)
+	table.Truncated = len(rowKeys) < len(rowFreq) || len(colKeys) < len(colFreq)
+
+	table.RowKeys = make([][]string, len(rowKeys))
+	table.Cells = make([][]*float64, len(rowKeys))
+	table.RowTotals = make([]*float64, len(rowKeys))
+	for i, rk := range rowKeys {
+		table.RowKeys[i] = splitPivotKey(rk, len(rowIdx))
+		table.Cells[i] = make([]*float64, len(colKeys))
+		for j, ck := range colKeys {
+			table.Cells[i][j] = result(cells[[2]string{rk, ck}])
+		}
+		table.RowTotals[i] = result(rowAccs[rk])
+	}
+	table.ColumnKeys = make([][]string, len(colKeys))
+	table.ColumnTotals = make([]*float64, len(colKeys))
+	for j, ck := range colKeys {
+		table.ColumnKeys[j] = splitPivotKey(ck, len(colIdx))
+		table.ColumnTotals[j] = result(colAccs[ck])
+	}
+	table.GrandTotal = result(grand)
+	return table, nil
+}
+
+// pivotKey joins the trimmed field values of a row
+func pivotKey(row []string, idx []int) string {
+	parts := make([]string, len(idx))
+	for i, c := range idx {
+		if c < len(row) {
+			parts[i] = strings.TrimSpace(row[c])
+		}
+	}
+	return strings.Join(parts, "\x1f")
+}
+
+func splitPivotKey(key string, fields int) []string {
+	if fields == 0 {
+		return []string{}
+	}
+	return strings.Split(key, "\x1f")
+}
+
+// topPivotKeys keeps the limit most frequent keys, returned in natural order
+// (numbers first, in numeric order)
+func topPivotKeys(freq map[string]int, limit int) []string {
+	keys := make([]string, 0, len(freq))
+	for k := range freq {
+		keys = append(keys, k)
+	}
+	if len(keys) > limit {
+		sort.Slice(keys, func(i, j int) bool {
+			if freq[keys[i]] != freq[keys[j]] {
+				return freq[keys[i]] > freq[keys[j]]
+			}
+			return keys[i] < keys[j]
+		})
+		keys = keys[:limit]
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		fi, err1 := strconv.ParseFloat(keys[i], 64)
+		fj, err2 := strconv.ParseFloat(keys[j], 64)
+		switch {
+		case err1 == nil && err2 == nil && fi != fj:
+			return fi < fj
+		case (err1 == nil) != (err2 == nil):
+			return err1 == nil // Numbers first
+		}
+		return keys[i] < keys[j]
+	})
+	return keys
+}
@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+	"strconv"
+	"testing"
+)
+
+func TestPivotNonFinite(t *testing.T) {
+	huge := strconv.FormatFloat(math.MaxFloat64, 'g', -1, 64)
+	rows := [][]string{
+		{"a", "1"}, {"a", "NaN"}, {"a", "Inf"}, {"a", "2"},
+		{"b", huge}, {"b", huge},
+		{"c", "-Infinity"},
+	}
+	table, err := Pivot([]string{"key", "amount"}, rows, PivotSpec{Rows: []string{"key"}, Value: "amount", Aggregation: AggSum})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := json.Marshal(table); err != nil {
+		t.Fatalf("table doesn't encode: %v", err)
+	}
+	if table.SkippedValues != 3 {
+		t.Errorf("skipped %d values, want 3", table.SkippedValues)
+	}
+	sums := map[string]*float64{}
+	for i, key := range table.RowKeys {
+		sums[key[0]] = table.RowTotals[i]
+	}
+	if sums["a"] == nil || *sums["a"] != 3 {
+		t.Errorf("sum of a: got %v, want 3", sums["a"])
+	}
+	if sums["b"] != nil || table.GrandTotal != nil || table.OverflowCells == 0 {
+		t.Errorf("overflowing sums: got b %v, grand total %v and %d overflow cells", sums["b"], table.GrandTotal, table.OverflowCells)
+	}
+}
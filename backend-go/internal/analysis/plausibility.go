@@ -0,0 +1,171 @@
+package analysis
+
+import (
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Plausibility checks
+const (
+	CheckBenford = "benford" // First-digit distribution of amounts
+	CheckLuhn    = "luhn"    // Check digit of card-like numbers
+	CheckIBAN    = "iban"    // ISO 13616 check digits
+)
+
+// Benford conformity levels of the first-digit mean absolute deviation (Nigrini)
+const (
+	BenfordClose         = "close"
+	BenfordAcceptable    = "acceptable"
+	BenfordMarginal      = "marginal"
+	BenfordNonconforming = "nonconforming"
+)
+
+// BenfordMinValues is the number of values a Benford test needs to mean anything
+const BenfordMinValues = 100
+
+// BenfordResult compares the first digits of values with Benford's law
+type BenfordResult struct {
+	Values     int        `json:"values"`
+	Observed   [9]float64 `json:"observed"` // Share of first digits 1-9
+	Expected   [9]float64 `json:"expected"`
+	MAD        float64    `json:"mad"` // Mean absolute deviation of the shares
+	Conformity string     `json:"conformity"`
+}
+
+// BenfordTest tests the first digits of the non-zero values. ok is false
+// with fewer than BenfordMinValues such values.
+func BenfordTest(values []float64) (BenfordResult, bool) {
+	var r BenfordResult
+	counts := [9]int{}
+	for _, v := range values {
+		if d := firstDigit(v); d > 0 {
+			counts[d-1]++
+			r.Values++
+		}
+	}
+	if r.Values < BenfordMinValues {
+		return r, false
+	}
+	for d := 1; d <= 9; d++ {
+		r.Expected[d-1] = math.Log10(1 + 1/float64(d))
+		r.Observed[d-1] = float64(counts[d-1]) / float64(r.Values)
+		r.MAD += math.Abs(r.Observed[d-1] - r.Expected[d-1])
+	}
+	r.MAD /= 9
+	switch {
+	case r.MAD < 0.006:
+		r.Conformity = BenfordClose
+	case r.MAD < 0.012:
+		r.Conformity = BenfordAcceptable
+	case r.MAD < 0.015:
+		r.Conformity = BenfordMarginal
+	default:
+		r.Conformity = BenfordNonconforming
+	}
+	return r, true
+}
+
+// firstDigit returns the leading significant digit of v, 0 for zero
+func firstDigit(v float64) int {
+	v = math.Abs(v)
+	if v == 0 || math.IsInf(v, 0) || math.IsNaN(v) {
+		return 0
+	}
+	d := int(v / math.Pow(10, math.Floor(math.Log10(v))))
+	if d < 1 {
+		return 1
+	}
+	if d > 9 {
+		return 9
+	}
+	return d
+}
+
+// CompactDigits strips spaces and dashes; ok is false when anything other
+// than digits remains
+func CompactDigits(s string) (string, bool) {
+	s = strings.NewReplacer(" ", "", "-", "").Replace(strings.TrimSpace(s))
+	if s == "" {
+		return "", false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return "", false
+		}
+	}
+	return s, true
+}
+
+// IsCardLike reports whether s is 13-19 digits, optionally grouped by spaces or dashes
+func IsCardLike(s string) bool {
+	digits, ok := CompactDigits(s)
+	return ok && len(digits) >= 13 && len(digits) <= 19
+}
+
+// ValidLuhn reports whether a digit string passes the Luhn check
+func ValidLuhn(s string) bool {
+	digits, ok := CompactDigits(s)
+	if !ok || len(digits) < 2 {
+		return false
+	}
+	sum := 0
+	double := false
+	for i := len(digits) - 1; i >= 0; i-- {
+		d := int(digits[i] - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
+
+// compactIBAN uppercases s and removes spaces
+func compactIBAN(s string) string {
+	return strings.ToUpper(strings.ReplaceAll(strings.TrimSpace(s), " ", ""))
+}
+
+// IsIBANLike reports whether s has the shape of an IBAN: country code, two
+// check digits and 11-30 alphanumerics
+func IsIBANLike(s string) bool {
+	s = compactIBAN(s)
+	if len(s) < 15 || len(s) > 34 {
+		return false
+	}
+	for i, c := range s {
+		switch {
+		case i < 2 && (c < 'A' || c > 'Z'):
+			return false
+		case i >= 2 && i < 4 && (c < '0' || c > '9'):
+			return false
+		case i >= 4 && !(c >= '0' && c <= '9' || c >= 'A' && c <= 'Z'):
+			return false
+		}
+	}
+	return true
+}
+
+// ValidIBAN reports whether an IBAN-like string has valid check digits
+// (mod 97 of the rearranged, letter-expanded number is 1)
+func ValidIBAN(s string) bool {
+	if !IsIBANLike(s) {
+		return false
+	}
+	s = compactIBAN(s)
+	var b strings.Builder
+	for _, c := range s[4:] + s[:4] {
+		if c >= 'A' && c <= 'Z' {
+			b.WriteString(strconv.Itoa(int(c-'A') + 10))
+		} else {
+			b.WriteRune(c)
+		}
+	}
+	n, ok := new(big.Int).SetString(b.String(), 10)
+	return ok && new(big.Int).Mod(n, big.NewInt(97)).Int64() == 1
+}
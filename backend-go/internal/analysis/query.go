@@ -0,0 +1,338 @@
+package analysis
+
+import (
+	"backend-go/internal/dateformat"
+	"backend-go/internal/models"
+	"backend-go/internal/nulltoken"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Query filter operators
+const (
+	OpEquals      = "equals"
+	OpNotEquals   = "not_equals"
+	OpContains    = "contains"
+	OpGreaterThan = "greater_than"
+	OpLessThan    = "less_than"
+)
+
+// Time grains bucketing date group-by values
+const (
+	GrainDay     = "day"
+	GrainMonth   = "month"
+	GrainQuarter = "quarter"
+	GrainYear    = "year"
+)
+
+// Query limits
+const (
+	QueryDefaultLimit = 100
+	QueryMaxLimit     = 1000
+)
+
+// The plan types are shared with the API client
+type (
+	QueryFilter      = models.QueryFilter
+	QueryAggregation = models.QueryAggregation
+	QueryOrder       = models.QueryOrder
+	QueryPlan        = models.QueryPlan
+)
+
+// planOperators are the case-insensitive operators a plan's equality
+// filters stand for: the model often gets the case of values wrong
+var planOperators = map[string]string{
+	OpEquals:    OpIEquals,
+	OpNotEquals: OpNotIEquals,
+}
+
+// planFilterGroup is the filter of a plan: all of its filters
+func planFilterGroup(p *QueryPlan) models.FilterGroup {
+	group := models.FilterGroup{Logic: LogicAnd}
+	for _, f := range p.Filters {
+		op := f.Operator
+		if mapped, ok := planOperators[op]; ok {
+			op = mapped
+		}
+		group.Conditions = append(group.Conditions, models.FilterCondition{Column: f.Column, Operator: op, Value: f.Value, Values: f.Values})
+	}
+	return group
+}
+
+// QueryResult is the table a plan produces
+type QueryResult struct {
+	Columns     []string                 `json:"columns"`
+	Rows        []map[string]interface{} `json:"rows"`
+	MatchedRows int                      `json:"matched_rows"` // Rows passing the filters
+	TotalRows   int                      `json:"total_rows"`   // Result rows before the limit
+}
+
+// ValidatePlan checks a plan against the table's columns and fills defaults
+func ValidatePlan(plan *QueryPlan, headers []string) error {
+	known := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		known[h] = true
+	}
+	if _, err := CompileFilter(planFilterGroup(plan), headers); err != nil {
+		return err
+	}
+	for _, g := range plan.GroupBy {
+		if !known[g] {
+			return fmt.Errorf("group-by column %q not found", g)
+		}
+	}
+	switch plan.TimeGrain {
+	case "", GrainDay, GrainMonth, GrainQuarter, GrainYear:
+	default:
+		return fmt.Errorf("unknown time grain %q", plan.TimeGrain)
+	}
+	if len(plan.GroupBy) > 0 && len(plan.Aggregations) == 0 {
+		plan.Aggregations = []QueryAggregation{{Function: AggCount}}
+	}
+	for _, a := range plan.Aggregations {
+		switch a.Function {
+		case AggCount:
+		case AggDistinct, AggSum, AggMean, AggMin, AggMax:
+			if a.Column == "" {
+				return fmt.Errorf("aggregation %s needs a column", a.Function)
+			}
+		default:
+			return fmt.Errorf("unknown aggregation %q", a.Function)
+		}
+		if a.Column != "" && !known[a.Column] {
+			return fmt.Errorf("aggregation column %q not found", a.Column)
+		}
+	}
+	for _, s := range plan.Select {
+		if !known[s] {
+			return fmt.Errorf("selected column %q not found", s)
+		}
+	}
+	if plan.OrderBy != nil {
+		valid := false
+		for _, c := range planColumns(plan, headers) {
+			if c == plan.OrderBy.Column {
+				valid = true
+			}
+		}
+		if !valid {
+			return fmt.Errorf("order-by column %q is not in the result", plan.OrderBy.Column)
+		}
+	}
+	if plan.Limit <= 0 {
+		plan.Limit = QueryDefaultLimit
+	}
+	if plan.Limit > QueryMaxLimit {
+		plan.Limit = QueryMaxLimit
+	}
+	return nil
+}
+
+// planColumns lists the result columns of a plan
+func planColumns(plan *QueryPlan, headers []string) []string {
+	if len(plan.Aggregations) == 0 {
+		if len(plan.Select) > 0 {
+			return plan.Select
+		}
+		return headers
+	}
+	cols := append([]string{}, plan.GroupBy...)
+	for _, a := range plan.Aggregations {
+		cols = append(cols, a.Name())
+	}
+	return cols
+}
+
+// ExecutePlan runs a validated plan over the rows of a table
+func ExecutePlan(headers []string, rows [][]string, plan *QueryPlan) (*QueryResult, error) {
+	if err := ValidatePlan(plan, headers); err != nil {
+		return nil, err
+	}
+	index := make(map[string]int, len(headers))
+	for i, h := range headers {
+		index[h] = i
+	}
+	cell := func(row []string, col string) string {
+		if i := index[col]; i < len(row) {
+			return strings.TrimSpace(row[i])
+		}
+		return ""
+	}
+
+	filter, err := CompileFilter(planFilterGroup(plan), headers)
+	if err != nil {
+		return nil, err
+	}
+	matched := [][]string{}
+	for _, row := range rows {
+		if filter(row) {
+			matched = append(matched, row)
+		}
+	}
+	result := &QueryResult{Columns: planColumns(plan, headers), Rows: []map[string]interface{}{}, MatchedRows: len(matched)}
+
+	if len(plan.Aggregations) == 0 {
+		for _, row := range matched {
+			out := make(map[string]interface{}, len(result.Columns))
+			for _, c := range result.Columns {
+				out[c] = cell(row, c)
+			}
+			result.Rows = append(result.Rows, out)
+		}
+	} else {
+		result.Rows = aggregateRows(matched, plan, cell)
+	}
+
+	if plan.OrderBy != nil {
+		col, desc := plan.OrderBy.Column, plan.OrderBy.Descending
+		sort.SliceStable(result.Rows, func(i, j int) bool {
+			a, b := result.Rows[i][col], result.Rows[j][col]
+			if desc && a != nil && b != nil {
+				a, b = b, a
+			}
+			return lessQueryValue(a, b)
+		})
+	}
+	result.TotalRows = len(result.Rows)
+	if len(result.Rows) > plan.Limit {
+		result.Rows = result.Rows[:plan.Limit]
+	}
+	return result, nil
+}
+
+// aggregateRows groups rows and computes the plan's aggregations per group
+func aggregateRows(rows [][]string, plan *QueryPlan, cell func([]string, string) string) []map[string]interface{} {
+	type group struct {
+		keys []string
+		accs []*pivotAcc
+	}
+	groups := make(map[string]*group)
+	order := []string{}
+	nullChecks := make([]func(string) bool, len(plan.Aggregations))
+	for i, a := range plan.Aggregations {
+		nullChecks[i] = nulltoken.ForColumn(a.Column)
+	}
+
+	buckets := make(map[string]string)
+	for _, row := range rows {
+		keys := make([]string, len(plan.GroupBy))
+		for i, g := range plan.GroupBy {
+			keys[i] = cell(row, g)
+			if plan.TimeGrain != "" {
+				keys[i] = timeBucket(keys[i], plan.TimeGrain, buckets)
+			}
+		}
+		id := strings.Join(keys, "\x1f")
+		grp, ok := groups[id]
+		if !ok {
+			grp = &group{keys: keys, accs: make([]*pivotAcc, len(plan.Aggregations))}
+			for i, a := range plan.Aggregations {
+				grp.accs[i] = &pivotAcc{}
+				if a.Function == AggDistinct {
+					grp.accs[i].distinct = map[string]bool{}
+				}
+			}
+			groups[id] = grp
+			order = append(order, id)
+		}
+		for i, a := range plan.Aggregations {
+			if a.Column == "" {
+				grp.accs[i].add("", 0, false)
+				continue
+			}
+			raw := cell(row, a.Column)
+			if nullChecks[i](raw) {
+				continue
+			}
+			v, err := strconv.ParseFloat(raw, 64)
+			if err != nil && a.Function != AggCount && a.Function != AggDistinct {
+				continue
+			}
+			grp.accs[i].add(raw, v, err == nil)
+		}
+	}
+
+	// A global aggregate over no rows still reports a zero count
+	if len(plan.GroupBy) == 0 && len(order) == 0 {
+		out := map[string]interface{}{}
+		for _, a := range plan.Aggregations {
+			out[a.Name()] = nil
+			if a.Function == AggCount || a.Function == AggDistinct {
+				out[a.Name()] = 0.0
+			}
+		}
+		return []map[string]interface{}{out}
+	}
+
+	result := make([]map[string]interface{}, 0, len(order))
+	for _, id := range order {
+		grp := groups[id]
+		out := make(map[string]interface{}, len(plan.GroupBy)+len(plan.Aggregations))
+		for i, g := range plan.GroupBy {
+			out[g] = grp.keys[i]
+		}
+		for i, a := range plan.Aggregations {
+			if v := grp.accs[i].result(a.Function); v != nil && !math.IsInf(*v, 0) {
+				out[a.Name()] = *v
+			} else {
+				out[a.Name()] = nil
+			}
+		}
+		result = append(result, out)
+	}
+	return result
+}
+
+// timeBucket truncates a date value to the grain, e.g. "2024-03" for a
+// month; values that aren't dates are kept as they are
+func timeBucket(value, grain string, cache map[string]string) string {
+	if b, ok := cache[value]; ok {
+		return b
+	}
+	b := value
+	if t, _, ok := dateformat.Parse(value); ok {
+		switch grain {
+		case GrainDay:
+			b = t.Format("2006-01-02")
+		case GrainMonth:
+			b = t.Format("2006-01")
+		case GrainQuarter:
+			b = fmt.Sprintf("%d-Q%d", t.Year(), (int(t.Month())+2)/3)
+		case GrainYear:
+			b = strconv.Itoa(t.Year())
+		}
+	}
+	cache[value] = b
+	return b
+}
+
+// lessQueryValue orders result values: nulls last, numbers numerically,
+// strings (numeric strings as numbers) lexically
+func lessQueryValue(a, b interface{}) bool {
+	if a == nil || b == nil {
+		return a != nil && b == nil
+	}
+	fa, okA := queryNumber(a)
+	fb, okB := queryNumber(b)
+	if okA && okB {
+		return fa < fb
+	}
+	if okA != okB {
+		return okA
+	}
+	return fmt.Sprint(a) < fmt.Sprint(b)
+}
+
+func queryNumber(v interface{}) (float64, bool) {
+	switch x := v.(type) {
+	case float64:
+		return x, true
+	case string:
+		f, err := strconv.ParseFloat(x, 64)
+		return f, err == nil
+	}
+	return 0, false
+}
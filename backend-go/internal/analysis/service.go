@@ -1,8 +1,8 @@
 package analysis
 
 import (
+	"backend-go/internal/dateformat"
 	"backend-go/internal/models"
-	"encoding/csv"
 	"fmt"
 	"io"
 	"os"
@@ -108,13 +108,18 @@ func (s *CSVService) AnalyzeData(data []map[string]interface{}, columns []string
 
 // AnalyzeFile reads a CSV file and returns analysis results
 func (s *CSVService) AnalyzeFile(filePath string) (models.DataAnalysisResult, error) {
+	dialect, err := SniffFile(filePath)
+	if err != nil {
+		return models.DataAnalysisResult{}, err
+	}
+
 	file, err := os.Open(filePath)
 	if err != nil {
 		return models.DataAnalysisResult{}, err
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
+	reader := NewRecordReader(file, dialect)
 
 	// Read header
 	headers, err := reader.Read()
@@ -204,19 +209,7 @@ func inferColumnType(rows [][]string, colIndex int) string {
 }
 
 func isDateString(val string) bool {
-	formats := []string{
-		time.RFC3339,
-		"2006-01-02",
-		"02/01/2006",
-		"01/02/2006",
-		"2006/01/02",
-	}
-	for _, f := range formats {
-		if _, err := time.Parse(f, val); err == nil {
-			return true
-		}
-	}
-	return false
+	return dateformat.IsDate(val)
 }
 
 func containsAny(s string, substrings []string) bool {
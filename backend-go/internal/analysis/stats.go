@@ -0,0 +1,168 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+)
+
+// maxHistogramBins caps the bins of a histogram
+const maxHistogramBins = 100
+
+// HistogramBin counts the values in [Lower, Upper); the last bin includes Upper
+type HistogramBin struct {
+	Lower float64 `json:"lower"`
+	Upper float64 `json:"upper"`
+	Count int     `json:"count"`
+}
+
+// Distribution describes the numeric values of a column
+type Distribution struct {
+	Min         float64            `json:"min"`
+	Max         float64            `json:"max"`
+	Mean        float64            `json:"mean"`
+	StdDev      float64            `json:"std_dev"` // Sample standard deviation
+	Skewness    float64            `json:"skewness"`
+	Percentiles map[string]float64 `json:"percentiles"` // p5, p25, p50, p75, p95
+	Histogram   []HistogramBin     `json:"histogram"`
+	NonFinite   int                `json:"non_finite"` // NaN and infinite values, left out
+}
+
+// DescribeDistribution computes percentiles, moments and an equal-width
+// histogram of values. bins <= 0 picks Sturges' rule. NaN and infinite
+// values are counted and left out.
+func DescribeDistribution(values []float64, bins int) Distribution {
+	d := Distribution{Percentiles: map[string]float64{}, Histogram: []HistogramBin{}}
+	values, d.NonFinite = finiteValues(values)
+	n := len(values)
+	if n == 0 {
+		return d
+	}
+	sorted := append([]float64{}, values...)
+	sort.Float64s(sorted)
+	d.Min, d.Max = sorted[0], sorted[n-1]
+	for _, p := range []struct {
+		name string
+		q    float64
+	}{{"p5", 0.05}, {"p25", 0.25}, {"p50", 0.5}, {"p75", 0.75}, {"p95", 0.95}} {
+		d.Percentiles[p.name] = Quantile(sorted, p.q)
+	}
+
+	// Moments are taken of the values scaled to [-1, 1], so sums of values
+	// near the float64 limits don't overflow
+	scale := math.Max(math.Abs(d.Min), math.Abs(d.Max))
+	if scale == 0 {
+		scale = 1
+	}
+	mean := 0.0
+	for _, v := range values {
+		mean += v / scale
+	}
+	mean /= float64(n)
+	d.Mean = mean * scale
+	m2, m3 := 0.0, 0.0
+	for _, v := range values {
+		dev := v/scale - mean
+		m2 += dev * dev
+		m3 += dev * dev * dev
+	}
+	if n > 1 {
+		d.StdDev = math.Sqrt(m2/float64(n-1)) * scale
+	}
+	// Adjusted Fisher-Pearson skewness, as reported by pandas
+	if n > 2 && m2 > 0 {
+		g1 := (m3 / float64(n)) / math.Pow(m2/float64(n), 1.5)
+		d.Skewness = g1 * math.Sqrt(float64(n*(n-1))) / float64(n-2)
+	}
+
+	if bins <= 0 {
+		bins = int(math.Ceil(math.Log2(float64(n)))) + 1
+	}
+	if bins > maxHistogramBins {
+		bins = maxHistogramBins
+	}
+	if d.Max == d.Min {
+		d.Histogram = append(d.Histogram, HistogramBin{Lower: d.Min, Upper: d.Max, Count: n})
+		return d
+	}
+	width := d.Max/float64(bins) - d.Min/float64(bins) // Max - Min may overflow
+	edge := func(i int) float64 {
+		if e := d.Min + float64(i)*width; !math.IsInf(e, 0) {
+			return e
+		}
+		f := float64(i) / float64(bins) // The offset overflows
+		return d.Min*(1-f) + d.Max*f
+	}
+	d.Histogram = make([]HistogramBin, bins)
+	for i := range d.Histogram {
+		d.Histogram[i].Lower = edge(i)
+		d.Histogram[i].Upper = edge(i + 1)
+	}
+	d.Histogram[bins-1].Upper = d.Max
+	for _, v := range values {
+		// Halved so v - Min can't overflow; clamped before the conversion
+		pos := (v/2 - d.Min/2) / (width / 2)
+		i := int(math.Max(0, math.Min(pos, float64(bins-1))))
+		d.Histogram[i].Count++
+	}
+	return d
+}
+
+// finiteValues returns the values that are neither NaN nor infinite, and
+// how many were left out
+func finiteValues(values []float64) ([]float64, int) {
+	finite := make([]float64, 0, len(values))
+	for _, v := range values {
+		if !math.IsNaN(v) && !math.IsInf(v, 0) {
+			finite = append(finite, v)
+		}
+	}
+	return finite, len(values) - len(finite)
+}
+
+// ValueFrequency is the count and share (0-100) of one value
+type ValueFrequency struct {
+	Value   string  `json:"value"`
+	Count   int     `json:"count"`
+	Percent float64 `json:"percent"`
+}
+
+// Frequencies lists the most common values of a column
+type Frequencies struct {
+	Total    int              `json:"total"` // Non-null values
+	Distinct int              `json:"distinct"`
+	Top      []ValueFrequency `json:"top"`
+	Other    ValueFrequency   `json:"other"` // All values outside the top K
+}
+
+// TopFrequencies returns the k most common values of counts (ties by value),
+// the rest summed into an "other" bucket
+func TopFrequencies(counts map[string]int, k int) Frequencies {
+	f := Frequencies{Distinct: len(counts), Top: []ValueFrequency{}, Other: ValueFrequency{Value: "other"}}
+	values := make([]string, 0, len(counts))
+	for v, n := range counts {
+		values = append(values, v)
+		f.Total += n
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if counts[values[i]] != counts[values[j]] {
+			return counts[values[i]] > counts[values[j]]
+		}
+		return values[i] < values[j]
+	})
+
+	percent := func(n int) float64 {
+		if f.Total == 0 {
+			return 0
+		}
+		return 100 * float64(n) / float64(f.Total)
+	}
+	for i, v := range values {
+		if k > 0 && i >= k {
+			f.Other.Count += counts[v]
+			continue
+		}
+		f.Top = append(f.Top, ValueFrequency{Value: v, Count: counts[v], Percent: percent(counts[v])})
+	}
+	f.Other.Percent = percent(f.Other.Count)
+	return f
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+)
+
+func TestDescribeDistributionNonFinite(t *testing.T) {
+	values := []float64{math.NaN(), 1, 2, math.Inf(1), 3, 4, math.Inf(-1)}
+	d := DescribeDistribution(values, 0)
+	if d.NonFinite != 3 || d.Min != 1 || d.Max != 4 || d.Mean != 2.5 {
+		t.Errorf("got non_finite %d, min %v, max %v, mean %v", d.NonFinite, d.Min, d.Max, d.Mean)
+	}
+	count := 0
+	for _, b := range d.Histogram {
+		count += b.Count
+	}
+	if count != 4 {
+		t.Errorf("histogram holds %d values, want 4", count)
+	}
+	if _, err := json.Marshal(d); err != nil {
+		t.Error(err)
+	}
+
+	if d := DescribeDistribution([]float64{math.NaN()}, 0); d.NonFinite != 1 || len(d.Histogram) != 0 {
+		t.Errorf("got %+v for no finite values", d)
+	}
+}
+
+func TestDescribeDistributionExtremes(t *testing.T) {
+	// Sums and the range of these overflow float64
+	values := []float64{math.MaxFloat64, math.MaxFloat64, -math.MaxFloat64, 0}
+	d := DescribeDistribution(values, 4)
+	if _, err := json.Marshal(d); err != nil {
+		t.Fatal(err)
+	}
+	if d.Histogram[0].Count != 1 || d.Histogram[1].Count+d.Histogram[2].Count != 1 || d.Histogram[3].Count != 2 {
+		t.Errorf("got histogram %+v", d.Histogram)
+	}
+}
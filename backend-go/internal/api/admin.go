@@ -0,0 +1,123 @@
+package api
+
+import (
+	"backend-go/internal/state"
+	"encoding/json"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Runtime Diagnostics
+// ============================================================================
+
+// startTime is when the process started serving, for the uptime
+var startTime = time.Now()
+
+// RuntimeStats is the response of GET /api/v1/admin/runtime
+type RuntimeStats struct {
+	GoVersion     string  `json:"go_version"`
+	UptimeSeconds float64 `json:"uptime_seconds"`
+	Goroutines    int     `json:"goroutines"`
+	CPUs          int     `json:"cpus"`
+	GOMAXPROCS    int     `json:"gomaxprocs"`
+
+	Memory     MemoryStats      `json:"memory"`
+	DataFrames []DataFrameUsage `json:"dataframes"`
+	// Estimated bytes of the loaded DataFrames, to compare with heap_alloc
+	DataFrameBytes int64 `json:"dataframe_bytes"`
+}
+
+// MemoryStats are the runtime's memory statistics, in bytes
+type MemoryStats struct {
+	HeapAlloc    uint64  `json:"heap_alloc"`    // Live and not yet collected heap objects
+	HeapInuse    uint64  `json:"heap_inuse"`    // Heap spans in use
+	HeapIdle     uint64  `json:"heap_idle"`     // Heap spans unused, some returned to the OS
+	HeapReleased uint64  `json:"heap_released"` // Heap returned to the OS
+	HeapObjects  uint64  `json:"heap_objects"`
+	Sys          uint64  `json:"sys"` // Obtained from the OS in total
+	TotalAlloc   uint64  `json:"total_alloc"`
+	NumGC        uint32  `json:"num_gc"`
+	LastGC       string  `json:"last_gc,omitempty"`
+	PauseTotalMs float64 `json:"pause_total_ms"`
+}
+
+// DataFrameUsage is the estimated memory of a loaded file
+type DataFrameUsage struct {
+	FileIndex  int    `json:"file_index"`
+	FileName   string `json:"file_name"`
+	TotalBytes int64  `json:"total_bytes"`
+	state.MemoryUsage
+}
+
+// GetRuntime handles GET /api/v1/admin/runtime
+// Reports goroutines, heap usage and the estimated size of the loaded
+// DataFrames, to tell data held by the files from memory growing elsewhere.
+func (h *Handler) GetRuntime(w http.ResponseWriter, r *http.Request) {
+	var mem runtime.MemStats
+	runtime.ReadMemStats(&mem)
+
+	stats := RuntimeStats{
+		GoVersion:     runtime.Version(),
+		UptimeSeconds: time.Since(startTime).Seconds(),
+		Goroutines:    runtime.NumGoroutine(),
+		CPUs:          runtime.NumCPU(),
+		GOMAXPROCS:    runtime.GOMAXPROCS(0),
+		Memory: MemoryStats{
+			HeapAlloc:    mem.HeapAlloc,
+			HeapInuse:    mem.HeapInuse,
+			HeapIdle:     mem.HeapIdle,
+			HeapReleased: mem.HeapReleased,
+			HeapObjects:  mem.HeapObjects,
+			Sys:          mem.Sys,
+			TotalAlloc:   mem.TotalAlloc,
+			NumGC:        mem.NumGC,
+			PauseTotalMs: float64(mem.PauseTotalNs) / 1e6,
+		},
+		DataFrames: []DataFrameUsage{},
+	}
+	if mem.LastGC > 0 {
+		stats.Memory.LastGC = time.Unix(0, int64(mem.LastGC)).UTC().Format(time.RFC3339)
+	}
+	for fileIndex := 1; fileIndex <= 2; fileIndex++ {
+		df := state.State.GetDataFrame(fileIndex)
+		if df == nil {
+			continue
+		}
+		usage := df.MemoryUsage()
+		stats.DataFrames = append(stats.DataFrames, DataFrameUsage{
+			FileIndex:   fileIndex,
+			FileName:    df.FileName,
+			TotalBytes:  usage.TotalBytes(),
+			MemoryUsage: usage,
+		})
+		stats.DataFrameBytes += usage.TotalBytes()
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(stats)
+}
+
+// Pprof handles GET /api/v1/admin/pprof/*
+// Serves net/http/pprof: the index, the named profiles (heap, goroutine,
+// allocs, ...), cmdline, profile (CPU), symbol and trace.
+func (h *Handler) Pprof(w http.ResponseWriter, r *http.Request) {
+	switch name := chi.URLParam(r, "*"); name {
+	case "":
+		pprof.Index(w, r)
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
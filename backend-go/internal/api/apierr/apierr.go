@@ -0,0 +1,145 @@
+// Package apierr defines the JSON error envelope every API endpoint returns:
+//
+//	{"code": "file_not_loaded", "message": "File 2 not loaded", "details": {"file_index": 2}}
+//
+// The code is stable for clients to branch on; the message is for people.
+package apierr
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+)
+
+// Code identifies the kind of error
+type Code string
+
+// Error codes
+const (
+	CodeInvalidJSON      Code = "invalid_json"       // Request body isn't valid JSON for the endpoint
+	CodeInvalidRequest   Code = "invalid_request"    // Missing or invalid parameters
+	CodeFileNotLoaded    Code = "file_not_loaded"    // The endpoint needs a file that isn't uploaded
+	CodeParseError       Code = "parse_error"        // An uploaded or fetched file couldn't be parsed
+	CodeUnauthorized     Code = "unauthorized"       // Missing or unknown API key
+	CodeForbidden        Code = "forbidden"          // The API key's role doesn't allow the endpoint
+	CodeNotFound         Code = "not_found"          // The addressed resource or route doesn't exist
+	CodeMethodNotAllowed Code = "method_not_allowed" // The route exists but not for this method
+	CodeConflict         Code = "conflict"           // The resource changed under the request
+	CodeRateLimited      Code = "rate_limited"       // The client sent too many requests
+	CodeUpstream         Code = "upstream_error"     // A remote source or service failed
+	CodeInternal         Code = "internal_error"     // Anything else
+)
+
+// Error is an API error with its HTTP status
+type Error struct {
+	Status  int                    `json:"-"`
+	Code    Code                   `json:"code"`
+	Message string                 `json:"message"`
+	Details map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// New creates an error
+func New(status int, code Code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+// WithDetail adds a detail to the error and returns it
+func (e *Error) WithDetail(key string, value interface{}) *Error {
+	if e.Details == nil {
+		e.Details = make(map[string]interface{})
+	}
+	e.Details[key] = value
+	return e
+}
+
+// InvalidJSON reports a request body that failed to decode
+func InvalidJSON(err error) *Error {
+	e := New(http.StatusBadRequest, CodeInvalidJSON, "Invalid JSON")
+	if err != nil {
+		e.WithDetail("reason", err.Error())
+	}
+	return e
+}
+
+// BadRequest reports missing or invalid parameters
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeInvalidRequest, message)
+}
+
+// FileNotLoaded reports that file 1 or 2 hasn't been uploaded
+func FileNotLoaded(fileIndex int) *Error {
+	e := New(http.StatusBadRequest, CodeFileNotLoaded, "File "+strconv.Itoa(fileIndex)+" not loaded")
+	return e.WithDetail("file_index", fileIndex)
+}
+
+// FilesNotLoaded reports that an endpoint needs files that aren't all uploaded
+func FilesNotLoaded(message string) *Error {
+	return New(http.StatusBadRequest, CodeFileNotLoaded, message)
+}
+
+// ParseError reports a file that couldn't be parsed
+func ParseError(message string) *Error {
+	return New(http.StatusBadRequest, CodeParseError, message)
+}
+
+// Unauthorized reports a missing or unknown API key
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+// Forbidden reports a role without access to the endpoint
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+// NotFound reports a missing resource
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+// Conflict reports a resource that changed under the request
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// TooManyRequests reports a client over its rate limit
+func TooManyRequests(message string) *Error {
+	return New(http.StatusTooManyRequests, CodeRateLimited, message)
+}
+
+// Upstream reports a failed remote source or service
+func Upstream(message string) *Error {
+	return New(http.StatusBadGateway, CodeUpstream, message)
+}
+
+// Internal reports an unexpected server-side failure
+func Internal(message string) *Error {
+	return New(http.StatusInternalServerError, CodeInternal, message)
+}
+
+// Write sends an error as the JSON envelope. Errors other than *Error are
+// reported as internal errors.
+func Write(w http.ResponseWriter, err error) {
+	e, ok := err.(*Error)
+	if !ok {
+		e = Internal(err.Error())
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(e)
+}
+
+// NotFoundHandler answers requests for unknown routes
+func NotFoundHandler(w http.ResponseWriter, r *http.Request) {
+	Write(w, NotFound("No route for "+r.URL.Path).WithDetail("path", r.URL.Path))
+}
+
+// MethodNotAllowedHandler answers requests with a method a route doesn't serve
+func MethodNotAllowedHandler(w http.ResponseWriter, r *http.Request) {
+	Write(w, New(http.StatusMethodNotAllowed, CodeMethodNotAllowed, r.Method+" is not allowed on "+r.URL.Path))
+}
@@ -0,0 +1,82 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/llm"
+	"backend-go/internal/models"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// ============================================================================
+// Audit Log
+// ============================================================================
+
+const (
+	defaultAuditLimit = 100
+	maxAuditLimit     = 1000
+)
+
+// auditUpload adds the loaded file to the audit entry of an upload
+func auditUpload(r *http.Request, fileIndex int, fileName string, resp *models.UploadResponse) {
+	audit.Annotate(r.Context(), "file_index", fileIndex)
+	audit.Annotate(r.Context(), "file", fileName)
+	audit.Annotate(r.Context(), "rows", resp.Rows)
+	audit.Annotate(r.Context(), "content_hash", resp.ContentHash)
+}
+
+// auditTruncation notes in the audit entry that prompts of the request were
+// cut to fit the LLM's token budget
+func auditTruncation(r *http.Request, report *llm.TruncationReport) {
+	if report.Truncated {
+		audit.Annotate(r.Context(), "llm_truncated", true)
+		audit.Annotate(r.Context(), "llm_dropped_columns", len(report.DroppedColumns))
+	}
+}
+
+// GetAuditLog handles GET /api/v1/audit
+// Lists the recorded changes and exports, newest first, filtered by user,
+// action (substring of "METHOD /api/v1/path"), since and until (RFC 3339)
+// and limited to limit entries (default 100, at most 1000).
+func (h *Handler) GetAuditLog(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	filter := audit.Filter{User: q.Get("user"), Action: q.Get("action"), Limit: defaultAuditLimit}
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		v := q.Get(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			apierr.Write(w, apierr.BadRequest(param.name+" must be an RFC 3339 time").WithDetail(param.name, v))
+			return
+		}
+		*param.dst = t
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxAuditLimit {
+			apierr.Write(w, apierr.BadRequest("limit must be between 1 and "+strconv.Itoa(maxAuditLimit)))
+			return
+		}
+		filter.Limit = limit
+	}
+
+	entries, err := audit.Query(filter)
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to read the audit log"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": entries,
+		"count":   len(entries),
+	})
+}
@@ -0,0 +1,279 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/auth"
+	"backend-go/internal/config"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Batch Comparison
+// ============================================================================
+
+// batchCompareRequest is the manifest of POST /api/v1/batch/compare
+type batchCompareRequest struct {
+	Name    string              `json:"name,omitempty"`
+	Profile string              `json:"profile,omitempty"` // Matching profile; default balanced
+	Pairs   []service.BatchPair `json:"pairs"`
+}
+
+const defaultBatchProfile = "balanced"
+
+// batchSourcePath resolves a batch source to the file it names: a stored
+// upload, or a relative path inside batch.root
+func batchSourcePath(src service.BatchSource) (string, error) {
+	switch {
+	case src.Upload != "" && src.Path != "":
+		return "", fmt.Errorf("give either upload or path, not both")
+	case src.Upload != "":
+		if src.Upload != filepath.Base(src.Upload) || strings.HasPrefix(src.Upload, ".") {
+			return "", fmt.Errorf("invalid upload name %q", src.Upload)
+		}
+		return filepath.Join(uploadDir(), src.Upload), nil
+	case src.Path != "":
+		root := config.Get().Batch.Root
+		if root == "" {
+			return "", fmt.Errorf("paths need batch.root to be configured; use uploads instead")
+		}
+		if !filepath.IsLocal(src.Path) {
+			return "", fmt.Errorf("path %q must be relative to batch.root and stay inside it", src.Path)
+		}
+		return filepath.Join(root, src.Path), nil
+	}
+	return "", fmt.Errorf("upload or path is required")
+}
+
+// RunBatchPair compares one pair of a batch job. The files are parsed from
+// copies, leaving the sources and the loaded files untouched.
+func (h *Handler) RunBatchPair(ctx context.Context, pair service.BatchPair, profile service.MatchingProfile) service.BatchPairResult {
+	result := service.BatchPairResult{}
+	df1, err := loadBatchSource(pair.File1)
+	if err != nil {
+		result.Error = "file1: " + err.Error()
+		return result
+	}
+	df2, err := loadBatchSource(pair.File2)
+	if err != nil {
+		result.Error = "file2: " + err.Error()
+		return result
+	}
+	h.compareFrames(ctx, &result, pair, df1, df2, profile)
+	return result
+}
+
+// compareFrames fills a pair result with the heuristic matching of two
+// parsed files
+func (h *Handler) compareFrames(ctx context.Context, result *service.BatchPairResult, pair service.BatchPair, df1, df2 *state.DataFrame, profile service.MatchingProfile) {
+	result.File1Summary = batchFileSummary(pair.File1, df1)
+	result.File2Summary = batchFileSummary(pair.File2, df2)
+
+	// Heuristic matching only; a batch would hold the LLM for too long
+	similarities, _, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarityWithOptions(ctx, df1, df2, nil, nil, profile.ScoringOptions())
+	if err != nil {
+		result.Error = err.Error()
+		return
+	}
+	candidates := make([]service.AssignmentCandidate, len(similarities))
+	for i, sim := range similarities {
+		candidates[i] = service.AssignmentCandidate{File1Column: sim.File1Column, File2Column: sim.File2Column, Confidence: sim.Confidence}
+	}
+	matched1, matched2 := map[string]bool{}, map[string]bool{}
+	assigned := []service.AssignmentCandidate{}
+	for _, i := range service.SelectAssignment(candidates, profile.AssignmentMode) {
+		sim := similarities[i]
+		if sim.Confidence <= profile.MinConfidence {
+			continue
+		}
+		result.Matches = append(result.Matches, service.BatchMatch{
+			File1Column: sim.File1Column,
+			File2Column: sim.File2Column,
+			Confidence:  sim.Confidence,
+			Type:        sim.Type,
+		})
+		assigned = append(assigned, candidates[i])
+		matched1[sim.File1Column], matched2[sim.File2Column] = true, true
+	}
+	for _, col := range df1.Headers {
+		if !matched1[col] {
+			result.UnmatchedFile1 = append(result.UnmatchedFile1, col)
+		}
+	}
+	for _, col := range df2.Headers {
+		if !matched2[col] {
+			result.UnmatchedFile2 = append(result.UnmatchedFile2, col)
+		}
+	}
+	result.CompositeKeys = h.EnhancedSimilarityService.SuggestCompositeKeys(df1, df2, assigned)
+}
+
+// loadBatchSource parses a copy of a batch source
+func loadBatchSource(src service.BatchSource) (*state.DataFrame, error) {
+	path, err := batchSourcePath(src)
+	if err != nil {
+		return nil, err
+	}
+	return loadFileCopy(path, src.String())
+}
+
+// loadFileCopy parses a copy of the file at path in a scratch directory
+// under the upload directory, removed once parsed; name describes the file
+// in errors
+func loadFileCopy(path, name string) (*state.DataFrame, error) {
+	in, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("can't open %s: %v", name, err)
+	}
+	defer in.Close()
+
+	scratchRoot := filepath.Join(uploadDir(), ".batch")
+	os.MkdirAll(scratchRoot, 0755)
+	scratch, err := os.MkdirTemp(scratchRoot, "pair-")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(scratch)
+
+	copyPath := filepath.Join(scratch, filepath.Base(path))
+	if _, err := saveLimited(copyPath, in, maxRemoteFileSize()); err != nil {
+		return nil, err
+	}
+	df, _, apiErr := parseUploadFile(copyPath, filepath.Base(path), uploadOptions{})
+	if apiErr != nil {
+		return nil, apiErr
+	}
+	df.FilePath = path
+	return df, nil
+}
+
+func batchFileSummary(src service.BatchSource, df *state.DataFrame) *service.BatchFileSummary {
+	return &service.BatchFileSummary{Source: src.String(), Name: df.FileName, Rows: len(df.Rows), Columns: len(df.Headers)}
+}
+
+// CreateBatchCompare handles POST /api/v1/batch/compare
+// Queues the comparison of every pair in the manifest; each pair names its
+// files as stored uploads ({"upload": "file1_a.csv"}) or paths inside
+// batch.root ({"path": "2024-06/accounts.csv"}).
+func (h *Handler) CreateBatchCompare(w http.ResponseWriter, r *http.Request) {
+	var req batchCompareRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	if req.Profile == "" {
+		req.Profile = defaultBatchProfile
+	}
+	profile, ok := service.GetMatchingProfileStore().Get(req.Profile)
+	if !ok {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown matching profile: %s", req.Profile)))
+		return
+	}
+
+	// Check every source up front so a typo fails the request, not pair 37
+	for i, pair := range req.Pairs {
+		for side, src := range []service.BatchSource{pair.File1, pair.File2} {
+			path, err := batchSourcePath(src)
+			if err == nil {
+				if _, statErr := os.Stat(path); statErr != nil {
+					err = fmt.Errorf("%s not found", src)
+				}
+			}
+			if err != nil {
+				apierr.Write(w, apierr.BadRequest(fmt.Sprintf("pairs[%d].file%d: %v", i, side+1, err)).
+					WithDetail("pair", i).WithDetail("file", side+1))
+				return
+			}
+		}
+	}
+
+	user := ""
+	if p, ok := auth.FromContext(r.Context()); ok {
+		user = p.Name
+	}
+	job, err := service.GetBatchStore().Submit(req.Name, user, req.Pairs, profile)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	audit.Annotate(r.Context(), "job_id", job.ID)
+	audit.Annotate(r.Context(), "pairs", len(job.Pairs))
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", APIPrefix+"/batch/"+job.ID)
+	w.WriteHeader(http.StatusAccepted)
+	json.NewEncoder(w).Encode(job)
+}
+
+// ListBatchJobs handles GET /api/v1/batch
+// Lists the batch jobs, newest first, with their progress
+func (h *Handler) ListBatchJobs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"jobs": service.GetBatchStore().List(),
+	})
+}
+
+// GetBatchJob handles GET /api/v1/batch/{id}
+// Returns a job with the result of each pair compared so far
+func (h *Handler) GetBatchJob(w http.ResponseWriter, r *http.Request) {
+	job, ok := service.GetBatchStore().Get(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Batch job not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(job)
+}
+
+// GetBatchReport handles GET /api/v1/batch/{id}/report
+// Consolidates a job into one row per pair, as JSON or, with format=csv,
+// a CSV download
+func (h *Handler) GetBatchReport(w http.ResponseWriter, r *http.Request) {
+	job, ok := service.GetBatchStore().Get(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Batch job not found"))
+		return
+	}
+	report := job.Report()
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", "json":
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(report)
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s_report.csv", job.ID))
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"pair", "status", "file1", "file2", "file1_rows", "file2_rows", "file1_columns", "file2_columns",
+			"matched_columns", "coverage", "unmatched_file1", "unmatched_file2", "join_key", "error"})
+		for _, row := range report.Rows {
+			cw.Write([]string{
+				row.Name, row.Status, row.File1, row.File2,
+				strconv.Itoa(row.File1Rows), strconv.Itoa(row.File2Rows),
+				strconv.Itoa(row.File1Columns), strconv.Itoa(row.File2Columns),
+				strconv.Itoa(row.MatchedColumns), strconv.FormatFloat(row.Coverage, 'f', 3, 64),
+				strconv.Itoa(row.UnmatchedFile1), strconv.Itoa(row.UnmatchedFile2),
+				row.JoinKey, row.Error,
+			})
+		}
+		cw.Flush()
+	default:
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use json or csv)", format)))
+	}
+}
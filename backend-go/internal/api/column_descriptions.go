@@ -0,0 +1,183 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/llm"
+	"backend-go/internal/models"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Column Description Suggestions
+// ============================================================================
+
+// suggestDescriptionsRequest is the optional body of
+// POST /context/{fileIndex}/descriptions/suggest
+type suggestDescriptionsRequest struct {
+	Columns []string `json:"columns"` // Default: the columns without a description
+}
+
+// acceptDescriptionsRequest is the body of POST /context/{fileIndex}/descriptions
+type acceptDescriptionsRequest struct {
+	Descriptions map[string]interface{} `json:"descriptions"`
+}
+
+// SuggestColumnDescriptions handles POST /api/v1/context/{fileIndex}/descriptions/suggest
+// Asks the LLM for a one-line description of each column, from its name,
+// masked samples and the file's business domain and purpose. By default
+// only the columns without a description are described. Suggestions are
+// returned for review and not stored; POST the accepted ones to
+// /context/{fileIndex}/descriptions.
+func (h *Handler) SuggestColumnDescriptions(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	var req suggestDescriptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	locale, err := requestLocale(r)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+	if h.LLMService == nil {
+		apierr.Write(w, apierr.Upstream("LLM service not configured"))
+		return
+	}
+
+	ctx := state.State.GetContext(fileIndex)
+	if ctx == nil {
+		ctx = models.NewContext()
+	}
+	known := map[string]bool{}
+	for _, col := range df.Headers {
+		known[col] = true
+	}
+	wanted := map[string]bool{}
+	for _, col := range req.Columns {
+		if !known[col] {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Column %q not found in file %d", col, fileIndex)))
+			return
+		}
+		wanted[col] = true
+	}
+	columns := []llm.ColumnSchema{}
+	for _, c := range h.EnhancedSimilarityService.ContextDraftColumns(df) {
+		if (len(wanted) == 0 && ctx.ColumnDescriptions[c.Name] == "") || wanted[c.Name] {
+			columns = append(columns, c)
+		}
+	}
+
+	table := llm.DescriptionContext{
+		FileName:       df.FileName,
+		BusinessDomain: ctx.BusinessDomain,
+		DatasetPurpose: ctx.DatasetPurpose,
+		Language:       service.LocaleLanguage(locale),
+	}
+	llmCtx, truncation := llm.WithTruncationReport(r.Context())
+	descriptions, err := h.LLMService.SuggestColumnDescriptions(llmCtx, table, columns)
+	if err != nil {
+		apierr.Write(w, apierr.Upstream(fmt.Sprintf("Error suggesting column descriptions: %v", err)))
+		return
+	}
+	// Only the columns asked about, in case the LLM strays
+	suggestions := map[string]string{}
+	for _, c := range columns {
+		if desc := strings.TrimSpace(descriptions[c.Name]); desc != "" {
+			suggestions[c.Name] = desc
+		}
+	}
+	audit.Annotate(r.Context(), "file_index", fileIndex)
+	audit.Annotate(r.Context(), "columns", len(columns))
+	auditTruncation(r, truncation)
+
+	unsuggested := []string{}
+	for _, c := range columns {
+		if _, ok := suggestions[c.Name]; !ok {
+			unsuggested = append(unsuggested, c.Name)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":             true,
+		"file_index":          fileIndex,
+		"suggestions":         suggestions,
+		"unsuggested_columns": unsuggested,
+		"truncation":          truncation,
+	})
+}
+
+// AcceptColumnDescriptions handles POST /api/v1/context/{fileIndex}/descriptions
+// Stores column descriptions in bulk, typically accepted suggestions,
+// replacing the descriptions of those columns and keeping the others
+func (h *Handler) AcceptColumnDescriptions(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	var req acceptDescriptionsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+
+	question := service.ContextFieldQuestions(fileIndex, df.Headers, nil, nil, service.DefaultLocale)["column_descriptions"]
+	if errs := service.ValidateAnswer("descriptions", question, req.Descriptions, df.Headers, nil, nil); len(errs) > 0 {
+		apierr.Write(w, apierr.BadRequest("Invalid column descriptions").WithDetail("errors", errs))
+		return
+	}
+
+	ctx := state.State.GetContext(fileIndex)
+	if ctx == nil {
+		ctx = models.NewContext()
+	}
+	if err := service.ApplyContextAnswer(ctx, question, req.Descriptions, nil, nil); err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	state.State.SetContext(fileIndex, ctx)
+	audit.Annotate(r.Context(), "file_index", fileIndex)
+	audit.Annotate(r.Context(), "columns", len(req.Descriptions))
+
+	undescribed := []string{}
+	for _, col := range df.Headers {
+		if ctx.ColumnDescriptions[col] == "" {
+			undescribed = append(undescribed, col)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":             true,
+		"file_index":          fileIndex,
+		"accepted":            len(req.Descriptions),
+		"context":             ctx,
+		"undescribed_columns": undescribed,
+	})
+}
@@ -0,0 +1,205 @@
+package api
+
+import (
+	"archive/zip"
+	"backend-go/internal/api/apierr"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// maxDecompressedSize caps the size of a file extracted from a gzip or zip
+// upload, so a small archive can't expand without bound on disk (2GB by
+// default)
+func maxDecompressedSize() int64 {
+	return 20 * maxFileSize()
+}
+
+// Compression kinds reported in upload responses
+const (
+	CompressionGzip = "gzip"
+	CompressionZip  = "zip"
+)
+
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zipMagic  = []byte("PK\x03\x04")
+)
+
+// decompressedUpload describes the CSV extracted from an upload
+type decompressedUpload struct {
+	Path        string // Path of the CSV to parse
+	Compression string // CompressionGzip, CompressionZip or "" for plain files
+	Entry       string // Zip entry that was extracted
+}
+
+// archiveEntryError is returned when a zip holds several CSV files and the
+// request did not pick one (or picked one that doesn't exist)
+type archiveEntryError struct {
+	Requested string
+	Entries   []string
+}
+
+func (e *archiveEntryError) Error() string {
+	if e.Requested != "" {
+		return fmt.Sprintf("entry %q not found in archive", e.Requested)
+	}
+	return fmt.Sprintf("archive contains %d CSV files; choose one with the 'entry' field", len(e.Entries))
+}
+
+// isAllowedUploadName accepts .csv files and their .gz / .zip variants
+func isAllowedUploadName(name string) bool {
+	name = strings.ToLower(name)
+	return strings.HasSuffix(name, ".csv") ||
+		strings.HasSuffix(name, ".gz") ||
+		strings.HasSuffix(name, ".zip")
+}
+
+// decompressUpload detects gzip and zip content by magic bytes and extracts
+// the CSV next to the saved upload, removing the archive. Plain files are
+// returned unchanged. entry picks a file inside a multi-entry zip.
+func decompressUpload(filePath, entry string) (decompressedUpload, error) {
+	magic, err := readMagic(filePath, 4)
+	if err != nil {
+		return decompressedUpload{}, err
+	}
+
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		outPath := strings.TrimSuffix(filePath, filepath.Ext(filePath))
+		if !strings.HasSuffix(strings.ToLower(outPath), ".csv") {
+			outPath += ".csv"
+		}
+		if err := gunzipFile(filePath, outPath); err != nil {
+			os.Remove(outPath)
+			return decompressedUpload{}, err
+		}
+		os.Remove(filePath)
+		uploadLog.Info("Decompressed gzip", "file", filepath.Base(filePath))
+		return decompressedUpload{Path: outPath, Compression: CompressionGzip}, nil
+
+	case bytes.HasPrefix(magic, zipMagic):
+		name, outPath, err := unzipEntry(filePath, entry)
+		if err != nil {
+			os.Remove(outPath)
+			return decompressedUpload{}, err
+		}
+		os.Remove(filePath)
+		uploadLog.Info("Extracted archive entry", "entry", name, "file", filepath.Base(filePath))
+		return decompressedUpload{Path: outPath, Compression: CompressionZip, Entry: name}, nil
+	}
+
+	return decompressedUpload{Path: filePath}, nil
+}
+
+func readMagic(filePath string, n int) ([]byte, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(file, buf)
+	if err != nil && err != io.ErrUnexpectedEOF && err != io.EOF {
+		return nil, err
+	}
+	return buf[:read], nil
+}
+
+func gunzipFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	gz, err := gzip.NewReader(in)
+	if err != nil {
+		return fmt.Errorf("invalid gzip file: %v", err)
+	}
+	defer gz.Close()
+
+	return copyLimited(dst, gz)
+}
+
+// unzipEntry extracts one CSV entry from a zip archive. Without an explicit
+// entry the archive must contain exactly one CSV file.
+func unzipEntry(src, entry string) (string, string, error) {
+	archive, err := zip.OpenReader(src)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid zip file: %v", err)
+	}
+	defer archive.Close()
+
+	var candidates []*zip.File
+	names := []string{}
+	for _, f := range archive.File {
+		if f.FileInfo().IsDir() || strings.HasPrefix(f.Name, "__MACOSX/") {
+			continue
+		}
+		if entry != "" && f.Name == entry {
+			candidates = []*zip.File{f}
+			names = nil
+			break
+		}
+		if strings.HasSuffix(strings.ToLower(f.Name), ".csv") {
+			candidates = append(candidates, f)
+			names = append(names, f.Name)
+		}
+	}
+
+	if entry != "" && names != nil {
+		return "", "", &archiveEntryError{Requested: entry, Entries: names}
+	}
+	if len(candidates) != 1 {
+		if len(candidates) == 0 {
+			return "", "", fmt.Errorf("archive contains no CSV files")
+		}
+		return "", "", &archiveEntryError{Entries: names}
+	}
+
+	f := candidates[0]
+	// Only the base name is used, so entry paths can't escape the upload dir
+	outPath := strings.TrimSuffix(src, filepath.Ext(src)) + "_" + filepath.Base(f.Name)
+
+	rc, err := f.Open()
+	if err != nil {
+		return "", "", err
+	}
+	defer rc.Close()
+
+	return f.Name, outPath, copyLimited(outPath, rc)
+}
+
+// copyLimited writes r to dst, failing once maxDecompressedSize is exceeded
+func copyLimited(dst string, r io.Reader) error {
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	limit := maxDecompressedSize()
+	n, err := io.Copy(out, io.LimitReader(r, limit+1))
+	if err != nil {
+		return fmt.Errorf("failed to decompress: %v", err)
+	}
+	if n > limit {
+		return fmt.Errorf("decompressed file exceeds %d MB", limit>>20)
+	}
+	return out.Close()
+}
+
+// decompressError reports a failed extraction; ambiguous archives list
+// their CSV entries so the client can retry with one selected
+func decompressError(err error) *apierr.Error {
+	if entryErr, ok := err.(*archiveEntryError); ok {
+		return apierr.BadRequest(entryErr.Error()).WithDetail("entries", entryErr.Entries)
+	}
+	return apierr.BadRequest(err.Error())
+}
@@ -0,0 +1,68 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/llm"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Context Drafts
+// ============================================================================
+
+// DraftContext handles POST /api/v1/context/{fileIndex}/auto
+// Asks the LLM to draft the whole context of a file (purpose, domain, key
+// entities and column descriptions) from its schema and masked sample
+// values. The draft is returned for review and not stored; POST it to
+// /context/{fileIndex} once edited.
+func (h *Handler) DraftContext(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+	if h.LLMService == nil {
+		apierr.Write(w, apierr.Upstream("LLM service not configured"))
+		return
+	}
+
+	columns := h.EnhancedSimilarityService.ContextDraftColumns(df)
+	llmCtx, truncation := llm.WithTruncationReport(r.Context())
+	draft, err := h.LLMService.DraftContext(llmCtx, df.FileName, len(df.Rows), columns)
+	if err != nil {
+		apierr.Write(w, apierr.Upstream(fmt.Sprintf("Error drafting context: %v", err)))
+		return
+	}
+	ctx := service.ContextFromDraft(draft, df)
+	audit.Annotate(r.Context(), "file_index", fileIndex)
+	auditTruncation(r, truncation)
+
+	undescribed := []string{}
+	for _, col := range df.Headers {
+		if _, ok := ctx.ColumnDescriptions[col]; !ok {
+			undescribed = append(undescribed, col)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":             true,
+		"file_index":          fileIndex,
+		"draft":               ctx,
+		"undescribed_columns": undescribed,
+		"truncation":          truncation,
+	})
+}
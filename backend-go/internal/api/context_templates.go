@@ -0,0 +1,88 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Context Templates
+// ============================================================================
+
+// applyContextTemplateRequest is the body of POST /context/templates/{id}/apply
+type applyContextTemplateRequest struct {
+	FileIndex int   `json:"file_index"`
+	Synonyms  *bool `json:"synonyms"` // Import the synonym pack into the glossary (default true)
+}
+
+// ListContextTemplates handles GET /api/v1/context/templates
+// Built-in templates without their synonym packs
+func (h *Handler) ListContextTemplates(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"templates": service.ContextTemplates(),
+	})
+}
+
+// GetContextTemplate handles GET /api/v1/context/templates/{id}
+// Returns a template with its synonym pack
+func (h *Handler) GetContextTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, ok := service.GetContextTemplate(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Context template not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(tmpl)
+}
+
+// ApplyContextTemplate handles POST /api/v1/context/templates/{id}/apply
+// Pre-fills a file's context from the template, keeping answers already
+// given, and imports the template's synonym pack into the glossary.
+func (h *Handler) ApplyContextTemplate(w http.ResponseWriter, r *http.Request) {
+	tmpl, ok := service.GetContextTemplate(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Context template not found"))
+		return
+	}
+
+	var req applyContextTemplateRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.FileIndex != 1 && req.FileIndex != 2 {
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
+		return
+	}
+
+	imported := 0
+	if req.Synonyms == nil || *req.Synonyms {
+		var err error
+		if imported, err = service.GetGlossary().Import(tmpl.Synonyms, false); err != nil {
+			apierr.Write(w, apierr.Conflict(fmt.Sprintf("Synonym pack clashes with the glossary: %v", err)))
+			return
+		}
+	}
+
+	ctx := tmpl.ApplyTo(state.State.GetContext(req.FileIndex))
+	state.State.SetContext(req.FileIndex, ctx)
+	audit.Annotate(r.Context(), "template", tmpl.ID)
+	audit.Annotate(r.Context(), "file_index", req.FileIndex)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":           true,
+		"message":           fmt.Sprintf("Template %s applied to File %d", tmpl.Name, req.FileIndex),
+		"context":           ctx,
+		"synonyms_imported": imported,
+	})
+}
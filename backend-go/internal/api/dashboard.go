@@ -0,0 +1,175 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/models"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// ============================================================================
+// Dashboard
+// ============================================================================
+
+// Dashboard limits and red-flag thresholds
+const (
+	dashboardTopMatches      = 5
+	dashboardTopCorrelations = 5
+	redFlagNullRate          = 0.3  // Columns missing at least this share of values
+	redFlagOutlierRate       = 0.05 // Numeric columns with at least this share outside the IQR fences
+)
+
+// DashboardFile summarizes one loaded file for the landing screen
+type DashboardFile struct {
+	models.FileStatus
+	HasContext        bool    `json:"has_context"`
+	ContextCompletion float64 `json:"context_completion"` // 0-1 share of the context filled in
+	DescribedColumns  int     `json:"described_columns"`
+}
+
+// QualityRedFlag is a data quality problem worth surfacing on the dashboard
+type QualityRedFlag struct {
+	FileIndex int    `json:"file_index"`
+	Column    string `json:"column"`
+	Issue     string `json:"issue"` // high_null_rate, constant, outliers or failed_<check>
+	Severity  string `json:"severity"`
+	Detail    string `json:"detail"`
+}
+
+// GetDashboard handles GET /api/v1/dashboard
+// Everything the landing screen needs in one payload: file statuses, context
+// completion, top matches and correlations, quality red flags and learning stats.
+// Sections needing both files are empty until both are loaded.
+func (h *Handler) GetDashboard(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	files := map[string]DashboardFile{}
+	redFlags := []QualityRedFlag{}
+	for i, df := range []*state.DataFrame{df1, df2} {
+		fileIndex := i + 1
+		files[fmt.Sprintf("file%d", fileIndex)] = dashboardFile(df, state.State.GetContext(fileIndex))
+		if df != nil {
+			redFlags = append(redFlags, qualityRedFlags(df, fileIndex)...)
+		}
+	}
+
+	matches := []service.SimilarityResult{}
+	correlations := []CorrelationItem{}
+	if df1 != nil && df2 != nil {
+		results, _, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarityWithOptions(
+			r.Context(), df1, df2, state.State.GetContext(1), state.State.GetContext(2), service.DefaultScoringOptions())
+		if err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+			return
+		}
+		if len(results) > dashboardTopMatches {
+			results = results[:dashboardTopMatches]
+		}
+		matches = append(matches, results...)
+
+		correlations = crossFileCorrelations(df1, df2)
+		if len(correlations) > dashboardTopCorrelations {
+			correlations = correlations[:dashboardTopCorrelations]
+		}
+	}
+
+	calibration := service.GetConfidenceCalibrator().GetCalibrationStats()
+	patterns := service.GetPatternLearner()
+	learning := map[string]interface{}{
+		"feedback":    service.GetFeedbackSystem().GetStats(),
+		"convergence": service.GetAdaptiveLearner().GetConvergenceStats(),
+		"calibration": map[string]interface{}{
+			"total_samples":    calibration["total_samples"],
+			"overall_accuracy": calibration["overall_accuracy"],
+		},
+		"pattern_rules":  len(patterns.GetPatterns()),
+		"token_mappings": len(patterns.GetTokenMappings()),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"files":            files,
+		"top_matches":      matches,
+		"top_correlations": correlations,
+		"red_flags":        redFlags,
+		"learning":         learning,
+	})
+}
+
+// dashboardFile reports a file's size and how much of its context is filled
+// in: purpose, domain, key entities and the share of columns described count
+// equally
+func dashboardFile(df *state.DataFrame, ctx *models.Context) DashboardFile {
+	file := DashboardFile{FileStatus: models.FileStatus{Loaded: df != nil}, HasContext: ctx != nil}
+	if df != nil {
+		file.Rows = len(df.Rows)
+		file.Columns = len(df.Headers)
+		file.Filename = df.FileName
+	}
+	if ctx == nil {
+		return file
+	}
+
+	filled := 0.0
+	for _, set := range []bool{
+		strings.TrimSpace(ctx.DatasetPurpose) != "",
+		strings.TrimSpace(ctx.BusinessDomain) != "",
+		len(ctx.KeyEntities) > 0,
+	} {
+		if set {
+			filled++
+		}
+	}
+	if df != nil && len(df.Headers) > 0 {
+		for _, h := range df.Headers {
+			if strings.TrimSpace(ctx.ColumnDescriptions[h]) != "" {
+				file.DescribedColumns++
+			}
+		}
+		filled += float64(file.DescribedColumns) / float64(len(df.Headers))
+	} else if len(ctx.ColumnDescriptions) > 0 {
+		file.DescribedColumns = len(ctx.ColumnDescriptions)
+		filled++
+	}
+	file.ContextCompletion = filled / 4
+	return file
+}
+
+// qualityRedFlags lists the columns of a file with many missing values, a
+// single value, many outliers or failed plausibility checks
+func qualityRedFlags(df *state.DataFrame, fileIndex int) []QualityRedFlag {
+	flags := []QualityRedFlag{}
+	for _, p := range service.NewDataQualityProfiler().ProfileAllColumns(df) {
+		flag := func(issue, severity, detail string) {
+			flags = append(flags, QualityRedFlag{FileIndex: fileIndex, Column: p.ColumnName, Issue: issue, Severity: severity, Detail: detail})
+		}
+		if p.NullRate >= redFlagNullRate {
+			severity := "warning"
+			if p.NullRate >= 0.8 {
+				severity = "critical"
+			}
+			flag("high_null_rate", severity, fmt.Sprintf("%.0f%% of values are missing", p.NullRate*100))
+		}
+		if p.DistinctCount == 1 && p.TotalRows > 1 {
+			flag("constant", "info", "Every non-missing value is the same")
+		}
+		if p.OutlierRate >= redFlagOutlierRate {
+			flag("outliers", "warning", fmt.Sprintf("%.1f%% of values lie outside the IQR fences", p.OutlierRate*100))
+		}
+		for _, c := range p.Plausibility {
+			if !c.Passed {
+				detail := fmt.Sprintf("%s check failed", c.Check)
+				if c.PassRate > 0 {
+					detail = fmt.Sprintf("%s check passed for only %.0f%% of values", c.Check, c.PassRate*100)
+				}
+				flag("failed_"+c.Check, "warning", detail)
+			}
+		}
+	}
+	return flags
+}
@@ -0,0 +1,119 @@
+package api
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// ============================================================================
+// Upload Deduplication
+// ============================================================================
+
+// parsedUpload is the result of parsing an upload, kept to reuse when the
+// same content is uploaded again with the same options
+type parsedUpload struct {
+	df   *state.DataFrame
+	resp models.UploadResponse
+}
+
+// parsedUploads remembers the most recent parses, up to upload.dedup_cache,
+// keyed by parsedUploadKey
+var parsedUploads = struct {
+	sync.Mutex
+	entries map[string]*parsedUpload
+	order   []string // Least recently used first
+}{entries: map[string]*parsedUpload{}}
+
+// hashFile returns the hex SHA-256 of a file's content
+func hashFile(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// parsedUploadKey identifies a parse: the content and the options that
+// change how it is parsed
+func parsedUploadKey(contentHash string, opts uploadOptions) string {
+	o, _ := json.Marshal(opts)
+	return contentHash + " " + string(o)
+}
+
+// cachedUpload returns the parse stored under key, or nil
+func cachedUpload(key string) *parsedUpload {
+	parsedUploads.Lock()
+	defer parsedUploads.Unlock()
+	p, ok := parsedUploads.entries[key]
+	if ok {
+		touchParsedUpload(key)
+	}
+	return p
+}
+
+// cacheUpload stores a parse, evicting the least recently used beyond the
+// configured size
+func cacheUpload(key string, df *state.DataFrame, resp models.UploadResponse) {
+	size := config.Get().Upload.DedupCache
+	if size <= 0 {
+		return
+	}
+	parsedUploads.Lock()
+	defer parsedUploads.Unlock()
+	parsedUploads.entries[key] = &parsedUpload{df: df, resp: resp}
+	touchParsedUpload(key)
+	for len(parsedUploads.order) > size {
+		delete(parsedUploads.entries, parsedUploads.order[0])
+		parsedUploads.order = parsedUploads.order[1:]
+	}
+}
+
+// touchParsedUpload moves key to the most recently used position (must
+// hold the lock)
+func touchParsedUpload(key string) {
+	for i, k := range parsedUploads.order {
+		if k == key {
+			parsedUploads.order = append(parsedUploads.order[:i], parsedUploads.order[i+1:]...)
+			break
+		}
+	}
+	parsedUploads.order = append(parsedUploads.order, key)
+}
+
+// clearParsedUploads drops every cached parse; their typed columns were
+// built with the null tokens and date formats of the time
+func clearParsedUploads() {
+	parsedUploads.Lock()
+	defer parsedUploads.Unlock()
+	parsedUploads.entries = map[string]*parsedUpload{}
+	parsedUploads.order = nil
+}
+
+// forgetParsedUploads drops the parses of the file at path, so deleting an
+// upload frees its memory
+func forgetParsedUploads(path string) {
+	parsedUploads.Lock()
+	defer parsedUploads.Unlock()
+	order := parsedUploads.order[:0]
+	for _, key := range parsedUploads.order {
+		if filepath.Clean(parsedUploads.entries[key].df.FilePath) == filepath.Clean(path) {
+			delete(parsedUploads.entries, key)
+			continue
+		}
+		order = append(order, key)
+	}
+	parsedUploads.order = order
+}
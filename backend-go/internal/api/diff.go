@@ -0,0 +1,122 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ============================================================================
+// Schema Drift
+// ============================================================================
+
+// GetSchemaDiff handles GET /api/v1/diff/schema
+// Treats file 1 as the previous and file 2 as the current version of a dataset
+// and reports added, removed, renamed and retyped columns
+func (h *Handler) GetSchemaDiff(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded"))
+		return
+	}
+
+	diff, err := h.EnhancedSimilarityService.DiffSchema(df1, df2)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error comparing schemas: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
+
+// GetDistributionDiff handles GET /api/v1/diff/distributions
+// Distribution shift (PSI) of every column kept or renamed between file 1 and file 2
+// Query: drift=stable|moderate|major (optional filter)
+func (h *Handler) GetDistributionDiff(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded"))
+		return
+	}
+
+	drift := r.URL.Query().Get("drift")
+	columns, err := h.EnhancedSimilarityService.DiffDistributions(df1, df2)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error comparing distributions: %v", err)))
+		return
+	}
+	counts := map[string]int{}
+	filtered := columns[:0]
+	for _, c := range columns {
+		counts[c.Drift]++
+		if drift == "" || c.Drift == drift {
+			filtered = append(filtered, c)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"columns": filtered,
+		"summary": counts,
+	})
+}
+
+// diffRowsRequest is the body of POST /api/diff/rows
+type diffRowsRequest struct {
+	KeyColumns []string `json:"key_columns"`
+	Kind       string   `json:"kind"` // added, deleted or changed; all when empty
+	Page       int      `json:"page"`
+	PageSize   int      `json:"page_size"`
+}
+
+// DiffRows handles POST /api/v1/diff/rows
+// Reconciles file 1 (old) and file 2 (new) by key and returns a page of
+// added, deleted and changed rows with their field changes
+func (h *Handler) DiffRows(w http.ResponseWriter, r *http.Request) {
+	var req diffRowsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && err != io.EOF {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.Kind != "" && req.Kind != service.RowAdded && req.Kind != service.RowDeleted && req.Kind != service.RowChanged {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown kind %q (use added, deleted or changed)", req.Kind)))
+		return
+	}
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 500 {
+		req.PageSize = 50
+	}
+
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded"))
+		return
+	}
+
+	diff, err := service.DiffRows(df1, df2, req.KeyColumns, req.Kind, (req.Page-1)*req.PageSize, req.PageSize)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"key_columns": diff.KeyColumns,
+		"columns":     diff.Columns,
+		"summary":     diff.Summary,
+		"rows":        diff.Rows,
+		"total":       diff.Total,
+		"page":        req.Page,
+		"page_size":   req.PageSize,
+	})
+}
@@ -0,0 +1,120 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/service"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Business Glossary
+// ============================================================================
+
+// maxGlossaryImportSize caps the body of a glossary import
+const maxGlossaryImportSize = 10 << 20
+
+// glossaryImport is the JSON body of a glossary import
+type glossaryImport struct {
+	Terms []service.GlossaryTerm `json:"terms"`
+}
+
+// ListGlossary handles GET /api/v1/glossary
+func (h *Handler) ListGlossary(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"terms": service.GetGlossary().List(),
+	})
+}
+
+// ImportGlossary handles POST /api/v1/glossary/import
+// Adds business terms from a JSON body, {"terms": [...]} or a bare array, or
+// from CSV with a term, definition, synonyms and steward header.
+// Query: format=json|csv (default: from the Content-Type), replace=true
+// to drop the terms not imported
+func (h *Handler) ImportGlossary(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "json"
+		if strings.Contains(r.Header.Get("Content-Type"), "csv") {
+			format = "csv"
+		}
+	}
+	body := io.LimitReader(r.Body, maxGlossaryImportSize)
+
+	var terms []service.GlossaryTerm
+	switch format {
+	case "csv":
+		var err error
+		if terms, err = service.ParseGlossaryCSV(body); err != nil {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Invalid glossary CSV: %v", err)))
+			return
+		}
+	case "json":
+		data, err := io.ReadAll(body)
+		if err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		if trimmed := strings.TrimSpace(string(data)); strings.HasPrefix(trimmed, "[") {
+			err = json.Unmarshal(data, &terms)
+		} else {
+			var req glossaryImport
+			err = json.Unmarshal(data, &req)
+			terms = req.Terms
+		}
+		if err != nil {
+			apierr.Write(w, apierr.InvalidJSON(err))
+			return
+		}
+	default:
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use json or csv)", format)))
+		return
+	}
+
+	imported, err := service.GetGlossary().Import(terms, r.URL.Query().Get("replace") == "true")
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "terms", imported)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"imported": imported,
+		"terms":    service.GetGlossary().List(),
+	})
+}
+
+// GetGlossaryTerm handles GET /api/v1/glossary/{term}
+func (h *Handler) GetGlossaryTerm(w http.ResponseWriter, r *http.Request) {
+	term, ok := service.GetGlossary().Get(chi.URLParam(r, "term"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Term not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(term)
+}
+
+// DeleteGlossaryTerm handles DELETE /api/v1/glossary/{term}
+func (h *Handler) DeleteGlossaryTerm(w http.ResponseWriter, r *http.Request) {
+	term := chi.URLParam(r, "term")
+	if err := service.GetGlossary().Delete(term); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "term", term)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
@@ -2,16 +2,24 @@ package api
 
 import (
 	"backend-go/internal/analysis"
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/auth"
+	"backend-go/internal/config"
+	"backend-go/internal/dateformat"
 	"backend-go/internal/llm"
+	"backend-go/internal/logging"
 	"backend-go/internal/models"
 	"backend-go/internal/service"
 	"backend-go/internal/state"
+	"backend-go/internal/textnorm"
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"io"
-	"log"
 	"math"
+	"math/rand"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -23,11 +31,25 @@ import (
 	"github.com/go-chi/chi/v5"
 )
 
-const (
-	UploadDir   = "./uploads"
-	MaxFileSize = 100 * 1024 * 1024 // 100MB
+// Loggers of the handlers
+var (
+	uploadLog     = logging.Component("upload")
+	similarityLog = logging.Component("similarity")
+	queryLog      = logging.Component("query")
+	exportLog     = logging.Component("export")
+	configLog     = logging.Component("config")
 )
 
+// uploadDir is where uploaded files are stored (upload.dir)
+func uploadDir() string {
+	return config.Get().Upload.Dir
+}
+
+// maxFileSize caps multipart uploads, in bytes (upload.max_size_mb)
+func maxFileSize() int64 {
+	return config.Get().Upload.MaxSize()
+}
+
 type Handler struct {
 	ContextService            *service.ContextService
 	QuestionGenerator         *service.QuestionGenerator
@@ -36,12 +58,17 @@ type Handler struct {
 	ExportService             *service.ExportService
 	EnhancedSimilarityService *service.EnhancedSimilarityService
 	AISemanticMatcher         *service.AISemanticMatcher
+	EnsembleMatcher           *service.EnsembleMatcher
 	LLMService                *llm.Service
 	CurrentDB                 service.DataSource // Active DB connection
+
+	routes  chi.Routes           // Router the routes are registered on, described by /openapi.json
+	aliases map[string]string    // Deprecated legacy routes -> their /api/v1 successors
+	roles   map[string]auth.Role // Routes -> the role they need
 }
 
 func NewHandler(ctx *service.ContextService, qg *service.QuestionGenerator, csv *analysis.CSVService, sim *service.SimilarityService, export *service.ExportService, llmSvc *llm.Service) *Handler {
-	return &Handler{
+	h := &Handler{
 		ContextService:            ctx,
 		QuestionGenerator:         qg,
 		CSVService:                csv,
@@ -51,75 +78,27 @@ func NewHandler(ctx *service.ContextService, qg *service.QuestionGenerator, csv
 		AISemanticMatcher:         service.NewAISemanticMatcher(llmSvc, ctx),
 		LLMService:                llmSvc,
 	}
-}
-
-func (h *Handler) RegisterRoutes(r chi.Router) {
-	// API V2 Routes (My Migration)
-	r.Get("/health", h.HealthCheck)
-	r.Post("/api/analyze-file", h.AnalyzeFile)
-	r.Post("/api/context/{fileIndex}", h.StoreContext)
-	r.Get("/api/questions/{fileIndex}", h.GetQuestions)
-	r.Get("/api/similarity/graph", h.GetSimilarityGraph)
-	r.Post("/api/export/sql", h.ExportSQL)
-	r.Post("/api/export/python", h.ExportPython)
-	r.Get("/api/status", h.GetAnalysisStatus)
-	r.Get("/api/context/status", h.GetAnalysisContextStatus)
-
-	// DB Routes
-	r.Post("/api/db/connect", h.ConnectDB)
-	r.Get("/api/db/tables", h.ListTables)
-	r.Post("/api/db/analyze", h.AnalyzeTable)
-
-	// Upstream/Legacy Routes
-	r.Post("/upload", h.Upload)
-	r.Get("/status", h.GetStatus)
-	r.Get("/preview", h.GetPreview)
-	r.Get("/column-types", h.GetColumnTypes)
-	r.Get("/kpis", h.GetKPIs)
-
-	r.Get("/column-similarity", h.GetColumnSimilarity)
-	r.Get("/correlation", h.GetCorrelation)
-	r.Post("/filter", h.FilterData)
-	r.Post("/query", h.Query)
-
-	r.Post("/context/questions", h.GenerateContextQuestions)
-	r.Post("/context/submit", h.SubmitContext)
-	r.Get("/context/{fileIndex}", h.GetContext)
-	r.Delete("/context/{fileIndex}", h.DeleteContext)
-	r.Get("/context/status", h.GetContextStatus)
-
-	r.Get("/config/ollama", h.GetOllamaConfig)
-	r.Post("/config/ollama", h.SaveOllamaConfig)
-
-	r.Post("/feedback/match", h.SubmitMatchFeedback)
-	r.Get("/feedback/stats", h.GetFeedbackStats)
-}
-
-// ============================================================================
-// Health
-// ============================================================================
-
-func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
-	w.Write([]byte("OK"))
+	h.EnsembleMatcher = service.NewEnsembleMatcher(h.EnhancedSimilarityService, service.NewEmbeddingMatcher(llmSvc), h.AISemanticMatcher)
+	return h
 }
 
 // ConnectDB establishes a database connection
 func (h *Handler) ConnectDB(w http.ResponseWriter, r *http.Request) {
 	var config service.DataSourceConfig
 	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
 	// Currently only Postgres supported
 	if config.Type != "postgres" {
-		http.Error(w, "Only postgres is supported currently", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("Only postgres is supported currently"))
 		return
 	}
 
 	ds := &service.PostgresDataSource{}
-	if err := ds.Connect(config); err != nil {
-		http.Error(w, fmt.Sprintf("Failed to connect: %v", err), http.StatusInternalServerError)
+	if err := ds.Connect(r.Context(), config); err != nil {
+		apierr.Write(w, apierr.Upstream(fmt.Sprintf("Failed to connect: %v", err)))
 		return
 	}
 
@@ -135,13 +114,13 @@ func (h *Handler) ConnectDB(w http.ResponseWriter, r *http.Request) {
 // ListTables returns tables from connected DB
 func (h *Handler) ListTables(w http.ResponseWriter, r *http.Request) {
 	if h.CurrentDB == nil {
-		http.Error(w, "No database connection", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("No database connection"))
 		return
 	}
 
-	tables, err := h.CurrentDB.ListTables()
+	tables, err := h.CurrentDB.ListTables(r.Context())
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error listing tables: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, apierr.Upstream(fmt.Sprintf("Error listing tables: %v", err)))
 		return
 	}
 
@@ -151,7 +130,7 @@ func (h *Handler) ListTables(w http.ResponseWriter, r *http.Request) {
 // AnalyzeTable fetches data from a table and analyzes it
 func (h *Handler) AnalyzeTable(w http.ResponseWriter, r *http.Request) {
 	if h.CurrentDB == nil {
-		http.Error(w, "No database connection", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("No database connection"))
 		return
 	}
 
@@ -160,20 +139,20 @@ func (h *Handler) AnalyzeTable(w http.ResponseWriter, r *http.Request) {
 		FileIndex int    `json:"file_index"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
 	// Fetch data (preview limit 1000 rows for analysis)
-	data, err := h.CurrentDB.PreviewData(req.TableName, 1000)
+	data, err := h.CurrentDB.PreviewData(r.Context(), req.TableName, 1000)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error fetching data: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, apierr.Upstream(fmt.Sprintf("Error fetching data: %v", err)))
 		return
 	}
 
 	// Analyze
 	if len(data) == 0 {
-		http.Error(w, "Table is empty", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("Table is empty"))
 		return
 	}
 
@@ -184,7 +163,7 @@ func (h *Handler) AnalyzeTable(w http.ResponseWriter, r *http.Request) {
 
 	analysisResult, err := h.CSVService.AnalyzeData(data, columns)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error analyzing data: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error analyzing data: %v", err)))
 		return
 	}
 
@@ -234,7 +213,7 @@ func (h *Handler) AnalyzeFile(w http.ResponseWriter, r *http.Request) {
 
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, "Error retrieving file", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("Error retrieving file"))
 		return
 	}
 	defer file.Close()
@@ -244,21 +223,30 @@ func (h *Handler) AnalyzeFile(w http.ResponseWriter, r *http.Request) {
 	tempFilePath := filepath.Join(tempDir, header.Filename)
 	tempFile, err := os.Create(tempFilePath)
 	if err != nil {
-		http.Error(w, "Error creating temp file", http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal("Error creating temp file"))
 		return
 	}
 	defer os.Remove(tempFilePath) // Clean up
 	defer tempFile.Close()
 
 	if _, err := io.Copy(tempFile, file); err != nil {
-		http.Error(w, "Error saving file", http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal("Error saving file"))
 		return
 	}
+	tempFile.Close()
+
+	// Extract gzip / zip uploads
+	upload, err := decompressUpload(tempFilePath, r.FormValue("entry"))
+	if err != nil {
+		apierr.Write(w, decompressError(err))
+		return
+	}
+	defer os.Remove(upload.Path)
 
 	// Analyze the file
-	analysisResult, err := h.CSVService.AnalyzeFile(tempFilePath)
+	analysisResult, err := h.CSVService.AnalyzeFile(upload.Path)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error analyzing file: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error analyzing file: %v", err)))
 		return
 	}
 
@@ -279,9 +267,9 @@ func (h *Handler) AnalyzeFile(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
-	// Parse multipart form (max 100MB)
-	if err := r.ParseMultipartForm(MaxFileSize); err != nil {
-		http.Error(w, "File too large", http.StatusBadRequest)
+	// Parse multipart form
+	if err := r.ParseMultipartForm(maxFileSize()); err != nil {
+		apierr.Write(w, apierr.BadRequest("File too large"))
 		return
 	}
 
@@ -292,103 +280,267 @@ func (h *Handler) Upload(w http.ResponseWriter, r *http.Request) {
 	}
 	fileIndex, err := strconv.Atoi(fileIndexStr)
 	if err != nil || (fileIndex != 1 && fileIndex != 2) {
-		http.Error(w, "file_index must be 1 or 2", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
 		return
 	}
 
 	// Get file from form
 	file, header, err := r.FormFile("file")
 	if err != nil {
-		http.Error(w, "No file uploaded", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("No file uploaded"))
 		return
 	}
 	defer file.Close()
 
 	// Validate file extension
-	if !strings.HasSuffix(strings.ToLower(header.Filename), ".csv") {
-		http.Error(w, "Only CSV files are allowed", http.StatusBadRequest)
+	if !isAllowedUploadName(header.Filename) {
+		apierr.Write(w, apierr.BadRequest("Only CSV files (optionally .gz or .zip compressed) are allowed"))
 		return
 	}
 
 	// Create upload directory
-	os.MkdirAll(UploadDir, 0755)
+	os.MkdirAll(uploadDir(), 0755)
 
 	// Save file
 	filename := fmt.Sprintf("file%d_%s", fileIndex, filepath.Base(header.Filename))
-	filePath := filepath.Join(UploadDir, filename)
+	filePath := filepath.Join(uploadDir(), filename)
 
 	dst, err := os.Create(filePath)
 	if err != nil {
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal("Failed to save file"))
 		return
 	}
 	defer dst.Close()
 
 	if _, err := io.Copy(dst, file); err != nil {
-		http.Error(w, "Failed to save file", http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal("Failed to save file"))
 		return
 	}
+	dst.Close()
 
-	// Parse CSV
-	df, err := parseCSVFile(filePath)
+	opts, err := uploadOptionsFromForm(r)
 	if err != nil {
 		os.Remove(filePath)
-		http.Error(w, fmt.Sprintf("Failed to parse CSV: %v", err), http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest(err.Error()))
 		return
 	}
-	df.FileName = header.Filename
-	df.FilePath = filePath
 
-	// Store in state
-	state.State.SetDataFrame(fileIndex, df)
+	resp := registerUpload(w, fileIndex, filePath, header.Filename, opts)
+	if resp == nil {
+		return
+	}
+	uploadLog.InfoContext(r.Context(), "File uploaded",
+		"file_index", fileIndex, "file", header.Filename, "rows", resp.Rows, "columns", resp.Columns)
+	auditUpload(r, fileIndex, header.Filename, resp)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// uploadOptions are the optional parsing overrides of an upload
+type uploadOptions struct {
+	Entry     string `json:"entry"`      // File to extract from a multi-entry zip
+	Delimiter string `json:"delimiter"`  // Overrides the sniffed delimiter
+	Quote     string `json:"quote"`      // Overrides the sniffed quote character
+	HasHeader *bool  `json:"has_header"` // Overrides header detection; nil means auto
+}
+
+func uploadOptionsFromForm(r *http.Request) (uploadOptions, error) {
+	opts := uploadOptions{
+		Entry:     r.FormValue("entry"),
+		Delimiter: r.FormValue("delimiter"),
+		Quote:     r.FormValue("quote"),
+	}
+	if v := r.FormValue("has_header"); v != "" && v != "auto" {
+		hasHeader, err := strconv.ParseBool(v)
+		if err != nil {
+			return opts, fmt.Errorf("has_header must be true, false or auto")
+		}
+		opts.HasHeader = &hasHeader
+	}
+	return opts, nil
+}
+
+// registerUpload turns a file saved in the upload directory into the DataFrame for
+// fileIndex, parsing it with parseUploadFile unless the content was parsed before.
+// On failure it writes the error response, removes the file and returns nil.
+func registerUpload(w http.ResponseWriter, fileIndex int, filePath, fileName string, opts uploadOptions) *models.UploadResponse {
+	// Content parsed before with the same options reuses that parse
+	contentHash, err := hashFile(filePath)
+	if err != nil {
+		os.Remove(filePath)
+		apierr.Write(w, apierr.Internal("Failed to read file"))
+		return nil
+	}
+	cacheKey := parsedUploadKey(contentHash, opts)
+	if cached := cachedUpload(cacheKey); cached != nil {
+		df := cached.df.Copy()
+		df.FilePath = filePath
+		df.FileName = fileName
+		if cached.resp.Entry != "" {
+			df.FileName = filepath.Base(cached.resp.Entry)
+		}
+		loadDataFrame(fileIndex, df)
+
+		resp := cached.resp
+		resp.Message = fmt.Sprintf("File '%s' uploaded successfully", df.FileName)
+		resp.Deduplicated = true
+		return &resp
+	}
+
+	df, resp, apiErr := parseUploadFile(filePath, fileName, opts)
+	if apiErr != nil {
+		apierr.Write(w, apiErr)
+		return nil
+	}
+	resp.ContentHash = contentHash
+	loadDataFrame(fileIndex, df)
+	cacheUpload(cacheKey, df.Copy(), *resp)
+	return resp
+}
 
-	// Return response
-	resp := models.UploadResponse{
-		Message:     fmt.Sprintf("File '%s' uploaded successfully", header.Filename),
+// parseUploadFile turns a saved file into a DataFrame: it decompresses,
+// transcodes, sniffs the dialect and parses it. On failure it removes the
+// file.
+func parseUploadFile(filePath, fileName string, opts uploadOptions) (*state.DataFrame, *models.UploadResponse, *apierr.Error) {
+	// Extract gzip / zip uploads; a multi-entry zip needs the 'entry' field
+	upload, err := decompressUpload(filePath, opts.Entry)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, nil, decompressError(err)
+	}
+	filePath = upload.Path
+	displayName := fileName
+	if upload.Entry != "" {
+		displayName = filepath.Base(upload.Entry)
+	}
+
+	// Transcode legacy encodings (UTF-16, Windows-1252) to UTF-8 before parsing
+	encoding, err := transcodeToUTF8(filePath)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, nil, apierr.Internal("Failed to read file")
+	}
+
+	// Detect the dialect, then apply any overrides
+	dialect, err := analysis.SniffFile(filePath)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, nil, apierr.Internal("Failed to read file")
+	}
+	if err := applyDialectOverrides(opts, &dialect); err != nil {
+		os.Remove(filePath)
+		return nil, nil, apierr.BadRequest(err.Error())
+	}
+
+	// Parse CSV
+	df, err := parseCSVFile(filePath, dialect)
+	if err != nil {
+		os.Remove(filePath)
+		return nil, nil, apierr.ParseError(fmt.Sprintf("Failed to parse CSV: %v", err))
+	}
+	df.FileName = displayName
+	df.FilePath = filePath
+	df.Encoding = encoding
+	df.BuildColumns()
+
+	resp := &models.UploadResponse{
+		Message:     fmt.Sprintf("File '%s' uploaded successfully", displayName),
 		Rows:        len(df.Rows),
 		Columns:     len(df.Headers),
 		ColumnNames: df.Headers,
+		Encoding:    encoding,
+		Dialect:     dialect,
+		Compression: upload.Compression,
+		Entry:       upload.Entry,
 	}
+	return df, resp, nil
+}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+// loadDataFrame stores df as the file at fileIndex and reruns the saved
+// queries watching it
+func loadDataFrame(fileIndex int, df *state.DataFrame) {
+	// Drop cached column profiles of the file being replaced, unless it has
+	// the same content
+	if old := state.State.GetDataFrame(fileIndex); old != nil && old.ContentHash() != df.ContentHash() {
+		service.GetColumnProfileCache().InvalidateDataFrame(old)
+	}
+
+	state.State.SetDataFrame(fileIndex, df)
+	service.GetSavedQueryStore().RunForUpload(fileIndex)
+}
+
+// transcodeToUTF8 detects the encoding of a saved upload and rewrites it as
+// UTF-8 when needed, returning the detected encoding
+func transcodeToUTF8(filePath string) (string, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return "", err
+	}
+
+	converted, encoding := textnorm.ToUTF8(data)
+	if encoding != textnorm.EncodingUTF8 {
+		uploadLog.Info("Transcoding to UTF-8", "file", filepath.Base(filePath), "encoding", encoding)
+		if err := os.WriteFile(filePath, converted, 0644); err != nil {
+			return "", err
+		}
+	}
+	return encoding, nil
+}
+
+// applyDialectOverrides applies the optional delimiter and quote overrides
+func applyDialectOverrides(opts uploadOptions, dialect *models.CSVDialect) error {
+	if v := opts.Delimiter; v != "" {
+		delimiter, err := analysis.ParseDelimiter(v)
+		if err != nil {
+			return err
+		}
+		dialect.Delimiter = delimiter
+		dialect.Source = "override"
+	}
+	if v := opts.Quote; v != "" {
+		quote, err := analysis.ParseQuote(v)
+		if err != nil {
+			return err
+		}
+		dialect.Quote = quote
+		dialect.Source = "override"
+	}
+	if opts.HasHeader != nil {
+		dialect.HasHeader = *opts.HasHeader
+		dialect.Source = "override"
+	}
+	return nil
 }
 
-func parseCSVFile(filePath string) (*state.DataFrame, error) {
+func parseCSVFile(filePath string, dialect models.CSVDialect) (*state.DataFrame, error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer file.Close()
 
-	reader := csv.NewReader(file)
-	reader.FieldsPerRecord = -1 // Allow variable fields
-	reader.LazyQuotes = true    // Allow bare quotes in non-quoted fields
-	reader.TrimLeadingSpace = true
+	reader := analysis.NewRecordReader(file, dialect)
 
-	// Try to read headers
-	headers, err := reader.Read()
+	// Read headers
+	first, err := reader.Read()
 	if err != nil {
-		// Try with semicolon separator
-		file.Seek(0, 0)
-		reader = csv.NewReader(file)
-		reader.Comma = ';'
-		reader.FieldsPerRecord = -1
-		reader.LazyQuotes = true
-		reader.TrimLeadingSpace = true
-		headers, err = reader.Read()
-		if err != nil {
-			return nil, fmt.Errorf("failed to read headers: %v", err)
-		}
+		return nil, fmt.Errorf("failed to read headers: %v", err)
 	}
 
-	// Clean headers
-	for i, h := range headers {
-		headers[i] = strings.TrimSpace(h)
+	// Without a header row the first record is data
+	var headers []string
+	rows := [][]string{}
+	if dialect.HasHeader {
+		headers = first
+		for i, h := range headers {
+			headers[i] = strings.TrimSpace(h)
+		}
+	} else {
+		rows = append(rows, first)
 	}
 
 	// Read all rows
-	rows := [][]string{}
 	for {
 		record, err := reader.Read()
 		if err == io.EOF {
@@ -401,6 +553,16 @@ func parseCSVFile(filePath string) (*state.DataFrame, error) {
 		rows = append(rows, record)
 	}
 
+	if !dialect.HasHeader {
+		width := 0
+		for _, row := range rows {
+			if len(row) > width {
+				width = len(row)
+			}
+		}
+		headers = syntheticHeaders(width)
+	}
+
 	return &state.DataFrame{
 		Headers:  headers,
 		Rows:     rows,
@@ -408,6 +570,56 @@ func parseCSVFile(filePath string) (*state.DataFrame, error) {
 	}, nil
 }
 
+// syntheticHeaders names the columns of a header-less file col_1..col_n
+func syntheticHeaders(n int) []string {
+	headers := make([]string, n)
+	for i := range headers {
+		headers[i] = fmt.Sprintf("col_%d", i+1)
+	}
+	return headers
+}
+
+// RenameColumns handles POST /api/v1/columns/rename
+// Renames columns of a loaded file, e.g. the synthetic col_N names of a
+// header-less upload
+func (h *Handler) RenameColumns(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileIndex int               `json:"file_index"`
+		Renames   map[string]string `json:"renames"` // Old name -> new name
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.FileIndex != 1 && req.FileIndex != 2 {
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
+		return
+	}
+	if len(req.Renames) == 0 {
+		apierr.Write(w, apierr.BadRequest("renames is required"))
+		return
+	}
+
+	df := state.State.GetDataFrame(req.FileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("File %d not loaded", req.FileIndex)))
+		return
+	}
+
+	// Profiles are cached by content hash, which includes the headers
+	service.GetColumnProfileCache().InvalidateDataFrame(df)
+	if err := df.RenameColumns(req.Renames); err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file_index":   req.FileIndex,
+		"column_names": df.Headers,
+	})
+}
+
 // ============================================================================
 // Status
 // ============================================================================
@@ -446,39 +658,138 @@ func (h *Handler) GetStatus(w http.ResponseWriter, r *http.Request) {
 // Preview
 // ============================================================================
 
+// GetPreview handles GET /api/v1/preview
+// Query: file_index, columns (comma-separated projection), sample (head,
+// tail or random; seed makes random repeatable), sort_by, sort_dir (asc or
+// desc), offset and limit (or rows, default 10, max 1000). Returns the rows
+// as an array; the X-Total-Count header holds the number of rows in the file.
 func (h *Handler) GetPreview(w http.ResponseWriter, r *http.Request) {
 	fileIndex := getIntParam(r, "file_index", 1)
-	rows := getIntParam(r, "rows", 10)
+	limit := getIntParam(r, "limit", getIntParam(r, "rows", 10))
+	offset := max(getIntParam(r, "offset", 0), 0)
+	if limit < 0 {
+		limit = 10
+	}
+	limit = min(limit, maxPageRows)
 
 	df := state.State.GetDataFrame(fileIndex)
 	if df == nil {
-		http.Error(w, fmt.Sprintf("File %d not loaded", fileIndex), http.StatusBadRequest)
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
 		return
 	}
 
-	// Convert rows to []map[string]interface{}
-	limit := rows
-	if limit > len(df.Rows) {
-		limit = len(df.Rows)
+	q := r.URL.Query()
+	columns, err := projectColumns(df, q.Get("columns"))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
 	}
 
-	data := make([]map[string]interface{}, limit)
-	for i := 0; i < limit; i++ {
-		row := make(map[string]interface{})
-		for j, header := range df.Headers {
-			if j < len(df.Rows[i]) {
-				row[header] = df.Rows[i][j]
-			} else {
-				row[header] = ""
-			}
+	// Pick the sampled rows first, then sort and page within them
+	n := len(df.Rows)
+	var idx []int
+	switch q.Get("sample") {
+	case "", "head":
+		idx = indexRange(0, n)
+	case "tail":
+		end := max(n-offset, 0)
+		idx = indexRange(max(end-limit, 0), end)
+		offset = 0
+	case "random":
+		seed := int64(getIntParam(r, "seed", 0))
+		if seed == 0 {
+			seed = time.Now().UnixNano()
 		}
-		data[i] = row
+		idx = rand.New(rand.NewSource(seed)).Perm(n)[:min(limit, n)]
+		sort.Ints(idx) // File order unless sorted by a column
+		offset = 0
+	default:
+		apierr.Write(w, apierr.BadRequest("sample must be head, tail or random"))
+		return
+	}
+
+	data, err := sortedPage(df, idx, columns, q.Get("sort_by"), q.Get("sort_dir"), offset, limit)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(n))
 	json.NewEncoder(w).Encode(data)
 }
 
+// projectColumns resolves a comma-separated column list to column indices;
+// an empty list selects every column
+func projectColumns(df *state.DataFrame, list string) ([]int, error) {
+	if strings.TrimSpace(list) == "" {
+		return indexRange(0, len(df.Headers)), nil
+	}
+	columns := []int{}
+	for _, name := range strings.Split(list, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" {
+			continue
+		}
+		idx := -1
+		for i, h := range df.Headers {
+			if h == name {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return nil, fmt.Errorf("column %q not found", name)
+		}
+		columns = append(columns, idx)
+	}
+	return columns, nil
+}
+
+// indexRange returns the indices from..to-1
+func indexRange(from, to int) []int {
+	idx := make([]int, 0, max(to-from, 0))
+	for i := from; i < to; i++ {
+		idx = append(idx, i)
+	}
+	return idx
+}
+
+// maxPageRows caps the rows of one preview or filter page
+const maxPageRows = 1000
+
+// sortedPage sorts row indices by a column and returns the records of rows
+// [offset, offset+limit) holding the given columns; missing cells are ""
+func sortedPage(df *state.DataFrame, idx, columns []int, sortBy, sortDir string, offset, limit int) ([]map[string]interface{}, error) {
+	if sortDir != "" && sortDir != "asc" && sortDir != "desc" {
+		return nil, fmt.Errorf("sort_dir must be asc or desc")
+	}
+	if sortBy != "" {
+		col := columnByName(df, sortBy)
+		if col == nil {
+			return nil, fmt.Errorf("sort column %q not found", sortBy)
+		}
+		col.SortIndices(idx, sortDir == "desc")
+	}
+
+	// Clamped before adding, as offset+limit can overflow
+	offset = min(max(offset, 0), len(idx))
+	end := offset + min(max(limit, 0), len(idx)-offset)
+	data := []map[string]interface{}{}
+	for _, i := range idx[offset:end] {
+		row := make(map[string]interface{}, len(columns))
+		for _, j := range columns {
+			if j < len(df.Rows[i]) {
+				row[df.Headers[j]] = df.Rows[i][j]
+			} else {
+				row[df.Headers[j]] = ""
+			}
+		}
+		data = append(data, row)
+	}
+	return data, nil
+}
+
 // ============================================================================
 // Column Types
 // ============================================================================
@@ -488,7 +799,7 @@ func (h *Handler) GetColumnTypes(w http.ResponseWriter, r *http.Request) {
 
 	df := state.State.GetDataFrame(fileIndex)
 	if df == nil {
-		http.Error(w, fmt.Sprintf("File %d not loaded", fileIndex), http.StatusBadRequest)
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
 		return
 	}
 
@@ -496,10 +807,11 @@ func (h *Handler) GetColumnTypes(w http.ResponseWriter, r *http.Request) {
 	numericCols := df.GetNumericColumnIndices()
 
 	for i, header := range df.Headers {
-		if numericCols[i] {
-			types[header] = "numeric"
-		} else if isDateColumn(df, i) {
+		// Dates first so epoch timestamp columns aren't reported as numeric
+		if isDateColumn(df, i) {
 			types[header] = "datetime"
+		} else if numericCols[i] {
+			types[header] = "numeric"
 		} else {
 			types[header] = "categorical"
 		}
@@ -509,91 +821,120 @@ func (h *Handler) GetColumnTypes(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(types)
 }
 
+// isDateColumn reports whether most sampled values share a date format
+// (including epoch timestamps)
 func isDateColumn(df *state.DataFrame, colIdx int) bool {
-	dateFormats := []string{
-		time.RFC3339, "2006-01-02", "02/01/2006", "01/02/2006",
-		"2006/01/02", "Jan 2, 2006", "January 2, 2006",
-	}
-
-	checkRows := 5
-	if len(df.Rows) < checkRows {
-		checkRows = len(df.Rows)
-	}
+	return detectDateColumn(df, colIdx).Detected
+}
 
-	for i := 0; i < checkRows; i++ {
-		if colIdx >= len(df.Rows[i]) {
-			continue
-		}
-		val := df.Rows[i][colIdx]
-		if val == "" {
-			continue
-		}
-		parsed := false
-		for _, format := range dateFormats {
-			if _, err := time.Parse(format, val); err == nil {
-				parsed = true
-				break
-			}
+// detectDateColumn runs majority-vote date detection over a column sample
+func detectDateColumn(df *state.DataFrame, colIdx int) dateformat.Detection {
+	values := []string{}
+	for _, row := range df.Rows {
+		if len(values) >= dateformat.DefaultSampleSize {
+			break
 		}
-		if parsed {
-			return true
+		if colIdx < len(row) && row[colIdx] != "" {
+			values = append(values, row[colIdx])
 		}
 	}
-	return false
+	return dateformat.DetectColumn(values, dateformat.DefaultSampleSize)
 }
 
 // ============================================================================
 // KPIs
 // ============================================================================
 
+// GetKPIs handles GET /api/v1/kpis
+// Query: file_index, workspace. Evaluates the workspace's KPI definitions,
+// or sums the measure-like numeric columns when none are configured.
 func (h *Handler) GetKPIs(w http.ResponseWriter, r *http.Request) {
 	fileIndex := getIntParam(r, "file_index", 1)
 
 	df := state.State.GetDataFrame(fileIndex)
 	if df == nil {
-		http.Error(w, fmt.Sprintf("File %d not loaded", fileIndex), http.StatusBadRequest)
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
 		return
 	}
 
-	kpis := []models.KPI{}
-	numericCols := df.GetNumericColumnIndices()
+	defs, ok := service.GetKPIStore().Get(r.URL.Query().Get("workspace"))
+	if !ok {
+		defs = service.DefaultKPIs(df)
+	}
 
-	for colIdx, isNumeric := range numericCols {
-		if !isNumeric || colIdx >= len(df.Headers) {
-			continue
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.EvaluateKPIs(df, fileIndex, defs))
+}
+
+// GetKPIConfig handles GET /api/v1/config/kpis
+// Query: workspace. Without configured definitions, returns the defaults
+// derived from file 1 when it is loaded.
+func (h *Handler) GetKPIConfig(w http.ResponseWriter, r *http.Request) {
+	workspace := r.URL.Query().Get("workspace")
+	defs, ok := service.GetKPIStore().Get(workspace)
+	if !ok {
+		defs = []service.KPIDefinition{}
+		if df := state.State.GetDataFrame(1); df != nil {
+			defs = service.DefaultKPIs(df)
 		}
+	}
+	if workspace == "" {
+		workspace = service.DefaultWorkspace
+	}
 
-		colName := df.Headers[colIdx]
-		values := []float64{}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"workspace":  workspace,
+		"configured": ok,
+		"kpis":       defs,
+		"workspaces": service.GetKPIStore().Workspaces(),
+	})
+}
 
-		for _, row := range df.Rows {
-			if colIdx >= len(row) {
-				continue
-			}
-			if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-				values = append(values, val)
-			}
-		}
+// kpiConfigRequest is the body of PUT /api/config/kpis
+type kpiConfigRequest struct {
+	KPIs []service.KPIDefinition `json:"kpis"`
+}
 
-		if len(values) == 0 {
-			continue
-		}
+// SaveKPIConfig handles PUT /api/v1/config/kpis
+// Query: workspace. Body: kpis, each with name, column, aggregation (count,
+// distinct, sum, mean, min, max), label, format (number, integer, percent,
+// currency), currency, filters and file_index.
+func (h *Handler) SaveKPIConfig(w http.ResponseWriter, r *http.Request) {
+	var req kpiConfigRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.KPIs == nil {
+		req.KPIs = []service.KPIDefinition{}
+	}
 
-		sum := 0.0
-		for _, v := range values {
-			sum += v
-		}
+	saved, err := service.GetKPIStore().Set(r.URL.Query().Get("workspace"), req.KPIs)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
 
-		kpis = append(kpis, models.KPI{
-			Name:  colName,
-			Value: sum,
-			Avg:   sum / float64(len(values)),
-			Type:  "sum",
-		})
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"kpis":    saved,
+	})
+}
+
+// ResetKPIConfig handles DELETE /api/v1/config/kpis
+// Query: workspace. Removes the definitions so the defaults apply again.
+func (h *Handler) ResetKPIConfig(w http.ResponseWriter, r *http.Request) {
+	if _, err := service.GetKPIStore().Set(r.URL.Query().Get("workspace"), nil); err != nil {
+		apierr.Write(w, apierr.Internal(err.Error()))
+		return
 	}
 
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(kpis)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
 }
 
 // ============================================================================
@@ -605,7 +946,7 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 	df2 := state.State.GetDataFrame(2)
 
 	if df1 == nil || df2 == nil {
-		http.Error(w, "Both files must be loaded to calculate similarity", http.StatusBadRequest)
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to calculate similarity"))
 		return
 	}
 
@@ -632,12 +973,29 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 	// Check if AI matching is requested
 	useAI := r.URL.Query().Get("use_ai") == "true"
 
+	// An optional matching profile sets weights, thresholds, AI usage and assignment
+	var profile *service.MatchingProfile
+	if name := r.URL.Query().Get("profile"); name != "" {
+		p, ok := service.GetMatchingProfileStore().Get(name)
+		if !ok {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown matching profile: %s", name)))
+			return
+		}
+		profile = &p
+		if r.URL.Query().Get("use_ai") == "" {
+			useAI = p.UseAI
+		}
+	}
+
 	// Convert to response format
 	type SimilarityItem struct {
 		File1Column            string  `json:"file1_column"`
 		File2Column            string  `json:"file2_column"`
 		Similarity             float64 `json:"similarity"`
 		Confidence             float64 `json:"confidence"`
+		ConfidenceLower        float64 `json:"confidence_lower"`
+		ConfidenceUpper        float64 `json:"confidence_upper"`
+		SampleSize             int     `json:"sample_size"`
 		Type                   string  `json:"type"`
 		DataSimilarity         float64 `json:"data_similarity"`
 		NameSimilarity         float64 `json:"name_similarity"`
@@ -647,23 +1005,45 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 		Reason                 string  `json:"reason,omitempty"`
 		TokenSimilarity        float64 `json:"token_similarity,omitempty"`
 		SynonymMatch           bool    `json:"synonym_match,omitempty"`
+		GlossaryTerm           string  `json:"glossary_term,omitempty"`
 		PatternMatch           string  `json:"pattern_match,omitempty"`
 		ValueOverlap           float64 `json:"value_overlap,omitempty"`
 		AIExplanation          string  `json:"ai_explanation,omitempty"`
+
+		FormatTransform *models.ColumnTransform `json:"format_transform,omitempty"`
+	}
+
+	// Pairs approved for files with similar schemas come from the knowledge
+	// base before any scoring runs
+	history := []service.HistorySuggestion{}
+	if r.URL.Query().Get("history") != "false" {
+		history = service.GetMappingKnowledge().Suggest(df1, df2)
 	}
 
 	similarities := []SimilarityItem{}
+	var runStats service.SimilarityRunStats
+	var truncation *llm.TruncationReport // How the LLM's prompts were fitted, with use_ai
 
 	if useAI && h.AISemanticMatcher != nil {
 		// Use AI-powered matching
-		log.Println("[API] Using AI-powered semantic matching via Ollama")
-		aiResults := h.AISemanticMatcher.MatchColumns(df1, df2, ctx1, ctx2)
+		similarityLog.InfoContext(r.Context(), "Using AI-powered semantic matching via Ollama")
+		aiCtx, report := llm.WithTruncationReport(r.Context())
+		truncation = report
+		var weights *service.AdaptiveWeights
+		if profile != nil {
+			weights = profile.Weights
+		}
+		aiResults := h.AISemanticMatcher.MatchColumnsWithWeights(aiCtx, df1, df2, ctx1, ctx2, weights)
+		auditTruncation(r, report)
 		for _, r := range aiResults {
 			similarities = append(similarities, SimilarityItem{
 				File1Column:            r.File1Column,
 				File2Column:            r.File2Column,
 				Similarity:             r.Confidence / 100,
 				Confidence:             r.Confidence,
+				ConfidenceLower:        r.ConfidenceLower,
+				ConfidenceUpper:        r.ConfidenceUpper,
+				SampleSize:             r.SampleSize,
 				Type:                   r.MatchType,
 				DataSimilarity:         r.DataSimilarity,
 				NameSimilarity:         r.NameSimilarity,
@@ -672,17 +1052,40 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 				Reason:                 r.Reason,
 				ValueOverlap:           r.ValueOverlap,
 				AIExplanation:          r.AIExplanation,
+				GlossaryTerm:           service.GetGlossary().PairTerm(r.File1Column, r.File2Column),
 			})
 		}
 	} else {
 		// Use Enhanced heuristic matching (default)
-		enhancedResults := h.EnhancedSimilarityService.CalculateEnhancedSimilarity(df1, df2, ctx1, ctx2)
+		opts := service.DefaultScoringOptions()
+		if profile != nil {
+			opts = profile.ScoringOptions()
+		}
+		if blocking := r.URL.Query().Get("blocking"); blocking != "" {
+			opts.Blocking = blocking
+		}
+		if algorithm := r.URL.Query().Get("name_algorithm"); algorithm != "" {
+			if err := service.ValidateNameAlgorithm(algorithm); err != nil {
+				apierr.Write(w, apierr.BadRequest(err.Error()))
+				return
+			}
+			opts.NameAlgorithm = algorithm
+		}
+		enhancedResults, stats, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarityWithOptions(r.Context(), df1, df2, ctx1, ctx2, opts)
+		if err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+			return
+		}
+		runStats = stats
 		for _, r := range enhancedResults {
 			similarities = append(similarities, SimilarityItem{
 				File1Column:            r.File1Column,
 				File2Column:            r.File2Column,
 				Similarity:             r.Similarity,
 				Confidence:             r.Confidence,
+				ConfidenceLower:        r.ConfidenceLower,
+				ConfidenceUpper:        r.ConfidenceUpper,
+				SampleSize:             r.SampleSize,
 				Type:                   r.Type,
 				DataSimilarity:         r.DataSimilarity,
 				NameSimilarity:         r.NameSimilarity,
@@ -692,14 +1095,90 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 				Reason:                 r.Reason,
 				TokenSimilarity:        r.TokenSimilarity,
 				SynonymMatch:           r.SynonymMatch,
+				GlossaryTerm:           r.GlossaryTerm,
 				PatternMatch:           r.PatternMatch,
 				ValueOverlap:           r.ValueOverlap,
+				FormatTransform:        r.FormatTransform,
+			})
+		}
+	}
+
+	// History leads and replaces the scored duplicates of its pairs
+	historyMatches := 0
+	if len(history) > 0 {
+		approved := service.GetMappingStore().Current()
+		suggested := map[[2]string]bool{}
+		merged := []SimilarityItem{}
+		for _, hs := range history {
+			if approved.IsRejected(hs.File1Column, hs.File2Column) {
+				continue
+			}
+			suggested[[2]string{hs.File1Column, hs.File2Column}] = true
+			merged = append(merged, SimilarityItem{
+				File1Column: hs.File1Column,
+				File2Column: hs.File2Column,
+				Similarity:  hs.Confidence / 100,
+				Confidence:  hs.Confidence,
+				// The share of columns in common is exact, not sampled
+				ConfidenceLower: hs.Confidence,
+				ConfidenceUpper: hs.Confidence,
+				Type:            "history",
+				Reason: fmt.Sprintf("Approved for %d past dataset pair(s), closest %s with %.0f%% of columns in common",
+					hs.Support, hs.SourceFiles, hs.SchemaSimilarity*100),
+				GlossaryTerm: service.GetGlossary().PairTerm(hs.File1Column, hs.File2Column),
 			})
 		}
+		for _, sim := range similarities {
+			if !suggested[[2]string{sim.File1Column, sim.File2Column}] {
+				merged = append(merged, sim)
+			}
+		}
+		similarities = merged
+		historyMatches = len(suggested)
+	}
+
+	if profile != nil {
+		candidates := make([]service.AssignmentCandidate, len(similarities))
+		for i, sim := range similarities {
+			candidates[i] = service.AssignmentCandidate{File1Column: sim.File1Column, File2Column: sim.File2Column, Confidence: sim.Confidence}
+		}
+		// Pairs under the profile's match threshold aren't matches, as for
+		// the hot folder and schema scoring
+		assigned := []SimilarityItem{}
+		for _, i := range service.SelectAssignment(candidates, profile.AssignmentMode) {
+			if similarities[i].Confidence > profile.MinConfidence && similarities[i].Confidence >= profile.MatchThreshold {
+				assigned = append(assigned, similarities[i])
+			}
+		}
+		similarities = assigned
 	}
 
 	totalRelationships := len(similarities)
 
+	// Every run is kept so it can be fetched and diffed later
+	runMatches := make([]service.SimilarityRunMatch, len(similarities))
+	for i, sim := range similarities {
+		runMatches[i] = service.SimilarityRunMatch{File1Column: sim.File1Column, File2Column: sim.File2Column, Confidence: sim.Confidence, Type: sim.Type}
+	}
+	runOpts := service.SimilarityRunOptions{
+		UseAI:         useAI,
+		Profile:       r.URL.Query().Get("profile"),
+		Blocking:      r.URL.Query().Get("blocking"),
+		NameAlgorithm: r.URL.Query().Get("name_algorithm"),
+		History:       r.URL.Query().Get("history") != "false",
+	}
+	run, err := service.GetSimilarityRunStore().Record(df1, df2, runOpts, runMatches, totalRelationships)
+	if err != nil {
+		similarityLog.ErrorContext(r.Context(), "Error storing similarity run", "error", err)
+	}
+
+	// Multi-column join keys, for files no single column joins
+	matches := make([]service.AssignmentCandidate, len(similarities))
+	for i, sim := range similarities {
+		matches[i] = service.AssignmentCandidate{File1Column: sim.File1Column, File2Column: sim.File2Column, Confidence: sim.Confidence}
+	}
+	compositeKeys := h.EnhancedSimilarityService.SuggestCompositeKeys(df1, df2, matches)
+
 	// Limit to top 15 for display
 	if len(similarities) > 15 {
 		similarities = similarities[:15]
@@ -708,14 +1187,25 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 	// Build edges from top similarities
 	edges := []map[string]interface{}{}
 	for _, sim := range similarities {
-		edges = append(edges, map[string]interface{}{
+		edge := map[string]interface{}{
 			"source":     fmt.Sprintf("file1_%s", sim.File1Column),
 			"target":     fmt.Sprintf("file2_%s", sim.File2Column),
 			"value":      sim.Confidence,
 			"similarity": sim.Similarity,
 			"type":       sim.Type,
 			"label":      fmt.Sprintf("%d%%", int(sim.Confidence)),
-		})
+		}
+		// Likely join keys get their cardinality and direction
+		if sim.Confidence >= 70 {
+			if rel, ok := service.InferRelationship(df1, df2, sim.File1Column, sim.File2Column); ok {
+				edge["cardinality"] = rel.Cardinality
+				edge["fan_out"] = rel.FanOut
+				if rel.Direction != "" {
+					edge["direction"] = rel.Direction
+				}
+			}
+		}
+		edges = append(edges, edge)
 	}
 
 	// Calculate correlations for ALL numeric column pairs (not just name-matched)
@@ -812,7 +1302,27 @@ func (h *Handler) GetColumnSimilarity(w http.ResponseWriter, r *http.Request) {
 		"similarities":        similarities,
 		"total_relationships": totalRelationships,
 		"correlations":        correlations,
+		"composite_keys":      compositeKeys,
+		"history_matches":     historyMatches,
+		"run_id":              run.ID,
 	}
+	if !useAI {
+		resp["run_stats"] = runStats
+	}
+	if truncation != nil {
+		resp["truncation"] = truncation
+	}
+
+	// Webhooks get the strongest matches
+	top := []map[string]interface{}{}
+	for _, sim := range similarities[:min(len(similarities), 5)] {
+		top = append(top, map[string]interface{}{
+			"file1_column": sim.File1Column,
+			"file2_column": sim.File2Column,
+			"confidence":   sim.Confidence,
+		})
+	}
+	notifySimilarityRun(df1, df2, top, totalRelationships, useAI)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
@@ -935,7 +1445,7 @@ func (h *Handler) GetCorrelation(w http.ResponseWriter, r *http.Request) {
 
 	df := state.State.GetDataFrame(fileIndex)
 	if df == nil {
-		http.Error(w, fmt.Sprintf("File %d not loaded", fileIndex), http.StatusBadRequest)
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
 		return
 	}
 
@@ -951,26 +1461,15 @@ func (h *Handler) GetCorrelation(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if col1Idx == -1 || col2Idx == -1 {
-		http.Error(w, "Column not found", http.StatusNotFound)
+		apierr.Write(w, apierr.NotFound("Column not found"))
 		return
 	}
 
 	// Calculate correlation
-	vals1, vals2 := []float64{}, []float64{}
-	for _, row := range df.Rows {
-		if col1Idx >= len(row) || col2Idx >= len(row) {
-			continue
-		}
-		v1, err1 := strconv.ParseFloat(row[col1Idx], 64)
-		v2, err2 := strconv.ParseFloat(row[col2Idx], 64)
-		if err1 == nil && err2 == nil {
-			vals1 = append(vals1, v1)
-			vals2 = append(vals2, v2)
-		}
-	}
+	vals1, vals2 := df.FloatPairs(col1Idx, col2Idx)
 
 	if len(vals1) < 2 {
-		http.Error(w, "Not enough numeric values for correlation", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("Not enough numeric values for correlation"))
 		return
 	}
 
@@ -1003,26 +1502,61 @@ func (h *Handler) GetAllCorrelations(w http.ResponseWriter, r *http.Request) {
 	df2 := state.State.GetDataFrame(2)
 
 	if df1 == nil || df2 == nil {
-		http.Error(w, "Both files must be loaded to calculate correlations", http.StatusBadRequest)
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to calculate correlations"))
 		return
 	}
 
-	// Get numeric columns from both files
 	numericCols1 := df1.GetNumericColumnIndices()
 	numericCols2 := df2.GetNumericColumnIndices()
+	correlations := crossFileCorrelations(df1, df2)
 
-	type CorrelationItem struct {
-		File1Column         string  `json:"file1_column"`
-		File2Column         string  `json:"file2_column"`
-		Correlation         float64 `json:"correlation"`
-		PearsonCorrelation  float64 `json:"pearson_correlation"`
-		SpearmanCorrelation float64 `json:"spearman_correlation"`
-		Strength            string  `json:"strength"`
-		SampleSize          int     `json:"sample_size"`
-		File1Rows           int     `json:"file1_rows"`
-		File2Rows           int     `json:"file2_rows"`
+	// Get column lists
+	file1Cols := []string{}
+	file2Cols := []string{}
+	for i, h := range df1.Headers {
+		if numericCols1[i] {
+			file1Cols = append(file1Cols, h)
+		}
+	}
+	for i, h := range df2.Headers {
+		if numericCols2[i] {
+			file2Cols = append(file2Cols, h)
+		}
 	}
 
+	resp := map[string]interface{}{
+		"total_correlations": len(correlations),
+		"correlations":       correlations,
+		"file1_columns":      file1Cols,
+		"file2_columns":      file2Cols,
+		"file1_rows":         len(df1.Rows),
+		"file2_rows":         len(df2.Rows),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// CorrelationItem is the correlation of a numeric column of file 1 with one of file 2
+type CorrelationItem struct {
+	File1Column         string  `json:"file1_column"`
+	File2Column         string  `json:"file2_column"`
+	Correlation         float64 `json:"correlation"`
+	PearsonCorrelation  float64 `json:"pearson_correlation"`
+	SpearmanCorrelation float64 `json:"spearman_correlation"`
+	Strength            string  `json:"strength"`
+	SampleSize          int     `json:"sample_size"`
+	File1Rows           int     `json:"file1_rows"`
+	File2Rows           int     `json:"file2_rows"`
+}
+
+// crossFileCorrelations correlates every numeric column of file 1 with every
+// one of file 2, strongest first (top 50)
+func crossFileCorrelations(df1, df2 *state.DataFrame) []CorrelationItem {
+	// Get numeric columns from both files
+	numericCols1 := df1.GetNumericColumnIndices()
+	numericCols2 := df2.GetNumericColumnIndices()
+
 	correlations := []CorrelationItem{}
 
 	// Calculate correlations for matching numeric columns
@@ -1098,45 +1632,11 @@ func (h *Handler) GetAllCorrelations(w http.ResponseWriter, r *http.Request) {
 	if len(correlations) > 50 {
 		correlations = correlations[:50]
 	}
-
-	// Get column lists
-	file1Cols := []string{}
-	file2Cols := []string{}
-	for i, h := range df1.Headers {
-		if numericCols1[i] {
-			file1Cols = append(file1Cols, h)
-		}
-	}
-	for i, h := range df2.Headers {
-		if numericCols2[i] {
-			file2Cols = append(file2Cols, h)
-		}
-	}
-
-	resp := map[string]interface{}{
-		"total_correlations": len(correlations),
-		"correlations":       correlations,
-		"file1_columns":      file1Cols,
-		"file2_columns":      file2Cols,
-		"file1_rows":         len(df1.Rows),
-		"file2_rows":         len(df2.Rows),
-	}
-
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	return correlations
 }
 
 func getNumericValues(df *state.DataFrame, colIdx int) []float64 {
-	values := []float64{}
-	for _, row := range df.Rows {
-		if colIdx >= len(row) {
-			continue
-		}
-		if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-			values = append(values, val)
-		}
-	}
-	return values
+	return df.FloatValues(colIdx)
 }
 
 func spearmanCorrelation(x, y []float64) float64 {
@@ -1203,128 +1703,157 @@ func pearsonCorrelation(x, y []float64) float64 {
 // Filter
 // ============================================================================
 
+// FilterData handles POST /api/v1/filter
+// Conditions and nested groups combine with "and" or "or". Operators: equals,
+// not_equals, in, not_in (values) compare exactly; iequals and not_iequals
+// trim and ignore case, as do contains and starts_with. Also regex,
+// is_null, not_null, greater_than, less_than, between (values [low, high],
+// numbers or dates), before and after (dates). Results are sorted by sort_by / sort_dir
+// and paged with page and page_size, or offset and limit.
 func (h *Handler) FilterData(w http.ResponseWriter, r *http.Request) {
 	df := state.State.GetDataFrame(1)
 	if df == nil {
-		http.Error(w, "No CSV file loaded", http.StatusBadRequest)
+		apierr.Write(w, apierr.FileNotLoaded(1))
 		return
 	}
 
 	var req models.FilterRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
-	// Build column index map
-	colIdx := make(map[string]int)
-	for i, h := range df.Headers {
-		colIdx[h] = i
+	if req.Page < 1 {
+		req.Page = 1
+	}
+	if req.PageSize < 1 || req.PageSize > 500 {
+		req.PageSize = 100
+	}
+	// Any page past the last is empty; bounding the page to one of them
+	// keeps the offset from overflowing
+	req.Page = min(req.Page, len(df.Rows)/req.PageSize+2)
+	if req.Limit > 0 {
+		req.PageSize = min(req.Limit, maxPageRows)
+		req.Offset = max(req.Offset, 0)
+	} else {
+		req.Offset = (req.Page - 1) * req.PageSize
 	}
 
-	// Filter rows
-	filtered := [][]string{}
-	for _, row := range df.Rows {
-		match := true
-		for _, cond := range req.Conditions {
-			idx, ok := colIdx[cond.Column]
-			if !ok || idx >= len(row) {
-				continue
-			}
-			val := row[idx]
+	match, err := analysis.CompileFilter(req.FilterGroup, df.Headers)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
 
-			switch cond.Operator {
-			case "equals":
-				if val != cond.Value {
-					match = false
-				}
-			case "contains":
-				if !strings.Contains(strings.ToLower(val), strings.ToLower(cond.Value)) {
-					match = false
-				}
-			case "greater_than":
-				fVal, err1 := strconv.ParseFloat(val, 64)
-				fCond, err2 := strconv.ParseFloat(cond.Value, 64)
-				if err1 != nil || err2 != nil || fVal <= fCond {
-					match = false
-				}
-			case "less_than":
-				fVal, err1 := strconv.ParseFloat(val, 64)
-				fCond, err2 := strconv.ParseFloat(cond.Value, 64)
-				if err1 != nil || err2 != nil || fVal >= fCond {
-					match = false
-				}
-			}
-		}
-		if match {
-			filtered = append(filtered, row)
+	matched := []int{}
+	for i, row := range df.Rows {
+		if match(row) {
+			matched = append(matched, i)
 		}
 	}
+	data, err := sortedPage(df, matched, indexRange(0, len(df.Headers)), req.SortBy, req.SortDir, req.Offset, req.PageSize)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
 
-	// Convert to response format (limit to 100)
-	limit := 100
-	if len(filtered) < limit {
-		limit = len(filtered)
+	resp := models.FilterResponse{
+		Rows:     len(matched),
+		Data:     data,
+		Page:     req.Offset/req.PageSize + 1,
+		PageSize: req.PageSize,
+		Offset:   req.Offset,
 	}
 
-	data := make([]map[string]interface{}, limit)
-	for i := 0; i < limit; i++ {
-		row := make(map[string]interface{})
-		for j, header := range df.Headers {
-			if j < len(filtered[i]) {
-				row[header] = filtered[i][j]
-			}
-		}
-		data[i] = row
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// ============================================================================
+// Query (LLM-based data analysis)
+// ============================================================================
+
+// The query types are shared with the API client
+type (
+	QueryRequest  = models.QueryRequest
+	QueryResponse = models.QueryResponse
+)
+
+func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
+	var req QueryRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
 	}
 
-	resp := models.FilterResponse{
-		Rows: len(filtered),
-		Data: data,
+	if req.Question == "" {
+		apierr.Write(w, apierr.BadRequest("Question is required"))
+		return
+	}
+
+	var turns []service.QueryTurn
+	if session, ok := service.GetQuerySessionStore().Get(req.SessionID); ok {
+		turns = session.Turns
+	}
+	fileIndex, err := queryFileIndex(req, turns)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
 	}
+	df, note, err := queryFrame(fileIndex)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	history := sessionHistory(turns, fileIndex)
+	ctx := logging.ContextWith(r.Context(), "file_index", fileIndex)
+
+	resp := h.answerQuery(ctx, df, req.Question, history)
+	resp.Answer = withNote(resp.Answer, note)
+	resp.FileIndex = fileIndex
+	resp.Dataset = queryDatasetName(fileIndex)
+	resp.SessionID = service.GetQuerySessionStore().Append(req.SessionID, service.QueryTurn{
+		Question:  req.Question,
+		FileIndex: fileIndex,
+		Answer:    resp.Answer,
+		Mode:      resp.Mode,
+		Plan:      resp.Plan,
+	})
+	queryLog.InfoContext(ctx, "Question answered", "session", resp.SessionID, "mode", resp.Mode)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
 }
 
-// ============================================================================
-// Query (LLM-based data analysis)
-// ============================================================================
-
-type QueryRequest struct {
-	Question string `json:"question"`
-}
-
-type QueryResponse struct {
-	Answer      string                   `json:"answer"`
-	Explanation string                   `json:"explanation"`
-	RawResponse string                   `json:"raw_response,omitempty"`
-	Result      string                   `json:"result,omitempty"`
-	ResultData  []map[string]interface{} `json:"result_data,omitempty"`
-	ResultType  string                   `json:"result_type,omitempty"`
-	Error       string                   `json:"error,omitempty"`
-}
-
-func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
-	df := state.State.GetDataFrame(1)
-	if df == nil {
-		http.Error(w, "No CSV file loaded. Please upload a file first.", http.StatusBadRequest)
-		return
+// answerQuery answers a question about one file, given the earlier turns of
+// the conversation on that file
+func (h *Handler) answerQuery(ctx context.Context, df *state.DataFrame, rawQuestion string, history []service.QueryTurn) QueryResponse {
+	// Translate the question into a query plan; keyword heuristics answer
+	// when Ollama is down or the plan doesn't validate
+	fallbackReason := "LLM service not configured"
+	if h.LLMService != nil {
+		resp, err := h.planQuery(ctx, df, rawQuestion, history)
+		if err == nil {
+			return resp
+		}
+		fallbackReason = err.Error()
+		queryLog.WarnContext(ctx, "Falling back to heuristics", "error", err)
 	}
 
-	var req QueryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
-		return
+	// "Total amount per region" groups the keyword plan; follow-ups like
+	// "now by month" refine the previous plan
+	question := strings.ToLower(rawQuestion)
+	plan := keywordPlan(df, question)
+	base := plan
+	if base == nil {
+		base = lastPlan(history)
 	}
-
-	if req.Question == "" {
-		http.Error(w, "Question is required", http.StatusBadRequest)
-		return
+	if resp, ok := groupedQuery(df, rawQuestion, base); ok {
+		resp.FallbackReason = fallbackReason
+		return resp
 	}
 
 	// Process the query
-	question := strings.ToLower(req.Question)
 	resp := QueryResponse{}
 
 	// Simple query processing without LLM (fallback mode)
@@ -1345,11 +1874,107 @@ func (h *Handler) Query(w http.ResponseWriter, r *http.Request) {
 	} else {
 		// Default: provide overview
 		resp = h.processOverviewQuery(df)
-		resp.Explanation = fmt.Sprintf("I understood your question: '%s'. Here's an overview of the data. For specific queries, try asking about averages, sums, counts, or statistics.", req.Question)
+		resp.Explanation = fmt.Sprintf("I understood your question: '%s'. Here's an overview of the data. For specific queries, try asking about averages, sums, counts, or statistics.", rawQuestion)
+	}
+	resp.Mode = "heuristic"
+	resp.Plan = plan
+	resp.FallbackReason = fallbackReason
+	return resp
+}
+
+// planQuery asks the LLM for a query plan, validates it against the schema
+// and executes it
+func (h *Handler) planQuery(ctx context.Context, df *state.DataFrame, question string, history []service.QueryTurn) (QueryResponse, error) {
+	schema := make([]llm.ColumnSchema, 0, len(df.Headers))
+	for _, col := range df.Columns() {
+		c := llm.ColumnSchema{Name: col.Name, Type: string(col.Type)}
+		for i := 0; i < col.Len() && len(c.Examples) < 3; i++ {
+			if !col.IsNull(i) {
+				c.Examples = append(c.Examples, strconv.Quote(col.Value(i)))
+			}
+		}
+		schema = append(schema, c)
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	exchanges := make([]llm.QueryExchange, len(history))
+	for i, t := range history {
+		exchanges[i].Question = t.Question
+		if t.Plan != nil {
+			if data, err := json.Marshal(t.Plan); err == nil {
+				exchanges[i].Plan = string(data)
+			}
+		}
+	}
+
+	ctx, truncation := llm.WithTruncationReport(ctx)
+	raw, err := h.LLMService.GenerateQueryPlan(ctx, question, schema, exchanges)
+	if err != nil {
+		return QueryResponse{}, fmt.Errorf("query planning failed: %w", err)
+	}
+	var plan analysis.QueryPlan
+	if err := json.Unmarshal([]byte(raw), &plan); err != nil {
+		return QueryResponse{}, fmt.Errorf("invalid query plan: %w", err)
+	}
+	result, err := analysis.ExecutePlan(df.Headers, df.Rows, &plan)
+	if err != nil {
+		return QueryResponse{}, fmt.Errorf("invalid query plan: %w", err)
+	}
+
+	resp := planResponse(&plan, result, "llm")
+	resp.RawResponse = raw
+	resp.Truncation = truncation
+	return resp, nil
+}
+
+// planResponse is the answer of an executed query plan
+func planResponse(plan *analysis.QueryPlan, result *analysis.QueryResult, mode string) QueryResponse {
+	explanation := plan.Explanation
+	if explanation == "" {
+		explanation = "Answered with a structured query plan."
+	}
+	return QueryResponse{
+		Answer:      formatQueryResult(result),
+		Explanation: explanation,
+		ResultData:  result.Rows,
+		ResultType:  "table",
+		Mode:        mode,
+		Plan:        plan,
+	}
+}
+
+// formatQueryResult renders a query result as text: "column: value" lines
+// for a single row, otherwise a row count and the first rows
+func formatQueryResult(result *analysis.QueryResult) string {
+	value := func(v interface{}) string {
+		switch x := v.(type) {
+		case nil:
+			return "-"
+		case float64:
+			return strconv.FormatFloat(x, 'f', -1, 64)
+		}
+		return fmt.Sprint(v)
+	}
+	if len(result.Rows) == 1 {
+		lines := []string{}
+		for _, c := range result.Columns {
+			lines = append(lines, fmt.Sprintf("%s: %s", c, value(result.Rows[0][c])))
+		}
+		return strings.Join(lines, "\n")
+	}
+
+	lines := []string{fmt.Sprintf("%d result rows (%d matching records)", result.TotalRows, result.MatchedRows)}
+	for i, row := range result.Rows {
+		if i == 10 {
+			lines = append(lines, "...")
+			break
+		}
+		parts := make([]string, len(result.Columns))
+		for j, c := range result.Columns {
+			parts[j] = fmt.Sprintf("%s=%s", c, value(row[c]))
+		}
+		lines = append(lines, strings.Join(parts, ", "))
+	}
+	return strings.Join(lines, "\n")
 }
 
 func (h *Handler) processAverageQuery(df *state.DataFrame, question string) QueryResponse {
@@ -1363,13 +1988,9 @@ func (h *Handler) processAverageQuery(df *state.DataFrame, question string) Quer
 		colName := df.Headers[colIdx]
 		if strings.Contains(question, strings.ToLower(colName)) || strings.Contains(question, "all") {
 			sum, count := 0.0, 0
-			for _, row := range df.Rows {
-				if colIdx < len(row) {
-					if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-						sum += val
-						count++
-					}
-				}
+			for _, val := range df.FloatValues(colIdx) {
+				sum += val
+				count++
 			}
 			if count > 0 {
 				avg := sum / float64(count)
@@ -1386,13 +2007,9 @@ func (h *Handler) processAverageQuery(df *state.DataFrame, question string) Quer
 			}
 			colName := df.Headers[colIdx]
 			sum, count := 0.0, 0
-			for _, row := range df.Rows {
-				if colIdx < len(row) {
-					if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-						sum += val
-						count++
-					}
-				}
+			for _, val := range df.FloatValues(colIdx) {
+				sum += val
+				count++
 			}
 			if count > 0 {
 				avg := sum / float64(count)
@@ -1419,12 +2036,8 @@ func (h *Handler) processSumQuery(df *state.DataFrame, question string) QueryRes
 		}
 		colName := df.Headers[colIdx]
 		sum := 0.0
-		for _, row := range df.Rows {
-			if colIdx < len(row) {
-				if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-					sum += val
-				}
-			}
+		for _, val := range df.FloatValues(colIdx) {
+			sum += val
 		}
 		results = append(results, fmt.Sprintf("%s: %.2f", colName, sum))
 	}
@@ -1456,13 +2069,9 @@ func (h *Handler) processMaxQuery(df *state.DataFrame, question string) QueryRes
 		}
 		colName := df.Headers[colIdx]
 		maxVal := math.Inf(-1)
-		for _, row := range df.Rows {
-			if colIdx < len(row) {
-				if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-					if val > maxVal {
-						maxVal = val
-					}
-				}
+		for _, val := range df.FloatValues(colIdx) {
+			if val > maxVal {
+				maxVal = val
 			}
 		}
 		if maxVal != math.Inf(-1) {
@@ -1488,13 +2097,9 @@ func (h *Handler) processMinQuery(df *state.DataFrame, question string) QueryRes
 		}
 		colName := df.Headers[colIdx]
 		minVal := math.Inf(1)
-		for _, row := range df.Rows {
-			if colIdx < len(row) {
-				if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-					if val < minVal {
-						minVal = val
-					}
-				}
+		for _, val := range df.FloatValues(colIdx) {
+			if val < minVal {
+				minVal = val
 			}
 		}
 		if minVal != math.Inf(1) {
@@ -1570,12 +2175,27 @@ func (h *Handler) processTopQuery(df *state.DataFrame, question string) QueryRes
 // Context Management
 // ============================================================================
 
+// requestLocale is the locale of the questions a request asks for: the
+// locale query parameter, else the Accept-Language header
+func requestLocale(r *http.Request) (string, error) {
+	if tag := r.URL.Query().Get("locale"); tag != "" {
+		return service.ParseLocale(tag)
+	}
+	return service.PreferredLocale(r.Header.Get("Accept-Language")), nil
+}
+
 func (h *Handler) GenerateContextQuestions(w http.ResponseWriter, r *http.Request) {
+	locale, err := requestLocale(r)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
 	df1 := state.State.GetDataFrame(1)
 	df2 := state.State.GetDataFrame(2)
 
 	if df1 == nil || df2 == nil {
-		http.Error(w, "Both files must be loaded to generate context questions", http.StatusBadRequest)
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to generate context questions"))
 		return
 	}
 
@@ -1583,26 +2203,10 @@ func (h *Handler) GenerateContextQuestions(w http.ResponseWriter, r *http.Reques
 	analysis1 := h.analyzeDataFrame(df1)
 	analysis2 := h.analyzeDataFrame(df2)
 
-	questions1 := h.QuestionGenerator.GenerateQuestions(analysis1, 1)
-	questions2 := h.QuestionGenerator.GenerateQuestions(analysis2, 2)
-
-	// Relationship questions
-	relationshipQuestions := []models.Question{
-		{
-			ID:       "rel_type",
-			Type:     models.QuestionTypeRelationships,
-			Text:     "How are these two datasets related?",
-			Options:  []string{"One-to-One", "One-to-Many", "Many-to-Many", "Hierarchical", "Temporal sequence", "Unknown"},
-			Required: false,
-		},
-		{
-			ID:       "rel_keys",
-			Type:     models.QuestionTypeCustomMappings,
-			Text:     "Which columns should be used to join these datasets?",
-			Options:  append(df1.Headers, df2.Headers...),
-			Required: false,
-		},
-	}
+	questions1 := h.QuestionGenerator.GenerateQuestions(analysis1, 1, locale)
+	questions2 := h.QuestionGenerator.GenerateQuestions(analysis2, 2, locale)
+
+	relationshipQuestions := service.RelationshipQuestions(df1.Headers, df2.Headers, locale)
 
 	resp := models.QuestionsResponse{
 		Success: true,
@@ -1612,6 +2216,7 @@ func (h *Handler) GenerateContextQuestions(w http.ResponseWriter, r *http.Reques
 			"relationship_questions": relationshipQuestions,
 		},
 		TotalQuestions: len(questions1) + len(questions2) + len(relationshipQuestions),
+		Locale:         locale,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -1628,7 +2233,8 @@ func (h *Handler) analyzeDataFrame(df *state.DataFrame) models.DataAnalysisResul
 
 	numericCols := df.GetNumericColumnIndices()
 	for i, header := range df.Headers {
-		if numericCols[i] {
+		isDate := isDateColumn(df, i)
+		if numericCols[i] && !isDate {
 			result.HasNumeric = true
 			headerLower := strings.ToLower(header)
 			if strings.Contains(headerLower, "id") || strings.Contains(headerLower, "key") {
@@ -1638,7 +2244,7 @@ func (h *Handler) analyzeDataFrame(df *state.DataFrame) models.DataAnalysisResul
 				result.PotentialAmounts = append(result.PotentialAmounts, header)
 			}
 			result.ColumnTypes[header] = "numeric"
-		} else if isDateColumn(df, i) {
+		} else if isDate {
 			result.HasDates = true
 			result.PotentialDates = append(result.PotentialDates, header)
 			result.ColumnTypes[header] = "date"
@@ -1655,19 +2261,19 @@ func (h *Handler) StoreContext(w http.ResponseWriter, r *http.Request) {
 	fileIndexStr := chi.URLParam(r, "fileIndex")
 	fileIndex, err := strconv.Atoi(fileIndexStr)
 	if err != nil {
-		http.Error(w, "Invalid file index", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("Invalid file index"))
 		return
 	}
 
 	var ctx models.Context
 	body, _ := io.ReadAll(r.Body)
 	if err := json.Unmarshal(body, &ctx); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
 	if err := h.ContextService.StoreContext(fileIndex, &ctx); err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest(err.Error()))
 		return
 	}
 
@@ -1678,42 +2284,66 @@ func (h *Handler) StoreContext(w http.ResponseWriter, r *http.Request) {
 func (h *Handler) SubmitContext(w http.ResponseWriter, r *http.Request) {
 	var req models.ContextSubmitRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
 	if req.FileIndex != 1 && req.FileIndex != 2 {
-		http.Error(w, "file_index must be 1 or 2", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
+		return
+	}
+	locale, err := requestLocale(r)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
 		return
 	}
 
-	// Convert context data to Context model
-	ctx := models.NewContext()
-	if purpose, ok := req.ContextData["dataset_purpose"].(string); ok {
-		ctx.DatasetPurpose = purpose
+	// Each field is validated against the answer type of the question asking
+	// for it; empty fields are left unset and unknown ones ignored
+	var columns, headers1, headers2 []string
+	df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2)
+	if df1 != nil {
+		headers1 = df1.Headers
 	}
-	if domain, ok := req.ContextData["business_domain"].(string); ok {
-		ctx.BusinessDomain = domain
+	if df2 != nil {
+		headers2 = df2.Headers
 	}
-	if entities, ok := req.ContextData["key_entities"].([]interface{}); ok {
-		for _, e := range entities {
-			if s, ok := e.(string); ok {
-				ctx.KeyEntities = append(ctx.KeyEntities, s)
-			}
-		}
+	if df := state.State.GetDataFrame(req.FileIndex); df != nil {
+		columns = df.Headers
 	}
-	if temporal, ok := req.ContextData["temporal_context"].(string); ok {
-		ctx.TemporalContext = temporal
+	questions := service.ContextFieldQuestions(req.FileIndex, columns, headers1, headers2, locale)
+
+	keys := make([]string, 0, len(req.ContextData))
+	for key, answer := range req.ContextData {
+		if _, ok := questions[key]; ok && !service.EmptyAnswer(answer) {
+			keys = append(keys, key)
+		}
 	}
-	if exclusions, ok := req.ContextData["exclusions"].([]interface{}); ok {
-		for _, e := range exclusions {
-			if s, ok := e.(string); ok {
-				ctx.Exclusions = append(ctx.Exclusions, s)
-			}
+	sort.Strings(keys)
+
+	ctx := models.NewContext()
+	errs := []service.AnswerError{}
+	for _, key := range keys {
+		q := questions[key]
+		if q.Type == models.QuestionTypeCustomMappings && (df1 == nil || df2 == nil) {
+			apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to declare join keys"))
+			return
+		}
+		if fieldErrs := service.ValidateAnswer("context_data."+key, q, req.ContextData[key], columns, headers1, headers2); len(fieldErrs) > 0 {
+			errs = append(errs, fieldErrs...)
+			continue
 		}
+		if err := service.ApplyContextAnswer(ctx, q, req.ContextData[key], headers1, headers2); err != nil {
+			errs = append(errs, service.AnswerError{Field: "context_data." + key, Code: service.AnswerErrorType, Message: err.Error()})
+		}
+	}
+	if len(errs) > 0 {
+		apierr.Write(w, apierr.BadRequest("Invalid context answers").WithDetail("errors", errs))
+		return
 	}
 
 	state.State.SetContext(req.FileIndex, ctx)
+	audit.Annotate(r.Context(), "file_index", req.FileIndex)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -1727,7 +2357,7 @@ func (h *Handler) GetContext(w http.ResponseWriter, r *http.Request) {
 	fileIndexStr := chi.URLParam(r, "fileIndex")
 	fileIndex, err := strconv.Atoi(fileIndexStr)
 	if err != nil || (fileIndex != 1 && fileIndex != 2) {
-		http.Error(w, "fileIndex must be 1 or 2", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
 		return
 	}
 
@@ -1748,18 +2378,23 @@ func (h *Handler) GetQuestions(w http.ResponseWriter, r *http.Request) {
 	fileIndexStr := chi.URLParam(r, "fileIndex")
 	fileIndex, err := strconv.Atoi(fileIndexStr)
 	if err != nil {
-		http.Error(w, "Invalid file index", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("Invalid file index"))
+		return
+	}
+	locale, err := requestLocale(r)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
 		return
 	}
 
 	// Retrieve analysis from storage
 	analysis := h.ContextService.GetAnalysis(fileIndex)
 	if analysis == nil {
-		http.Error(w, "Analysis not found for this file. Please upload and analyze file first.", http.StatusNotFound)
+		apierr.Write(w, apierr.NotFound("Analysis not found for this file. Please upload and analyze file first."))
 		return
 	}
 
-	questions := h.QuestionGenerator.GenerateQuestions(*analysis, fileIndex)
+	questions := h.QuestionGenerator.GenerateQuestions(*analysis, fileIndex, locale)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(questions)
@@ -1769,7 +2404,7 @@ func (h *Handler) DeleteContext(w http.ResponseWriter, r *http.Request) {
 	fileIndexStr := chi.URLParam(r, "fileIndex")
 	fileIndex, err := strconv.Atoi(fileIndexStr)
 	if err != nil || (fileIndex != 1 && fileIndex != 2) {
-		http.Error(w, "fileIndex must be 1 or 2", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
 		return
 	}
 
@@ -1784,11 +2419,19 @@ func (h *Handler) DeleteContext(w http.ResponseWriter, r *http.Request) {
 
 // GetSimilarityGraph generates the correlation graph (My V2 impl)
 func (h *Handler) GetSimilarityGraph(w http.ResponseWriter, r *http.Request) {
-	graph, err := h.SimilarityService.GenerateGraph(1, 2)
+	graph, err := h.SimilarityService.GenerateGraph(r.Context(), 1, 2)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error generating graph: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error generating graph: %v", err)))
 		return
 	}
+	if df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2); df1 != nil && df2 != nil {
+		matches := make([]service.AssignmentCandidate, len(graph.Similarities))
+		for i, sim := range graph.Similarities {
+			matches[i] = service.AssignmentCandidate{File1Column: sim.File1Column, File2Column: sim.File2Column, Confidence: sim.Confidence}
+		}
+		graph.CompositeKeys = h.EnhancedSimilarityService.SuggestCompositeKeys(df1, df2, matches)
+		service.AnnotateEdges(graph, df1, df2)
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(graph)
 }
@@ -1827,38 +2470,59 @@ func (h *Handler) GetContextStatus(w http.ResponseWriter, r *http.Request) {
 // Ollama Config
 // ============================================================================
 
-func (h *Handler) GetOllamaConfig(w http.ResponseWriter, r *http.Request) {
-	resp := models.OllamaConfig{
+// ollamaConfig returns the Ollama settings in effect, with the models each
+// LLM task is sent to
+func (h *Handler) ollamaConfig() models.OllamaConfig {
+	config := models.OllamaConfig{
 		BaseURL: state.State.OllamaBaseURL,
 		Model:   state.State.OllamaModel,
 	}
+	if h.LLMService != nil {
+		config.Tasks = h.LLMService.TaskRoutes()
+	}
+	return config
+}
 
+func (h *Handler) GetOllamaConfig(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(resp)
+	json.NewEncoder(w).Encode(h.ollamaConfig())
 }
 
+// SaveOllamaConfig handles POST /api/v1/config/ollama
+// tasks, when given, replaces the models of every LLM task until restart;
+// tasks left out use the default model
 func (h *Handler) SaveOllamaConfig(w http.ResponseWriter, r *http.Request) {
 	var config models.OllamaConfig
 	if err := json.NewDecoder(r.Body).Decode(&config); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
+	if config.Tasks != nil {
+		if h.LLMService == nil {
+			apierr.Write(w, apierr.Upstream("LLM service not configured"))
+			return
+		}
+		if err := h.LLMService.SetTaskModels(config.Tasks); err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		audit.Annotate(r.Context(), "tasks", config.Tasks)
+	}
 	if config.BaseURL != "" {
 		state.State.OllamaBaseURL = config.BaseURL
 	}
 	if config.Model != "" {
 		state.State.OllamaModel = config.Model
 	}
+	audit.Annotate(r.Context(), "base_url", state.State.OllamaBaseURL)
+	audit.Annotate(r.Context(), "model", state.State.OllamaModel)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"success": true,
 		"message": "Ollama configuration saved successfully",
-		"config": models.OllamaConfig{
-			BaseURL: state.State.OllamaBaseURL,
-			Model:   state.State.OllamaModel,
-		},
+		"config":  h.ollamaConfig(),
 	})
 }
 
@@ -1866,7 +2530,7 @@ func (h *Handler) SaveOllamaConfig(w http.ResponseWriter, r *http.Request) {
 // Feedback Learning
 // ============================================================================
 
-// SubmitMatchFeedback handles POST /feedback/match
+// SubmitMatchFeedback handles POST /api/v1/feedback/match
 func (h *Handler) SubmitMatchFeedback(w http.ResponseWriter, r *http.Request) {
 	var req struct {
 		File1Column    string  `json:"file1_column"`
@@ -1878,20 +2542,28 @@ func (h *Handler) SubmitMatchFeedback(w http.ResponseWriter, r *http.Request) {
 		DataSimilarity float64 `json:"data_similarity"`
 		PatternScore   float64 `json:"pattern_score"`
 		Confidence     float64 `json:"confidence"`
+		Scope          string  `json:"scope,omitempty"`
+		Global         bool    `json:"global,omitempty"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "Invalid request body", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
 	if req.File1Column == "" || req.File2Column == "" {
-		http.Error(w, "file1_column and file2_column are required", http.StatusBadRequest)
+		apierr.Write(w, apierr.BadRequest("file1_column and file2_column are required"))
 		return
 	}
 
 	feedbackSystem := service.GetFeedbackSystem()
 
+	// Scope feedback to the currently loaded dataset pair unless told otherwise
+	scope := req.Scope
+	if scope == "" && !req.Global {
+		scope = service.DatasetPairScope(state.State.GetDataFrame(1), state.State.GetDataFrame(2))
+	}
+
 	entry := service.FeedbackEntry{
 		File1Column:    req.File1Column,
 		File2Column:    req.File2Column,
@@ -1902,13 +2574,18 @@ func (h *Handler) SubmitMatchFeedback(w http.ResponseWriter, r *http.Request) {
 		DataSimilarity: req.DataSimilarity,
 		PatternScore:   req.PatternScore,
 		Confidence:     req.Confidence,
+		Scope:          scope,
+		Global:         req.Global,
 	}
 
 	result, err := feedbackSystem.AddFeedback(entry)
 	if err != nil {
-		http.Error(w, fmt.Sprintf("Error recording feedback: %v", err), http.StatusInternalServerError)
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error recording feedback: %v", err)))
 		return
 	}
+	audit.Annotate(r.Context(), "file1_column", req.File1Column)
+	audit.Annotate(r.Context(), "file2_column", req.File2Column)
+	audit.Annotate(r.Context(), "is_correct", req.IsCorrect)
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{
@@ -1918,7 +2595,7 @@ func (h *Handler) SubmitMatchFeedback(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
-// GetFeedbackStats handles GET /feedback/stats
+// GetFeedbackStats handles GET /api/v1/feedback/stats
 func (h *Handler) GetFeedbackStats(w http.ResponseWriter, r *http.Request) {
 	feedbackSystem := service.GetFeedbackSystem()
 	stats := feedbackSystem.GetStats()
@@ -1927,36 +2604,422 @@ func (h *Handler) GetFeedbackStats(w http.ResponseWriter, r *http.Request) {
 	json.NewEncoder(w).Encode(stats)
 }
 
+// ListFeedback handles GET /api/v1/feedback
+// Supports page, page_size, column, file1_column, file2_column, scope and is_correct
+func (h *Handler) ListFeedback(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	page := getIntParam(r, "page", 1)
+	if page < 1 {
+		page = 1
+	}
+	pageSize := getIntParam(r, "page_size", 50)
+	if pageSize < 1 || pageSize > 500 {
+		pageSize = 50
+	}
+
+	filter := service.FeedbackFilter{
+		Column:      q.Get("column"),
+		File1Column: q.Get("file1_column"),
+		File2Column: q.Get("file2_column"),
+		Scope:       q.Get("scope"),
+	}
+	if v := q.Get("is_correct"); v != "" {
+		isCorrect, err := strconv.ParseBool(v)
+		if err != nil {
+			apierr.Write(w, apierr.BadRequest("Invalid is_correct value"))
+			return
+		}
+		filter.IsCorrect = &isCorrect
+	}
+
+	entries, total := service.GetFeedbackSystem().ListFeedback(filter, (page-1)*pageSize, pageSize)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries":   entries,
+		"total":     total,
+		"page":      page,
+		"page_size": pageSize,
+	})
+}
+
+// UpdateFeedback handles PUT /api/v1/feedback/{id}
+func (h *Handler) UpdateFeedback(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var update service.FeedbackUpdate
+	if err := json.NewDecoder(r.Body).Decode(&update); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	entry, err := service.GetFeedbackSystem().UpdateFeedback(id, update)
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"feedback": entry,
+	})
+}
+
+// DeleteFeedback handles DELETE /api/v1/feedback/{id}
+// The learning systems are rebuilt from the remaining history
+func (h *Handler) DeleteFeedback(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := service.GetFeedbackSystem().DeleteFeedback(id); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Feedback deleted",
+	})
+}
+
 // ExportSQL generates SQL from the graph
+// Query: dialect=postgres|mysql|snowflake|bigquery|tsql, mode=select|view, view_name, table1, table2
 func (h *Handler) ExportSQL(w http.ResponseWriter, r *http.Request) {
 	var graph models.SimilarityGraph
 	body, _ := io.ReadAll(r.Body)
 	if err := json.Unmarshal(body, &graph); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
-	sql := h.ExportService.GenerateSQL(&graph)
+	q := r.URL.Query()
+	relType, joinKeys := service.DeclaredRelationship(state.State.GetContext(1), state.State.GetContext(2))
+	sql, err := h.ExportService.GenerateSQL(&graph, service.GetMappingStore().Current(), service.SQLOptions{
+		Dialect:      q.Get("dialect"),
+		Mode:         q.Get("mode"),
+		ViewName:     q.Get("view_name"),
+		Table1:       q.Get("table1"),
+		Table2:       q.Get("table2"),
+		Relationship: relType,
+		JoinKeys:     joinKeys,
+		File1:        state.State.GetDataFrame(1),
+		File2:        state.State.GetDataFrame(2),
+	})
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
 
 	w.Header().Set("Content-Type", "text/plain")
 	w.Write([]byte(sql))
 }
 
 // ExportPython generates Python script from the graph
+// Query: engine=pandas|pyspark|polars
 func (h *Handler) ExportPython(w http.ResponseWriter, r *http.Request) {
 	var graph models.SimilarityGraph
 	body, _ := io.ReadAll(r.Body)
 	if err := json.Unmarshal(body, &graph); err != nil {
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		apierr.Write(w, apierr.InvalidJSON(err))
 		return
 	}
 
-	python := h.ExportService.GeneratePython(&graph)
+	python, err := h.ExportService.GeneratePython(&graph, service.GetMappingStore().Current(), r.URL.Query().Get("engine"))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
 
 	w.Header().Set("Content-Type", "text/plain")
 	w.Write([]byte(python))
 }
 
+// ExportNotebook generates a Jupyter notebook from the approved mapping.
+// The graph body is optional and only used when there is no approved mapping.
+func (h *Handler) ExportNotebook(w http.ResponseWriter, r *http.Request) {
+	var graph models.SimilarityGraph
+	body, _ := io.ReadAll(r.Body)
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &graph); err != nil {
+			apierr.Write(w, apierr.InvalidJSON(err))
+			return
+		}
+	}
+
+	nb, err := h.ExportService.GenerateNotebook(&graph, service.GetMappingStore().Current(), state.State.GetDataFrame(1), state.State.GetDataFrame(2))
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error generating notebook: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/x-ipynb+json")
+	w.Header().Set("Content-Disposition", "attachment; filename=project_euler_mapping.ipynb")
+	w.Write(nb)
+}
+
+// ExportAirflow generates an Airflow DAG scaffold (extract, transform/join, load)
+// Query: target=sql|python, dag_id, schedule, conn_id, target_table, dialect, table1, table2
+func (h *Handler) ExportAirflow(w http.ResponseWriter, r *http.Request) {
+	var graph models.SimilarityGraph
+	body, _ := io.ReadAll(r.Body)
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &graph); err != nil {
+			apierr.Write(w, apierr.InvalidJSON(err))
+			return
+		}
+	}
+
+	q := r.URL.Query()
+	relType, joinKeys := service.DeclaredRelationship(state.State.GetContext(1), state.State.GetContext(2))
+	opts := service.AirflowOptions{
+		Target:      q.Get("target"),
+		DagID:       q.Get("dag_id"),
+		Schedule:    q.Get("schedule"),
+		ConnID:      q.Get("conn_id"),
+		TargetTable: q.Get("target_table"),
+		SQL: service.SQLOptions{
+			Dialect:      q.Get("dialect"),
+			Table1:       q.Get("table1"),
+			Table2:       q.Get("table2"),
+			Relationship: relType,
+			JoinKeys:     joinKeys,
+			File1:        state.State.GetDataFrame(1),
+			File2:        state.State.GetDataFrame(2),
+		},
+	}
+	dag, err := h.ExportService.GenerateAirflowDAG(&graph, service.GetMappingStore().Current(), opts)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	dagID := q.Get("dag_id")
+	if dagID == "" {
+		dagID = "project_euler_join"
+	}
+	w.Header().Set("Content-Type", "text/x-python")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.py", dagID))
+	w.Write([]byte(dag))
+}
+
+// ExportReport renders a shareable analysis report of the loaded files.
+// The graph body is optional and supplies the top matches.
+// Query: format=html|pdf, top=20
+func (h *Handler) ExportReport(w http.ResponseWriter, r *http.Request) {
+	var graph models.SimilarityGraph
+	body, _ := io.ReadAll(r.Body)
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &graph); err != nil {
+			apierr.Write(w, apierr.InvalidJSON(err))
+			return
+		}
+	}
+
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	if df1 == nil && df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("No files loaded"))
+		return
+	}
+
+	report := service.BuildAnalysisReport(&graph, service.GetMappingStore().Current(), df1, df2, getIntParam(r, "top", service.DefaultReportTopMatches))
+	filename := "project_euler_report_" + report.GeneratedAt.Format("20060102_150405")
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", service.ReportFormatHTML:
+		out, err := service.RenderReportHTML(report)
+		if err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error rendering report: %v", err)))
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.html", filename))
+		w.Write(out)
+	case service.ReportFormatPDF:
+		out, err := service.RenderReportPDF(report)
+		if err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error rendering report: %v", err)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/pdf")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%s.pdf", filename))
+		w.Write(out)
+	default:
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use html or pdf)", format)))
+	}
+}
+
+// ExportDictionary handles GET /api/v1/export/dictionary/{fileIndex}
+// Query: format=markdown|json
+func (h *Handler) ExportDictionary(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+
+	ctx := state.State.GetContext(fileIndex)
+	if ctx == nil {
+		ctx = h.ContextService.GetContext(fileIndex)
+	}
+	dict := h.EnhancedSimilarityService.BuildDataDictionary(df, fileIndex, ctx)
+
+	switch format := r.URL.Query().Get("format"); format {
+	case "", service.DictionaryFormatMarkdown:
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=data_dictionary_file%d.md", fileIndex))
+		w.Write([]byte(service.RenderDictionaryMarkdown(dict)))
+	case service.DictionaryFormatJSON:
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(dict)
+	default:
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use markdown or json)", format)))
+	}
+}
+
+// GetLineage handles GET /api/v1/lineage
+// Column lineage of the last generated SQL and Python exports.
+// Query: target=sql|python, format=openlineage|native
+func (h *Handler) GetLineage(w http.ResponseWriter, r *http.Request) {
+	target := r.URL.Query().Get("target")
+	format := r.URL.Query().Get("format")
+	if format != "" && format != "openlineage" && format != "native" {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use openlineage or native)", format)))
+		return
+	}
+
+	lineage := []*service.ExportLineage{}
+	for _, l := range h.ExportService.Lineage() {
+		if target == "" || l.Target == target {
+			lineage = append(lineage, l)
+		}
+	}
+	if len(lineage) == 0 {
+		apierr.Write(w, apierr.NotFound("No SQL or Python export generated yet"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if format == "native" {
+		json.NewEncoder(w).Encode(map[string]interface{}{"lineage": lineage})
+		return
+	}
+
+	file1, file2 := "file1", "file2"
+	if df := state.State.GetDataFrame(1); df != nil && df.FileName != "" {
+		file1 = df.FileName
+	}
+	if df := state.State.GetDataFrame(2); df != nil && df.FileName != "" {
+		file2 = df.FileName
+	}
+	events := make([]service.OpenLineageEvent, len(lineage))
+	for i, l := range lineage {
+		events[i] = l.OpenLineage(file1, file2)
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{"events": events})
+}
+
+// exportDataRequest is the body of POST /api/export/data
+type exportDataRequest struct {
+	service.JoinOptions
+	Format string `json:"format"` // csv (default) or parquet
+}
+
+// Parquet exports are built in memory, unlike streamed CSV, so the joined
+// data they hold is capped
+const (
+	exportDataMaxBody     = 1 << 20 // Request body
+	exportParquetMaxRows  = 1_000_000
+	exportParquetMaxBytes = 512 << 20 // Cell text
+)
+
+// ExportData handles POST /api/v1/export/data
+// Joins the loaded files on the approved join keys and streams the result
+func (h *Handler) ExportData(w http.ResponseWriter, r *http.Request) {
+	var req exportDataRequest
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, exportDataMaxBody))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Request body exceeds %d KB", exportDataMaxBody>>10)))
+		return
+	}
+	if len(body) > 0 {
+		if err := json.Unmarshal(body, &req); err != nil {
+			apierr.Write(w, apierr.InvalidJSON(err))
+			return
+		}
+	}
+	if req.Format == "" {
+		req.Format = service.DataFormatCSV
+	}
+	if req.Format != service.DataFormatCSV && req.Format != service.DataFormatParquet {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use csv or parquet)", req.Format)))
+		return
+	}
+	audit.Annotate(r.Context(), "format", req.Format)
+
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	mapping := service.GetMappingStore().Current()
+
+	if req.Format == service.DataFormatParquet {
+		// Parquet is written column by column, so collect the rows first
+		var headers []string
+		rows := [][]string{}
+		size := 0
+		err := service.JoinDataFrames(df1, df2, mapping, req.JoinOptions,
+			func(hs []string) error { headers = hs; return nil },
+			func(row []string) error {
+				for _, v := range row {
+					size += len(v)
+				}
+				if len(rows) >= exportParquetMaxRows || size > exportParquetMaxBytes {
+					return fmt.Errorf("joined data exceeds the parquet export limit of %d rows or %d MB; export csv, fewer columns or an inner join instead",
+						exportParquetMaxRows, exportParquetMaxBytes>>20)
+				}
+				rows = append(rows, row)
+				return nil
+			})
+		if err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		w.Header().Set("Content-Type", "application/vnd.apache.parquet")
+		w.Header().Set("Content-Disposition", "attachment; filename=joined_data.parquet")
+		if err := service.WriteParquet(w, headers, rows); err != nil {
+			exportLog.ErrorContext(r.Context(), "Error writing parquet", "error", err)
+		}
+		return
+	}
+
+	writer := csv.NewWriter(w)
+	started := false
+	err = service.JoinDataFrames(df1, df2, mapping, req.JoinOptions,
+		func(headers []string) error {
+			started = true
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", "attachment; filename=joined_data.csv")
+			return writer.Write(headers)
+		},
+		writer.Write)
+	writer.Flush()
+	if err == nil {
+		err = writer.Error()
+	}
+	if err != nil {
+		if !started {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		exportLog.ErrorContext(r.Context(), "Error streaming joined data", "error", err)
+	}
+}
+
 // ============================================================================
 // Helpers
 // ============================================================================
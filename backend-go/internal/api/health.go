@@ -0,0 +1,137 @@
+package api
+
+import (
+	"backend-go/internal/config"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ============================================================================
+// Health
+// ============================================================================
+
+// readinessTimeout bounds each readiness check
+const readinessTimeout = 2 * time.Second
+
+// DependencyStatus is the outcome of one readiness check
+type DependencyStatus struct {
+	Status     string `json:"status"` // ok, error or skipped
+	Required   bool   `json:"required"`
+	Error      string `json:"error,omitempty"`
+	Detail     string `json:"detail,omitempty"`
+	DurationMs int64  `json:"duration_ms"`
+}
+
+// ReadinessResponse is the body of GET /readyz
+type ReadinessResponse struct {
+	Status string                      `json:"status"` // ready or not_ready
+	Checks map[string]DependencyStatus `json:"checks"`
+}
+
+// HealthCheck handles GET /healthz
+// Liveness: the process serves requests. Dependencies are checked by /readyz.
+func (h *Handler) HealthCheck(w http.ResponseWriter, r *http.Request) {
+	w.Write([]byte("OK"))
+}
+
+// ReadinessCheck handles GET /readyz
+// Checks that the data and upload directories are writable, Ollama is reachable and the
+// active database connection, if any, answers. Answers 503 when a required
+// check fails; Ollama is only required with READYZ_REQUIRE_OLLAMA=true, as
+// matching and querying fall back to heuristics without it.
+func (h *Handler) ReadinessCheck(w http.ResponseWriter, r *http.Request) {
+	type check struct {
+		name     string
+		required bool
+		run      func(ctx context.Context) (detail string, err error)
+	}
+	checks := []check{
+		{"data_dir", true, func(context.Context) (string, error) {
+			dir := config.Get().Data.Dir
+			return dir, checkWritableDir(dir)
+		}},
+		{"uploads_dir", true, func(context.Context) (string, error) {
+			dir := uploadDir()
+			return dir, checkWritableDir(dir)
+		}},
+		{"ollama", strings.EqualFold(os.Getenv("READYZ_REQUIRE_OLLAMA"), "true"), func(ctx context.Context) (string, error) {
+			if h.LLMService == nil {
+				return "", errSkipped
+			}
+			return "", h.LLMService.Ping(ctx)
+		}},
+		{"database", true, func(ctx context.Context) (string, error) {
+			if h.CurrentDB == nil {
+				return "no active connection", errSkipped
+			}
+			return "", h.CurrentDB.Ping(ctx)
+		}},
+	}
+
+	resp := ReadinessResponse{Status: "ready", Checks: make(map[string]DependencyStatus, len(checks))}
+	var (
+		wg    sync.WaitGroup
+		mutex sync.Mutex
+	)
+	for _, c := range checks {
+		wg.Add(1)
+		go func(c check) {
+			defer wg.Done()
+			ctx, cancel := context.WithTimeout(r.Context(), readinessTimeout)
+			defer cancel()
+
+			start := time.Now()
+			detail, err := c.run(ctx)
+			status := DependencyStatus{Status: "ok", Required: c.required, Detail: detail, DurationMs: time.Since(start).Milliseconds()}
+			switch {
+			case err == errSkipped:
+				status.Status = "skipped"
+			case err != nil:
+				status.Status = "error"
+				status.Error = err.Error()
+			}
+
+			mutex.Lock()
+			defer mutex.Unlock()
+			resp.Checks[c.name] = status
+			if status.Status == "error" && c.required {
+				resp.Status = "not_ready"
+			}
+		}(c)
+	}
+	wg.Wait()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if resp.Status != "ready" {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+// errSkipped marks a check that doesn't apply, e.g. with no database connected
+var errSkipped = errors.New("skipped")
+
+// checkWritableDir creates the directory if needed and writes a probe file
+func checkWritableDir(dir string) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(dir, ".readyz-*")
+	if err != nil {
+		return err
+	}
+	name := f.Name()
+	_, err = f.Write([]byte("ok"))
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	os.Remove(name)
+	return err
+}
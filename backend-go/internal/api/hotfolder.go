@@ -0,0 +1,115 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/config"
+	"backend-go/internal/remote"
+	"backend-go/internal/service"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// ============================================================================
+// Hot Folder
+// ============================================================================
+
+// RunHotFolderFile ingests a file of the hot folder as a stored upload,
+// named inbound_<time>_<name>, and compares it with watch.reference
+func (h *Handler) RunHotFolderFile(ctx context.Context, file service.HotFolderFile, profile service.MatchingProfile) service.BatchPairResult {
+	reference := config.Get().Watch.Reference
+	pair := service.BatchPair{Name: file.Name, File1: service.BatchSource{Path: reference}}
+	result := service.BatchPairResult{BatchPair: pair}
+
+	upload, err := ingestHotFolderFile(ctx, file)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	pair.File2 = service.BatchSource{Upload: upload}
+	result.BatchPair = pair
+
+	df1, err := loadFileCopy(reference, "reference "+reference)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	df2, err := loadFileCopy(filepath.Join(uploadDir(), upload), upload)
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	df2.FileName = file.Name
+	h.compareFrames(ctx, &result, pair, df1, df2, profile)
+	return result
+}
+
+// AcceptsHotFolderFile tells the files of the hot folder that are ingested:
+// CSV files, optionally .gz or .zip compressed, like uploads
+func (h *Handler) AcceptsHotFolderFile(name string) bool {
+	return isAllowedUploadName(name)
+}
+
+// ingestHotFolderFile copies a local or s3 file of the hot folder to the
+// upload directory, returning the upload name
+func ingestHotFolderFile(ctx context.Context, file service.HotFolderFile) (string, error) {
+	var body io.ReadCloser
+	if strings.HasPrefix(file.Source, remote.SchemeS3+"://") {
+		obj, err := remote.Open(ctx, file.Source, config.Get().Watch.CredentialsRef)
+		if err != nil {
+			return "", err
+		}
+		body = obj.Body
+	} else {
+		f, err := os.Open(file.Source)
+		if err != nil {
+			return "", err
+		}
+		body = f
+	}
+	defer body.Close()
+
+	os.MkdirAll(uploadDir(), 0755)
+	name := fmt.Sprintf("inbound_%s_%s", time.Now().UTC().Format("20060102T150405"), filepath.Base(file.Name))
+	path := filepath.Join(uploadDir(), name)
+	if _, err := saveLimited(path, body, maxRemoteFileSize()); err != nil {
+		os.Remove(path)
+		return "", err
+	}
+	ingestLog.InfoContext(ctx, "Ingested hot folder file", "source", file.Source, "upload", name)
+	return name, nil
+}
+
+// GetHotFolder handles GET /api/v1/hotfolder
+// Returns the hot folder settings and its latest checks, newest first
+func (h *Handler) GetHotFolder(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.GetHotFolder().Status())
+}
+
+// ScanHotFolder handles POST /api/v1/hotfolder/scan
+// Polls the hot folder now instead of at the next interval
+func (h *Handler) ScanHotFolder(w http.ResponseWriter, r *http.Request) {
+	// A client hanging up must not cut a check short
+	checked, err := service.GetHotFolder().Scan(context.WithoutCancel(r.Context()))
+	if err != nil {
+		if !config.Get().Watch.Enabled() {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		apierr.Write(w, apierr.Upstream(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"checked": checked,
+		"status":  service.GetHotFolder().Status(),
+	})
+}
@@ -0,0 +1,188 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/models"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Context Interview
+// ============================================================================
+
+// InterviewStep is the next question of an interview and its progress
+type InterviewStep struct {
+	InterviewID string                    `json:"interview_id"`
+	Question    *models.Question          `json:"question"` // null once complete
+	Progress    service.InterviewProgress `json:"progress"`
+}
+
+// interviewAnswerRequest is the body of POST /context/interview/{id}/answer
+type interviewAnswerRequest struct {
+	QuestionID string      `json:"question_id"`
+	Answer     interface{} `json:"answer"`
+	Skip       bool        `json:"skip"` // Only for optional questions
+}
+
+// interviewStep computes the next question against the current contexts
+func interviewStep(iv *service.Interview) InterviewStep {
+	question, progress := iv.Next(state.State.GetContext(1), state.State.GetContext(2), service.GetMappingStore().Current())
+	return InterviewStep{InterviewID: iv.ID, Question: question, Progress: progress}
+}
+
+// StartInterview handles POST /api/v1/context/interview
+// Opens an interview over both loaded files and returns its first question.
+// The questions are asked in the locale of the request throughout.
+func (h *Handler) StartInterview(w http.ResponseWriter, r *http.Request) {
+	locale, err := requestLocale(r)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2)
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to start an interview"))
+		return
+	}
+
+	iv := service.GetInterviewStore().Start(h.analyzeDataFrame(df1), h.analyzeDataFrame(df2), df1.Headers, df2.Headers, locale)
+	audit.Annotate(r.Context(), "interview", iv.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(interviewStep(iv))
+}
+
+// GetInterview handles GET /api/v1/context/interview/{id}
+// The answers so far, the questions as they stand and the progress
+func (h *Handler) GetInterview(w http.ResponseWriter, r *http.Request) {
+	iv, ok := service.GetInterviewStore().Get(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Interview not found"))
+		return
+	}
+
+	ctx1, ctx2 := state.State.GetContext(1), state.State.GetContext(2)
+	mapping := service.GetMappingStore().Current()
+	_, progress := iv.Next(ctx1, ctx2, mapping)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"interview": iv,
+		"questions": iv.Questions(ctx1, ctx2, mapping),
+		"progress":  progress,
+	})
+}
+
+// NextInterviewQuestion handles GET /api/v1/context/interview/{id}/next
+func (h *Handler) NextInterviewQuestion(w http.ResponseWriter, r *http.Request) {
+	iv, ok := service.GetInterviewStore().Get(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Interview not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(interviewStep(iv))
+}
+
+// AnswerInterviewQuestion handles POST /api/v1/context/interview/{id}/answer
+// Stores the answer in the file's context (relationship answers in file 1's)
+// and returns the next question. Any question still asked may be answered
+// again; optional ones may be skipped.
+func (h *Handler) AnswerInterviewQuestion(w http.ResponseWriter, r *http.Request) {
+	store := service.GetInterviewStore()
+	iv, ok := store.Get(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Interview not found"))
+		return
+	}
+
+	var req interviewAnswerRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	var question *models.Question
+	for _, q := range iv.Questions(state.State.GetContext(1), state.State.GetContext(2), service.GetMappingStore().Current()) {
+		if q.ID == req.QuestionID {
+			q := q
+			question = &q
+			break
+		}
+	}
+	if question == nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Question %q is not asked in this interview", req.QuestionID)))
+		return
+	}
+	fileIndex := question.Metadata["file_index"].(int)
+
+	answer := service.InterviewAnswer{QuestionID: question.ID, FileIndex: fileIndex, Answer: req.Answer, Skipped: req.Skip}
+	if req.Skip {
+		if question.Required {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Question %q is required and cannot be skipped", question.ID)))
+			return
+		}
+		answer.Answer = nil
+	} else {
+		df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2)
+		if df1 == nil || df2 == nil {
+			apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to answer the interview"))
+			return
+		}
+		target, columns := 1, []string(nil)
+		switch fileIndex {
+		case 1:
+			columns = df1.Headers
+		case 2:
+			target, columns = 2, df2.Headers
+		}
+		if errs := service.ValidateAnswer("answer", *question, req.Answer, columns, df1.Headers, df2.Headers); len(errs) > 0 {
+			apierr.Write(w, apierr.BadRequest("Invalid answer").WithDetail("errors", errs))
+			return
+		}
+
+		ctx := state.State.GetContext(target)
+		if ctx == nil {
+			ctx = models.NewContext()
+		}
+		if err := service.ApplyContextAnswer(ctx, *question, req.Answer, df1.Headers, df2.Headers); err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		state.State.SetContext(target, ctx)
+	}
+
+	iv, err := store.Record(iv.ID, answer)
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "interview", iv.ID)
+	audit.Annotate(r.Context(), "question", question.ID)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(interviewStep(iv))
+}
+
+// DeleteInterview handles DELETE /api/v1/context/interview/{id}
+// Ends an interview; the answers stay in the contexts
+func (h *Handler) DeleteInterview(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !service.GetInterviewStore().Delete(id) {
+		apierr.Write(w, apierr.NotFound("Interview not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
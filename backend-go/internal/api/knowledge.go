@@ -0,0 +1,57 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Mapping Knowledge Base
+// ============================================================================
+
+// ListKnowledge handles GET /api/v1/knowledge
+// Approved mappings of past dataset pairs, most recent first.
+// Query: q filters on file and column names
+func (h *Handler) ListKnowledge(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"entries": service.GetMappingKnowledge().List(r.URL.Query().Get("q")),
+	})
+}
+
+// GetKnowledgeSuggestions handles GET /api/v1/knowledge/suggestions
+// Column pairs of the loaded files approved before for similar schemas
+func (h *Handler) GetKnowledgeSuggestions(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to suggest mappings"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"suggestions": service.GetMappingKnowledge().Suggest(df1, df2),
+	})
+}
+
+// DeleteKnowledgeEntry handles DELETE /api/v1/knowledge/{scope}
+func (h *Handler) DeleteKnowledgeEntry(w http.ResponseWriter, r *http.Request) {
+	scope := chi.URLParam(r, "scope")
+	if err := service.GetMappingKnowledge().Delete(scope); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "scope", scope)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
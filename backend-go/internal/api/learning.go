@@ -0,0 +1,233 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+)
+
+// ============================================================================
+// Learning / Active Feedback
+// ============================================================================
+
+// GetFeedbackSuggestions handles GET /api/v1/feedback/suggestions
+// Returns the column pairs whose labels would teach the learners the most
+func (h *Handler) GetFeedbackSuggestions(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to suggest pairs for feedback"))
+		return
+	}
+
+	limit := getIntParam(r, "limit", 5)
+	threshold := 50.0
+	if t := r.URL.Query().Get("threshold"); t != "" {
+		if v, err := strconv.ParseFloat(t, 64); err == nil {
+			threshold = v
+		}
+	}
+
+	ctx1 := state.State.GetContext(1)
+	ctx2 := state.State.GetContext(2)
+
+	results, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarity(df1, df2, ctx1, ctx2)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+		return
+	}
+	selector := service.NewActiveLearningSelector(threshold)
+	suggestions := selector.SelectForLabeling(service.DatasetPairScope(df1, df2), results, limit)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"suggestions":     suggestions,
+		"threshold":       threshold,
+		"candidate_pairs": len(results),
+	})
+}
+
+// ExportLearning handles GET /api/v1/learning/export
+// Bundles feedback, pattern rules, adaptive weights and calibration into one JSON document
+func (h *Handler) ExportLearning(w http.ResponseWriter, r *http.Request) {
+	bundle := service.ExportLearningBundle()
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=learning_bundle_%s.json", bundle.ExportedAt.Format("20060102_150405")))
+	json.NewEncoder(w).Encode(bundle)
+}
+
+// ImportLearning handles POST /api/v1/learning/import?strategy=merge|replace|keep_local
+func (h *Handler) ImportLearning(w http.ResponseWriter, r *http.Request) {
+	var bundle service.LearningBundle
+	if err := json.NewDecoder(r.Body).Decode(&bundle); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	summary, err := service.ImportLearningBundle(&bundle, r.URL.Query().Get("strategy"))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Error importing bundle: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"summary": summary,
+	})
+}
+
+// GetLearningWeights handles GET /api/v1/learning/weights
+// Returns the adaptive weights, training history and a convergence summary
+func (h *Handler) GetLearningWeights(w http.ResponseWriter, r *http.Request) {
+	learner := service.GetAdaptiveLearner()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"weights":          learner.GetWeights(),
+		"default_weights":  service.DefaultAdaptiveWeights,
+		"training_history": learner.GetTrainingHistory(),
+		"convergence":      learner.GetConvergenceStats(),
+	})
+}
+
+// SetLearningWeights handles PUT /api/v1/learning/weights
+// Body is either {"weights": {...}} to override or {"reset": true} to restore defaults
+func (h *Handler) SetLearningWeights(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Weights *service.AdaptiveWeights `json:"weights"`
+		Reset   bool                     `json:"reset"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	learner := service.GetAdaptiveLearner()
+	switch {
+	case req.Reset:
+		if err := learner.Reset(); err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error resetting weights: %v", err)))
+			return
+		}
+	case req.Weights != nil:
+		if err := learner.SetWeights(*req.Weights); err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+	default:
+		apierr.Write(w, apierr.BadRequest("Provide weights or reset"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"weights": learner.GetWeights(),
+	})
+}
+
+// GetLearningCalibration handles GET /api/v1/learning/calibration
+// Returns bucket statistics plus reliability-diagram data (predicted vs actual per bucket)
+func (h *Handler) GetLearningCalibration(w http.ResponseWriter, r *http.Request) {
+	calibrator := service.GetConfidenceCalibrator()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"stats":       calibrator.GetCalibrationStats(),
+		"reliability": calibrator.GetReliabilityReport(),
+	})
+}
+
+// ResetLearningCalibration handles POST /api/v1/learning/calibration/reset
+func (h *Handler) ResetLearningCalibration(w http.ResponseWriter, r *http.Request) {
+	if err := service.GetConfidenceCalibrator().Reset(); err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error resetting calibration: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": "Calibration reset",
+	})
+}
+
+// GetLearningPatterns handles GET /api/v1/learning/patterns
+// Lists learned pattern rules and token mappings with their evidence counts
+func (h *Handler) GetLearningPatterns(w http.ResponseWriter, r *http.Request) {
+	learner := service.GetPatternLearner()
+	patterns := learner.GetPatterns()
+
+	mappings := []service.TokenMapping{}
+	for _, m := range learner.GetTokenMappings() {
+		mappings = append(mappings, m)
+	}
+	sort.Slice(mappings, func(i, j int) bool {
+		return mappings[i].Occurrences > mappings[j].Occurrences
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"pattern_rules":  patterns,
+		"token_mappings": mappings,
+		"total_rules":    len(patterns),
+		"total_mappings": len(mappings),
+	})
+}
+
+// DeleteLearningPattern handles DELETE /api/v1/learning/patterns?pattern1=...&pattern2=...
+// or DELETE /api/learning/patterns?token1=...&token2=... for a token mapping
+func (h *Handler) DeleteLearningPattern(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	learner := service.GetPatternLearner()
+
+	var err error
+	switch {
+	case q.Get("pattern1") != "" && q.Get("pattern2") != "":
+		err = learner.DeletePatternRule(q.Get("pattern1"), q.Get("pattern2"))
+	case q.Get("token1") != "" && q.Get("token2") != "":
+		err = learner.DeleteTokenMapping(q.Get("token1"), q.Get("token2"))
+	default:
+		apierr.Write(w, apierr.BadRequest("Provide pattern1 and pattern2, or token1 and token2"))
+		return
+	}
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// DryRunLearningPatterns handles POST /api/v1/learning/patterns/dry-run
+// Shows which learned rules would fire for a column pair without recording anything
+func (h *Handler) DryRunLearningPatterns(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		File1Column string `json:"file1_column"`
+		File2Column string `json:"file2_column"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.File1Column == "" || req.File2Column == "" {
+		apierr.Write(w, apierr.BadRequest("file1_column and file2_column are required"))
+		return
+	}
+
+	explanation := service.GetPatternLearner().ExplainPatternBoost(req.File1Column, req.File2Column)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(explanation)
+}
@@ -0,0 +1,129 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ============================================================================
+// Record Linkage
+// ============================================================================
+
+// BuildLinkageIndex handles POST /api/v1/linkage/index
+// Indexes one file's key column with banded MinHash LSH
+func (h *Handler) BuildLinkageIndex(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileIndex int    `json:"file_index"`
+		Column    string `json:"column"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.FileIndex != 1 && req.FileIndex != 2 {
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
+		return
+	}
+
+	df := state.State.GetDataFrame(req.FileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(req.FileIndex))
+		return
+	}
+
+	info, err := service.GetRecordLinker().BuildIndex(df, req.FileIndex, req.Column)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	service.GetWebhookStore().Notify(service.WebhookEvent{
+		Event: service.EventLinkageCompleted,
+		Summary: fmt.Sprintf("Indexed %s of %s: %d rows, %d distinct values in %dms",
+			info.Column, df.FileName, info.Rows, info.Distinct, info.BuildMs),
+		Data: info,
+	})
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"index":   info,
+	})
+}
+
+// GetLinkageIndex handles GET /api/v1/linkage/index
+func (h *Handler) GetLinkageIndex(w http.ResponseWriter, r *http.Request) {
+	linker := service.GetRecordLinker()
+	info := linker.Info()
+
+	resp := map[string]interface{}{
+		"indexed": info != nil,
+	}
+	if info != nil {
+		resp["index"] = info
+		resp["stale"] = linker.IsStale(state.State.GetDataFrame(info.FileIndex))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// QueryLinkage handles POST /api/v1/linkage/query
+// Links explicit values, or every value of a column in the other file, to the indexed rows
+func (h *Handler) QueryLinkage(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Column    string   `json:"column"`
+		Values    []string `json:"values"`
+		Threshold float64  `json:"threshold"`
+		Limit     int      `json:"limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.Limit <= 0 {
+		req.Limit = 5
+	}
+
+	linker := service.GetRecordLinker()
+	info := linker.Info()
+	if info == nil {
+		apierr.Write(w, apierr.BadRequest("No linkage index built; POST /api/v1/linkage/index first"))
+		return
+	}
+	if linker.IsStale(state.State.GetDataFrame(info.FileIndex)) {
+		apierr.Write(w, apierr.Conflict("Linkage index is stale; the indexed file has changed"))
+		return
+	}
+
+	var (
+		links []service.RecordLink
+		stats service.LinkageStats
+		err   error
+	)
+	if len(req.Values) > 0 {
+		links, stats, err = linker.LinkValues(req.Values, req.Threshold, req.Limit)
+	} else {
+		otherIndex := 3 - info.FileIndex
+		df := state.State.GetDataFrame(otherIndex)
+		if df == nil {
+			apierr.Write(w, apierr.FileNotLoaded(otherIndex))
+			return
+		}
+		links, stats, err = linker.Link(df, req.Column, req.Threshold, req.Limit)
+	}
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"index": info,
+		"links": links,
+		"stats": stats,
+	})
+}
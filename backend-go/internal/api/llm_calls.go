@@ -0,0 +1,185 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/llm"
+	"backend-go/internal/service"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// LLM Health and Call Log
+// ============================================================================
+
+// LLMHealthResponse is the body of GET /llm/health
+type LLMHealthResponse struct {
+	Health *llm.ModelHealth `json:"health"`
+	WarmUp *llm.ModelHealth `json:"warm_up"` // At startup; null until it ends
+}
+
+// GetLLMHealth handles GET /api/v1/llm/health
+// Sends a trivial prompt to the model and reports whether it answered, how
+// fast and how long loading it took, with the outcome of the warm-up at
+// startup. Answers 503 when the model did not answer.
+func (h *Handler) GetLLMHealth(w http.ResponseWriter, r *http.Request) {
+	if h.LLMService == nil {
+		apierr.Write(w, apierr.Upstream("LLM service not configured"))
+		return
+	}
+	resp := LLMHealthResponse{
+		Health: h.LLMService.CheckHealth(r.Context()),
+		WarmUp: h.LLMService.LastWarmUp(),
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	if !resp.Health.Available {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	json.NewEncoder(w).Encode(resp)
+}
+
+const (
+	defaultLLMCallLimit = 100
+	maxLLMCallLimit     = 1000
+)
+
+// llmCallFilter reads the filter of the LLM call endpoints from the query:
+// task, model, outcome, since and until (RFC 3339), limit and offset
+func llmCallFilter(r *http.Request) (service.LLMCallFilter, *apierr.Error) {
+	q := r.URL.Query()
+	filter := service.LLMCallFilter{Task: q.Get("task"), Model: q.Get("model"), Outcome: q.Get("outcome"), Limit: defaultLLMCallLimit}
+	for _, param := range []struct {
+		name string
+		dst  *time.Time
+	}{{"since", &filter.Since}, {"until", &filter.Until}} {
+		v := q.Get(param.name)
+		if v == "" {
+			continue
+		}
+		t, err := time.Parse(time.RFC3339, v)
+		if err != nil {
+			return filter, apierr.BadRequest(param.name+" must be an RFC 3339 time").WithDetail(param.name, v)
+		}
+		*param.dst = t
+	}
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxLLMCallLimit {
+			return filter, apierr.BadRequest("limit must be between 1 and " + strconv.Itoa(maxLLMCallLimit))
+		}
+		filter.Limit = limit
+	}
+	if v := q.Get("offset"); v != "" {
+		offset, err := strconv.Atoi(v)
+		if err != nil || offset < 0 {
+			return filter, apierr.BadRequest("offset must not be negative")
+		}
+		filter.Offset = offset
+	}
+	return filter, nil
+}
+
+// ListLLMCalls handles GET /api/v1/llm/calls
+// Lists the logged LLM calls, newest first, without their prompts and
+// responses, filtered by task, model, outcome, since and until and paged by
+// limit (default 100, at most 1000) and offset
+func (h *Handler) ListLLMCalls(w http.ResponseWriter, r *http.Request) {
+	filter, apiErr := llmCallFilter(r)
+	if apiErr != nil {
+		apierr.Write(w, apiErr)
+		return
+	}
+
+	store := service.GetLLMCallStore()
+	calls, total := store.List(filter)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"calls":  calls,
+		"count":  len(calls),
+		"total":  total,
+		"offset": filter.Offset,
+		"mode":   store.Mode(),
+	})
+}
+
+// GetLLMCall handles GET /api/v1/llm/calls/{id}
+// A logged call with its prompt and response, unless redacted
+func (h *Handler) GetLLMCall(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	call, ok := service.GetLLMCallStore().Get(id)
+	if !ok {
+		apierr.Write(w, apierr.NotFound("LLM call not found: "+id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(call)
+}
+
+// GetLLMCallSummary handles GET /api/v1/llm/calls/summary
+// Totals the logged calls matching the filters of /llm/calls, overall, per
+// task and per model: outcomes, estimated tokens, latency and the cost at
+// prompt_price and response_price per million tokens
+func (h *Handler) GetLLMCallSummary(w http.ResponseWriter, r *http.Request) {
+	filter, apiErr := llmCallFilter(r)
+	if apiErr != nil {
+		apierr.Write(w, apiErr)
+		return
+	}
+	prices := map[string]float64{}
+	for _, name := range []string{"prompt_price", "response_price"} {
+		v := r.URL.Query().Get(name)
+		if v == "" {
+			continue
+		}
+		price, err := strconv.ParseFloat(v, 64)
+		if err != nil || price < 0 {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("%s must be a price per million tokens, not negative", name)))
+			return
+		}
+		prices[name] = price
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.GetLLMCallStore().Summary(filter, prices["prompt_price"], prices["response_price"]))
+}
+
+// RedactLLMCalls handles POST /api/v1/llm/calls/redact
+// Removes the prompts and responses of the logged calls, keeping their
+// sizes, latency and outcome
+func (h *Handler) RedactLLMCalls(w http.ResponseWriter, r *http.Request) {
+	redacted, err := service.GetLLMCallStore().Redact()
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to redact the LLM call log"))
+		return
+	}
+	audit.Annotate(r.Context(), "redacted", redacted)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"redacted": redacted,
+	})
+}
+
+// ClearLLMCalls handles DELETE /api/v1/llm/calls
+func (h *Handler) ClearLLMCalls(w http.ResponseWriter, r *http.Request) {
+	if err := service.GetLLMCallStore().Clear(); err != nil {
+		apierr.Write(w, apierr.Internal("Failed to clear the LLM call log"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
@@ -0,0 +1,245 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/logging"
+	"backend-go/internal/models"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+var mappingsLog = logging.Component("mappings")
+
+// ============================================================================
+// Approved Mapping
+// ============================================================================
+
+// GetApprovedMapping handles GET /api/v1/mappings/approved
+func (h *Handler) GetApprovedMapping(w http.ResponseWriter, r *http.Request) {
+	scope, err := service.CurrentMappingScope()
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	mapping := service.GetMappingStore().Get(scope)
+	if mapping == nil {
+		mapping = &service.ApprovedMapping{Scope: scope, Mappings: []service.ColumnMapping{}}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(mapping)
+}
+
+// AcceptMapping handles POST /api/v1/mappings/accept
+func (h *Handler) AcceptMapping(w http.ResponseWriter, r *http.Request) {
+	h.reviewMapping(w, r, service.MappingAccepted, service.MappingSourceSuggested)
+}
+
+// RejectMapping handles POST /api/v1/mappings/reject
+func (h *Handler) RejectMapping(w http.ResponseWriter, r *http.Request) {
+	h.reviewMapping(w, r, service.MappingRejected, service.MappingSourceSuggested)
+}
+
+// CreateManualMapping handles POST /api/v1/mappings/manual
+// Adds a user-defined mapping that the matcher didn't suggest
+func (h *Handler) CreateManualMapping(w http.ResponseWriter, r *http.Request) {
+	h.reviewMapping(w, r, service.MappingAccepted, service.MappingSourceManual)
+}
+
+// reviewMapping records a review in the approved mapping and as match feedback
+func (h *Handler) reviewMapping(w http.ResponseWriter, r *http.Request, status, source string) {
+	var req models.MappingReview
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.File1Column == "" || req.File2Column == "" {
+		apierr.Write(w, apierr.BadRequest("file1_column and file2_column are required"))
+		return
+	}
+
+	scope, err := service.CurrentMappingScope()
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+	if !hasColumn(df1, req.File1Column) || !hasColumn(df2, req.File2Column) {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Column pair %s -> %s not found in the loaded files", req.File1Column, req.File2Column)))
+		return
+	}
+
+	confidence := req.Confidence
+	if source == service.MappingSourceManual && confidence == 0 {
+		confidence = 100
+	}
+
+	formatTransform := req.FormatTransform
+	if status != service.MappingAccepted {
+		formatTransform = nil
+	} else if formatTransform == nil {
+		formatTransform = h.EnhancedSimilarityService.SuggestFormatTransform(df1, df2, req.File1Column, req.File2Column)
+	} else if err := service.ValidateFormatTransform(formatTransform); err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	mapping, err := service.GetMappingStore().Review(scope, service.ColumnMapping{
+		File1Column: req.File1Column,
+		File2Column: req.File2Column,
+		Status:      status,
+		Source:      source,
+		Confidence:  confidence,
+		JoinKey:     req.JoinKey && status == service.MappingAccepted,
+		Transform:   req.Transform,
+		Note:        req.Note,
+
+		FormatTransform: formatTransform,
+	})
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error saving mapping: %v", err)))
+		return
+	}
+	audit.Annotate(r.Context(), "scope", scope)
+	audit.Annotate(r.Context(), "file1_column", req.File1Column)
+	audit.Annotate(r.Context(), "file2_column", req.File2Column)
+
+	// Reviews double as match feedback so the matcher learns from curation
+	entry := service.FeedbackEntry{
+		File1Column:    req.File1Column,
+		File2Column:    req.File2Column,
+		IsCorrect:      status == service.MappingAccepted,
+		UserNote:       req.Note,
+		NameSimilarity: req.NameSimilarity,
+		DataSimilarity: req.DataSimilarity,
+		PatternScore:   req.PatternScore,
+		Confidence:     req.Confidence,
+		Scope:          scope,
+	}
+	if _, err := service.GetFeedbackSystem().AddFeedback(entry); err != nil {
+		mappingsLog.ErrorContext(r.Context(), "Error recording feedback", "error", err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"mapping": mapping,
+	})
+}
+
+// DeleteApprovedMapping handles DELETE /api/v1/mappings/approved
+// Removes one review (?file1_column=&file2_column=) or the whole document
+func (h *Handler) DeleteApprovedMapping(w http.ResponseWriter, r *http.Request) {
+	scope, err := service.CurrentMappingScope()
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	store := service.GetMappingStore()
+	file1Col := r.URL.Query().Get("file1_column")
+	file2Col := r.URL.Query().Get("file2_column")
+
+	w.Header().Set("Content-Type", "application/json")
+	if file1Col == "" && file2Col == "" {
+		if err := store.Clear(scope); err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error clearing mapping: %v", err)))
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"success": true})
+		return
+	}
+
+	mapping, err := store.Remove(scope, file1Col, file2Col)
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"mapping": mapping,
+	})
+}
+
+// PreviewApprovedJoin handles GET /api/v1/mappings/join-preview?rows=20
+// Inner-joins the loaded files on the approved join keys
+func (h *Handler) PreviewApprovedJoin(w http.ResponseWriter, r *http.Request) {
+	scope, err := service.CurrentMappingScope()
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	rows := getIntParam(r, "rows", 20)
+	if rows < 0 {
+		rows = 0
+	}
+
+	mapping := service.GetMappingStore().Get(scope)
+	preview, err := service.PreviewJoin(state.State.GetDataFrame(1), state.State.GetDataFrame(2), mapping.JoinKeys(), rows)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(preview)
+}
+
+// ExportMapping handles GET /api/v1/mapping
+// Downloads the approved mapping as a versioned, portable JSON artifact
+func (h *Handler) ExportMapping(w http.ResponseWriter, r *http.Request) {
+	artifact, err := service.ExportMappingArtifact(
+		state.State.GetDataFrame(1),
+		state.State.GetDataFrame(2),
+		service.GetMappingStore().Current(),
+	)
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=mapping_%s.json", artifact.ExportedAt.Format("20060102_150405")))
+	json.NewEncoder(w).Encode(artifact)
+}
+
+// ImportMapping handles POST /api/v1/mapping
+// Applies an exported mapping artifact to the loaded pair of files
+func (h *Handler) ImportMapping(w http.ResponseWriter, r *http.Request) {
+	var artifact service.MappingArtifact
+	if err := json.NewDecoder(r.Body).Decode(&artifact); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	summary, err := service.ImportMappingArtifact(&artifact, state.State.GetDataFrame(1), state.State.GetDataFrame(2))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Error importing mapping: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"summary": summary,
+		"mapping": service.GetMappingStore().Current(),
+	})
+}
+
+func hasColumn(df *state.DataFrame, column string) bool {
+	if df == nil {
+		return false
+	}
+	for _, h := range df.Headers {
+		if h == column {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,132 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/llm"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Ontology Mapping
+// ============================================================================
+
+// conceptDictionaryResponse is a concept-tagged dictionary and how its
+// concepts were proposed
+type conceptDictionaryResponse struct {
+	Dictionary     *service.DataDictionary `json:"dictionary"`
+	UseAI          bool                    `json:"use_ai"`
+	FallbackReason string                  `json:"fallback_reason,omitempty"`
+	Truncation     *llm.TruncationReport   `json:"truncation,omitempty"` // How the LLM's prompts were fitted, with use_ai
+}
+
+// ListOntologies handles GET /api/v1/ontologies
+// Lists the built-in vocabularies columns can be mapped to
+func (h *Handler) ListOntologies(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"vocabularies": service.Vocabularies(),
+	})
+}
+
+// GetOntology handles GET /api/v1/ontologies/{id}
+// Returns a vocabulary with its concepts
+func (h *Handler) GetOntology(w http.ResponseWriter, r *http.Request) {
+	vocab, ok := service.GetVocabulary(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Vocabulary not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(vocab)
+}
+
+// GetConceptDictionary handles GET /api/v1/ontologies/{id}/dictionary/{fileIndex}
+// The data dictionary of a loaded file with each column tagged with the
+// concept of the vocabulary it represents.
+// Query: profile, use_ai=true|false (default: the profile's), format=json|markdown
+func (h *Handler) GetConceptDictionary(w http.ResponseWriter, r *http.Request) {
+	vocab, ok := service.GetVocabulary(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Vocabulary not found"))
+		return
+	}
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+	profile, ok := service.GetMatchingProfileStore().Get(r.URL.Query().Get("profile"))
+	if !ok {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown matching profile: %s", r.URL.Query().Get("profile"))))
+		return
+	}
+	useAI := profile.UseAI
+	if v := r.URL.Query().Get("use_ai"); v != "" {
+		useAI = v == "true"
+	}
+	format := r.URL.Query().Get("format")
+	if format != "" && format != service.DictionaryFormatJSON && format != service.DictionaryFormatMarkdown {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use json or markdown)", format)))
+		return
+	}
+
+	ctx := state.State.GetContext(fileIndex)
+	if ctx == nil {
+		ctx = h.ContextService.GetContext(fileIndex)
+	}
+	dict := h.EnhancedSimilarityService.BuildDataDictionary(df, fileIndex, ctx)
+	h.EnhancedSimilarityService.TagConcepts(dict, vocab, profile)
+
+	// The LLM's proposals replace the heuristic tags; the heuristic ones
+	// stand when it is unavailable
+	fallbackReason := ""
+	var truncation *llm.TruncationReport
+	if useAI {
+		fallbackReason = "LLM service not configured"
+		if h.LLMService != nil {
+			columns := make([]llm.ColumnSchema, len(dict.Columns))
+			for i, c := range dict.Columns {
+				columns[i] = llm.ColumnSchema{Name: c.Name, Type: c.InferredType, Examples: c.SampleValues}
+			}
+			llmCtx, report := llm.WithTruncationReport(r.Context())
+			proposals, err := h.LLMService.ProposeConcepts(llmCtx, columns, vocab.ConceptOptions())
+			truncation = report
+			auditTruncation(r, report)
+			if err == nil {
+				applied := service.ApplyConceptProposals(dict, vocab, proposals)
+				similarityLog.InfoContext(r.Context(), "Applied LLM concept proposals", "vocabulary", vocab.ID, "applied", applied)
+				fallbackReason = ""
+			} else {
+				fallbackReason = err.Error()
+				similarityLog.WarnContext(r.Context(), "LLM concept proposals failed", "error", err)
+			}
+		}
+	}
+
+	if format == service.DictionaryFormatMarkdown {
+		w.Header().Set("Content-Type", "text/markdown; charset=utf-8")
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=concepts_%s_file%d.md", vocab.ID, fileIndex))
+		w.Write([]byte(service.RenderDictionaryMarkdown(dict)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(conceptDictionaryResponse{
+		Dictionary:     dict,
+		UseAI:          useAI,
+		FallbackReason: fallbackReason,
+		Truncation:     truncation,
+	})
+}
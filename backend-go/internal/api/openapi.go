@@ -0,0 +1,452 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/auth"
+	"backend-go/internal/llm"
+	"backend-go/internal/models"
+	"backend-go/internal/service"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// OpenAPI
+// ============================================================================
+
+// The spec lists every route registered on the router, so it can't drift
+// from the handlers; routeDocs adds what the router can't know: summaries,
+// query parameters and body types, whose schemas come from the Go types.
+
+// routeDoc documents a route beyond its method and path
+type routeDoc struct {
+	Summary  string
+	Query    []string    // "name" or "name:type" (integer, number, boolean; string by default)
+	Request  interface{} // Zero value of the body type
+	Response interface{} // Zero value of the 200 body type
+}
+
+// routeDocs is keyed by "METHOD /pattern"; legacy aliases share their successor's
+var routeDocs = map[string]routeDoc{
+	"GET /healthz":                    {Summary: "Liveness check"},
+	"GET /readyz":                     {Summary: "Readiness check of each dependency; 503 when a required one fails", Response: ReadinessResponse{}},
+	"GET /openapi.json":               {Summary: "This OpenAPI specification"},
+	"GET /docs":                       {Summary: "Swagger UI over the specification"},
+	"GET /api/v1/status":              {Summary: "Loaded files and their sizes", Response: models.StatusResponse{}},
+	"POST /api/v1/upload":             {Summary: "Upload file 1 or 2 as multipart form data (file, file_index and dialect overrides)", Response: models.UploadResponse{}},
+	"GET /api/v1/preview":             {Summary: "Rows of a file", Query: []string{"file_index:integer", "columns", "sample", "seed:integer", "sort_by", "sort_dir", "offset:integer", "limit:integer"}, Response: []map[string]interface{}{}},
+	"GET /api/v1/kpis":                {Summary: "KPIs of a file from the workspace's definitions", Query: []string{"file_index:integer", "workspace"}, Response: []models.KPI{}},
+	"GET /api/v1/correlation":         {Summary: "Correlation of two columns, or of every numeric column pair across the files", Query: []string{"col1", "col2", "file_index:integer"}},
+	"GET /api/v1/column-similarity":   {Summary: "Column matches between the files", Query: []string{"use_ai:boolean", "profile", "blocking", "name_algorithm", "history:boolean"}},
+	"POST /api/v1/filter":             {Summary: "Filter, sort and page the rows of file 1", Request: models.FilterRequest{}, Response: models.FilterResponse{}},
+	"POST /api/v1/query":              {Summary: "Answer a natural-language question about the data", Request: QueryRequest{}, Response: QueryResponse{}},
+	"POST /api/v1/context/submit":     {Summary: "Store the answers to the context questions", Query: []string{"locale"}, Request: models.ContextSubmitRequest{}},
+	"GET /api/v1/context/{fileIndex}": {Summary: "Context of a file", Response: models.Context{}},
+	"GET /api/v1/context/status":      {Summary: "Which files have context", Response: models.ContextStatusResponse{}},
+	"GET /api/v1/config/ollama":       {Summary: "LLM connection settings", Response: models.OllamaConfig{}},
+	"POST /api/v1/config/ollama":      {Summary: "Change the LLM connection settings", Request: models.OllamaConfig{}},
+
+	"GET /api/v1/dashboard":                       {Summary: "Everything the landing screen shows in one payload"},
+	"GET /api/v1/similarity/graph":                {Summary: "Similarity graph of the columns of both files", Response: models.SimilarityGraph{}},
+	"GET /api/v1/graph/analyze":                   {Summary: "Communities, centrality and indirect relationships of the similarity graph", Query: []string{"profile", "max_depth:integer", "limit:integer", "source", "target"}, Response: service.GraphAnalysis{}},
+	"GET /api/v1/graph/export":                    {Summary: "Download the similarity graph as GraphML or Cytoscape JSON", Query: []string{"format", "profile"}},
+	"GET /api/v1/similarity/runs":                 {Summary: "Stored column similarity runs, newest first", Response: []service.SimilarityRunSummary{}},
+	"GET /api/v1/similarity/runs/diff":            {Summary: "Matches that appeared, disappeared or changed between two runs", Query: []string{"from", "to"}, Response: service.SimilarityRunDiff{}},
+	"GET /api/v1/similarity/runs/{id}":            {Summary: "A stored column similarity run with its matches", Response: service.SimilarityRun{}},
+	"DELETE /api/v1/similarity/runs/{id}":         {Summary: "Delete a stored similarity run"},
+	"GET /api/v1/similarity/candidates":           {Summary: "Ranked match candidates of one column with every signal", Query: []string{"file:integer", "column", "profile", "name_algorithm", "limit:integer"}, Response: []ColumnCandidate{}},
+	"GET /api/v1/similarity/ensemble":             {Summary: "Column matches combining the heuristic, embedding and LLM matchers, with per-matcher agreement", Query: []string{"profile", "limit:integer"}, Response: service.EnsembleReport{}},
+	"GET /api/v1/context/templates":               {Summary: "Context templates for common business domains", Response: []service.ContextTemplate{}},
+	"GET /api/v1/context/templates/{id}":          {Summary: "A context template with its synonym pack", Response: service.ContextTemplate{}},
+	"POST /api/v1/context/templates/{id}/apply":   {Summary: "Pre-fill a file's context from a template and import its synonym pack", Request: applyContextTemplateRequest{}, Response: models.Context{}},
+	"POST /api/v1/context/{fileIndex}/auto":       {Summary: "LLM draft of a file's context from its schema and masked samples, for review"},
+	"POST /api/v1/context/interview":              {Summary: "Start an adaptive context interview of both files", Query: []string{"locale"}, Response: InterviewStep{}},
+	"GET /api/v1/context/interview/{id}":          {Summary: "Answers, current questions and progress of an interview"},
+	"GET /api/v1/context/interview/{id}/next":     {Summary: "Next question of an interview", Response: InterviewStep{}},
+	"POST /api/v1/context/interview/{id}/answer":  {Summary: "Answer or skip an interview question and get the next one", Request: interviewAnswerRequest{}, Response: InterviewStep{}},
+	"DELETE /api/v1/context/interview/{id}":       {Summary: "End an interview"},
+	"POST /api/v1/context/questions":              {Summary: "Context questions of both files and their relationship", Query: []string{"locale"}, Response: models.QuestionsResponse{}},
+	"GET /api/v1/questions/{fileIndex}":           {Summary: "Context questions of an analyzed file", Query: []string{"locale"}, Response: []models.Question{}},
+	"GET /api/v1/config/kpis":                     {Summary: "KPI definitions of a workspace", Query: []string{"workspace"}},
+	"PUT /api/v1/config/kpis":                     {Summary: "Replace the KPI definitions of a workspace", Query: []string{"workspace"}, Request: kpiConfigRequest{}},
+	"DELETE /api/v1/config/kpis":                  {Summary: "Restore the default KPIs of a workspace", Query: []string{"workspace"}},
+	"GET /api/v1/admin/runtime":                   {Summary: "Goroutines, heap usage and the memory of the loaded files", Response: RuntimeStats{}},
+	"GET /api/v1/admin/pprof/*":                   {Summary: "Go profiling data (net/http/pprof): index, heap, goroutine, profile, trace, ..."},
+	"POST /api/v1/admin/pprof/symbol":             {Summary: "Look up program counters, for go tool pprof"},
+	"GET /api/v1/audit":                           {Summary: "Audit log of changes and exports, newest first", Query: []string{"user", "action", "since", "until", "limit:integer"}},
+	"GET /api/v1/llm/health":                      {Summary: "Whether the model answers a trivial prompt, how fast, and the startup warm-up; 503 when it doesn't", Response: LLMHealthResponse{}},
+	"GET /api/v1/llm/calls":                       {Summary: "Logged LLM calls without their prompts and responses, newest first", Query: []string{"task", "model", "outcome", "since", "until", "limit:integer", "offset:integer"}},
+	"GET /api/v1/llm/calls/summary":               {Summary: "Outcomes, estimated tokens, latency and cost of the logged LLM calls, per task and model", Query: []string{"task", "model", "outcome", "since", "until", "prompt_price:number", "response_price:number"}, Response: service.LLMCallSummary{}},
+	"GET /api/v1/llm/calls/{id}":                  {Summary: "A logged LLM call with its prompt and response, unless redacted", Response: llm.Call{}},
+	"POST /api/v1/llm/calls/redact":               {Summary: "Remove the prompts and responses of the logged LLM calls"},
+	"DELETE /api/v1/llm/calls":                    {Summary: "Clear the LLM call log"},
+	"GET /api/v1/webhooks":                        {Summary: "Registered webhooks, without their secrets", Response: []service.Webhook{}},
+	"POST /api/v1/webhooks":                       {Summary: "Register a URL notified when analyses complete", Request: webhookRequest{}, Response: service.Webhook{}},
+	"DELETE /api/v1/webhooks/{id}":                {Summary: "Remove a webhook"},
+	"POST /api/v1/webhooks/{id}/test":             {Summary: "Send a test event to a webhook", Response: service.WebhookDelivery{}},
+	"GET /api/v1/uploads":                         {Summary: "Stored uploads with their sizes and ages"},
+	"DELETE /api/v1/uploads/{name}":               {Summary: "Delete a stored upload, unloading the file read from it"},
+	"POST /api/v1/uploads/sessions":               {Summary: "Start a resumable upload"},
+	"GET /api/v1/uploads/sessions/{id}":           {Summary: "Offset to resume a resumable upload from"},
+	"PUT /api/v1/uploads/sessions/{id}":           {Summary: "Append a chunk at ?offset= to a resumable upload"},
+	"POST /api/v1/uploads/sessions/{id}/complete": {Summary: "Finish a resumable upload, registering the file"},
+	"DELETE /api/v1/uploads/sessions/{id}":        {Summary: "Abandon a resumable upload"},
+	"POST /api/v1/batch/compare":                  {Summary: "Queue the comparison of many file pairs", Request: batchCompareRequest{}, Response: service.BatchJob{}},
+	"GET /api/v1/batch":                           {Summary: "Batch jobs with their progress", Response: []service.BatchJob{}},
+	"GET /api/v1/batch/{id}":                      {Summary: "A batch job with the result of each pair", Response: service.BatchJob{}},
+	"GET /api/v1/batch/{id}/report":               {Summary: "Consolidated report of a batch job, one row per pair", Query: []string{"format"}, Response: service.BatchReport{}},
+	"GET /api/v1/hotfolder":                       {Summary: "Hot folder settings and its latest checks against the reference", Response: service.HotFolderStatus{}},
+	"POST /api/v1/hotfolder/scan":                 {Summary: "Poll the hot folder now"},
+	"GET /api/v1/schemas":                         {Summary: "Registered reference schemas", Response: []service.ReferenceSchema{}},
+	"POST /api/v1/schemas":                        {Summary: "Register a reference schema, from its columns or a loaded file", Request: createSchemaRequest{}, Response: service.ReferenceSchema{}},
+	"GET /api/v1/schemas/{name}":                  {Summary: "A reference schema", Response: service.ReferenceSchema{}},
+	"PUT /api/v1/schemas/{name}":                  {Summary: "Create or replace a reference schema", Request: service.ReferenceSchema{}, Response: service.ReferenceSchema{}},
+	"DELETE /api/v1/schemas/{name}":               {Summary: "Delete a reference schema"},
+	"POST /api/v1/schemas/{name}/score":           {Summary: "Score a loaded file's conformance to a reference schema", Response: service.SchemaConformance{}},
+
+	"GET /api/v1/ontologies":                             {Summary: "Built-in target vocabularies", Response: []service.Vocabulary{}},
+	"GET /api/v1/ontologies/{id}":                        {Summary: "A vocabulary with its concepts", Response: service.Vocabulary{}},
+	"GET /api/v1/ontologies/{id}/dictionary/{fileIndex}": {Summary: "Data dictionary of a loaded file tagged with the vocabulary's concepts", Query: []string{"profile", "use_ai:boolean", "format"}, Response: conceptDictionaryResponse{}},
+	"GET /api/v1/glossary":                               {Summary: "Business glossary terms", Response: []service.GlossaryTerm{}},
+	"POST /api/v1/glossary/import":                       {Summary: "Import business glossary terms from JSON or CSV", Query: []string{"format", "replace:boolean"}, Request: glossaryImport{}, Response: []service.GlossaryTerm{}},
+	"GET /api/v1/glossary/{term}":                        {Summary: "A business glossary term", Response: service.GlossaryTerm{}},
+	"DELETE /api/v1/glossary/{term}":                     {Summary: "Delete a business glossary term"},
+	"GET /api/v1/knowledge":                              {Summary: "Approved mappings of past dataset pairs", Query: []string{"q"}, Response: []service.KnowledgeEntry{}},
+	"GET /api/v1/knowledge/suggestions":                  {Summary: "Column pairs of the loaded files approved before for similar schemas", Response: []service.HistorySuggestion{}},
+	"DELETE /api/v1/knowledge/{scope}":                   {Summary: "Forget the approved mapping of a past dataset pair"},
+
+	"GET /api/v1/config":                          {Summary: "Effective server settings and their sources"},
+	"GET /api/v1/config/log-level":                {Summary: "Minimum level logged"},
+	"PUT /api/v1/config/log-level":                {Summary: "Change the minimum level logged until restart", Request: logLevelRequest{}},
+	"GET /api/v1/config/profiles/{name}":          {Summary: "A matching profile", Response: service.MatchingProfile{}},
+	"PUT /api/v1/config/profiles/{name}":          {Summary: "Create or replace a matching profile", Request: service.MatchingProfile{}},
+	"POST /api/v1/pivot":                          {Summary: "Pivot table of a file", Request: pivotRequest{}},
+	"POST /api/v1/diff/rows":                      {Summary: "Rows added, deleted or changed between the files", Request: diffRowsRequest{}},
+	"POST /api/v1/export/data":                    {Summary: "Export the files joined on the approved mapping", Request: exportDataRequest{}},
+	"POST /api/v1/mapping":                        {Summary: "Import a mapping artifact", Request: service.MappingArtifact{}},
+	"GET /api/v1/mapping":                         {Summary: "Export the approved mapping as an artifact", Response: service.MappingArtifact{}},
+	"POST /api/v1/learning/import":                {Summary: "Import a learning bundle", Request: service.LearningBundle{}},
+	"GET /api/v1/learning/export":                 {Summary: "Export the learned weights, calibration and patterns", Response: service.LearningBundle{}},
+	"GET /api/v1/learning/ensemble":               {Summary: "Ensemble matcher weights learned from feedback"},
+	"DELETE /api/v1/learning/ensemble":            {Summary: "Restore the default ensemble matcher weights"},
+	"GET /api/v1/query/saved":                     {Summary: "Saved queries"},
+	"POST /api/v1/query/saved":                    {Summary: "Save a question or plan for re-running", Request: service.SavedQuery{}},
+	"GET /api/v1/query/saved/{id}":                {Summary: "A saved query", Response: service.SavedQuery{}},
+	"POST /api/v1/query/saved/{id}/run":           {Summary: "Run a saved query now", Response: service.SavedQueryRun{}},
+	"GET /api/v1/query/sessions/{sessionID}":      {Summary: "The turns of a query session", Response: service.QuerySession{}},
+	"POST /api/v1/db/connect":                     {Summary: "Connect to a database", Request: service.DataSourceConfig{}},
+	"PUT /api/v1/feedback/{id}":                   {Summary: "Correct a feedback entry", Request: service.FeedbackUpdate{}},
+	"GET /api/v1/quality/profiles/{fileIndex}":    {Summary: "Quality metrics of every column of a file"},
+	"GET /api/v1/quality/outliers/{fileIndex}":    {Summary: "Outlying values of the numeric columns", Query: []string{"column", "methods", "k:number", "z:number", "limit:integer"}},
+	"GET /api/v1/quality/missingness/{fileIndex}": {Summary: "Missing value patterns of a file", Query: []string{"segment"}},
+
+	"POST /api/v1/context/{fileIndex}/descriptions/suggest": {Summary: "LLM one-line descriptions of a file's columns, for review", Query: []string{"locale"}, Request: suggestDescriptionsRequest{}},
+	"POST /api/v1/context/{fileIndex}/descriptions":         {Summary: "Store column descriptions in bulk, e.g. accepted suggestions", Request: acceptDescriptionsRequest{}, Response: models.Context{}},
+	"GET /api/v1/columns/{fileIndex}/{column}/values":       {Summary: "Distinct values of a column with counts and the forms matching compares", Query: []string{"sort", "search", "offset:integer", "limit:integer"}},
+}
+
+// pathParamPattern matches chi path parameters, with an optional regexp
+var pathParamPattern = regexp.MustCompile(`\{([^}:]+)(:[^}]*)?\}`)
+
+// GetOpenAPISpec handles GET /openapi.json
+func (h *Handler) GetOpenAPISpec(w http.ResponseWriter, r *http.Request) {
+	spec, err := buildOpenAPISpec(h.routes, h.aliases, h.roles)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(spec)
+}
+
+// GetDocs handles GET /docs
+// Swagger UI over /openapi.json
+func (h *Handler) GetDocs(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(docsPage))
+}
+
+// buildOpenAPISpec describes every route of the router, marking the legacy
+// aliases deprecated and the routes needing an API key with their role
+func buildOpenAPISpec(routes chi.Routes, aliases map[string]string, roles map[string]auth.Role) (map[string]interface{}, error) {
+	schemas := &schemaRegistry{components: map[string]interface{}{}}
+	errorSchema := schemas.schemaFor(reflect.TypeOf(apierr.Error{}))
+	paths := map[string]map[string]interface{}{}
+
+	err := chi.Walk(routes, func(method, route string, handler http.Handler, _ ...func(http.Handler) http.Handler) error {
+		if method == "OPTIONS" || method == "HEAD" {
+			return nil
+		}
+		path := strings.TrimSuffix(pathParamPattern.ReplaceAllString(route, "{$1}"), "/*")
+		if path == "" {
+			path = "/"
+		}
+		successor, deprecated := aliases[method+" "+route]
+		doc, role := routeDocs[method+" "+route], roles[method+" "+route]
+		if deprecated {
+			doc, role = routeDocs[method+" "+successor], roles[method+" "+successor]
+		}
+
+		op := map[string]interface{}{
+			"tags": []string{routeTag(path)},
+		}
+		name := handlerName(handler)
+		if name != "" && !deprecated {
+			op["operationId"] = name
+		}
+		switch {
+		case doc.Summary != "":
+			op["summary"] = doc.Summary
+		case name != "":
+			op["summary"] = humanize(name)
+		}
+		if deprecated {
+			op["deprecated"] = true
+			op["description"] = "Deprecated alias of " + successor
+		}
+		if role != "" {
+			op["security"] = []map[string][]string{{"apiKey": {}}, {"bearer": {}}}
+			op["x-required-role"] = role
+		}
+
+		params := []map[string]interface{}{}
+		for _, m := range pathParamPattern.FindAllStringSubmatch(route, -1) {
+			params = append(params, map[string]interface{}{
+				"name": m[1], "in": "path", "required": true, "schema": map[string]interface{}{"type": "string"},
+			})
+		}
+		for _, q := range doc.Query {
+			name, typ, _ := strings.Cut(q, ":")
+			if typ == "" {
+				typ = "string"
+			}
+			params = append(params, map[string]interface{}{
+				"name": name, "in": "query", "schema": map[string]interface{}{"type": typ},
+			})
+		}
+		if len(params) > 0 {
+			op["parameters"] = params
+		}
+
+		if doc.Request != nil {
+			op["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(schemas.schemaFor(reflect.TypeOf(doc.Request))),
+			}
+		}
+		ok := map[string]interface{}{"description": "OK"}
+		if doc.Response != nil {
+			ok["content"] = jsonContent(schemas.schemaFor(reflect.TypeOf(doc.Response)))
+		}
+		op["responses"] = map[string]interface{}{
+			"200":     ok,
+			"default": map[string]interface{}{"description": "Error", "content": jsonContent(errorSchema)},
+		}
+
+		if paths[path] == nil {
+			paths[path] = map[string]interface{}{}
+		}
+		paths[path][strings.ToLower(method)] = op
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":       "Project Euler API",
+			"description": "Compare two CSV files: profiling, column matching, context capture, querying and export. Errors use the envelope {code, message, details}.",
+			"version":     "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]interface{}{
+			"schemas": schemas.components,
+			"securitySchemes": map[string]interface{}{
+				"apiKey": map[string]interface{}{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": map[string]interface{}{"type": "http", "scheme": "bearer"},
+			},
+		},
+	}, nil
+}
+
+func jsonContent(schema map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{"application/json": map[string]interface{}{"schema": schema}}
+}
+
+// routeTag groups a route by its first path segment after /api/v1 (or /api)
+func routeTag(path string) string {
+	parts := strings.Split(strings.Trim(strings.TrimPrefix(path, APIPrefix), "/"), "/")
+	if parts[0] == "api" && len(parts) > 1 {
+		parts = parts[1:]
+	}
+	if parts[0] == "" {
+		return "root"
+	}
+	return parts[0]
+}
+
+// handlerName returns the method name of a Handler method value, or "" for
+// other functions
+func handlerName(handler http.Handler) string {
+	if chain, ok := handler.(*chi.ChainHandler); ok {
+		handler = chain.Endpoint
+	}
+	fn, ok := handler.(http.HandlerFunc)
+	if !ok {
+		return ""
+	}
+	full := runtime.FuncForPC(reflect.ValueOf(fn).Pointer()).Name()
+	if !strings.Contains(full, "(*Handler).") {
+		return ""
+	}
+	return strings.TrimSuffix(full[strings.LastIndex(full, ".")+1:], "-fm")
+}
+
+// humanize turns a method name into a summary: GetKPIConfig -> "Get KPI config"
+func humanize(name string) string {
+	runes := []rune(name)
+	words := []string{}
+	start := 0
+	for i := 1; i <= len(runes); i++ {
+		// Words start at a capital after a lower-case letter, or at the last
+		// capital of an acronym that a lower-case letter follows
+		if i < len(runes) && unicode.IsUpper(runes[i]) &&
+			(unicode.IsLower(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])) || i == len(runes) {
+			words = append(words, string(runes[start:i]))
+			start = i
+		}
+	}
+	for i := 1; i < len(words); i++ {
+		if strings.ToUpper(words[i]) != words[i] || len(words[i]) == 1 {
+			words[i] = strings.ToLower(words[i])
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// schemaRegistry builds JSON schemas from Go types, keeping named structs
+// as shared components
+type schemaRegistry struct {
+	components map[string]interface{}
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor returns the schema of a type, or a reference to its component
+func (s *schemaRegistry) schemaFor(t reflect.Type) map[string]interface{} {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return map[string]interface{}{"type": "string", "format": "byte"}
+		}
+		return map[string]interface{}{"type": "array", "items": s.schemaFor(t.Elem())}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": s.schemaFor(t.Elem())}
+	case reflect.Struct:
+		if t == timeType {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		if t.Name() == "" {
+			return s.structSchema(t)
+		}
+		name := componentName(t)
+		if _, ok := s.components[name]; !ok {
+			s.components[name] = map[string]interface{}{} // Placeholder for recursive types
+			s.components[name] = s.structSchema(t)
+		}
+		return map[string]interface{}{"$ref": "#/components/schemas/" + name}
+	}
+	return map[string]interface{}{} // interface{}: any value
+}
+
+// structSchema describes a struct's JSON fields, flattening embedded structs
+func (s *schemaRegistry) structSchema(t reflect.Type) map[string]interface{} {
+	properties := map[string]interface{}{}
+	required := []string{}
+	var addFields func(t reflect.Type)
+	addFields = func(t reflect.Type) {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			tag := f.Tag.Get("json")
+			if tag == "-" {
+				continue
+			}
+			name, opts, _ := strings.Cut(tag, ",")
+			ft := f.Type
+			for ft.Kind() == reflect.Ptr {
+				ft = ft.Elem()
+			}
+			if f.Anonymous && name == "" && ft.Kind() == reflect.Struct {
+				addFields(ft)
+				continue
+			}
+			if !f.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			properties[name] = s.schemaFor(f.Type)
+			if !strings.Contains(opts, "omitempty") && f.Type.Kind() != reflect.Ptr {
+				required = append(required, name)
+			}
+		}
+	}
+	addFields(t)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// componentName names a type's component by package and type: models.KPI
+func componentName(t reflect.Type) string {
+	pkg := t.PkgPath()
+	return pkg[strings.LastIndex(pkg, "/")+1:] + "." + t.Name()
+}
+
+const docsPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Project Euler API</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+  <script>
+    window.onload = function () {
+      window.ui = SwaggerUIBundle({ url: "/openapi.json", dom_id: "#swagger-ui" });
+    };
+  </script>
+</body>
+</html>
+`
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+)
+
+// ============================================================================
+// Pivot Tables
+// ============================================================================
+
+// pivotRequest is the body of POST /api/pivot
+type pivotRequest struct {
+	FileIndex int `json:"file_index"`
+	analysis.PivotSpec
+}
+
+// PivotData handles POST /api/v1/pivot
+// Cross-tabulates a loaded file: rows and columns are lists of fields, value
+// and aggregation (count, distinct, sum, mean, min, max) fill the cells.
+// At most 500 row keys and 50 column keys are returned, the most frequent ones.
+func (h *Handler) PivotData(w http.ResponseWriter, r *http.Request) {
+	var req pivotRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.FileIndex == 0 {
+		req.FileIndex = 1
+	}
+	if req.FileIndex != 1 && req.FileIndex != 2 {
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(req.FileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("File %d not loaded", req.FileIndex)))
+		return
+	}
+
+	table, err := analysis.Pivot(df.Headers, df.Rows, req.PivotSpec)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	// Encoded before the status is sent, so a failure is still an error
+	data, err := json.Marshal(table)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error encoding pivot table: %v", err)))
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(append(data, '\n'))
+}
@@ -0,0 +1,352 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/config"
+	"backend-go/internal/dateformat"
+	"backend-go/internal/logging"
+	"backend-go/internal/nulltoken"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Matching Profiles
+// ============================================================================
+
+// ListMatchingProfiles handles GET /api/v1/config/profiles
+func (h *Handler) ListMatchingProfiles(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"profiles": service.GetMatchingProfileStore().List(),
+		"default":  service.DefaultProfileName,
+	})
+}
+
+// GetMatchingProfile handles GET /api/v1/config/profiles/{name}
+func (h *Handler) GetMatchingProfile(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+
+	profile, ok := service.GetMatchingProfileStore().Get(name)
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Profile not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(profile)
+}
+
+// SaveMatchingProfile handles PUT /api/v1/config/profiles/{name}
+// Creates or replaces a custom profile; built-in presets are read-only
+func (h *Handler) SaveMatchingProfile(w http.ResponseWriter, r *http.Request) {
+	var profile service.MatchingProfile
+	if err := json.NewDecoder(r.Body).Decode(&profile); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	profile.Name = chi.URLParam(r, "name")
+
+	saved, err := service.GetMatchingProfileStore().Save(profile)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"profile": saved,
+	})
+}
+
+// DeleteMatchingProfile handles DELETE /api/v1/config/profiles/{name}
+func (h *Handler) DeleteMatchingProfile(w http.ResponseWriter, r *http.Request) {
+	if err := service.GetMatchingProfileStore().Delete(chi.URLParam(r, "name")); err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// GetScoringScript handles GET /api/v1/config/scoring-script
+func (h *Handler) GetScoringScript(w http.ResponseWriter, r *http.Request) {
+	script, updatedAt := service.GetScoringScriptStore().Current()
+
+	resp := map[string]interface{}{
+		"active": script != nil,
+		"source": "",
+	}
+	if script != nil {
+		resp["source"] = script.Source
+		resp["updated_at"] = updatedAt
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// SaveScoringScript handles PUT /api/v1/config/scoring-script
+// The script is compiled before it is activated; an empty source clears it
+func (h *Handler) SaveScoringScript(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source string `json:"source"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	script, err := service.GetScoringScriptStore().Set(req.Source)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Invalid script: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"active":  script != nil,
+	})
+}
+
+// TestScoringScript handles POST /api/v1/config/scoring-script/test
+// Evaluates a script against a caller-supplied signal vector without
+// activating it. Admin only, like saving one: the step limit stops loops, but
+// a single expression can still allocate gigabytes.
+func (h *Handler) TestScoringScript(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Source    string                 `json:"source"`
+		Variables map[string]interface{} `json:"variables"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	script, err := service.CompileScoringScript(req.Source)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Invalid script: %v", err)))
+		return
+	}
+
+	outcome, err := script.Evaluate(req.Variables)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Script failed: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"vetoed":     outcome.Vetoed,
+		"adjustment": outcome.Adjustment,
+		"fired":      outcome.Fired,
+	})
+}
+
+// ============================================================================
+// Date Formats
+// ============================================================================
+
+// GetDateFormats handles GET /api/v1/config/date-formats
+func (h *Handler) GetDateFormats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"custom":      dateformat.Custom(),
+		"built_in":    dateformat.BuiltIn(),
+		"epoch":       []string{dateformat.EpochSeconds, dateformat.EpochMilliseconds},
+		"sample_size": dateformat.DefaultSampleSize,
+	})
+}
+
+// SaveDateFormats handles PUT /api/v1/config/date-formats
+// Replaces the custom formats (Go layout syntax, e.g. "02.01.2006 15:04");
+// custom formats are tried before the built-in ones
+func (h *Handler) SaveDateFormats(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Formats []string `json:"formats"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	saved, err := dateformat.SetCustom(req.Formats)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	// Typed columns, cached parses and cached profiles hold formats
+	// detected with the old list
+	rebuildTypedColumns()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"custom":  saved,
+	})
+}
+
+// TestDateFormats handles POST /api/v1/config/date-formats/test
+// Runs column-level detection over the given values and reports how each parses
+func (h *Handler) TestDateFormats(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Values []string `json:"values"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	detection := dateformat.DetectColumn(req.Values, len(req.Values))
+
+	parsed := []map[string]interface{}{}
+	for _, v := range req.Values {
+		entry := map[string]interface{}{"value": v, "parsed": false}
+		if detection.Layout != "" {
+			if t, ok := dateformat.ParseWith(detection.Layout, v); ok {
+				entry["parsed"] = true
+				entry["iso"] = t.Format(time.RFC3339)
+			}
+		}
+		parsed = append(parsed, entry)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"detection": detection,
+		"values":    parsed,
+	})
+}
+
+// ============================================================================
+// Null Tokens
+// ============================================================================
+
+// GetNullTokens handles GET /api/v1/config/null-tokens
+func (h *Handler) GetNullTokens(w http.ResponseWriter, r *http.Request) {
+	cfg := nulltoken.Get()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"global":   cfg.Global,
+		"columns":  cfg.Columns,
+		"defaults": nulltoken.Defaults(),
+	})
+}
+
+// SaveNullTokens handles PUT /api/v1/config/null-tokens
+// Replaces the tokens treated as missing (e.g. "N/A", "-", "999999"), matched
+// case-insensitively on trimmed cells; columns adds tokens for single columns.
+// Omitting global restores the defaults.
+func (h *Handler) SaveNullTokens(w http.ResponseWriter, r *http.Request) {
+	var req nulltoken.Config
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	saved, err := nulltoken.Set(req)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(err.Error()))
+		return
+	}
+	// Typed columns, cached parses and cached profiles were built with the
+	// old tokens
+	rebuildTypedColumns()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"global":  saved.Global,
+		"columns": saved.Columns,
+	})
+}
+
+// rebuildTypedColumns retypes the loaded files and drops the parses and
+// profiles cached under the old null tokens or date formats
+func rebuildTypedColumns() {
+	for _, fileIndex := range []int{1, 2} {
+		if df := state.State.GetDataFrame(fileIndex); df != nil {
+			df.BuildColumns()
+		}
+	}
+	clearParsedUploads()
+	service.GetColumnProfileCache().Clear()
+}
+
+// ============================================================================
+// Log Level
+// ============================================================================
+
+// logLevelRequest is the body of PUT /api/v1/config/log-level
+type logLevelRequest struct {
+	Level string `json:"level"` // debug, info, warn or error
+}
+
+// GetLogLevel handles GET /api/v1/config/log-level
+func (h *Handler) GetLogLevel(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"level": logging.Level(),
+	})
+}
+
+// SetLogLevel handles PUT /api/v1/config/log-level
+// Changes the minimum level logged until restart; LOG_LEVEL sets it at startup
+func (h *Handler) SetLogLevel(w http.ResponseWriter, r *http.Request) {
+	var req logLevelRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if err := logging.SetLevel(req.Level); err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	configLog.InfoContext(r.Context(), "Log level changed", "level", logging.Level())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"level":   logging.Level(),
+	})
+}
+
+// ============================================================================
+// Server Configuration
+// ============================================================================
+
+// GetServerConfig handles GET /api/v1/config
+// Reports the effective settings and where each came from (default, file,
+// env or flag). Ollama and log level settings changed through their own
+// endpoints since startup show their current values, sourced "runtime".
+func (h *Handler) GetServerConfig(w http.ResponseWriter, r *http.Request) {
+	cfg := config.Get()
+	sources := config.Sources()
+	runtime := func(key string, value *string, now string) {
+		if *value != now {
+			*value = now
+			sources[key] = "runtime"
+		}
+	}
+	runtime("ollama.base_url", &cfg.Ollama.BaseURL, state.State.OllamaBaseURL)
+	runtime("ollama.model", &cfg.Ollama.Model, state.State.OllamaModel)
+	runtime("log.level", &cfg.Log.Level, logging.Level())
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"config":  cfg,
+		"sources": sources,
+		"file":    config.File(),
+	})
+}
@@ -0,0 +1,146 @@
+package api
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Data Quality
+// ============================================================================
+
+// GetQualityProfiles handles GET /api/v1/quality/profiles/{fileIndex}
+// Quality metrics of every column, including Benford and checksum plausibility checks
+func (h *Handler) GetQualityProfiles(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file_index": fileIndex,
+		"columns":    service.NewDataQualityProfiler().ProfileAllColumns(df),
+	})
+}
+
+// GetOutliers handles GET /api/v1/quality/outliers/{fileIndex}
+// Flags outlying values of each numeric column
+// Query: column=name, methods=iqr,zscore,isolation, k=1.5, z=3, limit=100 (flagged rows per column)
+func (h *Handler) GetOutliers(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+
+	q := r.URL.Query()
+	opts := analysis.OutlierOptions{}
+	if m := q.Get("methods"); m != "" {
+		opts.Methods = strings.Split(m, ",")
+	}
+	if v := q.Get("k"); v != "" {
+		if opts.IQRMultiplier, err = strconv.ParseFloat(v, 64); err != nil {
+			apierr.Write(w, apierr.BadRequest("Invalid k value"))
+			return
+		}
+	}
+	if v := q.Get("z"); v != "" {
+		if opts.ZThreshold, err = strconv.ParseFloat(v, 64); err != nil {
+			apierr.Write(w, apierr.BadRequest("Invalid z value"))
+			return
+		}
+	}
+	limit := getIntParam(r, "limit", 100)
+	column := q.Get("column")
+
+	results := []analysis.ColumnOutliers{}
+	for i, col := range df.Columns() {
+		if col.Type != state.ColumnFloat || (column != "" && col.Name != column) {
+			continue
+		}
+		values := make([]float64, 0, col.FloatCount)
+		rows := make([]int, 0, col.FloatCount)
+		for row := 0; row < col.Len(); row++ {
+			if v, ok := col.FloatAt(row); ok {
+				values = append(values, v)
+				rows = append(rows, row)
+			}
+		}
+		result, err := analysis.DetectOutliers(df.Headers[i], values, rows, opts)
+		if err != nil {
+			if len(values) == 0 {
+				continue
+			}
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		if limit > 0 && len(result.Outliers) > limit {
+			result.Outliers = result.Outliers[:limit]
+		}
+		results = append(results, result)
+	}
+	if column != "" && len(results) == 0 {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Column %q not found or not numeric", column)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file_index": fileIndex,
+		"columns":    results,
+	})
+}
+
+// GetMissingness handles GET /api/v1/quality/missingness/{fileIndex}
+// Columns null together, recurring row patterns of missing values and null rates per segment
+// Query: segment=column (optional categorical column)
+func (h *Handler) GetMissingness(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+
+	segmentCol := -1
+	if segment := r.URL.Query().Get("segment"); segment != "" {
+		for i, h := range df.Headers {
+			if h == segment {
+				segmentCol = i
+				break
+			}
+		}
+		if segmentCol < 0 {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Column %q not found", segment)))
+			return
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analysis.AnalyzeMissingness(df.Headers, df.Rows, segmentCol))
+}
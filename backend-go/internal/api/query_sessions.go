@@ -0,0 +1,339 @@
+package api
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Query Sessions
+// ============================================================================
+
+// queryHistoryTurns is the number of earlier turns given to the query planner
+const queryHistoryTurns = 6
+
+// queryBothFiles is the file index of questions over both files joined
+const queryBothFiles = 0
+
+var (
+	fileMentionPattern  = regexp.MustCompile(`\b(?:file\s*([12])|(first|second)\s+file)\b`)
+	bothMentionPattern  = regexp.MustCompile(`\b(?:both\s+files|across\s+(?:the\s+)?files|joined\s+(?:data|files))\b`)
+	groupByPattern      = regexp.MustCompile(`\b(?:by|per|for each)\s+([\w ]+?)\s*[?.!]*$`)
+	questionWordPattern = regexp.MustCompile(`[a-z0-9]+`)
+)
+
+// timeGrainWords maps follow-up wording to time grains
+var timeGrainWords = map[string]string{
+	"day": analysis.GrainDay, "daily": analysis.GrainDay, "date": analysis.GrainDay,
+	"month": analysis.GrainMonth, "monthly": analysis.GrainMonth,
+	"quarter": analysis.GrainQuarter, "quarterly": analysis.GrainQuarter,
+	"year": analysis.GrainYear, "yearly": analysis.GrainYear,
+}
+
+// queryFileIndex picks the data a question is about: the requested dataset
+// or file, the files named in the question ("file 2", "both files"), both
+// files when the question names columns only one or the other file has, or
+// the file of the previous turn, defaulting to file 1
+func queryFileIndex(req QueryRequest, turns []service.QueryTurn) (int, error) {
+	switch req.Dataset {
+	case "":
+	case "both":
+		return queryBothFiles, nil
+	case "file1", "1":
+		return 1, nil
+	case "file2", "2":
+		return 2, nil
+	default:
+		return 0, fmt.Errorf("dataset must be file1, file2 or both")
+	}
+	if req.FileIndex != 0 {
+		if req.FileIndex != 1 && req.FileIndex != 2 {
+			return 0, fmt.Errorf("file_index must be 1 or 2")
+		}
+		return req.FileIndex, nil
+	}
+
+	question := strings.ToLower(req.Question)
+	if bothMentionPattern.MatchString(question) {
+		return queryBothFiles, nil
+	}
+	if m := fileMentionPattern.FindStringSubmatch(question); m != nil {
+		if m[1] == "2" || m[2] == "second" {
+			return 2, nil
+		}
+		return 1, nil
+	}
+	if needsBothFiles(question) {
+		return queryBothFiles, nil
+	}
+	if len(turns) > 0 {
+		return turns[len(turns)-1].FileIndex, nil
+	}
+	return 1, nil
+}
+
+// needsBothFiles reports whether a question names a column only file 1 has
+// and one only file 2 has, with an approved join between the files
+func needsBothFiles(question string) bool {
+	df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2)
+	if df1 == nil || df2 == nil || len(service.GetMappingStore().Current().JoinKeys()) == 0 {
+		return false
+	}
+	mentions := func(df, other *state.DataFrame) bool {
+		for _, h := range df.Headers {
+			if columnIndexFold(other.Headers, h) < 0 && mentionsColumn(question, h) {
+				return true
+			}
+		}
+		return false
+	}
+	return mentions(df1, df2) && mentions(df2, df1)
+}
+
+// mentionsColumn reports whether a question names a column, matching words
+// so "order value" names order_value
+func mentionsColumn(question, column string) bool {
+	words := questionWordPattern.FindAllString(strings.ToLower(column), -1)
+	if len(words) == 0 {
+		return false
+	}
+	text := " " + strings.Join(questionWordPattern.FindAllString(strings.ToLower(question), -1), " ") + " "
+	return strings.Contains(text, " "+strings.Join(words, " ")+" ")
+}
+
+// columnIndexFold finds a header ignoring case, -1 when absent
+func columnIndexFold(headers []string, name string) int {
+	for i, h := range headers {
+		if strings.EqualFold(h, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// queryJoinMaxRows caps the joined data a question over both files runs on
+const queryJoinMaxRows = 1_000_000
+
+// queryFrame returns the data of a file index, joining the files for
+// queryBothFiles. The left join keeps every file 1 row, but a row whose key
+// matches several file 2 rows is repeated once per match, which inflates file 1
+// sums and counts; note says so for the answer, empty when nothing repeats.
+func queryFrame(fileIndex int) (df *state.DataFrame, note string, err error) {
+	if fileIndex == queryBothFiles {
+		df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2)
+		if df1 == nil || df2 == nil {
+			return nil, "", fmt.Errorf("Both files must be loaded to query them together")
+		}
+		joined, err := service.JoinedFrame(df1, df2, service.GetMappingStore().Current(),
+			service.JoinOptions{JoinType: service.JoinLeft}, queryJoinMaxRows)
+		if err != nil {
+			return nil, "", err
+		}
+		if extra := len(joined.Rows) - len(df1.Rows); extra > 0 {
+			note = fmt.Sprintf("Note: some file 1 rows match several file 2 rows, so the joined data repeats them (%d extra rows) and file 1 totals and counts include the repeats.", extra)
+		}
+		return joined, note, nil
+	}
+	df = state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		if fileIndex == 1 {
+			return nil, "", fmt.Errorf("No CSV file loaded. Please upload a file first.")
+		}
+		return nil, "", fmt.Errorf("File %d not loaded", fileIndex)
+	}
+	return df, "", nil
+}
+
+// withNote appends a note to an answer
+func withNote(answer, note string) string {
+	if note == "" {
+		return answer
+	}
+	if answer == "" {
+		return note
+	}
+	return answer + "\n\n" + note
+}
+
+// queryDatasetName names the data of a file index: "file1", "file2" or "both"
+func queryDatasetName(fileIndex int) string {
+	if fileIndex == queryBothFiles {
+		return "both"
+	}
+	return fmt.Sprintf("file%d", fileIndex)
+}
+
+// sessionHistory returns the latest turns of a conversation on one file
+func sessionHistory(turns []service.QueryTurn, fileIndex int) []service.QueryTurn {
+	history := []service.QueryTurn{}
+	for _, t := range turns {
+		if t.FileIndex == fileIndex {
+			history = append(history, t)
+		}
+	}
+	if len(history) > queryHistoryTurns {
+		history = history[len(history)-queryHistoryTurns:]
+	}
+	return history
+}
+
+// groupedQuery answers "... by <column>" and "... by month" by adding a
+// group-by to a plan: the last plan of the conversation for follow-ups, or
+// the keyword plan of the question itself
+func groupedQuery(df *state.DataFrame, question string, base *analysis.QueryPlan) (QueryResponse, bool) {
+	m := groupByPattern.FindStringSubmatch(strings.ToLower(question))
+	if base == nil || m == nil {
+		return QueryResponse{}, false
+	}
+	target := strings.TrimPrefix(strings.TrimSpace(m[1]), "the ")
+
+	plan := analysis.QueryPlan{
+		Filters:      append([]analysis.QueryFilter(nil), base.Filters...),
+		GroupBy:      append([]string(nil), base.GroupBy...),
+		TimeGrain:    base.TimeGrain,
+		Aggregations: append([]analysis.QueryAggregation(nil), base.Aggregations...),
+	}
+	var column string
+	if grain, ok := timeGrainWords[target]; ok {
+		for _, col := range df.Columns() {
+			if col.Type == state.ColumnTime {
+				column = col.Name
+				break
+			}
+		}
+		if column == "" {
+			return QueryResponse{}, false
+		}
+		plan.TimeGrain = grain
+	} else {
+		for _, h := range df.Headers {
+			if mentionsColumn(target, h) {
+				column = h
+				break
+			}
+		}
+		if column == "" {
+			return QueryResponse{}, false
+		}
+	}
+	if !containsString(plan.GroupBy, column) {
+		plan.GroupBy = append(plan.GroupBy, column)
+	}
+	if plan.TimeGrain != "" {
+		plan.OrderBy = &analysis.QueryOrder{Column: column}
+	}
+	plan.Explanation = fmt.Sprintf("Broken down by %s.", target)
+
+	result, err := analysis.ExecutePlan(df.Headers, df.Rows, &plan)
+	if err != nil {
+		return QueryResponse{}, false
+	}
+	return planResponse(&plan, result, "heuristic"), true
+}
+
+// keywordPlan is the plan equivalent of a keyword answer (average, sum,
+// count, max, min), recorded so follow-ups can refine it. Numeric columns
+// named in the question are used, otherwise all numeric columns.
+func keywordPlan(df *state.DataFrame, question string) *analysis.QueryPlan {
+	var function string
+	switch {
+	case strings.Contains(question, "average") || strings.Contains(question, "mean"):
+		function = analysis.AggMean
+	case strings.Contains(question, "sum") || strings.Contains(question, "total"):
+		function = analysis.AggSum
+	case strings.Contains(question, "count") || strings.Contains(question, "how many"):
+		return &analysis.QueryPlan{Aggregations: []analysis.QueryAggregation{{Function: analysis.AggCount}}}
+	case strings.Contains(question, "max") || strings.Contains(question, "highest"):
+		function = analysis.AggMax
+	case strings.Contains(question, "min") || strings.Contains(question, "lowest"):
+		function = analysis.AggMin
+	default:
+		return nil
+	}
+
+	numeric, named := []string{}, []string{}
+	for _, col := range df.Columns() {
+		if col.Type != state.ColumnFloat {
+			continue
+		}
+		numeric = append(numeric, col.Name)
+		if mentionsColumn(question, col.Name) {
+			named = append(named, col.Name)
+		}
+	}
+	if len(named) > 0 {
+		numeric = named
+	}
+	if len(numeric) == 0 {
+		return nil
+	}
+	plan := &analysis.QueryPlan{}
+	for _, c := range numeric {
+		plan.Aggregations = append(plan.Aggregations, analysis.QueryAggregation{Column: c, Function: function})
+	}
+	return plan
+}
+
+// lastPlan returns the latest plan of a conversation, nil when none
+func lastPlan(history []service.QueryTurn) *analysis.QueryPlan {
+	for i := len(history) - 1; i >= 0; i-- {
+		if history[i].Plan != nil {
+			return history[i].Plan
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+// ListQuerySessions handles GET /api/v1/query/sessions
+func (h *Handler) ListQuerySessions(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"sessions": service.GetQuerySessionStore().List(),
+	})
+}
+
+// GetQuerySession handles GET /api/v1/query/sessions/{sessionID}
+// Returns the conversation history of a session.
+func (h *Handler) GetQuerySession(w http.ResponseWriter, r *http.Request) {
+	session, ok := service.GetQuerySessionStore().Get(chi.URLParam(r, "sessionID"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Query session not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(session)
+}
+
+// ClearQuerySession handles DELETE /api/v1/query/sessions/{sessionID}
+func (h *Handler) ClearQuerySession(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "sessionID")
+	if !service.GetQuerySessionStore().Clear(id) {
+		apierr.Write(w, apierr.NotFound("Query session not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"message": fmt.Sprintf("Query session %s cleared", id),
+	})
+}
@@ -0,0 +1,62 @@
+package api
+
+import (
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// RealIP replaces the remote address of requests coming from a trusted
+// proxy with the client address the proxies forwarded: the last address of
+// X-Forwarded-For that isn't a trusted proxy, else X-Real-IP. Requests from
+// anywhere else keep their connection's address, so clients can't pick the
+// address they are rate limited and audited by.
+func RealIP(trusted []netip.Prefix) func(http.Handler) http.Handler {
+	isTrusted := func(addr netip.Addr) bool {
+		for _, p := range trusted {
+			if p.Contains(addr.Unmap()) {
+				return true
+			}
+		}
+		return false
+	}
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			peer, err := netip.ParseAddr(remoteHost(r))
+			if len(trusted) == 0 || err != nil || !isTrusted(peer) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if client, ok := forwardedClient(r, isTrusted); ok {
+				r.RemoteAddr = client.Unmap().String()
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// forwardedClient walks X-Forwarded-For from the nearest hop back, skipping
+// trusted proxies; the first other address is the client. An unparsable
+// hop ends the walk, as the ones before it can't be trusted.
+func forwardedClient(r *http.Request, isTrusted func(netip.Addr) bool) (netip.Addr, bool) {
+	hops := []string{}
+	for _, header := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(header, ",")...)
+	}
+	var client netip.Addr
+	for i := len(hops) - 1; i >= 0; i-- {
+		addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			break
+		}
+		client = addr
+		if !isTrusted(addr) {
+			return client, true
+		}
+	}
+	if client.IsValid() {
+		return client, true // Every hop is a proxy; the farthest is the client
+	}
+	addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP")))
+	return addr, err == nil
+}
@@ -0,0 +1,482 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/auth"
+	"backend-go/internal/logging"
+	"backend-go/internal/ratelimit"
+	"backend-go/internal/service"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/http/pprof"
+	"net/url"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// APIPrefix is the namespace of the current API version
+const APIPrefix = "/api/v1"
+
+// RegisterRoutes registers every endpoint under /api/v1, plus the paths used
+// before versioning as deprecated aliases. Reads need the viewer role and
+// changes the analyst role unless the route names another. Every route is
+// rate limited per client, and the routes calling the LLM by the LLM limiter too.
+// Changes needing more than the viewer role, and exports, are audited.
+func (h *Handler) RegisterRoutes(r chi.Router) {
+	h.routes = r
+	h.aliases = make(map[string]string)
+	h.roles = make(map[string]auth.Role)
+	r.NotFound(apierr.NotFoundHandler)
+	r.MethodNotAllowed(apierr.MethodNotAllowedHandler)
+
+	// Unversioned
+	r.Get("/healthz", h.HealthCheck)
+	r.Get("/readyz", h.ReadinessCheck)
+	// The probe before liveness and readiness were split
+	h.aliases["GET /health"] = "/healthz"
+	r.With(deprecatedAlias("/healthz")).Get("/health", h.HealthCheck)
+	r.Get("/openapi.json", h.GetOpenAPISpec)
+	r.Get("/docs", h.GetDocs)
+
+	v := &versionedRouter{root: r, v1: chi.NewRouter(), aliases: h.aliases, roles: h.roles}
+	viewer, admin := v.With(auth.RoleViewer), v.With(auth.RoleAdmin)
+	exports, unaudited := viewer.Audit(true), v.Audit(false)
+	always := func(*http.Request) bool { return true }
+
+	// Files
+	v.Post("/upload", h.Upload, "/upload")
+	admin.Post("/ingest/remote", h.IngestRemote, "/api/ingest/remote")
+	v.Post("/columns/rename", h.RenameColumns, "/api/columns/rename")
+	v.Get("/columns/{fileIndex}/{column}/values", h.GetColumnValues)
+	v.Get("/status", h.GetStatus, "/status")
+	v.Get("/preview", h.GetPreview, "/preview")
+	v.Get("/column-types", h.GetColumnTypes, "/column-types")
+	v.Get("/kpis", h.GetKPIs, "/kpis")
+	v.Get("/dashboard", h.GetDashboard, "/api/dashboard")
+	v.Get("/uploads", h.ListUploads)
+	v.Delete("/uploads/{name}", h.DeleteUpload)
+	v.Post("/uploads/sessions", h.CreateUploadSession)
+	v.Get("/uploads/sessions/{id}", h.GetUploadSession)
+	unaudited.Put("/uploads/sessions/{id}", h.AppendUploadChunk)
+	v.Post("/uploads/sessions/{id}/complete", h.CompleteUploadSession)
+	v.Delete("/uploads/sessions/{id}", h.AbortUploadSession)
+	v.Post("/batch/compare", h.CreateBatchCompare)
+	v.Get("/batch", h.ListBatchJobs)
+	v.Get("/batch/{id}", h.GetBatchJob)
+	v.Get("/batch/{id}/report", h.GetBatchReport)
+	v.Get("/hotfolder", h.GetHotFolder)
+	admin.Post("/hotfolder/scan", h.ScanHotFolder)
+
+	// Analysis
+	v.Post("/analyze-file", h.AnalyzeFile, "/api/analyze-file")
+	v.Get("/analysis/status", h.GetAnalysisStatus, "/api/status")
+	v.LLM(always).Get("/questions/{fileIndex}", h.GetQuestions, "/api/questions/{fileIndex}")
+	v.LLM(similarityUsesAI).Get("/column-similarity", h.GetColumnSimilarity, "/column-similarity")
+	v.Get("/correlation", h.GetCorrelation, "/correlation")
+	v.Get("/similarity/graph", h.GetSimilarityGraph, "/api/similarity/graph")
+	v.Get("/graph/analyze", h.AnalyzeGraph, "/api/graph/analyze")
+	v.Get("/graph/export", h.ExportGraph, "/api/graph/export")
+	v.Get("/similarity/runs", h.ListSimilarityRuns)
+	v.Get("/similarity/runs/diff", h.DiffSimilarityRuns)
+	v.Get("/similarity/runs/{id}", h.GetSimilarityRun)
+	admin.Delete("/similarity/runs/{id}", h.DeleteSimilarityRun)
+	v.LLM(always).Get("/similarity/ensemble", h.GetEnsembleSimilarity)
+	v.Get("/similarity/candidates", h.GetColumnCandidates, "/api/similarity/candidates")
+	viewer.Post("/similarity/whatif", h.WhatIfSimilarity, "/api/similarity/whatif")
+	v.Get("/similarity/scorers", h.GetSimilarityScorers, "/api/similarity/scorers")
+	v.Get("/similarity/cache", h.GetProfileCacheStats, "/api/similarity/cache")
+	v.Delete("/similarity/cache", h.ClearProfileCache, "/api/similarity/cache")
+	v.Post("/linkage/index", h.BuildLinkageIndex, "/api/linkage/index")
+	v.Get("/linkage/index", h.GetLinkageIndex, "/api/linkage/index")
+	viewer.Post("/linkage/query", h.QueryLinkage, "/api/linkage/query")
+	v.Get("/lineage", h.GetLineage, "/api/lineage")
+	v.Get("/diff/schema", h.GetSchemaDiff, "/api/diff/schema")
+	v.Get("/diff/distributions", h.GetDistributionDiff, "/api/diff/distributions")
+	viewer.Post("/diff/rows", h.DiffRows, "/api/diff/rows")
+	v.Get("/quality/profiles/{fileIndex}", h.GetQualityProfiles, "/api/quality/profiles/{fileIndex}")
+	v.Get("/quality/outliers/{fileIndex}", h.GetOutliers, "/api/quality/outliers/{fileIndex}")
+	v.Get("/quality/missingness/{fileIndex}", h.GetMissingness, "/api/quality/missingness/{fileIndex}")
+	v.Get("/stats/frequencies", h.GetValueFrequencies, "/api/stats/frequencies")
+	v.Get("/stats/{fileIndex}/{column}", h.GetColumnStats, "/api/stats/{fileIndex}/{column}")
+	viewer.Post("/pivot", h.PivotData, "/api/pivot")
+
+	// Reference schemas
+	v.Get("/schemas", h.ListSchemas)
+	v.Post("/schemas", h.CreateSchema)
+	v.Get("/schemas/{name}", h.GetSchema)
+	v.Put("/schemas/{name}", h.SaveSchema)
+	admin.Delete("/schemas/{name}", h.DeleteSchema)
+	viewer.Post("/schemas/{name}/score", h.ScoreSchemaConformance)
+
+	// Ontology mapping
+	v.Get("/ontologies", h.ListOntologies)
+	v.Get("/ontologies/{id}", h.GetOntology)
+	v.LLM(similarityUsesAI).Get("/ontologies/{id}/dictionary/{fileIndex}", h.GetConceptDictionary)
+
+	// Business glossary
+	v.Get("/glossary", h.ListGlossary)
+	v.Post("/glossary/import", h.ImportGlossary)
+	v.Get("/glossary/{term}", h.GetGlossaryTerm)
+	admin.Delete("/glossary/{term}", h.DeleteGlossaryTerm)
+
+	// Knowledge base of approved mappings
+	v.Get("/knowledge", h.ListKnowledge)
+	v.Get("/knowledge/suggestions", h.GetKnowledgeSuggestions)
+	admin.Delete("/knowledge/{scope}", h.DeleteKnowledgeEntry)
+
+	// Mappings and export
+	v.Get("/mappings/approved", h.GetApprovedMapping, "/api/mappings/approved")
+	admin.Delete("/mappings/approved", h.DeleteApprovedMapping, "/api/mappings/approved")
+	v.Post("/mappings/accept", h.AcceptMapping, "/api/mappings/accept")
+	v.Post("/mappings/reject", h.RejectMapping, "/api/mappings/reject")
+	v.Post("/mappings/manual", h.CreateManualMapping, "/api/mappings/manual")
+	v.Get("/mappings/join-preview", h.PreviewApprovedJoin, "/api/mappings/join-preview")
+	exports.Get("/mapping", h.ExportMapping, "/api/mapping")
+	admin.Post("/mapping", h.ImportMapping, "/api/mapping")
+	exports.Post("/export/sql", h.ExportSQL, "/api/export/sql")
+	exports.Post("/export/python", h.ExportPython, "/api/export/python")
+	exports.Post("/export/notebook", h.ExportNotebook, "/api/export/notebook")
+	exports.Post("/export/airflow", h.ExportAirflow, "/api/export/airflow")
+	exports.Post("/export/report", h.ExportReport, "/api/export/report")
+	exports.Get("/export/dictionary/{fileIndex}", h.ExportDictionary, "/api/export/dictionary/{fileIndex}")
+	exports.Post("/export/data", h.ExportData, "/api/export/data")
+
+	// Querying
+	viewer.Post("/filter", h.FilterData, "/filter")
+	viewer.LLM(always).Post("/query", h.Query, "/query")
+	v.Get("/query/sessions", h.ListQuerySessions, "/api/query/sessions")
+	v.Get("/query/sessions/{sessionID}", h.GetQuerySession, "/api/query/sessions/{sessionID}")
+	v.Delete("/query/sessions/{sessionID}", h.ClearQuerySession, "/api/query/sessions/{sessionID}")
+	v.Get("/query/saved", h.ListSavedQueries, "/api/query/saved")
+	v.Post("/query/saved", h.CreateSavedQuery, "/api/query/saved")
+	v.Get("/query/saved/{id}", h.GetSavedQuery, "/api/query/saved/{id}")
+	v.Delete("/query/saved/{id}", h.DeleteSavedQuery, "/api/query/saved/{id}")
+	v.LLM(always).Post("/query/saved/{id}/run", h.RunSavedQueryNow, "/api/query/saved/{id}/run")
+	v.Get("/query/saved/{id}/runs", h.GetSavedQueryRuns, "/api/query/saved/{id}/runs")
+
+	// Context
+	v.LLM(always).Post("/context/questions", h.GenerateContextQuestions, "/context/questions")
+	v.Post("/context/submit", h.SubmitContext, "/context/submit")
+	v.Get("/context/status", h.GetContextStatus, "/context/status")
+	v.Get("/context/templates", h.ListContextTemplates, "/api/context/templates")
+	v.Get("/context/templates/{id}", h.GetContextTemplate, "/api/context/templates/{id}")
+	v.Post("/context/templates/{id}/apply", h.ApplyContextTemplate, "/api/context/templates/{id}/apply")
+	v.Post("/context/interview", h.StartInterview)
+	v.Get("/context/interview/{id}", h.GetInterview)
+	v.Get("/context/interview/{id}/next", h.NextInterviewQuestion)
+	v.Post("/context/interview/{id}/answer", h.AnswerInterviewQuestion)
+	v.Delete("/context/interview/{id}", h.DeleteInterview)
+	v.Get("/context/{fileIndex}", h.GetContext, "/context/{fileIndex}")
+	v.Post("/context/{fileIndex}", h.StoreContext, "/api/context/{fileIndex}")
+	v.LLM(always).Post("/context/{fileIndex}/auto", h.DraftContext, "/api/context/{fileIndex}/auto")
+	v.LLM(always).Post("/context/{fileIndex}/descriptions/suggest", h.SuggestColumnDescriptions)
+	v.Post("/context/{fileIndex}/descriptions", h.AcceptColumnDescriptions)
+	v.Delete("/context/{fileIndex}", h.DeleteContext, "/context/{fileIndex}")
+	// The older context status shape, superseded by /api/v1/context/status
+	viewer.Alias(http.MethodGet, "/api/context/status", h.GetAnalysisContextStatus, "/context/status")
+
+	// DB Routes
+	admin.Post("/db/connect", h.ConnectDB, "/api/db/connect")
+	admin.Get("/db/tables", h.ListTables, "/api/db/tables")
+	admin.Post("/db/analyze", h.AnalyzeTable, "/api/db/analyze")
+
+	// Configuration
+	v.Get("/config", h.GetServerConfig)
+	v.Get("/config/ollama", h.GetOllamaConfig, "/config/ollama")
+	admin.Post("/config/ollama", h.SaveOllamaConfig, "/config/ollama")
+	v.Get("/config/profiles", h.ListMatchingProfiles, "/api/config/profiles")
+	v.Get("/config/profiles/{name}", h.GetMatchingProfile, "/api/config/profiles/{name}")
+	admin.Put("/config/profiles/{name}", h.SaveMatchingProfile, "/api/config/profiles/{name}")
+	admin.Delete("/config/profiles/{name}", h.DeleteMatchingProfile, "/api/config/profiles/{name}")
+	v.Get("/config/scoring-script", h.GetScoringScript, "/api/config/scoring-script")
+	admin.Put("/config/scoring-script", h.SaveScoringScript, "/api/config/scoring-script")
+	admin.Audit(false).Post("/config/scoring-script/test", h.TestScoringScript, "/api/config/scoring-script/test") // Scripts can allocate freely
+	v.Get("/config/date-formats", h.GetDateFormats, "/api/config/date-formats")
+	admin.Put("/config/date-formats", h.SaveDateFormats, "/api/config/date-formats")
+	unaudited.Post("/config/date-formats/test", h.TestDateFormats, "/api/config/date-formats/test")
+	v.Get("/config/null-tokens", h.GetNullTokens, "/api/config/null-tokens")
+	admin.Put("/config/null-tokens", h.SaveNullTokens, "/api/config/null-tokens")
+	v.Get("/config/kpis", h.GetKPIConfig, "/api/config/kpis")
+	admin.Put("/config/kpis", h.SaveKPIConfig, "/api/config/kpis")
+	admin.Delete("/config/kpis", h.ResetKPIConfig, "/api/config/kpis")
+	v.Get("/config/log-level", h.GetLogLevel)
+	admin.Put("/config/log-level", h.SetLogLevel)
+
+	// Runtime diagnostics
+	admin.Get("/admin/runtime", h.GetRuntime)
+	admin.Get("/admin/pprof/*", h.Pprof)
+	admin.Post("/admin/pprof/symbol", pprof.Symbol)
+	admin.Get("/audit", h.GetAuditLog)
+
+	// LLM health and call log
+	v.LLM(always).Get("/llm/health", h.GetLLMHealth)
+	v.Get("/llm/calls", h.ListLLMCalls)
+	v.Get("/llm/calls/summary", h.GetLLMCallSummary)
+	v.Get("/llm/calls/{id}", h.GetLLMCall)
+	admin.Post("/llm/calls/redact", h.RedactLLMCalls)
+	admin.Delete("/llm/calls", h.ClearLLMCalls)
+
+	// Webhooks
+	admin.Get("/webhooks", h.ListWebhooks)
+	admin.Post("/webhooks", h.CreateWebhook)
+	admin.Delete("/webhooks/{id}", h.DeleteWebhook)
+	admin.Post("/webhooks/{id}/test", h.TestWebhook)
+
+	// Feedback and learning
+	v.Post("/feedback/match", h.SubmitMatchFeedback, "/feedback/match")
+	v.Get("/feedback/stats", h.GetFeedbackStats, "/feedback/stats")
+	v.Get("/feedback/suggestions", h.GetFeedbackSuggestions, "/api/feedback/suggestions")
+	v.Get("/feedback", h.ListFeedback, "/feedback")
+	v.Put("/feedback/{id}", h.UpdateFeedback, "/feedback/{id}")
+	admin.Delete("/feedback/{id}", h.DeleteFeedback, "/feedback/{id}")
+	exports.Get("/learning/export", h.ExportLearning, "/api/learning/export")
+	admin.Post("/learning/import", h.ImportLearning, "/api/learning/import")
+	v.Get("/learning/weights", h.GetLearningWeights, "/api/learning/weights")
+	admin.Put("/learning/weights", h.SetLearningWeights, "/api/learning/weights")
+	v.Get("/learning/ensemble", h.GetEnsembleWeights)
+	admin.Delete("/learning/ensemble", h.ResetEnsembleWeights)
+	v.Get("/learning/calibration", h.GetLearningCalibration, "/api/learning/calibration")
+	admin.Post("/learning/calibration/reset", h.ResetLearningCalibration, "/api/learning/calibration/reset")
+	v.Get("/learning/patterns", h.GetLearningPatterns, "/api/learning/patterns")
+	admin.Delete("/learning/patterns", h.DeleteLearningPattern, "/api/learning/patterns")
+	unaudited.Post("/learning/patterns/dry-run", h.DryRunLearningPatterns, "/api/learning/patterns/dry-run")
+
+	r.Mount(APIPrefix, v.v1)
+}
+
+// versionedRouter registers a route under /api/v1 and its legacy paths on
+// the root router, both gated by the route's role and rate limits
+type versionedRouter struct {
+	root    chi.Router
+	v1      chi.Router
+	aliases map[string]string        // "METHOD legacy pattern" -> successor pattern
+	roles   map[string]auth.Role     // "METHOD /api/v1 pattern" -> required role
+	role    auth.Role                // Role of the routes registered; empty = by method
+	llm     func(*http.Request) bool // Whether a request calls the LLM; nil = never
+	audit   *bool                    // Whether requests are audited; nil = by method and role
+}
+
+// With returns a router registering routes that need the given role
+func (v *versionedRouter) With(role auth.Role) *versionedRouter {
+	w := *v
+	w.role = role
+	return &w
+}
+
+// LLM returns a router registering routes whose requests, when the function
+// says so, also count against the LLM rate limit
+func (v *versionedRouter) LLM(when func(*http.Request) bool) *versionedRouter {
+	w := *v
+	w.llm = when
+	return &w
+}
+
+// Audit returns a router registering routes whose requests are recorded in
+// the audit log, or not, whatever their method and role
+func (v *versionedRouter) Audit(on bool) *versionedRouter {
+	w := *v
+	w.audit = &on
+	return &w
+}
+
+// middlewares gate a route, the /api/v1 pattern given: the audit log
+// records the attempt, the client address is rate limited, so API keys
+// can't be guessed at full speed, then its role and the client's rate
+// limits are checked
+func (v *versionedRouter) middlewares(method, path string) []func(http.Handler) http.Handler {
+	mws := []func(http.Handler) http.Handler{}
+	if v.audited(method) {
+		mws = append(mws, auditRequest(method+" "+APIPrefix+path))
+	}
+	mws = append(mws,
+		rateLimit(ratelimit.IP(), nil, addressKey),
+		requireRole(v.roleFor(method)),
+		rateLimit(ratelimit.General(), nil, clientKey))
+	if v.llm != nil {
+		mws = append(mws, rateLimit(ratelimit.LLM(), v.llm, clientKey))
+	}
+	return mws
+}
+
+// audited reports whether a route's requests are recorded: the router's
+// choice, or changes that need more than the viewer role
+func (v *versionedRouter) audited(method string) bool {
+	if v.audit != nil {
+		return *v.audit
+	}
+	return method != http.MethodGet && v.roleFor(method) != auth.RoleViewer
+}
+
+// roleFor is the role a route needs: the router's, or viewer for reads and
+// analyst for changes
+func (v *versionedRouter) roleFor(method string) auth.Role {
+	switch {
+	case v.role != "":
+		return v.role
+	case method == http.MethodGet:
+		return auth.RoleViewer
+	}
+	return auth.RoleAnalyst
+}
+
+func (v *versionedRouter) Get(path string, handler http.HandlerFunc, legacy ...string) {
+	v.handle(http.MethodGet, path, handler, legacy)
+}
+
+func (v *versionedRouter) Post(path string, handler http.HandlerFunc, legacy ...string) {
+	v.handle(http.MethodPost, path, handler, legacy)
+}
+
+func (v *versionedRouter) Put(path string, handler http.HandlerFunc, legacy ...string) {
+	v.handle(http.MethodPut, path, handler, legacy)
+}
+
+func (v *versionedRouter) Delete(path string, handler http.HandlerFunc, legacy ...string) {
+	v.handle(http.MethodDelete, path, handler, legacy)
+}
+
+func (v *versionedRouter) handle(method, path string, handler http.HandlerFunc, legacy []string) {
+	role := v.roleFor(method)
+	v.roles[method+" "+APIPrefix+path] = role
+	v.v1.With(v.middlewares(method, path)...).Method(method, path, handler)
+	for _, old := range legacy {
+		v.Alias(method, old, handler, path)
+	}
+}
+
+// Alias registers a deprecated legacy path whose successor is the /api/v1
+// path given
+func (v *versionedRouter) Alias(method, legacy string, handler http.HandlerFunc, successor string) {
+	v.aliases[method+" "+legacy] = APIPrefix + successor
+	v.root.With(deprecatedAlias(APIPrefix+successor)).With(v.middlewares(method, successor)...).Method(method, legacy, handler)
+}
+
+// requireRole authenticates the request's API key and checks its role
+func requireRole(role auth.Role) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			p, ok := auth.Authenticate(r)
+			if ok {
+				audit.SetPrincipal(r.Context(), p.Name, string(p.Role))
+			}
+			if !ok {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+				apierr.Write(w, apierr.Unauthorized("A valid API key is required (Authorization: Bearer <key> or X-API-Key)"))
+				return
+			}
+			if !p.Role.Includes(role) {
+				apierr.Write(w, apierr.Forbidden("This endpoint needs the "+string(role)+" role").WithDetail("role", p.Role))
+				return
+			}
+			ctx := logging.ContextWith(auth.WithPrincipal(r.Context(), p), "user", p.Name)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// auditRequest records a request in the audit log once served, as the
+// action given, with its status, principal and the details the handler added
+func auditRequest(action string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := audit.Begin(r.Context())
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			next.ServeHTTP(ww, r.WithContext(ctx))
+
+			status := ww.Status()
+			if status == 0 {
+				status = http.StatusOK
+			}
+			audit.Finish(ctx, audit.Entry{Action: action, Path: r.URL.Path, Status: status, Remote: remoteHost(r)})
+		})
+	}
+}
+
+// rateLimit takes a token of the client's bucket for the requests applies
+// to (all when nil), rejecting the request once the bucket is empty. key
+// names the bucket of a request.
+func rateLimit(limiter *ratelimit.Limiter, applies func(*http.Request) bool, key func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		if !limiter.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if applies != nil && !applies(r) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			res := limiter.Allow(key(r))
+			w.Header().Set("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			if !res.Allowed {
+				seconds := int(math.Ceil(res.RetryAfter.Seconds()))
+				w.Header().Set("Retry-After", strconv.Itoa(seconds))
+				apierr.Write(w, apierr.TooManyRequests(fmt.Sprintf("Rate limit exceeded; retry in %ds", seconds)).
+					WithDetail("limit", limiter.Name()).
+					WithDetail("retry_after", seconds))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// clientKey identifies the client of a request for rate limiting: its API
+// key user when authentication is on, its address otherwise
+func clientKey(r *http.Request) string {
+	if p, ok := auth.FromContext(r.Context()); ok && auth.Enabled() {
+		return "user:" + p.Name
+	}
+	return addressKey(r)
+}
+
+// addressKey identifies the client of a request by address
+func addressKey(r *http.Request) string {
+	return "ip:" + remoteHost(r)
+}
+
+// remoteHost is the address of the client, without the port. Forwarded
+// addresses only count from trusted proxies (see RealIP).
+func remoteHost(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// similarityUsesAI reports whether a column similarity request asks for AI
+// matching, directly or through its matching profile
+func similarityUsesAI(r *http.Request) bool {
+	switch r.URL.Query().Get("use_ai") {
+	case "true":
+		return true
+	case "":
+		if name := r.URL.Query().Get("profile"); name != "" {
+			p, ok := service.GetMatchingProfileStore().Get(name)
+			return ok && p.UseAI
+		}
+	}
+	return false
+}
+
+// deprecatedAlias marks responses of a legacy path with the Deprecation
+// header and a Link to the successor, with the request's path parameters
+func deprecatedAlias(successor string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			link := pathParamPattern.ReplaceAllStringFunc(successor, func(param string) string {
+				name := pathParamPattern.FindStringSubmatch(param)[1]
+				return url.PathEscape(chi.URLParam(r, name))
+			})
+			w.Header().Set("Deprecation", "true")
+			w.Header().Set("Link", "<"+link+`>; rel="successor-version"`)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package api
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"context"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Saved Queries
+// ============================================================================
+
+// RunSavedQuery executes a saved query against the loaded files: a plan as
+// is, a question through the /query pipeline without conversation history
+func (h *Handler) RunSavedQuery(q service.SavedQuery) service.SavedQueryRun {
+	run := service.SavedQueryRun{}
+	fileIndex, err := queryFileIndex(QueryRequest{Question: q.Question, Dataset: q.Dataset}, nil)
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	run.Dataset = queryDatasetName(fileIndex)
+	df, note, err := queryFrame(fileIndex)
+	if err != nil {
+		run.Error = err.Error()
+		return run
+	}
+	for _, i := range []int{1, 2} {
+		if fileIndex == i || fileIndex == queryBothFiles {
+			run.Files = append(run.Files, state.State.GetDataFrame(i).FileName)
+		}
+	}
+
+	if q.Plan != nil {
+		plan := *q.Plan
+		result, err := analysis.ExecutePlan(df.Headers, df.Rows, &plan)
+		if err != nil {
+			run.Error = err.Error()
+			return run
+		}
+		run.Mode = "plan"
+		run.Answer = withNote(formatQueryResult(result), note)
+		run.Plan = &plan
+		run.Rows = result.Rows
+		return run
+	}
+
+	resp := h.answerQuery(context.Background(), df, q.Question, nil)
+	run.Mode = resp.Mode
+	run.Answer = withNote(resp.Answer, note)
+	run.Plan = resp.Plan
+	run.Rows = resp.ResultData
+	return run
+}
+
+// ListSavedQueries handles GET /api/v1/query/saved
+func (h *Handler) ListSavedQueries(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"queries": service.GetSavedQueryStore().List(),
+	})
+}
+
+// CreateSavedQuery handles POST /api/v1/query/saved
+// Body: name, question or plan, optional dataset, schedule (e.g. "24h") and
+// run_on_upload
+func (h *Handler) CreateSavedQuery(w http.ResponseWriter, r *http.Request) {
+	var q service.SavedQuery
+	if err := json.NewDecoder(r.Body).Decode(&q); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	saved, err := service.GetSavedQueryStore().Save(q)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"query":   saved,
+	})
+}
+
+// GetSavedQuery handles GET /api/v1/query/saved/{id}
+func (h *Handler) GetSavedQuery(w http.ResponseWriter, r *http.Request) {
+	q, ok := service.GetSavedQueryStore().Get(chi.URLParam(r, "id"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Saved query not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(q)
+}
+
+// DeleteSavedQuery handles DELETE /api/v1/query/saved/{id}
+func (h *Handler) DeleteSavedQuery(w http.ResponseWriter, r *http.Request) {
+	if err := service.GetSavedQueryStore().Delete(chi.URLParam(r, "id")); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// RunSavedQueryNow handles POST /api/v1/query/saved/{id}/run
+// Runs the query against the loaded files and stores the result.
+func (h *Handler) RunSavedQueryNow(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, ok := service.GetSavedQueryStore().Get(id); !ok {
+		apierr.Write(w, apierr.NotFound("Saved query not found"))
+		return
+	}
+
+	run, err := service.GetSavedQueryStore().Run(id, service.RunTriggerManual)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// GetSavedQueryRuns handles GET /api/v1/query/saved/{id}/runs
+// Returns the stored results of the latest runs, newest first.
+func (h *Handler) GetSavedQueryRuns(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if _, ok := service.GetSavedQueryStore().Get(id); !ok {
+		apierr.Write(w, apierr.NotFound("Saved query not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"runs": service.GetSavedQueryStore().Runs(id),
+	})
+}
@@ -0,0 +1,181 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/logging"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Reference Schemas
+// ============================================================================
+
+var schemasLog = logging.Component("schemas")
+
+// createSchemaRequest registers a schema from its columns or, with
+// from_file_index, from the columns of a loaded file
+type createSchemaRequest struct {
+	service.ReferenceSchema
+	FromFileIndex int `json:"from_file_index,omitempty"`
+}
+
+// ListSchemas handles GET /api/v1/schemas
+func (h *Handler) ListSchemas(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"schemas": service.GetReferenceSchemaStore().List(),
+	})
+}
+
+// CreateSchema handles POST /api/v1/schemas
+// Registers a canonical schema; from_file_index captures the names, types
+// and semantic types of a loaded file instead of listing columns
+func (h *Handler) CreateSchema(w http.ResponseWriter, r *http.Request) {
+	var req createSchemaRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	schema := req.ReferenceSchema
+	if req.FromFileIndex != 0 {
+		if len(schema.Columns) > 0 {
+			apierr.Write(w, apierr.BadRequest("give either columns or from_file_index, not both"))
+			return
+		}
+		df, apiErr := loadedFrame(req.FromFileIndex)
+		if apiErr != nil {
+			apierr.Write(w, apiErr)
+			return
+		}
+		ctx := state.State.GetContext(req.FromFileIndex)
+		if ctx == nil {
+			ctx = h.ContextService.GetContext(req.FromFileIndex)
+		}
+		dict := h.EnhancedSimilarityService.BuildDataDictionary(df, req.FromFileIndex, ctx)
+		schema = service.SchemaFromDictionary(schema.Name, schema.Description, dict)
+	}
+
+	saved, err := service.GetReferenceSchemaStore().Save(schema, false)
+	if err != nil {
+		if _, exists := service.GetReferenceSchemaStore().Get(schema.Name); exists {
+			apierr.Write(w, apierr.Conflict(err.Error()))
+			return
+		}
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "schema", saved.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(saved)
+}
+
+// GetSchema handles GET /api/v1/schemas/{name}
+func (h *Handler) GetSchema(w http.ResponseWriter, r *http.Request) {
+	schema, ok := service.GetReferenceSchemaStore().Get(chi.URLParam(r, "name"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Schema not found"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(schema)
+}
+
+// SaveSchema handles PUT /api/v1/schemas/{name}
+// Creates or replaces a schema
+func (h *Handler) SaveSchema(w http.ResponseWriter, r *http.Request) {
+	var schema service.ReferenceSchema
+	if err := json.NewDecoder(r.Body).Decode(&schema); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	schema.Name = chi.URLParam(r, "name")
+
+	saved, err := service.GetReferenceSchemaStore().Save(schema, true)
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "schema", saved.Name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(saved)
+}
+
+// DeleteSchema handles DELETE /api/v1/schemas/{name}
+func (h *Handler) DeleteSchema(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if err := service.GetReferenceSchemaStore().Delete(name); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "schema", name)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// ScoreSchemaConformance handles POST /api/v1/schemas/{name}/score
+// Scores a loaded file against the schema: the percent of canonical columns
+// it holds with a compatible type, and the file column mapped to each.
+// Body: {"file_index": 1, "profile": "strict"}
+func (h *Handler) ScoreSchemaConformance(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileIndex int    `json:"file_index"`
+		Profile   string `json:"profile"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.FileIndex == 0 {
+		req.FileIndex = 1
+	}
+
+	schema, ok := service.GetReferenceSchemaStore().Get(chi.URLParam(r, "name"))
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Schema not found"))
+		return
+	}
+	profile, ok := service.GetMatchingProfileStore().Get(req.Profile)
+	if !ok {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown matching profile: %s", req.Profile)))
+		return
+	}
+	df, apiErr := loadedFrame(req.FileIndex)
+	if apiErr != nil {
+		apierr.Write(w, apiErr)
+		return
+	}
+
+	result := h.EnhancedSimilarityService.ScoreConformance(df, req.FileIndex, schema, profile)
+	schemasLog.InfoContext(r.Context(), "Scored schema conformance", "schema", schema.Name,
+		"file_index", req.FileIndex, "conformance", result.Conformance)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(result)
+}
+
+// loadedFrame returns a loaded file, checking the index
+func loadedFrame(fileIndex int) (*state.DataFrame, *apierr.Error) {
+	if fileIndex != 1 && fileIndex != 2 {
+		return nil, apierr.BadRequest("file_index must be 1 or 2")
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		return nil, apierr.FileNotLoaded(fileIndex)
+	}
+	return df, nil
+}
@@ -0,0 +1,442 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+// ============================================================================
+// Similarity / What-If
+// ============================================================================
+
+// WhatIfSimilarity handles POST /api/v1/similarity/whatif
+// Re-ranks the loaded files with caller-supplied weights and thresholds and
+// compares the result against the current configuration. Nothing is persisted.
+func (h *Handler) WhatIfSimilarity(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to calculate similarity"))
+		return
+	}
+
+	var req struct {
+		Profile        string                   `json:"profile"`
+		Weights        *service.AdaptiveWeights `json:"weights"`
+		MinConfidence  *float64                 `json:"min_confidence"`
+		MatchThreshold *float64                 `json:"match_threshold"`
+		NameAlgorithm  string                   `json:"name_algorithm"`
+		TopN           int                      `json:"top_n"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	// Start from a named profile if given, then apply explicit overrides
+	opts := service.DefaultScoringOptions()
+	matchThreshold := 50.0
+	assignmentMode := service.AssignmentAll
+	if req.Profile != "" {
+		profile, ok := service.GetMatchingProfileStore().Get(req.Profile)
+		if !ok {
+			apierr.Write(w, apierr.BadRequest("Unknown matching profile: "+req.Profile))
+			return
+		}
+		opts = profile.ScoringOptions()
+		matchThreshold = profile.MatchThreshold
+		assignmentMode = profile.AssignmentMode
+	}
+	if req.Weights != nil {
+		weights, err := service.NormalizeWeights(*req.Weights)
+		if err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		opts.Weights = &weights
+	}
+	if req.MinConfidence != nil {
+		opts.MinConfidence = *req.MinConfidence
+	}
+	if req.MatchThreshold != nil {
+		matchThreshold = *req.MatchThreshold
+	}
+	if req.NameAlgorithm != "" {
+		if err := service.ValidateNameAlgorithm(req.NameAlgorithm); err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		opts.NameAlgorithm = req.NameAlgorithm
+	}
+
+	ctx1 := state.State.GetContext(1)
+	ctx2 := state.State.GetContext(2)
+
+	baseline, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarity(df1, df2, ctx1, ctx2)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+		return
+	}
+	whatIf, whatIfStats, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarityWithOptions(r.Context(), df1, df2, ctx1, ctx2, opts)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+		return
+	}
+	whatIf = service.ApplyAssignment(whatIf, assignmentMode)
+	comparison := service.CompareMatchSets(baseline, whatIf, matchThreshold)
+
+	baselineWeights := service.GetAdaptiveLearner().GetWeights()
+	whatIfWeights := baselineWeights
+	if opts.Weights != nil {
+		whatIfWeights = *opts.Weights
+	}
+
+	if req.TopN > 0 {
+		if len(baseline) > req.TopN {
+			baseline = baseline[:req.TopN]
+		}
+		if len(whatIf) > req.TopN {
+			whatIf = whatIf[:req.TopN]
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"baseline": map[string]interface{}{
+			"weights": baselineWeights,
+			"results": baseline,
+		},
+		"whatif": map[string]interface{}{
+			"weights":         whatIfWeights,
+			"min_confidence":  opts.MinConfidence,
+			"assignment_mode": assignmentMode,
+			"name_algorithm":  opts.NameAlgorithm,
+			"results":         whatIf,
+			"run_stats":       whatIfStats,
+		},
+		"comparison": comparison,
+	})
+}
+
+// AnalyzeGraph handles GET /api/v1/graph/analyze
+// Runs community detection, PageRank centrality and transitive path finding
+// on the similarity graph of the loaded files.
+// Query: profile, max_depth (2-4, default 3), limit (default 20), source and
+// target node IDs (e.g. f1_email, f2_mail) to list the paths between them
+func (h *Handler) AnalyzeGraph(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to analyze the similarity graph"))
+		return
+	}
+
+	opts := service.DefaultScoringOptions()
+	if name := r.URL.Query().Get("profile"); name != "" {
+		profile, ok := service.GetMatchingProfileStore().Get(name)
+		if !ok {
+			apierr.Write(w, apierr.BadRequest("Unknown matching profile: "+name))
+			return
+		}
+		opts = profile.ScoringOptions()
+	}
+	maxDepth := 3
+	if v := r.URL.Query().Get("max_depth"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 2 || n > 4 {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("max_depth must be 2 to 4, got %q", v)))
+			return
+		}
+		maxDepth = n
+	}
+	limit := 20
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("limit must be a positive integer, got %q", v)))
+			return
+		}
+		limit = n
+	}
+
+	results, _, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarityWithOptions(r.Context(), df1, df2,
+		state.State.GetContext(1), state.State.GetContext(2), opts)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+		return
+	}
+	analyzer := service.NewGraphAnalyzer()
+	graph := analyzer.BuildSchemaGraph(results, df1.Headers, df2.Headers)
+	analysis := analyzer.Analyze(graph, maxDepth, limit)
+
+	source, target := r.URL.Query().Get("source"), r.URL.Query().Get("target")
+	if source != "" || target != "" {
+		for _, id := range []string{source, target} {
+			if !graph.HasNode(id) {
+				apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown node %q (use f1_<column> or f2_<column>)", id)))
+				return
+			}
+		}
+		analysis.Paths = analyzer.FindTransitivePaths(graph, source, target, maxDepth)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(analysis)
+}
+
+// ExportGraph handles GET /api/v1/graph/export
+// Downloads the similarity graph, with communities and centrality, for
+// Gephi, yEd or Cytoscape.
+// Query: format=graphml|cytoscape, profile
+func (h *Handler) ExportGraph(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to export the similarity graph"))
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = service.GraphFormatGraphML
+	}
+	if format != service.GraphFormatGraphML && format != service.GraphFormatCytoscape {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Unknown format %q (use graphml or cytoscape)", format)))
+		return
+	}
+
+	opts := service.DefaultScoringOptions()
+	if name := r.URL.Query().Get("profile"); name != "" {
+		profile, ok := service.GetMatchingProfileStore().Get(name)
+		if !ok {
+			apierr.Write(w, apierr.BadRequest("Unknown matching profile: "+name))
+			return
+		}
+		opts = profile.ScoringOptions()
+	}
+
+	results, _, err := h.EnhancedSimilarityService.CalculateEnhancedSimilarityWithOptions(r.Context(), df1, df2,
+		state.State.GetContext(1), state.State.GetContext(2), opts)
+	if err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error computing similarity: %v", err)))
+		return
+	}
+	analyzer := service.NewGraphAnalyzer()
+	graph := analyzer.BuildSchemaGraph(results, df1.Headers, df2.Headers)
+	analyzer.Enrich(graph)
+
+	switch format {
+	case service.GraphFormatGraphML:
+		out, err := service.RenderGraphML(graph, df1.FileName, df2.FileName)
+		if err != nil {
+			apierr.Write(w, apierr.Internal(fmt.Sprintf("Error rendering graph: %v", err)))
+			return
+		}
+		w.Header().Set("Content-Type", "application/graphml+xml")
+		w.Header().Set("Content-Disposition", "attachment; filename=similarity_graph.graphml")
+		w.Write(out)
+	case service.GraphFormatCytoscape:
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Content-Disposition", "attachment; filename=similarity_graph.cyjs")
+		json.NewEncoder(w).Encode(service.BuildCytoscapeGraph(graph, df1.FileName, df2.FileName))
+	}
+}
+
+// GetEnsembleSimilarity handles GET /api/v1/similarity/ensemble
+// Runs the heuristic, embedding and LLM matchers together and combines
+// their scores with the learned ensemble weights, reporting each matcher's
+// score and how many agree.
+// Query: profile, limit (default 50)
+func (h *Handler) GetEnsembleSimilarity(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to calculate similarity"))
+		return
+	}
+
+	opts := service.DefaultScoringOptions()
+	if name := r.URL.Query().Get("profile"); name != "" {
+		profile, ok := service.GetMatchingProfileStore().Get(name)
+		if !ok {
+			apierr.Write(w, apierr.BadRequest("Unknown matching profile: "+name))
+			return
+		}
+		opts = profile.ScoringOptions()
+	}
+	limit := 50
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("limit must be a positive integer, got %q", v)))
+			return
+		}
+		limit = n
+	}
+
+	report := h.EnsembleMatcher.Match(r.Context(), df1, df2, state.State.GetContext(1), state.State.GetContext(2), opts)
+	total := len(report.Results)
+	if len(report.Results) > limit {
+		report.Results = report.Results[:limit]
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"results":             report.Results,
+		"total_relationships": total,
+		"matchers":            report.Matchers,
+		"weights":             report.Weights,
+	})
+}
+
+// GetEnsembleWeights handles GET /api/v1/learning/ensemble
+// The matcher weights of the ensemble, learned from match feedback
+func (h *Handler) GetEnsembleWeights(w http.ResponseWriter, r *http.Request) {
+	learner := service.GetEnsembleLearner()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"weights":         learner.GetWeights(),
+		"default_weights": service.DefaultEnsembleWeights,
+		"updates":         learner.Updates(),
+	})
+}
+
+// ResetEnsembleWeights handles DELETE /api/v1/learning/ensemble
+func (h *Handler) ResetEnsembleWeights(w http.ResponseWriter, r *http.Request) {
+	if err := service.GetEnsembleLearner().Reset(); err != nil {
+		apierr.Write(w, apierr.Internal(fmt.Sprintf("Error resetting ensemble weights: %v", err)))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"weights": service.DefaultEnsembleWeights,
+	})
+}
+
+// ColumnCandidate is a candidate match of one column with its review status
+type ColumnCandidate struct {
+	service.SimilarityResult
+	Rank   int    `json:"rank"`
+	Status string `json:"status,omitempty"` // Review status of the pair, if reviewed
+}
+
+// GetColumnCandidates handles GET /api/v1/similarity/candidates
+// Ranks the columns of the other file as matches for one column, with every
+// signal, scoring only that column's pairs.
+// Query: file (1 or 2), column, profile, name_algorithm, limit (default 10)
+func (h *Handler) GetColumnCandidates(w http.ResponseWriter, r *http.Request) {
+	df1 := state.State.GetDataFrame(1)
+	df2 := state.State.GetDataFrame(2)
+
+	if df1 == nil || df2 == nil {
+		apierr.Write(w, apierr.FilesNotLoaded("Both files must be loaded to rank match candidates"))
+		return
+	}
+
+	fileIndex, err := strconv.Atoi(r.URL.Query().Get("file"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("file must be 1 or 2"))
+		return
+	}
+	column := r.URL.Query().Get("column")
+	if column == "" {
+		apierr.Write(w, apierr.BadRequest("column is required"))
+		return
+	}
+
+	opts := service.DefaultScoringOptions()
+	if name := r.URL.Query().Get("profile"); name != "" {
+		profile, ok := service.GetMatchingProfileStore().Get(name)
+		if !ok {
+			apierr.Write(w, apierr.BadRequest("Unknown matching profile: "+name))
+			return
+		}
+		opts = profile.ScoringOptions()
+	}
+	if algorithm := r.URL.Query().Get("name_algorithm"); algorithm != "" {
+		if err := service.ValidateNameAlgorithm(algorithm); err != nil {
+			apierr.Write(w, apierr.BadRequest(err.Error()))
+			return
+		}
+		opts.NameAlgorithm = algorithm
+	}
+	limit := 10
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			apierr.Write(w, apierr.BadRequest(fmt.Sprintf("limit must be a positive integer, got %q", v)))
+			return
+		}
+		limit = n
+	}
+
+	results, err := h.EnhancedSimilarityService.ColumnCandidates(r.Context(), df1, df2,
+		state.State.GetContext(1), state.State.GetContext(2), fileIndex, column, opts)
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	total := len(results)
+	if len(results) > limit {
+		results = results[:limit]
+	}
+
+	approved := service.GetMappingStore().Current()
+	candidates := make([]ColumnCandidate, len(results))
+	for i, res := range results {
+		candidates[i] = ColumnCandidate{
+			SimilarityResult: res,
+			Rank:             i + 1,
+			Status:           approved.StatusOf(res.File1Column, res.File2Column),
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file":       fileIndex,
+		"column":     column,
+		"candidates": candidates,
+		"total":      total,
+	})
+}
+
+// GetSimilarityScorers handles GET /api/v1/similarity/scorers
+// Lists the registered similarity signals with their current effective weights
+func (h *Handler) GetSimilarityScorers(w http.ResponseWriter, r *http.Request) {
+	weights := service.GetAdaptiveLearner().GetWeights()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"scorers":          h.EnhancedSimilarityService.Scorers().Describe(weights),
+		"adaptive_weights": weights,
+		"name_algorithms":  service.NameAlgorithms(),
+	})
+}
+
+// GetProfileCacheStats reports column profile cache usage
+func (h *Handler) GetProfileCacheStats(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(service.GetColumnProfileCache().Stats())
+}
+
+// ClearProfileCache drops all cached column profiles
+func (h *Handler) ClearProfileCache(w http.ResponseWriter, r *http.Request) {
+	service.GetColumnProfileCache().Clear()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Profile cache cleared",
+	})
+}
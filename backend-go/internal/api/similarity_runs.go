@@ -0,0 +1,72 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/audit"
+	"backend-go/internal/service"
+	"encoding/json"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Similarity Runs
+// ============================================================================
+
+// ListSimilarityRuns handles GET /api/v1/similarity/runs
+// Stored column similarity runs without their matches, newest first
+func (h *Handler) ListSimilarityRuns(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"runs": service.GetSimilarityRunStore().List(),
+	})
+}
+
+// GetSimilarityRun handles GET /api/v1/similarity/runs/{id}
+func (h *Handler) GetSimilarityRun(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	run, ok := service.GetSimilarityRunStore().Get(id)
+	if !ok {
+		apierr.Write(w, apierr.NotFound("Similarity run not found: "+id))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(run)
+}
+
+// DeleteSimilarityRun handles DELETE /api/v1/similarity/runs/{id}
+func (h *Handler) DeleteSimilarityRun(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if err := service.GetSimilarityRunStore().Delete(id); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+	audit.Annotate(r.Context(), "run", id)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// DiffSimilarityRuns handles GET /api/v1/similarity/runs/diff
+// Query: from and to run IDs. Lists the matches that appeared, disappeared
+// or changed confidence between the two runs.
+func (h *Handler) DiffSimilarityRuns(w http.ResponseWriter, r *http.Request) {
+	from, to := r.URL.Query().Get("from"), r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		apierr.Write(w, apierr.BadRequest("from and to run IDs are required"))
+		return
+	}
+
+	diff, err := service.GetSimilarityRunStore().Diff(from, to)
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(diff)
+}
@@ -0,0 +1,204 @@
+package api
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Column Statistics
+// ============================================================================
+
+// GetColumnStats handles GET /api/v1/stats/{fileIndex}/{column}
+// Counts, percentiles, moments and a histogram of one column; the
+// distribution is only reported for numeric columns
+// Query: bins=N (default Sturges' rule)
+func (h *Handler) GetColumnStats(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+	name, err := url.PathUnescape(chi.URLParam(r, "column"))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest("Invalid column name"))
+		return
+	}
+
+	col := columnByName(df, name)
+	if col == nil {
+		apierr.Write(w, apierr.NotFound(fmt.Sprintf("Column %q not found", name)))
+		return
+	}
+
+	distinct := make(map[string]struct{})
+	nulls := 0
+	for i := 0; i < col.Len(); i++ {
+		if col.IsNull(i) {
+			nulls++
+			continue
+		}
+		distinct[col.Value(i)] = struct{}{}
+	}
+
+	resp := map[string]interface{}{
+		"file_index": fileIndex,
+		"column":     name,
+		"type":       col.Type,
+		"count":      col.Len() - nulls,
+		"nulls":      nulls,
+		"distinct":   len(distinct),
+	}
+	if col.Type == state.ColumnFloat {
+		resp["numeric_count"] = col.FloatCount
+		dist := analysis.DescribeDistribution(col.FloatValues(), getIntParam(r, "bins", 0))
+		dist.NonFinite += col.NonFinite // Left out of the column's values already
+		resp["distribution"] = dist
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// GetValueFrequencies handles GET /api/v1/stats/frequencies
+// Most common values of a column with counts and percentages, the rest in an "other" bucket
+// Query: file=1|2, column=name, k=10, normalize=true (group case and surrounding whitespace)
+func (h *Handler) GetValueFrequencies(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	fileIndex := getIntParam(r, "file", 1)
+	if fileIndex != 1 && fileIndex != 2 {
+		apierr.Write(w, apierr.BadRequest("file must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+	name := q.Get("column")
+	if name == "" {
+		apierr.Write(w, apierr.BadRequest("column is required"))
+		return
+	}
+	col := columnByName(df, name)
+	if col == nil {
+		apierr.Write(w, apierr.NotFound(fmt.Sprintf("Column %q not found", name)))
+		return
+	}
+	normalize := q.Get("normalize") == "true"
+
+	counts := make(map[string]int)
+	for i := 0; i < col.Len(); i++ {
+		if col.IsNull(i) {
+			continue
+		}
+		v := col.Value(i)
+		if normalize {
+			v = strings.ToLower(strings.TrimSpace(v))
+		}
+		counts[v]++
+	}
+	freq := analysis.TopFrequencies(counts, getIntParam(r, "k", 10))
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file_index": fileIndex,
+		"column":     name,
+		"nulls":      col.Len() - freq.Total,
+		"total":      freq.Total,
+		"distinct":   freq.Distinct,
+		"top":        freq.Top,
+		"other":      freq.Other,
+	})
+}
+
+// GetColumnValues handles GET /api/v1/columns/{fileIndex}/{column}/values
+// Distinct values of a column with their counts and the folded and
+// normalized forms value overlap and normalized match compare, flagging
+// those outside the leading rows the column profile samples
+// Query: sort=count|value (default count), search, offset, limit (default 100, max 1000)
+func (h *Handler) GetColumnValues(w http.ResponseWriter, r *http.Request) {
+	fileIndex, err := strconv.Atoi(chi.URLParam(r, "fileIndex"))
+	if err != nil || (fileIndex != 1 && fileIndex != 2) {
+		apierr.Write(w, apierr.BadRequest("fileIndex must be 1 or 2"))
+		return
+	}
+	df := state.State.GetDataFrame(fileIndex)
+	if df == nil {
+		apierr.Write(w, apierr.FileNotLoaded(fileIndex))
+		return
+	}
+	name, err := url.PathUnescape(chi.URLParam(r, "column"))
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest("Invalid column name"))
+		return
+	}
+	colIdx := -1
+	for i, header := range df.Headers {
+		if header == name {
+			colIdx = i
+			break
+		}
+	}
+	if colIdx < 0 {
+		apierr.Write(w, apierr.NotFound(fmt.Sprintf("Column %q not found", name)))
+		return
+	}
+	q := r.URL.Query()
+	sortBy := q.Get("sort")
+	if sortBy != "" && sortBy != "count" && sortBy != "value" {
+		apierr.Write(w, apierr.BadRequest("sort must be count or value").WithDetail("sort", sortBy))
+		return
+	}
+	limit := getIntParam(r, "limit", 100)
+	if limit < 1 {
+		limit = 100
+	}
+	limit = min(limit, maxPageRows)
+	offset := max(getIntParam(r, "offset", 0), 0)
+
+	values := h.EnhancedSimilarityService.ColumnValues(df, colIdx, sortBy, q.Get("search"))
+	// Clamped before adding, as offset+limit can overflow
+	start := min(offset, len(values.Values))
+	page := values.Values[start : start+min(limit, len(values.Values)-start)]
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Total-Count", strconv.Itoa(len(values.Values)))
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"file_index":        fileIndex,
+		"column":            name,
+		"rows":              values.Rows,
+		"empty":             values.Empty,
+		"distinct":          len(values.Values),
+		"offset":            offset,
+		"count":             len(page),
+		"values":            page,
+		"value_sample":      values.ValueSample,
+		"normalized_sample": values.NormalizedSample,
+		"sketched":          values.Sketched,
+	})
+}
+
+// columnByName returns the typed column of df with the given header
+func columnByName(df *state.DataFrame, name string) *state.Column {
+	for _, c := range df.Columns() {
+		if c.Name == name {
+			return c
+		}
+	}
+	return nil
+}
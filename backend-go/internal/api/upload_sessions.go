@@ -0,0 +1,490 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/config"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Resumable Uploads
+// ============================================================================
+
+// A resumable upload is a session: created with the file's name, its chunks
+// are appended in order at the offset the server reports, then completed,
+// which registers the file like an upload. The bytes received are the
+// partial file on disk, so after a dropped connection, or a restart, the
+// client asks for the offset and resends from there.
+
+// uploadSessionTTL is how long an idle session is kept
+const uploadSessionTTL = 24 * time.Hour
+
+// uploadSession is a resumable upload in progress
+type uploadSession struct {
+	ID        string    `json:"id"`
+	FileName  string    `json:"file_name"`
+	FileIndex int       `json:"file_index"`
+	Size      int64     `json:"size,omitempty"` // Declared total size; 0 if unknown
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	Client    string    `json:"client"` // Who created it, as rate limited
+	uploadOptions
+}
+
+// uploadSessionResponse reports a session and how much of it was received
+type uploadSessionResponse struct {
+	*uploadSession
+	Offset    int64     `json:"offset"`
+	MaxChunk  int64     `json:"max_chunk"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// uploadSessionLock serializes the requests of a session; refs counts the
+// requests holding or waiting for it
+type uploadSessionLock struct {
+	sync.Mutex
+	refs int
+}
+
+// uploadSessionLocks are the locks of the sessions with requests in
+// progress. A lock is dropped with its last request, so completed, expired
+// and unknown sessions leave nothing behind.
+var uploadSessionLocks = struct {
+	sync.Mutex
+	byID map[string]*uploadSessionLock
+}{byID: map[string]*uploadSessionLock{}}
+
+func lockUploadSession(id string) func() {
+	uploadSessionLocks.Lock()
+	l, ok := uploadSessionLocks.byID[id]
+	if !ok {
+		l = &uploadSessionLock{}
+		uploadSessionLocks.byID[id] = l
+	}
+	l.refs++
+	uploadSessionLocks.Unlock()
+	l.Lock()
+	return func() {
+		l.Unlock()
+		uploadSessionLocks.Lock()
+		if l.refs--; l.refs == 0 {
+			delete(uploadSessionLocks.byID, id)
+		}
+		uploadSessionLocks.Unlock()
+	}
+}
+
+// uploadSessionCreation serializes creating sessions, so two can't both
+// take the last free slot
+var uploadSessionCreation sync.Mutex
+
+// uploadSessionQuota counts the bytes reserved by chunks being written, on
+// top of what the partial files hold
+var uploadSessionQuota struct {
+	sync.Mutex
+	reserved int64
+}
+
+// reserveUploadBytes reserves up to want bytes of upload.session_quota_mb
+// for a chunk and returns how many it got. Bytes written count both on disk
+// and as reserved until releaseUploadBytes, erring towards refusing.
+func reserveUploadBytes(want int64) int64 {
+	uploadSessionQuota.Lock()
+	defer uploadSessionQuota.Unlock()
+	free := config.Get().Upload.SessionQuota() - partialBytes() - uploadSessionQuota.reserved
+	n := max(0, min(want, free))
+	uploadSessionQuota.reserved += n
+	return n
+}
+
+func releaseUploadBytes(n int64) {
+	uploadSessionQuota.Lock()
+	uploadSessionQuota.reserved -= n
+	uploadSessionQuota.Unlock()
+}
+
+// partialBytes is the size of all partial files
+func partialBytes() int64 {
+	entries, err := os.ReadDir(uploadSessionDir())
+	if err != nil {
+		return 0
+	}
+	total := int64(0)
+	for _, e := range entries {
+		if strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		if info, err := e.Info(); err == nil {
+			total += info.Size()
+		}
+	}
+	return total
+}
+
+// uploadSessionDir holds the partial files and their sessions; hidden, so
+// it is not listed with the uploads
+func uploadSessionDir() string {
+	return filepath.Join(uploadDir(), ".partial")
+}
+
+func (s *uploadSession) partPath() string {
+	return filepath.Join(uploadSessionDir(), s.ID)
+}
+
+func (s *uploadSession) metaPath() string {
+	return filepath.Join(uploadSessionDir(), s.ID+".json")
+}
+
+func (s *uploadSession) save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.metaPath(), data, 0644)
+}
+
+// offset is the number of bytes received
+func (s *uploadSession) offset() (int64, error) {
+	info, err := os.Stat(s.partPath())
+	if err != nil {
+		return 0, err
+	}
+	return info.Size(), nil
+}
+
+func (s *uploadSession) remove() {
+	os.Remove(s.partPath())
+	os.Remove(s.metaPath())
+}
+
+func (s *uploadSession) response(offset int64) uploadSessionResponse {
+	return uploadSessionResponse{
+		uploadSession: s,
+		Offset:        offset,
+		MaxChunk:      maxFileSize(),
+		ExpiresAt:     s.UpdatedAt.Add(uploadSessionTTL),
+	}
+}
+
+// openUploadSession locks and reads the request's session, writing a 404
+// when there is none; the caller unlocks it when done
+func openUploadSession(w http.ResponseWriter, r *http.Request) (*uploadSession, func()) {
+	id := chi.URLParam(r, "id")
+	if _, err := hex.DecodeString(id); err != nil || len(id) != 32 {
+		apierr.Write(w, apierr.NotFound("Upload session not found"))
+		return nil, nil
+	}
+	unlock := lockUploadSession(id)
+	data, err := os.ReadFile(filepath.Join(uploadSessionDir(), id+".json"))
+	if err != nil {
+		unlock()
+		apierr.Write(w, apierr.NotFound("Upload session not found"))
+		return nil, nil
+	}
+	var s uploadSession
+	if err := json.Unmarshal(data, &s); err != nil {
+		unlock()
+		apierr.Write(w, apierr.Internal("Upload session is corrupt"))
+		return nil, nil
+	}
+	return &s, unlock
+}
+
+// sweepUploadSessions removes the sessions idle for longer than the TTL
+// and returns the open ones
+func sweepUploadSessions(now time.Time) []uploadSession {
+	entries, err := os.ReadDir(uploadSessionDir())
+	if err != nil {
+		return nil
+	}
+	open := []uploadSession{}
+	for _, e := range entries {
+		id, ok := strings.CutSuffix(e.Name(), ".json")
+		if !ok {
+			continue
+		}
+		unlock := lockUploadSession(id)
+		data, err := os.ReadFile(filepath.Join(uploadSessionDir(), e.Name()))
+		var s uploadSession
+		if err == nil && json.Unmarshal(data, &s) == nil {
+			if now.Sub(s.UpdatedAt) > uploadSessionTTL {
+				s.remove()
+				uploadLog.Info("Expired upload session removed", "session", s.ID, "file", s.FileName)
+			} else {
+				open = append(open, s)
+			}
+		}
+		unlock()
+	}
+	return open
+}
+
+// CreateUploadSession handles POST /api/v1/uploads/sessions
+// Starts a resumable upload of file_name into file_index (with the options
+// of /upload); size, when given, is checked at completion. Sessions open at
+// once are capped by upload.max_sessions, and per client by
+// upload.max_client_sessions.
+func (h *Handler) CreateUploadSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		FileName  string `json:"file_name"`
+		FileIndex int    `json:"file_index"`
+		Size      int64  `json:"size"`
+		uploadOptions
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+	if req.FileIndex == 0 {
+		req.FileIndex = 1
+	}
+	if req.FileIndex != 1 && req.FileIndex != 2 {
+		apierr.Write(w, apierr.BadRequest("file_index must be 1 or 2"))
+		return
+	}
+	req.FileName = filepath.Base(req.FileName)
+	if !isAllowedUploadName(req.FileName) {
+		apierr.Write(w, apierr.BadRequest("Only CSV files (optionally .gz or .zip compressed) are allowed"))
+		return
+	}
+	maxSize := min(maxRemoteFileSize(), config.Get().Upload.SessionQuota())
+	if req.Size < 0 || req.Size > maxSize {
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("size must be between 0 and %d MB", maxSize>>20)))
+		return
+	}
+
+	uploadSessionCreation.Lock()
+	defer uploadSessionCreation.Unlock()
+	open := sweepUploadSessions(time.Now())
+	client := clientKey(r)
+	mine := 0
+	for _, s := range open {
+		if s.Client == client {
+			mine++
+		}
+	}
+	switch limits := config.Get().Upload; {
+	case len(open) >= limits.MaxSessions:
+		apierr.Write(w, apierr.TooManyRequests(fmt.Sprintf("%d upload sessions are already open", len(open))))
+		return
+	case mine >= limits.MaxClientSessions:
+		apierr.Write(w, apierr.TooManyRequests(fmt.Sprintf("You already have %d upload sessions open", mine)))
+		return
+	}
+
+	if err := os.MkdirAll(uploadSessionDir(), 0755); err != nil {
+		apierr.Write(w, apierr.Internal("Failed to create upload session"))
+		return
+	}
+	b := make([]byte, 16)
+	rand.Read(b)
+	now := time.Now().UTC()
+	s := &uploadSession{
+		ID:            hex.EncodeToString(b),
+		FileName:      req.FileName,
+		FileIndex:     req.FileIndex,
+		Size:          req.Size,
+		CreatedAt:     now,
+		UpdatedAt:     now,
+		Client:        client,
+		uploadOptions: req.uploadOptions,
+	}
+	if err := os.WriteFile(s.partPath(), nil, 0644); err != nil {
+		apierr.Write(w, apierr.Internal("Failed to create upload session"))
+		return
+	}
+	if err := s.save(); err != nil {
+		s.remove()
+		apierr.Write(w, apierr.Internal("Failed to create upload session"))
+		return
+	}
+	uploadLog.InfoContext(r.Context(), "Upload session created", "session", s.ID, "file", s.FileName, "size", s.Size)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(s.response(0))
+}
+
+// GetUploadSession handles GET /api/v1/uploads/sessions/{id}
+// Reports the offset to resume from.
+func (h *Handler) GetUploadSession(w http.ResponseWriter, r *http.Request) {
+	s, unlock := openUploadSession(w, r)
+	if s == nil {
+		return
+	}
+	defer unlock()
+	offset, err := s.offset()
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to read upload session"))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.response(offset))
+}
+
+// AppendUploadChunk handles PUT /api/v1/uploads/sessions/{id}?offset=N
+// Appends the request body, of at most upload.max_size_mb, at offset N,
+// which must be the number of bytes received so far; a 409 carries the
+// offset to resume from. A chunk cut off midway is kept up to where it
+// stopped. Chunks past upload.session_quota_mb, which all sessions share,
+// get a 429 until other sessions complete or expire.
+func (h *Handler) AppendUploadChunk(w http.ResponseWriter, r *http.Request) {
+	s, unlock := openUploadSession(w, r)
+	if s == nil {
+		return
+	}
+	defer unlock()
+	offset, err := strconv.ParseInt(r.URL.Query().Get("offset"), 10, 64)
+	if err != nil || offset < 0 {
+		apierr.Write(w, apierr.BadRequest("offset must be the number of bytes already sent"))
+		return
+	}
+	current, err := s.offset()
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to read upload session"))
+		return
+	}
+	if offset != current {
+		apierr.Write(w, apierr.Conflict(fmt.Sprintf("Expected offset %d", current)).WithDetail("offset", current))
+		return
+	}
+
+	limit := maxRemoteFileSize() - current
+	if s.Size > 0 {
+		limit = s.Size - current
+	}
+	if limit > maxFileSize() {
+		limit = maxFileSize()
+	}
+	wanted := limit
+	if limit > 0 {
+		limit = reserveUploadBytes(limit)
+		defer releaseUploadBytes(limit)
+	}
+	if wanted > 0 && limit == 0 {
+		apierr.Write(w, apierr.TooManyRequests("Upload sessions are using all of their disk quota").WithDetail("offset", current))
+		return
+	}
+	f, err := os.OpenFile(s.partPath(), os.O_WRONLY|os.O_APPEND, 0644)
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to write upload session"))
+		return
+	}
+	written, copyErr := io.Copy(f, io.LimitReader(r.Body, limit))
+	closeErr := f.Close()
+	// Anything past the limit is an error; what was written is kept
+	var extra [1]byte
+	n, _ := r.Body.Read(extra[:])
+
+	s.UpdatedAt = time.Now().UTC()
+	s.save()
+	switch {
+	case copyErr != nil:
+		apierr.Write(w, apierr.BadRequest("Chunk interrupted").WithDetail("offset", current+written))
+		return
+	case closeErr != nil:
+		apierr.Write(w, apierr.Internal("Failed to write upload session"))
+		return
+	case n > 0 && limit < wanted:
+		apierr.Write(w, apierr.TooManyRequests("Upload sessions are using all of their disk quota").WithDetail("offset", current+written))
+		return
+	case n > 0:
+		apierr.Write(w, apierr.BadRequest(fmt.Sprintf("Chunk exceeds the declared size or %d MB", maxFileSize()>>20)).
+			WithDetail("offset", current+written))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(s.response(current + written))
+}
+
+// CompleteUploadSession handles POST /api/v1/uploads/sessions/{id}/complete
+// Registers the received file like an upload. The optional sha256 is
+// checked against the bytes received.
+func (h *Handler) CompleteUploadSession(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		SHA256 string `json:"sha256"`
+	}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+			apierr.Write(w, apierr.InvalidJSON(err))
+			return
+		}
+	}
+
+	s, unlock := openUploadSession(w, r)
+	if s == nil {
+		return
+	}
+	defer unlock()
+	offset, err := s.offset()
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to read upload session"))
+		return
+	}
+	if s.Size > 0 && offset != s.Size {
+		apierr.Write(w, apierr.Conflict(fmt.Sprintf("Received %d of %d bytes", offset, s.Size)).WithDetail("offset", offset))
+		return
+	}
+	if req.SHA256 != "" {
+		sum, err := hashFile(s.partPath())
+		if err != nil {
+			apierr.Write(w, apierr.Internal("Failed to read upload session"))
+			return
+		}
+		if !strings.EqualFold(sum, req.SHA256) {
+			apierr.Write(w, apierr.Conflict("sha256 does not match the bytes received").WithDetail("sha256", sum))
+			return
+		}
+	}
+
+	filePath := filepath.Join(uploadDir(), fmt.Sprintf("file%d_%s", s.FileIndex, s.FileName))
+	if err := os.Rename(s.partPath(), filePath); err != nil {
+		apierr.Write(w, apierr.Internal("Failed to save file"))
+		return
+	}
+	s.remove()
+
+	resp := registerUpload(w, s.FileIndex, filePath, s.FileName, s.uploadOptions)
+	if resp == nil {
+		return
+	}
+	uploadLog.InfoContext(r.Context(), "File uploaded",
+		"file_index", s.FileIndex, "file", s.FileName, "bytes", offset, "session", s.ID,
+		"rows", resp.Rows, "columns", resp.Columns)
+	auditUpload(r, s.FileIndex, s.FileName, resp)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(resp)
+}
+
+// AbortUploadSession handles DELETE /api/v1/uploads/sessions/{id}
+func (h *Handler) AbortUploadSession(w http.ResponseWriter, r *http.Request) {
+	s, unlock := openUploadSession(w, r)
+	if s == nil {
+		return
+	}
+	defer unlock()
+	s.remove()
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+		"id":      s.ID,
+	})
+}
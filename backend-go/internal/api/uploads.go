@@ -0,0 +1,210 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/config"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Upload Management
+// ============================================================================
+
+// StoredUpload is a file in the upload directory
+type StoredUpload struct {
+	Name       string     `json:"name"`
+	Size       int64      `json:"size"`
+	ModifiedAt time.Time  `json:"modified_at"`
+	AgeSeconds float64    `json:"age_seconds"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"` // Under a retention policy
+	FileIndex  int        `json:"file_index,omitempty"` // The file slot it is loaded in
+}
+
+// uploadsMutex serializes deletions with the retention sweep
+var uploadsMutex sync.Mutex
+
+// storedUploads lists the files in the upload directory, newest first
+func storedUploads(now time.Time) ([]StoredUpload, error) {
+	entries, err := os.ReadDir(uploadDir())
+	if errors.Is(err, fs.ErrNotExist) {
+		return []StoredUpload{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := loadedUploadPaths()
+	retention := config.Get().Upload.RetentionPeriod()
+	uploads := []StoredUpload{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Removed since listing
+		}
+		u := StoredUpload{
+			Name:       e.Name(),
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime().UTC(),
+			AgeSeconds: now.Sub(info.ModTime()).Seconds(),
+			FileIndex:  loaded[filepath.Join(uploadDir(), e.Name())],
+		}
+		if retention > 0 {
+			expires := u.ModifiedAt.Add(retention)
+			u.ExpiresAt = &expires
+		}
+		uploads = append(uploads, u)
+	}
+	sort.Slice(uploads, func(i, j int) bool { return uploads[i].ModifiedAt.After(uploads[j].ModifiedAt) })
+	return uploads, nil
+}
+
+// loadedUploadPaths maps the paths of the loaded files to their file index
+func loadedUploadPaths() map[string]int {
+	paths := map[string]int{}
+	for fileIndex := 1; fileIndex <= 2; fileIndex++ {
+		if df := state.State.GetDataFrame(fileIndex); df != nil {
+			paths[filepath.Clean(df.FilePath)] = fileIndex
+		}
+	}
+	return paths
+}
+
+// releaseUpload unloads the files read from path, with their context,
+// cached profiles and kept parse, returning the file indexes released
+func releaseUpload(path string) []int {
+	released := []int{}
+	for fileIndex := 1; fileIndex <= 2; fileIndex++ {
+		df := state.State.GetDataFrame(fileIndex)
+		if df == nil || filepath.Clean(df.FilePath) != filepath.Clean(path) {
+			continue
+		}
+		service.GetColumnProfileCache().InvalidateDataFrame(df)
+		state.State.SetDataFrame(fileIndex, nil)
+		state.State.ClearContext(&fileIndex)
+		released = append(released, fileIndex)
+	}
+	forgetParsedUploads(path)
+	return released
+}
+
+// ListUploads handles GET /api/v1/uploads
+// Lists the stored uploads with their sizes, ages and, for the loaded ones,
+// the file slot they are in.
+func (h *Handler) ListUploads(w http.ResponseWriter, r *http.Request) {
+	uploads, err := storedUploads(time.Now())
+	if err != nil {
+		apierr.Write(w, apierr.Internal("Failed to list uploads"))
+		return
+	}
+	var total int64
+	for _, u := range uploads {
+		total += u.Size
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"uploads":     uploads,
+		"count":       len(uploads),
+		"total_bytes": total,
+		"retention":   config.Get().Upload.Retention,
+	})
+}
+
+// DeleteUpload handles DELETE /api/v1/uploads/{name}
+// Deletes a stored upload; a file loaded from it is unloaded along with its
+// context.
+func (h *Handler) DeleteUpload(w http.ResponseWriter, r *http.Request) {
+	name := chi.URLParam(r, "name")
+	if name == "" || name != filepath.Base(name) || strings.HasPrefix(name, ".") {
+		apierr.Write(w, apierr.BadRequest("Invalid upload name"))
+		return
+	}
+	path := filepath.Join(uploadDir(), name)
+
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+	if err := os.Remove(path); err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			apierr.Write(w, apierr.NotFound("Upload not found"))
+			return
+		}
+		apierr.Write(w, apierr.Internal("Failed to delete upload"))
+		return
+	}
+	released := releaseUpload(path)
+	uploadLog.InfoContext(r.Context(), "Upload deleted", "file", name, "unloaded", released)
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  true,
+		"name":     name,
+		"unloaded": released,
+	})
+}
+
+// StartUploadSweeper deletes uploads older than the retention period, with
+// the state loaded from them, every sweep interval. Without a retention
+// period uploads are kept.
+func (h *Handler) StartUploadSweeper() {
+	cfg := config.Get().Upload
+	retention := cfg.RetentionPeriod()
+	if retention <= 0 {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(cfg.SweepPeriod())
+		defer ticker.Stop()
+		sweepUploads(time.Now(), retention)
+		for now := range ticker.C {
+			sweepUploads(now, retention)
+		}
+	}()
+	uploadLog.Info("Upload retention enabled", "retention", cfg.Retention, "sweep_interval", cfg.SweepInterval)
+}
+
+// sweepUploads deletes the uploads last modified before now - retention
+func sweepUploads(now time.Time, retention time.Duration) {
+	uploadsMutex.Lock()
+	defer uploadsMutex.Unlock()
+
+	uploads, err := storedUploads(now)
+	if err != nil {
+		uploadLog.Error("Error listing uploads to expire", "error", err)
+		return
+	}
+	removed, freed := 0, int64(0)
+	for _, u := range uploads {
+		if now.Sub(u.ModifiedAt) < retention {
+			continue
+		}
+		path := filepath.Join(uploadDir(), u.Name)
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			uploadLog.Error("Error deleting expired upload", "file", u.Name, "error", err)
+			continue
+		}
+		if released := releaseUpload(path); len(released) > 0 {
+			uploadLog.Info("Unloaded expired upload", "file", u.Name, "file_index", released)
+		}
+		removed++
+		freed += u.Size
+	}
+	if removed > 0 {
+		uploadLog.Info("Expired uploads deleted", "files", removed, "bytes", freed)
+	}
+}
@@ -0,0 +1,112 @@
+package api
+
+import (
+	"backend-go/internal/api/apierr"
+	"backend-go/internal/service"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// ============================================================================
+// Webhooks
+// ============================================================================
+
+// webhookRequest is the body of POST /api/v1/webhooks
+type webhookRequest struct {
+	URL    string   `json:"url"`
+	Events []string `json:"events,omitempty"` // Empty = every event
+	Format string   `json:"format,omitempty"` // "json" (default), "slack" or "teams"
+	Secret string   `json:"secret,omitempty"` // Signs payloads with HMAC-SHA256
+}
+
+// notifySimilarityRun tells the webhooks a column similarity run finished,
+// with its strongest matches
+func notifySimilarityRun(df1, df2 *state.DataFrame, top []map[string]interface{}, total int, useAI bool) {
+	pairs := make([]string, len(top))
+	for i, m := range top {
+		pairs[i] = fmt.Sprintf("%s ↔ %s (%.0f%%)", m["file1_column"], m["file2_column"], m["confidence"])
+	}
+	summary := fmt.Sprintf("%d column matches between %s and %s", total, df1.FileName, df2.FileName)
+	if len(pairs) > 0 {
+		summary += ": " + strings.Join(pairs, ", ")
+	}
+	service.GetWebhookStore().Notify(service.WebhookEvent{
+		Event:   service.EventSimilarityCompleted,
+		Summary: summary,
+		Data: map[string]interface{}{
+			"file1":               df1.FileName,
+			"file2":               df2.FileName,
+			"total_relationships": total,
+			"top_matches":         top,
+			"use_ai":              useAI,
+		},
+	})
+}
+
+// ListWebhooks handles GET /api/v1/webhooks
+func (h *Handler) ListWebhooks(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"webhooks": service.GetWebhookStore().List(),
+	})
+}
+
+// CreateWebhook handles POST /api/v1/webhooks
+// Registers a URL to POST to when a similarity run, linkage index build or
+// saved query run completes.
+func (h *Handler) CreateWebhook(w http.ResponseWriter, r *http.Request) {
+	var req webhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		apierr.Write(w, apierr.InvalidJSON(err))
+		return
+	}
+
+	wh, err := service.GetWebhookStore().Create(service.Webhook{
+		URL:    strings.TrimSpace(req.URL),
+		Events: req.Events,
+		Format: req.Format,
+		Secret: req.Secret,
+	})
+	if err != nil {
+		apierr.Write(w, apierr.BadRequest(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(wh)
+}
+
+// DeleteWebhook handles DELETE /api/v1/webhooks/{id}
+func (h *Handler) DeleteWebhook(w http.ResponseWriter, r *http.Request) {
+	if err := service.GetWebhookStore().Delete(chi.URLParam(r, "id")); err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success": true,
+	})
+}
+
+// TestWebhook handles POST /api/v1/webhooks/{id}/test
+// Sends a test event and reports the delivery, retries included.
+func (h *Handler) TestWebhook(w http.ResponseWriter, r *http.Request) {
+	delivery, err := service.GetWebhookStore().Test(chi.URLParam(r, "id"))
+	if err != nil {
+		apierr.Write(w, apierr.NotFound(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"success":  delivery.Succeeded,
+		"delivery": delivery,
+	})
+}
@@ -0,0 +1,267 @@
+// Package audit keeps an append-only log of who changed or exported what:
+// one JSON entry per line in audit.jsonl in the data directory, rotated by
+// size into timestamped files that are never rewritten.
+package audit
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = logging.Component("audit")
+
+const (
+	logFile       = "audit.jsonl"
+	rotatedPrefix = "audit-"
+	rotatedLayout = "20060102T150405.000000000Z"
+)
+
+// Entry is a recorded action
+type Entry struct {
+	Time      time.Time              `json:"time"`
+	Action    string                 `json:"action"` // "METHOD /api/v1 pattern"
+	Path      string                 `json:"path"`
+	Status    int                    `json:"status"`
+	User      string                 `json:"user,omitempty"`
+	Role      string                 `json:"role,omitempty"`
+	RequestID string                 `json:"request_id,omitempty"`
+	Remote    string                 `json:"remote,omitempty"`
+	Details   map[string]interface{} `json:"details,omitempty"` // Added by the handler
+}
+
+// mutex serializes appends with rotation
+var mutex sync.Mutex
+
+// record collects what a request adds to its entry while being served
+type record struct {
+	mu      sync.Mutex
+	user    string
+	role    string
+	details map[string]interface{}
+}
+
+type recordKey struct{}
+
+// Begin returns a context collecting the principal and details of the
+// request's entry, to pass to Finish once served
+func Begin(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recordKey{}, &record{})
+}
+
+// SetPrincipal names the user and role of an audited request
+func SetPrincipal(ctx context.Context, user, role string) {
+	if rec, ok := ctx.Value(recordKey{}).(*record); ok {
+		rec.mu.Lock()
+		rec.user, rec.role = user, role
+		rec.mu.Unlock()
+	}
+}
+
+// Annotate adds a detail to the entry of an audited request, such as the
+// file uploaded or the columns of an approved mapping; other requests
+// ignore it
+func Annotate(ctx context.Context, key string, value interface{}) {
+	if rec, ok := ctx.Value(recordKey{}).(*record); ok {
+		rec.mu.Lock()
+		if rec.details == nil {
+			rec.details = map[string]interface{}{}
+		}
+		rec.details[key] = value
+		rec.mu.Unlock()
+	}
+}
+
+// Finish fills in the entry from what was collected in a context returned by
+// Begin and records it
+func Finish(ctx context.Context, e Entry) {
+	if rec, ok := ctx.Value(recordKey{}).(*record); ok {
+		rec.mu.Lock()
+		e.User, e.Role, e.Details = rec.user, rec.role, rec.details
+		rec.mu.Unlock()
+	}
+	e.RequestID = logging.Field(ctx, "request_id")
+	if err := Record(e); err != nil {
+		logger.ErrorContext(ctx, "Error writing audit entry", "action", e.Action, "error", err)
+	}
+}
+
+// Record appends an entry, rotating the log first once it reaches
+// audit.max_size_mb
+func Record(e Entry) error {
+	if e.Time.IsZero() {
+		e.Time = time.Now()
+	}
+	e.Time = e.Time.UTC()
+	line, err := json.Marshal(e)
+	if err != nil {
+		return err
+	}
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	path := config.DataPath(logFile)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	if err := rotate(path, e.Time); err != nil {
+		logger.Error("Error rotating the audit log", "error", err)
+	}
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = f.Write(append(line, '\n'))
+	return err
+}
+
+// rotate renames the log to audit-<time>.jsonl once it is over the size
+// limit, then removes the oldest rotated logs beyond audit.max_files (must
+// hold the lock)
+func rotate(path string, now time.Time) error {
+	cfg := config.Get().Audit
+	info, err := os.Stat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if info.Size() < int64(cfg.MaxSizeMB)<<20 {
+		return nil
+	}
+	rotated := filepath.Join(filepath.Dir(path), rotatedPrefix+now.Format(rotatedLayout)+".jsonl")
+	if err := os.Rename(path, rotated); err != nil {
+		return err
+	}
+	logger.Info("Rotated the audit log", "file", filepath.Base(rotated), "bytes", info.Size())
+
+	if cfg.MaxFiles == 0 {
+		return nil
+	}
+	files, err := rotatedFiles(filepath.Dir(path))
+	if err != nil {
+		return err
+	}
+	for len(files) > cfg.MaxFiles {
+		oldest := files[len(files)-1]
+		if err := os.Remove(oldest); err != nil {
+			return err
+		}
+		files = files[:len(files)-1]
+	}
+	return nil
+}
+
+// rotatedFiles lists the rotated logs in dir, newest first
+func rotatedFiles(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, e := range entries {
+		if e.Type().IsRegular() && strings.HasPrefix(e.Name(), rotatedPrefix) && strings.HasSuffix(e.Name(), ".jsonl") {
+			files = append(files, filepath.Join(dir, e.Name()))
+		}
+	}
+	// The timestamps sort like the names
+	sort.Sort(sort.Reverse(sort.StringSlice(files)))
+	return files, nil
+}
+
+// Filter selects entries; zero fields match all
+type Filter struct {
+	User   string
+	Action string // Substring of the action, ignoring case
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+}
+
+func (f Filter) matches(e Entry) bool {
+	switch {
+	case f.User != "" && e.User != f.User:
+		return false
+	case f.Action != "" && !strings.Contains(strings.ToLower(e.Action), strings.ToLower(f.Action)):
+		return false
+	case !f.Since.IsZero() && e.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !e.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// Query returns the entries matching the filter, newest first, reading the
+// rotated logs back until the limit is reached
+func Query(f Filter) ([]Entry, error) {
+	mutex.Lock()
+	path := config.DataPath(logFile)
+	rotated, err := rotatedFiles(filepath.Dir(path))
+	mutex.Unlock()
+	if errors.Is(err, fs.ErrNotExist) {
+		return []Entry{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	entries := []Entry{}
+	for _, file := range append([]string{path}, rotated...) {
+		fileEntries, err := readFile(file)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue // Rotated or removed since listing
+		}
+		if err != nil {
+			return nil, err
+		}
+		for i := len(fileEntries) - 1; i >= 0; i-- {
+			e := fileEntries[i]
+			if !f.Since.IsZero() && e.Time.Before(f.Since) {
+				return entries, nil // Older files hold older entries
+			}
+			if !f.matches(e) {
+				continue
+			}
+			entries = append(entries, e)
+			if f.Limit > 0 && len(entries) >= f.Limit {
+				return entries, nil
+			}
+		}
+	}
+	return entries, nil
+}
+
+// readFile reads a log's entries in the order written, skipping lines that
+// don't parse, such as one cut short by a crash
+func readFile(path string) ([]Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	entries := []Entry{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 64*1024), 4<<20)
+	for scanner.Scan() {
+		var e Entry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	return entries, scanner.Err()
+}
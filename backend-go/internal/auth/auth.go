@@ -0,0 +1,209 @@
+// Package auth authenticates API clients by key and gates endpoints by role.
+//
+// Keys come from the API_KEYS environment variable ("key:role" entries,
+// comma-separated) and from a users file (API_USERS_FILE, default
+// api_users.json in the data directory). With no keys configured authentication is off
+// and every request acts as an admin, as before keys existed.
+package auth
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+)
+
+var logger = logging.Component("auth")
+
+const defaultUsersFile = "api_users.json"
+
+// Role is a level of access; each role includes the ones below it
+type Role string
+
+// Roles, lowest first
+const (
+	RoleViewer  Role = "viewer"  // Read data, results and configuration
+	RoleAnalyst Role = "analyst" // Upload files, run analyses, give feedback and change mappings
+	RoleAdmin   Role = "admin"   // Databases, configuration and deleting shared state
+)
+
+var roleLevels = map[Role]int{RoleViewer: 1, RoleAnalyst: 2, RoleAdmin: 3}
+
+// Includes reports whether the role grants the access of another
+func (r Role) Includes(other Role) bool {
+	return roleLevels[r] >= roleLevels[other]
+}
+
+// ParseRole validates a role name
+func ParseRole(name string) (Role, error) {
+	role := Role(strings.ToLower(strings.TrimSpace(name)))
+	if _, ok := roleLevels[role]; !ok {
+		return "", fmt.Errorf("unknown role %q (use viewer, analyst or admin)", name)
+	}
+	return role, nil
+}
+
+// User is an API client entry of the users file. Key holds the key itself;
+// KeySHA256 its hex SHA-256, to keep keys out of the file.
+type User struct {
+	Name      string `json:"name"`
+	Role      string `json:"role"`
+	Key       string `json:"key,omitempty"`
+	KeySHA256 string `json:"key_sha256,omitempty"`
+}
+
+// Principal is the authenticated client of a request
+type Principal struct {
+	Name string `json:"name"`
+	Role Role   `json:"role"`
+}
+
+type principalKey struct{}
+
+var (
+	users    map[string]Principal // Hex SHA-256 of the key -> client
+	loadOnce sync.Once
+	mutex    sync.RWMutex
+)
+
+// Enabled reports whether any API keys are configured
+func Enabled() bool {
+	load()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return len(users) > 0
+}
+
+// Reload re-reads the keys from the environment and the users file
+func Reload() error {
+	loadOnce.Do(func() {})
+	loaded, err := readUsers()
+	if err != nil {
+		return err
+	}
+	mutex.Lock()
+	users = loaded
+	mutex.Unlock()
+	if len(loaded) == 0 {
+		logger.Warn("No API keys configured; authentication is disabled")
+	} else {
+		logger.Info("Loaded API keys", "count", len(loaded))
+	}
+	return nil
+}
+
+func load() {
+	loadOnce.Do(func() {
+		loaded, err := readUsers()
+		if err != nil {
+			// Refuse every request rather than fall back to an open server
+			logger.Error("Error loading API keys, rejecting all requests", "error", err)
+			loaded = map[string]Principal{"": {}}
+		}
+		mutex.Lock()
+		users = loaded
+		mutex.Unlock()
+	})
+}
+
+// readUsers collects the keys of API_KEYS and the users file
+func readUsers() (map[string]Principal, error) {
+	loaded := make(map[string]Principal)
+	add := func(u User, source string) error {
+		role, err := ParseRole(u.Role)
+		if err != nil {
+			return fmt.Errorf("%s: %v", source, err)
+		}
+		hash := strings.ToLower(strings.TrimSpace(u.KeySHA256))
+		if u.Key != "" {
+			hash = hashKey(u.Key)
+		}
+		if len(hash) != sha256.Size*2 {
+			return fmt.Errorf("%s: key or a 64-character key_sha256 is required", source)
+		}
+		loaded[hash] = Principal{Name: u.Name, Role: role}
+		return nil
+	}
+
+	for i, entry := range strings.Split(os.Getenv("API_KEYS"), ",") {
+		if entry = strings.TrimSpace(entry); entry == "" {
+			continue
+		}
+		key, role, ok := strings.Cut(entry, ":")
+		if !ok {
+			return nil, fmt.Errorf("API_KEYS entry %d: use key:role", i+1)
+		}
+		if err := add(User{Name: fmt.Sprintf("env-%d", i+1), Role: role, Key: key}, fmt.Sprintf("API_KEYS entry %d", i+1)); err != nil {
+			return nil, err
+		}
+	}
+
+	path := os.Getenv("API_USERS_FILE")
+	if path == "" {
+		path = config.DataPath(defaultUsersFile)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		if os.IsNotExist(err) && os.Getenv("API_USERS_FILE") == "" {
+			return loaded, nil
+		}
+		return nil, err
+	}
+	var file struct {
+		Users []User `json:"users"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %v", path, err)
+	}
+	for i, u := range file.Users {
+		if err := add(u, fmt.Sprintf("%s user %d", path, i+1)); err != nil {
+			return nil, err
+		}
+	}
+	return loaded, nil
+}
+
+func hashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
+
+// Authenticate resolves the key of a request, sent as "Authorization:
+// Bearer <key>" or "X-API-Key: <key>". ok is false for a missing or unknown
+// key; with authentication disabled every request is an admin.
+func Authenticate(r *http.Request) (Principal, bool) {
+	load()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	if len(users) == 0 {
+		return Principal{Name: "anonymous", Role: RoleAdmin}, true
+	}
+
+	key := r.Header.Get("X-API-Key")
+	if auth := r.Header.Get("Authorization"); key == "" && len(auth) > 7 && strings.EqualFold(auth[:7], "Bearer ") {
+		key = strings.TrimSpace(auth[7:])
+	}
+	if key == "" {
+		return Principal{}, false
+	}
+	p, ok := users[hashKey(key)]
+	return p, ok && p.Role != ""
+}
+
+// FromContext returns the client a request was authenticated as
+func FromContext(ctx context.Context) (Principal, bool) {
+	p, ok := ctx.Value(principalKey{}).(Principal)
+	return p, ok
+}
+
+// WithPrincipal attaches the authenticated client to a context
+func WithPrincipal(ctx context.Context, p Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, p)
+}
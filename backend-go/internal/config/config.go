@@ -0,0 +1,574 @@
+// Package config holds the server settings.
+//
+// Settings come from, in increasing precedence: the defaults, a config file
+// (TOML, or JSON when the name ends in .json), environment variables and
+// command-line flags. The file is named by -config or CONFIG_FILE and
+// defaults to ./config.toml when that exists. Load runs once at startup;
+// Get returns the effective settings afterwards.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"net/netip"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultFile is read when no config file is named and it exists
+const DefaultFile = "./config.toml"
+
+// Config is the effective server configuration
+type Config struct {
+	Server ServerConfig `json:"server"`
+	CORS   CORSConfig   `json:"cors"`
+	TLS    TLSConfig    `json:"tls"`
+	Upload UploadConfig `json:"upload"`
+	Data   DataConfig   `json:"data"`
+	Ollama OllamaConfig `json:"ollama"`
+	Log    LogConfig    `json:"log"`
+	Audit  AuditConfig  `json:"audit"`
+	Batch  BatchConfig  `json:"batch"`
+	Watch  WatchConfig  `json:"watch"`
+}
+
+type ServerConfig struct {
+	Port        int    `json:"port"`
+	Environment string `json:"environment"` // Selects the [cors.<environment>] origins
+	// Addresses or CIDRs of the reverse proxies whose X-Forwarded-For and
+	// X-Real-IP headers are believed
+	TrustedProxies []string `json:"trusted_proxies"`
+}
+
+// Proxies parses TrustedProxies; bare addresses become single-address
+// prefixes
+func (s ServerConfig) Proxies() ([]netip.Prefix, error) {
+	prefixes := []netip.Prefix{}
+	for _, p := range s.TrustedProxies {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if addr, err := netip.ParseAddr(p); err == nil {
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("server.trusted_proxies: %q is not an address or CIDR", p)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// TLSConfig enables HTTPS, with HTTP/2, when a certificate and key are set
+type TLSConfig struct {
+	CertFile   string `json:"cert_file"`   // PEM certificate chain
+	KeyFile    string `json:"key_file"`    // PEM private key
+	MinVersion string `json:"min_version"` // "1.2" or "1.3"
+}
+
+// Enabled reports whether the server serves HTTPS
+func (t TLSConfig) Enabled() bool {
+	return t.CertFile != ""
+}
+
+type UploadConfig struct {
+	Dir           string `json:"dir"`
+	MaxSizeMB     int    `json:"max_size_mb"`
+	Retention     string `json:"retention"`      // Age after which uploads are deleted, e.g. "7d"; "" keeps them
+	SweepInterval string `json:"sweep_interval"` // How often expired uploads are looked for
+	DedupCache    int    `json:"dedup_cache"`    // Parsed uploads kept to reuse for identical content; 0 disables
+
+	// Resumable upload sessions open at once, in total and per client, and
+	// the bytes their partial files may hold together
+	MaxSessions       int `json:"max_sessions"`
+	MaxClientSessions int `json:"max_client_sessions"`
+	SessionQuotaMB    int `json:"session_quota_mb"`
+}
+
+// RetentionPeriod is the parsed Retention, 0 when uploads are kept forever
+func (u UploadConfig) RetentionPeriod() time.Duration {
+	d, _ := ParseDuration(u.Retention)
+	return d
+}
+
+// SweepPeriod is the parsed SweepInterval
+func (u UploadConfig) SweepPeriod() time.Duration {
+	d, _ := ParseDuration(u.SweepInterval)
+	return d
+}
+
+// MaxSize is the largest accepted upload in bytes
+func (u UploadConfig) MaxSize() int64 {
+	return int64(u.MaxSizeMB) << 20
+}
+
+// SessionQuota is the bytes all upload sessions may hold together
+func (u UploadConfig) SessionQuota() int64 {
+	return int64(u.SessionQuotaMB) << 20
+}
+
+type DataConfig struct {
+	Dir string `json:"dir"` // Where the services persist their state
+}
+
+type OllamaConfig struct {
+	BaseURL       string `json:"base_url"`
+	Model         string `json:"model"`
+	EmbedModel    string `json:"embed_model"`    // Embeds column descriptions for the ensemble matcher
+	ContextWindow int    `json:"context_window"` // Tokens of the model's context window prompts are fitted to
+	OutputTokens  int    `json:"output_tokens"`  // Tokens of the window kept for the response
+	CallLog       string `json:"call_log"`       // "full", "redacted" (no prompts or responses) or "off"
+	// Tasks holds the lists of [ollama.tasks]: the models an LLM task is
+	// sent to, tried in order before model (embed_model for embedding)
+	Tasks map[string][]string `json:"tasks,omitempty"`
+}
+
+// setTaskModels stores an ollama.tasks.<task> file value, reporting whether
+// key is one
+func (o *OllamaConfig) setTaskModels(key string, v interface{}) (bool, error) {
+	task, ok := strings.CutPrefix(key, "ollama.tasks.")
+	if !ok || task == "" {
+		return false, nil
+	}
+	var models []string
+	if err := setValue(&models, v); err != nil {
+		return true, err
+	}
+	if len(models) == 0 {
+		return true, fmt.Errorf("no models listed")
+	}
+	if o.Tasks == nil {
+		o.Tasks = map[string][]string{}
+	}
+	o.Tasks[task] = models
+	return true, nil
+}
+
+type LogConfig struct {
+	Level  string `json:"level"`
+	Format string `json:"format"`
+}
+
+// AuditConfig rotates the audit log, audit.jsonl in the data directory
+type AuditConfig struct {
+	MaxSizeMB int `json:"max_size_mb"` // Size at which the log is rotated
+	MaxFiles  int `json:"max_files"`   // Rotated logs kept; 0 keeps all
+}
+
+// BatchConfig sets where batch comparisons may read files from
+type BatchConfig struct {
+	Root string `json:"root"` // Directory manifest paths are relative to; empty allows uploads only
+}
+
+// WatchConfig sets up the hot folder, whose new files are checked against a
+// reference file
+type WatchConfig struct {
+	Dir            string `json:"dir"`             // Local directory or s3://bucket/prefix; empty disables
+	Reference      string `json:"reference"`       // File whose columns new files must match
+	Interval       string `json:"interval"`        // How often the folder is polled
+	Profile        string `json:"profile"`         // Matching profile; its match_threshold decides matched columns
+	MinCoverage    int    `json:"min_coverage"`    // Percent of the reference columns a file must match to pass
+	CredentialsRef string `json:"credentials_ref"` // Credentials of an s3 folder (see remote.Credentials)
+}
+
+// Enabled reports whether a hot folder is watched
+func (w WatchConfig) Enabled() bool {
+	return w.Dir != ""
+}
+
+// PollPeriod is the parsed Interval
+func (w WatchConfig) PollPeriod() time.Duration {
+	d, _ := ParseDuration(w.Interval)
+	return d
+}
+
+// Default returns the built-in settings
+func Default() Config {
+	return Config{
+		Server: ServerConfig{Port: 8001, Environment: "development"},
+		CORS: CORSConfig{
+			Origins: []string{"http://localhost:3000", "http://localhost:3001", "http://localhost:3002", "http://127.0.0.1:3000"},
+		},
+		TLS:    TLSConfig{MinVersion: "1.2"},
+		Upload: UploadConfig{Dir: "./uploads", MaxSizeMB: 100, SweepInterval: "1h", DedupCache: 4, MaxSessions: 20, MaxClientSessions: 4, SessionQuotaMB: 20480},
+		Data:   DataConfig{Dir: "./data"},
+		Ollama: OllamaConfig{BaseURL: "http://localhost:11434", Model: "qwen3-vl:2b", EmbedModel: "nomic-embed-text", ContextWindow: 4096, OutputTokens: 1024, CallLog: "full"},
+		Log:    LogConfig{Level: "info", Format: "text"},
+		Audit:  AuditConfig{MaxSizeMB: 10},
+		Watch:  WatchConfig{Interval: "1m", Profile: "balanced", MinCoverage: 100},
+	}
+}
+
+// Sources of a setting
+const (
+	SourceDefault = "default"
+	SourceFile    = "file"
+	SourceEnv     = "env"
+	SourceFlag    = "flag"
+)
+
+// setting binds a key of the file to its environment variable and flag
+type setting struct {
+	key   string // section.name in the file
+	env   string
+	flag  string
+	usage string
+	ptr   func(*Config) interface{} // *string, *int or *[]string
+}
+
+var settings = []setting{
+	{"server.port", "PORT", "port", "port to listen on", func(c *Config) interface{} { return &c.Server.Port }},
+	{"server.environment", "APP_ENV", "env", "deployment environment, selecting its CORS origins", func(c *Config) interface{} { return &c.Server.Environment }},
+	{"server.trusted_proxies", "TRUSTED_PROXIES", "trusted-proxies", "comma-separated addresses or CIDRs of reverse proxies whose forwarded client addresses are believed", func(c *Config) interface{} { return &c.Server.TrustedProxies }},
+	{"cors.origins", "CORS_ORIGINS", "cors-origins", "comma-separated origins allowed by CORS", func(c *Config) interface{} { return &c.CORS.Origins }},
+	{"tls.cert_file", "TLS_CERT_FILE", "tls-cert", "TLS certificate file (PEM); enables HTTPS", func(c *Config) interface{} { return &c.TLS.CertFile }},
+	{"tls.key_file", "TLS_KEY_FILE", "tls-key", "TLS private key file (PEM)", func(c *Config) interface{} { return &c.TLS.KeyFile }},
+	{"tls.min_version", "TLS_MIN_VERSION", "tls-min-version", "minimum TLS version (1.2 or 1.3)", func(c *Config) interface{} { return &c.TLS.MinVersion }},
+	{"upload.dir", "UPLOAD_DIR", "upload-dir", "directory uploads are stored in", func(c *Config) interface{} { return &c.Upload.Dir }},
+	{"upload.max_size_mb", "UPLOAD_MAX_SIZE_MB", "upload-max-size-mb", "largest accepted upload in MB", func(c *Config) interface{} { return &c.Upload.MaxSizeMB }},
+	{"upload.retention", "UPLOAD_RETENTION", "upload-retention", "age after which uploads are deleted, e.g. 7d or 12h (empty keeps them)", func(c *Config) interface{} { return &c.Upload.Retention }},
+	{"upload.sweep_interval", "UPLOAD_SWEEP_INTERVAL", "upload-sweep-interval", "how often expired uploads are looked for", func(c *Config) interface{} { return &c.Upload.SweepInterval }},
+	{"upload.dedup_cache", "UPLOAD_DEDUP_CACHE", "upload-dedup-cache", "parsed uploads kept to reuse when the same content is uploaded again (0 disables)", func(c *Config) interface{} { return &c.Upload.DedupCache }},
+	{"upload.max_sessions", "UPLOAD_MAX_SESSIONS", "upload-max-sessions", "resumable upload sessions open at once", func(c *Config) interface{} { return &c.Upload.MaxSessions }},
+	{"upload.max_client_sessions", "UPLOAD_MAX_CLIENT_SESSIONS", "upload-max-client-sessions", "resumable upload sessions one client may have open", func(c *Config) interface{} { return &c.Upload.MaxClientSessions }},
+	{"upload.session_quota_mb", "UPLOAD_SESSION_QUOTA_MB", "upload-session-quota-mb", "MB the partial files of all upload sessions may hold together", func(c *Config) interface{} { return &c.Upload.SessionQuotaMB }},
+	{"data.dir", "DATA_DIR", "data-dir", "directory the services persist their state in", func(c *Config) interface{} { return &c.Data.Dir }},
+	{"ollama.base_url", "OLLAMA_BASE_URL", "ollama-url", "default Ollama base URL", func(c *Config) interface{} { return &c.Ollama.BaseURL }},
+	{"ollama.model", "OLLAMA_MODEL", "ollama-model", "default Ollama model", func(c *Config) interface{} { return &c.Ollama.Model }},
+	{"ollama.embed_model", "OLLAMA_EMBED_MODEL", "ollama-embed-model", "Ollama model column descriptions are embedded with", func(c *Config) interface{} { return &c.Ollama.EmbedModel }},
+	{"ollama.context_window", "OLLAMA_CONTEXT_WINDOW", "ollama-context-window", "tokens of the model's context window prompts are fitted to", func(c *Config) interface{} { return &c.Ollama.ContextWindow }},
+	{"ollama.output_tokens", "OLLAMA_OUTPUT_TOKENS", "ollama-output-tokens", "tokens of the context window kept for the response", func(c *Config) interface{} { return &c.Ollama.OutputTokens }},
+	{"ollama.call_log", "OLLAMA_CALL_LOG", "ollama-call-log", "LLM call log: full, redacted (no prompts or responses) or off", func(c *Config) interface{} { return &c.Ollama.CallLog }},
+	{"log.level", "LOG_LEVEL", "log-level", "log level (debug, info, warn or error)", func(c *Config) interface{} { return &c.Log.Level }},
+	{"log.format", "LOG_FORMAT", "log-format", "log format (text or json)", func(c *Config) interface{} { return &c.Log.Format }},
+	{"audit.max_size_mb", "AUDIT_MAX_SIZE_MB", "audit-max-size-mb", "size in MB at which the audit log is rotated", func(c *Config) interface{} { return &c.Audit.MaxSizeMB }},
+	{"audit.max_files", "AUDIT_MAX_FILES", "audit-max-files", "rotated audit logs kept (0 keeps all)", func(c *Config) interface{} { return &c.Audit.MaxFiles }},
+	{"batch.root", "BATCH_ROOT", "batch-root", "directory batch comparisons may read files from", func(c *Config) interface{} { return &c.Batch.Root }},
+	{"watch.dir", "WATCH_DIR", "watch-dir", "hot folder (directory or s3://bucket/prefix) whose new files are ingested", func(c *Config) interface{} { return &c.Watch.Dir }},
+	{"watch.reference", "WATCH_REFERENCE", "watch-reference", "reference file new files in the hot folder are checked against", func(c *Config) interface{} { return &c.Watch.Reference }},
+	{"watch.interval", "WATCH_INTERVAL", "watch-interval", "how often the hot folder is polled", func(c *Config) interface{} { return &c.Watch.Interval }},
+	{"watch.profile", "WATCH_PROFILE", "watch-profile", "matching profile of hot folder checks", func(c *Config) interface{} { return &c.Watch.Profile }},
+	{"watch.min_coverage", "WATCH_MIN_COVERAGE", "watch-min-coverage", "percent of the reference columns a hot folder file must match to pass", func(c *Config) interface{} { return &c.Watch.MinCoverage }},
+	{"watch.credentials_ref", "WATCH_CREDENTIALS_REF", "watch-credentials-ref", "credentials reference of an s3 hot folder", func(c *Config) interface{} { return &c.Watch.CredentialsRef }},
+}
+
+var (
+	mutex   sync.RWMutex
+	current = Default()
+	sources = map[string]string{}
+	file    string // Config file read, if any
+)
+
+// Load reads the configuration from the file, the environment and the
+// command-line arguments (without the program name) and makes it current
+func Load(args []string) (Config, error) {
+	cfg := Default()
+	src := map[string]string{}
+	for _, s := range settings {
+		src[s.key] = SourceDefault
+	}
+
+	fset := flag.NewFlagSet("server", flag.ContinueOnError)
+	configFlag := fset.String("config", "", "config file (TOML, or JSON if named *.json)")
+	flagValues := map[string]*string{}
+	for _, s := range settings {
+		flagValues[s.flag] = fset.String(s.flag, "", s.usage+" (env "+s.env+")")
+	}
+	if err := fset.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	path, required := *configFlag, true
+	if path == "" {
+		path = os.Getenv("CONFIG_FILE")
+	}
+	if path == "" {
+		path, required = DefaultFile, false
+	}
+	values, err := readFile(path)
+	switch {
+	case err == nil:
+	case !required && errors.Is(err, fs.ErrNotExist):
+		path = ""
+	default:
+		return cfg, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	for _, s := range settings {
+		if v, ok := values[s.key]; ok {
+			if err := setValue(s.ptr(&cfg), v); err != nil {
+				return cfg, fmt.Errorf("config file %s: %s: %w", path, s.key, err)
+			}
+			src[s.key] = SourceFile
+			delete(values, s.key)
+		}
+	}
+	for key, v := range values {
+		ok, err := cfg.CORS.setEnvironmentOrigins(key, v)
+		if !ok && err == nil {
+			ok, err = cfg.Ollama.setTaskModels(key, v)
+		}
+		if err != nil {
+			return cfg, fmt.Errorf("config file %s: %s: %w", path, key, err)
+		}
+		if !ok {
+			return cfg, fmt.Errorf("config file %s: unknown setting %s", path, key)
+		}
+	}
+
+	for _, s := range settings {
+		if v, ok := os.LookupEnv(s.env); ok && v != "" {
+			if err := setString(s.ptr(&cfg), v); err != nil {
+				return cfg, fmt.Errorf("%s: %w", s.env, err)
+			}
+			src[s.key] = SourceEnv
+		}
+	}
+
+	var flagErr error
+	fset.Visit(func(f *flag.Flag) {
+		for _, s := range settings {
+			if s.flag == f.Name && flagErr == nil {
+				if err := setString(s.ptr(&cfg), *flagValues[s.flag]); err != nil {
+					flagErr = fmt.Errorf("-%s: %w", s.flag, err)
+				}
+				src[s.key] = SourceFlag
+			}
+		}
+	})
+	if flagErr != nil {
+		return cfg, flagErr
+	}
+
+	// The environment's origins replace the file's general list, but not
+	// origins given by CORS_ORIGINS or -cors-origins
+	if origins, ok := cfg.CORS.Environments[cfg.Server.Environment]; ok && src["cors.origins"] != SourceEnv && src["cors.origins"] != SourceFlag {
+		cfg.CORS.Origins = origins
+		src["cors.origins"] = SourceFile
+	}
+
+	cfg.Log.Level = strings.ToLower(cfg.Log.Level)
+	cfg.Log.Format = strings.ToLower(cfg.Log.Format)
+	cfg.Ollama.CallLog = strings.ToLower(cfg.Ollama.CallLog)
+	if err := cfg.validate(); err != nil {
+		return cfg, err
+	}
+
+	mutex.Lock()
+	current, sources, file = cfg, src, path
+	mutex.Unlock()
+	return cfg, nil
+}
+
+func (c *Config) validate() error {
+	if c.Server.Port <= 0 || c.Server.Port > 65535 {
+		return fmt.Errorf("server.port %d out of range", c.Server.Port)
+	}
+	if _, err := c.Server.Proxies(); err != nil {
+		return err
+	}
+	if c.Upload.MaxSizeMB <= 0 {
+		return fmt.Errorf("upload.max_size_mb must be positive")
+	}
+	if c.Audit.MaxSizeMB <= 0 || c.Audit.MaxFiles < 0 {
+		return fmt.Errorf("audit.max_size_mb must be positive and audit.max_files not negative")
+	}
+	if c.Upload.DedupCache < 0 {
+		return fmt.Errorf("upload.dedup_cache must not be negative")
+	}
+	if c.Upload.MaxSessions <= 0 || c.Upload.MaxClientSessions <= 0 || c.Upload.SessionQuotaMB <= 0 {
+		return fmt.Errorf("upload.max_sessions, upload.max_client_sessions and upload.session_quota_mb must be positive")
+	}
+	if c.Ollama.ContextWindow <= 0 || c.Ollama.OutputTokens <= 0 || c.Ollama.OutputTokens > c.Ollama.ContextWindow/2 {
+		return fmt.Errorf("ollama.context_window and ollama.output_tokens must be positive, output_tokens at most half the window")
+	}
+	if c.Ollama.CallLog != "full" && c.Ollama.CallLog != "redacted" && c.Ollama.CallLog != "off" {
+		return fmt.Errorf("ollama.call_log %q must be full, redacted or off", c.Ollama.CallLog)
+	}
+	if c.Upload.Dir == "" || c.Data.Dir == "" {
+		return fmt.Errorf("upload.dir and data.dir must not be empty")
+	}
+	if _, err := ParseDuration(c.Upload.Retention); err != nil {
+		return fmt.Errorf("upload.retention: %w", err)
+	}
+	if d, err := ParseDuration(c.Upload.SweepInterval); err != nil || d <= 0 {
+		return fmt.Errorf("upload.sweep_interval %q must be a positive duration", c.Upload.SweepInterval)
+	}
+	if c.Watch.Enabled() && c.Watch.Reference == "" {
+		return fmt.Errorf("watch.reference must be set with watch.dir")
+	}
+	if d, err := ParseDuration(c.Watch.Interval); err != nil || d <= 0 {
+		return fmt.Errorf("watch.interval %q must be a positive duration", c.Watch.Interval)
+	}
+	if c.Watch.MinCoverage < 0 || c.Watch.MinCoverage > 100 {
+		return fmt.Errorf("watch.min_coverage must be between 0 and 100")
+	}
+	if (c.TLS.CertFile == "") != (c.TLS.KeyFile == "") {
+		return fmt.Errorf("tls.cert_file and tls.key_file must be set together")
+	}
+	if c.TLS.MinVersion != "1.2" && c.TLS.MinVersion != "1.3" {
+		return fmt.Errorf("tls.min_version %q must be 1.2 or 1.3", c.TLS.MinVersion)
+	}
+	return c.CORS.compile()
+}
+
+// Get returns the current configuration
+func Get() Config {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	cfg := current
+	cfg.CORS.Origins = append([]string(nil), cfg.CORS.Origins...)
+	cfg.Server.TrustedProxies = append([]string(nil), cfg.Server.TrustedProxies...)
+	return cfg
+}
+
+// Sources returns where each setting, by key, came from
+func Sources() map[string]string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	out := make(map[string]string, len(settings))
+	for _, s := range settings {
+		out[s.key] = SourceDefault
+		if src, ok := sources[s.key]; ok {
+			out[s.key] = src
+		}
+	}
+	return out
+}
+
+// File returns the config file read, or "" if none was
+func File() string {
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return file
+}
+
+// DataPath returns the path of a file in the data directory
+func DataPath(name string) string {
+	return filepath.Join(Get().Data.Dir, name)
+}
+
+// ParseDuration parses a Go duration ("90m", "12h") or a number of days
+// ("7d"); "" and "0" are 0
+func ParseDuration(s string) (time.Duration, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || s == "0" {
+		return 0, nil
+	}
+	if days, ok := strings.CutSuffix(s, "d"); ok {
+		n, err := strconv.ParseFloat(days, 64)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid duration %q", s)
+		}
+		return time.Duration(n * 24 * float64(time.Hour)), nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("invalid duration %q", s)
+	}
+	return d, nil
+}
+
+// readFile reads the settings of a config file, keyed by section.name
+func readFile(path string) (map[string]interface{}, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		var sections map[string]map[string]interface{}
+		if err := json.Unmarshal(data, &sections); err != nil {
+			return nil, err
+		}
+		values := map[string]interface{}{}
+		for section, kv := range sections {
+			flatten(values, section, kv)
+		}
+		return values, nil
+	}
+	return parseTOML(string(data))
+}
+
+// flatten adds the values of a JSON object under prefix, keying nested
+// objects' values as prefix.name.key
+func flatten(values map[string]interface{}, prefix string, kv map[string]interface{}) {
+	for k, v := range kv {
+		if nested, ok := v.(map[string]interface{}); ok {
+			flatten(values, prefix+"."+k, nested)
+		} else {
+			values[prefix+"."+k] = v
+		}
+	}
+}
+
+// setValue stores a value decoded from a file
+func setValue(ptr interface{}, v interface{}) error {
+	switch p := ptr.(type) {
+	case *string:
+		s, ok := v.(string)
+		if !ok {
+			return fmt.Errorf("expected a string")
+		}
+		*p = s
+	case *int:
+		switch n := v.(type) {
+		case int64:
+			*p = int(n)
+		case float64:
+			if n != float64(int(n)) {
+				return fmt.Errorf("expected an integer")
+			}
+			*p = int(n)
+		default:
+			return fmt.Errorf("expected an integer")
+		}
+	case *[]string:
+		items, ok := v.([]interface{})
+		if !ok {
+			return fmt.Errorf("expected an array of strings")
+		}
+		list := make([]string, 0, len(items))
+		for _, item := range items {
+			s, ok := item.(string)
+			if !ok {
+				return fmt.Errorf("expected an array of strings")
+			}
+			list = append(list, s)
+		}
+		*p = list
+	}
+	return nil
+}
+
+// setString stores a value given as text by an environment variable or flag
+func setString(ptr interface{}, s string) error {
+	switch p := ptr.(type) {
+	case *string:
+		*p = s
+	case *int:
+		n, err := strconv.Atoi(strings.TrimSpace(s))
+		if err != nil {
+			return fmt.Errorf("expected an integer, got %q", s)
+		}
+		*p = n
+	case *[]string:
+		var list []string
+		for _, item := range strings.Split(s, ",") {
+			if item = strings.TrimSpace(item); item != "" {
+				list = append(list, item)
+			}
+		}
+		*p = list
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package config
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// CORSConfig lists the origins allowed to call the API from a browser.
+//
+// An origin is matched exactly, with * standing for any characters
+// ("https://*.example.com", or "*" for any origin), or, when prefixed with
+// "regex:", by a regular expression that must match the whole origin.
+// Environments holds the lists of [cors.<environment>] sections; the one of
+// server.environment replaces Origins.
+type CORSConfig struct {
+	Origins      []string            `json:"origins"`
+	Environments map[string][]string `json:"environments,omitempty"`
+
+	patterns []*regexp.Regexp // Compiled Origins
+}
+
+// AllowOrigin reports whether a browser may call the API from origin
+func (c CORSConfig) AllowOrigin(origin string) bool {
+	patterns := c.patterns
+	if patterns == nil {
+		if err := c.compile(); err != nil {
+			return false
+		}
+		patterns = c.patterns
+	}
+	for _, re := range patterns {
+		if re.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsAny reports whether every origin is allowed
+func (c CORSConfig) AllowsAny() bool {
+	for _, o := range c.Origins {
+		if o == "*" {
+			return true
+		}
+	}
+	return false
+}
+
+// compile turns the origins into anchored, case-insensitive expressions
+func (c *CORSConfig) compile() error {
+	patterns := make([]*regexp.Regexp, 0, len(c.Origins))
+	for _, o := range c.Origins {
+		var expr string
+		if re, ok := strings.CutPrefix(o, "regex:"); ok {
+			expr = re
+		} else {
+			expr = strings.ReplaceAll(regexp.QuoteMeta(strings.TrimSuffix(o, "/")), `\*`, ".*")
+		}
+		re, err := regexp.Compile("(?i)^(?:" + expr + ")$")
+		if err != nil {
+			return fmt.Errorf("cors.origins: %q: %w", o, err)
+		}
+		patterns = append(patterns, re)
+	}
+	c.patterns = patterns
+	return nil
+}
+
+// setEnvironmentOrigins stores a cors.<environment>.origins file value,
+// reporting whether key is one
+func (c *CORSConfig) setEnvironmentOrigins(key string, v interface{}) (bool, error) {
+	env, ok := strings.CutPrefix(key, "cors.")
+	if !ok {
+		return false, nil
+	}
+	env, ok = strings.CutSuffix(env, ".origins")
+	if !ok || env == "" {
+		return false, nil
+	}
+	var origins []string
+	if err := setValue(&origins, v); err != nil {
+		return true, err
+	}
+	if c.Environments == nil {
+		c.Environments = map[string][]string{}
+	}
+	c.Environments[env] = origins
+	return true, nil
+}
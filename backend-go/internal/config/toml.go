@@ -0,0 +1,138 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// parseTOML reads the subset of TOML the config file needs: [section]
+// headers and key = value pairs whose values are strings, integers, floats,
+// booleans or single-line arrays of those, with # comments
+func parseTOML(text string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	section := ""
+	for i, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(stripComment(line))
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") || strings.HasPrefix(line, "[[") {
+				return nil, fmt.Errorf("line %d: invalid section header", i+1)
+			}
+			section = strings.TrimSpace(line[1 : len(line)-1])
+			if section == "" {
+				return nil, fmt.Errorf("line %d: empty section name", i+1)
+			}
+			continue
+		}
+		eq := strings.Index(line, "=")
+		if eq < 0 {
+			return nil, fmt.Errorf("line %d: expected key = value", i+1)
+		}
+		key := strings.TrimSpace(line[:eq])
+		if key == "" {
+			return nil, fmt.Errorf("line %d: missing key", i+1)
+		}
+		if section != "" {
+			key = section + "." + key
+		}
+		v, rest, err := parseTOMLValue(strings.TrimSpace(line[eq+1:]))
+		if err == nil && strings.TrimSpace(rest) != "" {
+			err = fmt.Errorf("unexpected %q after value", rest)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", i+1, err)
+		}
+		if _, dup := values[key]; dup {
+			return nil, fmt.Errorf("line %d: duplicate key %s", i+1, key)
+		}
+		values[key] = v
+	}
+	return values, nil
+}
+
+// stripComment drops a # comment that is not inside a string
+func stripComment(line string) string {
+	var quote byte
+	for i := 0; i < len(line); i++ {
+		c := line[i]
+		switch {
+		case quote != 0:
+			if c == '\\' && quote == '"' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '#':
+			return line[:i]
+		}
+	}
+	return line
+}
+
+// parseTOMLValue parses the value at the start of s, returning the rest
+func parseTOMLValue(s string) (interface{}, string, error) {
+	switch {
+	case s == "":
+		return nil, "", fmt.Errorf("missing value")
+	case s[0] == '"':
+		for i := 1; i < len(s); i++ {
+			if s[i] == '\\' {
+				i++
+			} else if s[i] == '"' {
+				v, err := strconv.Unquote(s[:i+1])
+				return v, s[i+1:], err
+			}
+		}
+		return nil, "", fmt.Errorf("unterminated string")
+	case s[0] == '\'':
+		end := strings.IndexByte(s[1:], '\'')
+		if end < 0 {
+			return nil, "", fmt.Errorf("unterminated string")
+		}
+		return s[1 : end+1], s[end+2:], nil
+	case s[0] == '[':
+		items := []interface{}{}
+		rest := strings.TrimSpace(s[1:])
+		for {
+			if strings.HasPrefix(rest, "]") {
+				return items, rest[1:], nil
+			}
+			v, r, err := parseTOMLValue(rest)
+			if err != nil {
+				return nil, "", err
+			}
+			items = append(items, v)
+			rest = strings.TrimSpace(r)
+			if strings.HasPrefix(rest, ",") {
+				rest = strings.TrimSpace(rest[1:])
+			} else if !strings.HasPrefix(rest, "]") {
+				return nil, "", fmt.Errorf("expected , or ] in array")
+			}
+		}
+	}
+
+	end := strings.IndexAny(s, ",] \t")
+	if end < 0 {
+		end = len(s)
+	}
+	word, rest := s[:end], s[end:]
+	switch word {
+	case "true":
+		return true, rest, nil
+	case "false":
+		return false, rest, nil
+	}
+	clean := strings.ReplaceAll(word, "_", "")
+	if n, err := strconv.ParseInt(clean, 10, 64); err == nil {
+		return n, rest, nil
+	}
+	if f, err := strconv.ParseFloat(clean, 64); err == nil {
+		return f, rest, nil
+	}
+	return nil, "", fmt.Errorf("invalid value %q", word)
+}
@@ -0,0 +1,293 @@
+// Package dateformat holds the date layouts used for date detection and
+// normalization, including user-defined layouts persisted to the data directory.
+package dateformat
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = logging.Component("date_formats")
+
+const customFormatsFile = "date_formats.json"
+
+// Epoch kinds reported by DetectColumn
+const (
+	EpochSeconds      = "epoch_s"
+	EpochMilliseconds = "epoch_ms"
+)
+
+// Plausible epoch range: 2000-01-01 to 2100-01-01
+const (
+	minEpochSeconds = 946684800
+	maxEpochSeconds = 4102444800
+)
+
+// DefaultSampleSize is how many non-empty values DetectColumn votes over
+const DefaultSampleSize = 200
+
+// builtInLayouts are tried in order; earlier layouts win ties (ISO and US first)
+var builtInLayouts = []string{
+	time.RFC3339Nano,
+	time.RFC3339,
+	"2006-01-02T15:04:05",
+	"2006-01-02T15:04",
+	"2006-01-02 15:04:05",
+	"2006-01-02 15:04",
+	"2006-01-02",
+	"2006/01/02",
+	"2006.01.02",
+	"01/02/2006",
+	"02/01/2006",
+	"1/2/2006",
+	"2/1/2006",
+	"01/02/2006 15:04:05",
+	"02/01/2006 15:04:05",
+	"01/02/2006 15:04",
+	"02/01/2006 15:04",
+	"01-02-2006",
+	"02-01-2006",
+	"02.01.2006",
+	"02.01.2006 15:04:05",
+	"02.01.2006 15:04",
+	"02-Jan-2006",
+	"02 Jan 2006",
+	"2 Jan 2006",
+	"Jan 2, 2006",
+	"January 2, 2006",
+	"2 January 2006",
+	time.RFC1123,
+	time.RFC1123Z,
+}
+
+var (
+	custom     []string
+	customOnce sync.Once
+	mutex      sync.RWMutex
+)
+
+// BuiltIn returns the built-in layouts
+func BuiltIn() []string {
+	return append([]string(nil), builtInLayouts...)
+}
+
+// Custom returns the user-defined layouts
+func Custom() []string {
+	loadCustom()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	return append([]string(nil), custom...)
+}
+
+// Layouts returns custom layouts followed by the built-in ones
+func Layouts() []string {
+	return append(Custom(), builtInLayouts...)
+}
+
+// SetCustom validates and persists user-defined layouts
+func SetCustom(layouts []string) ([]string, error) {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, l := range layouts {
+		l = strings.TrimSpace(l)
+		if l == "" || seen[l] {
+			continue
+		}
+		if err := ValidateLayout(l); err != nil {
+			return nil, err
+		}
+		seen[l] = true
+		cleaned = append(cleaned, l)
+	}
+
+	loadCustom()
+	mutex.Lock()
+	custom = cleaned
+	mutex.Unlock()
+
+	logger.Info("Saved custom formats", "count", len(cleaned))
+	return cleaned, saveCustom(cleaned)
+}
+
+// ValidateLayout checks that a Go time layout round-trips a reference date
+func ValidateLayout(layout string) error {
+	ref := time.Date(2024, time.March, 15, 13, 45, 30, 0, time.UTC)
+	formatted := ref.Format(layout)
+	if formatted == layout {
+		return fmt.Errorf("format %q has no date components (use Go layout syntax, e.g. 2006-01-02)", layout)
+	}
+	if _, err := time.Parse(layout, formatted); err != nil {
+		return fmt.Errorf("format %q can't be parsed back: %v", layout, err)
+	}
+	return nil
+}
+
+// Parse parses a value with the first matching layout. Epoch numbers are not
+// accepted here because single numbers are ambiguous; use DetectColumn.
+func Parse(value string) (time.Time, string, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return time.Time{}, "", false
+	}
+	for _, layout := range Layouts() {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, layout, true
+		}
+	}
+	return time.Time{}, "", false
+}
+
+// IsDate reports whether a value parses with any layout
+func IsDate(value string) bool {
+	_, _, ok := Parse(value)
+	return ok
+}
+
+// Detection is the outcome of column-level date detection
+type Detection struct {
+	Detected bool    `json:"detected"`
+	Layout   string  `json:"layout,omitempty"` // Go layout, or EpochSeconds / EpochMilliseconds
+	Matched  int     `json:"matched"`
+	Sampled  int     `json:"sampled"`
+	Ratio    float64 `json:"ratio"`
+}
+
+// IsEpoch reports whether the detected layout is a Unix timestamp
+func (d Detection) IsEpoch() bool {
+	return d.Layout == EpochSeconds || d.Layout == EpochMilliseconds
+}
+
+// DetectColumn votes over up to sampleSize non-empty values: every layout
+// that parses a value gets a vote, and the best layout is detected when it
+// parses more than half of the sample
+func DetectColumn(values []string, sampleSize int) Detection {
+	if sampleSize <= 0 {
+		sampleSize = DefaultSampleSize
+	}
+	layouts := append(Layouts(), EpochSeconds, EpochMilliseconds)
+	votes := make([]int, len(layouts))
+
+	sampled := 0
+	for _, v := range values {
+		if sampled >= sampleSize {
+			break
+		}
+		v = strings.TrimSpace(v)
+		if v == "" {
+			continue
+		}
+		sampled++
+		for i, layout := range layouts {
+			if matchesLayout(layout, v) {
+				votes[i]++
+			}
+		}
+	}
+
+	d := Detection{Sampled: sampled}
+	if sampled == 0 {
+		return d
+	}
+	best := -1
+	for i, n := range votes {
+		if n > 0 && (best < 0 || n > votes[best]) {
+			best = i
+		}
+	}
+	if best < 0 {
+		return d
+	}
+
+	d.Layout = layouts[best]
+	d.Matched = votes[best]
+	d.Ratio = float64(d.Matched) / float64(sampled)
+	d.Detected = d.Matched*2 > sampled
+	return d
+}
+
+// ParseWith parses a value with a layout returned by DetectColumn
+func ParseWith(layout, value string) (time.Time, bool) {
+	value = strings.TrimSpace(value)
+	switch layout {
+	case EpochSeconds, EpochMilliseconds:
+		n, ok := epochValue(layout, value)
+		if !ok {
+			return time.Time{}, false
+		}
+		if layout == EpochMilliseconds {
+			return time.UnixMilli(n).UTC(), true
+		}
+		return time.Unix(n, 0).UTC(), true
+	}
+	t, err := time.Parse(layout, value)
+	return t, err == nil
+}
+
+func matchesLayout(layout, value string) bool {
+	_, ok := ParseWith(layout, value)
+	return ok
+}
+
+// epochValue parses a 10-digit (seconds) or 13-digit (milliseconds) timestamp
+// within the plausible range
+func epochValue(kind, value string) (int64, bool) {
+	digits := 10
+	scale := int64(1)
+	if kind == EpochMilliseconds {
+		digits = 13
+		scale = 1000
+	}
+	if len(value) != digits {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n/scale < minEpochSeconds || n/scale > maxEpochSeconds {
+		return 0, false
+	}
+	return n, true
+}
+
+// loadCustom loads custom layouts from file once
+func loadCustom() {
+	customOnce.Do(func() {
+		data, err := os.ReadFile(config.DataPath(customFormatsFile))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Error("Error loading custom formats", "error", err)
+			}
+			return
+		}
+
+		var saved []string
+		if err := json.Unmarshal(data, &saved); err != nil {
+			logger.Error("Error parsing custom formats", "error", err)
+			return
+		}
+
+		mutex.Lock()
+		custom = saved
+		mutex.Unlock()
+		logger.Info("Loaded custom formats", "count", len(saved))
+	})
+}
+
+// saveCustom persists custom layouts to file
+func saveCustom(layouts []string) error {
+	data, err := json.MarshalIndent(layouts, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(customFormatsFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(customFormatsFile), data, 0644)
+}
@@ -0,0 +1,276 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Defaults of the token budget: small local models often run with a 4k
+// context window, and part of it is kept for the response
+const (
+	DefaultContextWindow = 4096
+	DefaultOutputTokens  = 1024
+)
+
+// maxExampleChars caps a sample value once a prompt is over its budget
+const maxExampleChars = 32
+
+// ErrResponseTruncated is returned when the response was cut off at the
+// output token limit; it is then usually incomplete JSON
+var ErrResponseTruncated = errors.New("LLM response cut off at the output token limit")
+
+// SetTokenBudget sets the context window of the model and the tokens of it
+// kept for the response; 0 restores a default
+func (s *Service) SetTokenBudget(contextWindow, outputTokens int) {
+	if contextWindow <= 0 {
+		contextWindow = DefaultContextWindow
+	}
+	if outputTokens <= 0 {
+		outputTokens = DefaultOutputTokens
+	}
+	s.config.ContextWindow = contextWindow
+	s.config.OutputTokens = min(outputTokens, contextWindow/2)
+}
+
+// ContextWindow returns the context window of the model, in tokens
+func (s *Service) ContextWindow() int {
+	if s.config.ContextWindow == 0 {
+		return DefaultContextWindow
+	}
+	return s.config.ContextWindow
+}
+
+// OutputTokens returns the tokens kept for the response
+func (s *Service) OutputTokens() int {
+	if s.config.OutputTokens == 0 {
+		return DefaultOutputTokens
+	}
+	return s.config.OutputTokens
+}
+
+// PromptBudget returns the tokens a prompt may take
+func (s *Service) PromptBudget() int {
+	return s.ContextWindow() - s.OutputTokens()
+}
+
+// EstimateTokens estimates the tokens of a text at three characters per
+// token. Column names and codes split into more tokens than English prose,
+// so this errs high rather than overflowing the context window.
+func EstimateTokens(text string) int {
+	return (utf8.RuneCountInString(text) + 2) / 3
+}
+
+// TruncationReport records how the prompts of a request were fitted to the
+// token budget
+type TruncationReport struct {
+	Truncated          bool     `json:"truncated"`                     // Something was left out or shortened
+	Calls              int      `json:"calls"`                         // Prompts sent
+	EstimatedTokens    int      `json:"estimated_tokens"`              // Of the largest prompt sent
+	BudgetTokens       int      `json:"budget_tokens"`                 // Prompt tokens allowed
+	DroppedColumns     []string `json:"dropped_columns,omitempty"`     // Left out of the prompts
+	DroppedHistory     int      `json:"dropped_history,omitempty"`     // Earlier questions left out
+	ShortenedValues    int      `json:"shortened_values,omitempty"`    // Sample values cut or left out
+	TruncatedResponses int      `json:"truncated_responses,omitempty"` // Cut off at the output limit
+
+	mutex sync.Mutex
+}
+
+type truncationReportKey struct{}
+
+// WithTruncationReport returns a context whose LLM calls record how their
+// prompts were fitted to the token budget in the returned report
+func WithTruncationReport(ctx context.Context) (context.Context, *TruncationReport) {
+	report := &TruncationReport{DroppedColumns: []string{}}
+	return context.WithValue(ctx, truncationReportKey{}, report), report
+}
+
+// truncationReport returns the context's report; nil without one, which
+// the recording methods ignore
+func truncationReport(ctx context.Context) *TruncationReport {
+	report, _ := ctx.Value(truncationReportKey{}).(*TruncationReport)
+	return report
+}
+
+// call records a prompt sent and its estimated tokens
+func (t *TruncationReport) call(tokens, budget int) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	t.Calls++
+	t.EstimatedTokens = max(t.EstimatedTokens, tokens)
+	t.BudgetTokens = budget
+}
+
+// record applies an update of what was cut and marks the report truncated
+func (t *TruncationReport) record(update func(t *TruncationReport)) {
+	if t == nil {
+		return
+	}
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	update(t)
+	t.Truncated = true
+}
+
+// columnLines renders columns for a prompt, one per line
+func columnLines(columns []ColumnSchema) string {
+	var b strings.Builder
+	for _, c := range columns {
+		b.WriteString(columnLine(c))
+	}
+	return b.String()
+}
+
+func columnLine(c ColumnSchema) string {
+	return fmt.Sprintf("- %q (%s), e.g. %s\n", c.Name, c.Type, strings.Join(c.Examples, ", "))
+}
+
+// fitColumns fits columns into a prompt budget of tokens and at most
+// maxColumns columns (0 for no limit). Long sample values are shortened
+// first, then each column keeps one sample, then columns are left out from
+// the end: callers list the columns that matter most first. The context's
+// report records what was cut.
+func fitColumns(ctx context.Context, columns []ColumnSchema, budget, maxColumns int) []ColumnSchema {
+	if maxColumns <= 0 {
+		maxColumns = len(columns)
+	}
+	if len(columns) <= maxColumns && EstimateTokens(columnLines(columns)) <= budget {
+		return columns
+	}
+	report := truncationReport(ctx)
+
+	fitted := make([]ColumnSchema, len(columns))
+	shortened := 0
+	for i, c := range columns {
+		c.Examples = append([]string(nil), c.Examples...)
+		for j, e := range c.Examples {
+			if utf8.RuneCountInString(e) > maxExampleChars {
+				c.Examples[j] = string([]rune(e)[:maxExampleChars-1]) + "…"
+				shortened++
+			}
+		}
+		fitted[i] = c
+	}
+	if EstimateTokens(columnLines(fitted)) > budget {
+		for i := range fitted {
+			if n := len(fitted[i].Examples); n > 1 {
+				fitted[i].Examples = fitted[i].Examples[:1]
+				shortened += n - 1
+			}
+		}
+	}
+
+	tokens := EstimateTokens(columnLines(fitted))
+	dropped := []string{}
+	for len(fitted) > 1 && (len(fitted) > maxColumns || tokens > budget) {
+		last := fitted[len(fitted)-1]
+		tokens -= EstimateTokens(columnLine(last))
+		dropped = append(dropped, last.Name)
+		fitted = fitted[:len(fitted)-1]
+	}
+	if shortened > 0 || len(dropped) > 0 {
+		report.record(func(t *TruncationReport) {
+			t.ShortenedValues += shortened
+			t.DroppedColumns = append(t.DroppedColumns, dropped...)
+		})
+	}
+	return fitted
+}
+
+// chunkColumns splits columns into chunks that each fit a prompt budget of
+// tokens and hold at most maxColumns columns. A column too large for a
+// chunk of its own has its samples shortened.
+func chunkColumns(ctx context.Context, columns []ColumnSchema, budget, maxColumns int) [][]ColumnSchema {
+	maxColumns = max(maxColumns, 1)
+	chunks := [][]ColumnSchema{}
+	chunk, tokens := []ColumnSchema{}, 0
+	for _, c := range columns {
+		n := EstimateTokens(columnLine(c))
+		if n > budget {
+			c = fitColumns(ctx, []ColumnSchema{c}, budget, 1)[0]
+			n = EstimateTokens(columnLine(c))
+		}
+		if len(chunk) > 0 && (len(chunk) == maxColumns || tokens+n > budget) {
+			chunks = append(chunks, chunk)
+			chunk, tokens = []ColumnSchema{}, 0
+		}
+		chunk = append(chunk, c)
+		tokens += n
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// fitNames keeps the names that fit a prompt budget of tokens, rendered
+// comma-separated, leaving out names from the end
+func fitNames(ctx context.Context, names []string, budget int) []string {
+	tokens := EstimateTokens(strings.Join(names, ", "))
+	if tokens <= budget {
+		return names
+	}
+	fitted := names
+	for len(fitted) > 1 && tokens > budget {
+		tokens -= EstimateTokens(fitted[len(fitted)-1] + ", ")
+		fitted = fitted[:len(fitted)-1]
+	}
+	if dropped := names[len(fitted):]; len(dropped) > 0 {
+		truncationReport(ctx).record(func(t *TruncationReport) {
+			t.DroppedColumns = append(t.DroppedColumns, dropped...)
+		})
+	}
+	return fitted
+}
+
+// chunkNames splits names into chunks that each fit a prompt budget of
+// tokens, rendered comma-separated, and hold at most maxNames names
+func chunkNames(names []string, budget, maxNames int) [][]string {
+	maxNames = max(maxNames, 1)
+	chunks := [][]string{}
+	chunk, tokens := []string{}, 0
+	for _, name := range names {
+		n := EstimateTokens(name + ", ")
+		if len(chunk) > 0 && (len(chunk) == maxNames || tokens+n > budget) {
+			chunks = append(chunks, chunk)
+			chunk, tokens = []string{}, 0
+		}
+		chunk = append(chunk, name)
+		tokens += n
+	}
+	if len(chunk) > 0 {
+		chunks = append(chunks, chunk)
+	}
+	return chunks
+}
+
+// minColumnBudget is the fewest tokens left for columns, however long the
+// rest of a prompt
+const minColumnBudget = 128
+
+// columnBudget is the budget left for the columns of a prompt, given the
+// prompt rendered without them
+func (s *Service) columnBudget(emptyPrompt string) int {
+	return max(s.PromptBudget()-EstimateTokens(emptyPrompt), minColumnBudget)
+}
+
+// sendChunk sends the prompt of the items [lo, hi) of a chunk for a task
+// and parses the response. A response cut off at the output limit is sent
+// again as two halves, down to single items.
+func (s *Service) sendChunk(ctx context.Context, task string, lo, hi int, prompt func(lo, hi int) string, parse func(response string) error) error {
+	_, err := s.route(ctx, task, prompt(lo, hi), parse, hi-lo > 1)
+	if errors.Is(err, ErrResponseTruncated) && hi-lo > 1 {
+		mid := (lo + hi) / 2
+		if err := s.sendChunk(ctx, task, lo, mid, prompt, parse); err != nil {
+			return err
+		}
+		return s.sendChunk(ctx, task, mid, hi, prompt, parse)
+	}
+	return err
+}
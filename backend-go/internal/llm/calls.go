@@ -0,0 +1,114 @@
+package llm
+
+import (
+	"backend-go/internal/logging"
+	"backend-go/internal/tracing"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+)
+
+// Tasks LLM calls are made for
+const (
+	TaskGenerate     = "generate" // CallOllama, without a task
+	TaskMatching     = "matching"
+	TaskPairCheck    = "pair_check"
+	TaskQuestions    = "questions"
+	TaskContextDraft = "context_draft"
+	TaskDescriptions = "descriptions"
+	TaskConcepts     = "concepts"
+	TaskQuery        = "query"
+	TaskEmbedding    = "embedding"
+	TaskHealth       = "health" // Health checks and the warm-up
+)
+
+// Outcomes of an LLM call
+const (
+	OutcomeOK         = "ok"          // Response parsed
+	OutcomeRetried    = "retried"     // Cut off at the output limit and sent again in halves
+	OutcomeTruncated  = "truncated"   // Cut off at the output limit
+	OutcomeParseError = "parse_error" // Response not understood
+	OutcomeFailed     = "failed"      // No response
+)
+
+// Call is a logged LLM request and its response
+type Call struct {
+	ID             string    `json:"id"`
+	Time           time.Time `json:"time"`
+	Task           string    `json:"task"`
+	Model          string    `json:"model"`
+	Fallback       int       `json:"fallback,omitempty"` // Models of the task tried before this one
+	Outcome        string    `json:"outcome"`
+	Error          string    `json:"error,omitempty"`
+	LatencyMS      int64     `json:"latency_ms"`
+	LoadMS         int64     `json:"load_ms,omitempty"` // Spent loading the model, on a cold start
+	PromptTokens   int       `json:"prompt_tokens"`     // Estimated
+	ResponseTokens int       `json:"response_tokens"`   // Estimated
+	PromptHash     string    `json:"prompt_hash"`       // SHA-256, to spot repeated prompts
+	Prompt         string    `json:"prompt,omitempty"`
+	Response       string    `json:"response,omitempty"`
+	Redacted       bool      `json:"redacted,omitempty"` // Prompt and response left out
+	RequestID      string    `json:"request_id,omitempty"`
+	TraceID        string    `json:"trace_id,omitempty"`
+}
+
+// CallRecorder keeps the calls of a Service
+type CallRecorder interface {
+	RecordCall(call Call)
+}
+
+// SetCallRecorder sets where calls are logged; nil logs none
+func (s *Service) SetCallRecorder(recorder CallRecorder) {
+	s.recorder = recorder
+}
+
+// newCall starts the log entry of a call made in the context's span
+func newCall(ctx context.Context, task, model, prompt string) *Call {
+	hash := sha256.Sum256([]byte(prompt))
+	call := &Call{
+		Time:         time.Now().UTC(),
+		Task:         task,
+		Model:        model,
+		PromptTokens: EstimateTokens(prompt),
+		PromptHash:   hex.EncodeToString(hash[:]),
+		Prompt:       prompt,
+		RequestID:    logging.Field(ctx, "request_id"),
+	}
+	if span := tracing.FromContext(ctx); span != nil {
+		call.TraceID = span.TraceIDString()
+	}
+	return call
+}
+
+// logCall completes a call with its outcome and hands it to the recorder
+func (s *Service) logCall(call *Call, outcome string, err error) {
+	if s.recorder == nil {
+		return
+	}
+	call.Outcome = outcome
+	if err != nil {
+		call.Error = err.Error()
+	}
+	s.recorder.RecordCall(*call)
+}
+
+// finish parses the response of a call, unless the call failed, and logs
+// the call with its outcome. A truncated response is not parsed and its
+// error returned.
+func (s *Service) finish(call *Call, response string, err error, parse func(response string) error) error {
+	outcome := OutcomeOK
+	switch {
+	case errors.Is(err, ErrResponseTruncated):
+		outcome = OutcomeTruncated
+	case err != nil:
+		outcome = OutcomeFailed
+	default:
+		if err = parse(response); err != nil {
+			outcome = OutcomeParseError
+		}
+	}
+	s.logCall(call, outcome, err)
+	return err
+}
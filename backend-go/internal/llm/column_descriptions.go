@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// DescriptionContext is what is known about a table when describing its
+// columns; empty fields are left out of the prompt
+type DescriptionContext struct {
+	FileName       string
+	BusinessDomain string
+	DatasetPurpose string
+	Language       string // e.g. German; English when empty
+}
+
+// SuggestColumnDescriptions asks the LLM for a one-line description of each
+// column from its name, type and sample values. The samples should be
+// masked by the caller; the caller also checks the descriptions name the
+// columns asked about. Wide tables are described in chunks that fit the
+// token budget.
+func (s *Service) SuggestColumnDescriptions(ctx context.Context, table DescriptionContext, columns []ColumnSchema) (map[string]string, error) {
+	var about strings.Builder
+	fmt.Fprintf(&about, "File: %q\n", table.FileName)
+	if table.BusinessDomain != "" {
+		fmt.Fprintf(&about, "Business domain: %s\n", table.BusinessDomain)
+	}
+	if table.DatasetPurpose != "" {
+		fmt.Fprintf(&about, "Purpose: %s\n", table.DatasetPurpose)
+	}
+	language := ""
+	if table.Language != "" {
+		language = fmt.Sprintf("- Write the descriptions in %s.\n", table.Language)
+	}
+	render := func(cols string) string {
+		return fmt.Sprintf(`
+You are a data analyst writing a data dictionary. Describe what each column of this dataset holds.
+
+%s
+Columns:
+%s
+Some example values are masked: letters are replaced by x or X and digits by 9.
+
+Format:
+{"column_name": "What the column holds"}
+
+Rules:
+- Use the column names listed above, spelled exactly, and describe every column.
+- Write one line of at most 15 words per column; mention units or codes when the values show them.
+%s
+Return ONLY the JSON.
+`, about.String(), cols, language)
+	}
+
+	descriptions := map[string]string{}
+	for _, chunk := range chunkColumns(ctx, columns, s.columnBudget(render("")), s.OutputTokens()/descriptionTokens) {
+		prompt := func(lo, hi int) string { return render(columnLines(chunk[lo:hi])) }
+		err := s.sendChunk(ctx, TaskDescriptions, 0, len(chunk), prompt, func(response string) error {
+			jsonStr := regexp.MustCompile(`\{[\s\S]*\}`).FindString(response)
+			if jsonStr == "" {
+				return fmt.Errorf("no JSON found in response")
+			}
+			var parsed map[string]string
+			if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+				return err
+			}
+			for col, desc := range parsed {
+				descriptions[col] = desc
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return descriptions, nil
+}
@@ -0,0 +1,76 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+)
+
+// ContextDraft is the business context the LLM proposes for a table, for
+// the user to review before it is stored
+type ContextDraft struct {
+	DatasetPurpose     string            `json:"dataset_purpose"`
+	BusinessDomain     string            `json:"business_domain"`
+	KeyEntities        []string          `json:"key_entities"`
+	TemporalContext    string            `json:"temporal_context"`
+	ColumnDescriptions map[string]string `json:"column_descriptions"`
+}
+
+// Output tokens of a context draft besides the column descriptions, and of
+// one column description
+const (
+	draftTokens       = 200
+	descriptionTokens = 32
+)
+
+// DraftContext asks the LLM to describe a table from its schema and sample
+// values. The samples should be masked by the caller; the caller also checks
+// the descriptions name known columns. Columns that don't fit the token
+// budget are left out, from the end, and so go undescribed.
+func (s *Service) DraftContext(ctx context.Context, fileName string, rows int, columns []ColumnSchema) (*ContextDraft, error) {
+	render := func(cols string) string {
+		return fmt.Sprintf(`
+You are a data analyst documenting a dataset. From its file name, columns and example values, describe what the dataset is about.
+
+File: %q (%d rows)
+
+Columns:
+%s
+Some example values are masked: letters are replaced by x or X and digits by 9.
+
+Format:
+{
+	"dataset_purpose": "One sentence on what the data records and what it is used for",
+	"business_domain": "E-commerce",
+	"key_entities": ["customer", "order"],
+	"temporal_context": "Daily snapshots from 2023, or empty if unclear",
+	"column_descriptions": {"column_name": "What the column holds"}
+}
+
+Rules:
+- Use the column names listed above, spelled exactly, and describe every column.
+- Keep each description under 15 words.
+- key_entities are the business objects the rows are about, in singular.
+
+Return ONLY the JSON.
+`, fileName, rows, cols)
+	}
+	// Every column is described in the response, so the output tokens
+	// limit the columns too
+	columns = fitColumns(ctx, columns, s.columnBudget(render("")), (s.OutputTokens()-draftTokens)/descriptionTokens)
+	prompt := render(columnLines(columns))
+
+	var draft ContextDraft
+	err := s.Generate(ctx, TaskContextDraft, prompt, func(response string) error {
+		jsonStr := regexp.MustCompile(`\{[\s\S]*\}`).FindString(response)
+		if jsonStr == "" {
+			return fmt.Errorf("no JSON found in response")
+		}
+		return json.Unmarshal([]byte(jsonStr), &draft)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &draft, nil
+}
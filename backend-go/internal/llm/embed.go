@@ -0,0 +1,113 @@
+package llm
+
+import (
+	"backend-go/internal/tracing"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// DefaultEmbedModel is the Ollama model texts are embedded with
+const DefaultEmbedModel = "nomic-embed-text"
+
+type EmbedRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+}
+
+type EmbedResponse struct {
+	Embeddings [][]float64 `json:"embeddings"`
+}
+
+// SetEmbedModel sets the model Embed uses; "" restores DefaultEmbedModel
+func (s *Service) SetEmbedModel(model string) {
+	if model == "" {
+		model = DefaultEmbedModel
+	}
+	s.config.EmbedModel = model
+}
+
+// EmbedModel returns the model Embed uses
+func (s *Service) EmbedModel() string {
+	if s.config.EmbedModel == "" {
+		return DefaultEmbedModel
+	}
+	return s.config.EmbedModel
+}
+
+// Embed returns one embedding vector per text, in order, from Ollama's
+// /api/embed, and the model that embedded them: the models of the embedding
+// task are tried in turn. Vectors of different models don't compare.
+func (s *Service) Embed(ctx context.Context, texts []string) (vectors [][]float64, model string, err error) {
+	models := s.TaskModels(TaskEmbedding)
+	for i, model := range models {
+		vectors, err = s.embed(ctx, model, texts, i)
+		if err == nil || ctx.Err() != nil {
+			return vectors, model, err
+		}
+		if i+1 < len(models) {
+			logger.WarnContext(ctx, "Embedding failed, trying the next model", "model", model, "next", models[i+1], "error", err)
+		}
+	}
+	return nil, "", err
+}
+
+// EmbedWith returns one embedding vector per text, in order, from the given
+// model, without falling back to another
+func (s *Service) EmbedWith(ctx context.Context, model string, texts []string) ([][]float64, error) {
+	return s.embed(ctx, model, texts, 0)
+}
+
+// embed embeds texts with a model, logging the call as the fallback-th try
+func (s *Service) embed(ctx context.Context, model string, texts []string, fallback int) (vectors [][]float64, err error) {
+	ctx, span := tracing.StartKind(ctx, "llm.embed", tracing.KindClient)
+	span.SetAttr("llm.model", model)
+	span.SetAttr("llm.inputs", len(texts))
+	call := newCall(ctx, TaskEmbedding, model, strings.Join(texts, "\n"))
+	call.Fallback = fallback
+	defer func() {
+		call.LatencyMS = time.Since(call.Time).Milliseconds()
+		outcome := OutcomeOK
+		if err != nil {
+			outcome = OutcomeFailed
+		}
+		s.logCall(call, outcome, err)
+		span.RecordError(err)
+		span.End()
+	}()
+
+	jsonData, err := json.Marshal(EmbedRequest{Model: model, Input: texts})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.BaseURL+"/api/embed", bytes.NewBuffer(jsonData))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama API returned status: %d", resp.StatusCode)
+	}
+
+	var embedResp EmbedResponse
+	if err := json.NewDecoder(resp.Body).Decode(&embedResp); err != nil {
+		return nil, err
+	}
+	if len(embedResp.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(embedResp.Embeddings), len(texts))
+	}
+	return embedResp.Embeddings, nil
+}
@@ -0,0 +1,35 @@
+package llm
+
+import (
+	"fmt"
+	"strings"
+)
+
+// GlossaryHint is a business glossary term offered to the LLM
+type GlossaryHint struct {
+	Term       string
+	Definition string
+	Synonyms   []string
+}
+
+// glossarySection renders the glossary terms for a prompt, empty without
+// terms
+func glossarySection(hints []GlossaryHint) string {
+	if len(hints) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("Business glossary (names listed for the same term mean the same thing):\n")
+	for _, h := range hints {
+		b.WriteString("- " + h.Term)
+		if len(h.Synonyms) > 0 {
+			fmt.Fprintf(&b, " (also: %s)", strings.Join(h.Synonyms, ", "))
+		}
+		if h.Definition != "" {
+			b.WriteString(": " + h.Definition)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("\n")
+	return b.String()
+}
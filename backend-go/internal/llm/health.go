@@ -0,0 +1,80 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// healthPrompt is the trivial prompt of a health check; healthTokens caps
+// its response
+const (
+	healthPrompt = "Reply with the single word OK."
+	healthTokens = 8
+)
+
+// ModelHealth is the outcome of a health check of the model
+type ModelHealth struct {
+	Model     string    `json:"model"`
+	Available bool      `json:"available"`  // The model answered the prompt
+	Reachable bool      `json:"reachable"`  // Ollama answered
+	Pulled    bool      `json:"pulled"`     // The model is among Ollama's
+	LatencyMS int64     `json:"latency_ms"` // Of the prompt, loading included
+	LoadMS    int64     `json:"load_ms"`    // Spent loading the model; 0 when it was loaded
+	Response  string    `json:"response,omitempty"`
+	Error     string    `json:"error,omitempty"`
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CheckHealth sends a trivial prompt to the model and reports whether and
+// how fast it answered, and why not. The prompt loads the model if Ollama
+// has not, so a check after a cold start takes the loading time.
+func (s *Service) CheckHealth(ctx context.Context) *ModelHealth {
+	health := &ModelHealth{Model: s.config.Model, CheckedAt: time.Now().UTC()}
+	models, err := s.Models(ctx)
+	if err != nil {
+		health.Error = err.Error()
+		return health
+	}
+	health.Reachable = true
+	for _, m := range models {
+		if m == s.config.Model || m == s.config.Model+":latest" {
+			health.Pulled = true
+		}
+	}
+	if !health.Pulled {
+		health.Error = fmt.Sprintf("model %q is not pulled (ollama pull %s)", s.config.Model, s.config.Model)
+		return health
+	}
+
+	response, call, err := s.generate(ctx, TaskHealth, s.config.Model, healthPrompt, healthTokens)
+	err = s.finish(call, response, err, func(string) error { return nil })
+	health.LatencyMS, health.LoadMS = call.LatencyMS, call.LoadMS
+	health.Response = strings.TrimSpace(response)
+	// Any answer will do, even one running past the few tokens allowed
+	if err != nil && !errors.Is(err, ErrResponseTruncated) {
+		health.Error = err.Error()
+		return health
+	}
+	health.Available = true
+	return health
+}
+
+// WarmUp runs a health check, loading the model so the first request
+// doesn't wait for it, and keeps its outcome for LastWarmUp
+func (s *Service) WarmUp(ctx context.Context) *ModelHealth {
+	health := s.CheckHealth(ctx)
+	s.mutex.Lock()
+	s.warmUp = health
+	s.mutex.Unlock()
+	return health
+}
+
+// LastWarmUp returns the outcome of the last warm-up, nil before one ended
+func (s *Service) LastWarmUp() *ModelHealth {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.warmUp
+}
@@ -0,0 +1,82 @@
+package llm
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ConceptOption is a concept of a vocabulary offered to the LLM
+type ConceptOption struct {
+	ID          string
+	Description string
+}
+
+// ConceptProposal is the concept the LLM proposes for a column
+type ConceptProposal struct {
+	Column     string  `json:"column"`
+	ConceptID  string  `json:"concept"`
+	Confidence float64 `json:"confidence"` // 0-1
+	Reason     string  `json:"reason"`
+}
+
+// tagTokens are the output tokens of one concept tag
+const tagTokens = 48
+
+// ProposeConcepts asks the LLM which concept of a vocabulary each column
+// represents. The caller checks the proposals name known columns and
+// concepts. Wide tables are sent in chunks that fit the token budget.
+func (s *Service) ProposeConcepts(ctx context.Context, columns []ColumnSchema, concepts []ConceptOption) ([]ConceptProposal, error) {
+	var options strings.Builder
+	for _, c := range concepts {
+		fmt.Fprintf(&options, "- %s: %s\n", c.ID, c.Description)
+	}
+	render := func(cols string) string {
+		return fmt.Sprintf(`
+You are an expert in data standards. For each column of a table, choose the concept of the vocabulary it represents, judging by its name and example values.
+
+Columns:
+%s
+Concepts:
+%s
+Format:
+{
+	"tags": [
+		{"column": "column_name", "concept": "concept_id", "confidence": 0.9, "reason": "Values are..."}
+	]
+}
+
+Rules:
+- Use the column names and concept IDs listed above, spelled exactly.
+- Leave out columns that represent none of the concepts.
+- Only include tags where you are confident (confidence > 0.5).
+
+Return ONLY the JSON.
+`, cols, options.String())
+	}
+
+	proposals := []ConceptProposal{}
+	for _, chunk := range chunkColumns(ctx, columns, s.columnBudget(render("")), s.OutputTokens()/tagTokens) {
+		prompt := func(lo, hi int) string { return render(columnLines(chunk[lo:hi])) }
+		err := s.sendChunk(ctx, TaskConcepts, 0, len(chunk), prompt, func(response string) error {
+			jsonStr := regexp.MustCompile(`\{[\s\S]*\}`).FindString(response)
+			if jsonStr == "" {
+				return fmt.Errorf("no JSON found in response")
+			}
+			var parsed struct {
+				Tags []ConceptProposal `json:"tags"`
+			}
+			if err := json.Unmarshal([]byte(jsonStr), &parsed); err != nil {
+				return err
+			}
+			proposals = append(proposals, parsed.Tags...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return proposals, nil
+}
@@ -0,0 +1,104 @@
+package llm
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// ColumnSchema describes a column to the query planner
+type ColumnSchema struct {
+	Name     string
+	Type     string // string, float or time
+	Examples []string
+}
+
+// QueryExchange is an earlier question of the conversation and the JSON
+// plan that answered it (empty when no plan was made)
+type QueryExchange struct {
+	Question string
+	Plan     string
+}
+
+// GenerateQueryPlan asks the LLM to translate a question about a table into
+// a JSON query plan and returns the JSON text. The caller validates it.
+// History lets follow-ups such as "now by month" refine the previous plan.
+// Over the token budget, the oldest exchanges are left out first, then
+// sample values are shortened and the last columns left out.
+func (s *Service) GenerateQueryPlan(ctx context.Context, question string, schema []ColumnSchema, history []QueryExchange) (string, error) {
+	render := func(cols string, history []QueryExchange) string {
+		return fmt.Sprintf(`
+You translate questions about a table into a JSON query plan.
+
+Columns:
+%s%s
+Question: %s
+
+Plan format:
+{
+	"filters": [{"column": "...", "operator": "equals|not_equals|contains|starts_with|greater_than|less_than|before|after|is_null|not_null", "value": "..."},
+	            {"column": "...", "operator": "in|not_in|between", "values": ["...", "..."]}],
+	"group_by": ["..."],
+	"time_grain": "day|month|quarter|year",
+	"aggregations": [{"column": "...", "function": "count|distinct|sum|mean|min|max"}],
+	"select": ["..."],
+	"order_by": {"column": "...", "descending": true},
+	"limit": 10,
+	"explanation": "One sentence describing what the plan computes"
+}
+
+Rules:
+- Use only the column names listed above, spelled exactly.
+- Aggregation result columns are named like "sum(amount)"; "count" without a column is named "count(*)". Use these names in order_by.
+- "time_grain" buckets date columns in group_by, e.g. "by month" groups a date column with "month".
+- Leave aggregations empty to list matching rows; "select" then picks the columns to show.
+- Omit fields you don't need.
+
+Return ONLY the JSON.
+`, cols, conversationSection(history), question)
+	}
+
+	columnTokens := EstimateTokens(columnLines(schema))
+	kept := history
+	for len(kept) > 0 && EstimateTokens(render("", kept))+columnTokens > s.PromptBudget() {
+		kept = kept[1:]
+	}
+	if dropped := len(history) - len(kept); dropped > 0 {
+		truncationReport(ctx).record(func(t *TruncationReport) { t.DroppedHistory += dropped })
+	}
+	schema = fitColumns(ctx, schema, s.columnBudget(render("", kept)), 0)
+	prompt := render(columnLines(schema), kept)
+
+	var jsonStr string
+	err := s.Generate(ctx, TaskQuery, prompt, func(response string) error {
+		jsonStr = regexp.MustCompile(`\{[\s\S]*\}`).FindString(response)
+		if jsonStr == "" {
+			return fmt.Errorf("no JSON found in response")
+		}
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	return jsonStr, nil
+}
+
+// conversationSection renders the earlier exchanges of a query session for
+// a prompt, empty without any
+func conversationSection(history []QueryExchange) string {
+	if len(history) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("\nConversation so far (oldest first):\n")
+	for _, e := range history {
+		plan := e.Plan
+		if plan == "" {
+			plan = "(no plan)"
+		}
+		fmt.Fprintf(&b, "Q: %s\nPlan: %s\n", e.Question, plan)
+	}
+	b.WriteString("If the question is a follow-up (\"that\", \"now\", \"instead\"), start from the last plan and change only what it asks for.\n")
+	return b.String()
+}
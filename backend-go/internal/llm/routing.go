@@ -0,0 +1,100 @@
+package llm
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Tasks lists the tasks models can be routed by
+func Tasks() []string {
+	return []string{TaskGenerate, TaskMatching, TaskPairCheck, TaskQuestions, TaskContextDraft, TaskDescriptions, TaskConcepts, TaskQuery, TaskEmbedding}
+}
+
+// SetTaskModels routes tasks to models: each task is sent to its models in
+// order, falling back to the next when one fails, and finally to the
+// default model (the embed model for embeddings). Tasks left out use the
+// default model only. nil removes all routes.
+func (s *Service) SetTaskModels(routes map[string][]string) error {
+	known := map[string]bool{}
+	for _, task := range Tasks() {
+		known[task] = true
+	}
+	copied := make(map[string][]string, len(routes))
+	for task, models := range routes {
+		if !known[task] {
+			return fmt.Errorf("unknown LLM task %q (use %s)", task, strings.Join(Tasks(), ", "))
+		}
+		for _, m := range models {
+			if strings.TrimSpace(m) == "" {
+				return fmt.Errorf("task %s: empty model name", task)
+			}
+		}
+		copied[task] = append([]string(nil), models...)
+	}
+	s.mutex.Lock()
+	s.config.TaskModels = copied
+	s.mutex.Unlock()
+	return nil
+}
+
+// TaskModels returns the models a task is sent to, in the order tried
+func (s *Service) TaskModels(task string) []string {
+	fallback := s.config.Model
+	if task == TaskEmbedding {
+		fallback = s.EmbedModel()
+	}
+	s.mutex.Lock()
+	routed := s.config.TaskModels[task]
+	s.mutex.Unlock()
+
+	models := []string{}
+	seen := map[string]bool{}
+	for _, m := range append(append([]string(nil), routed...), fallback) {
+		if !seen[m] {
+			seen[m] = true
+			models = append(models, m)
+		}
+	}
+	return models
+}
+
+// TaskRoutes returns the models of every task, in the order tried
+func (s *Service) TaskRoutes() map[string][]string {
+	routes := map[string][]string{}
+	for _, task := range Tasks() {
+		routes[task] = s.TaskModels(task)
+	}
+	return routes
+}
+
+// route sends the prompt of a task to its models in turn until one's
+// response parses, and returns that response. A response cut off at the
+// output limit ends the attempts with ErrResponseTruncated, as the other
+// models would be cut off too; it is logged as retried when the caller
+// sends the prompt again in parts. Otherwise the last error is returned.
+func (s *Service) route(ctx context.Context, task, prompt string, parse func(response string) error, retried bool) (string, error) {
+	var (
+		response string
+		err      error
+	)
+	models := s.TaskModels(task)
+	for i, model := range models {
+		var call *Call
+		response, call, err = s.generate(ctx, task, model, prompt, s.OutputTokens())
+		call.Fallback = i
+		if errors.Is(err, ErrResponseTruncated) && retried {
+			s.logCall(call, OutcomeRetried, err)
+			return response, err
+		}
+		err = s.finish(call, response, err, parse)
+		if err == nil || errors.Is(err, ErrResponseTruncated) || ctx.Err() != nil {
+			return response, err
+		}
+		if i+1 < len(models) {
+			logger.WarnContext(ctx, "LLM call failed, trying the next model", "task", task, "model", model, "next", models[i+1], "error", err)
+		}
+	}
+	return response, err
+}
@@ -1,24 +1,37 @@
 package llm
 
 import (
+	"backend-go/internal/logging"
+	"backend-go/internal/tracing"
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
 	"regexp"
 	"strings"
+	"sync"
 	"time"
 )
 
+var logger = logging.Component("llm")
+
 type Config struct {
-	BaseURL string
-	Model   string
+	BaseURL       string
+	Model         string
+	EmbedModel    string              // Model for Embed; "" = DefaultEmbedModel
+	ContextWindow int                 // Tokens; 0 = DefaultContextWindow
+	OutputTokens  int                 // Tokens of the window kept for the response; 0 = DefaultOutputTokens
+	TaskModels    map[string][]string // Models per task, tried in order before the default
 }
 
 type Service struct {
-	config Config
-	client *http.Client
+	config   Config
+	client   *http.Client
+	recorder CallRecorder // Logs the calls; nil logs none
+	warmUp   *ModelHealth // Outcome of the last warm-up
+	mutex    sync.Mutex
 }
 
 func NewService(baseURL, model string) *Service {
@@ -40,49 +53,147 @@ func NewService(baseURL, model string) *Service {
 }
 
 type GenerateRequest struct {
-	Model  string `json:"model"`
-	Prompt string `json:"prompt"`
-	Stream bool   `json:"stream"`
+	Model   string          `json:"model"`
+	Prompt  string          `json:"prompt"`
+	Stream  bool            `json:"stream"`
+	Options GenerateOptions `json:"options"`
+}
+
+// GenerateOptions are the Ollama model options of a request
+type GenerateOptions struct {
+	NumCtx     int `json:"num_ctx"`     // Context window
+	NumPredict int `json:"num_predict"` // Most tokens generated
 }
 
 type GenerateResponse struct {
-	Response string `json:"response"`
+	Response     string `json:"response"`
+	DoneReason   string `json:"done_reason"`   // "length" when cut off at num_predict
+	LoadDuration int64  `json:"load_duration"` // Nanoseconds spent loading the model
 }
 
 // CallOllama calls the Ollama API
 func (s *Service) CallOllama(prompt string) (string, error) {
+	return s.CallOllamaContext(context.Background(), prompt)
+}
+
+// CallOllamaContext calls the Ollama API as part of the context's trace.
+// A response cut off at the output token limit is returned along with
+// ErrResponseTruncated.
+func (s *Service) CallOllamaContext(ctx context.Context, prompt string) (string, error) {
+	return s.route(ctx, TaskGenerate, prompt, func(string) error { return nil }, false)
+}
+
+// Generate sends the prompt of a task to the task's models and parses the
+// response, logging each call with its outcome
+func (s *Service) Generate(ctx context.Context, task, prompt string, parse func(response string) error) error {
+	_, err := s.route(ctx, task, prompt, parse, false)
+	return err
+}
+
+// generate calls the Ollama API with a model, generating at most numPredict
+// tokens, and returns the response along with the call to log, whose outcome is left to
+// the caller. A response cut off at numPredict is returned along with
+// ErrResponseTruncated.
+func (s *Service) generate(ctx context.Context, task, model, prompt string, numPredict int) (response string, call *Call, err error) {
+	ctx, span := tracing.StartKind(ctx, "llm.generate", tracing.KindClient)
+	call = newCall(ctx, task, model, prompt)
+	report := truncationReport(ctx)
+	report.call(call.PromptTokens, s.PromptBudget())
+
+	span.SetAttr("llm.model", model)
+	span.SetAttr("llm.task", task)
+	span.SetAttr("llm.prompt_chars", len(prompt))
+	span.SetAttr("llm.prompt_tokens_estimate", call.PromptTokens)
+	defer func() {
+		call.LatencyMS = time.Since(call.Time).Milliseconds()
+		call.Response = response
+		call.ResponseTokens = EstimateTokens(response)
+		span.SetAttr("llm.response_chars", len(response))
+		span.RecordError(err)
+		span.End()
+	}()
+
 	reqBody := GenerateRequest{
-		Model:  s.config.Model,
-		Prompt: prompt,
-		Stream: false,
+		Model:   model,
+		Prompt:  prompt,
+		Stream:  false,
+		Options: GenerateOptions{NumCtx: s.ContextWindow(), NumPredict: numPredict},
 	}
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		return "", err
+		return "", call, err
 	}
 
-	resp, err := s.client.Post(s.config.BaseURL+"/api/generate", "application/json", bytes.NewBuffer(jsonData))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.config.BaseURL+"/api/generate", bytes.NewBuffer(jsonData))
 	if err != nil {
-		return "", err
+		return "", call, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	tracing.Inject(ctx, req.Header)
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", call, err
 	}
 	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("ollama API returned status: %d", resp.StatusCode)
+		return "", call, fmt.Errorf("ollama API returned status: %d", resp.StatusCode)
 	}
 
 	body, err := io.ReadAll(resp.Body)
 	if err != nil {
-		return "", err
+		return "", call, err
 	}
 
 	var genResp GenerateResponse
 	if err := json.Unmarshal(body, &genResp); err != nil {
-		return "", err
+		return "", call, err
 	}
+	call.LoadMS = genResp.LoadDuration / int64(time.Millisecond)
+	if genResp.DoneReason == "length" {
+		report.record(func(t *TruncationReport) { t.TruncatedResponses++ })
+		return genResp.Response, call, ErrResponseTruncated
+	}
+
+	return genResp.Response, call, nil
+}
 
-	return genResp.Response, nil
+// Ping checks that Ollama answers, listing its models
+func (s *Service) Ping(ctx context.Context) error {
+	_, err := s.Models(ctx)
+	return err
+}
+
+// Models lists the models pulled into Ollama
+func (s *Service) Models(ctx context.Context) ([]string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.config.BaseURL+"/api/tags", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ollama API returned status: %d", resp.StatusCode)
+	}
+
+	var tags struct {
+		Models []struct {
+			Name string `json:"name"`
+		} `json:"models"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&tags); err != nil {
+		return nil, err
+	}
+	models := make([]string, 0, len(tags.Models))
+	for _, m := range tags.Models {
+		models = append(models, m.Name)
+	}
+	return models, nil
 }
 
 type Match struct {
@@ -96,15 +207,23 @@ type MatchesResponse struct {
 	Matches []Match `json:"matches"`
 }
 
-// GetSemanticMatches asks the LLM to match columns
-func (s *Service) GetSemanticMatches(cols1, cols2 []string) ([]Match, error) {
-	prompt := fmt.Sprintf(`
+// matchTokens are the output tokens of one match
+const matchTokens = 48
+
+// GetSemanticMatches asks the LLM to match columns, telling it the business
+// glossary terms the columns resolve to. List B goes whole into every
+// prompt, taking at most half the token budget and losing columns from the
+// end beyond it; list A is sent in chunks that fit the rest. Callers list
+// the columns that matter most first.
+func (s *Service) GetSemanticMatches(ctx context.Context, cols1, cols2 []string, glossary []GlossaryHint) ([]Match, error) {
+	render := func(listA, listB string) string {
+		return fmt.Sprintf(`
 You are an expert data integration specialist. Match columns from List A to List B based on semantic meaning.
 
 List A: %s
 List B: %s
 
-Return a JSON object where keys are columns from List A and values are the best matching column from List B.
+%sReturn a JSON object where keys are columns from List A and values are the best matching column from List B.
 Only include matches where you are confident (score > 0.5).
 
 Format:
@@ -115,24 +234,34 @@ Format:
 }
 
 Return ONLY the JSON.
-`, strings.Join(cols1, ", "), strings.Join(cols2, ", "))
-
-	response, err := s.CallOllama(prompt)
-	if err != nil {
-		return nil, err
+`, listA, listB, glossarySection(glossary))
 	}
 
-	// Extract JSON
-	jsonRegex := regexp.MustCompile(`\{[\s\S]*\}`)
-	jsonStr := jsonRegex.FindString(response)
-	if jsonStr == "" {
-		return nil, fmt.Errorf("no JSON found in response")
-	}
+	budget := s.columnBudget(render("", ""))
+	listB := strings.Join(fitNames(ctx, cols2, budget/2), ", ")
+	budgetA := budget - EstimateTokens(listB)
 
-	var matchesResp MatchesResponse
-	if err := json.Unmarshal([]byte(jsonStr), &matchesResp); err != nil {
-		return nil, err
-	}
+	matches := []Match{}
+	for _, chunk := range chunkNames(cols1, budgetA, s.OutputTokens()/matchTokens) {
+		prompt := func(lo, hi int) string { return render(strings.Join(chunk[lo:hi], ", "), listB) }
+		err := s.sendChunk(ctx, TaskMatching, 0, len(chunk), prompt, func(response string) error {
+			// Extract JSON
+			jsonRegex := regexp.MustCompile(`\{[\s\S]*\}`)
+			jsonStr := jsonRegex.FindString(response)
+			if jsonStr == "" {
+				return fmt.Errorf("no JSON found in response")
+			}
 
-	return matchesResp.Matches, nil
+			var matchesResp MatchesResponse
+			if err := json.Unmarshal([]byte(jsonStr), &matchesResp); err != nil {
+				return err
+			}
+			matches = append(matches, matchesResp.Matches...)
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	return matches, nil
 }
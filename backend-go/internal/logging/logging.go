@@ -0,0 +1,127 @@
+// Package logging configures structured logging with log/slog.
+//
+// LOG_LEVEL (debug, info, warn, error; default info) and LOG_FORMAT (text or
+// json; default text) configure the output; the level can also be changed
+// at runtime. Loggers made by Component tag records with their component
+// and add the fields attached to the context by ContextWith, such as the
+// request ID, so handlers log with InfoContext(r.Context(), ...).
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync/atomic"
+)
+
+var (
+	level   slog.LevelVar
+	current atomic.Pointer[slog.Handler] // Handler writing the records
+)
+
+func init() {
+	if err := Configure(os.Getenv("LOG_FORMAT"), os.Getenv("LOG_LEVEL")); err != nil {
+		Configure("", "")
+		slog.Warn("Ignoring invalid logging configuration", "component", "logging", "error", err)
+	}
+}
+
+// Configure sets the output format and level; empty values keep the defaults
+func Configure(format, levelName string) error {
+	if levelName != "" {
+		if err := SetLevel(levelName); err != nil {
+			return err
+		}
+	}
+	opts := &slog.HandlerOptions{Level: &level}
+	var h slog.Handler
+	switch strings.ToLower(format) {
+	case "", "text":
+		h = slog.NewTextHandler(os.Stderr, opts)
+	case "json":
+		h = slog.NewJSONHandler(os.Stderr, opts)
+	default:
+		return fmt.Errorf("unknown log format %q (use text or json)", format)
+	}
+	current.Store(&h)
+	slog.SetDefault(slog.New(handler{}))
+	return nil
+}
+
+// SetLevel changes the minimum level logged
+func SetLevel(name string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(name)); err != nil {
+		return fmt.Errorf("unknown log level %q (use debug, info, warn or error)", name)
+	}
+	level.Set(l)
+	return nil
+}
+
+// Level returns the minimum level logged, e.g. "info"
+func Level() string {
+	return strings.ToLower(level.Level().String())
+}
+
+// Component returns a logger tagging records with the component name
+func Component(name string) *slog.Logger {
+	return slog.New(handler{attrs: []slog.Attr{slog.String("component", name)}})
+}
+
+type fieldsKey struct{}
+
+// ContextWith attaches fields (key-value pairs as for slog) to the context;
+// records logged with it carry them
+func ContextWith(ctx context.Context, args ...any) context.Context {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	r := slog.Record{}
+	r.Add(args...)
+	merged := append([]slog.Attr(nil), fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		merged = append(merged, a)
+		return true
+	})
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// Field returns the value of a context field, or ""
+func Field(ctx context.Context, key string) string {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	for i := len(fields) - 1; i >= 0; i-- {
+		if fields[i].Key == key {
+			return fields[i].Value.String()
+		}
+	}
+	return ""
+}
+
+// handler adds its attributes and the context's fields to records and
+// passes them to the configured handler, so loggers made before a
+// reconfiguration follow it
+type handler struct {
+	attrs []slog.Attr
+}
+
+func (h handler) Enabled(_ context.Context, l slog.Level) bool {
+	return l >= level.Level()
+}
+
+func (h handler) Handle(ctx context.Context, r slog.Record) error {
+	fields, _ := ctx.Value(fieldsKey{}).([]slog.Attr)
+	if len(h.attrs)+len(fields) > 0 {
+		r = r.Clone()
+		r.AddAttrs(h.attrs...)
+		r.AddAttrs(fields...)
+	}
+	return (*current.Load()).Handle(ctx, r)
+}
+
+func (h handler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return handler{attrs: append(append([]slog.Attr(nil), h.attrs...), attrs...)}
+}
+
+func (h handler) WithGroup(name string) slog.Handler {
+	return (*current.Load()).WithAttrs(h.attrs).WithGroup(name)
+}
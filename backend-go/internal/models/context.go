@@ -14,6 +14,21 @@ type Context struct {
 	Exclusions         []string          `json:"exclusions"`
 	CreatedAt          string            `json:"created_at"`
 	UpdatedAt          string            `json:"updated_at"`
+
+	// Answers to the relationship questions, rel_type and rel_keys; either
+	// file's context may hold them for the pair
+	RelationshipType string        `json:"relationship_type,omitempty"`
+	JoinKeys         []JoinKeyPair `json:"join_keys,omitempty"`
+
+	// Answers to the domain follow-up questions of the interview, e.g.
+	// coding_systems for healthcare data
+	DomainDetails map[string]string `json:"domain_details,omitempty"`
+}
+
+// JoinKeyPair is a file 1 and a file 2 column declared to join the datasets
+type JoinKeyPair struct {
+	File1Column string `json:"file1_column"`
+	File2Column string `json:"file2_column"`
 }
 
 // NewContext creates a new empty Context with initialized maps/slices
@@ -0,0 +1,103 @@
+package models
+
+// Mapping review statuses
+const (
+	MappingAccepted = "accepted"
+	MappingRejected = "rejected"
+)
+
+// Mapping sources
+const (
+	MappingSourceSuggested = "suggested" // Accepted or rejected from a similarity result
+	MappingSourceManual    = "manual"    // Created by the user
+)
+
+// MappingReview is the body of the accept, reject and manual mapping
+// endpoints
+type MappingReview struct {
+	File1Column    string  `json:"file1_column"`
+	File2Column    string  `json:"file2_column"`
+	Confidence     float64 `json:"confidence,omitempty"`
+	NameSimilarity float64 `json:"name_similarity,omitempty"`
+	DataSimilarity float64 `json:"data_similarity,omitempty"`
+	PatternScore   float64 `json:"pattern_score,omitempty"`
+	JoinKey        bool    `json:"join_key,omitempty"`
+	Transform      string  `json:"transform,omitempty"`
+	Note           string  `json:"note,omitempty"`
+
+	// Format normalization of the pair; suggested from the files when omitted
+	FormatTransform *ColumnTransform `json:"format_transform,omitempty"`
+}
+
+// ColumnMapping is one reviewed column correspondence
+type ColumnMapping struct {
+	File1Column string  `json:"file1_column"`
+	File2Column string  `json:"file2_column"`
+	Status      string  `json:"status"` // MappingAccepted or MappingRejected
+	Source      string  `json:"source"` // MappingSourceSuggested or MappingSourceManual
+	Confidence  float64 `json:"confidence"`
+	JoinKey     bool    `json:"join_key"`
+	Transform   string  `json:"transform,omitempty"` // Optional expression applied to file 1 values
+	Note        string  `json:"note,omitempty"`
+	UpdatedAt   string  `json:"updated_at"`
+
+	// Normalization that makes both columns' formats comparable when joining
+	FormatTransform *ColumnTransform `json:"format_transform,omitempty"`
+}
+
+// ApprovedMapping is the curated mapping document for one dataset pair
+type ApprovedMapping struct {
+	Scope     string          `json:"scope"`
+	File1Name string          `json:"file1_name,omitempty"`
+	File2Name string          `json:"file2_name,omitempty"`
+	Mappings  []ColumnMapping `json:"mappings"`
+	UpdatedAt string          `json:"updated_at"`
+}
+
+// Accepted returns the accepted mappings
+func (m *ApprovedMapping) Accepted() []ColumnMapping {
+	accepted := []ColumnMapping{}
+	if m == nil {
+		return accepted
+	}
+	for _, cm := range m.Mappings {
+		if cm.Status == MappingAccepted {
+			accepted = append(accepted, cm)
+		}
+	}
+	return accepted
+}
+
+// JoinKeys returns the accepted mappings flagged as join keys, or all
+// accepted mappings when none are flagged
+func (m *ApprovedMapping) JoinKeys() []ColumnMapping {
+	accepted := m.Accepted()
+	keys := []ColumnMapping{}
+	for _, cm := range accepted {
+		if cm.JoinKey {
+			keys = append(keys, cm)
+		}
+	}
+	if len(keys) == 0 {
+		return accepted
+	}
+	return keys
+}
+
+// IsRejected reports whether a column pair was rejected
+func (m *ApprovedMapping) IsRejected(file1Col, file2Col string) bool {
+	return m.StatusOf(file1Col, file2Col) == MappingRejected
+}
+
+// StatusOf returns the review status of a column pair, "" when unreviewed
+func (m *ApprovedMapping) StatusOf(file1Col, file2Col string) string {
+	if m == nil {
+		return ""
+	}
+	for _, cm := range m.Mappings {
+		if cm.File1Column == file1Col && cm.File2Column == file2Col {
+			return cm.Status
+		}
+	}
+	return ""
+}
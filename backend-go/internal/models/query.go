@@ -0,0 +1,77 @@
+package models
+
+import (
+	"backend-go/internal/llm"
+	"fmt"
+)
+
+// QueryRequest is a natural-language question about the loaded files
+type QueryRequest struct {
+	Question  string `json:"question"`
+	SessionID string `json:"session_id,omitempty"` // Continues a conversation; empty starts one
+	FileIndex int    `json:"file_index,omitempty"` // 1 or 2; defaults to the file(s) named in the question, then the previous turn's
+	Dataset   string `json:"dataset,omitempty"`    // "both" queries the files joined on the approved join keys
+}
+
+// QueryResponse is the answer to a question
+type QueryResponse struct {
+	Answer         string                   `json:"answer"`
+	Explanation    string                   `json:"explanation"`
+	RawResponse    string                   `json:"raw_response,omitempty"`
+	Result         string                   `json:"result,omitempty"`
+	ResultData     []map[string]interface{} `json:"result_data,omitempty"`
+	ResultType     string                   `json:"result_type,omitempty"`
+	Error          string                   `json:"error,omitempty"`
+	Mode           string                   `json:"mode"`           // "llm" (query plan) or "heuristic"
+	Plan           *QueryPlan               `json:"plan,omitempty"` // Executed plan, for transparency
+	FallbackReason string                   `json:"fallback_reason,omitempty"`
+	Truncation     *llm.TruncationReport    `json:"truncation,omitempty"` // How the planning prompt was fitted, in llm mode
+	SessionID      string                   `json:"session_id"`
+	FileIndex      int                      `json:"file_index"` // 0 when both files were queried
+	Dataset        string                   `json:"dataset"`    // "file1", "file2" or "both"
+}
+
+// QueryFilter keeps rows whose column compares to Value (or Values, for the
+// list and range operators of the filter endpoint)
+type QueryFilter struct {
+	Column   string   `json:"column"`
+	Operator string   `json:"operator"`
+	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"`
+}
+
+// QueryAggregation computes Function (count, distinct, sum, mean, min, max)
+// over Column; count may omit the column to count rows
+type QueryAggregation struct {
+	Column   string `json:"column,omitempty"`
+	Function string `json:"function"`
+}
+
+// Name is the result column of the aggregation, e.g. "sum(amount)"
+func (a QueryAggregation) Name() string {
+	col := a.Column
+	if col == "" {
+		col = "*"
+	}
+	return fmt.Sprintf("%s(%s)", a.Function, col)
+}
+
+// QueryOrder sorts the result by a result column
+type QueryOrder struct {
+	Column     string `json:"column"` // Group-by column or aggregation name
+	Descending bool   `json:"descending"`
+}
+
+// QueryPlan is a structured query: filter, group, aggregate, order, limit.
+// Without aggregations the filtered rows are returned (projected onto
+// Select when given).
+type QueryPlan struct {
+	Filters      []QueryFilter      `json:"filters"`
+	GroupBy      []string           `json:"group_by"`
+	TimeGrain    string             `json:"time_grain,omitempty"` // Buckets date group-by values
+	Aggregations []QueryAggregation `json:"aggregations"`
+	Select       []string           `json:"select,omitempty"`
+	OrderBy      *QueryOrder        `json:"order_by,omitempty"`
+	Limit        int                `json:"limit,omitempty"`
+	Explanation  string             `json:"explanation,omitempty"`
+}
@@ -10,16 +10,29 @@ const (
 	QuestionTypeRelationships   = "relationships"
 	QuestionTypeCustomMappings  = "custom_mappings"
 	QuestionTypeExclusions      = "exclusions"
+	QuestionTypeDomainDetail    = "domain_detail" // Interview follow-ups on the business domain
+)
+
+// Answer types, the shape an answer must have
+const (
+	AnswerTypeText        = "text"         // Non-empty string
+	AnswerTypeSelect      = "select"       // One of the options
+	AnswerTypeMultiSelect = "multi_select" // Array of options
+	AnswerTypeTags        = "tags"         // Array of free-form strings
+	AnswerTypeColumns     = "columns"      // Array of columns of the file
+	AnswerTypeColumnMap   = "column_map"   // Object of column of the file -> description
+	AnswerTypeJoinKeys    = "join_keys"    // Array of join keys: file1_col=file2_col or column names
 )
 
 // Question represents a context collection question
 type Question struct {
-	ID       string                 `json:"id"`
-	Type     string                 `json:"type"`
-	Text     string                 `json:"text"`
-	Options  []string               `json:"options"`
-	Required bool                   `json:"required"`
-	Metadata map[string]interface{} `json:"metadata"`
+	ID         string                 `json:"id"`
+	Type       string                 `json:"type"`
+	AnswerType string                 `json:"answer_type"`
+	Text       string                 `json:"text"`
+	Options    []string               `json:"options"`
+	Required   bool                   `json:"required"`
+	Metadata   map[string]interface{} `json:"metadata"`
 }
 
 // DataAnalysisResult holds analysis of a dataframe for question generation
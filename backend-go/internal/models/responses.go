@@ -2,10 +2,24 @@ package models
 
 // UploadResponse is returned after successful file upload
 type UploadResponse struct {
-	Message     string   `json:"message"`
-	Rows        int      `json:"rows"`
-	Columns     int      `json:"columns"`
-	ColumnNames []string `json:"column_names"`
+	Message      string     `json:"message"`
+	Rows         int        `json:"rows"`
+	Columns      int        `json:"columns"`
+	ColumnNames  []string   `json:"column_names"`
+	Encoding     string     `json:"encoding"` // Detected source encoding; content is stored as UTF-8
+	Dialect      CSVDialect `json:"dialect"`
+	Compression  string     `json:"compression,omitempty"`  // "gzip" or "zip" when the upload was compressed
+	Entry        string     `json:"entry,omitempty"`        // File extracted from a zip upload
+	ContentHash  string     `json:"content_hash,omitempty"` // SHA-256 of the uploaded bytes
+	Deduplicated bool       `json:"deduplicated,omitempty"` // Same content as an earlier upload, whose parse was reused
+}
+
+// CSVDialect describes how a CSV file is delimited and quoted
+type CSVDialect struct {
+	Delimiter string `json:"delimiter"`
+	Quote     string `json:"quote"`
+	HasHeader bool   `json:"has_header"`
+	Source    string `json:"source"` // "sniffed" or "override"
 }
 
 // FileStatus represents status of a loaded file
@@ -26,10 +40,14 @@ type StatusResponse struct {
 
 // KPI represents a key performance indicator
 type KPI struct {
-	Name  string  `json:"name"`
-	Value float64 `json:"value"`
-	Avg   float64 `json:"avg"`
-	Type  string  `json:"type"`
+	Name    string  `json:"name"`
+	Value   float64 `json:"value"`
+	Avg     float64 `json:"avg"`
+	Type    string  `json:"type"`
+	Label   string  `json:"label,omitempty"`
+	Format  string  `json:"format,omitempty"`
+	Display string  `json:"display,omitempty"` // Value rendered in the format
+	Error   string  `json:"error,omitempty"`   // Why the KPI couldn't be computed for this file
 }
 
 // ColumnSimilarity represents similarity between two columns
@@ -70,8 +88,9 @@ type ContextStatusItem struct {
 
 // OllamaConfig for /config/ollama endpoint
 type OllamaConfig struct {
-	BaseURL string `json:"baseUrl"`
-	Model   string `json:"model"`
+	BaseURL string              `json:"baseUrl"`
+	Model   string              `json:"model"`
+	Tasks   map[string][]string `json:"tasks,omitempty"` // Models per LLM task, in the order tried
 }
 
 // QuestionsResponse for /context/questions
@@ -79,6 +98,7 @@ type QuestionsResponse struct {
 	Success        bool                   `json:"success"`
 	Questions      map[string][]Question  `json:"questions"`
 	TotalQuestions int                    `json:"total_questions"`
+	Locale         string                 `json:"locale"`
 }
 
 // ContextSubmitRequest for /context/submit
@@ -89,18 +109,36 @@ type ContextSubmitRequest struct {
 
 // FilterCondition for /filter endpoint
 type FilterCondition struct {
-	Column   string `json:"column"`
-	Operator string `json:"operator"`
-	Value    string `json:"value"`
+	Column   string   `json:"column"`
+	Operator string   `json:"operator"`
+	Value    string   `json:"value"`
+	Values   []string `json:"values,omitempty"` // Lists for in / not_in; [low, high] for between
 }
 
-// FilterRequest for /filter endpoint
-type FilterRequest struct {
+// FilterGroup combines conditions and nested groups with "and" (default) or "or"
+type FilterGroup struct {
+	Logic      string            `json:"logic,omitempty"`
 	Conditions []FilterCondition `json:"conditions"`
+	Groups     []FilterGroup     `json:"groups,omitempty"`
+}
+
+// FilterRequest for /filter endpoint. Offset and limit, when limit is set,
+// take precedence over page and page_size.
+type FilterRequest struct {
+	FilterGroup
+	SortBy   string `json:"sort_by,omitempty"`
+	SortDir  string `json:"sort_dir,omitempty"` // "asc" (default) or "desc"
+	Page     int    `json:"page"`
+	PageSize int    `json:"page_size"`
+	Offset   int    `json:"offset,omitempty"`
+	Limit    int    `json:"limit,omitempty"`
 }
 
 // FilterResponse for /filter endpoint
 type FilterResponse struct {
-	Rows int                      `json:"rows"`
-	Data []map[string]interface{} `json:"data"`
+	Rows     int                      `json:"rows"` // Matching rows across all pages
+	Data     []map[string]interface{} `json:"data"`
+	Page     int                      `json:"page"`
+	PageSize int                      `json:"page_size"`
+	Offset   int                      `json:"offset"`
 }
@@ -6,6 +6,8 @@ type SimilarityGraph struct {
 	Similarities       []Similarity  `json:"similarities"`
 	TotalRelationships int           `json:"total_relationships"`
 	Correlations       []Correlation `json:"correlations"`
+
+	CompositeKeys []CompositeJoinKey `json:"composite_keys,omitempty"`
 }
 
 type Node struct {
@@ -20,6 +22,11 @@ type Edge struct {
 	Value      float64 `json:"value"`
 	Similarity float64 `json:"similarity"`
 	Type       string  `json:"type"`
+
+	// Inferred relationship of likely key matches
+	Cardinality string  `json:"cardinality,omitempty"` // 1:1, 1:N, N:1 or N:M (File 1 side first)
+	Direction   string  `json:"direction,omitempty"`   // file1->file2 when File 1 holds the foreign key, or file2->file1
+	FanOut      float64 `json:"fan_out,omitempty"`     // Joined rows per matched File 1 row
 }
 
 type Similarity struct {
@@ -34,6 +41,36 @@ type Similarity struct {
 	JSONConfidence         float64 `json:"json_confidence"` // Pattern score
 	LLMSemanticScore       float64 `json:"llm_semantic_score"`
 	Reason                 string  `json:"reason,omitempty"`
+
+	FormatTransform *ColumnTransform `json:"format_transform,omitempty"`
+}
+
+// ColumnTransform normalizes two columns that hold the same data in different
+// formats so their values compare equal. The expressions are examples over
+// the bare column names; exports render them for their dialect or engine.
+type ColumnTransform struct {
+	Kind        string  `json:"kind"`                   // date, phone, number, text or scale
+	File1Format string  `json:"file1_format,omitempty"` // strftime date format
+	File2Format string  `json:"file2_format,omitempty"`
+	Factor      float64 `json:"factor,omitempty"` // Scale: file 2 value = file 1 value × factor
+	Unit        string  `json:"unit,omitempty"`   // Scale: the unit conversion, when a known one
+	File1SQL    string  `json:"file1_sql"`        // Postgres expression over the file 1 column
+	File2SQL    string  `json:"file2_sql"`        // Postgres expression over the file 2 column
+	File1Python string  `json:"file1_python"`     // pandas expression over df1
+	File2Python string  `json:"file2_python"`     // pandas expression over df2
+}
+
+// CompositeJoinKey is a multi-column join key proposed for the two files.
+// Coverage is the share of a file's rows whose key value occurs in the other file.
+type CompositeJoinKey struct {
+	File1Columns    []string `json:"file1_columns"`
+	File2Columns    []string `json:"file2_columns"`
+	File1Uniqueness float64  `json:"file1_uniqueness"`
+	File2Uniqueness float64  `json:"file2_uniqueness"`
+	File1Coverage   float64  `json:"file1_coverage"`
+	File2Coverage   float64  `json:"file2_coverage"`
+	MatchedKeys     int      `json:"matched_keys"` // Distinct key values found in both files
+	Confidence      float64  `json:"confidence"`   // 0-100
 }
 
 type Correlation struct {
@@ -44,3 +81,17 @@ type Correlation struct {
 	Strength            string  `json:"strength"`
 	SampleSize          int     `json:"sample_size"`
 }
+
+// ColumnLineage records where one output column of a generated export comes from
+type ColumnLineage struct {
+	Column    string          `json:"column"`
+	Sources   []LineageSource `json:"sources"`
+	Transform string          `json:"transform,omitempty"` // Transform applied to the file 1 source
+	JoinKey   bool            `json:"join_key,omitempty"`
+}
+
+// LineageSource is one input column of an output column
+type LineageSource struct {
+	FileIndex int    `json:"file_index"`
+	Column    string `json:"column"`
+}
@@ -0,0 +1,177 @@
+// Package nulltoken holds the values treated as missing, globally and per
+// column, including user-defined tokens persisted to the data directory.
+package nulltoken
+
+import (
+	appconfig "backend-go/internal/config"
+	"backend-go/internal/logging"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+)
+
+var logger = logging.Component("null_tokens")
+
+const configFile = "null_tokens.json"
+
+// defaultTokens are the global tokens until configured; empty cells are always null
+var defaultTokens = []string{"null", "None"}
+
+// Config is the set of null tokens. Tokens match trimmed cells, ignoring case.
+type Config struct {
+	Global  []string            `json:"global"`
+	Columns map[string][]string `json:"columns"` // Column name -> extra tokens
+}
+
+var (
+	config     = Config{Global: defaultTokens, Columns: map[string][]string{}}
+	global     = toSet(defaultTokens)
+	columns    = map[string]map[string]bool{}
+	configOnce sync.Once
+	mutex      sync.RWMutex
+)
+
+// Defaults returns the built-in global tokens
+func Defaults() []string {
+	return append([]string(nil), defaultTokens...)
+}
+
+// Get returns the current configuration
+func Get() Config {
+	load()
+	mutex.RLock()
+	defer mutex.RUnlock()
+	cfg := Config{Global: append([]string(nil), config.Global...), Columns: make(map[string][]string, len(config.Columns))}
+	for c, tokens := range config.Columns {
+		cfg.Columns[c] = append([]string(nil), tokens...)
+	}
+	return cfg
+}
+
+// Set cleans and persists a configuration; a nil Global keeps the defaults
+func Set(cfg Config) (Config, error) {
+	if cfg.Global == nil {
+		cfg.Global = Defaults()
+	}
+	cleaned := Config{Global: clean(cfg.Global), Columns: map[string][]string{}}
+	for c, tokens := range cfg.Columns {
+		if tokens = clean(tokens); len(tokens) > 0 && strings.TrimSpace(c) != "" {
+			cleaned.Columns[c] = tokens
+		}
+	}
+
+	load()
+	apply(cleaned)
+	logger.Info("Saved null tokens", "global", len(cleaned.Global), "columns", len(cleaned.Columns))
+	return cleaned, save(cleaned)
+}
+
+// IsNull reports whether a cell of the named column is missing: empty, a
+// global token or one of the column's tokens
+func IsNull(column, value string) bool {
+	return ForColumn(column)(value)
+}
+
+// ForColumn returns a null check for the named column bound to the current
+// configuration, for scans over many cells
+func ForColumn(column string) func(string) bool {
+	load()
+	mutex.RLock()
+	g, c := global, columns[column]
+	mutex.RUnlock()
+	maxLen := 0
+	for _, set := range []map[string]bool{g, c} {
+		for t := range set {
+			maxLen = max(maxLen, len(t))
+		}
+	}
+	return func(value string) bool {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			return true
+		}
+		// Most cells are longer than any token; skip lowercasing them
+		if len(value) > maxLen {
+			return false
+		}
+		key := strings.ToLower(value)
+		return g[key] || c[key]
+	}
+}
+
+// clean trims and deduplicates tokens, dropping empty ones
+func clean(tokens []string) []string {
+	cleaned := []string{}
+	seen := make(map[string]bool)
+	for _, t := range tokens {
+		t = strings.TrimSpace(t)
+		if t == "" || seen[strings.ToLower(t)] {
+			continue
+		}
+		seen[strings.ToLower(t)] = true
+		cleaned = append(cleaned, t)
+	}
+	return cleaned
+}
+
+func toSet(tokens []string) map[string]bool {
+	set := make(map[string]bool, len(tokens))
+	for _, t := range tokens {
+		set[strings.ToLower(t)] = true
+	}
+	return set
+}
+
+// apply installs a cleaned configuration
+func apply(cfg Config) {
+	mutex.Lock()
+	defer mutex.Unlock()
+	config = cfg
+	global = toSet(cfg.Global)
+	columns = make(map[string]map[string]bool, len(cfg.Columns))
+	for c, tokens := range cfg.Columns {
+		columns[c] = toSet(tokens)
+	}
+}
+
+// load loads the configuration from file once
+func load() {
+	configOnce.Do(func() {
+		data, err := os.ReadFile(appconfig.DataPath(configFile))
+		if err != nil {
+			if !os.IsNotExist(err) {
+				logger.Error("Error loading null tokens", "error", err)
+			}
+			return
+		}
+
+		var saved Config
+		if err := json.Unmarshal(data, &saved); err != nil {
+			logger.Error("Error parsing null tokens", "error", err)
+			return
+		}
+		if saved.Global == nil {
+			saved.Global = Defaults()
+		}
+		if saved.Columns == nil {
+			saved.Columns = map[string][]string{}
+		}
+		apply(saved)
+		logger.Info("Loaded null tokens", "global", len(saved.Global), "columns", len(saved.Columns))
+	})
+}
+
+// save persists the configuration to file
+func save(cfg Config) error {
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(appconfig.DataPath(configFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(appconfig.DataPath(configFile), data, 0644)
+}
@@ -0,0 +1,180 @@
+// Package ratelimit throttles API clients with a token bucket per client.
+//
+// Three limiters are configured from the environment: IP, applied to every
+// API request by client address before its API key is checked, so keys
+// can't be guessed at full speed; General, applied to every API request by
+// client; and LLM, applied on top of it to the endpoints calling the
+// language model, so one client can't monopolize the Ollama backend.
+//
+//	IP_RATE_LIMIT_PER_MINUTE   Requests per minute and address (default 600)
+//	IP_RATE_LIMIT_BURST        Burst size per address (default 120)
+//	RATE_LIMIT_PER_MINUTE      General requests per minute (default 300)
+//	RATE_LIMIT_BURST           General burst size (default 60)
+//	LLM_RATE_LIMIT_PER_MINUTE  LLM-backed requests per minute (default 10)
+//	LLM_RATE_LIMIT_BURST       LLM-backed burst size (default 3)
+//
+// A rate of 0 disables a limiter.
+package ratelimit
+
+import (
+	"backend-go/internal/logging"
+	"math"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var logger = logging.Component("ratelimit")
+
+// Buckets untouched this long are full again and dropped
+const idleAfter = 10 * time.Minute
+
+// maxBuckets caps the buckets of a limiter. Once it is reached, clients
+// without a bucket share the overflow bucket until idle ones are dropped,
+// so a flood of client keys can't grow the map without bound.
+const (
+	maxBuckets  = 100000
+	overflowKey = "\x00overflow"
+)
+
+// Limiter holds a token bucket per client key
+type Limiter struct {
+	name      string
+	perSecond float64
+	burst     float64
+
+	mutex     sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	seen   time.Time
+}
+
+// Result is the outcome of one request against a limiter
+type Result struct {
+	Allowed    bool
+	Limit      int           // Burst size
+	Remaining  int           // Whole tokens left
+	RetryAfter time.Duration // Until a token is available, when not allowed
+}
+
+// New returns a limiter refilling perMinute tokens a minute up to burst. A
+// perMinute of 0 or less allows everything.
+func New(name string, perMinute float64, burst int) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &Limiter{
+		name:      name,
+		perSecond: perMinute / 60,
+		burst:     float64(burst),
+		buckets:   make(map[string]*bucket),
+	}
+}
+
+// Enabled reports whether the limiter limits anything
+func (l *Limiter) Enabled() bool {
+	return l != nil && l.perSecond > 0
+}
+
+// Allow takes a token from the client's bucket
+func (l *Limiter) Allow(key string) Result {
+	if !l.Enabled() {
+		return Result{Allowed: true}
+	}
+
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	now := time.Now()
+	full := len(l.buckets) >= maxBuckets
+	if now.Sub(l.lastSweep) > idleAfter || full && now.Sub(l.lastSweep) > time.Second {
+		for k, b := range l.buckets {
+			if now.Sub(b.seen) > idleAfter {
+				delete(l.buckets, k)
+			}
+		}
+		l.lastSweep = now
+		full = len(l.buckets) >= maxBuckets
+	}
+
+	b, ok := l.buckets[key]
+	if !ok && full {
+		key = overflowKey
+		b, ok = l.buckets[key]
+	}
+	if !ok {
+		b = &bucket{tokens: l.burst, seen: now}
+		l.buckets[key] = b
+	}
+	b.tokens = math.Min(l.burst, b.tokens+now.Sub(b.seen).Seconds()*l.perSecond)
+	b.seen = now
+
+	res := Result{Limit: int(l.burst)}
+	if b.tokens >= 1 {
+		b.tokens--
+		res.Allowed = true
+	} else {
+		res.RetryAfter = time.Duration((1 - b.tokens) / l.perSecond * float64(time.Second))
+	}
+	res.Remaining = int(b.tokens)
+	return res
+}
+
+// Name identifies the limiter in responses and logs
+func (l *Limiter) Name() string {
+	return l.name
+}
+
+var (
+	ip, general, llm *Limiter
+	loadOnce         sync.Once
+)
+
+// IP is the limiter of every API request by client address, checked before
+// authentication
+func IP() *Limiter {
+	load()
+	return ip
+}
+
+// General is the limiter of every API request
+func General() *Limiter {
+	load()
+	return general
+}
+
+// LLM is the limiter of requests calling the language model
+func LLM() *Limiter {
+	load()
+	return llm
+}
+
+func load() {
+	loadOnce.Do(func() {
+		ip = New("ip", envFloat("IP_RATE_LIMIT_PER_MINUTE", 600), int(envFloat("IP_RATE_LIMIT_BURST", 120)))
+		general = New("general", envFloat("RATE_LIMIT_PER_MINUTE", 300), int(envFloat("RATE_LIMIT_BURST", 60)))
+		llm = New("llm", envFloat("LLM_RATE_LIMIT_PER_MINUTE", 10), int(envFloat("LLM_RATE_LIMIT_BURST", 3)))
+		logger.Info("Rate limits per client",
+			"ip_per_minute", ip.perSecond*60, "ip_burst", ip.burst,
+			"per_minute", general.perSecond*60, "burst", general.burst,
+			"llm_per_minute", llm.perSecond*60, "llm_burst", llm.burst)
+	})
+}
+
+func envFloat(name string, def float64) float64 {
+	raw := os.Getenv(name)
+	if raw == "" {
+		return def
+	}
+	v, err := strconv.ParseFloat(raw, 64)
+	if err != nil || v < 0 {
+		logger.Warn("Ignoring invalid setting", "name", name, "value", raw)
+		return def
+	}
+	return v
+}
@@ -0,0 +1,123 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"time"
+)
+
+// maxRedirects caps the redirects followed by a download
+const maxRedirects = 5
+
+// ErrUnavailable is returned, wrapping the cause, when a remote file can't
+// be fetched. The cause may name internal hosts and addresses, so callers
+// should log it rather than return it.
+var ErrUnavailable = errors.New("remote file could not be downloaded")
+
+// errBlockedAddress refuses hosts resolving to addresses of the server's
+// own networks
+var errBlockedAddress = errors.New("host resolves to a loopback, private or link-local address")
+
+// sharedAddressSpace is the carrier-grade NAT range (RFC 6598), internal to
+// the provider's network like the private ranges
+var sharedAddressSpace = netip.MustParsePrefix("100.64.0.0/10")
+
+// publicAddress reports whether ip may be dialed for a user-supplied URI:
+// not loopback, private (including IPv6 unique-local), link-local (cloud
+// metadata services live there), multicast or unspecified
+func publicAddress(ip netip.Addr) bool {
+	ip = ip.Unmap()
+	return ip.IsValid() && !ip.IsLoopback() && !ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() && !ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() && !ip.IsMulticast() &&
+		!ip.IsUnspecified() && !sharedAddressSpace.Contains(ip)
+}
+
+// guardedDialContext resolves the host itself and dials the first public
+// address, so the address checked is the one connected to; a second
+// lookup by the dialer could return another one (DNS rebinding)
+func guardedDialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		host, port, err := net.SplitHostPort(addr)
+		if err != nil {
+			return nil, err
+		}
+		addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+		if err != nil {
+			return nil, err
+		}
+		for _, ip := range addrs {
+			if publicAddress(ip) {
+				return dialer.DialContext(ctx, network, net.JoinHostPort(ip.Unmap().String(), port))
+			}
+		}
+		return nil, fmt.Errorf("%s: %w", host, errBlockedAddress)
+	}
+}
+
+// CheckHost refuses the host of a user-supplied URL when it resolves to no
+// public address. Requests through NewGuardedClient are checked again when
+// dialing, as the answer may have changed since.
+func CheckHost(ctx context.Context, host string) error {
+	addrs, err := net.DefaultResolver.LookupNetIP(ctx, "ip", host)
+	if err != nil {
+		return err
+	}
+	for _, ip := range addrs {
+		if publicAddress(ip) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%s: %w", host, errBlockedAddress)
+}
+
+// checkRedirect follows at most maxRedirects https redirects; each hop is
+// dialed through the guard again
+func checkRedirect(req *http.Request, via []*http.Request) error {
+	if len(via) >= maxRedirects {
+		return fmt.Errorf("stopped after %d redirects", maxRedirects)
+	}
+	if req.URL.Scheme != SchemeHTTPS {
+		return fmt.Errorf("redirect to a %s URL refused", req.URL.Scheme)
+	}
+	return nil
+}
+
+// newClient returns a client for downloads. Without a proxy: one would
+// connect on the client's behalf to hosts the guard never sees. Only the
+// client of a server-configured S3 endpoint, which may well live on a
+// private network, dials without the guard.
+func newClient(guarded bool) *http.Client {
+	dialer := &net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second}
+	transport := &http.Transport{
+		DialContext:           dialer.DialContext,
+		ResponseHeaderTimeout: 30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+	}
+	if guarded {
+		transport.DialContext = guardedDialContext(dialer)
+	}
+	return &http.Client{Transport: transport, CheckRedirect: checkRedirect}
+}
+
+// NewGuardedClient returns a client for user-supplied http and https URLs
+// other than downloads, such as webhooks. Every hop, redirects included, is
+// dialed through the guard.
+func NewGuardedClient(timeout time.Duration) *http.Client {
+	c := newClient(true)
+	c.Timeout = timeout
+	c.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if len(via) >= maxRedirects {
+			return fmt.Errorf("stopped after %d redirects", maxRedirects)
+		}
+		if req.URL.Scheme != "http" && req.URL.Scheme != SchemeHTTPS {
+			return fmt.Errorf("redirect to a %s URL refused", req.URL.Scheme)
+		}
+		return nil
+	}
+	return c
+}
@@ -0,0 +1,207 @@
+// Package remote streams files from https, s3:// and gs:// URIs and lists
+// s3:// prefixes. Credentials
+// are never passed in requests; a credentials reference names a set of
+// environment variables on the server (see Credentials).
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Supported URI schemes
+const (
+	SchemeHTTPS = "https"
+	SchemeS3    = "s3"
+	SchemeGCS   = "gs"
+)
+
+var refPattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// client has no overall timeout since downloads can take a long time; the
+// request context cancels them instead. It dials public addresses only;
+// endpointClient serves the S3 endpoints configured on the server.
+var (
+	client         = newClient(true)
+	endpointClient = newClient(false)
+)
+
+// Credentials are resolved from environment variables. With an empty
+// reference the provider defaults are used (AWS_ACCESS_KEY_ID,
+// AWS_SECRET_ACCESS_KEY, AWS_SESSION_TOKEN, AWS_REGION, and
+// GOOGLE_OAUTH_ACCESS_TOKEN); with reference REF the variables are
+// REF_ACCESS_KEY_ID, REF_SECRET_ACCESS_KEY, REF_SESSION_TOKEN, REF_REGION,
+// REF_ENDPOINT (S3-compatible stores) and REF_TOKEN (bearer token for https
+// and gs).
+type Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+	Region          string
+	Endpoint        string
+	Token           string
+}
+
+// ResolveCredentials looks up the environment variables for a reference
+func ResolveCredentials(ref string) (Credentials, error) {
+	if ref == "" {
+		return Credentials{
+			AccessKeyID:     os.Getenv("AWS_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("AWS_SECRET_ACCESS_KEY"),
+			SessionToken:    os.Getenv("AWS_SESSION_TOKEN"),
+			Region:          os.Getenv("AWS_REGION"),
+			Token:           os.Getenv("GOOGLE_OAUTH_ACCESS_TOKEN"),
+		}, nil
+	}
+	if !refPattern.MatchString(ref) {
+		return Credentials{}, fmt.Errorf("invalid credentials reference %q", ref)
+	}
+
+	prefix := strings.ToUpper(ref) + "_"
+	creds := Credentials{
+		AccessKeyID:     os.Getenv(prefix + "ACCESS_KEY_ID"),
+		SecretAccessKey: os.Getenv(prefix + "SECRET_ACCESS_KEY"),
+		SessionToken:    os.Getenv(prefix + "SESSION_TOKEN"),
+		Region:          os.Getenv(prefix + "REGION"),
+		Endpoint:        os.Getenv(prefix + "ENDPOINT"),
+		Token:           os.Getenv(prefix + "TOKEN"),
+	}
+	if creds.AccessKeyID == "" && creds.Token == "" {
+		return Credentials{}, fmt.Errorf("credentials reference %q is not configured", ref)
+	}
+	return creds, nil
+}
+
+// Object is an open remote file
+type Object struct {
+	Body io.ReadCloser
+	Name string // Base name of the object, used as the file name
+	Size int64  // Content length, or -1 when unknown
+}
+
+// Open starts streaming the object at uri. The caller must close Body.
+func Open(ctx context.Context, uri, credentialsRef string) (*Object, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI: %v", err)
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URI must include a host or bucket")
+	}
+
+	creds, err := ResolveCredentials(credentialsRef)
+	if err != nil {
+		return nil, err
+	}
+
+	var req *http.Request
+	switch u.Scheme {
+	case SchemeHTTPS:
+		req, err = http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+		if err == nil && credentialsRef != "" && creds.Token != "" {
+			req.Header.Set("Authorization", "Bearer "+creds.Token)
+		}
+	case SchemeS3:
+		req, err = newS3Request(ctx, u.Host, strings.TrimPrefix(u.Path, "/"), creds)
+	case SchemeGCS:
+		req, err = newGCSRequest(ctx, u.Host, strings.TrimPrefix(u.Path, "/"), creds)
+	default:
+		return nil, fmt.Errorf("unsupported scheme %q (use https, s3 or gs)", u.Scheme)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := clientFor(u.Scheme, creds).Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		resp.Body.Close()
+		return nil, fmt.Errorf("%w: %s", ErrUnavailable, resp.Status)
+	}
+
+	name := path.Base(u.Path)
+	if name == "/" || name == "." {
+		name = "download.csv"
+	}
+	return &Object{Body: resp.Body, Name: name, Size: resp.ContentLength}, nil
+}
+
+// clientFor picks the client of a request: the S3 endpoint configured with
+// the credentials is trusted, anything named by the URI alone is not
+func clientFor(scheme string, creds Credentials) *http.Client {
+	if scheme == SchemeS3 && creds.Endpoint != "" {
+		return endpointClient
+	}
+	return client
+}
+
+// ObjectInfo describes a listed remote file
+type ObjectInfo struct {
+	URI        string    `json:"uri"`
+	Name       string    `json:"name"`
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Version    string    `json:"version,omitempty"` // ETag; changes with the content
+}
+
+// List lists the objects under an s3://bucket/prefix URI
+func List(ctx context.Context, uri, credentialsRef string) ([]ObjectInfo, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return nil, fmt.Errorf("invalid URI: %v", err)
+	}
+	if u.Scheme != SchemeS3 {
+		return nil, fmt.Errorf("listing supports s3 URIs only")
+	}
+	if u.Host == "" {
+		return nil, fmt.Errorf("URI must include a bucket")
+	}
+	creds, err := ResolveCredentials(credentialsRef)
+	if err != nil {
+		return nil, err
+	}
+	return listS3(ctx, u.Host, strings.TrimPrefix(u.Path, "/"), creds)
+}
+
+// newGCSRequest builds a GET against the GCS XML API, authenticated with a
+// bearer token when one is configured (public objects need none)
+func newGCSRequest(ctx context.Context, bucket, object string, creds Credentials) (*http.Request, error) {
+	if object == "" {
+		return nil, fmt.Errorf("gs URI must include an object path")
+	}
+	endpoint := "https://storage.googleapis.com/" + bucket + "/" + escapePath(object)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if creds.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+creds.Token)
+	}
+	return req, nil
+}
+
+// escapePath percent-encodes an object key the way SigV4 canonicalizes it:
+// everything except unreserved characters and slashes
+func escapePath(key string) string {
+	var b strings.Builder
+	for i := 0; i < len(key); i++ {
+		c := key[i]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' ||
+			c == '-' || c == '_' || c == '.' || c == '~' || c == '/' {
+			b.WriteByte(c)
+		} else {
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,201 @@
+package remote
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+const defaultS3Region = "us-east-1"
+
+// newS3Request builds a GET for an S3 object, signed with AWS Signature
+// Version 4 when an access key is configured (public objects need none).
+// With a custom endpoint (S3-compatible stores) path-style addressing is used.
+func newS3Request(ctx context.Context, bucket, key string, creds Credentials) (*http.Request, error) {
+	if key == "" {
+		return nil, fmt.Errorf("s3 URI must include an object key")
+	}
+	return newS3BucketRequest(ctx, bucket, escapePath(key), "", creds)
+}
+
+// newS3BucketRequest builds a signed GET of an escaped key, or of the
+// bucket itself when key is empty, with a canonical (sorted and escaped)
+// query
+func newS3BucketRequest(ctx context.Context, bucket, key, query string, creds Credentials) (*http.Request, error) {
+	region := creds.Region
+	if region == "" {
+		region = defaultS3Region
+	}
+
+	var endpoint string
+	if creds.Endpoint != "" {
+		base, err := url.Parse(creds.Endpoint)
+		if err != nil || base.Scheme != "https" || base.Host == "" {
+			return nil, fmt.Errorf("S3 endpoint must be an https URL")
+		}
+		endpoint = "https://" + base.Host + "/" + bucket
+		if key != "" {
+			endpoint += "/" + key
+		}
+	} else {
+		endpoint = fmt.Sprintf("https://%s.s3.%s.amazonaws.com/%s", bucket, region, key)
+	}
+	if query != "" {
+		endpoint += "?" + query
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return nil, err
+	}
+	if creds.AccessKeyID != "" {
+		signV4(req, creds, region, unsignedPayload, time.Now().UTC())
+	}
+	return req, nil
+}
+
+// s3ListPage is the part of a ListObjectsV2 response that is read
+type s3ListPage struct {
+	Contents []struct {
+		Key          string    `xml:"Key"`
+		LastModified time.Time `xml:"LastModified"`
+		ETag         string    `xml:"ETag"`
+		Size         int64     `xml:"Size"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// listS3 lists the objects of a bucket under a prefix with ListObjectsV2,
+// following continuation tokens
+func listS3(ctx context.Context, bucket, prefix string, creds Credentials) ([]ObjectInfo, error) {
+	objects := []ObjectInfo{}
+	token := ""
+	for {
+		params := url.Values{"list-type": {"2"}, "prefix": {prefix}}
+		if token != "" {
+			params.Set("continuation-token", token)
+		}
+		// SigV4 wants spaces as %20; Encode already sorts the keys
+		query := strings.ReplaceAll(params.Encode(), "+", "%20")
+		req, err := newS3BucketRequest(ctx, bucket, "", query, creds)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := clientFor(SchemeS3, creds).Do(req)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+		}
+		var page s3ListPage
+		if resp.StatusCode != http.StatusOK {
+			resp.Body.Close()
+			return nil, fmt.Errorf("%w: %s", ErrUnavailable, resp.Status)
+		}
+		err = xml.NewDecoder(resp.Body).Decode(&page)
+		resp.Body.Close()
+		if err != nil {
+			return nil, fmt.Errorf("invalid listing: %v", err)
+		}
+
+		for _, c := range page.Contents {
+			if strings.HasSuffix(c.Key, "/") {
+				continue // Folder placeholder
+			}
+			objects = append(objects, ObjectInfo{
+				URI:        "s3://" + bucket + "/" + c.Key,
+				Name:       path.Base(c.Key),
+				Size:       c.Size,
+				ModifiedAt: c.LastModified,
+				Version:    strings.Trim(c.ETag, `"`),
+			})
+		}
+		if !page.IsTruncated || page.NextContinuationToken == "" {
+			return objects, nil
+		}
+		token = page.NextContinuationToken
+	}
+}
+
+// unsignedPayload is sent as the payload hash of GETs, which have no body
+const unsignedPayload = "UNSIGNED-PAYLOAD"
+
+// signV4 adds SigV4 headers for an S3 GET with the given payload hash
+func signV4(req *http.Request, creds Credentials, region, payloadHash string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	if creds.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", creds.SessionToken)
+	}
+
+	// Canonical headers must be sorted by lowercase name
+	headers := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	values := map[string]string{
+		"host":                 req.URL.Host,
+		"x-amz-content-sha256": payloadHash,
+		"x-amz-date":           amzDate,
+	}
+	if creds.SessionToken != "" {
+		headers = append(headers, "x-amz-security-token")
+		values["x-amz-security-token"] = creds.SessionToken
+	}
+	var canonicalHeaders strings.Builder
+	for _, h := range headers {
+		canonicalHeaders.WriteString(h + ":" + values[h] + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		req.URL.RawQuery,
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		sha256Hex(canonicalRequest),
+	}, "\n")
+
+	key := signingKey(creds.SecretAccessKey, date, region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		creds.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// signingKey derives the SigV4 key of a secret for one day, region and
+// service
+func signingKey(secret, date, region, service string) []byte {
+	key := hmacSHA256([]byte("AWS4"+secret), date)
+	key = hmacSHA256(key, region)
+	key = hmacSHA256(key, service)
+	return hmacSHA256(key, "aws4_request")
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func sha256Hex(data string) string {
+	sum := sha256.Sum256([]byte(data))
+	return hex.EncodeToString(sum[:])
+}
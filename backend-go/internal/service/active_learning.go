@@ -0,0 +1,103 @@
+package service
+
+import (
+	"math"
+	"sort"
+)
+
+// LabelSuggestion is a column pair the user should label next
+type LabelSuggestion struct {
+	File1Column      string             `json:"file1_column"`
+	File2Column      string             `json:"file2_column"`
+	Confidence       float64            `json:"confidence"`
+	NameSimilarity   float64            `json:"name_similarity"`
+	DataSimilarity   float64            `json:"data_similarity"`
+	PatternScore     float64            `json:"pattern_score"`
+	Interval         ConfidenceInterval `json:"interval"`
+	ThresholdMargin  float64            `json:"threshold_margin"`
+	UncertaintyScore float64            `json:"uncertainty_score"`
+	Reason           string             `json:"reason"`
+}
+
+// ActiveLearningSelector picks the most informative pairs for user feedback
+type ActiveLearningSelector struct {
+	probabilistic *ProbabilisticMatcher
+	threshold     float64
+}
+
+// NewActiveLearningSelector creates a selector around the given decision threshold (0-100)
+func NewActiveLearningSelector(threshold float64) *ActiveLearningSelector {
+	if threshold <= 0 || threshold >= 100 {
+		threshold = 50
+	}
+	return &ActiveLearningSelector{
+		probabilistic: NewProbabilisticMatcher(),
+		threshold:     threshold,
+	}
+}
+
+// SelectForLabeling ranks results by uncertainty and returns the top n pairs
+// that have no feedback yet in the given dataset pair scope
+func (als *ActiveLearningSelector) SelectForLabeling(scope string, results []SimilarityResult, n int) []LabelSuggestion {
+	feedbackSystem := GetFeedbackSystem()
+	calibrator := GetConfidenceCalibrator()
+	buckets := calibrator.GetBuckets()
+
+	suggestions := []LabelSuggestion{}
+	for _, r := range results {
+		// Pairs the user already judged carry no new information
+		if feedbackSystem.HasFeedback(scope, r.File1Column, r.File2Column) {
+			continue
+		}
+
+		// 1. Distance from the decision threshold (1 = right on it)
+		margin := math.Abs(r.Confidence-als.threshold) / math.Max(als.threshold, 100-als.threshold)
+		closeness := math.Max(0, 1-margin)
+
+		// 2. Width of the Bayesian interval for this confidence bucket
+		bucketIdx := int(r.Confidence / 10)
+		if bucketIdx >= len(buckets) {
+			bucketIdx = len(buckets) - 1
+		}
+		if bucketIdx < 0 {
+			bucketIdx = 0
+		}
+		bucket := buckets[bucketIdx]
+		interval := als.probabilistic.BayesianConfidence(bucket.CorrectCount, bucket.TotalCount)
+		width := interval.Upper - interval.Lower
+		if bucket.TotalCount == 0 {
+			// No evidence at all for this confidence range
+			interval = ConfidenceInterval{Lower: 0, Upper: 1, Mean: 0.5, Confidence: 0.95}
+			width = 1
+		}
+
+		score := (closeness * 0.6) + (width * 0.4)
+
+		reason := "confidence close to decision threshold"
+		if width > closeness {
+			reason = "little feedback for this confidence range"
+		}
+
+		suggestions = append(suggestions, LabelSuggestion{
+			File1Column:      r.File1Column,
+			File2Column:      r.File2Column,
+			Confidence:       r.Confidence,
+			NameSimilarity:   r.NameSimilarity,
+			DataSimilarity:   r.DataSimilarity,
+			PatternScore:     r.JSONConfidence,
+			Interval:         interval,
+			ThresholdMargin:  r.Confidence - als.threshold,
+			UncertaintyScore: score,
+			Reason:           reason,
+		})
+	}
+
+	sort.Slice(suggestions, func(i, j int) bool {
+		return suggestions[i].UncertaintyScore > suggestions[j].UncertaintyScore
+	})
+
+	if n > 0 && len(suggestions) > n {
+		suggestions = suggestions[:n]
+	}
+	return suggestions
+}
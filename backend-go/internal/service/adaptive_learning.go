@@ -1,16 +1,21 @@
 package service
 
 import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
 	"encoding/json"
-	"log"
+	"fmt"
 	"math"
 	"os"
 	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 )
 
-const adaptiveWeightsFile = "./data/adaptive_weights.json"
+var adaptiveLog = logging.Component("adaptive_learner")
+
+const adaptiveWeightsFile = "adaptive_weights.json"
 
 // AdaptiveWeights represents the learned weights for different similarity factors
 type AdaptiveWeights struct {
@@ -34,6 +39,11 @@ type AdaptiveWeightLearner struct {
 	learningRate    float64
 	trainingHistory []TrainingHistoryEntry
 	mutex           sync.RWMutex
+
+	// Rebuild starts from base and replays feedback recorded after baseSince.
+	// base is the default weights until they are set by hand, reset or imported.
+	base      AdaptiveWeights
+	baseSince time.Time
 }
 
 var (
@@ -41,18 +51,22 @@ var (
 	adaptiveLearnerOnce sync.Once
 )
 
+// DefaultAdaptiveWeights are the weights used before any training
+var DefaultAdaptiveWeights = AdaptiveWeights{
+	Name:    0.35,
+	Data:    0.30,
+	Pattern: 0.20,
+	LLM:     0.15,
+}
+
 // GetAdaptiveLearner returns the singleton adaptive learner
 func GetAdaptiveLearner() *AdaptiveWeightLearner {
 	adaptiveLearnerOnce.Do(func() {
 		adaptiveLearner = &AdaptiveWeightLearner{
-			weights: AdaptiveWeights{
-				Name:    0.35, // Default weights
-				Data:    0.30,
-				Pattern: 0.20,
-				LLM:     0.15,
-			},
+			weights:         DefaultAdaptiveWeights,
 			learningRate:    0.01,
 			trainingHistory: []TrainingHistoryEntry{},
+			base:            DefaultAdaptiveWeights,
 		}
 		adaptiveLearner.load()
 	})
@@ -61,33 +75,39 @@ func GetAdaptiveLearner() *AdaptiveWeightLearner {
 
 // load loads weights from file
 func (a *AdaptiveWeightLearner) load() {
-	dir := filepath.Dir(adaptiveWeightsFile)
+	dir := filepath.Dir(config.DataPath(adaptiveWeightsFile))
 	os.MkdirAll(dir, 0755)
 
-	data, err := os.ReadFile(adaptiveWeightsFile)
+	data, err := os.ReadFile(config.DataPath(adaptiveWeightsFile))
 	if err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("[AdaptiveLearner] Error loading weights: %v", err)
+			adaptiveLog.Error("Error loading weights", "error", err)
 		}
 		return
 	}
 
 	var saved struct {
-		Weights  AdaptiveWeights        `json:"weights"`
-		History  []TrainingHistoryEntry `json:"history"`
+		Weights   AdaptiveWeights        `json:"weights"`
+		History   []TrainingHistoryEntry `json:"history"`
+		Base      *AdaptiveWeights       `json:"base"`
+		BaseSince time.Time              `json:"base_since"`
 	}
 	if err := json.Unmarshal(data, &saved); err != nil {
-		log.Printf("[AdaptiveLearner] Error parsing weights: %v", err)
+		adaptiveLog.Error("Error parsing weights", "error", err)
 		return
 	}
 
 	a.mutex.Lock()
 	a.weights = saved.Weights
 	a.trainingHistory = saved.History
+	if saved.Base != nil {
+		a.base = *saved.Base
+		a.baseSince = saved.BaseSince
+	}
 	a.mutex.Unlock()
 
-	log.Printf("[AdaptiveLearner] Loaded weights: Name=%.2f, Data=%.2f, Pattern=%.2f, LLM=%.2f",
-		a.weights.Name, a.weights.Data, a.weights.Pattern, a.weights.LLM)
+	adaptiveLog.Info("Loaded weights",
+		"name", a.weights.Name, "data", a.weights.Data, "pattern", a.weights.Pattern, "llm", a.weights.LLM)
 }
 
 // save persists weights to file
@@ -95,7 +115,9 @@ func (a *AdaptiveWeightLearner) save() error {
 	a.mutex.RLock()
 	data, err := json.MarshalIndent(map[string]interface{}{
 		"weights": a.weights,
-		"history": a.trainingHistory,
+		"history":    a.trainingHistory,
+		"base":       a.base,
+		"base_since": a.baseSince,
 	}, "", "  ")
 	a.mutex.RUnlock()
 
@@ -103,10 +125,10 @@ func (a *AdaptiveWeightLearner) save() error {
 		return err
 	}
 
-	dir := filepath.Dir(adaptiveWeightsFile)
+	dir := filepath.Dir(config.DataPath(adaptiveWeightsFile))
 	os.MkdirAll(dir, 0755)
 
-	return os.WriteFile(adaptiveWeightsFile, data, 0644)
+	return os.WriteFile(config.DataPath(adaptiveWeightsFile), data, 0644)
 }
 
 // GetWeights returns the current weights
@@ -134,6 +156,17 @@ func (a *AdaptiveWeightLearner) UpdateWeights(feedbackBatch []FeedbackEntry) {
 	a.mutex.Lock()
 	defer a.mutex.Unlock()
 
+	avgLoss := a.gradientStep(feedbackBatch, time.Now())
+
+	// Save updated weights
+	go a.save()
+
+	adaptiveLog.Info("Weights updated",
+		"name", a.weights.Name, "data", a.weights.Data, "pattern", a.weights.Pattern, "llm", a.weights.LLM, "loss", avgLoss)
+}
+
+// gradientStep applies one gradient descent update and records it (must hold lock)
+func (a *AdaptiveWeightLearner) gradientStep(feedbackBatch []FeedbackEntry, at time.Time) float64 {
 	// Accumulate gradients
 	gradients := AdaptiveWeights{}
 	totalLoss := 0.0
@@ -189,7 +222,7 @@ func (a *AdaptiveWeightLearner) UpdateWeights(feedbackBatch []FeedbackEntry) {
 	// Record training history
 	avgLoss := totalLoss / n
 	a.trainingHistory = append(a.trainingHistory, TrainingHistoryEntry{
-		Timestamp: time.Now(),
+		Timestamp: at,
 		Loss:      avgLoss,
 		Weights:   a.weights,
 		BatchSize: len(feedbackBatch),
@@ -200,11 +233,118 @@ func (a *AdaptiveWeightLearner) UpdateWeights(feedbackBatch []FeedbackEntry) {
 		a.trainingHistory = a.trainingHistory[len(a.trainingHistory)-100:]
 	}
 
-	// Save updated weights
-	go a.save()
+	return avgLoss
+}
+
+// Rebuild returns to the base weights and replays training over the feedback
+// recorded since they were set, using the same sliding 10-entry batches as
+// live feedback. Weights set by hand or imported are kept as the starting point.
+func (a *AdaptiveWeightLearner) Rebuild(entries []FeedbackEntry) {
+	a.mutex.Lock()
+	a.weights = a.base
+	history := []TrainingHistoryEntry{}
+	for _, h := range a.trainingHistory {
+		if !h.Timestamp.After(a.baseSince) {
+			history = append(history, h)
+		}
+	}
+	a.trainingHistory = history
+	for i := 9; i < len(entries); i++ {
+		if entries[i].Timestamp.After(a.baseSince) {
+			a.gradientStep(entries[i-9:i+1], entries[i].Timestamp)
+		}
+	}
+	a.mutex.Unlock()
+
+	if err := a.save(); err != nil {
+		adaptiveLog.Error("Error saving weights", "error", err)
+	}
+}
+
+// SetWeights overrides the learned weights. Weights must be non-negative and
+// sum to 1 (within a small tolerance); they are renormalized exactly on save.
+func (a *AdaptiveWeightLearner) SetWeights(w AdaptiveWeights) error {
+	if w.Name < 0 || w.Data < 0 || w.Pattern < 0 || w.LLM < 0 {
+		return fmt.Errorf("weights must be non-negative")
+	}
+	sum := w.Name + w.Data + w.Pattern + w.LLM
+	if math.Abs(sum-1.0) > 0.01 {
+		return fmt.Errorf("weights must sum to 1.0 (got %.3f)", sum)
+	}
+	w.Name /= sum
+	w.Data /= sum
+	w.Pattern /= sum
+	w.LLM /= sum
+
+	a.mutex.Lock()
+	a.weights = w
+	a.base, a.baseSince = w, time.Now()
+	a.mutex.Unlock()
+
+	adaptiveLog.Info("Weights set manually",
+		"name", w.Name, "data", w.Data, "pattern", w.Pattern, "llm", w.LLM)
+	return a.save()
+}
+
+// Reset restores the default weights and clears training history
+func (a *AdaptiveWeightLearner) Reset() error {
+	a.mutex.Lock()
+	a.weights = DefaultAdaptiveWeights
+	a.trainingHistory = []TrainingHistoryEntry{}
+	a.base, a.baseSince = DefaultAdaptiveWeights, time.Now()
+	a.mutex.Unlock()
 
-	log.Printf("[AdaptiveLearner] Weights updated: Name=%.3f, Data=%.3f, Pattern=%.3f, LLM=%.3f (Loss=%.4f)",
-		a.weights.Name, a.weights.Data, a.weights.Pattern, a.weights.LLM, avgLoss)
+	adaptiveLog.Info("Weights reset to defaults")
+	return a.save()
+}
+
+// ConvergenceStats summarizes how the training loss has evolved
+type ConvergenceStats struct {
+	Updates        int     `json:"updates"`
+	FirstLoss      float64 `json:"first_loss"`
+	LastLoss       float64 `json:"last_loss"`
+	EarlyAvgLoss   float64 `json:"early_avg_loss"`  // Mean loss over the oldest window
+	RecentAvgLoss  float64 `json:"recent_avg_loss"` // Mean loss over the newest window
+	MaxWeightDelta float64 `json:"max_weight_delta"` // Largest single-weight change across the newest window
+	Converging     bool    `json:"converging"`
+}
+
+// GetConvergenceStats compares early and recent losses in the training history
+func (a *AdaptiveWeightLearner) GetConvergenceStats() ConvergenceStats {
+	a.mutex.RLock()
+	defer a.mutex.RUnlock()
+
+	h := a.trainingHistory
+	stats := ConvergenceStats{Updates: len(h)}
+	if len(h) == 0 {
+		return stats
+	}
+
+	stats.FirstLoss = h[0].Loss
+	stats.LastLoss = h[len(h)-1].Loss
+
+	window := 10
+	if len(h) < 2*window {
+		window = (len(h) + 1) / 2
+	}
+	for _, e := range h[:window] {
+		stats.EarlyAvgLoss += e.Loss
+	}
+	stats.EarlyAvgLoss /= float64(window)
+	recent := h[len(h)-window:]
+	for _, e := range recent {
+		stats.RecentAvgLoss += e.Loss
+	}
+	stats.RecentAvgLoss /= float64(window)
+
+	first, last := recent[0].Weights, recent[len(recent)-1].Weights
+	stats.MaxWeightDelta = math.Max(
+		math.Max(math.Abs(last.Name-first.Name), math.Abs(last.Data-first.Data)),
+		math.Max(math.Abs(last.Pattern-first.Pattern), math.Abs(last.LLM-first.LLM)))
+
+	// Loss going down and weights settling
+	stats.Converging = len(h) >= 2 && stats.RecentAvgLoss <= stats.EarlyAvgLoss && stats.MaxWeightDelta < 0.05
+	return stats
 }
 
 // GetTrainingHistory returns recent training history
@@ -213,3 +353,56 @@ func (a *AdaptiveWeightLearner) GetTrainingHistory() []TrainingHistoryEntry {
 	defer a.mutex.RUnlock()
 	return a.trainingHistory
 }
+
+// Merge combines imported weights with the local weights. Invalid weights
+// (negative or all zero) are rejected without changing anything.
+func (a *AdaptiveWeightLearner) Merge(weights AdaptiveWeights, history []TrainingHistoryEntry, strategy string) error {
+	weights, err := NormalizeWeights(weights)
+	if err != nil {
+		return err
+	}
+	if strategy == MergeStrategyKeepLocal {
+		return nil
+	}
+
+	a.mutex.Lock()
+	if strategy == MergeStrategyReplace {
+		a.weights = weights
+		a.trainingHistory = append([]TrainingHistoryEntry{}, history...)
+	} else {
+		// Average the two weight vectors, trusting each side by how much it has trained
+		localN := float64(len(a.trainingHistory) + 1)
+		remoteN := float64(len(history) + 1)
+		total := localN + remoteN
+		a.weights = AdaptiveWeights{
+			Name:    (a.weights.Name*localN + weights.Name*remoteN) / total,
+			Data:    (a.weights.Data*localN + weights.Data*remoteN) / total,
+			Pattern: (a.weights.Pattern*localN + weights.Pattern*remoteN) / total,
+			LLM:     (a.weights.LLM*localN + weights.LLM*remoteN) / total,
+		}
+		a.trainingHistory = append(a.trainingHistory, history...)
+		sort.SliceStable(a.trainingHistory, func(i, j int) bool {
+			return a.trainingHistory[i].Timestamp.Before(a.trainingHistory[j].Timestamp)
+		})
+	}
+
+	// Normalize weights to sum to 1.0
+	sum := a.weights.Name + a.weights.Data + a.weights.Pattern + a.weights.LLM
+	if sum > 0 {
+		a.weights.Name /= sum
+		a.weights.Data /= sum
+		a.weights.Pattern /= sum
+		a.weights.LLM /= sum
+	}
+
+	if len(a.trainingHistory) > 100 {
+		a.trainingHistory = a.trainingHistory[len(a.trainingHistory)-100:]
+	}
+	a.base, a.baseSince = a.weights, time.Now()
+	a.mutex.Unlock()
+
+	if err := a.save(); err != nil {
+		adaptiveLog.Error("Error saving merged weights", "error", err)
+	}
+	return nil
+}
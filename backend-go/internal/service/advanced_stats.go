@@ -3,7 +3,6 @@ package service
 import (
 	"backend-go/internal/state"
 	"math"
-	"strconv"
 )
 
 // AdvancedStatsCalculator provides advanced statistical correlation methods
@@ -187,15 +186,7 @@ func extractValues(df *state.DataFrame, colIdx int) []string {
 }
 
 func extractFloatValues(df *state.DataFrame, colIdx int) []float64 {
-	values := []float64{}
-	for _, row := range df.Rows {
-		if colIdx < len(row) {
-			if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-				values = append(values, val)
-			}
-		}
-	}
-	return values
+	return df.FloatValues(colIdx)
 }
 
 func discretize(values []float64, numBins int) []int {
@@ -2,18 +2,21 @@ package service
 
 import (
 	"backend-go/internal/llm"
+	"backend-go/internal/logging"
 	"backend-go/internal/models"
 	"backend-go/internal/state"
+	"backend-go/internal/tracing"
+	"context"
 	"fmt"
-	"log"
 	"math"
 	"sort"
-	"strconv"
 	"strings"
 	"sync"
 	"time"
 )
 
+var aiMatcherLog = logging.Component("ai_matcher")
+
 // AISemanticMatcher uses LLM for intelligent column matching
 type AISemanticMatcher struct {
 	llmService     *llm.Service
@@ -21,6 +24,7 @@ type AISemanticMatcher struct {
 	cache          map[string]*SemanticMatch
 	cacheMutex     sync.RWMutex
 	cacheExpiry    time.Duration
+	intervals      *ProbabilisticMatcher
 }
 
 // SemanticMatch represents an AI-determined match
@@ -39,6 +43,11 @@ type SemanticMatch struct {
 	SemanticScore          float64 `json:"semantic_score"`
 	DistributionSimilarity float64 `json:"distribution_similarity"`
 	ValueOverlap           float64 `json:"value_overlap"`
+
+	// 95% interval of Confidence from the number of values compared
+	ConfidenceLower float64 `json:"confidence_lower"`
+	ConfidenceUpper float64 `json:"confidence_upper"`
+	SampleSize      int     `json:"sample_size"`
 }
 
 // NewAISemanticMatcher creates a new AI-powered matcher
@@ -48,33 +57,63 @@ func NewAISemanticMatcher(llmSvc *llm.Service, ctxSvc *ContextService) *AISemant
 		contextService: ctxSvc,
 		cache:          make(map[string]*SemanticMatch),
 		cacheExpiry:    30 * time.Minute,
+		intervals:      NewProbabilisticMatcher(),
 	}
 }
 
 // MatchColumns performs AI-powered column matching
 func (m *AISemanticMatcher) MatchColumns(
+	ctx context.Context,
+	df1, df2 *state.DataFrame,
+	ctx1, ctx2 *models.Context,
+) []SemanticMatch {
+	return m.MatchColumnsWithWeights(ctx, df1, df2, ctx1, ctx2, nil)
+}
+
+// MatchColumnsWithWeights is MatchColumns scoring with a profile's weights:
+// Name weighs the name similarity, LLM the semantic score and Data plus
+// Pattern the data similarity. Nil weights keep 30/40/30.
+func (m *AISemanticMatcher) MatchColumnsWithWeights(
+	ctx context.Context,
 	df1, df2 *state.DataFrame,
 	ctx1, ctx2 *models.Context,
+	weights *AdaptiveWeights,
 ) []SemanticMatch {
+	ctx, span := tracing.Start(ctx, "ai_matcher.match_columns")
+	defer span.End()
 	results := []SemanticMatch{}
 
 	// Step 1: Quick heuristic pre-filtering
+	_, heuristics := tracing.Start(ctx, "ai_matcher.heuristics")
 	candidates := m.preFilterCandidates(df1, df2)
-	log.Printf("[AI Matcher] Found %d candidate pairs from heuristics", len(candidates))
-
-	// Step 2: Use LLM for semantic matching on column names
-	llmMatches, err := m.getLLMSemanticMatches(df1.Headers, df2.Headers)
+	heuristics.SetAttr("candidates", len(candidates))
+	heuristics.End()
+	aiMatcherLog.InfoContext(ctx, "Found candidate pairs from heuristics", "candidates", len(candidates))
+
+	// Step 2: Use LLM for semantic matching on column names, unmatched
+	// columns first so they are the last left out of a prompt over budget
+	cols1, cols2 := unmatchedFirst(df1.Headers, df2.Headers, candidates)
+	llmMatches, err := m.getLLMSemanticMatches(ctx, cols1, cols2)
 	if err != nil {
-		log.Printf("[AI Matcher] LLM matching failed, falling back to heuristics: %v", err)
+		aiMatcherLog.WarnContext(ctx, "LLM matching failed, falling back to heuristics", "error", err)
 	} else {
-		log.Printf("[AI Matcher] LLM found %d semantic matches", len(llmMatches))
+		aiMatcherLog.InfoContext(ctx, "LLM found semantic matches", "matches", len(llmMatches))
 		// Merge LLM matches with candidates
 		for _, match := range llmMatches {
 			candidates[match.File1Column+"||"+match.File2Column] = &match
 		}
 	}
 
+	// Declared join keys are always candidates
+	_, declared := DeclaredRelationship(ctx1, ctx2)
+	for _, k := range declared {
+		if _, ok := candidates[k.File1Column+"||"+k.File2Column]; !ok {
+			candidates[k.File1Column+"||"+k.File2Column] = &SemanticMatch{File1Column: k.File1Column, File2Column: k.File2Column}
+		}
+	}
+
 	// Step 3: Enhance each candidate with data analysis
+	_, analysis := tracing.Start(ctx, "ai_matcher.data_analysis")
 	for key, match := range candidates {
 		parts := strings.Split(key, "||")
 		if len(parts) != 2 {
@@ -91,19 +130,31 @@ func (m *AISemanticMatcher) MatchColumns(
 		}
 
 		// Enhance with data analysis
-		enhanced := m.enhanceWithDataAnalysis(df1, df2, col1Idx, col2Idx, match)
+		enhanced := m.enhanceWithDataAnalysis(df1, df2, col1Idx, col2Idx, match, weights)
 
 		// Apply context boost if available
 		if ctx1 != nil && ctx2 != nil {
 			enhanced = m.applyContextBoost(enhanced, ctx1, ctx2)
 		}
 
+		// Join keys declared in the relationship answers, by either file
+		if isDeclaredJoinKey(ctx1, ctx2, col1, col2) {
+			enhanced.Confidence = math.Max(enhanced.Confidence, DeclaredKeyConfidence)
+			enhanced.MatchType = "declared_key"
+			enhanced.Reason = "Declared join key"
+		}
+
+		enhanced.ConfidenceLower, enhanced.ConfidenceUpper = m.intervals.ConfidenceBounds(enhanced.Confidence, enhanced.SampleSize)
+
 		// Only include meaningful matches
 		if enhanced.Confidence > 15 {
 			results = append(results, *enhanced)
 		}
 	}
 
+	analysis.SetAttr("matches", len(results))
+	analysis.End()
+
 	// Sort by confidence
 	sort.Slice(results, func(i, j int) bool {
 		return results[i].Confidence > results[j].Confidence
@@ -138,8 +189,39 @@ func (m *AISemanticMatcher) preFilterCandidates(df1, df2 *state.DataFrame) map[s
 	return candidates
 }
 
+// strongNameSimilarity is the name similarity at which a heuristic
+// candidate counts as a match before the LLM is asked
+const strongNameSimilarity = 0.8
+
+// unmatchedFirst orders the columns of both files with the columns that
+// have no strong heuristic candidate first, keeping their order otherwise
+func unmatchedFirst(headers1, headers2 []string, candidates map[string]*SemanticMatch) ([]string, []string) {
+	matched := map[string]bool{}
+	for _, c := range candidates {
+		if c.NameSimilarity >= strongNameSimilarity {
+			matched["1:"+c.File1Column] = true
+			matched["2:"+c.File2Column] = true
+		}
+	}
+	order := func(headers []string, file string) []string {
+		ordered := make([]string, 0, len(headers))
+		for _, h := range headers {
+			if !matched[file+h] {
+				ordered = append(ordered, h)
+			}
+		}
+		for _, h := range headers {
+			if matched[file+h] {
+				ordered = append(ordered, h)
+			}
+		}
+		return ordered
+	}
+	return order(headers1, "1:"), order(headers2, "2:")
+}
+
 // getLLMSemanticMatches uses the LLM for semantic matching
-func (m *AISemanticMatcher) getLLMSemanticMatches(cols1, cols2 []string) ([]SemanticMatch, error) {
+func (m *AISemanticMatcher) getLLMSemanticMatches(ctx context.Context, cols1, cols2 []string) ([]SemanticMatch, error) {
 	if m.llmService == nil {
 		return nil, fmt.Errorf("LLM service not configured")
 	}
@@ -156,7 +238,7 @@ func (m *AISemanticMatcher) getLLMSemanticMatches(cols1, cols2 []string) ([]Sema
 	m.cacheMutex.RUnlock()
 
 	// Call LLM
-	matches, err := m.llmService.GetSemanticMatches(cols1, cols2)
+	matches, err := m.llmService.GetSemanticMatches(ctx, cols1, cols2, GetGlossary().PromptHints(cols1, cols2))
 	if err != nil {
 		return nil, err
 	}
@@ -185,6 +267,7 @@ func (m *AISemanticMatcher) enhanceWithDataAnalysis(
 	df1, df2 *state.DataFrame,
 	col1Idx, col2Idx int,
 	match *SemanticMatch,
+	weights *AdaptiveWeights,
 ) *SemanticMatch {
 	if match == nil {
 		match = &SemanticMatch{}
@@ -203,17 +286,27 @@ func (m *AISemanticMatcher) enhanceWithDataAnalysis(
 		// Numeric: distribution similarity
 		result.DistributionSimilarity = calculateDistributionSim(df1, df2, col1Idx, col2Idx)
 		result.DataSimilarity = result.DistributionSimilarity
+		result.SampleSize = minInt(len(getFloatVals(df1, col1Idx)), len(getFloatVals(df2, col2Idx)))
 	} else if !isNum1 && !isNum2 {
 		// Categorical: value overlap
 		result.ValueOverlap = calculateValueOverlapSim(df1, df2, col1Idx, col2Idx)
 		result.DataSimilarity = result.ValueOverlap
+		result.SampleSize = minInt(200, minInt(len(df1.Rows), len(df2.Rows))) // Rows calculateValueOverlapSim reads
 	}
 
 	// Recalculate confidence with data
-	// Weights: Name 30%, Semantic 40%, Data 30%
-	result.Confidence = (result.NameSimilarity * 30) +
-		(result.SemanticScore * 40) +
-		(result.DataSimilarity * 30)
+	// Weights: Name 30%, Semantic 40%, Data 30% unless given
+	nameWeight, semanticWeight, dataWeight := 30.0, 40.0, 30.0
+	if weights != nil {
+		if sum := weights.Name + weights.LLM + weights.Data + weights.Pattern; sum > 0 {
+			nameWeight = weights.Name / sum * 100
+			semanticWeight = weights.LLM / sum * 100
+			dataWeight = (weights.Data + weights.Pattern) / sum * 100
+		}
+	}
+	result.Confidence = (result.NameSimilarity * nameWeight) +
+		(result.SemanticScore * semanticWeight) +
+		(result.DataSimilarity * dataWeight)
 
 	// Boost for high semantic matches from AI
 	if result.MatchType == "ai_semantic" && result.SemanticScore > 0.7 {
@@ -287,7 +380,11 @@ Respond with JSON only:
   "match_type": "exact|semantic|partial|none"
 }`, col1, sampleData1[:minInt(5, len(sampleData1))], col2, sampleData2[:minInt(5, len(sampleData2))])
 
-	response, err := m.llmService.CallOllama(prompt)
+	var response string
+	err := m.llmService.Generate(context.Background(), llm.TaskPairCheck, prompt, func(r string) error {
+		response = r
+		return nil
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -362,7 +459,11 @@ func calculateDistributionSim(df1, df2 *state.DataFrame, col1Idx, col2Idx int) f
 	}
 
 	cvSim := math.Max(0, 1-math.Abs(cv1-cv2))
-	return cvSim
+
+	// Binned distributions over the common range; a small CV alone doesn't
+	// tell ages from order quantities
+	histSim := histogramSimilarity(vals1, vals2)
+	return histSim*0.7 + cvSim*0.3
 }
 
 func calculateValueOverlapSim(df1, df2 *state.DataFrame, col1Idx, col2Idx int) float64 {
@@ -401,15 +502,7 @@ func calculateValueOverlapSim(df1, df2 *state.DataFrame, col1Idx, col2Idx int) f
 }
 
 func getFloatVals(df *state.DataFrame, colIdx int) []float64 {
-	vals := []float64{}
-	for _, row := range df.Rows {
-		if colIdx < len(row) {
-			if v, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-				vals = append(vals, v)
-			}
-		}
-	}
-	return vals
+	return df.FloatValues(colIdx)
 }
 
 func calcMeanStd(vals []float64) (float64, float64) {
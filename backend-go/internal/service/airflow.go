@@ -0,0 +1,187 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Airflow export targets
+const (
+	AirflowTargetSQL    = "sql"    // Runs the generated SQL in the warehouse
+	AirflowTargetPython = "python" // Runs the pandas join in the worker
+)
+
+var dagIDPattern = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// AirflowOptions control GenerateAirflowDAG output
+type AirflowOptions struct {
+	Target      string // AirflowTargetSQL (default) or AirflowTargetPython
+	DagID       string
+	Schedule    string // Cron expression or preset; "" = manual runs only
+	ConnID      string // Source/warehouse connection (SQL target) or load target (Python target)
+	TargetTable string // Table the joined result is loaded into
+	SQL         SQLOptions
+}
+
+// Validate fills defaults and checks names
+func (o *AirflowOptions) Validate() error {
+	switch o.Target {
+	case "":
+		o.Target = AirflowTargetSQL
+	case AirflowTargetSQL, AirflowTargetPython:
+	default:
+		return fmt.Errorf("unknown target %q (use sql or python)", o.Target)
+	}
+	if o.DagID == "" {
+		o.DagID = "project_euler_join"
+	}
+	if !dagIDPattern.MatchString(o.DagID) {
+		return fmt.Errorf("invalid dag_id %q", o.DagID)
+	}
+	if o.ConnID == "" {
+		o.ConnID = "warehouse"
+	}
+	if o.TargetTable == "" {
+		o.TargetTable = "joined_result"
+	}
+	if !tableNamePattern.MatchString(o.TargetTable) {
+		return fmt.Errorf("invalid table name %q", o.TargetTable)
+	}
+
+	o.SQL.Mode = SQLModeView
+	if o.SQL.ViewName == "" {
+		o.SQL.ViewName = strings.NewReplacer(".", "_", "-", "_").Replace(o.DagID) + "_joined"
+	}
+	return o.SQL.Validate()
+}
+
+// GenerateAirflowDAG wraps the generated SQL or pandas join into an Airflow
+// DAG with extract, transform/join and load tasks. Connection IDs and table
+// names are module constants that can be overridden with environment variables.
+func (s *ExportService) GenerateAirflowDAG(graph *models.SimilarityGraph, mapping *ApprovedMapping, opts AirflowOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+
+	schedule := "None"
+	if opts.Schedule != "" {
+		schedule = pyString(opts.Schedule)
+	}
+
+	var sb strings.Builder
+	sb.WriteString("# Generated by Project Euler\n")
+	sb.WriteString(fmt.Sprintf("# Airflow DAG (%s target): extract -> transform/join -> load\n", opts.Target))
+	sb.WriteString("import os\n")
+	sb.WriteString("from datetime import datetime\n\n")
+	sb.WriteString("from airflow import DAG\n")
+
+	if opts.Target == AirflowTargetSQL {
+		joinSQL, err := s.GenerateSQL(graph, mapping, opts.SQL)
+		if err != nil {
+			return "", err
+		}
+		d := sqlDialects[opts.SQL.Dialect]
+
+		sb.WriteString("from airflow.providers.common.sql.operators.sql import SQLCheckOperator, SQLExecuteQueryOperator\n\n")
+		sb.WriteString("# Connections and tables (override with environment variables)\n")
+		sb.WriteString(fmt.Sprintf("CONN_ID = os.environ.get('PE_CONN_ID', %s)\n\n", pyString(opts.ConnID)))
+
+		sb.WriteString(fmt.Sprintf("JOIN_SQL = %s\n\n", pyTripleQuoted(joinSQL)))
+		sb.WriteString(fmt.Sprintf("LOAD_SQL = %s\n\n", pyTripleQuoted(d.createTableAs(opts.SQL.Dialect, opts.TargetTable, opts.SQL.ViewName))))
+
+		writeDAGHeader(&sb, opts.DagID, schedule)
+		sb.WriteString("    # Extract: make sure both sources are present and non-empty\n")
+		sb.WriteString("    extract_file1 = SQLCheckOperator(\n")
+		sb.WriteString("        task_id='extract_file1',\n")
+		sb.WriteString("        conn_id=CONN_ID,\n")
+		sb.WriteString(fmt.Sprintf("        sql=%s,\n", pyString("SELECT COUNT(*) FROM "+d.quoteTable(opts.SQL.Table1))))
+		sb.WriteString("    )\n")
+		sb.WriteString("    extract_file2 = SQLCheckOperator(\n")
+		sb.WriteString("        task_id='extract_file2',\n")
+		sb.WriteString("        conn_id=CONN_ID,\n")
+		sb.WriteString(fmt.Sprintf("        sql=%s,\n", pyString("SELECT COUNT(*) FROM "+d.quoteTable(opts.SQL.Table2))))
+		sb.WriteString("    )\n\n")
+		sb.WriteString("    # Transform/join: (re)create the joined view per the mapping\n")
+		sb.WriteString("    transform_join = SQLExecuteQueryOperator(\n")
+		sb.WriteString("        task_id='transform_join',\n")
+		sb.WriteString("        conn_id=CONN_ID,\n")
+		sb.WriteString("        sql=JOIN_SQL,\n")
+		sb.WriteString("    )\n\n")
+		sb.WriteString("    # Load: materialize the view into the target table\n")
+		sb.WriteString("    load = SQLExecuteQueryOperator(\n")
+		sb.WriteString("        task_id='load',\n")
+		sb.WriteString("        conn_id=CONN_ID,\n")
+		sb.WriteString("        sql=LOAD_SQL,\n")
+		sb.WriteString("        split_statements=True,\n")
+		sb.WriteString("    )\n\n")
+		sb.WriteString("    [extract_file1, extract_file2] >> transform_join >> load\n")
+		return sb.String(), nil
+	}
+
+	pairs := joinColumns(graph, mapping)
+	left := make([]string, len(pairs))
+	right := make([]string, len(pairs))
+	for i, pair := range pairs {
+		left[i] = pyString(pair[0])
+		right[i] = pyString(pair[1])
+	}
+
+	sb.WriteString("from airflow.hooks.base import BaseHook\n")
+	sb.WriteString("from airflow.operators.python import PythonOperator\n\n")
+	sb.WriteString("# Connections, paths and tables (override with environment variables)\n")
+	sb.WriteString(fmt.Sprintf("TARGET_CONN_ID = os.environ.get('PE_TARGET_CONN_ID', %s)\n", pyString(opts.ConnID)))
+	sb.WriteString(fmt.Sprintf("TARGET_TABLE = os.environ.get('PE_TARGET_TABLE', %s)\n", pyString(opts.TargetTable)))
+	sb.WriteString("FILE1_PATH = os.environ.get('PE_FILE1_PATH', 'file1.csv')\n")
+	sb.WriteString("FILE2_PATH = os.environ.get('PE_FILE2_PATH', 'file2.csv')\n")
+	sb.WriteString("STAGING_DIR = os.environ.get('PE_STAGING_DIR', '/tmp/project_euler')\n\n")
+	sb.WriteString(fmt.Sprintf("LEFT_KEYS = [%s]\n", strings.Join(left, ", ")))
+	sb.WriteString(fmt.Sprintf("RIGHT_KEYS = [%s]\n\n\n", strings.Join(right, ", ")))
+
+	sb.WriteString("def extract():\n")
+	sb.WriteString("    import pandas as pd\n")
+	sb.WriteString("    os.makedirs(STAGING_DIR, exist_ok=True)\n")
+	sb.WriteString("    pd.read_csv(FILE1_PATH).to_parquet(os.path.join(STAGING_DIR, 'file1.parquet'))\n")
+	sb.WriteString("    pd.read_csv(FILE2_PATH).to_parquet(os.path.join(STAGING_DIR, 'file2.parquet'))\n\n\n")
+
+	sb.WriteString("def transform_join():\n")
+	sb.WriteString("    import pandas as pd\n")
+	sb.WriteString("    df1 = pd.read_parquet(os.path.join(STAGING_DIR, 'file1.parquet'))\n")
+	sb.WriteString("    df2 = pd.read_parquet(os.path.join(STAGING_DIR, 'file2.parquet'))\n")
+	sb.WriteString("    if LEFT_KEYS:\n")
+	sb.WriteString("        merged = pd.merge(df1, df2, left_on=LEFT_KEYS, right_on=RIGHT_KEYS, how='inner', suffixes=('', '_file2'))\n")
+	sb.WriteString("    else:\n")
+	sb.WriteString("        merged = pd.merge(df1, df2, how='cross', suffixes=('', '_file2'))  # No high confidence relationships found\n")
+	sb.WriteString("    merged.to_parquet(os.path.join(STAGING_DIR, 'merged.parquet'))\n\n\n")
+
+	sb.WriteString("def load():\n")
+	sb.WriteString("    import pandas as pd\n")
+	sb.WriteString("    merged = pd.read_parquet(os.path.join(STAGING_DIR, 'merged.parquet'))\n")
+	sb.WriteString("    engine = BaseHook.get_connection(TARGET_CONN_ID).get_hook().get_sqlalchemy_engine()\n")
+	sb.WriteString("    merged.to_sql(TARGET_TABLE, engine, if_exists='replace', index=False)\n\n\n")
+
+	writeDAGHeader(&sb, opts.DagID, schedule)
+	sb.WriteString("    extract_task = PythonOperator(task_id='extract', python_callable=extract)\n")
+	sb.WriteString("    transform_task = PythonOperator(task_id='transform_join', python_callable=transform_join)\n")
+	sb.WriteString("    load_task = PythonOperator(task_id='load', python_callable=load)\n\n")
+	sb.WriteString("    extract_task >> transform_task >> load_task\n")
+	return sb.String(), nil
+}
+
+func writeDAGHeader(sb *strings.Builder, dagID, schedule string) {
+	sb.WriteString("with DAG(\n")
+	sb.WriteString(fmt.Sprintf("    dag_id=%s,\n", pyString(dagID)))
+	sb.WriteString(fmt.Sprintf("    schedule=%s,\n", schedule))
+	sb.WriteString("    start_date=datetime(2024, 1, 1),\n")
+	sb.WriteString("    catchup=False,\n")
+	sb.WriteString("    tags=['project_euler'],\n")
+	sb.WriteString(") as dag:\n")
+}
+
+// pyTripleQuoted renders a triple-quoted Python string literal
+func pyTripleQuoted(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, `"""`, `\"\"\"`)
+	return `"""` + s + `"""`
+}
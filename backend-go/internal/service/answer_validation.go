@@ -0,0 +1,190 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Answer error codes
+const (
+	AnswerErrorRequired      = "required"         // Empty answer
+	AnswerErrorType          = "invalid_type"     // Wrong JSON type for the answer type
+	AnswerErrorOption        = "invalid_option"   // Not one of the question's options
+	AnswerErrorUnknownColumn = "unknown_column"   // Not a column of the file
+	AnswerErrorJoinKey       = "invalid_join_key" // Join keys that don't resolve to column pairs
+	AnswerErrorTooLong       = "too_long"
+)
+
+// maxAnswerLength caps text answers and the items of list answers, in characters
+const maxAnswerLength = 2000
+
+// AnswerError is a problem with one field of an answer
+type AnswerError struct {
+	Field   string `json:"field"` // e.g. answer, answer[2] or context_data.column_descriptions.amt
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// answerType is the answer type of a question; questions without one take
+// free text
+func answerType(q models.Question) string {
+	if q.AnswerType == "" {
+		return models.AnswerTypeText
+	}
+	return q.AnswerType
+}
+
+// EmptyAnswer reports whether an answer holds nothing: null, blank text or
+// an empty array or object
+func EmptyAnswer(answer interface{}) bool {
+	switch a := answer.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(a) == ""
+	case []interface{}:
+		return len(a) == 0
+	case map[string]interface{}:
+		return len(a) == 0
+	}
+	return false
+}
+
+// matchOption finds the option of a question a value names, by the option
+// or its localized label, ignoring case
+func matchOption(q models.Question, value string) (string, bool) {
+	value = strings.TrimSpace(value)
+	labels, _ := q.Metadata["option_labels"].([]string)
+	for i, o := range q.Options {
+		if strings.EqualFold(o, value) || (i < len(labels) && strings.EqualFold(labels[i], value)) {
+			return o, true
+		}
+	}
+	return "", false
+}
+
+// ValidateAnswer checks an answer against its question's answer type and
+// returns the problems, with field paths under field. columns are the
+// file's columns that column answers must name (nil skips the check), and
+// headers1 and headers2 resolve join keys.
+func ValidateAnswer(field string, q models.Question, answer interface{}, columns, headers1, headers2 []string) []AnswerError {
+	errs := []AnswerError{}
+	fail := func(f, code, format string, args ...interface{}) {
+		errs = append(errs, AnswerError{Field: f, Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+	if EmptyAnswer(answer) {
+		fail(field, AnswerErrorRequired, "An answer is required")
+		return errs
+	}
+	known := map[string]bool{}
+	for _, c := range columns {
+		known[c] = true
+	}
+
+	switch t := answerType(q); t {
+	case models.AnswerTypeText, models.AnswerTypeSelect:
+		s, ok := answer.(string)
+		switch {
+		case !ok:
+			fail(field, AnswerErrorType, "Must be text")
+		case len(s) > maxAnswerLength:
+			fail(field, AnswerErrorTooLong, "Must be at most %d characters", maxAnswerLength)
+		case t == models.AnswerTypeSelect && len(q.Options) > 0:
+			if _, ok := matchOption(q, s); !ok {
+				fail(field, AnswerErrorOption, "Must be one of: %s", strings.Join(q.Options, ", "))
+			}
+		}
+
+	case models.AnswerTypeMultiSelect, models.AnswerTypeTags, models.AnswerTypeColumns, models.AnswerTypeJoinKeys:
+		items, ok := answer.([]interface{})
+		if !ok {
+			fail(field, AnswerErrorType, "Must be an array of strings")
+			return errs
+		}
+		values := make([]string, 0, len(items))
+		for i, item := range items {
+			f := fmt.Sprintf("%s[%d]", field, i)
+			s, ok := item.(string)
+			switch {
+			case !ok || strings.TrimSpace(s) == "":
+				fail(f, AnswerErrorType, "Must be non-empty text")
+				continue
+			case len(s) > maxAnswerLength:
+				fail(f, AnswerErrorTooLong, "Must be at most %d characters", maxAnswerLength)
+			case t == models.AnswerTypeMultiSelect && len(q.Options) > 0:
+				if _, ok := matchOption(q, s); !ok {
+					fail(f, AnswerErrorOption, "%q is not one of: %s", s, strings.Join(q.Options, ", "))
+				}
+			case t == models.AnswerTypeColumns && columns != nil:
+				if !known[strings.TrimSpace(s)] {
+					fail(f, AnswerErrorUnknownColumn, "%q is not a column of the file", s)
+				}
+			}
+			values = append(values, s)
+		}
+		if t == models.AnswerTypeJoinKeys && len(errs) == 0 {
+			if _, err := ParseJoinKeys(values, headers1, headers2); err != nil {
+				fail(field, AnswerErrorJoinKey, "%s", err.Error())
+			}
+		}
+
+	case models.AnswerTypeColumnMap:
+		descriptions, ok := answer.(map[string]interface{})
+		if !ok {
+			fail(field, AnswerErrorType, "Must be an object of column name to description")
+			return errs
+		}
+		cols := make([]string, 0, len(descriptions))
+		for col := range descriptions {
+			cols = append(cols, col)
+		}
+		sort.Strings(cols)
+		for _, col := range cols {
+			f := field + "." + col
+			s, ok := descriptions[col].(string)
+			switch {
+			case columns != nil && !known[col]:
+				fail(f, AnswerErrorUnknownColumn, "%q is not a column of the file", col)
+			case !ok || strings.TrimSpace(s) == "":
+				fail(f, AnswerErrorType, "Must be a non-empty description")
+			case len(s) > maxAnswerLength:
+				fail(f, AnswerErrorTooLong, "Must be at most %d characters", maxAnswerLength)
+			}
+		}
+
+	default:
+		fail(field, AnswerErrorType, "Unknown answer type %q", t)
+	}
+	return errs
+}
+
+// ContextFieldQuestions maps the fields of a context submit to the
+// questions asking for them, whose answer types validate the fields.
+// columns are the file's columns; the relationship fields also go by their
+// question IDs, rel_type and rel_keys. Options may be given by their labels
+// in locale.
+func ContextFieldQuestions(fileIndex int, columns, headers1, headers2 []string, locale string) map[string]models.Question {
+	relationship := RelationshipQuestions(headers1, headers2, locale)
+	return map[string]models.Question{
+		"dataset_purpose": purposeQuestion(fileIndex, locale),
+		"business_domain": domainQuestion(fileIndex, locale),
+		"key_entities":    entitiesQuestion(fileIndex, locale),
+		"temporal_context": {
+			ID:         fmt.Sprintf("f%d_temporal", fileIndex),
+			Type:       models.QuestionTypeTemporalContext,
+			AnswerType: models.AnswerTypeText,
+		},
+		"column_descriptions": {
+			ID:         fmt.Sprintf("f%d_column_semantics", fileIndex),
+			Type:       models.QuestionTypeColumnSemantic,
+			AnswerType: models.AnswerTypeColumnMap,
+		},
+		"exclusions":        exclusionsQuestion(models.DataAnalysisResult{ColumnNames: columns}, fileIndex, locale),
+		"rel_type":          relationship[0],
+		"relationship_type": relationship[0],
+		"rel_keys":          relationship[1],
+		"join_keys":         relationship[1],
+	}
+}
@@ -0,0 +1,343 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+var mappingsLog = logging.Component("mappings")
+
+const approvedMappingsFile = "approved_mappings.json"
+
+// Mapping review statuses
+const (
+	MappingAccepted = models.MappingAccepted
+	MappingRejected = models.MappingRejected
+)
+
+// Mapping sources
+const (
+	MappingSourceSuggested = models.MappingSourceSuggested
+	MappingSourceManual    = models.MappingSourceManual
+)
+
+// The mapping types are shared with the API client
+type (
+	ColumnMapping   = models.ColumnMapping
+	ApprovedMapping = models.ApprovedMapping
+)
+
+// MappingStore manages approved mapping documents keyed by dataset pair scope
+type MappingStore struct {
+	mappings map[string]*ApprovedMapping
+	mutex    sync.RWMutex
+}
+
+var (
+	mappingStore     *MappingStore
+	mappingStoreOnce sync.Once
+)
+
+// GetMappingStore returns the singleton mapping store
+func GetMappingStore() *MappingStore {
+	mappingStoreOnce.Do(func() {
+		mappingStore = &MappingStore{
+			mappings: make(map[string]*ApprovedMapping),
+		}
+		mappingStore.load()
+	})
+	return mappingStore
+}
+
+// CurrentMappingScope returns the scope of the loaded dataset pair, or an
+// error when either file is missing
+func CurrentMappingScope() (string, error) {
+	scope := DatasetPairScope(state.State.GetDataFrame(1), state.State.GetDataFrame(2))
+	if scope == "" {
+		return "", fmt.Errorf("both files must be loaded")
+	}
+	return scope, nil
+}
+
+// Get returns a copy of the mapping document for a scope (nil if none)
+func (s *MappingStore) Get(scope string) *ApprovedMapping {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	m, ok := s.mappings[scope]
+	if !ok {
+		return nil
+	}
+	return copyMapping(m)
+}
+
+// Current returns the mapping document for the loaded dataset pair (nil if none)
+func (s *MappingStore) Current() *ApprovedMapping {
+	scope, err := CurrentMappingScope()
+	if err != nil {
+		return nil
+	}
+	return s.Get(scope)
+}
+
+// Review records an accepted or rejected mapping. Accepting a pair replaces
+// any other accepted mapping of the same file 1 column.
+func (s *MappingStore) Review(scope string, cm ColumnMapping) (*ApprovedMapping, error) {
+	if cm.File1Column == "" || cm.File2Column == "" {
+		return nil, fmt.Errorf("file1_column and file2_column are required")
+	}
+	if cm.Status != MappingAccepted && cm.Status != MappingRejected {
+		return nil, fmt.Errorf("invalid status %q", cm.Status)
+	}
+	if cm.Source == "" {
+		cm.Source = MappingSourceSuggested
+	}
+	now := time.Now().Format(time.RFC3339)
+	cm.UpdatedAt = now
+
+	s.mutex.Lock()
+	m := s.getOrCreate(scope)
+	kept := m.Mappings[:0]
+	for _, existing := range m.Mappings {
+		samePair := existing.File1Column == cm.File1Column && existing.File2Column == cm.File2Column
+		superseded := cm.Status == MappingAccepted && existing.Status == MappingAccepted &&
+			existing.File1Column == cm.File1Column
+		if !samePair && !superseded {
+			kept = append(kept, existing)
+		}
+	}
+	m.Mappings = append(kept, cm)
+	m.UpdatedAt = now
+	result := copyMapping(m)
+	s.mutex.Unlock()
+
+	mappingsLog.Info("Mapping decided", "status", cm.Status, "file1_column", cm.File1Column, "file2_column", cm.File2Column)
+	return result, s.saveAndRecord(result)
+}
+
+// Remove deletes the review of one column pair
+func (s *MappingStore) Remove(scope, file1Col, file2Col string) (*ApprovedMapping, error) {
+	s.mutex.Lock()
+	m, ok := s.mappings[scope]
+	if !ok {
+		s.mutex.Unlock()
+		return nil, fmt.Errorf("no approved mapping for the current files")
+	}
+	found := false
+	kept := m.Mappings[:0]
+	for _, existing := range m.Mappings {
+		if existing.File1Column == file1Col && existing.File2Column == file2Col {
+			found = true
+			continue
+		}
+		kept = append(kept, existing)
+	}
+	m.Mappings = kept
+	m.UpdatedAt = time.Now().Format(time.RFC3339)
+	result := copyMapping(m)
+	s.mutex.Unlock()
+
+	if !found {
+		return nil, fmt.Errorf("mapping %s -> %s not found", file1Col, file2Col)
+	}
+	return result, s.saveAndRecord(result)
+}
+
+// Clear removes the mapping document of a scope
+func (s *MappingStore) Clear(scope string) error {
+	s.mutex.Lock()
+	delete(s.mappings, scope)
+	s.mutex.Unlock()
+	return s.save()
+}
+
+// Put replaces the mapping document of a scope
+func (s *MappingStore) Put(scope string, m *ApprovedMapping) error {
+	m = copyMapping(m)
+	m.Scope = scope
+	m.UpdatedAt = time.Now().Format(time.RFC3339)
+
+	s.mutex.Lock()
+	s.mappings[scope] = m
+	s.mutex.Unlock()
+	return s.saveAndRecord(copyMapping(m))
+}
+
+// getOrCreate returns the document for a scope, creating it (must hold lock)
+func (s *MappingStore) getOrCreate(scope string) *ApprovedMapping {
+	m, ok := s.mappings[scope]
+	if !ok {
+		m = &ApprovedMapping{Scope: scope, Mappings: []ColumnMapping{}}
+		s.mappings[scope] = m
+	}
+	if df1 := state.State.GetDataFrame(1); df1 != nil {
+		m.File1Name = df1.FileName
+	}
+	if df2 := state.State.GetDataFrame(2); df2 != nil {
+		m.File2Name = df2.FileName
+	}
+	return m
+}
+
+func copyMapping(m *ApprovedMapping) *ApprovedMapping {
+	c := *m
+	c.Mappings = append([]ColumnMapping{}, m.Mappings...)
+	return &c
+}
+
+// load loads mapping documents from file
+func (s *MappingStore) load() {
+	data, err := os.ReadFile(config.DataPath(approvedMappingsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			mappingsLog.Error("Error loading mappings", "error", err)
+		}
+		return
+	}
+
+	var saved map[string]*ApprovedMapping
+	if err := json.Unmarshal(data, &saved); err != nil {
+		mappingsLog.Error("Error parsing mappings", "error", err)
+		return
+	}
+
+	s.mutex.Lock()
+	for scope, m := range saved {
+		s.mappings[scope] = m
+	}
+	s.mutex.Unlock()
+
+	mappingsLog.Info("Loaded approved mappings", "count", len(saved))
+}
+
+// save persists mapping documents to file
+func (s *MappingStore) save() error {
+	s.mutex.RLock()
+	data, err := json.MarshalIndent(s.mappings, "", "  ")
+	s.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(approvedMappingsFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(approvedMappingsFile), data, 0644)
+}
+
+// saveAndRecord persists mapping documents and keeps the accepted mappings
+// of a changed document in the knowledge base
+func (s *MappingStore) saveAndRecord(m *ApprovedMapping) error {
+	if err := s.save(); err != nil {
+		return err
+	}
+	return GetMappingKnowledge().Record(m)
+}
+
+// JoinPreview is a sample of the inner join of the loaded files on the
+// approved join keys
+type JoinPreview struct {
+	Keys        []ColumnMapping `json:"keys"`
+	Headers     []string        `json:"headers"`
+	Rows        [][]string      `json:"rows"`
+	File1Rows   int             `json:"file1_rows"`
+	File1Joined int             `json:"file1_joined"` // File 1 rows with at least one match
+	JoinedRows  int             `json:"joined_rows"`  // Total rows of the inner join
+}
+
+// PreviewJoin inner-joins df1 and df2 on the key mappings (values compared
+// trimmed and case-insensitively) and returns up to limit joined rows
+func PreviewJoin(df1, df2 *state.DataFrame, keys []ColumnMapping, limit int) (*JoinPreview, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no accepted mappings to join on")
+	}
+
+	idx1 := make([]int, len(keys))
+	idx2 := make([]int, len(keys))
+	for i, k := range keys {
+		idx1[i] = columnIndex(df1, k.File1Column)
+		idx2[i] = columnIndex(df2, k.File2Column)
+		if idx1[i] < 0 || idx2[i] < 0 {
+			return nil, fmt.Errorf("mapping %s -> %s doesn't match the loaded files", k.File1Column, k.File2Column)
+		}
+	}
+
+	// Index file 2 rows by key
+	index := make(map[string][]int)
+	for r, row := range df2.Rows {
+		if key, ok := joinKey(row, idx2); ok {
+			index[key] = append(index[key], r)
+		}
+	}
+
+	headers := make([]string, 0, len(df1.Headers)+len(df2.Headers))
+	for _, h := range df1.Headers {
+		headers = append(headers, "file1."+h)
+	}
+	for _, h := range df2.Headers {
+		headers = append(headers, "file2."+h)
+	}
+
+	preview := &JoinPreview{
+		Keys:      keys,
+		Headers:   headers,
+		Rows:      [][]string{},
+		File1Rows: len(df1.Rows),
+	}
+	for _, row := range df1.Rows {
+		key, ok := joinKey(row, idx1)
+		if !ok {
+			continue
+		}
+		matches := index[key]
+		if len(matches) == 0 {
+			continue
+		}
+		preview.File1Joined++
+		preview.JoinedRows += len(matches)
+		for _, r := range matches {
+			if len(preview.Rows) >= limit {
+				break
+			}
+			joined := make([]string, 0, len(headers))
+			joined = append(joined, padRow(row, len(df1.Headers))...)
+			joined = append(joined, padRow(df2.Rows[r], len(df2.Headers))...)
+			preview.Rows = append(preview.Rows, joined)
+		}
+	}
+	return preview, nil
+}
+
+// joinKey builds the composite key of a row; rows with an empty key part don't join
+func joinKey(row []string, idx []int) (string, bool) {
+	parts := make([]string, len(idx))
+	for i, c := range idx {
+		if c >= len(row) {
+			return "", false
+		}
+		v := strings.ToLower(strings.TrimSpace(row[c]))
+		if v == "" {
+			return "", false
+		}
+		parts[i] = v
+	}
+	return strings.Join(parts, "\x1f"), true
+}
+
+func padRow(row []string, width int) []string {
+	if len(row) >= width {
+		return row[:width]
+	}
+	padded := make([]string, width)
+	copy(padded, row)
+	return padded
+}
@@ -0,0 +1,460 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/models"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var batchLog = logging.Component("batch")
+
+const batchJobsFile = "batch_jobs.json"
+
+// Batch limits
+const (
+	BatchMaxPairs = 200 // Pairs per job
+	batchMaxJobs  = 50  // Stored jobs, newest kept
+)
+
+// Batch job and pair statuses
+const (
+	BatchQueued    = "queued"
+	BatchRunning   = "running"
+	BatchCompleted = "completed"
+	BatchFailed    = "failed" // Pairs only; a job completes even when pairs fail
+)
+
+// BatchSource is a file of a batch pair: a stored upload or a path under
+// batch.root
+type BatchSource struct {
+	Upload string `json:"upload,omitempty"`
+	Path   string `json:"path,omitempty"`
+}
+
+func (s BatchSource) String() string {
+	if s.Upload != "" {
+		return "upload:" + s.Upload
+	}
+	return s.Path
+}
+
+// BatchPair is a pair of files to compare
+type BatchPair struct {
+	Name  string      `json:"name,omitempty"`
+	File1 BatchSource `json:"file1"`
+	File2 BatchSource `json:"file2"`
+}
+
+// BatchFileSummary describes a file read by a batch pair
+type BatchFileSummary struct {
+	Source  string `json:"source"`
+	Name    string `json:"name"`
+	Rows    int    `json:"rows"`
+	Columns int    `json:"columns"`
+}
+
+// BatchMatch is a column pair matched in a batch pair
+type BatchMatch struct {
+	File1Column string  `json:"file1_column"`
+	File2Column string  `json:"file2_column"`
+	Confidence  float64 `json:"confidence"`
+	Type        string  `json:"type,omitempty"`
+}
+
+// BatchPairResult is the comparison of one pair
+type BatchPairResult struct {
+	BatchPair
+	Status         string                    `json:"status"`
+	Error          string                    `json:"error,omitempty"`
+	File1Summary   *BatchFileSummary         `json:"file1_summary,omitempty"`
+	File2Summary   *BatchFileSummary         `json:"file2_summary,omitempty"`
+	Matches        []BatchMatch              `json:"matches,omitempty"`
+	UnmatchedFile1 []string                  `json:"unmatched_file1,omitempty"`
+	UnmatchedFile2 []string                  `json:"unmatched_file2,omitempty"`
+	CompositeKeys  []models.CompositeJoinKey `json:"composite_keys,omitempty"`
+	DurationMs     int64                     `json:"duration_ms,omitempty"`
+}
+
+// BatchJob compares many pairs of files in the background
+type BatchJob struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name,omitempty"`
+	Profile    string            `json:"profile"`
+	User       string            `json:"user,omitempty"`
+	Status     string            `json:"status"`
+	CreatedAt  time.Time         `json:"created_at"`
+	StartedAt  *time.Time        `json:"started_at,omitempty"`
+	FinishedAt *time.Time        `json:"finished_at,omitempty"`
+	Completed  int               `json:"completed"`
+	Failed     int               `json:"failed"`
+	Pairs      []BatchPairResult `json:"pairs"`
+}
+
+// BatchPairRunner compares one pair of files with a matching profile
+type BatchPairRunner func(ctx context.Context, pair BatchPair, profile MatchingProfile) BatchPairResult
+
+// BatchStore keeps batch jobs and runs them one at a time
+type BatchStore struct {
+	jobs   map[string]*BatchJob
+	runner BatchPairRunner
+	slot   chan struct{} // Held by the running job
+	mutex  sync.Mutex
+}
+
+var (
+	batchStore     *BatchStore
+	batchStoreOnce sync.Once
+)
+
+// GetBatchStore returns the singleton batch store
+func GetBatchStore() *BatchStore {
+	batchStoreOnce.Do(func() {
+		batchStore = &BatchStore{
+			jobs: make(map[string]*BatchJob),
+			slot: make(chan struct{}, 1),
+		}
+		batchStore.load()
+	})
+	return batchStore
+}
+
+// load loads jobs from file; jobs a restart interrupted have their
+// unfinished pairs failed
+func (s *BatchStore) load() {
+	data, err := os.ReadFile(config.DataPath(batchJobsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			batchLog.Error("Error loading batch jobs", "error", err)
+		}
+		return
+	}
+
+	var jobs []*BatchJob
+	if err := json.Unmarshal(data, &jobs); err != nil {
+		batchLog.Error("Error parsing batch jobs", "error", err)
+		return
+	}
+	for _, job := range jobs {
+		if job.Status != BatchCompleted {
+			for i := range job.Pairs {
+				if p := &job.Pairs[i]; p.Status != BatchCompleted && p.Status != BatchFailed {
+					p.Status = BatchFailed
+					p.Error = "interrupted by a server restart"
+					job.Failed++
+				}
+			}
+			job.Status = BatchCompleted
+			now := time.Now()
+			job.FinishedAt = &now
+		}
+		s.jobs[job.ID] = job
+	}
+	batchLog.Info("Loaded batch jobs", "count", len(jobs))
+}
+
+// save persists jobs to file (must hold lock)
+func (s *BatchStore) save() error {
+	jobs := s.sorted()
+	data, err := json.MarshalIndent(jobs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(batchJobsFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(batchJobsFile), data, 0644)
+}
+
+// sorted lists the jobs oldest first (must hold lock)
+func (s *BatchStore) sorted() []*BatchJob {
+	jobs := make([]*BatchJob, 0, len(s.jobs))
+	for _, job := range s.jobs {
+		jobs = append(jobs, job)
+	}
+	sort.Slice(jobs, func(i, j int) bool { return jobs[i].CreatedAt.Before(jobs[j].CreatedAt) })
+	return jobs
+}
+
+// SetRunner installs the function that compares pairs
+func (s *BatchStore) SetRunner(runner BatchPairRunner) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runner = runner
+}
+
+// Submit queues a job comparing the pairs with the named matching profile
+// and returns it; the job runs once the jobs before it finish
+func (s *BatchStore) Submit(name, user string, pairs []BatchPair, profile MatchingProfile) (BatchJob, error) {
+	if len(pairs) == 0 {
+		return BatchJob{}, fmt.Errorf("pairs is required")
+	}
+	if len(pairs) > BatchMaxPairs {
+		return BatchJob{}, fmt.Errorf("a batch holds at most %d pairs", BatchMaxPairs)
+	}
+	job := &BatchJob{
+		ID:        newBatchJobID(),
+		Name:      name,
+		Profile:   profile.Name,
+		User:      user,
+		Status:    BatchQueued,
+		CreatedAt: time.Now(),
+		Pairs:     make([]BatchPairResult, len(pairs)),
+	}
+	for i, pair := range pairs {
+		if pair.Name == "" {
+			pair.Name = "pair " + strconv.Itoa(i+1)
+		}
+		job.Pairs[i] = BatchPairResult{BatchPair: pair, Status: BatchQueued}
+	}
+
+	s.mutex.Lock()
+	if s.runner == nil {
+		s.mutex.Unlock()
+		return BatchJob{}, fmt.Errorf("batch comparisons can't be run yet")
+	}
+	s.jobs[job.ID] = job
+	s.prune()
+	if err := s.save(); err != nil {
+		batchLog.Error("Error saving batch job", "error", err)
+	}
+	snapshot := job.copy()
+	s.mutex.Unlock()
+
+	batchLog.Info("Queued batch job", "id", job.ID, "pairs", len(pairs), "profile", profile.Name)
+	go s.run(job.ID, profile)
+	return snapshot, nil
+}
+
+// prune drops the oldest finished jobs beyond batchMaxJobs (must hold lock)
+func (s *BatchStore) prune() {
+	jobs := s.sorted()
+	for i := 0; len(s.jobs) > batchMaxJobs && i < len(jobs); i++ {
+		if jobs[i].Status == BatchCompleted {
+			delete(s.jobs, jobs[i].ID)
+		}
+	}
+}
+
+// run compares a job's pairs in order, saving after each
+func (s *BatchStore) run(id string, profile MatchingProfile) {
+	s.slot <- struct{}{}
+	defer func() { <-s.slot }()
+
+	s.mutex.Lock()
+	job := s.jobs[id]
+	runner := s.runner
+	started := time.Now()
+	job.Status = BatchRunning
+	job.StartedAt = &started
+	pairs := make([]BatchPair, len(job.Pairs))
+	for i, p := range job.Pairs {
+		pairs[i] = p.BatchPair
+	}
+	s.mutex.Unlock()
+
+	for i, pair := range pairs {
+		s.update(id, func(job *BatchJob) { job.Pairs[i].Status = BatchRunning })
+		start := time.Now()
+		result := runBatchPair(runner, id, pair, profile)
+		result.BatchPair = pair
+		result.DurationMs = time.Since(start).Milliseconds()
+		if result.Error != "" {
+			result.Status = BatchFailed
+			batchLog.Warn("Batch pair failed", "id", id, "pair", pair.Name, "error", result.Error)
+		} else {
+			result.Status = BatchCompleted
+		}
+		s.update(id, func(job *BatchJob) {
+			job.Pairs[i] = result
+			if result.Status == BatchFailed {
+				job.Failed++
+			} else {
+				job.Completed++
+			}
+		})
+	}
+
+	var finished BatchJob
+	s.update(id, func(job *BatchJob) {
+		now := time.Now()
+		job.Status = BatchCompleted
+		job.FinishedAt = &now
+		finished = job.copy()
+	})
+	batchLog.Info("Batch job finished", "id", id, "completed", finished.Completed, "failed", finished.Failed,
+		"duration_ms", time.Since(started).Milliseconds())
+	notifyBatchCompleted(finished)
+}
+
+// runBatchPair runs one pair, recording a panic as the pair's error so one
+// bad file neither kills the process nor leaves the job running
+func runBatchPair(runner BatchPairRunner, id string, pair BatchPair, profile MatchingProfile) (result BatchPairResult) {
+	defer func() {
+		if v := recover(); v != nil {
+			batchLog.Error("Batch pair panicked", "id", id, "pair", pair.Name, "panic", v, "stack", string(debug.Stack()))
+			result = BatchPairResult{Error: fmt.Sprintf("internal error: %v", v)}
+		}
+	}()
+	return runner(context.Background(), pair, profile)
+}
+
+// update changes a job under the lock and saves the store
+func (s *BatchStore) update(id string, change func(job *BatchJob)) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	change(s.jobs[id])
+	if err := s.save(); err != nil {
+		batchLog.Error("Error saving batch job", "error", err)
+	}
+}
+
+// copy returns a copy of the job whose pairs can be read without the lock
+func (job *BatchJob) copy() BatchJob {
+	c := *job
+	c.Pairs = append([]BatchPairResult(nil), job.Pairs...)
+	return c
+}
+
+// List returns the jobs, newest first, without their pairs
+func (s *BatchStore) List() []BatchJob {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	jobs := s.sorted()
+	list := make([]BatchJob, 0, len(jobs))
+	for i := len(jobs) - 1; i >= 0; i-- {
+		job := *jobs[i]
+		job.Pairs = nil
+		list = append(list, job)
+	}
+	return list
+}
+
+// Get looks up a job by ID
+func (s *BatchStore) Get(id string) (BatchJob, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	job, ok := s.jobs[id]
+	if !ok {
+		return BatchJob{}, false
+	}
+	return job.copy(), true
+}
+
+// BatchReportRow summarizes one pair of a batch report
+type BatchReportRow struct {
+	Name           string  `json:"name"`
+	Status         string  `json:"status"`
+	Error          string  `json:"error,omitempty"`
+	File1          string  `json:"file1"`
+	File2          string  `json:"file2"`
+	File1Rows      int     `json:"file1_rows"`
+	File2Rows      int     `json:"file2_rows"`
+	File1Columns   int     `json:"file1_columns"`
+	File2Columns   int     `json:"file2_columns"`
+	MatchedColumns int     `json:"matched_columns"`
+	Coverage       float64 `json:"coverage"` // Matched share of the columns of the narrower file, 0-1
+	UnmatchedFile1 int     `json:"unmatched_file1"`
+	UnmatchedFile2 int     `json:"unmatched_file2"`
+	JoinKey        string  `json:"join_key,omitempty"` // Best composite key, or the best 1:1 match
+}
+
+// BatchReport is the consolidated result of a batch job
+type BatchReport struct {
+	JobID        string           `json:"job_id"`
+	Name         string           `json:"name,omitempty"`
+	Status       string           `json:"status"`
+	Profile      string           `json:"profile"`
+	Pairs        int              `json:"pairs"`
+	Completed    int              `json:"completed"`
+	Failed       int              `json:"failed"`
+	MeanCoverage float64          `json:"mean_coverage"` // Over the completed pairs
+	Rows         []BatchReportRow `json:"rows"`
+}
+
+// Report consolidates a job's pairs into one row each
+func (job BatchJob) Report() BatchReport {
+	report := BatchReport{
+		JobID:     job.ID,
+		Name:      job.Name,
+		Status:    job.Status,
+		Profile:   job.Profile,
+		Pairs:     len(job.Pairs),
+		Completed: job.Completed,
+		Failed:    job.Failed,
+		Rows:      make([]BatchReportRow, 0, len(job.Pairs)),
+	}
+	var coverage float64
+	for _, p := range job.Pairs {
+		row := BatchReportRow{
+			Name:           p.Name,
+			Status:         p.Status,
+			Error:          p.Error,
+			File1:          p.File1.String(),
+			File2:          p.File2.String(),
+			MatchedColumns: len(p.Matches),
+			UnmatchedFile1: len(p.UnmatchedFile1),
+			UnmatchedFile2: len(p.UnmatchedFile2),
+		}
+		if f1, f2 := p.File1Summary, p.File2Summary; f1 != nil && f2 != nil {
+			row.File1, row.File2 = f1.Name, f2.Name
+			row.File1Rows, row.File2Rows = f1.Rows, f2.Rows
+			row.File1Columns, row.File2Columns = f1.Columns, f2.Columns
+			if narrower := min(f1.Columns, f2.Columns); narrower > 0 {
+				row.Coverage = float64(len(p.Matches)) / float64(narrower)
+			}
+		}
+		switch {
+		case len(p.CompositeKeys) > 0:
+			k := p.CompositeKeys[0]
+			row.JoinKey = fmt.Sprintf("%v = %v", k.File1Columns, k.File2Columns)
+		case len(p.Matches) > 0:
+			row.JoinKey = p.Matches[0].File1Column + " = " + p.Matches[0].File2Column
+		}
+		if p.Status == BatchCompleted {
+			coverage += row.Coverage
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	if job.Completed > 0 {
+		report.MeanCoverage = coverage / float64(job.Completed)
+	}
+	return report
+}
+
+// notifyBatchCompleted tells the webhooks a batch job finished
+func notifyBatchCompleted(job BatchJob) {
+	report := job.Report()
+	name := job.Name
+	if name == "" {
+		name = job.ID
+	}
+	GetWebhookStore().Notify(WebhookEvent{
+		Event: EventBatchCompleted,
+		Summary: fmt.Sprintf("Batch %q compared %d pairs: %d completed, %d failed, mean column coverage %.0f%%",
+			name, report.Pairs, report.Completed, report.Failed, report.MeanCoverage*100),
+		Data: report,
+	})
+}
+
+func newBatchJobID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "batch_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "batch_" + hex.EncodeToString(b)
+}
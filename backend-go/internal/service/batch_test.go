@@ -0,0 +1,40 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBatchPairPanic(t *testing.T) {
+	s := &BatchStore{jobs: make(map[string]*BatchJob), slot: make(chan struct{}, 1)}
+	s.SetRunner(func(ctx context.Context, pair BatchPair, profile MatchingProfile) BatchPairResult {
+		if pair.Name == "bad" {
+			panic("bad file")
+		}
+		return BatchPairResult{}
+	})
+
+	submitted, err := s.Submit("panic", "", []BatchPair{{Name: "bad"}, {Name: "good"}}, MatchingProfile{Name: "default"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		job, _ := s.Get(submitted.ID)
+		if job.Status == BatchCompleted {
+			if job.Failed != 1 || job.Completed != 1 {
+				t.Errorf("got %d failed and %d completed, want 1 of each", job.Failed, job.Completed)
+			}
+			if p := job.Pairs[0]; p.Status != BatchFailed || !strings.Contains(p.Error, "bad file") {
+				t.Errorf("panicking pair: got status %s and error %q", p.Status, p.Error)
+			}
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("job still %s", job.Status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Blocking modes for the similarity pipeline
+const (
+	BlockingAuto = "auto" // Block only when the pair count exceeds blockingAutoThreshold
+	BlockingOn   = "on"
+	BlockingOff  = "off"
+)
+
+// blockingAutoThreshold is the number of column pairs above which auto mode prunes
+const blockingAutoThreshold = 2500
+
+// blockingValueSample is how many distinct values per column feed the value index
+const blockingValueSample = 50
+
+// ColumnBlocker prunes obviously unrelated column pairs before pairwise scoring.
+// Two columns become a candidate pair when they share at least one blocking
+// key: a name token (or synonym), a token prefix, a glossary term, a name
+// MinHash bucket, a detected value pattern, a sampled normalized value, or a
+// numeric magnitude.
+type ColumnBlocker struct {
+	svc   *EnhancedSimilarityService
+	fuzzy *FuzzyMatcher
+}
+
+// NewColumnBlocker creates a blocker that reuses the service's synonyms
+func NewColumnBlocker(svc *EnhancedSimilarityService) *ColumnBlocker {
+	return &ColumnBlocker{svc: svc, fuzzy: NewFuzzyMatcher()}
+}
+
+// CandidatePairs returns, for each file1 column index, the file2 column
+// indices worth comparing
+func (b *ColumnBlocker) CandidatePairs(
+	df1, df2 *state.DataFrame,
+	profiles1, profiles2 []*ColumnProfile,
+	ctx1, ctx2 *models.Context,
+) map[int][]int {
+	keys1 := b.columnKeys(profiles1)
+	keys2 := b.columnKeys(profiles2)
+
+	// Inverted index over file2 columns
+	index := make(map[string][]int)
+	for col2Idx, keys := range keys2 {
+		for key := range keys {
+			index[key] = append(index[key], col2Idx)
+		}
+	}
+
+	col2ByName := make(map[string]int, len(df2.Headers))
+	for i, h := range df2.Headers {
+		col2ByName[h] = i
+	}
+
+	_, declared := DeclaredRelationship(ctx1, ctx2)
+	candidates := make(map[int][]int, len(df1.Headers))
+	for col1Idx, keys := range keys1 {
+		seen := make(map[int]bool)
+		for key := range keys {
+			for _, col2Idx := range index[key] {
+				seen[col2Idx] = true
+			}
+		}
+
+		// Explicit context mappings and declared join keys are never pruned
+		if ctx1 != nil {
+			if target, ok := ctx1.CustomMappings[df1.Headers[col1Idx]]; ok {
+				if col2Idx, ok := col2ByName[target]; ok {
+					seen[col2Idx] = true
+				}
+			}
+		}
+		for _, k := range declared {
+			if k.File1Column != df1.Headers[col1Idx] {
+				continue
+			}
+			if col2Idx, ok := col2ByName[k.File2Column]; ok {
+				seen[col2Idx] = true
+			}
+		}
+
+		cols := make([]int, 0, len(seen))
+		for col2Idx := range seen {
+			cols = append(cols, col2Idx)
+		}
+		sort.Ints(cols)
+		candidates[col1Idx] = cols
+	}
+
+	return candidates
+}
+
+// columnKeys computes the blocking keys of every profiled column
+func (b *ColumnBlocker) columnKeys(profiles []*ColumnProfile) []map[string]bool {
+	all := make([]map[string]bool, len(profiles))
+
+	for colIdx, p := range profiles {
+		keys := make(map[string]bool)
+
+		// Name tokens, their synonyms and short prefixes
+		for _, token := range tokenize(p.Name) {
+			keys["tok:"+token] = true
+			for _, syn := range b.svc.synonyms[token] {
+				keys["tok:"+syn] = true
+			}
+			for _, syn := range GetGlossary().TokenSynonyms(token) {
+				keys["tok:"+syn] = true
+			}
+			if len(token) >= 3 {
+				keys["pre:"+token[:3]] = true
+			}
+		}
+		if term, ok := GetGlossary().Resolve(p.Name); ok {
+			keys["term:"+term] = true
+		}
+		keys["mh:"+strconv.FormatUint(b.fuzzy.minHash(normalize(p.Name)), 36)] = true
+
+		// Value pattern
+		if p.Pattern != "" {
+			keys["pat:"+p.Pattern] = true
+		}
+
+		if p.IsNumeric {
+			// Numeric columns block on order of magnitude (and its neighbours)
+			if mag, ok := medianMagnitude(p.FloatValues); ok {
+				for d := -1; d <= 1; d++ {
+					keys[fmt.Sprintf("mag:%d", mag+d)] = true
+				}
+			}
+		} else {
+			// Categorical columns block on a sample of normalized values
+			distinct := 0
+			for _, v := range p.Normalized {
+				if distinct >= blockingValueSample {
+					break
+				}
+				if v == "" || keys["val:"+v] {
+					continue
+				}
+				keys["val:"+v] = true
+				distinct++
+			}
+		}
+
+		all[colIdx] = keys
+	}
+
+	return all
+}
+
+// medianMagnitude returns floor(log10(|median|)), treating values below 1 as magnitude 0
+func medianMagnitude(vals []float64) (int, bool) {
+	if len(vals) == 0 {
+		return 0, false
+	}
+	sorted := make([]float64, len(vals))
+	copy(sorted, vals)
+	sort.Float64s(sorted)
+	median := math.Abs(sorted[len(sorted)/2])
+	if median < 1 {
+		return 0, true
+	}
+	return int(math.Floor(math.Log10(median))), true
+}
+
+// useBlocking decides whether to prune for this run
+func useBlocking(mode string, pairs int) bool {
+	switch strings.ToLower(mode) {
+	case BlockingOn:
+		return true
+	case BlockingOff:
+		return false
+	}
+	return pairs > blockingAutoThreshold
+}
@@ -0,0 +1,513 @@
+package service
+
+import (
+	"backend-go/internal/dateformat"
+	"backend-go/internal/nulltoken"
+	"backend-go/internal/state"
+	"backend-go/internal/textnorm"
+	"fmt"
+	"math"
+	"runtime"
+	"runtime/debug"
+	"sync"
+)
+
+// Sample sizes used when profiling a column. They match the per-pair sampling
+// the similarity pipeline used before profiles were precomputed.
+const (
+	profilePatternSample    = 50
+	profileValueSetSample   = 500
+	profileNormalizedSample = 200
+	profileFormatSample     = 10
+)
+
+// ColumnProfile holds everything the similarity pipeline needs to know about a
+// single column, computed once per run instead of once per pair
+type ColumnProfile struct {
+	Index     int
+	Name      string
+	Quality   DataQualityProfile
+	Pattern   string
+	IsNumeric bool
+	Format    string // First non-text format detected in the leading rows
+	// strftime format of the leading rows when Format is "date" and the
+	// detected layout has a portable equivalent
+	DateFormat string
+
+	// Numeric summary (only when IsNumeric)
+	FloatValues []float64
+	Mean, Std   float64
+	Min, Max    float64
+
+	// Lowercased distinct values of the leading rows, for Jaccard overlap
+	ValueSet map[string]bool
+
+	// Sketch of all values, built at ingest; used for overlap and cardinality
+	// when the column is larger than the value-set sample
+	Sketch           *state.ColumnSketch
+	DistinctEstimate float64
+
+	// Normalized value per leading row ("" when empty), plus the distinct set
+	// of all of them; pairs with fewer rows re-slice Normalized
+	Normalized    []string
+	NormalizedSet map[string]bool
+}
+
+// ProfileColumns returns the profiles of every column of a DataFrame, from the
+// profile cache when the file content is unchanged. A panic while profiling
+// is raised again in the caller's goroutine.
+func (s *EnhancedSimilarityService) ProfileColumns(df *state.DataFrame) []*ColumnProfile {
+	profiles, _, err := s.profileColumnsCached(df)
+	if err != nil {
+		panic(err)
+	}
+	return profiles
+}
+
+// profileColumnsCached is ProfileColumns that also reports whether the cache
+// was hit, and returns a panic while profiling as an error
+func (s *EnhancedSimilarityService) profileColumnsCached(df *state.DataFrame) ([]*ColumnProfile, bool, error) {
+	cache := GetColumnProfileCache()
+	hash := df.ContentHash()
+	if profiles, ok := cache.get(hash); ok && len(profiles) == len(df.Headers) {
+		return profiles, true, nil
+	}
+
+	profiles, err := s.computeColumnProfiles(df)
+	if err != nil {
+		return nil, false, err
+	}
+	cache.put(hash, profiles)
+	return profiles, false, nil
+}
+
+// computeColumnProfiles profiles every column of a DataFrame in parallel
+func (s *EnhancedSimilarityService) computeColumnProfiles(df *state.DataFrame) ([]*ColumnProfile, error) {
+	profiles := make([]*ColumnProfile, len(df.Headers))
+	numeric := df.GetNumericColumnIndices()
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var panics workerPanics
+	for w := 0; w < workerCount(len(df.Headers)); w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for colIdx := range jobs {
+				func() {
+					defer panics.catch("profiling column " + df.Headers[colIdx])
+					profiles[colIdx] = s.profileColumn(df, colIdx, numeric[colIdx])
+				}()
+			}
+		}()
+	}
+	for colIdx := range df.Headers {
+		jobs <- colIdx
+	}
+	close(jobs)
+	wg.Wait()
+
+	if err := panics.Err(); err != nil {
+		return nil, err
+	}
+	return profiles, nil
+}
+
+// profileColumn computes the profile of one column
+func (s *EnhancedSimilarityService) profileColumn(df *state.DataFrame, colIdx int, isNumeric bool) *ColumnProfile {
+	p := &ColumnProfile{
+		Index:     colIdx,
+		Name:      df.Headers[colIdx],
+		Quality:   s.qualityProfiler.ProfileColumn(df, colIdx),
+		Pattern:   s.detectPattern(df, colIdx),
+		IsNumeric: isNumeric,
+	}
+
+	if col := df.Column(colIdx); col != nil && !col.Sketch.Empty() {
+		p.Sketch = col.Sketch
+		p.DistinctEstimate = col.Sketch.Cardinality()
+	}
+
+	if isNumeric {
+		p.FloatValues = getFloatValues(df, colIdx)
+		p.Mean, p.Std = meanAndStd(p.FloatValues)
+		p.Min, p.Max = minMax(p.FloatValues)
+	}
+
+	// Null tokens are left out of the value sets as they are out of the
+	// column's sketch
+	isNull := nulltoken.ForColumn(p.Name)
+	p.ValueSet = make(map[string]bool)
+	for i := 0; i < len(df.Rows) && i < profileValueSetSample; i++ {
+		if colIdx < len(df.Rows[i]) && !isNull(df.Rows[i][colIdx]) {
+			p.ValueSet[textnorm.Fold(df.Rows[i][colIdx])] = true
+		}
+	}
+
+	normalizer := s.normalizedMatcher.normalizer
+	p.NormalizedSet = make(map[string]bool)
+	for i := 0; i < len(df.Rows) && i < profileNormalizedSample; i++ {
+		normalized := ""
+		if colIdx < len(df.Rows[i]) && !isNull(df.Rows[i][colIdx]) {
+			normalized = normalizer.NormalizeValue(df.Rows[i][colIdx])
+		}
+		p.Normalized = append(p.Normalized, normalized)
+		if normalized != "" {
+			p.NormalizedSet[normalized] = true
+		}
+	}
+
+	for i := 0; i < len(df.Rows) && i < profileFormatSample; i++ {
+		if colIdx < len(df.Rows[i]) && df.Rows[i][colIdx] != "" {
+			p.Format = normalizer.DetectFormat(df.Rows[i][colIdx])
+			if p.Format != "text" {
+				break
+			}
+		}
+	}
+
+	if p.Format == "date" {
+		values := make([]string, 0, profileNormalizedSample)
+		for i := 0; i < len(df.Rows) && i < profileNormalizedSample; i++ {
+			if colIdx < len(df.Rows[i]) {
+				values = append(values, df.Rows[i][colIdx])
+			}
+		}
+		if d := dateformat.DetectColumn(values, profileNormalizedSample); d.Detected && !d.IsEpoch() {
+			p.DateFormat, _ = strftimeFormat(d.Layout)
+		}
+	}
+
+	return p
+}
+
+// normalizedSetPrefix returns the distinct normalized values of the first n rows
+func (p *ColumnProfile) normalizedSetPrefix(n int) map[string]bool {
+	if n >= len(p.Normalized) {
+		return p.NormalizedSet
+	}
+	set := make(map[string]bool)
+	for _, v := range p.Normalized[:n] {
+		if v != "" {
+			set[v] = true
+		}
+	}
+	return set
+}
+
+// profileNormalizedMatch is the Jaccard similarity of normalized values over
+// the rows both columns have (up to profileNormalizedSample)
+func profileNormalizedMatch(p1, p2 *ColumnProfile) float64 {
+	n := len(p1.Normalized)
+	if len(p2.Normalized) < n {
+		n = len(p2.Normalized)
+	}
+	return jaccardSets(p1.normalizedSetPrefix(n), p2.normalizedSetPrefix(n))
+}
+
+// profileValueOverlap is the Jaccard similarity of raw (lowercased) values.
+// Small columns are compared exactly; when either column has more rows than
+// the value-set sample, the full-column MinHash estimate is used instead.
+func profileValueOverlap(p1, p2 *ColumnProfile) float64 {
+	sampled := p1.Quality.TotalRows > profileValueSetSample || p2.Quality.TotalRows > profileValueSetSample
+	if sampled && p1.Sketch != nil && p2.Sketch != nil {
+		return p1.Sketch.Jaccard(p2.Sketch)
+	}
+	return jaccardSets(p1.ValueSet, p2.ValueSet)
+}
+
+// pairSampleSize is the number of values the data similarity of two columns
+// rests on: the shorter numeric sample, or the smaller distinct value count
+// (estimated from the sketches when the value sets are samples). Columns of
+// different kinds share no data evidence.
+func pairSampleSize(p1, p2 *ColumnProfile) int {
+	if p1.IsNumeric != p2.IsNumeric {
+		return 0
+	}
+	if p1.IsNumeric {
+		return min(len(p1.FloatValues), len(p2.FloatValues))
+	}
+	sampled := p1.Quality.TotalRows > profileValueSetSample || p2.Quality.TotalRows > profileValueSetSample
+	if sampled && p1.Sketch != nil && p2.Sketch != nil {
+		return int(math.Min(p1.DistinctEstimate, p2.DistinctEstimate))
+	}
+	return min(len(p1.ValueSet), len(p2.ValueSet))
+}
+
+// profileDistributionSimilarity compares the binned distributions of two
+// numeric columns, then their coefficient of variation and range. The
+// histograms share their bins, so columns over different ranges (ages and
+// order quantities, both with a small CV) score low.
+func profileDistributionSimilarity(p1, p2 *ColumnProfile) float64 {
+	if len(p1.FloatValues) < 5 || len(p2.FloatValues) < 5 {
+		return 0
+	}
+
+	// Coefficient of Variation similarity
+	cv1, cv2 := 0.0, 0.0
+	if p1.Mean != 0 {
+		cv1 = p1.Std / math.Abs(p1.Mean)
+	}
+	if p2.Mean != 0 {
+		cv2 = p2.Std / math.Abs(p2.Mean)
+	}
+	cvSim := math.Max(0, 1-math.Abs(cv1-cv2))
+
+	// Range similarity (normalized)
+	range1 := p1.Max - p1.Min
+	range2 := p2.Max - p2.Min
+	rangeSim := 0.0
+	if range1 > 0 && range2 > 0 {
+		rangeSim = math.Min(range1, range2) / math.Max(range1, range2)
+	}
+
+	histSim := histogramSimilarity(p1.FloatValues, p2.FloatValues)
+	return (histSim * 0.6) + (cvSim * 0.25) + (rangeSim * 0.15)
+}
+
+// Bins of the histograms compared by histogramSimilarity: the square root of
+// the shorter sample, within these bounds
+const (
+	histogramMinBins = 5
+	histogramMaxBins = 20
+)
+
+// histogramSimilarity bins both samples over their common range and averages
+// the histogram intersection with one minus the symmetric chi-square
+// distance of the bin shares; both are 1 for identical histograms and 0 for
+// disjoint ones. NaN and infinite values are left out.
+func histogramSimilarity(a, b []float64) float64 {
+	a, b = finiteValues(a), finiteValues(b)
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	lo, hi := math.Inf(1), math.Inf(-1)
+	for _, vals := range [][]float64{a, b} {
+		for _, v := range vals {
+			lo, hi = math.Min(lo, v), math.Max(hi, v)
+		}
+	}
+	if hi == lo {
+		return 1 // Both constant and equal
+	}
+
+	bins := int(math.Sqrt(float64(min(len(a), len(b)))))
+	bins = max(histogramMinBins, min(histogramMaxBins, bins))
+	share := func(vals []float64) []float64 {
+		h := make([]float64, bins)
+		for _, v := range vals {
+			i := max(0, min(int((v-lo)/(hi-lo)*float64(bins)), bins-1))
+			h[i]++
+		}
+		for i := range h {
+			h[i] /= float64(len(vals))
+		}
+		return h
+	}
+	h1, h2 := share(a), share(b)
+
+	intersection, chiSquare := 0.0, 0.0
+	for i := range h1 {
+		intersection += math.Min(h1[i], h2[i])
+		if sum := h1[i] + h2[i]; sum > 0 {
+			chiSquare += (h1[i] - h2[i]) * (h1[i] - h2[i]) / sum
+		}
+	}
+	// The symmetric chi-square of shares lies in [0, 2]
+	return math.Max(0, (intersection+(1-chiSquare/2))/2)
+}
+
+// finiteValues returns the values that are neither NaN nor infinite,
+// sharing vals when all of them are
+func finiteValues(vals []float64) []float64 {
+	for i, v := range vals {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			finite := append([]float64{}, vals[:i]...)
+			for _, v := range vals[i+1:] {
+				if !math.IsNaN(v) && !math.IsInf(v, 0) {
+					finite = append(finite, v)
+				}
+			}
+			return finite
+		}
+	}
+	return vals
+}
+
+// profileFormatTransformation reports whether both columns share a non-text
+// format and agree once normalized
+func profileFormatTransformation(p1, p2 *ColumnProfile, normalizedMatch float64) (bool, string) {
+	if p1.Format != "" && p1.Format == p2.Format && p1.Format != "text" && normalizedMatch > 0.5 {
+		return true, p1.Format
+	}
+	return false, ""
+}
+
+// jaccardSets computes |a ∩ b| / |a ∪ b|
+func jaccardSets(a, b map[string]bool) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	if len(b) < len(a) {
+		a, b = b, a
+	}
+	intersection := 0
+	for k := range a {
+		if b[k] {
+			intersection++
+		}
+	}
+	union := len(a) + len(b) - intersection
+	if union == 0 {
+		return 0
+	}
+	return float64(intersection) / float64(union)
+}
+
+// workerPanics collects the panics of worker goroutines, which the HTTP
+// recoverer doesn't see, so the caller can return the first as an error
+type workerPanics struct {
+	mu  sync.Mutex
+	err error
+}
+
+// catch must be deferred directly by the job; the worker then goes on
+// taking jobs, so the sender never blocks
+func (p *workerPanics) catch(job string) {
+	v := recover()
+	if v == nil {
+		return
+	}
+	similarityLog.Error("Worker panicked", "job", job, "panic", v, "stack", string(debug.Stack()))
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.err == nil {
+		p.err = fmt.Errorf("%s: panic: %v", job, v)
+	}
+}
+
+// Err returns the first panic, or nil
+func (p *workerPanics) Err() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.err
+}
+
+// workerCount bounds the worker pool by CPU count and the amount of work
+func workerCount(jobs int) int {
+	n := runtime.NumCPU()
+	if jobs < n {
+		n = jobs
+	}
+	if n < 1 {
+		n = 1
+	}
+	return n
+}
+
+// maxCachedFrames bounds how many distinct file versions keep cached profiles
+const maxCachedFrames = 8
+
+// ColumnProfileCache keeps column profiles keyed by file content hash and column
+// index, so repeated similarity runs over unchanged files skip profiling
+type ColumnProfileCache struct {
+	frames map[string][]*ColumnProfile // content hash -> profiles by column index
+	order  []string                    // least recently used first
+	hits   int
+	misses int
+	mutex  sync.Mutex
+}
+
+// ProfileCacheStats reports cache usage
+type ProfileCacheStats struct {
+	Frames int `json:"frames"`
+	Hits   int `json:"hits"`
+	Misses int `json:"misses"`
+}
+
+var (
+	profileCache     *ColumnProfileCache
+	profileCacheOnce sync.Once
+)
+
+// GetColumnProfileCache returns the singleton profile cache
+func GetColumnProfileCache() *ColumnProfileCache {
+	profileCacheOnce.Do(func() {
+		profileCache = &ColumnProfileCache{
+			frames: make(map[string][]*ColumnProfile),
+		}
+	})
+	return profileCache
+}
+
+// get returns the cached profiles for a content hash, if any
+func (c *ColumnProfileCache) get(hash string) ([]*ColumnProfile, bool) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	profiles, ok := c.frames[hash]
+	if !ok {
+		c.misses++
+		return nil, false
+	}
+	c.hits++
+	c.touch(hash)
+	return profiles, true
+}
+
+// put stores profiles for a content hash, evicting the least recently used frame
+func (c *ColumnProfileCache) put(hash string, profiles []*ColumnProfile) {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	c.frames[hash] = profiles
+	c.touch(hash)
+	for len(c.order) > maxCachedFrames {
+		delete(c.frames, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// touch moves a hash to the most recently used position (must hold lock)
+func (c *ColumnProfileCache) touch(hash string) {
+	for i, h := range c.order {
+		if h == hash {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+	c.order = append(c.order, hash)
+}
+
+// InvalidateDataFrame drops cached profiles for a DataFrame, e.g. when it is replaced
+func (c *ColumnProfileCache) InvalidateDataFrame(df *state.DataFrame) {
+	if df == nil {
+		return
+	}
+	hash := df.ContentHash()
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	delete(c.frames, hash)
+	for i, h := range c.order {
+		if h == hash {
+			c.order = append(c.order[:i], c.order[i+1:]...)
+			break
+		}
+	}
+}
+
+// Clear drops every cached profile
+func (c *ColumnProfileCache) Clear() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.frames = make(map[string][]*ColumnProfile)
+	c.order = nil
+}
+
+// Stats returns cache usage counters
+func (c *ColumnProfileCache) Stats() ProfileCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return ProfileCacheStats{Frames: len(c.frames), Hits: c.hits, Misses: c.misses}
+}
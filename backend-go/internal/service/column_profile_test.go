@@ -0,0 +1,40 @@
+package service
+
+import (
+	"math"
+	"strconv"
+	"testing"
+
+	"backend-go/internal/state"
+)
+
+func TestHistogramSimilarityNonFinite(t *testing.T) {
+	a := []float64{1, 2, 3, math.NaN(), 4, 5, math.Inf(1), 6, math.Inf(-1)}
+	b := []float64{1, 2, 3, 4, 5, 6}
+	if got := histogramSimilarity(a, b); math.Abs(got-1) > 1e-9 {
+		t.Errorf("got %v, want 1 with the non-finite values left out", got)
+	}
+	if got := histogramSimilarity([]float64{math.NaN(), math.Inf(1)}, b); got != 0 {
+		t.Errorf("got %v for no finite values, want 0", got)
+	}
+}
+
+func TestDistributionSimilarityNaNColumn(t *testing.T) {
+	// The non-numeric cells come after the rows sampled for the column type
+	rows := func(special ...string) [][]string {
+		out := [][]string{}
+		for i := 1; i <= 30; i++ {
+			out = append(out, []string{strconv.Itoa(i)})
+		}
+		for _, v := range special {
+			out = append(out, []string{v})
+		}
+		return out
+	}
+	df1 := &state.DataFrame{Headers: []string{"amount"}, Rows: rows("NaN", "Inf", "-Infinity")}
+	df2 := &state.DataFrame{Headers: []string{"amount"}, Rows: rows()}
+
+	// Neither the AI matcher's nor the profile's distribution may panic
+	calculateDistributionSim(df1, df2, 0, 0)
+	NewEnhancedSimilarityService(nil).CalculateEnhancedSimilarity(df1, df2, nil, nil)
+}
@@ -0,0 +1,91 @@
+package service
+
+import (
+	"backend-go/internal/nulltoken"
+	"backend-go/internal/state"
+	"backend-go/internal/textnorm"
+	"sort"
+	"strings"
+)
+
+// ColumnValue is a distinct raw value of a column with the forms the
+// similarity pipeline compares
+type ColumnValue struct {
+	Value      string `json:"value"`
+	Count      int    `json:"count"`
+	Folded     string `json:"folded"`     // Compared by value overlap
+	Normalized string `json:"normalized"` // Compared by normalized match
+	FirstRow   int    `json:"first_row"`  // 0-based
+	// Whether the first occurrence falls within the leading rows the
+	// value-set and normalized samples of the column profile are taken from
+	InValueSample      bool `json:"in_value_sample"`
+	InNormalizedSample bool `json:"in_normalized_sample"`
+}
+
+// ColumnValues are the distinct values of a column and how its value
+// overlap is computed
+type ColumnValues struct {
+	Values []ColumnValue
+	Rows   int
+	Empty  int // Empty cells and null tokens, which are never compared
+	// Leading rows the value-set and normalized samples are taken from.
+	// Sketched is set when the column has more rows than the value-set
+	// sample, so value overlap against it uses the MinHash sketches.
+	ValueSample      int
+	NormalizedSample int
+	Sketched         bool
+}
+
+// ColumnValues returns the distinct values of a column, most frequent
+// first (sortBy "value" orders them by value), with their folded and
+// normalized forms. A search keeps the values whose folded or normalized
+// form contains it, folded too.
+func (s *EnhancedSimilarityService) ColumnValues(df *state.DataFrame, colIdx int, sortBy, search string) *ColumnValues {
+	result := &ColumnValues{
+		Values:           []ColumnValue{},
+		Rows:             len(df.Rows),
+		ValueSample:      profileValueSetSample,
+		NormalizedSample: profileNormalizedSample,
+		Sketched:         len(df.Rows) > profileValueSetSample,
+	}
+
+	isNull := nulltoken.ForColumn(df.Headers[colIdx])
+	byValue := make(map[string]int)
+	for i, row := range df.Rows {
+		if colIdx >= len(row) || isNull(row[colIdx]) {
+			result.Empty++
+			continue
+		}
+		v := row[colIdx]
+		if idx, ok := byValue[v]; ok {
+			result.Values[idx].Count++
+			continue
+		}
+		byValue[v] = len(result.Values)
+		result.Values = append(result.Values, ColumnValue{Value: v, Count: 1, FirstRow: i})
+	}
+
+	normalizer := s.normalizedMatcher.normalizer
+	search = textnorm.Fold(search)
+	kept := result.Values[:0]
+	for _, cv := range result.Values {
+		cv.Folded = textnorm.Fold(cv.Value)
+		cv.Normalized = normalizer.NormalizeValue(cv.Value)
+		cv.InValueSample = cv.FirstRow < profileValueSetSample
+		cv.InNormalizedSample = cv.FirstRow < profileNormalizedSample
+		if search != "" && !strings.Contains(cv.Folded, search) && !strings.Contains(textnorm.Fold(cv.Normalized), search) {
+			continue
+		}
+		kept = append(kept, cv)
+	}
+	result.Values = kept
+
+	sort.SliceStable(result.Values, func(i, j int) bool {
+		a, b := result.Values[i], result.Values[j]
+		if sortBy != "value" && a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+	return result
+}
@@ -0,0 +1,212 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"sort"
+	"strings"
+)
+
+// Composite join key search limits
+const (
+	compositeKeySampleRows  = 5000 // Rows the uniqueness search looks at
+	compositeKeyMinCoverage = 0.3  // Coverage a proposal needs on either side
+	compositeKeyMinMatch    = 50.0 // Confidence a column match needs to map key columns
+	compositeKeyMaxResults  = 5
+)
+
+// SuggestCompositeKeys proposes multi-column join keys: column combinations
+// that are unique in one file (CrossColumnDetector) and whose matched columns
+// in the other file are unique too, ranked by how many rows the
+// concatenated, normalized keys connect. matches are the column matches of
+// the two files; each column takes part in at most one of them.
+func (s *EnhancedSimilarityService) SuggestCompositeKeys(df1, df2 *state.DataFrame, matches []AssignmentCandidate) []models.CompositeJoinKey {
+	results := []models.CompositeJoinKey{}
+	if df1 == nil || df2 == nil || len(df1.Rows) == 0 || len(df2.Rows) == 0 {
+		return results
+	}
+
+	confident := []AssignmentCandidate{}
+	for _, m := range matches {
+		if m.Confidence >= compositeKeyMinMatch {
+			confident = append(confident, m)
+		}
+	}
+	to2 := make(map[string]string)
+	to1 := make(map[string]string)
+	for _, i := range SelectAssignment(confident, AssignmentOneToOne) {
+		to2[confident[i].File1Column] = confident[i].File2Column
+		to1[confident[i].File2Column] = confident[i].File1Column
+	}
+
+	detector := NewCrossColumnDetector()
+	sample1, sample2 := sampleRows(df1, compositeKeySampleRows), sampleRows(df2, compositeKeySampleRows)
+
+	// Candidate pairs of column lists, from either file's composite keys
+	seen := make(map[string]bool)
+	type candidate struct{ cols1, cols2 []string }
+	candidates := []candidate{}
+	add := func(cols1, cols2 []string) {
+		id := strings.Join(cols1, "\x1f") + "\x1e" + strings.Join(cols2, "\x1f")
+		if !seen[id] {
+			seen[id] = true
+			candidates = append(candidates, candidate{cols1, cols2})
+		}
+	}
+	for _, k := range minimalCompositeKeys(detector, sample1) {
+		if cols2, ok := mapColumns(k.Columns, to2); ok {
+			add(k.Columns, cols2)
+		}
+	}
+	for _, k := range minimalCompositeKeys(detector, sample2) {
+		if cols1, ok := mapColumns(k.Columns, to1); ok {
+			add(cols1, k.Columns)
+		}
+	}
+
+	normalizer := s.normalizedMatcher.normalizer
+	for _, c := range candidates {
+		idx1, idx2 := columnIndices(df1, c.cols1), columnIndices(df2, c.cols2)
+		uniq1 := detector.calculateCompositeUniqueness(sample1, columnIndices(sample1, c.cols1))
+		uniq2 := detector.calculateCompositeUniqueness(sample2, columnIndices(sample2, c.cols2))
+		if uniq1 <= 0.95 && uniq2 <= 0.95 {
+			continue
+		}
+
+		keys1, total1 := compositeKeyValues(df1, idx1, normalizer)
+		keys2, total2 := compositeKeyValues(df2, idx2, normalizer)
+		covered1, covered2, matched := 0, 0, 0
+		for k, n := range keys1 {
+			if _, ok := keys2[k]; ok {
+				covered1 += n
+				matched++
+			}
+		}
+		for k, n := range keys2 {
+			if _, ok := keys1[k]; ok {
+				covered2 += n
+			}
+		}
+		if matched == 0 || total1 == 0 || total2 == 0 {
+			continue
+		}
+
+		key := models.CompositeJoinKey{
+			File1Columns:    c.cols1,
+			File2Columns:    c.cols2,
+			File1Uniqueness: uniq1,
+			File2Uniqueness: uniq2,
+			File1Coverage:   float64(covered1) / float64(len(df1.Rows)),
+			File2Coverage:   float64(covered2) / float64(len(df2.Rows)),
+			MatchedKeys:     matched,
+		}
+		if key.File1Coverage < compositeKeyMinCoverage && key.File2Coverage < compositeKeyMinCoverage {
+			continue
+		}
+		// Mean coverage, discounted when a side isn't unique
+		key.Confidence = 100 * (key.File1Coverage + key.File2Coverage) / 2
+		if uniq1 < 0.95 || uniq2 < 0.95 {
+			key.Confidence *= (uniq1 + uniq2) / 2
+		}
+		results = append(results, key)
+	}
+
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Confidence > results[j].Confidence })
+	if len(results) > compositeKeyMaxResults {
+		results = results[:compositeKeyMaxResults]
+	}
+	return results
+}
+
+// minimalCompositeKeys returns the composite keys of df that contain no
+// single unique column and no smaller composite key
+func minimalCompositeKeys(detector *CrossColumnDetector, df *state.DataFrame) []CompositeKey {
+	unique := make(map[string]bool)
+	for i, h := range df.Headers {
+		if detector.calculateCompositeUniqueness(df, []int{i}) > 0.95 {
+			unique[h] = true
+		}
+	}
+
+	keys := detector.DetectCompositeKeys(df)
+	pairs := make(map[string]bool)
+	minimal := []CompositeKey{}
+	for _, k := range keys {
+		redundant := false
+		for _, c := range k.Columns {
+			if unique[c] {
+				redundant = true
+			}
+		}
+		if len(k.Columns) == 3 {
+			for i := 0; i < 3; i++ {
+				for j := i + 1; j < 3; j++ {
+					if pairs[k.Columns[i]+"\x1f"+k.Columns[j]] {
+						redundant = true
+					}
+				}
+			}
+		}
+		if redundant {
+			continue
+		}
+		if len(k.Columns) == 2 {
+			pairs[k.Columns[0]+"\x1f"+k.Columns[1]] = true
+		}
+		minimal = append(minimal, k)
+	}
+	return minimal
+}
+
+// mapColumns translates column names through a one-to-one match
+func mapColumns(cols []string, match map[string]string) ([]string, bool) {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		m, ok := match[c]
+		if !ok {
+			return nil, false
+		}
+		out[i] = m
+	}
+	return out, true
+}
+
+// columnIndices returns the indices of named columns
+func columnIndices(df *state.DataFrame, cols []string) []int {
+	idx := make([]int, len(cols))
+	for i, c := range cols {
+		idx[i] = columnIndex(df, c)
+	}
+	return idx
+}
+
+// compositeKeyValues counts rows per normalized concatenated key; rows with
+// an empty key part are skipped. total is the number of counted rows.
+func compositeKeyValues(df *state.DataFrame, idx []int, normalizer *FormatNormalizer) (map[string]int, int) {
+	values := make(map[string]int)
+	total := 0
+	parts := make([]string, len(idx))
+	for _, row := range df.Rows {
+		complete := true
+		for i, c := range idx {
+			if c < 0 || c >= len(row) || strings.TrimSpace(row[c]) == "" {
+				complete = false
+				break
+			}
+			parts[i] = normalizer.NormalizeValue(strings.TrimSpace(row[c]))
+		}
+		if complete {
+			values[strings.Join(parts, "\x1f")]++
+			total++
+		}
+	}
+	return values, total
+}
+
+// sampleRows returns df limited to its first n rows
+func sampleRows(df *state.DataFrame, n int) *state.DataFrame {
+	if len(df.Rows) <= n {
+		return df
+	}
+	return &state.DataFrame{Headers: df.Headers, Rows: df.Rows[:n]}
+}
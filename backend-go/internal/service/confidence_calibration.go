@@ -1,15 +1,20 @@
 package service
 
 import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
 	"encoding/json"
-	"log"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"sync"
 	"time"
 )
 
-const confidenceCalibrationFile = "./data/confidence_calibration.json"
+var calibratorLog = logging.Component("calibrator")
+
+const confidenceCalibrationFile = "confidence_calibration.json"
 
 // CalibrationBucket represents a confidence range bucket
 type CalibrationBucket struct {
@@ -71,13 +76,13 @@ func initializeBuckets() []CalibrationBucket {
 
 // load loads calibration data from file
 func (c *ConfidenceCalibrator) load() {
-	dir := filepath.Dir(confidenceCalibrationFile)
+	dir := filepath.Dir(config.DataPath(confidenceCalibrationFile))
 	os.MkdirAll(dir, 0755)
 
-	data, err := os.ReadFile(confidenceCalibrationFile)
+	data, err := os.ReadFile(config.DataPath(confidenceCalibrationFile))
 	if err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("[Calibrator] Error loading calibration: %v", err)
+			calibratorLog.Error("Error loading calibration", "error", err)
 		}
 		return
 	}
@@ -87,7 +92,7 @@ func (c *ConfidenceCalibrator) load() {
 		History []CalibrationHistory `json:"history"`
 	}
 	if err := json.Unmarshal(data, &saved); err != nil {
-		log.Printf("[Calibrator] Error parsing calibration: %v", err)
+		calibratorLog.Error("Error parsing calibration", "error", err)
 		return
 	}
 
@@ -98,7 +103,7 @@ func (c *ConfidenceCalibrator) load() {
 	c.history = saved.History
 	c.mutex.Unlock()
 
-	log.Printf("[Calibrator] Loaded calibration data")
+	calibratorLog.Info("Loaded calibration data")
 }
 
 // save persists calibration data to file
@@ -114,10 +119,10 @@ func (c *ConfidenceCalibrator) save() error {
 		return err
 	}
 
-	dir := filepath.Dir(confidenceCalibrationFile)
+	dir := filepath.Dir(config.DataPath(confidenceCalibrationFile))
 	os.MkdirAll(dir, 0755)
 
-	return os.WriteFile(confidenceCalibrationFile, data, 0644)
+	return os.WriteFile(config.DataPath(confidenceCalibrationFile), data, 0644)
 }
 
 // Update records a new prediction outcome and updates calibration
@@ -168,8 +173,8 @@ func (c *ConfidenceCalibrator) Update(predictedConfidence float64, actualCorrect
 	// Save async
 	go c.save()
 
-	log.Printf("[Calibrator] Updated bucket %d: count=%d, accuracy=%.2f, factor=%.2f",
-		bucketIdx, c.buckets[bucketIdx].TotalCount, c.buckets[bucketIdx].ActualAccuracy, c.buckets[bucketIdx].CalibrationFactor)
+	calibratorLog.Debug("Updated bucket", "bucket", bucketIdx, "count", c.buckets[bucketIdx].TotalCount,
+		"accuracy", c.buckets[bucketIdx].ActualAccuracy, "factor", c.buckets[bucketIdx].CalibrationFactor)
 }
 
 // Calibrate returns a calibrated confidence score
@@ -244,3 +249,174 @@ func (c *ConfidenceCalibrator) GetCalibrationStats() map[string]interface{} {
 		"buckets":          c.buckets,
 	}
 }
+
+// Merge combines imported bucket counts with the local buckets
+func (c *ConfidenceCalibrator) Merge(buckets []CalibrationBucket, strategy string) error {
+	if len(buckets) != 10 {
+		return fmt.Errorf("expected 10 calibration buckets, got %d", len(buckets))
+	}
+	if strategy == MergeStrategyKeepLocal {
+		return nil
+	}
+
+	c.mutex.Lock()
+	for i := range c.buckets {
+		if strategy == MergeStrategyReplace {
+			c.buckets[i].TotalCount = buckets[i].TotalCount
+			c.buckets[i].CorrectCount = buckets[i].CorrectCount
+		} else {
+			c.buckets[i].TotalCount += buckets[i].TotalCount
+			c.buckets[i].CorrectCount += buckets[i].CorrectCount
+		}
+		c.recomputeBucket(i)
+	}
+	c.mutex.Unlock()
+
+	return c.save()
+}
+
+// recomputeBucket refreshes accuracy and calibration factor from counts (must hold lock)
+func (c *ConfidenceCalibrator) recomputeBucket(i int) {
+	b := &c.buckets[i]
+	expectedAccuracy := (b.RangeMin + b.RangeMax) / 200.0
+	if b.TotalCount == 0 {
+		b.ActualAccuracy = expectedAccuracy
+		b.CalibrationFactor = 1.0
+		return
+	}
+	b.ActualAccuracy = float64(b.CorrectCount) / float64(b.TotalCount)
+	if expectedAccuracy > 0 {
+		b.CalibrationFactor = b.ActualAccuracy / expectedAccuracy
+	}
+}
+
+// Revise swaps one feedback entry's outcome in the buckets for its edited
+// version, or just removes it when updated is nil. Counts from other feedback
+// and from imported calibration are left alone.
+func (c *ConfidenceCalibrator) Revise(old FeedbackEntry, updated *FeedbackEntry) {
+	c.mutex.Lock()
+	idx := bucketIndex(old.Confidence)
+	if c.buckets[idx].TotalCount > 0 {
+		c.buckets[idx].TotalCount--
+		if old.IsCorrect && c.buckets[idx].CorrectCount > 0 {
+			c.buckets[idx].CorrectCount--
+		}
+		c.recomputeBucket(idx)
+	}
+	if updated != nil {
+		idx = bucketIndex(updated.Confidence)
+		c.buckets[idx].TotalCount++
+		if updated.IsCorrect {
+			c.buckets[idx].CorrectCount++
+		}
+		c.recomputeBucket(idx)
+	}
+	c.mutex.Unlock()
+
+	if err := c.save(); err != nil {
+		calibratorLog.Error("Error saving calibration", "error", err)
+	}
+}
+
+// bucketIndex maps a confidence (0-100) to its bucket
+func bucketIndex(confidence float64) int {
+	idx := int(confidence / 10)
+	if idx >= 10 {
+		idx = 9
+	}
+	if idx < 0 {
+		idx = 0
+	}
+	return idx
+}
+
+// ReliabilityPoint is one bar of a reliability diagram (predicted vs actual accuracy)
+type ReliabilityPoint struct {
+	RangeMin          float64 `json:"range_min"`
+	RangeMax          float64 `json:"range_max"`
+	Count             int     `json:"count"`
+	MeanPredicted     float64 `json:"mean_predicted"`  // 0-1, from history when available, else bucket midpoint
+	ActualAccuracy    float64 `json:"actual_accuracy"` // 0-1, 0 when the bucket is empty
+	Gap               float64 `json:"gap"`             // actual - predicted (negative = overconfident)
+	CalibrationFactor float64 `json:"calibration_factor"`
+}
+
+// ReliabilityReport summarizes how well reported confidences match outcomes
+type ReliabilityReport struct {
+	Points                 []ReliabilityPoint `json:"points"`
+	TotalSamples           int                `json:"total_samples"`
+	ExpectedCalibrationErr float64            `json:"expected_calibration_error"` // Count-weighted mean |gap|
+	MaxCalibrationErr      float64            `json:"max_calibration_error"`
+	BrierScore             float64            `json:"brier_score"` // Over recent history; lower is better
+	HistorySamples         int                `json:"history_samples"`
+}
+
+// GetReliabilityReport builds reliability-diagram data from the buckets and history
+func (c *ConfidenceCalibrator) GetReliabilityReport() ReliabilityReport {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+
+	predictedSum := make([]float64, len(c.buckets))
+	predictedCount := make([]int, len(c.buckets))
+	brier := 0.0
+	for _, h := range c.history {
+		idx := bucketIndex(h.PredictedConf)
+		predictedSum[idx] += h.PredictedConf / 100
+		predictedCount[idx]++
+
+		outcome := 0.0
+		if h.ActualCorrect {
+			outcome = 1.0
+		}
+		p := h.PredictedConf / 100
+		brier += (p - outcome) * (p - outcome)
+	}
+
+	report := ReliabilityReport{
+		Points:         make([]ReliabilityPoint, 0, len(c.buckets)),
+		HistorySamples: len(c.history),
+	}
+	if len(c.history) > 0 {
+		report.BrierScore = brier / float64(len(c.history))
+	}
+
+	weightedErr := 0.0
+	for i, b := range c.buckets {
+		meanPredicted := (b.RangeMin + b.RangeMax) / 200.0
+		if predictedCount[i] > 0 {
+			meanPredicted = predictedSum[i] / float64(predictedCount[i])
+		}
+
+		point := ReliabilityPoint{
+			RangeMin:          b.RangeMin,
+			RangeMax:          b.RangeMax,
+			Count:             b.TotalCount,
+			MeanPredicted:     meanPredicted,
+			CalibrationFactor: b.CalibrationFactor,
+		}
+		if b.TotalCount > 0 {
+			point.ActualAccuracy = float64(b.CorrectCount) / float64(b.TotalCount)
+			point.Gap = point.ActualAccuracy - meanPredicted
+			weightedErr += math.Abs(point.Gap) * float64(b.TotalCount)
+			report.MaxCalibrationErr = math.Max(report.MaxCalibrationErr, math.Abs(point.Gap))
+		}
+		report.TotalSamples += b.TotalCount
+		report.Points = append(report.Points, point)
+	}
+	if report.TotalSamples > 0 {
+		report.ExpectedCalibrationErr = weightedErr / float64(report.TotalSamples)
+	}
+
+	return report
+}
+
+// Reset discards all calibration data
+func (c *ConfidenceCalibrator) Reset() error {
+	c.mutex.Lock()
+	c.buckets = initializeBuckets()
+	c.history = []CalibrationHistory{}
+	c.mutex.Unlock()
+
+	calibratorLog.Info("Calibration reset")
+	return c.save()
+}
@@ -3,6 +3,7 @@ package service
 import (
 	"backend-go/internal/models"
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 )
@@ -60,6 +61,18 @@ func (s *ContextService) MergeContext(existing *models.Context, newCtx *models.C
 		existing.Exclusions = append(existing.Exclusions, newCtx.Exclusions...)
 		existing.Exclusions = uniqueStrings(existing.Exclusions)
 	}
+	if newCtx.RelationshipType != "" {
+		existing.RelationshipType = newCtx.RelationshipType
+	}
+	if len(newCtx.JoinKeys) > 0 {
+		existing.JoinKeys = newCtx.JoinKeys
+	}
+	for k, v := range newCtx.DomainDetails {
+		if existing.DomainDetails == nil {
+			existing.DomainDetails = make(map[string]string)
+		}
+		existing.DomainDetails[k] = v
+	}
 
 	existing.UpdatedAt = time.Now().Format(time.RFC3339)
 	return existing
@@ -80,6 +93,9 @@ func (s *ContextService) BuildContextPrompt() string {
 		if len(s.File1Context.KeyEntities) > 0 {
 			sb.WriteString(fmt.Sprintf("  - Key Entities: %s\n", strings.Join(s.File1Context.KeyEntities, ", ")))
 		}
+		for _, k := range detailKeys(s.File1Context.DomainDetails) {
+			sb.WriteString(fmt.Sprintf("  - %s: %s\n", k, s.File1Context.DomainDetails[k]))
+		}
 		sb.WriteString("\n")
 	}
 
@@ -90,6 +106,9 @@ func (s *ContextService) BuildContextPrompt() string {
 		if len(s.File2Context.KeyEntities) > 0 {
 			sb.WriteString(fmt.Sprintf("  - Key Entities: %s\n", strings.Join(s.File2Context.KeyEntities, ", ")))
 		}
+		for _, k := range detailKeys(s.File2Context.DomainDetails) {
+			sb.WriteString(fmt.Sprintf("  - %s: %s\n", k, s.File2Context.DomainDetails[k]))
+		}
 		sb.WriteString("\n")
 	}
 
@@ -156,3 +175,13 @@ func (s *ContextService) GetAnalysis(fileIndex int) *models.DataAnalysisResult {
 	}
 	return nil
 }
+
+// detailKeys lists the keys of the domain details alphabetically
+func detailKeys(details map[string]string) []string {
+	keys := make([]string, 0, len(details))
+	for k := range details {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
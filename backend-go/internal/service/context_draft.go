@@ -0,0 +1,77 @@
+package service
+
+import (
+	"backend-go/internal/llm"
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"strings"
+	"unicode"
+)
+
+// maskedSemanticTypes are the semantic types whose sample values identify
+// people or records and are masked before they reach the LLM
+var maskedSemanticTypes = map[string]bool{
+	"email": true, "phone": true, "name": true, "ip": true, "uuid": true, "zipcode": true,
+}
+
+// ContextDraftColumns describes the columns of df to the LLM. Samples of
+// personal and identifying columns (emails, phones, names, IPs, UUIDs, zip
+// codes and primary keys) keep only their shape.
+func (s *EnhancedSimilarityService) ContextDraftColumns(df *state.DataFrame) []llm.ColumnSchema {
+	dict := s.BuildDataDictionary(df, 0, nil)
+	columns := make([]llm.ColumnSchema, len(dict.Columns))
+	for i, c := range dict.Columns {
+		t := c.InferredType
+		if c.SemanticType != "" {
+			t += ", " + c.SemanticType
+		}
+		mask := maskedSemanticTypes[c.SemanticType] || c.IsPrimaryKey
+		examples := make([]string, len(c.SampleValues))
+		for j, v := range c.SampleValues {
+			if mask {
+				v = maskValue(v)
+			}
+			examples[j] = v
+		}
+		columns[i] = llm.ColumnSchema{Name: c.Name, Type: t, Examples: examples}
+	}
+	return columns
+}
+
+// maskValue keeps the shape of a value: letters become x or X, digits 9,
+// and punctuation such as @ . - stays
+func maskValue(v string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case unicode.IsDigit(r):
+			return '9'
+		case unicode.IsUpper(r):
+			return 'X'
+		case unicode.IsLetter(r):
+			return 'x'
+		}
+		return r
+	}, v)
+}
+
+// ContextFromDraft turns the LLM's draft into a context, dropping
+// descriptions of columns df doesn't have
+func ContextFromDraft(draft *llm.ContextDraft, df *state.DataFrame) *models.Context {
+	ctx := models.NewContext()
+	ctx.DatasetPurpose = strings.TrimSpace(draft.DatasetPurpose)
+	ctx.BusinessDomain = strings.TrimSpace(draft.BusinessDomain)
+	ctx.TemporalContext = strings.TrimSpace(draft.TemporalContext)
+	for _, e := range draft.KeyEntities {
+		if e = strings.TrimSpace(e); e != "" {
+			ctx.KeyEntities = append(ctx.KeyEntities, e)
+		}
+	}
+	ctx.KeyEntities = uniqueStrings(ctx.KeyEntities)
+
+	for _, h := range df.Headers {
+		if desc := strings.TrimSpace(draft.ColumnDescriptions[h]); desc != "" {
+			ctx.ColumnDescriptions[h] = desc
+		}
+	}
+	return ctx
+}
@@ -0,0 +1,138 @@
+package service
+
+import "backend-go/internal/models"
+
+// ContextTemplate pre-fills the context interview for a common kind of
+// dataset: its domain, key entities, columns typically excluded from
+// matching and a synonym pack of glossary terms
+type ContextTemplate struct {
+	ID             string         `json:"id"`
+	Name           string         `json:"name"`
+	Description    string         `json:"description"`
+	BusinessDomain string         `json:"business_domain"`
+	DatasetPurpose string         `json:"dataset_purpose"`
+	KeyEntities    []string       `json:"key_entities"`
+	Exclusions     []string       `json:"exclusions"`
+	Synonyms       []GlossaryTerm `json:"synonyms,omitempty"`
+	SynonymCount   int            `json:"synonym_count,omitempty"`
+}
+
+// packTerm builds a synonym pack term
+func packTerm(term, definition string, synonyms ...string) GlossaryTerm {
+	return GlossaryTerm{Term: term, Definition: definition, Synonyms: synonyms}
+}
+
+// builtInContextTemplates are the templates offered for common datasets
+func builtInContextTemplates() []ContextTemplate {
+	return []ContextTemplate{
+		{
+			ID:             "ecommerce_orders",
+			Name:           "E-commerce orders",
+			Description:    "Orders, order lines, customers and products of an online shop",
+			BusinessDomain: "E-commerce",
+			DatasetPurpose: "Customer orders with their lines, payments and shipments",
+			KeyEntities:    []string{"order", "customer", "product", "sku", "payment", "shipment"},
+			Exclusions:     []string{"session_id", "user_agent", "ip_address", "cart_token", "utm_source", "utm_medium", "utm_campaign"},
+			Synonyms: []GlossaryTerm{
+				packTerm("Order Number", "Identifier of a customer order", "order_id", "order_no", "order_ref", "purchase_id"),
+				packTerm("Customer Identifier", "Identifier of the buying customer", "customer_id", "cust_id", "client_id", "buyer_id", "shopper_id"),
+				packTerm("SKU", "Stock keeping unit of the product sold", "product_code", "item_code", "article_number", "product_sku"),
+				packTerm("Order Date", "When the order was placed", "purchase_date", "placed_at", "ordered_at", "checkout_date"),
+				packTerm("Order Total", "Amount charged for the order", "grand_total", "order_amount", "total_paid", "order_value"),
+				packTerm("Ship Date", "When the order left the warehouse", "shipped_at", "dispatch_date", "fulfillment_date"),
+			},
+		},
+		{
+			ID:             "hr_employees",
+			Name:           "HR employees",
+			Description:    "Employee master data, positions and compensation",
+			BusinessDomain: "Human resources",
+			DatasetPurpose: "Employees with their positions, departments and pay",
+			KeyEntities:    []string{"employee", "department", "position", "manager", "location"},
+			Exclusions:     []string{"ssn", "national_id", "bank_account", "iban", "password_hash", "notes"},
+			Synonyms: []GlossaryTerm{
+				packTerm("Employee Identifier", "Identifier of an employee", "employee_id", "emp_id", "emp_no", "staff_id", "personnel_number", "worker_id"),
+				packTerm("Hire Date", "First day of employment", "start_date", "date_of_hire", "joining_date", "employment_start"),
+				packTerm("Termination Date", "Last day of employment", "end_date", "leave_date", "exit_date", "separation_date"),
+				packTerm("Department", "Organizational unit the employee belongs to", "dept", "dept_name", "org_unit", "division"),
+				packTerm("Job Title", "Position held", "position", "role", "job_name", "designation"),
+				packTerm("Base Salary", "Contractual yearly pay before bonuses", "salary", "annual_salary", "base_pay", "compensation"),
+				packTerm("Manager Identifier", "Identifier of the employee's line manager", "manager_id", "supervisor_id", "reports_to"),
+			},
+		},
+		{
+			ID:             "finance_gl",
+			Name:           "Finance general ledger",
+			Description:    "General ledger journal entries and the chart of accounts",
+			BusinessDomain: "Finance",
+			DatasetPurpose: "General ledger postings by account, period and cost center",
+			KeyEntities:    []string{"account", "journal", "entry", "period", "cost center", "entity"},
+			Exclusions:     []string{"created_by", "approved_by", "batch_id", "source_system", "import_run"},
+			Synonyms: []GlossaryTerm{
+				packTerm("Account Number", "General ledger account code", "account_id", "gl_account", "account_code", "acct_no", "natural_account"),
+				packTerm("Journal Entry", "Identifier of a journal entry", "je_id", "journal_id", "entry_id", "voucher_no", "document_number"),
+				packTerm("Posting Date", "Date the entry hits the ledger", "gl_date", "post_date", "accounting_date", "booking_date"),
+				packTerm("Fiscal Period", "Accounting period of the entry", "period", "fiscal_month", "accounting_period", "period_name"),
+				packTerm("Debit Amount", "Debit side of the entry", "debit", "dr_amount", "dr"),
+				packTerm("Credit Amount", "Credit side of the entry", "credit", "cr_amount", "cr"),
+				packTerm("Cost Center", "Cost center the entry is charged to", "cost_centre", "cc_code", "profit_center", "department_code"),
+			},
+		},
+		{
+			ID:             "healthcare_claims",
+			Name:           "Healthcare claims",
+			Description:    "Medical claims, claim lines, members and providers",
+			BusinessDomain: "Healthcare",
+			DatasetPurpose: "Insurance claims for services rendered to members by providers",
+			KeyEntities:    []string{"claim", "member", "patient", "provider", "diagnosis", "procedure", "payer"},
+			Exclusions:     []string{"member_ssn", "patient_name", "address_line", "phone", "adjuster_notes"},
+			Synonyms: []GlossaryTerm{
+				packTerm("Claim Identifier", "Identifier of a claim", "claim_id", "claim_no", "claim_number", "icn", "tcn"),
+				packTerm("Member Identifier", "Identifier of the insured member", "member_id", "subscriber_id", "patient_id", "beneficiary_id", "enrollee_id"),
+				packTerm("Provider NPI", "National Provider Identifier of the rendering provider", "npi", "provider_id", "rendering_npi", "billing_npi"),
+				packTerm("Date of Service", "When the service was rendered", "service_date", "dos", "svc_date", "from_date"),
+				packTerm("Diagnosis Code", "ICD diagnosis code", "icd_code", "icd10", "dx_code", "primary_diagnosis"),
+				packTerm("Procedure Code", "CPT or HCPCS procedure code", "cpt_code", "hcpcs", "proc_code", "px_code"),
+				packTerm("Billed Amount", "Amount the provider charged", "charge_amount", "billed", "submitted_amount", "total_charges"),
+				packTerm("Paid Amount", "Amount the payer paid", "paid", "allowed_paid", "payment_amount", "net_paid"),
+			},
+		},
+	}
+}
+
+// ContextTemplates lists the built-in templates without their synonym packs
+func ContextTemplates() []ContextTemplate {
+	list := builtInContextTemplates()
+	for i := range list {
+		list[i].SynonymCount = len(list[i].Synonyms)
+		list[i].Synonyms = nil
+	}
+	return list
+}
+
+// GetContextTemplate looks up a built-in template by ID
+func GetContextTemplate(id string) (ContextTemplate, bool) {
+	for _, t := range builtInContextTemplates() {
+		if t.ID == id {
+			return t, true
+		}
+	}
+	return ContextTemplate{}, false
+}
+
+// ApplyTo pre-fills a file's context from the template. Answers already in
+// the existing context win over the template's, and lists are combined.
+func (t ContextTemplate) ApplyTo(existing *models.Context) *models.Context {
+	ctx := models.NewContext()
+	ctx.BusinessDomain = t.BusinessDomain
+	ctx.DatasetPurpose = t.DatasetPurpose
+	ctx.KeyEntities = append(ctx.KeyEntities, t.KeyEntities...)
+	ctx.Exclusions = append(ctx.Exclusions, t.Exclusions...)
+	if existing == nil {
+		return ctx
+	}
+
+	ctx = NewContextService().MergeContext(ctx, existing)
+	ctx.CreatedAt = existing.CreatedAt
+	return ctx
+}
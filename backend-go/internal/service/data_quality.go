@@ -1,8 +1,12 @@
 package service
 
 import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/nulltoken"
 	"backend-go/internal/state"
 	"math"
+	"sort"
+	"strings"
 )
 
 // DataQualityProfile holds quality metrics for a column
@@ -15,9 +19,33 @@ type DataQualityProfile struct {
 	UniquenessRatio float64 `json:"uniqueness_ratio"`
 	Entropy         float64 `json:"entropy"`
 	IsPrimaryKey    bool    `json:"is_primary_key"`
+	OutlierRate     float64 `json:"outlier_rate"`  // Share of numeric values outside the IQR fences
 	QualityScore    float64 `json:"quality_score"` // 0-1
+
+	// Benford and checksum checks, for the columns they apply to
+	Plausibility []PlausibilityCheck `json:"plausibility,omitempty"`
+}
+
+// PlausibilityCheck is the outcome of a Benford or checksum check on a column
+type PlausibilityCheck struct {
+	Check      string  `json:"check"`  // benford, luhn or iban
+	Values     int     `json:"values"` // Values checked
+	PassRate   float64 `json:"pass_rate,omitempty"`
+	MAD        float64 `json:"mad,omitempty"` // Benford mean absolute deviation
+	Conformity string  `json:"conformity,omitempty"`
+	Passed     bool    `json:"passed"`
 }
 
+// Plausibility check thresholds
+const (
+	plausibilityShapeShare = 0.9  // Share of values shaped like cards or IBANs for a check to apply
+	plausibilityPassRate   = 0.95 // Checksum pass rate of a plausible column
+	benfordMinMagnitudes   = 3    // Orders of magnitude a column without an amount-like name must span
+)
+
+// amountKeywords mark columns whose values should follow Benford's law
+var amountKeywords = []string{"amount", "price", "cost", "revenue", "salary", "total", "balance", "sales", "fee", "payment", "spend", "income"}
+
 // DataQualityProfiler analyzes data quality metrics
 type DataQualityProfiler struct{}
 
@@ -37,13 +65,14 @@ func (dqp *DataQualityProfiler) ProfileColumn(df *state.DataFrame, colIdx int) D
 	uniqueValues := make(map[string]int)
 	nonNullCount := 0
 
+	isNull := nulltoken.ForColumn(profile.ColumnName)
 	for _, row := range df.Rows {
 		if colIdx >= len(row) {
 			continue
 		}
 
 		value := row[colIdx]
-		if value == "" || value == "null" || value == "NULL" || value == "None" {
+		if isNull(value) {
 			continue
 		}
 
@@ -71,6 +100,9 @@ func (dqp *DataQualityProfiler) ProfileColumn(df *state.DataFrame, colIdx int) D
 	// High uniqueness (>95%) and low null rate (<5%)
 	profile.IsPrimaryKey = profile.UniquenessRatio > 0.95 && profile.NullRate < 0.05
 
+	profile.OutlierRate = dqp.calculateOutlierRate(df, colIdx)
+	profile.Plausibility = dqp.checkPlausibility(df, colIdx, profile.IsPrimaryKey)
+
 	// Calculate overall quality score
 	profile.QualityScore = dqp.calculateQualityScore(profile)
 
@@ -103,6 +135,113 @@ func (dqp *DataQualityProfiler) calculateEntropy(valueCounts map[string]int, tot
 	return entropy
 }
 
+// calculateOutlierRate returns the share of a numeric column's values outside
+// the Tukey fences (1.5 IQR); 0 for other columns
+func (dqp *DataQualityProfiler) calculateOutlierRate(df *state.DataFrame, colIdx int) float64 {
+	col := df.Column(colIdx)
+	if col == nil || col.Type != state.ColumnFloat {
+		return 0
+	}
+	values := col.FloatValues()
+	if len(values) == 0 {
+		return 0
+	}
+	sort.Float64s(values)
+	lower, upper := analysis.IQRFences(values, 1.5)
+	outliers := 0
+	for _, v := range values {
+		if v < lower || v > upper {
+			outliers++
+		}
+	}
+	return float64(outliers) / float64(len(values))
+}
+
+// checkPlausibility runs the checks that apply to a column: Benford for
+// amount-like numbers, Luhn for card-like numbers and check digits for IBANs
+func (dqp *DataQualityProfiler) checkPlausibility(df *state.DataFrame, colIdx int, isKey bool) []PlausibilityCheck {
+	col := df.Column(colIdx)
+	if col == nil {
+		return nil
+	}
+	checks := []PlausibilityCheck{}
+
+	if col.Type == state.ColumnFloat {
+		values := col.FloatValues()
+		lo, hi := math.Inf(1), 0.0
+		for _, v := range values {
+			if v := math.Abs(v); v > 0 {
+				lo, hi = math.Min(lo, v), math.Max(hi, v)
+			}
+		}
+		name := strings.ToLower(df.Headers[colIdx])
+		amountLike := false
+		for _, k := range amountKeywords {
+			if strings.Contains(name, k) {
+				amountLike = true
+				break
+			}
+		}
+		// Near-unique amounts look like keys, so only the magnitude heuristic skips keys
+		if amountLike || (!isKey && hi > 0 && math.Log10(hi/lo) >= benfordMinMagnitudes) {
+			if r, ok := analysis.BenfordTest(values); ok {
+				checks = append(checks, PlausibilityCheck{
+					Check:      analysis.CheckBenford,
+					Values:     r.Values,
+					MAD:        r.MAD,
+					Conformity: r.Conformity,
+					Passed:     r.Conformity != analysis.BenfordNonconforming,
+				})
+			}
+		}
+	}
+
+	for _, c := range []struct {
+		name  string
+		shape func(string) bool
+		valid func(string) bool
+	}{
+		{analysis.CheckLuhn, analysis.IsCardLike, analysis.ValidLuhn},
+		{analysis.CheckIBAN, analysis.IsIBANLike, analysis.ValidIBAN},
+	} {
+		// Cheap rejection on the first value before scanning the column
+		first := -1
+		for i := 0; i < col.Len() && first < 0; i++ {
+			if !col.IsNull(i) {
+				first = i
+			}
+		}
+		if first < 0 || !c.shape(col.Value(first)) {
+			continue
+		}
+		shaped, valid, total := 0, 0, 0
+		for i := 0; i < col.Len(); i++ {
+			if col.IsNull(i) {
+				continue
+			}
+			v := col.Value(i)
+			total++
+			if c.shape(v) {
+				shaped++
+				if c.valid(v) {
+					valid++
+				}
+			}
+		}
+		if float64(shaped) < plausibilityShapeShare*float64(total) {
+			continue
+		}
+		check := PlausibilityCheck{Check: c.name, Values: shaped, PassRate: float64(valid) / float64(shaped)}
+		check.Passed = check.PassRate >= plausibilityPassRate
+		checks = append(checks, check)
+	}
+
+	if len(checks) == 0 {
+		return nil
+	}
+	return checks
+}
+
 // calculateQualityScore computes overall quality (0-1)
 func (dqp *DataQualityProfiler) calculateQualityScore(profile DataQualityProfile) float64 {
 	score := 1.0
@@ -116,6 +255,9 @@ func (dqp *DataQualityProfiler) calculateQualityScore(profile DataQualityProfile
 	entropyPenalty := math.Abs(profile.Entropy-idealEntropy) / 10.0
 	score *= math.Max(0.5, 1.0-entropyPenalty)
 
+	// Penalize outliers (corrupted or mis-scaled values)
+	score *= 1.0 - profile.OutlierRate
+
 	// Ensure score is between 0 and 1
 	return math.Max(0, math.Min(1, score))
 }
@@ -1,6 +1,8 @@
 package service
 
 import (
+	"backend-go/internal/tracing"
+	"context"
 	"database/sql"
 	"fmt"
 
@@ -20,10 +22,11 @@ type DataSourceConfig struct {
 
 // DataSource defines the interface for data sources
 type DataSource interface {
-	Connect(config DataSourceConfig) error
+	Connect(ctx context.Context, config DataSourceConfig) error
 	Close() error
-	ListTables() ([]string, error)
-	PreviewData(tableName string, limit int) ([]map[string]interface{}, error)
+	Ping(ctx context.Context) error
+	ListTables(ctx context.Context) ([]string, error)
+	PreviewData(ctx context.Context, tableName string, limit int) ([]map[string]interface{}, error)
 }
 
 // PostgresDataSource implements DataSource for PostgreSQL
@@ -31,7 +34,10 @@ type PostgresDataSource struct {
 	db *sql.DB
 }
 
-func (p *PostgresDataSource) Connect(config DataSourceConfig) error {
+func (p *PostgresDataSource) Connect(ctx context.Context, config DataSourceConfig) (err error) {
+	ctx, span := startDBSpan(ctx, "postgres.connect", config.DBName)
+	defer func() { span.RecordError(err); span.End() }()
+
 	connStr := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s sslmode=%s",
 		config.Host, config.Port, config.User, config.Password, config.DBName, config.SSLMode)
 
@@ -40,7 +46,7 @@ func (p *PostgresDataSource) Connect(config DataSourceConfig) error {
 		return err
 	}
 
-	if err := db.Ping(); err != nil {
+	if err := db.PingContext(ctx); err != nil {
 		return err
 	}
 
@@ -55,20 +61,34 @@ func (p *PostgresDataSource) Close() error {
 	return nil
 }
 
-func (p *PostgresDataSource) ListTables() ([]string, error) {
+// Ping checks that the connection is still alive
+func (p *PostgresDataSource) Ping(ctx context.Context) (err error) {
+	ctx, span := startDBSpan(ctx, "postgres.ping", "")
+	defer func() { span.RecordError(err); span.End() }()
+
+	if p.db == nil {
+		return fmt.Errorf("not connected")
+	}
+	return p.db.PingContext(ctx)
+}
+
+func (p *PostgresDataSource) ListTables(ctx context.Context) (tables []string, err error) {
 	query := `
 		SELECT table_name
 		FROM information_schema.tables
 		WHERE table_schema = 'public'
 		ORDER BY table_name;
 	`
-	rows, err := p.db.Query(query)
+	ctx, span := startDBSpan(ctx, "postgres.list_tables", "")
+	span.SetAttr("db.statement", query)
+	defer func() { span.RecordError(err); span.End() }()
+
+	rows, err := p.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var tables []string
 	for rows.Next() {
 		var tableName string
 		if err := rows.Scan(&tableName); err != nil {
@@ -79,12 +99,20 @@ func (p *PostgresDataSource) ListTables() ([]string, error) {
 	return tables, nil
 }
 
-func (p *PostgresDataSource) PreviewData(tableName string, limit int) ([]map[string]interface{}, error) {
+func (p *PostgresDataSource) PreviewData(ctx context.Context, tableName string, limit int) (result []map[string]interface{}, err error) {
 	// WARNING: VULNERABLE TO SQL INJECTION IF tableName IS UNTRUSTED
 	// In a real app, validate tableName against ListTables() whitelist
 	query := fmt.Sprintf("SELECT * FROM %s LIMIT %d", tableName, limit)
 
-	rows, err := p.db.Query(query)
+	ctx, span := startDBSpan(ctx, "postgres.query", "")
+	span.SetAttr("db.statement", query)
+	defer func() {
+		span.SetAttr("db.rows", len(result))
+		span.RecordError(err)
+		span.End()
+	}()
+
+	rows, err := p.db.QueryContext(ctx, query)
 	if err != nil {
 		return nil, err
 	}
@@ -95,8 +123,6 @@ func (p *PostgresDataSource) PreviewData(tableName string, limit int) ([]map[str
 		return nil, err
 	}
 
-	var result []map[string]interface{}
-
 	for rows.Next() {
 		// Prepare a slice of interface{} to hold values
 		values := make([]interface{}, len(columns))
@@ -126,3 +152,13 @@ func (p *PostgresDataSource) PreviewData(tableName string, limit int) ([]map[str
 
 	return result, nil
 }
+
+// startDBSpan begins a client span of a database call
+func startDBSpan(ctx context.Context, name, dbName string) (context.Context, *tracing.Span) {
+	ctx, span := tracing.StartKind(ctx, name, tracing.KindClient)
+	span.SetAttr("db.system", "postgresql")
+	if dbName != "" {
+		span.SetAttr("db.name", dbName)
+	}
+	return ctx, span
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"fmt"
+	"strings"
+)
+
+// Answers to the rel_type question
+const (
+	RelationshipOneToOne     = "One-to-One"
+	RelationshipOneToMany    = "One-to-Many"
+	RelationshipManyToMany   = "Many-to-Many"
+	RelationshipHierarchical = "Hierarchical"
+	RelationshipTemporal     = "Temporal sequence"
+	RelationshipUnknown      = "Unknown"
+)
+
+// RelationshipTypes lists the answers to the rel_type question
+func RelationshipTypes() []string {
+	return []string{RelationshipOneToOne, RelationshipOneToMany, RelationshipManyToMany, RelationshipHierarchical, RelationshipTemporal, RelationshipUnknown}
+}
+
+// RelationshipQuestions ask how the two datasets relate and which columns
+// join them, in a locale
+func RelationshipQuestions(headers1, headers2 []string, locale string) []models.Question {
+	return []models.Question{
+		withOptionLabels(models.Question{
+			ID:         "rel_type",
+			Type:       models.QuestionTypeRelationships,
+			AnswerType: models.AnswerTypeSelect,
+			Text:       message(locale, "rel_type.text"),
+			Options:    RelationshipTypes(),
+			Required:   false,
+		}, locale),
+		{
+			ID:         "rel_keys",
+			Type:       models.QuestionTypeCustomMappings,
+			AnswerType: models.AnswerTypeJoinKeys,
+			Text:       message(locale, "rel_keys.text"),
+			Options:    append(append([]string{}, headers1...), headers2...),
+			Required:   false,
+		},
+	}
+}
+
+// DeclaredKeyConfidence is the least confidence of a column pair declared as
+// a join key in the relationship answers
+const DeclaredKeyConfidence = 95.0
+
+// ParseRelationshipType matches a rel_type answer to its canonical spelling,
+// ignoring case
+func ParseRelationshipType(answer string) (string, error) {
+	answer = strings.TrimSpace(answer)
+	for _, t := range RelationshipTypes() {
+		if strings.EqualFold(answer, t) {
+			return t, nil
+		}
+	}
+	return "", fmt.Errorf("unknown relationship type %q (use %s)", answer, strings.Join(RelationshipTypes(), ", "))
+}
+
+// ParseJoinKeys resolves rel_keys answers to column pairs. An answer is
+// either an explicit pair, "file1_col=file2_col", or a column name: a name
+// both files have joins to itself, and the other names pair up in order, the
+// n-th file 1 column with the n-th file 2 column.
+func ParseJoinKeys(answers []string, headers1, headers2 []string) ([]models.JoinKeyPair, error) {
+	in1, in2 := map[string]bool{}, map[string]bool{}
+	for _, h := range headers1 {
+		in1[h] = true
+	}
+	for _, h := range headers2 {
+		in2[h] = true
+	}
+
+	pairs := []models.JoinKeyPair{}
+	only1, only2 := []string{}, []string{}
+	for _, answer := range answers {
+		answer = strings.TrimSpace(answer)
+		if answer == "" {
+			continue
+		}
+		if col1, col2, ok := strings.Cut(answer, "="); ok {
+			col1, col2 = strings.TrimSpace(col1), strings.TrimSpace(col2)
+			if !in1[col1] || !in2[col2] {
+				return nil, fmt.Errorf("join key %q must name a file 1 column, then a file 2 column", answer)
+			}
+			pairs = append(pairs, models.JoinKeyPair{File1Column: col1, File2Column: col2})
+			continue
+		}
+		switch {
+		case in1[answer] && in2[answer]:
+			pairs = append(pairs, models.JoinKeyPair{File1Column: answer, File2Column: answer})
+		case in1[answer]:
+			only1 = append(only1, answer)
+		case in2[answer]:
+			only2 = append(only2, answer)
+		default:
+			return nil, fmt.Errorf("join key %q is not a column of either file", answer)
+		}
+	}
+	if len(only1) != len(only2) {
+		return nil, fmt.Errorf("join keys %s have no counterpart; name as many file 1 as file 2 columns or use file1_col=file2_col",
+			strings.Join(append(only1, only2...), ", "))
+	}
+	for i := range only1 {
+		pairs = append(pairs, models.JoinKeyPair{File1Column: only1[i], File2Column: only2[i]})
+	}
+	return pairs, nil
+}
+
+// DeclaredRelationship returns the relationship type and join keys answered
+// for the dataset pair, from file 1's context first
+func DeclaredRelationship(ctx1, ctx2 *models.Context) (string, []models.JoinKeyPair) {
+	relType, keys := "", []models.JoinKeyPair(nil)
+	for _, ctx := range []*models.Context{ctx1, ctx2} {
+		if ctx == nil {
+			continue
+		}
+		if relType == "" {
+			relType = ctx.RelationshipType
+		}
+		if len(keys) == 0 {
+			keys = ctx.JoinKeys
+		}
+	}
+	return relType, keys
+}
+
+// isDeclaredJoinKey reports whether a column pair was answered as a join key
+func isDeclaredJoinKey(ctx1, ctx2 *models.Context, col1, col2 string) bool {
+	_, keys := DeclaredRelationship(ctx1, ctx2)
+	for _, k := range keys {
+		if k.File1Column == col1 && k.File2Column == col2 {
+			return true
+		}
+	}
+	return false
+}
+
+// sqlJoinKeyword picks the join of a declared relationship: File 1 rows
+// without children or later records are kept for one-to-many, hierarchical
+// and temporal relationships, and only matching rows otherwise
+func sqlJoinKeyword(relType string) string {
+	switch relType {
+	case RelationshipOneToMany, RelationshipHierarchical, RelationshipTemporal:
+		return "LEFT JOIN"
+	default:
+		return "INNER JOIN"
+	}
+}
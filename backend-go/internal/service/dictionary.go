@@ -0,0 +1,185 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Data dictionary output formats
+const (
+	DictionaryFormatMarkdown = "markdown"
+	DictionaryFormatJSON     = "json"
+)
+
+// dictionarySampleValues is the number of distinct sample values per column
+const dictionarySampleValues = 5
+
+// DictionaryColumn describes one column of a data dictionary
+type DictionaryColumn struct {
+	Name          string      `json:"name"`
+	InferredType  string      `json:"inferred_type"`
+	SemanticType  string      `json:"semantic_type,omitempty"`
+	NullRate      float64     `json:"null_rate"`
+	DistinctCount int         `json:"distinct_count"`
+	IsPrimaryKey  bool        `json:"is_primary_key"`
+	SampleValues  []string    `json:"sample_values"`
+	Description   string      `json:"description,omitempty"`
+	Concept       *ConceptTag `json:"concept,omitempty"` // With a vocabulary
+}
+
+// DataDictionary documents the columns of one loaded file
+type DataDictionary struct {
+	FileIndex      int                `json:"file_index"`
+	FileName       string             `json:"file_name"`
+	Rows           int                `json:"rows"`
+	DatasetPurpose string             `json:"dataset_purpose,omitempty"`
+	BusinessDomain string             `json:"business_domain,omitempty"`
+	Vocabulary     string             `json:"vocabulary,omitempty"` // Of the concept tags
+	GeneratedAt    time.Time          `json:"generated_at"`
+	Columns        []DictionaryColumn `json:"columns"`
+}
+
+// BuildDataDictionary documents every column of df, taking descriptions from
+// the user-provided context (ctx may be nil)
+func (s *EnhancedSimilarityService) BuildDataDictionary(df *state.DataFrame, fileIndex int, ctx *models.Context) *DataDictionary {
+	dict := &DataDictionary{
+		FileIndex:   fileIndex,
+		FileName:    df.FileName,
+		Rows:        len(df.Rows),
+		GeneratedAt: time.Now(),
+		Columns:     make([]DictionaryColumn, 0, len(df.Headers)),
+	}
+	if ctx != nil {
+		dict.DatasetPurpose = ctx.DatasetPurpose
+		dict.BusinessDomain = ctx.BusinessDomain
+	}
+
+	for _, p := range s.ProfileColumns(df) {
+		col := DictionaryColumn{
+			Name:          p.Name,
+			InferredType:  string(state.ColumnString),
+			NullRate:      p.Quality.NullRate,
+			DistinctCount: p.Quality.DistinctCount,
+			IsPrimaryKey:  p.Quality.IsPrimaryKey,
+			SampleValues:  sampleValues(df, p.Index, dictionarySampleValues),
+		}
+		if c := df.Column(p.Index); c != nil {
+			col.InferredType = string(c.Type)
+		}
+		col.SemanticType = s.semanticType(df, p, state.ColumnType(col.InferredType))
+		if ctx != nil {
+			col.Description = ctx.ColumnDescriptions[p.Name]
+		}
+		dict.Columns = append(dict.Columns, col)
+	}
+	return dict
+}
+
+// semanticPatternOrder is the order patterns are tried in; the loose ones
+// (phone, currency) come last so they don't shadow the specific ones
+var semanticPatternOrder = []string{"email", "uuid", "url", "ip", "date_iso", "date_us", "zipcode", "phone", "currency"}
+
+// semanticType classifies a column by the pattern most of its leading values
+// match. Numeric columns match the loose digit patterns trivially, so only
+// text columns are checked.
+func (s *EnhancedSimilarityService) semanticType(df *state.DataFrame, p *ColumnProfile, colType state.ColumnType) string {
+	switch colType {
+	case state.ColumnTime:
+		return "date"
+	case state.ColumnFloat:
+		return ""
+	}
+
+	values := sampleValues(df, p.Index, profilePatternSample)
+	if len(values) == 0 {
+		return ""
+	}
+	for _, name := range semanticPatternOrder {
+		pattern, ok := s.patterns[name]
+		if !ok {
+			continue
+		}
+		matched := 0
+		for _, v := range values {
+			if pattern.MatchString(v) {
+				matched++
+			}
+		}
+		if float64(matched) >= float64(len(values))*0.6 {
+			return name
+		}
+	}
+	if p.Format == "name" {
+		return p.Format
+	}
+	return ""
+}
+
+// sampleValues returns the first n distinct non-empty values of a column
+func sampleValues(df *state.DataFrame, colIdx, n int) []string {
+	samples := []string{}
+	seen := make(map[string]bool)
+	for _, row := range df.Rows {
+		if len(samples) >= n {
+			break
+		}
+		if colIdx >= len(row) {
+			continue
+		}
+		v := strings.TrimSpace(row[colIdx])
+		if v == "" || seen[v] {
+			continue
+		}
+		seen[v] = true
+		samples = append(samples, v)
+	}
+	return samples
+}
+
+// RenderDictionaryMarkdown renders a data dictionary as a Markdown document
+func RenderDictionaryMarkdown(dict *DataDictionary) string {
+	var sb strings.Builder
+
+	name := dict.FileName
+	if name == "" {
+		name = fmt.Sprintf("File %d", dict.FileIndex)
+	}
+	sb.WriteString(fmt.Sprintf("# Data dictionary: %s\n\n", name))
+	sb.WriteString(fmt.Sprintf("Generated %s. %d rows, %d columns.\n\n", dict.GeneratedAt.Format("2006-01-02 15:04"), dict.Rows, len(dict.Columns)))
+	if dict.DatasetPurpose != "" {
+		sb.WriteString(fmt.Sprintf("**Purpose:** %s\n\n", dict.DatasetPurpose))
+	}
+	if dict.BusinessDomain != "" {
+		sb.WriteString(fmt.Sprintf("**Domain:** %s\n\n", dict.BusinessDomain))
+	}
+
+	tagged := dict.Vocabulary != ""
+	if tagged {
+		sb.WriteString(fmt.Sprintf("**Concepts:** %s\n\n", dict.Vocabulary))
+		sb.WriteString("| Column | Concept | Type | Semantic type | Null rate | Distinct | Key | Sample values | Description |\n")
+		sb.WriteString("|---|---|---|---|---|---|---|---|---|\n")
+	} else {
+		sb.WriteString("| Column | Type | Semantic type | Null rate | Distinct | Key | Sample values | Description |\n")
+		sb.WriteString("|---|---|---|---|---|---|---|---|\n")
+	}
+	for _, c := range dict.Columns {
+		samples := make([]string, len(c.SampleValues))
+		for i, v := range c.SampleValues {
+			samples[i] = "`" + strings.ReplaceAll(mdCell(v), "`", "'") + "`"
+		}
+		sb.WriteString("| " + mdCell(c.Name) + " | ")
+		if tagged {
+			if c.Concept != nil {
+				sb.WriteString(fmt.Sprintf("`%s` (%.0f%%)", c.Concept.ID, c.Concept.Confidence))
+			}
+			sb.WriteString(" | ")
+		}
+		sb.WriteString(fmt.Sprintf("%s | %s | %.1f%% | %d | %s | %s | %s |\n",
+			c.InferredType, c.SemanticType, c.NullRate*100, c.DistinctCount,
+			yesNo(c.IsPrimaryKey), strings.Join(samples, ", "), mdCell(c.Description)))
+	}
+	return sb.String()
+}
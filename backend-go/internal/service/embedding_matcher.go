@@ -0,0 +1,142 @@
+package service
+
+import (
+	"backend-go/internal/llm"
+	"backend-go/internal/state"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+)
+
+// embeddingSampleValues is the number of distinct values a column description
+// lists after its name
+const embeddingSampleValues = 5
+
+// EmbeddingMatcher scores column pairs by the cosine similarity of embeddings
+// of their descriptions (name words and a few sample values)
+type EmbeddingMatcher struct {
+	llmService *llm.Service
+	cache      map[[2]string][]float64 // Model and description -> vector
+	mutex      sync.Mutex
+}
+
+// NewEmbeddingMatcher creates a matcher embedding through the given LLM service
+func NewEmbeddingMatcher(llmSvc *llm.Service) *EmbeddingMatcher {
+	return &EmbeddingMatcher{
+		llmService: llmSvc,
+		cache:      make(map[[2]string][]float64),
+	}
+}
+
+// MatchColumns returns the 0-1 similarity of every column pair, keyed by
+// file 1 and file 2 column. Descriptions seen before are not embedded again.
+func (m *EmbeddingMatcher) MatchColumns(ctx context.Context, df1, df2 *state.DataFrame) (map[[2]string]float64, error) {
+	if m.llmService == nil {
+		return nil, fmt.Errorf("no LLM service configured")
+	}
+	texts1, texts2 := columnDescriptions(df1), columnDescriptions(df2)
+	vectors, err := m.embed(ctx, append(append([]string{}, texts1...), texts2...))
+	if err != nil {
+		return nil, err
+	}
+
+	scores := make(map[[2]string]float64, len(df1.Headers)*len(df2.Headers))
+	for i, col1 := range df1.Headers {
+		for j, col2 := range df2.Headers {
+			scores[[2]string{col1, col2}] = math.Max(0, cosineSimilarity(vectors[texts1[i]], vectors[texts2[j]]))
+		}
+	}
+	return scores, nil
+}
+
+// embed returns the vector of each text, embedding only the texts not
+// cached for the preferred embedding model. When a fallback model embeds
+// them instead, it embeds every text, as vectors of different models don't
+// compare.
+func (m *EmbeddingMatcher) embed(ctx context.Context, texts []string) (map[string][]float64, error) {
+	preferred := m.llmService.TaskModels(llm.TaskEmbedding)[0]
+	vectors := make(map[string][]float64, len(texts))
+	missing := []string{}
+
+	m.mutex.Lock()
+	for _, t := range texts {
+		if v, ok := m.cache[[2]string{preferred, t}]; ok {
+			vectors[t] = v
+		} else if _, queued := vectors[t]; !queued {
+			vectors[t] = nil
+			missing = append(missing, t)
+		}
+	}
+	m.mutex.Unlock()
+
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+	embedded, model, err := m.llmService.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if model != preferred && len(missing) < len(vectors) {
+		missing = missing[:0]
+		for t := range vectors {
+			missing = append(missing, t)
+		}
+		if embedded, err = m.llmService.EmbedWith(ctx, model, missing); err != nil {
+			return nil, err
+		}
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	for i, t := range missing {
+		m.cache[[2]string{model, t}] = embedded[i]
+		vectors[t] = embedded[i]
+	}
+	return vectors, nil
+}
+
+// columnDescriptions describes each column as its name split into words
+// followed by its first distinct values
+func columnDescriptions(df *state.DataFrame) []string {
+	texts := make([]string, len(df.Headers))
+	for colIdx, name := range df.Headers {
+		seen := map[string]bool{}
+		samples := []string{}
+		for _, row := range df.Rows {
+			if len(samples) == embeddingSampleValues {
+				break
+			}
+			if colIdx < len(row) && row[colIdx] != "" && !seen[row[colIdx]] {
+				seen[row[colIdx]] = true
+				samples = append(samples, row[colIdx])
+			}
+		}
+		texts[colIdx] = name
+		if words := tokenize(name); len(words) > 0 {
+			texts[colIdx] = strings.Join(words, " ")
+		}
+		if len(samples) > 0 {
+			texts[colIdx] += ": " + strings.Join(samples, ", ")
+		}
+	}
+	return texts
+}
+
+// cosineSimilarity of two vectors; 0 when either is empty or they differ in length
+func cosineSimilarity(a, b []float64) float64 {
+	if len(a) == 0 || len(a) != len(b) {
+		return 0
+	}
+	var dot, normA, normB float64
+	for i := range a {
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
@@ -1,37 +1,55 @@
 package service
 
 import (
+	"backend-go/internal/logging"
 	"backend-go/internal/models"
 	"backend-go/internal/state"
+	"backend-go/internal/textnorm"
+	"backend-go/internal/tracing"
+	"context"
+	"fmt"
 	"math"
 	"regexp"
 	"sort"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 	"unicode"
 )
 
+var similarityLog = logging.Component("similarity")
+
 // EnhancedSimilarityService provides advanced column matching capabilities
 type EnhancedSimilarityService struct {
-	contextService    *ContextService
-	synonyms          map[string][]string
-	patterns          map[string]*regexp.Regexp
-	normalizedMatcher *NormalizedValueMatcher
-	qualityProfiler   *DataQualityProfiler
+	contextService       *ContextService
+	synonyms             map[string][]string
+	patterns             map[string]*regexp.Regexp
+	normalizedMatcher    *NormalizedValueMatcher
+	qualityProfiler      *DataQualityProfiler
+	probabilisticMatcher *ProbabilisticMatcher
+	scorers              *ScorerRegistry
 }
 
 // NewEnhancedSimilarityService creates a new enhanced similarity service
 func NewEnhancedSimilarityService(ctx *ContextService) *EnhancedSimilarityService {
 	svc := &EnhancedSimilarityService{
-		contextService:    ctx,
-		synonyms:          buildSynonymMap(),
-		patterns:          buildPatternMap(),
-		normalizedMatcher: NewNormalizedValueMatcher(),
-		qualityProfiler:   NewDataQualityProfiler(),
-	}
+		contextService:       ctx,
+		synonyms:             buildSynonymMap(),
+		patterns:             buildPatternMap(),
+		normalizedMatcher:    NewNormalizedValueMatcher(),
+		qualityProfiler:      NewDataQualityProfiler(),
+		probabilisticMatcher: NewProbabilisticMatcher(),
+	}
+	svc.scorers = defaultScorerRegistry(svc)
 	return svc
 }
 
+// Scorers returns the registry of signals used by compareColumns
+func (s *EnhancedSimilarityService) Scorers() *ScorerRegistry {
+	return s.scorers
+}
+
 // buildSynonymMap creates a map of common synonyms for column names
 func buildSynonymMap() map[string][]string {
 	return map[string][]string{
@@ -111,108 +129,313 @@ type SimilarityResult struct {
 	LLMSemanticScore       float64 `json:"llm_semantic_score"`
 	Reason                 string  `json:"reason,omitempty"`
 
+	// 95% interval of Confidence, from the number of values the overlap or
+	// distribution estimate rests on (SampleSize)
+	ConfidenceLower float64 `json:"confidence_lower"`
+	ConfidenceUpper float64 `json:"confidence_upper"`
+	SampleSize      int     `json:"sample_size"`
+
 	// Enhanced metrics
 	TokenSimilarity float64 `json:"token_similarity"`
 	SynonymMatch    bool    `json:"synonym_match"`
+	GlossaryTerm    string  `json:"glossary_term,omitempty"` // Business term the pair resolves to
 	PatternMatch    string  `json:"pattern_match,omitempty"`
 	ValueOverlap    float64 `json:"value_overlap"`
+
+	// Expressions that make the two columns' formats comparable
+	FormatTransform *models.ColumnTransform `json:"format_transform,omitempty"`
+
+	// Raw output of each scorer, keyed by scorer name
+	Signals map[string]float64 `json:"signals,omitempty"`
+
+	// Reason the custom scoring script gave for this pair
+	ScriptRules  []string `json:"script_rules,omitempty"`
+	ScriptVetoed bool     `json:"script_vetoed,omitempty"`
+	ScriptError  string   `json:"script_error,omitempty"` // The script failed for this pair and left it alone
+}
+
+// ScoringOptions overrides parts of the scoring pipeline for a single run
+type ScoringOptions struct {
+	Weights       *AdaptiveWeights // nil = use the learned adaptive weights
+	MinConfidence float64          // Results at or below this confidence are dropped
+	Blocking      string           // BlockingAuto (default), BlockingOn or BlockingOff
+	Workers       int              // Parallel comparison workers; 0 = one per CPU
+	NameAlgorithm string           // Name similarity measure; "" = DefaultNameAlgorithm
+}
+
+// SimilarityRunStats reports how much work a similarity run did
+type SimilarityRunStats struct {
+	TotalPairs      int     `json:"total_pairs"`
+	ComparedPairs   int     `json:"compared_pairs"`
+	PrunedPairs     int     `json:"pruned_pairs"`
+	PruningRatio    float64 `json:"pruning_ratio"`
+	BlockingEnabled bool    `json:"blocking_enabled"`
+	Workers         int     `json:"workers"`
+	ProfileMs       int64   `json:"profile_ms"`
+	DurationMs      int64   `json:"duration_ms"`
+	CacheHits       int     `json:"profile_cache_hits"` // Files (0-2) whose profiles came from the cache
+}
+
+// DefaultScoringOptions returns the options used by the standard pipeline
+func DefaultScoringOptions() ScoringOptions {
+	return ScoringOptions{MinConfidence: 10, Blocking: BlockingAuto}
 }
 
 // CalculateEnhancedSimilarity performs comprehensive similarity analysis
 func (s *EnhancedSimilarityService) CalculateEnhancedSimilarity(
 	df1, df2 *state.DataFrame,
 	ctx1, ctx2 *models.Context,
-) []SimilarityResult {
+) ([]SimilarityResult, error) {
+	results, _, err := s.CalculateEnhancedSimilarityWithOptions(context.Background(), df1, df2, ctx1, ctx2, DefaultScoringOptions())
+	return results, err
+}
+
+// CalculateEnhancedSimilarityWithOptions runs the similarity analysis with
+// per-call overrides; nothing in opts is persisted. A panic while profiling
+// or scoring is returned as an error.
+func (s *EnhancedSimilarityService) CalculateEnhancedSimilarityWithOptions(
+	ctx context.Context,
+	df1, df2 *state.DataFrame,
+	ctx1, ctx2 *models.Context,
+	opts ScoringOptions,
+) ([]SimilarityResult, SimilarityRunStats, error) {
+	ctx, span := tracing.Start(ctx, "similarity.enhanced")
+	defer span.End()
+	start := time.Now()
+	scope := DatasetPairScope(df1, df2)
+
+	// Profile every column once; pairs only combine precomputed profiles
+	_, profiling := tracing.Start(ctx, "similarity.profile")
+	profiles1, hit1, err := s.profileColumnsCached(df1)
+	if err != nil {
+		profiling.End()
+		return nil, SimilarityRunStats{}, err
+	}
+	profiles2, hit2, err := s.profileColumnsCached(df2)
+	if err != nil {
+		profiling.End()
+		return nil, SimilarityRunStats{}, err
+	}
+	profiling.SetAttr("cache_hits", boolCount(hit1)+boolCount(hit2))
+	profiling.End()
+
+	stats := SimilarityRunStats{
+		TotalPairs: len(df1.Headers) * len(df2.Headers),
+		ProfileMs:  time.Since(start).Milliseconds(),
+		CacheHits:  boolCount(hit1) + boolCount(hit2),
+	}
+	var candidates map[int][]int
+	if useBlocking(opts.Blocking, stats.TotalPairs) {
+		stats.BlockingEnabled = true
+		candidates = NewColumnBlocker(s).CandidatePairs(df1, df2, profiles1, profiles2, ctx1, ctx2)
+	}
+
+	weights := GetAdaptiveLearner().GetWeights()
+	if opts.Weights != nil {
+		weights = *opts.Weights
+	}
+
+	stats.Workers = opts.Workers
+	if stats.Workers <= 0 {
+		stats.Workers = workerCount(len(df1.Headers))
+	}
+
+	// Each worker takes whole file1 columns; results are kept per column so the
+	// final order doesn't depend on scheduling
+	_, scoring := tracing.Start(ctx, "similarity.score")
+	perColumn := make([][]SimilarityResult, len(df1.Headers))
+	compared := make([]int, len(df1.Headers))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	var panics workerPanics
+	for w := 0; w < stats.Workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for col1Idx := range jobs {
+				func() {
+					defer panics.catch("scoring column " + df1.Headers[col1Idx])
+					col2Indices := candidates[col1Idx]
+					if !stats.BlockingEnabled {
+						col2Indices = allIndices(len(df2.Headers))
+					}
+					for _, col2Idx := range col2Indices {
+						compared[col1Idx]++
+						result := s.compareColumns(df1, df2, profiles1[col1Idx], profiles2[col2Idx], ctx1, ctx2, scope, weights, opts.NameAlgorithm)
+
+						// Only include if has meaningful similarity
+						if result.Confidence > opts.MinConfidence {
+							perColumn[col1Idx] = append(perColumn[col1Idx], result)
+						}
+					}
+				}()
+			}
+		}()
+	}
+	for col1Idx := range df1.Headers {
+		jobs <- col1Idx
+	}
+	close(jobs)
+	wg.Wait()
+	scoring.SetAttr("workers", stats.Workers)
+	scoring.End()
+	if err := panics.Err(); err != nil {
+		return nil, SimilarityRunStats{}, err
+	}
+
 	results := []SimilarityResult{}
+	for col1Idx := range perColumn {
+		results = append(results, perColumn[col1Idx]...)
+		stats.ComparedPairs += compared[col1Idx]
+	}
 
-	for col1Idx, col1 := range df1.Headers {
-		for col2Idx, col2 := range df2.Headers {
-			result := s.compareColumns(df1, df2, col1Idx, col2Idx, col1, col2, ctx1, ctx2)
+	s.finishResults(df1, df2, profiles1, profiles2, scope, results)
 
-			// Only include if has meaningful similarity
-			if result.Confidence > 10 {
-				results = append(results, result)
-			}
+	stats.PrunedPairs = stats.TotalPairs - stats.ComparedPairs
+	if stats.TotalPairs > 0 {
+		stats.PruningRatio = float64(stats.PrunedPairs) / float64(stats.TotalPairs)
+	}
+	stats.DurationMs = time.Since(start).Milliseconds()
+	span.SetAttr("pairs.total", stats.TotalPairs)
+	span.SetAttr("pairs.compared", stats.ComparedPairs)
+	span.SetAttr("blocking", stats.BlockingEnabled)
+	similarityLog.InfoContext(ctx, "Compared column pairs",
+		"compared", stats.ComparedPairs, "total", stats.TotalPairs, "workers", stats.Workers,
+		"duration_ms", stats.DurationMs, "profile_ms", stats.ProfileMs)
+
+	return results, stats, nil
+}
+
+// ColumnCandidates scores one column of a file against every column of the
+// other file, strongest first, without comparing the other pairs. Results
+// keep the file 1 / file 2 orientation whichever file the column is from.
+func (s *EnhancedSimilarityService) ColumnCandidates(
+	ctx context.Context,
+	df1, df2 *state.DataFrame,
+	ctx1, ctx2 *models.Context,
+	fileIndex int,
+	column string,
+	opts ScoringOptions,
+) ([]SimilarityResult, error) {
+	_, span := tracing.Start(ctx, "similarity.candidates")
+	defer span.End()
+	span.SetAttr("file_index", fileIndex)
+
+	df := df1
+	if fileIndex == 2 {
+		df = df2
+	}
+	colIdx := columnIndex(df, column)
+	if colIdx < 0 {
+		return nil, fmt.Errorf("column %q not found in file %d", column, fileIndex)
+	}
+
+	profiles1, _, err := s.profileColumnsCached(df1)
+	if err != nil {
+		return nil, err
+	}
+	profiles2, _, err := s.profileColumnsCached(df2)
+	if err != nil {
+		return nil, err
+	}
+	scope := DatasetPairScope(df1, df2)
+	weights := GetAdaptiveLearner().GetWeights()
+	if opts.Weights != nil {
+		weights = *opts.Weights
+	}
+
+	results := []SimilarityResult{}
+	if fileIndex == 1 {
+		for _, p2 := range profiles2 {
+			results = append(results, s.compareColumns(df1, df2, profiles1[colIdx], p2, ctx1, ctx2, scope, weights, opts.NameAlgorithm))
+		}
+	} else {
+		for _, p1 := range profiles1 {
+			results = append(results, s.compareColumns(df1, df2, p1, profiles2[colIdx], ctx1, ctx2, scope, weights, opts.NameAlgorithm))
 		}
 	}
 
+	kept := []SimilarityResult{}
+	for _, r := range results {
+		if r.Confidence > opts.MinConfidence {
+			kept = append(kept, r)
+		}
+	}
+	s.finishResults(df1, df2, profiles1, profiles2, scope, kept)
+	span.SetAttr("candidates", len(kept))
+	return kept, nil
+}
+
+// finishResults applies the adjustments that need the scored pairs, sets
+// the confidence intervals and sorts the results by confidence
+func (s *EnhancedSimilarityService) finishResults(df1, df2 *state.DataFrame, profiles1, profiles2 []*ColumnProfile, scope string, results []SimilarityResult) {
+	// Numeric matches in different units only show up across rows
+	detectScaleMismatches(df1, df2, profiles1, profiles2, scope, results)
+
+	// Intervals come last, once every adjustment to the confidence is made
+	for i := range results {
+		results[i].ConfidenceLower, results[i].ConfidenceUpper = s.probabilisticMatcher.ConfidenceBounds(results[i].Confidence, results[i].SampleSize)
+	}
+
 	// Sort by confidence
-	sort.Slice(results, func(i, j int) bool {
+	sort.SliceStable(results, func(i, j int) bool {
 		return results[i].Confidence > results[j].Confidence
 	})
+}
+
+// boolCount returns 1 for true, 0 for false
+func boolCount(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
 
-	return results
+// allIndices returns 0..n-1
+func allIndices(n int) []int {
+	idx := make([]int, n)
+	for i := range idx {
+		idx[i] = i
+	}
+	return idx
 }
 
 // compareColumns performs detailed comparison between two columns
 func (s *EnhancedSimilarityService) compareColumns(
 	df1, df2 *state.DataFrame,
-	col1Idx, col2Idx int,
-	col1, col2 string,
+	p1, p2 *ColumnProfile,
 	ctx1, ctx2 *models.Context,
+	scope string,
+	weights AdaptiveWeights,
+	nameAlgorithm string,
 ) SimilarityResult {
+	col1, col2 := p1.Name, p2.Name
 	result := SimilarityResult{
 		File1Column: col1,
 		File2Column: col2,
 	}
 
-	// 1. Tokenized Name Similarity
-	tokenSim, isSynonym := s.calculateTokenSimilarity(col1, col2)
-	result.TokenSimilarity = tokenSim
-	result.SynonymMatch = isSynonym
-	result.NameSimilarity = tokenSim
-
-	// 2. Pattern Detection
-	pattern1 := s.detectPattern(df1, col1Idx)
-	pattern2 := s.detectPattern(df2, col2Idx)
-	patternScore := 0.0
-	if pattern1 != "" && pattern1 == pattern2 {
-		patternScore = 0.9
-		result.PatternMatch = pattern1
+	pc := &PairContext{
+		DF1: df1, DF2: df2,
+		P1: p1, P2: p2,
+		Col1: col1, Col2: col2,
+		Ctx1: ctx1, Ctx2: ctx2,
+		Scope:         scope,
+		NameAlgorithm: nameAlgorithm,
+		Result:        &result,
 	}
-	result.JSONConfidence = patternScore
 
-	// 3. Data Quality Profiling (NEW)
-	profile1 := s.qualityProfiler.ProfileColumn(df1, col1Idx)
-	profile2 := s.qualityProfiler.ProfileColumn(df2, col2Idx)
-	qualityMatch := s.qualityProfiler.CompareQuality(profile1, profile2)
+	// 1-8. Weighted signals from the scorer registry (name, value overlap,
+	// pattern, LLM, quality, cardinality, normalized values)
+	result.Signals, result.Confidence = s.scorers.Run(pc, weights)
+	result.SampleSize = pairSampleSize(p1, p2)
 
-	// 4. Cardinality Analysis (NEW)
+	profile1, profile2 := pc.Profiles()
+	normalizedMatch := pc.NormalizedMatch()
 	cardinalityMatch := s.normalizedMatcher.CalculateCardinalityMatch(profile1, profile2)
-
-	// 5. Format Normalization & Value Matching (NEW)
-	normalizedMatch := s.normalizedMatcher.CalculateNormalizedMatch(df1, df2, col1Idx, col2Idx)
-	formatTransform, formatType := s.normalizedMatcher.DetectFormatTransformation(df1, df2, col1Idx, col2Idx)
-
-	// 6. Traditional Value Overlap (for categorical) or Distribution (for numeric)
-	numericCols1 := df1.GetNumericColumnIndices()
-	numericCols2 := df2.GetNumericColumnIndices()
-	isNum1, isNum2 := numericCols1[col1Idx], numericCols2[col2Idx]
-
-	if isNum1 && isNum2 {
-		// Numeric: distribution similarity
-		result.DistributionSimilarity = s.calculateDistributionSimilarity(df1, df2, col1Idx, col2Idx)
-		result.DataSimilarity = result.DistributionSimilarity
-	} else if !isNum1 && !isNum2 {
-		// Categorical: use normalized match if better than raw overlap
-		rawOverlap := s.calculateValueOverlap(df1, df2, col1Idx, col2Idx)
-		result.ValueOverlap = math.Max(rawOverlap, normalizedMatch)
-		result.DataSimilarity = result.ValueOverlap
-	}
-
-	// 7. Get adaptive weights
-	adaptiveLearner := GetAdaptiveLearner()
-	weights := adaptiveLearner.GetWeights()
-
-	// 8. Calculate Final Confidence using ENHANCED weights
-	// Include new signals: quality, cardinality, normalized matching
-	result.Confidence = (result.NameSimilarity * weights.Name * 100) +
-		(result.DataSimilarity * weights.Data * 100) +
-		(patternScore * weights.Pattern * 100) +
-		(result.LLMSemanticScore * weights.LLM * 100) +
-		(qualityMatch * 10) + // NEW: Quality boost up to 10%
-		(cardinalityMatch * 15) + // NEW: Cardinality boost up to 15%
-		(normalizedMatch * 10) // NEW: Normalized match boost up to 10%
+	formatTransform, formatType := profileFormatTransformation(p1, p2, normalizedMatch)
+	isSynonym := result.SynonymMatch
+	result.GlossaryTerm = GetGlossary().PairTerm(col1, col2)
 
 	// 9. Format transformation bonus (NEW)
 	if formatTransform {
@@ -220,9 +443,9 @@ func (s *EnhancedSimilarityService) compareColumns(
 		result.PatternMatch = formatType + "_transform"
 	}
 
-	// 10. Apply learned boosts from feedback
+	// 10. Apply learned boosts from feedback (scoped to this dataset pair)
 	feedbackSystem := GetFeedbackSystem()
-	feedbackBoost := feedbackSystem.GetLearnedBoost(col1, col2)
+	feedbackBoost := feedbackSystem.GetLearnedBoost(scope, col1, col2)
 	result.Confidence += feedbackBoost * 100
 
 	// 11. Apply pattern learning boost
@@ -245,10 +468,31 @@ func (s *EnhancedSimilarityService) compareColumns(
 		result.Confidence = s.applyContextBoost(result.Confidence, col1, col2, ctx1, ctx2)
 	}
 
-	// 15. Apply confidence calibration
+	// 15. Custom scoring script (final adjustment or veto before calibration)
+	if script, _ := GetScoringScriptStore().Current(); script != nil {
+		outcome, err := script.Evaluate(ScriptVariables(pc, result.Confidence))
+		if err != nil {
+			result.ScriptError = err.Error()
+		}
+		result.ScriptRules = outcome.Fired
+		if outcome.Vetoed {
+			result.ScriptVetoed = true
+			result.Confidence = 0
+		} else {
+			result.Confidence += outcome.Adjustment
+		}
+	}
+
+	// 16. Apply confidence calibration
 	calibrator := GetConfidenceCalibrator()
 	result.Confidence = calibrator.Calibrate(result.Confidence)
 
+	// 17. Join keys declared in the relationship answers
+	declared := !result.ScriptVetoed && isDeclaredJoinKey(ctx1, ctx2, col1, col2)
+	if declared {
+		result.Confidence = math.Max(result.Confidence, DeclaredKeyConfidence)
+	}
+
 	// Clamp to 0-100
 	if result.Confidence < 0 {
 		result.Confidence = 0
@@ -259,8 +503,16 @@ func (s *EnhancedSimilarityService) compareColumns(
 
 	// Determine similarity type
 	result.Type = s.determineType(result)
+	if declared {
+		result.Type = "declared_key"
+	}
 	result.Similarity = result.Confidence / 100
 
+	// 18. Suggest a format transform for likely matches
+	if result.Confidence >= formatTransformMinConfidence {
+		result.FormatTransform = suggestFormatTransform(df1, df2, p1, p2, formatType)
+	}
+
 	// Build ENHANCED reason string (NEW)
 	result.Reason = s.normalizedMatcher.ExplainMatch(
 		col1, col2,
@@ -272,12 +524,16 @@ func (s *EnhancedSimilarityService) compareColumns(
 		formatTransform,
 		formatType,
 	)
+	if declared {
+		result.Reason = "Declared join key; " + result.Reason
+	}
 
 	return result
 }
 
-// calculateTokenSimilarity compares tokenized column names with synonym matching
-func (s *EnhancedSimilarityService) calculateTokenSimilarity(col1, col2 string) (float64, bool) {
+// calculateTokenSimilarity compares tokenized column names with synonym matching,
+// using the given string measure for partial matches
+func (s *EnhancedSimilarityService) calculateTokenSimilarity(col1, col2, algorithm string) (float64, bool) {
 	// Normalize and tokenize
 	tokens1 := tokenize(col1)
 	tokens2 := tokenize(col2)
@@ -291,6 +547,12 @@ func (s *EnhancedSimilarityService) calculateTokenSimilarity(col1, col2 string)
 		return 1.0, false
 	}
 
+	// Names of the same business glossary term
+	glossary := GetGlossary()
+	if glossary.PairTerm(col1, col2) != "" {
+		return glossaryMatchSimilarity, true
+	}
+
 	// Token set comparison
 	set1 := make(map[string]bool)
 	set2 := make(map[string]bool)
@@ -312,14 +574,9 @@ func (s *EnhancedSimilarityService) calculateTokenSimilarity(col1, col2 string)
 	// Synonym matching
 	synonymMatch := false
 	for t1 := range set1 {
-		if synonyms, ok := s.synonyms[t1]; ok {
-			for _, syn := range synonyms {
-				if set2[syn] {
-					intersection++
-					synonymMatch = true
-					break
-				}
-			}
+		if anyIn(set2, s.synonyms[t1]) || anyIn(set2, glossary.TokenSynonyms(t1)) {
+			intersection++
+			synonymMatch = true
 		}
 	}
 
@@ -331,19 +588,29 @@ func (s *EnhancedSimilarityService) calculateTokenSimilarity(col1, col2 string)
 	// Jaccard similarity of tokens
 	jaccardSim := float64(intersection) / float64(union)
 
-	// Also consider Levenshtein for partial matches
-	levenSim := LevenshteinRatio(col1, col2)
+	// Also consider a string measure for partial matches
+	stringSim := StringSimilarity(algorithm, textnorm.Fold(col1), textnorm.Fold(col2))
 
 	// Combine both
-	finalSim := math.Max(jaccardSim, levenSim)
+	finalSim := math.Max(jaccardSim, stringSim)
 
 	return finalSim, synonymMatch
 }
 
+// anyIn reports whether any of the tokens is in the set
+func anyIn(set map[string]bool, tokens []string) bool {
+	for _, t := range tokens {
+		if set[t] {
+			return true
+		}
+	}
+	return false
+}
+
 // tokenize splits a column name into normalized tokens
 func tokenize(name string) []string {
-	// Convert to lowercase
-	name = strings.ToLower(name)
+	// Fold accents, compatibility forms and case
+	name = textnorm.Fold(name)
 
 	// Split by common separators
 	name = strings.ReplaceAll(name, "_", " ")
@@ -374,7 +641,7 @@ func tokenize(name string) []string {
 
 // normalize removes common prefixes/suffixes and normalizes
 func normalize(name string) string {
-	name = strings.ToLower(name)
+	name = textnorm.Fold(name)
 	name = strings.ReplaceAll(name, "_", "")
 	name = strings.ReplaceAll(name, "-", "")
 	name = strings.ReplaceAll(name, " ", "")
@@ -423,106 +690,9 @@ func (s *EnhancedSimilarityService) detectPattern(df *state.DataFrame, colIdx in
 	return ""
 }
 
-// calculateValueOverlap computes Jaccard similarity of unique values
-func (s *EnhancedSimilarityService) calculateValueOverlap(df1, df2 *state.DataFrame, col1Idx, col2Idx int) float64 {
-	set1 := make(map[string]bool)
-	set2 := make(map[string]bool)
-
-	// Sample up to 500 rows
-	limit1 := 500
-	if len(df1.Rows) < limit1 {
-		limit1 = len(df1.Rows)
-	}
-	for i := 0; i < limit1; i++ {
-		if col1Idx < len(df1.Rows[i]) && df1.Rows[i][col1Idx] != "" {
-			set1[strings.ToLower(df1.Rows[i][col1Idx])] = true
-		}
-	}
-
-	limit2 := 500
-	if len(df2.Rows) < limit2 {
-		limit2 = len(df2.Rows)
-	}
-	for i := 0; i < limit2; i++ {
-		if col2Idx < len(df2.Rows[i]) && df2.Rows[i][col2Idx] != "" {
-			set2[strings.ToLower(df2.Rows[i][col2Idx])] = true
-		}
-	}
-
-	if len(set1) == 0 || len(set2) == 0 {
-		return 0
-	}
-
-	// Jaccard similarity
-	intersection := 0
-	for k := range set1 {
-		if set2[k] {
-			intersection++
-		}
-	}
-
-	union := len(set1) + len(set2) - intersection
-	if union == 0 {
-		return 0
-	}
-
-	return float64(intersection) / float64(union)
-}
-
-// calculateDistributionSimilarity compares statistical distributions
-func (s *EnhancedSimilarityService) calculateDistributionSimilarity(df1, df2 *state.DataFrame, col1Idx, col2Idx int) float64 {
-	vals1 := getFloatValues(df1, col1Idx)
-	vals2 := getFloatValues(df2, col2Idx)
-
-	if len(vals1) < 5 || len(vals2) < 5 {
-		return 0
-	}
-
-	// Calculate stats for both columns
-	mean1, std1 := meanAndStd(vals1)
-	mean2, std2 := meanAndStd(vals2)
-
-	// Coefficient of Variation similarity
-	cv1 := 0.0
-	cv2 := 0.0
-	if mean1 != 0 {
-		cv1 = std1 / math.Abs(mean1)
-	}
-	if mean2 != 0 {
-		cv2 = std2 / math.Abs(mean2)
-	}
-
-	cvDiff := math.Abs(cv1 - cv2)
-	cvSim := math.Max(0, 1-cvDiff)
-
-	// Range similarity (normalized)
-	min1, max1 := minMax(vals1)
-	min2, max2 := minMax(vals2)
-	range1 := max1 - min1
-	range2 := max2 - min2
-
-	rangeSim := 0.0
-	if range1 > 0 && range2 > 0 {
-		rangeRatio := math.Min(range1, range2) / math.Max(range1, range2)
-		rangeSim = rangeRatio
-	}
-
-	// Combine metrics
-	return (cvSim * 0.6) + (rangeSim * 0.4)
-}
-
 // getFloatValues extracts numeric values from a column
 func getFloatValues(df *state.DataFrame, colIdx int) []float64 {
-	values := []float64{}
-	for _, row := range df.Rows {
-		if colIdx >= len(row) {
-			continue
-		}
-		if val, err := strconv.ParseFloat(row[colIdx], 64); err == nil {
-			values = append(values, val)
-		}
-	}
-	return values
+	return df.FloatValues(colIdx)
 }
 
 // meanAndStd calculates mean and standard deviation
@@ -0,0 +1,27 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"backend-go/internal/state"
+)
+
+// panickingScorer fails on every pair
+type panickingScorer struct{}
+
+func (panickingScorer) Name() string               { return "panicking" }
+func (panickingScorer) Description() string        { return "Panics" }
+func (panickingScorer) Score(*PairContext) float64 { panic("scorer bug") }
+
+func TestEnhancedSimilarityWorkerPanic(t *testing.T) {
+	df1 := &state.DataFrame{Headers: []string{"id", "name", "city"}, Rows: [][]string{{"1", "Ada", "Paris"}}}
+	df2 := &state.DataFrame{Headers: []string{"id", "full_name"}, Rows: [][]string{{"1", "Ada"}}}
+
+	s := NewEnhancedSimilarityService(nil)
+	s.Scorers().Register(panickingScorer{}, 1)
+	results, err := s.CalculateEnhancedSimilarity(df1, df2, nil, nil)
+	if err == nil || !strings.Contains(err.Error(), "scorer bug") {
+		t.Fatalf("got %d results and error %v, want the panic as an error", len(results), err)
+	}
+}
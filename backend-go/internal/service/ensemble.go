@@ -0,0 +1,420 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ensembleLog = logging.Component("ensemble")
+
+const ensembleWeightsFile = "ensemble_weights.json"
+
+// Matchers combined by the ensemble
+const (
+	EnsembleHeuristic = "heuristic" // EnhancedSimilarityService
+	EnsembleEmbedding = "embedding" // EmbeddingMatcher
+	EnsembleLLM       = "llm"       // AISemanticMatcher
+)
+
+// ensembleMatchers lists the matchers in the order their scores are combined
+var ensembleMatchers = []string{EnsembleHeuristic, EnsembleEmbedding, EnsembleLLM}
+
+// Ensemble tuning
+const (
+	ensembleEmbeddingCandidate = 0.75 // Embedding similarity that makes a pair a candidate on its own
+	ensembleLearningRate       = 0.5  // Step of the multiplicative weight update
+	ensembleMinWeight          = 0.05 // No matcher is ever silenced completely
+)
+
+// EnsembleWeights are the weights of each matcher in the combined score
+type EnsembleWeights struct {
+	Heuristic float64 `json:"heuristic"`
+	Embedding float64 `json:"embedding"`
+	LLM       float64 `json:"llm"`
+}
+
+// DefaultEnsembleWeights are the weights used before any feedback
+var DefaultEnsembleWeights = EnsembleWeights{
+	Heuristic: 0.5,
+	Embedding: 0.2,
+	LLM:       0.3,
+}
+
+// get returns the weight of a matcher
+func (w EnsembleWeights) get(matcher string) float64 {
+	switch matcher {
+	case EnsembleHeuristic:
+		return w.Heuristic
+	case EnsembleEmbedding:
+		return w.Embedding
+	case EnsembleLLM:
+		return w.LLM
+	}
+	return 0
+}
+
+// set sets the weight of a matcher
+func (w *EnsembleWeights) set(matcher string, v float64) {
+	switch matcher {
+	case EnsembleHeuristic:
+		w.Heuristic = v
+	case EnsembleEmbedding:
+		w.Embedding = v
+	case EnsembleLLM:
+		w.LLM = v
+	}
+}
+
+// normalized returns the weights scaled to sum to 1
+func (w EnsembleWeights) normalized() EnsembleWeights {
+	total := w.Heuristic + w.Embedding + w.LLM
+	if total <= 0 {
+		return DefaultEnsembleWeights
+	}
+	return EnsembleWeights{Heuristic: w.Heuristic / total, Embedding: w.Embedding / total, LLM: w.LLM / total}
+}
+
+// EnsembleResult is a column pair scored by the ensemble
+type EnsembleResult struct {
+	File1Column string  `json:"file1_column"`
+	File2Column string  `json:"file2_column"`
+	Confidence  float64 `json:"confidence"` // 0-100, weighted mean of the matcher scores
+	// 0-1 score of each matcher that ran; a matcher that ran without
+	// reporting the pair scores it 0
+	Scores map[string]float64 `json:"scores"`
+	// Share of the matchers that ran whose verdict (score of at least 0.5)
+	// is the ensemble's
+	Agreement float64  `json:"agreement"`
+	Agreeing  []string `json:"agreeing"`
+	Spread    float64  `json:"spread"` // Highest minus lowest matcher score
+	Type      string   `json:"type"`   // Type given by the heuristic matcher, "ensemble" otherwise
+	Reason    string   `json:"reason,omitempty"`
+}
+
+// EnsembleMatcherStatus reports whether a matcher took part in a run
+type EnsembleMatcherStatus struct {
+	Ran        bool    `json:"ran"`
+	Weight     float64 `json:"weight"`
+	Pairs      int     `json:"pairs"` // Pairs the matcher reported
+	DurationMs int64   `json:"duration_ms"`
+	Error      string  `json:"error,omitempty"`
+}
+
+// EnsembleReport is the outcome of an ensemble run
+type EnsembleReport struct {
+	Results  []EnsembleResult                 `json:"results"`
+	Matchers map[string]EnsembleMatcherStatus `json:"matchers"`
+	Weights  EnsembleWeights                  `json:"weights"`
+}
+
+// EnsembleMatcher runs the heuristic, embedding and LLM matchers and
+// combines their scores with EnsembleMatch, weighted by what feedback has
+// shown each matcher to get right
+type EnsembleMatcher struct {
+	enhanced  *EnhancedSimilarityService
+	embedding *EmbeddingMatcher
+	ai        *AISemanticMatcher
+	combiner  *ProbabilisticMatcher
+}
+
+// NewEnsembleMatcher creates an ensemble of the given matchers; nil
+// embedding or AI matchers are left out
+func NewEnsembleMatcher(enhanced *EnhancedSimilarityService, embedding *EmbeddingMatcher, ai *AISemanticMatcher) *EnsembleMatcher {
+	return &EnsembleMatcher{
+		enhanced:  enhanced,
+		embedding: embedding,
+		ai:        ai,
+		combiner:  NewProbabilisticMatcher(),
+	}
+}
+
+// Match scores the column pairs of two files with every matcher at once.
+// Candidates are the pairs the heuristic or LLM matcher report plus those
+// the embeddings find very similar; pairs at or below opts.MinConfidence
+// are dropped. A failing matcher is reported and left out of the mean.
+func (e *EnsembleMatcher) Match(ctx context.Context, df1, df2 *state.DataFrame, ctx1, ctx2 *models.Context, opts ScoringOptions) *EnsembleReport {
+	weights := GetEnsembleLearner().GetWeights()
+	report := &EnsembleReport{Matchers: map[string]EnsembleMatcherStatus{}, Weights: weights}
+	scores := map[string]map[[2]string]float64{}
+	types := map[[2]string]string{}
+	candidates := map[[2]string]bool{}
+
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	run := func(matcher string, fn func() (map[[2]string]float64, error)) {
+		defer wg.Done()
+		start := time.Now()
+		pairs, err := fn()
+		status := EnsembleMatcherStatus{Ran: err == nil, Weight: weights.get(matcher), Pairs: len(pairs), DurationMs: time.Since(start).Milliseconds()}
+		if err != nil {
+			status.Error = err.Error()
+			ensembleLog.WarnContext(ctx, "Matcher failed, leaving it out of the ensemble", "matcher", matcher, "error", err)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		report.Matchers[matcher] = status
+		if err == nil {
+			scores[matcher] = pairs
+		}
+	}
+
+	wg.Add(3)
+	go run(EnsembleHeuristic, func() (map[[2]string]float64, error) {
+		heuristicOpts := opts
+		heuristicOpts.MinConfidence = 0
+		results, _, err := e.enhanced.CalculateEnhancedSimilarityWithOptions(ctx, df1, df2, ctx1, ctx2, heuristicOpts)
+		if err != nil {
+			return nil, err
+		}
+		pairs := make(map[[2]string]float64, len(results))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, r := range results {
+			key := [2]string{r.File1Column, r.File2Column}
+			pairs[key] = r.Confidence / 100
+			types[key] = r.Type
+			if r.Confidence > opts.MinConfidence {
+				candidates[key] = true
+			}
+		}
+		return pairs, nil
+	})
+	go run(EnsembleEmbedding, func() (map[[2]string]float64, error) {
+		if e.embedding == nil {
+			return nil, fmt.Errorf("no embedding matcher configured")
+		}
+		pairs, err := e.embedding.MatchColumns(ctx, df1, df2)
+		if err != nil {
+			return nil, err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		for key, score := range pairs {
+			if score >= ensembleEmbeddingCandidate {
+				candidates[key] = true
+			}
+		}
+		return pairs, nil
+	})
+	go run(EnsembleLLM, func() (map[[2]string]float64, error) {
+		if e.ai == nil {
+			return nil, fmt.Errorf("no LLM matcher configured")
+		}
+		matches := e.ai.MatchColumns(ctx, df1, df2, ctx1, ctx2)
+		pairs := make(map[[2]string]float64, len(matches))
+		mu.Lock()
+		defer mu.Unlock()
+		for _, m := range matches {
+			key := [2]string{m.File1Column, m.File2Column}
+			pairs[key] = m.Confidence / 100
+			candidates[key] = true
+		}
+		return pairs, nil
+	})
+	wg.Wait()
+
+	report.Results = []EnsembleResult{}
+	recorded := map[[2]string]map[string]float64{}
+	for key := range candidates {
+		result := e.combine(key, scores, weights)
+		if result.Confidence <= opts.MinConfidence {
+			continue
+		}
+		result.Type = "ensemble"
+		if t, ok := types[key]; ok && t != "" {
+			result.Type = t
+		}
+		report.Results = append(report.Results, result)
+		recorded[key] = result.Scores
+	}
+	sort.Slice(report.Results, func(i, j int) bool {
+		if report.Results[i].Confidence != report.Results[j].Confidence {
+			return report.Results[i].Confidence > report.Results[j].Confidence
+		}
+		if report.Results[i].File1Column != report.Results[j].File1Column {
+			return report.Results[i].File1Column < report.Results[j].File1Column
+		}
+		return report.Results[i].File2Column < report.Results[j].File2Column
+	})
+
+	// Feedback on these pairs tells the learner which matchers were right
+	GetEnsembleLearner().Remember(DatasetPairScope(df1, df2), recorded)
+	return report
+}
+
+// combine scores one pair with the matchers that ran
+func (e *EnsembleMatcher) combine(key [2]string, scores map[string]map[[2]string]float64, weights EnsembleWeights) EnsembleResult {
+	result := EnsembleResult{File1Column: key[0], File2Column: key[1], Scores: map[string]float64{}, Agreeing: []string{}}
+
+	values, ws := []float64{}, []float64{}
+	for _, matcher := range ensembleMatchers {
+		pairs, ran := scores[matcher]
+		if !ran {
+			continue
+		}
+		score := pairs[key]
+		result.Scores[matcher] = score
+		values = append(values, score)
+		ws = append(ws, weights.get(matcher))
+	}
+	if len(values) == 0 {
+		return result
+	}
+	combined := e.combiner.EnsembleMatch(values, ws)
+	result.Confidence = combined * 100
+
+	low, high := values[0], values[0]
+	parts := []string{}
+	for _, matcher := range ensembleMatchers {
+		score, ran := result.Scores[matcher]
+		if !ran {
+			continue
+		}
+		low, high = math.Min(low, score), math.Max(high, score)
+		if (score >= 0.5) == (combined >= 0.5) {
+			result.Agreeing = append(result.Agreeing, matcher)
+		}
+		parts = append(parts, fmt.Sprintf("%s %s", matcher, formatPercent(score)))
+	}
+	result.Agreement = float64(len(result.Agreeing)) / float64(len(values))
+	result.Spread = high - low
+	result.Reason = strings.Join(parts, " | ")
+	return result
+}
+
+// EnsembleLearner learns the ensemble weights from match feedback: each
+// piece of feedback on a pair the ensemble scored shrinks the weight of a
+// matcher by how far its score was from the verdict
+type EnsembleLearner struct {
+	weights EnsembleWeights
+	updates int
+	recent  map[string]map[[2]string]map[string]float64 // Scope -> pair -> matcher scores of the last run
+	mutex   sync.RWMutex
+}
+
+// ensembleLearnerData is the file layout of the learner
+type ensembleLearnerData struct {
+	Weights EnsembleWeights `json:"weights"`
+	Updates int             `json:"updates"`
+}
+
+var (
+	ensembleLearner     *EnsembleLearner
+	ensembleLearnerOnce sync.Once
+)
+
+// GetEnsembleLearner returns the singleton ensemble weight learner
+func GetEnsembleLearner() *EnsembleLearner {
+	ensembleLearnerOnce.Do(func() {
+		ensembleLearner = &EnsembleLearner{
+			weights: DefaultEnsembleWeights,
+			recent:  make(map[string]map[[2]string]map[string]float64),
+		}
+		ensembleLearner.load()
+	})
+	return ensembleLearner
+}
+
+// load loads the learned weights from file
+func (l *EnsembleLearner) load() {
+	data, err := os.ReadFile(config.DataPath(ensembleWeightsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			ensembleLog.Error("Error loading ensemble weights", "error", err)
+		}
+		return
+	}
+
+	var saved ensembleLearnerData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		ensembleLog.Error("Error parsing ensemble weights", "error", err)
+		return
+	}
+	l.weights = saved.Weights.normalized()
+	l.updates = saved.Updates
+	ensembleLog.Info("Loaded ensemble weights", "updates", saved.Updates)
+}
+
+// save persists the learned weights to file (must hold lock)
+func (l *EnsembleLearner) save() error {
+	data, err := json.MarshalIndent(ensembleLearnerData{Weights: l.weights, Updates: l.updates}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(ensembleWeightsFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(ensembleWeightsFile), data, 0644)
+}
+
+// GetWeights returns the current ensemble weights
+func (l *EnsembleLearner) GetWeights() EnsembleWeights {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.weights
+}
+
+// Updates returns the number of feedback entries the weights learned from
+func (l *EnsembleLearner) Updates() int {
+	l.mutex.RLock()
+	defer l.mutex.RUnlock()
+	return l.updates
+}
+
+// Remember keeps the matcher scores of an ensemble run of a dataset pair,
+// replacing those of its previous run
+func (l *EnsembleLearner) Remember(scope string, scores map[[2]string]map[string]float64) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.recent[scope] = scores
+}
+
+// Learn updates the weights from feedback on a pair the ensemble scored in
+// its scope; feedback on other pairs is ignored
+func (l *EnsembleLearner) Learn(feedback FeedbackEntry) {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+
+	scores, ok := l.recent[feedback.Scope][[2]string{feedback.File1Column, feedback.File2Column}]
+	if !ok || len(scores) == 0 {
+		return
+	}
+	outcome := 0.0
+	if feedback.IsCorrect {
+		outcome = 1
+	}
+
+	weights := l.weights
+	for matcher, score := range scores {
+		loss := (score - outcome) * (score - outcome)
+		weights.set(matcher, math.Max(ensembleMinWeight, weights.get(matcher)*math.Exp(-ensembleLearningRate*loss)))
+	}
+	l.weights = weights.normalized()
+	l.updates++
+	if err := l.save(); err != nil {
+		ensembleLog.Error("Error saving ensemble weights", "error", err)
+	}
+	ensembleLog.Debug("Updated ensemble weights", "heuristic", l.weights.Heuristic, "embedding", l.weights.Embedding, "llm", l.weights.LLM)
+}
+
+// Reset restores the default weights
+func (l *EnsembleLearner) Reset() error {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	l.weights = DefaultEnsembleWeights
+	l.updates = 0
+	return l.save()
+}
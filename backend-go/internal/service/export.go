@@ -4,48 +4,211 @@ import (
 	"backend-go/internal/models"
 	"fmt"
 	"strings"
+	"sync"
 )
 
-type ExportService struct{}
+type ExportService struct {
+	mu      sync.Mutex
+	lineage map[string]*ExportLineage // Last generated export per lineage target
+}
 
 func NewExportService() *ExportService {
-	return &ExportService{}
+	return &ExportService{lineage: make(map[string]*ExportLineage)}
+}
+
+// joinColumns picks the column pairs to join on: the approved join keys when
+// the pair has an approved mapping, otherwise high-confidence graph matches
+// that weren't rejected
+func joinColumns(graph *models.SimilarityGraph, mapping *ApprovedMapping) [][2]string {
+	pairs := [][2]string{}
+	if keys := mapping.JoinKeys(); len(keys) > 0 {
+		for _, k := range keys {
+			pairs = append(pairs, [2]string{k.File1Column, k.File2Column})
+		}
+		return pairs
+	}
+	for _, sim := range graph.Similarities {
+		if sim.Confidence >= 70.0 && !mapping.IsRejected(sim.File1Column, sim.File2Column) { // Only high confidence
+			pairs = append(pairs, [2]string{sim.File1Column, sim.File2Column})
+		}
+	}
+	return pairs
 }
 
-func (s *ExportService) GenerateSQL(graph *models.SimilarityGraph) string {
+// GenerateSQL emits a join of the two files in the requested dialect, as a
+// SELECT or a view. mapping may be nil.
+func (s *ExportService) GenerateSQL(graph *models.SimilarityGraph, mapping *ApprovedMapping, opts SQLOptions) (string, error) {
+	if err := opts.Validate(); err != nil {
+		return "", err
+	}
+	d := sqlDialects[opts.Dialect]
+	pairs := joinColumns(graph, mapping)
+	if len(mapping.JoinKeys()) == 0 && len(opts.JoinKeys) > 0 {
+		pairs = pairs[:0]
+		for _, k := range opts.JoinKeys {
+			pairs = append(pairs, [2]string{k.File1Column, k.File2Column})
+		}
+	}
+	transforms := joinTransforms(graph, mapping, pairs)
+	types1 := columnTypes(opts.File1)
+	types2 := columnTypes(opts.File2)
+
 	var sb strings.Builder
 
 	sb.WriteString("-- Generated by Project Euler\n")
-	sb.WriteString("-- SQL Query to join File 1 and File 2 based on high-confidence mappings\n\n")
+	sb.WriteString(fmt.Sprintf("-- %s SQL to join File 1 and File 2 based on high-confidence mappings\n", opts.Dialect))
+	if opts.Relationship != "" {
+		sb.WriteString(fmt.Sprintf("-- Declared relationship: %s\n", opts.Relationship))
+		if opts.Relationship == RelationshipManyToMany {
+			sb.WriteString("-- Warning: declared many-to-many, rows of both files repeat; aggregate or deduplicate one side first\n")
+		}
+	}
+	for _, line := range relationshipWarnings(graph, pairs) {
+		sb.WriteString("-- " + line + "\n")
+	}
+	sb.WriteString("\n")
+
+	if opts.Mode == SQLModeView {
+		sb.WriteString(fmt.Sprintf("%s %s AS\n", d.createView, d.quoteTable(opts.ViewName)))
+	}
 	sb.WriteString("SELECT\n")
 
-	// Select fields (mocking table names as table1 and table2)
-	sb.WriteString("    t1.*,\n")
-	sb.WriteString("    t2.*\n")
-	sb.WriteString("FROM table1 t1\n")
-	sb.WriteString("JOIN table2 t2 ON\n")
+	// Explicit select list when the files are loaded, so views don't end up
+	// with duplicate column names
+	if opts.File1 != nil && opts.File2 != nil {
+		taken := make(map[string]bool)
+		selects := []string{}
+		for _, h := range opts.File1.Headers {
+			selects = append(selects, "t1."+d.quoteIdent(h))
+			taken[h] = true
+		}
+		for _, h := range opts.File2.Headers {
+			if taken[h] {
+				selects = append(selects, fmt.Sprintf("t2.%s AS %s", d.quoteIdent(h), d.quoteIdent("file2_"+h)))
+			} else {
+				selects = append(selects, "t2."+d.quoteIdent(h))
+			}
+		}
+		sb.WriteString("    " + strings.Join(selects, ",\n    ") + "\n")
+	} else {
+		sb.WriteString("    t1.*,\n")
+		sb.WriteString("    t2.*\n")
+	}
 
-	first := true
-	for _, sim := range graph.Similarities {
-		if sim.Confidence >= 70.0 { // Only high confidence
-			if !first {
+	sb.WriteString(fmt.Sprintf("FROM %s t1\n", d.quoteTable(opts.Table1)))
+	sb.WriteString(fmt.Sprintf("%s %s t2", sqlJoinKeyword(opts.Relationship), d.quoteTable(opts.Table2)))
+
+	// USING needs equal names and no casts or transforms on either side
+	useUsing := d.supportsUsing && len(pairs) > 0
+	for _, pair := range pairs {
+		if pair[0] != pair[1] || types1[pair[0]] != types2[pair[1]] || transforms[pair] != nil {
+			useUsing = false
+			break
+		}
+	}
+
+	switch {
+	case len(pairs) == 0:
+		sb.WriteString(" ON\n    -- No high confidence relationships found\n    1 = 1\n")
+	case useUsing:
+		cols := make([]string, len(pairs))
+		for i, pair := range pairs {
+			cols[i] = d.quoteIdent(pair[0])
+		}
+		sb.WriteString(fmt.Sprintf(" USING (%s)\n", strings.Join(cols, ", ")))
+	default:
+		sb.WriteString(" ON\n")
+		for i, pair := range pairs {
+			left := "t1." + d.quoteIdent(pair[0])
+			right := "t2." + d.quoteIdent(pair[1])
+			t1, ok1 := types1[pair[0]]
+			t2, ok2 := types2[pair[1]]
+			if t := transforms[pair]; t != nil {
+				// Bring both formats to a common one
+				left = sqlTransform(opts.Dialect, t, 1, left)
+				right = sqlTransform(opts.Dialect, t, 2, right)
+			} else if ok1 && ok2 && t1 != t2 {
+				// Compare mismatched types as text
+				left = fmt.Sprintf("CAST(%s AS %s)", left, d.textType)
+				right = fmt.Sprintf("CAST(%s AS %s)", right, d.textType)
+			}
+			if i > 0 {
 				sb.WriteString("    AND ")
 			} else {
 				sb.WriteString("    ")
 			}
-			sb.WriteString(fmt.Sprintf("t1.%s = t2.%s\n", sim.File1Column, sim.File2Column))
-			first = false
+			sb.WriteString(fmt.Sprintf("%s = %s\n", left, right))
 		}
 	}
 
-	if first {
-		sb.WriteString("    1 = 1 -- No high confidence relationships found\n")
+	// Explicit select lists prefix clashing file 2 names; t1.*, t2.* keeps them
+	layout := lineageLayout{}
+	if opts.File1 != nil && opts.File2 != nil {
+		layout.rightPrefix = "file2_"
 	}
+	output := "query"
+	if opts.Mode == SQLModeView {
+		output = opts.ViewName
+	}
+	s.recordLineage(&ExportLineage{
+		Target:  LineageTargetSQL,
+		Variant: opts.Dialect,
+		Output:  output,
+		Columns: buildLineage(exportHeaders(graph, opts.File1, 1), exportHeaders(graph, opts.File2, 2), pairs, transforms, mapping, layout),
+	})
 
-	return sb.String()
+	return strings.TrimSuffix(sb.String(), "\n") + ";\n", nil
+}
+
+// Python engines supported by GeneratePython
+const (
+	PythonEnginePandas  = "pandas"
+	PythonEnginePySpark = "pyspark"
+	PythonEnginePolars  = "polars"
+)
+
+// PythonEngines lists the supported engine names
+func PythonEngines() []string {
+	return []string{PythonEnginePandas, PythonEnginePySpark, PythonEnginePolars}
+}
+
+// GeneratePython emits a join script of the two files for the given engine
+// ("" = pandas). mapping may be nil.
+func (s *ExportService) GeneratePython(graph *models.SimilarityGraph, mapping *ApprovedMapping, engine string) (string, error) {
+	pairs := joinColumns(graph, mapping)
+	transforms := joinTransforms(graph, mapping, pairs)
+
+	var script string
+	switch engine {
+	case "", PythonEnginePandas:
+		engine = PythonEnginePandas
+		script = generatePandas(pairs, transforms, pairValidate(graph, pairs, engine))
+	case PythonEnginePySpark:
+		script = generatePySpark(pairs, transforms)
+	case PythonEnginePolars:
+		script = generatePolars(pairs, transforms, pairValidate(graph, pairs, engine))
+	default:
+		return "", fmt.Errorf("unknown engine %q (use %s)", engine, strings.Join(PythonEngines(), ", "))
+	}
+
+	s.recordLineage(&ExportLineage{
+		Target:  LineageTargetPython,
+		Variant: engine,
+		Output:  "merged_df",
+		Columns: buildLineage(exportHeaders(graph, nil, 1), exportHeaders(graph, nil, 2), pairs, transforms, mapping, lineageLayouts[engine]),
+	})
+	return script, nil
 }
 
-func (s *ExportService) GeneratePython(graph *models.SimilarityGraph) string {
+// transformKey names the helper column holding the normalized value of join
+// pair i
+func transformKey(i int) string {
+	return fmt.Sprintf("_join_key_%d", i)
+}
+
+// generatePandas emits a pandas merge; validate is the merge's cardinality
+// check ("" = none)
+func generatePandas(pairs [][2]string, transforms map[[2]string]*models.ColumnTransform, validate string) string {
 	var sb strings.Builder
 
 	sb.WriteString("# Generated by Project Euler\n")
@@ -55,6 +218,35 @@ func (s *ExportService) GeneratePython(graph *models.SimilarityGraph) string {
 	sb.WriteString("df1 = pd.read_csv('file1.csv')\n")
 	sb.WriteString("df2 = pd.read_csv('file2.csv')\n\n")
 
+	if len(pairs) == 0 {
+		sb.WriteString("# Merge DataFrames\n")
+		sb.WriteString("# No high confidence relationships found\n")
+		sb.WriteString("merged_df = pd.merge(df1, df2, how='cross')\n\n")
+		sb.WriteString("print(merged_df.head())\n")
+		return sb.String()
+	}
+
+	// Keys in different formats are joined on normalized helper columns
+	left := make([]string, len(pairs))
+	right := make([]string, len(pairs))
+	helpers := []string{}
+	for i, pair := range pairs {
+		left[i], right[i] = pyString(pair[0]), pyString(pair[1])
+		if t := transforms[pair]; t != nil {
+			key := pyString(transformKey(i))
+			if len(helpers) == 0 {
+				sb.WriteString("# Normalize key formats so they compare equal\n")
+			}
+			sb.WriteString(fmt.Sprintf("df1[%s] = %s\n", key, pythonTransform(PythonEnginePandas, t, 1, "df1["+left[i]+"]")))
+			sb.WriteString(fmt.Sprintf("df2[%s] = %s\n", key, pythonTransform(PythonEnginePandas, t, 2, "df2["+right[i]+"]")))
+			left[i], right[i] = key, key
+			helpers = append(helpers, key)
+		}
+	}
+	if len(helpers) > 0 {
+		sb.WriteString("\n")
+	}
+
 	sb.WriteString("# Merge DataFrames\n")
 	sb.WriteString("merged_df = pd.merge(\n")
 	sb.WriteString("    df1,\n")
@@ -62,24 +254,139 @@ func (s *ExportService) GeneratePython(graph *models.SimilarityGraph) string {
 	sb.WriteString("    left_on=[\n")
 
 	// Left keys
-	for _, sim := range graph.Similarities {
-		if sim.Confidence >= 70.0 {
-			sb.WriteString(fmt.Sprintf("        '%s',\n", sim.File1Column))
-		}
+	for _, key := range left {
+		sb.WriteString(fmt.Sprintf("        %s,\n", key))
 	}
 	sb.WriteString("    ],\n")
 	sb.WriteString("    right_on=[\n")
 
 	// Right keys
-	for _, sim := range graph.Similarities {
-		if sim.Confidence >= 70.0 {
-			sb.WriteString(fmt.Sprintf("        '%s',\n", sim.File2Column))
-		}
+	for _, key := range right {
+		sb.WriteString(fmt.Sprintf("        %s,\n", key))
 	}
 	sb.WriteString("    ],\n")
-	sb.WriteString("    how='inner'\n")
-	sb.WriteString(")\n\n")
+	if validate != "" {
+		sb.WriteString("    how='inner',\n")
+		sb.WriteString(fmt.Sprintf("    validate=%s,  # Inferred key cardinality\n", pyString(validate)))
+	} else {
+		sb.WriteString("    how='inner'\n")
+	}
+	if len(helpers) > 0 {
+		sb.WriteString(fmt.Sprintf(").drop(columns=[%s])\n\n", strings.Join(helpers, ", ")))
+	} else {
+		sb.WriteString(")\n\n")
+	}
 	sb.WriteString("print(merged_df.head())\n")
 
 	return sb.String()
 }
+
+func generatePySpark(pairs [][2]string, transforms map[[2]string]*models.ColumnTransform) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Generated by Project Euler\n")
+	sb.WriteString("from pyspark.sql import SparkSession\n")
+	if len(transforms) > 0 {
+		sb.WriteString("from pyspark.sql import functions as F\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString("spark = SparkSession.builder.appName('project_euler_join').getOrCreate()\n\n")
+
+	sb.WriteString("# Load your data\n")
+	sb.WriteString("df1 = spark.read.csv('file1.csv', header=True, inferSchema=True)\n")
+	sb.WriteString("df2 = spark.read.csv('file2.csv', header=True, inferSchema=True)\n\n")
+
+	sb.WriteString("# Join DataFrames\n")
+	if len(pairs) == 0 {
+		sb.WriteString("# No high confidence relationships found\n")
+		sb.WriteString("merged_df = df1.crossJoin(df2)\n\n")
+		sb.WriteString("merged_df.show(5)\n")
+		return sb.String()
+	}
+	sb.WriteString("condition = [\n")
+	for _, pair := range pairs {
+		left := fmt.Sprintf("df1[%s]", pyString(pair[0]))
+		right := fmt.Sprintf("df2[%s]", pyString(pair[1]))
+		if t := transforms[pair]; t != nil {
+			// Compare normalized formats
+			left = pythonTransform(PythonEnginePySpark, t, 1, left)
+			right = pythonTransform(PythonEnginePySpark, t, 2, right)
+		}
+		sb.WriteString(fmt.Sprintf("    %s == %s,\n", left, right))
+	}
+	sb.WriteString("]\n")
+	sb.WriteString("merged_df = df1.join(df2, on=condition, how='inner')\n\n")
+	sb.WriteString("merged_df.show(5)\n")
+
+	return sb.String()
+}
+
+// generatePolars emits a lazy polars join; validate is the join's
+// cardinality check ("" = none)
+func generatePolars(pairs [][2]string, transforms map[[2]string]*models.ColumnTransform, validate string) string {
+	var sb strings.Builder
+
+	sb.WriteString("# Generated by Project Euler\n")
+	sb.WriteString("import polars as pl\n\n")
+
+	sb.WriteString("# Scan your data lazily so large files aren't loaded up front\n")
+	sb.WriteString("lf1 = pl.scan_csv('file1.csv')\n")
+	sb.WriteString("lf2 = pl.scan_csv('file2.csv')\n\n")
+
+	if len(pairs) == 0 {
+		sb.WriteString("# Join DataFrames\n")
+		sb.WriteString("# No high confidence relationships found\n")
+		sb.WriteString("merged_df = lf1.join(lf2, how='cross').collect()\n\n")
+		sb.WriteString("print(merged_df.head())\n")
+		return sb.String()
+	}
+
+	// Keys in different formats are joined on normalized helper columns
+	left := make([]string, len(pairs))
+	right := make([]string, len(pairs))
+	helpers := []string{}
+	for i, pair := range pairs {
+		left[i] = pyString(pair[0])
+		right[i] = pyString(pair[1])
+		if t := transforms[pair]; t != nil {
+			key := pyString(transformKey(i))
+			if len(helpers) == 0 {
+				sb.WriteString("# Normalize key formats so they compare equal\n")
+			}
+			sb.WriteString(fmt.Sprintf("lf1 = lf1.with_columns(%s.alias(%s))\n", pythonTransform(PythonEnginePolars, t, 1, "pl.col("+left[i]+")"), key))
+			sb.WriteString(fmt.Sprintf("lf2 = lf2.with_columns(%s.alias(%s))\n", pythonTransform(PythonEnginePolars, t, 2, "pl.col("+right[i]+")"), key))
+			left[i], right[i] = key, key
+			helpers = append(helpers, key)
+		}
+	}
+	if len(helpers) > 0 {
+		sb.WriteString("\n")
+	}
+
+	sb.WriteString("# Join DataFrames\n")
+	sb.WriteString("merged_df = lf1.join(\n")
+	sb.WriteString("    lf2,\n")
+	sb.WriteString(fmt.Sprintf("    left_on=[%s],\n", strings.Join(left, ", ")))
+	sb.WriteString(fmt.Sprintf("    right_on=[%s],\n", strings.Join(right, ", ")))
+	sb.WriteString("    how='inner',\n")
+	sb.WriteString("    suffix='_file2',\n")
+	if validate != "" {
+		sb.WriteString(fmt.Sprintf("    validate=%s,  # Inferred key cardinality\n", pyString(validate)))
+	}
+	if len(helpers) > 0 {
+		sb.WriteString(fmt.Sprintf(").drop(%s).collect()\n\n", strings.Join(helpers, ", ")))
+	} else {
+		sb.WriteString(").collect()\n\n")
+	}
+	sb.WriteString("print(merged_df.head())\n")
+
+	return sb.String()
+}
+
+// pyString renders a single-quoted Python string literal
+func pyString(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\\\")
+	s = strings.ReplaceAll(s, "'", "\\'")
+	s = strings.ReplaceAll(s, "\n", "\\n")
+	return "'" + s + "'"
+}
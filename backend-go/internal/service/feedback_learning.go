@@ -1,18 +1,29 @@
 package service
 
 import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/state"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
-	"log"
+	"fmt"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 )
 
-const feedbackFile = "./data/matching_feedback.json"
+var feedbackLog = logging.Component("feedback")
+
+const feedbackFile = "matching_feedback.json"
 
 // FeedbackEntry represents a single feedback submission
 type FeedbackEntry struct {
+	ID             string    `json:"id"`
 	File1Column    string    `json:"file1_column"`
 	File2Column    string    `json:"file2_column"`
 	IsCorrect      bool      `json:"is_correct"`
@@ -23,6 +34,8 @@ type FeedbackEntry struct {
 	DataSimilarity float64   `json:"data_similarity"`
 	PatternScore   float64   `json:"pattern_score"`
 	Confidence     float64   `json:"confidence"`
+	Scope          string    `json:"scope,omitempty"`  // Dataset pair fingerprint; empty = legacy/global
+	Global         bool      `json:"global,omitempty"` // Apply to every dataset pair
 }
 
 // Correction represents a learned correction
@@ -30,6 +43,7 @@ type Correction struct {
 	Suggested string `json:"suggested"`
 	Correct   string `json:"correct"`
 	Count     int    `json:"count"`
+	Scope     string `json:"scope,omitempty"`
 }
 
 // FeedbackData holds all feedback data
@@ -40,11 +54,15 @@ type FeedbackData struct {
 
 // FeedbackLearningSystem manages feedback-based learning
 type FeedbackLearningSystem struct {
-	data   *FeedbackData
-	mutex  sync.RWMutex
-	dirty  bool
+	data  *FeedbackData
+	mutex sync.RWMutex
+	dirty bool
 }
 
+// minScopesForGeneralization is how many distinct dataset pairs must agree
+// on a verdict before it is applied to unrelated dataset pairs
+const minScopesForGeneralization = 2
+
 var (
 	feedbackSystem *FeedbackLearningSystem
 	feedbackOnce   sync.Once
@@ -67,20 +85,20 @@ func GetFeedbackSystem() *FeedbackLearningSystem {
 // load loads feedback from file
 func (f *FeedbackLearningSystem) load() {
 	// Ensure directory exists
-	dir := filepath.Dir(feedbackFile)
+	dir := filepath.Dir(config.DataPath(feedbackFile))
 	os.MkdirAll(dir, 0755)
 
-	data, err := os.ReadFile(feedbackFile)
+	data, err := os.ReadFile(config.DataPath(feedbackFile))
 	if err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("[Feedback] Error loading feedback: %v", err)
+			feedbackLog.Error("Error loading feedback", "error", err)
 		}
 		return
 	}
 
 	var loaded FeedbackData
 	if err := json.Unmarshal(data, &loaded); err != nil {
-		log.Printf("[Feedback] Error parsing feedback: %v", err)
+		feedbackLog.Error("Error parsing feedback", "error", err)
 		return
 	}
 
@@ -89,9 +107,24 @@ func (f *FeedbackLearningSystem) load() {
 	if f.data.Corrections == nil {
 		f.data.Corrections = make(map[string]Correction)
 	}
+	// Entries recorded before IDs existed need one to be editable
+	for i := range f.data.Matches {
+		if f.data.Matches[i].ID == "" {
+			f.data.Matches[i].ID = newFeedbackID()
+			f.dirty = true
+		}
+	}
+	dirty := f.dirty
+	f.dirty = false
 	f.mutex.Unlock()
 
-	log.Printf("[Feedback] Loaded %d feedback entries", len(f.data.Matches))
+	if dirty {
+		if err := f.save(); err != nil {
+			feedbackLog.Error("Error saving feedback IDs", "error", err)
+		}
+	}
+
+	feedbackLog.Info("Loaded feedback entries", "count", len(f.data.Matches))
 }
 
 // save persists feedback to file
@@ -104,34 +137,23 @@ func (f *FeedbackLearningSystem) save() error {
 		return err
 	}
 
-	dir := filepath.Dir(feedbackFile)
+	dir := filepath.Dir(config.DataPath(feedbackFile))
 	os.MkdirAll(dir, 0755)
 
-	return os.WriteFile(feedbackFile, data, 0644)
+	return os.WriteFile(config.DataPath(feedbackFile), data, 0644)
 }
 
 // AddFeedback records user feedback on a column match
 func (f *FeedbackLearningSystem) AddFeedback(entry FeedbackEntry) (*FeedbackEntry, error) {
+	entry.ID = newFeedbackID()
 	entry.Timestamp = time.Now()
 
 	f.mutex.Lock()
 	f.data.Matches = append(f.data.Matches, entry)
 
 	// Store corrections for learning
-	if !entry.IsCorrect && entry.CorrectMatch != "" {
-		key := entry.File1Column + "|" + entry.File2Column
-		existing, ok := f.data.Corrections[key]
-		count := 1
-		if ok {
-			count = existing.Count + 1
-		}
-		f.data.Corrections[key] = Correction{
-			Suggested: entry.File2Column,
-			Correct:   entry.CorrectMatch,
-			Count:     count,
-		}
-	}
-	
+	f.recordCorrection(entry)
+
 	// Get recent feedback for batch learning
 	recentFeedback := f.data.Matches
 	if len(recentFeedback) > 10 {
@@ -141,18 +163,76 @@ func (f *FeedbackLearningSystem) AddFeedback(entry FeedbackEntry) (*FeedbackEntr
 
 	// Save to file
 	if err := f.save(); err != nil {
-		log.Printf("[Feedback] Error saving feedback: %v", err)
+		feedbackLog.Error("Error saving feedback", "error", err)
 	}
 
 	// Trigger ML learning systems asynchronously
 	go f.triggerMLLearning(entry, recentFeedback)
 
-	log.Printf("[Feedback] Recorded: %s ↔ %s (correct: %v)", 
-		entry.File1Column, entry.File2Column, entry.IsCorrect)
+	feedbackLog.Info("Recorded feedback",
+		"file1_column", entry.File1Column, "file2_column", entry.File2Column, "correct", entry.IsCorrect)
 
 	return &entry, nil
 }
 
+// recordCorrection stores a correction for an incorrect match (must hold lock)
+func (f *FeedbackLearningSystem) recordCorrection(entry FeedbackEntry) {
+	if entry.IsCorrect || entry.CorrectMatch == "" {
+		return
+	}
+
+	key := entryCorrectionKey(entry)
+	existing, ok := f.data.Corrections[key]
+	count := 1
+	if ok {
+		count = existing.Count + 1
+	}
+	f.data.Corrections[key] = Correction{
+		Suggested: entry.File2Column,
+		Correct:   entry.CorrectMatch,
+		Count:     count,
+		Scope:     f.correctionScope(entry),
+	}
+}
+
+// forgetCorrection takes back what recordCorrection stored for an entry that
+// has been edited or removed (must hold lock)
+func (f *FeedbackLearningSystem) forgetCorrection(entry FeedbackEntry) {
+	if entry.IsCorrect || entry.CorrectMatch == "" {
+		return
+	}
+
+	key := entryCorrectionKey(entry)
+	existing, ok := f.data.Corrections[key]
+	if !ok {
+		return
+	}
+	existing.Count--
+	if existing.Count <= 0 {
+		delete(f.data.Corrections, key)
+		return
+	}
+	if existing.Correct == entry.CorrectMatch {
+		// Fall back to the latest remaining correction for the pair, if any
+		for i := len(f.data.Matches) - 1; i >= 0; i-- {
+			m := f.data.Matches[i]
+			if m.ID != entry.ID && !m.IsCorrect && m.CorrectMatch != "" && entryCorrectionKey(m) == key {
+				existing.Correct = m.CorrectMatch
+				break
+			}
+		}
+	}
+	f.data.Corrections[key] = existing
+}
+
+// entryCorrectionKey is where an entry's correction is stored
+func entryCorrectionKey(entry FeedbackEntry) string {
+	if entry.Global {
+		return correctionKey("", entry.File1Column, entry.File2Column)
+	}
+	return correctionKey(entry.Scope, entry.File1Column, entry.File2Column)
+}
+
 // triggerMLLearning triggers all ML learning systems
 func (f *FeedbackLearningSystem) triggerMLLearning(feedback FeedbackEntry, recentBatch []FeedbackEntry) {
 	// 1. Update confidence calibration
@@ -178,19 +258,24 @@ func (f *FeedbackLearningSystem) triggerMLLearning(feedback FeedbackEntry, recen
 		adaptiveLearner.UpdateWeights(recentBatch)
 	}
 
-	log.Printf("[ML Learning] Triggered for: %s ↔ %s", feedback.File1Column, feedback.File2Column)
+	// 4. Update ensemble weights when the ensemble scored the pair
+	GetEnsembleLearner().Learn(feedback)
+
+	feedbackLog.Debug("Learning triggered", "file1_column", feedback.File1Column, "file2_column", feedback.File2Column)
 }
 
 
 // GetLearnedBoost returns a confidence adjustment based on historical feedback
+// for a column pair within a dataset pair scope (see DatasetPairScope).
+// Feedback recorded in other scopes only applies once it has generalized.
 // Returns a value between -0.3 and +0.3
-func (f *FeedbackLearningSystem) GetLearnedBoost(file1Col, file2Col string) float64 {
+func (f *FeedbackLearningSystem) GetLearnedBoost(scope, file1Col, file2Col string) float64 {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
-	// Check if this exact match has feedback
+	// Check if this exact match has feedback in scope (or globally)
 	for _, match := range f.data.Matches {
-		if match.File1Column == file1Col && match.File2Column == file2Col {
+		if match.File1Column == file1Col && match.File2Column == file2Col && appliesToScope(match, scope) {
 			if match.IsCorrect {
 				return 0.2 // Boost by 20%
 			}
@@ -199,14 +284,25 @@ func (f *FeedbackLearningSystem) GetLearnedBoost(file1Col, file2Col string) floa
 	}
 
 	// Check corrections
-	key := file1Col + "|" + file2Col
-	if _, ok := f.data.Corrections[key]; ok {
+	if _, ok := f.data.Corrections[correctionKey(scope, file1Col, file2Col)]; ok {
 		return -0.25 // Penalize known incorrect matches
 	}
+	if _, ok := f.data.Corrections[correctionKey("", file1Col, file2Col)]; ok {
+		return -0.25
+	}
+
+	// Feedback from other dataset pairs counts at half strength once
+	// enough of them agree
+	if verdict, ok := f.generalizedVerdict(file1Col, file2Col); ok {
+		if verdict {
+			return 0.1
+		}
+		return -0.15
+	}
 
 	// Check if file2_col was previously suggested incorrectly
 	for _, correction := range f.data.Corrections {
-		if correction.Suggested == file2Col {
+		if correction.Suggested == file2Col && (correction.Scope == "" || correction.Scope == scope) {
 			return -0.15
 		}
 	}
@@ -214,22 +310,82 @@ func (f *FeedbackLearningSystem) GetLearnedBoost(file1Col, file2Col string) floa
 	return 0.0
 }
 
-// GetSuggestedMatch returns the learned correct match for a column
-func (f *FeedbackLearningSystem) GetSuggestedMatch(file1Col string) string {
+// generalizedVerdict reports the verdict for a pair when feedback from at least
+// minScopesForGeneralization distinct scopes agrees (must hold lock)
+func (f *FeedbackLearningSystem) generalizedVerdict(file1Col, file2Col string) (bool, bool) {
+	verdicts := make(map[string]bool)
+	for _, match := range f.data.Matches {
+		if match.Scope == "" || match.File1Column != file1Col || match.File2Column != file2Col {
+			continue
+		}
+		if prev, seen := verdicts[match.Scope]; seen && prev != match.IsCorrect {
+			return false, false // Scope disagrees with itself
+		}
+		verdicts[match.Scope] = match.IsCorrect
+	}
+
+	if len(verdicts) < minScopesForGeneralization {
+		return false, false
+	}
+
+	var first, initialized bool
+	for _, v := range verdicts {
+		if !initialized {
+			first, initialized = v, true
+		} else if v != first {
+			return false, false
+		}
+	}
+	return first, true
+}
+
+// appliesToScope reports whether a feedback entry is valid in the given scope
+func appliesToScope(entry FeedbackEntry, scope string) bool {
+	return entry.Global || entry.Scope == "" || entry.Scope == scope
+}
+
+// correctionScope returns the scope a correction should be stored under
+func (f *FeedbackLearningSystem) correctionScope(entry FeedbackEntry) string {
+	if entry.Global {
+		return ""
+	}
+	return entry.Scope
+}
+
+// correctionKey builds the corrections map key; unscoped keys keep the legacy "col1|col2" form
+func correctionKey(scope, file1Col, file2Col string) string {
+	if scope == "" {
+		return file1Col + "|" + file2Col
+	}
+	return scope + "|" + file1Col + "|" + file2Col
+}
+
+// DatasetPairScope fingerprints a pair of datasets by their schemas
+func DatasetPairScope(df1, df2 *state.DataFrame) string {
+	if df1 == nil || df2 == nil {
+		return ""
+	}
+	return df1.SchemaHash() + ":" + df2.SchemaHash()
+}
+
+// GetSuggestedMatch returns the learned correct match for a column within a scope
+func (f *FeedbackLearningSystem) GetSuggestedMatch(scope, file1Col string) string {
 	f.mutex.RLock()
 	defer f.mutex.RUnlock()
 
 	// Check for confirmed correct matches
 	for _, match := range f.data.Matches {
-		if match.File1Column == file1Col && match.IsCorrect {
+		if match.File1Column == file1Col && match.IsCorrect && appliesToScope(match, scope) {
 			return match.File2Column
 		}
 	}
 
 	// Check corrections
-	for key, correction := range f.data.Corrections {
-		if len(key) > len(file1Col)+1 && key[:len(file1Col)+1] == file1Col+"|" {
-			return correction.Correct
+	for _, prefix := range []string{correctionKey(scope, file1Col, ""), correctionKey("", file1Col, "")} {
+		for key, correction := range f.data.Corrections {
+			if strings.HasPrefix(key, prefix) && (correction.Scope == "" || correction.Scope == scope) {
+				return correction.Correct
+			}
 		}
 	}
 
@@ -288,6 +444,19 @@ func (f *FeedbackLearningSystem) HasPositiveFeedback(file1Col, file2Col string)
 	return false
 }
 
+// HasFeedback checks if a column pair has any feedback applicable to the scope
+func (f *FeedbackLearningSystem) HasFeedback(scope, file1Col, file2Col string) bool {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	for _, match := range f.data.Matches {
+		if match.File1Column == file1Col && match.File2Column == file2Col && appliesToScope(match, scope) {
+			return true
+		}
+	}
+	return false
+}
+
 // ClearFeedback clears all feedback (for testing)
 func (f *FeedbackLearningSystem) ClearFeedback() {
 	f.mutex.Lock()
@@ -298,3 +467,270 @@ func (f *FeedbackLearningSystem) ClearFeedback() {
 	f.mutex.Unlock()
 	f.save()
 }
+
+// Snapshot returns a deep copy of all feedback data
+func (f *FeedbackLearningSystem) Snapshot() FeedbackData {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	snapshot := FeedbackData{
+		Matches:     make([]FeedbackEntry, len(f.data.Matches)),
+		Corrections: make(map[string]Correction, len(f.data.Corrections)),
+	}
+	copy(snapshot.Matches, f.data.Matches)
+	for k, v := range f.data.Corrections {
+		snapshot.Corrections[k] = v
+	}
+	return snapshot
+}
+
+// Merge combines imported feedback with local feedback using the given strategy.
+// Returns the number of entries added.
+func (f *FeedbackLearningSystem) Merge(incoming FeedbackData, strategy string) int {
+	f.mutex.Lock()
+
+	if strategy == MergeStrategyReplace {
+		f.data = &FeedbackData{
+			Matches:     append([]FeedbackEntry{}, incoming.Matches...),
+			Corrections: make(map[string]Correction),
+		}
+		ids := make(map[string]bool, len(f.data.Matches))
+		for i := range f.data.Matches {
+			assignFeedbackID(&f.data.Matches[i], ids)
+		}
+		for k, v := range incoming.Corrections {
+			f.data.Corrections[k] = v
+		}
+		added := len(incoming.Matches)
+		f.mutex.Unlock()
+		f.save()
+		return added
+	}
+
+	// Skip entries we already have (same pair, verdict and timestamp)
+	seen := make(map[string]bool, len(f.data.Matches))
+	ids := make(map[string]bool, len(f.data.Matches))
+	for _, m := range f.data.Matches {
+		seen[feedbackEntryKey(m)] = true
+		ids[m.ID] = true
+	}
+
+	added := 0
+	for _, m := range incoming.Matches {
+		key := feedbackEntryKey(m)
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		assignFeedbackID(&m, ids)
+		f.data.Matches = append(f.data.Matches, m)
+		added++
+	}
+
+	for key, remote := range incoming.Corrections {
+		local, exists := f.data.Corrections[key]
+		if !exists {
+			f.data.Corrections[key] = remote
+			continue
+		}
+		if strategy == MergeStrategyKeepLocal {
+			continue
+		}
+		// Same correction on both sides: pool the evidence.
+		// Conflicting corrections: the better supported one wins.
+		if local.Correct == remote.Correct {
+			local.Count += remote.Count
+			f.data.Corrections[key] = local
+		} else if remote.Count > local.Count {
+			f.data.Corrections[key] = remote
+		}
+	}
+
+	sort.SliceStable(f.data.Matches, func(i, j int) bool {
+		return f.data.Matches[i].Timestamp.Before(f.data.Matches[j].Timestamp)
+	})
+	f.mutex.Unlock()
+
+	if err := f.save(); err != nil {
+		feedbackLog.Error("Error saving merged feedback", "error", err)
+	}
+	return added
+}
+
+// assignFeedbackID gives an imported entry a fresh ID when it has none or its
+// ID is already taken, so every entry stays addressable by ID
+func assignFeedbackID(e *FeedbackEntry, ids map[string]bool) {
+	if e.ID == "" || ids[e.ID] {
+		e.ID = newFeedbackID()
+	}
+	ids[e.ID] = true
+}
+
+func feedbackEntryKey(e FeedbackEntry) string {
+	return e.Scope + "|" + e.File1Column + "|" + e.File2Column + "|" + strconv.FormatBool(e.IsCorrect) + "|" + e.Timestamp.UTC().Format(time.RFC3339Nano)
+}
+
+// FeedbackFilter narrows down feedback listings
+type FeedbackFilter struct {
+	Column      string // Matches either side
+	File1Column string
+	File2Column string
+	Scope       string
+	IsCorrect   *bool
+}
+
+// FeedbackUpdate holds editable fields of a feedback entry (nil = unchanged)
+type FeedbackUpdate struct {
+	IsCorrect    *bool   `json:"is_correct,omitempty"`
+	CorrectMatch *string `json:"correct_match,omitempty"`
+	UserNote     *string `json:"user_note,omitempty"`
+	Global       *bool   `json:"global,omitempty"`
+}
+
+// ListFeedback returns a page of feedback entries (newest first) and the total match count
+func (f *FeedbackLearningSystem) ListFeedback(filter FeedbackFilter, offset, limit int) ([]FeedbackEntry, int) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	matched := []FeedbackEntry{}
+	for i := len(f.data.Matches) - 1; i >= 0; i-- {
+		m := f.data.Matches[i]
+		if filter.Column != "" && m.File1Column != filter.Column && m.File2Column != filter.Column {
+			continue
+		}
+		if filter.File1Column != "" && m.File1Column != filter.File1Column {
+			continue
+		}
+		if filter.File2Column != "" && m.File2Column != filter.File2Column {
+			continue
+		}
+		if filter.Scope != "" && m.Scope != filter.Scope {
+			continue
+		}
+		if filter.IsCorrect != nil && m.IsCorrect != *filter.IsCorrect {
+			continue
+		}
+		matched = append(matched, m)
+	}
+
+	total := len(matched)
+	if offset >= total {
+		return []FeedbackEntry{}, total
+	}
+	end := offset + limit
+	if limit <= 0 || end > total {
+		end = total
+	}
+	return matched[offset:end], total
+}
+
+// GetFeedback returns a single feedback entry by ID
+func (f *FeedbackLearningSystem) GetFeedback(id string) (*FeedbackEntry, bool) {
+	f.mutex.RLock()
+	defer f.mutex.RUnlock()
+
+	for _, m := range f.data.Matches {
+		if m.ID == id {
+			entry := m
+			return &entry, true
+		}
+	}
+	return nil, false
+}
+
+// UpdateFeedback edits a feedback entry and retrains the learning systems
+func (f *FeedbackLearningSystem) UpdateFeedback(id string, update FeedbackUpdate) (*FeedbackEntry, error) {
+	f.mutex.Lock()
+	idx := f.indexOf(id)
+	if idx < 0 {
+		f.mutex.Unlock()
+		return nil, fmt.Errorf("feedback %s not found", id)
+	}
+
+	entry := &f.data.Matches[idx]
+	old := *entry
+	if update.IsCorrect != nil {
+		entry.IsCorrect = *update.IsCorrect
+	}
+	if update.CorrectMatch != nil {
+		entry.CorrectMatch = *update.CorrectMatch
+	}
+	if update.UserNote != nil {
+		entry.UserNote = *update.UserNote
+	}
+	if update.Global != nil {
+		entry.Global = *update.Global
+	}
+	updated := *entry
+	f.mutex.Unlock()
+
+	f.relearn(old, &updated)
+
+	feedbackLog.Info("Updated feedback", "id", id,
+		"file1_column", updated.File1Column, "file2_column", updated.File2Column, "correct", updated.IsCorrect)
+	return &updated, nil
+}
+
+// DeleteFeedback removes a feedback entry and retrains the learning systems
+func (f *FeedbackLearningSystem) DeleteFeedback(id string) error {
+	f.mutex.Lock()
+	idx := f.indexOf(id)
+	if idx < 0 {
+		f.mutex.Unlock()
+		return fmt.Errorf("feedback %s not found", id)
+	}
+	old := f.data.Matches[idx]
+	f.data.Matches = append(f.data.Matches[:idx], f.data.Matches[idx+1:]...)
+	f.mutex.Unlock()
+
+	f.relearn(old, nil)
+
+	feedbackLog.Info("Deleted feedback", "id", id)
+	return nil
+}
+
+// indexOf finds a feedback entry by ID (must hold lock)
+func (f *FeedbackLearningSystem) indexOf(id string) int {
+	for i, m := range f.data.Matches {
+		if m.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// relearn replaces what one feedback entry taught the learning systems with
+// its edited version (updated is nil once it is deleted), so edits and
+// deletions take effect immediately. Only that entry's contribution changes:
+// imported or hand-set learning and deleted rules are kept.
+func (f *FeedbackLearningSystem) relearn(old FeedbackEntry, updated *FeedbackEntry) {
+	f.mutex.Lock()
+	f.forgetCorrection(old)
+	if updated != nil {
+		f.recordCorrection(*updated)
+	}
+	entries := make([]FeedbackEntry, len(f.data.Matches))
+	copy(entries, f.data.Matches)
+	f.mutex.Unlock()
+
+	if err := f.save(); err != nil {
+		feedbackLog.Error("Error saving feedback", "error", err)
+	}
+
+	// A changed note, correction or scope does not change what the ML systems learned
+	if updated == nil || updated.IsCorrect != old.IsCorrect {
+		GetConfidenceCalibrator().Revise(old, updated)
+		GetPatternLearner().Revise(old, updated)
+		GetAdaptiveLearner().Rebuild(entries)
+	}
+
+	feedbackLog.Info("Learning updated from feedback", "id", old.ID, "deleted", updated == nil)
+}
+
+func newFeedbackID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "fb_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "fb_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMergeAssignsFeedbackIDs(t *testing.T) {
+	fs := GetFeedbackSystem()
+	at := time.Now().Add(-2 * time.Hour)
+	fs.Merge(FeedbackData{Matches: []FeedbackEntry{
+		{File1Column: "ids_a", File2Column: "ids_b", IsCorrect: true, Timestamp: at},
+		{ID: "fb_shared", File1Column: "ids_c", File2Column: "ids_d", IsCorrect: true, Timestamp: at},
+		{ID: "fb_shared", File1Column: "ids_e", File2Column: "ids_f", IsCorrect: false, Timestamp: at},
+	}}, MergeStrategyMerge)
+
+	seen := map[string]bool{}
+	for _, m := range fs.Snapshot().Matches {
+		if m.ID == "" {
+			t.Errorf("entry %s ↔ %s has no ID", m.File1Column, m.File2Column)
+		}
+		if seen[m.ID] {
+			t.Errorf("ID %s is used twice", m.ID)
+		}
+		seen[m.ID] = true
+	}
+}
+
+func TestRelearnKeepsLearnedState(t *testing.T) {
+	fs := GetFeedbackSystem()
+	entry := FeedbackEntry{
+		File1Column: "relearn_order_id", File2Column: "relearn_order_identifier",
+		IsCorrect: true, Confidence: 85,
+		Timestamp: time.Now().Add(-time.Hour),
+	}
+	fs.Merge(FeedbackData{Matches: []FeedbackEntry{entry}}, MergeStrategyMerge)
+	var id string
+	for _, m := range fs.Snapshot().Matches {
+		if m.File1Column == entry.File1Column {
+			id = m.ID
+		}
+	}
+
+	// What live feedback taught, then a manual rule deletion
+	patterns := GetPatternLearner()
+	patterns.LearnFromPositive(entry.File1Column, entry.File2Column)
+	if err := patterns.DeletePatternRule("*_id", "*_identifier"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Imported calibration and hand-set weights
+	buckets := initializeBuckets()
+	buckets[3].TotalCount, buckets[3].CorrectCount = 6, 2
+	calibrator := GetConfidenceCalibrator()
+	if err := calibrator.Merge(buckets, MergeStrategyMerge); err != nil {
+		t.Fatal(err)
+	}
+	imported := calibrator.GetBuckets()[3]
+	manual := AdaptiveWeights{Name: 0.4, Data: 0.4, Pattern: 0.1, LLM: 0.1}
+	if err := GetAdaptiveLearner().SetWeights(manual); err != nil {
+		t.Fatal(err)
+	}
+
+	wrong := false
+	if _, err := fs.UpdateFeedback(id, FeedbackUpdate{IsCorrect: &wrong}); err != nil {
+		t.Fatal(err)
+	}
+	if err := fs.DeleteFeedback(id); err != nil {
+		t.Fatal(err)
+	}
+
+	if got := GetAdaptiveLearner().GetWeights(); got != manual {
+		t.Errorf("weights after relearn = %+v, want the manual %+v", got, manual)
+	}
+	if got := calibrator.GetBuckets()[3]; got.TotalCount != imported.TotalCount || got.CorrectCount != imported.CorrectCount {
+		t.Errorf("imported bucket after relearn: %d/%d, want %d/%d", got.CorrectCount, got.TotalCount, imported.CorrectCount, imported.TotalCount)
+	}
+	for _, rule := range patterns.GetPatterns() {
+		if rule.Pattern1 == "*_id" && rule.Pattern2 == "*_identifier" {
+			t.Errorf("deleted rule came back: %+v", rule)
+		}
+	}
+}
@@ -1,15 +1,15 @@
 package service
 
 import (
+	"backend-go/internal/dateformat"
+	"backend-go/internal/textnorm"
 	"fmt"
 	"regexp"
 	"strings"
-	"time"
 )
 
 // FormatNormalizer handles normalization of different data formats
 type FormatNormalizer struct {
-	dateFormats   []string
 	phonePattern  *regexp.Regexp
 	numberPattern *regexp.Regexp
 }
@@ -17,16 +17,6 @@ type FormatNormalizer struct {
 // NewFormatNormalizer creates a new format normalizer
 func NewFormatNormalizer() *FormatNormalizer {
 	return &FormatNormalizer{
-		dateFormats: []string{
-			"2006-01-02",          // ISO: 2024-01-15
-			"01/02/2006",          // US: 01/15/2024
-			"02/01/2006",          // EU: 15/01/2024
-			"2006/01/02",          // Alt ISO
-			"02-Jan-2006",         // Text: 15-Jan-2024
-			"January 2, 2006",     // Full text
-			time.RFC3339,          // With time
-			"2006-01-02 15:04:05", // SQL datetime
-		},
 		phonePattern:  regexp.MustCompile(`[\s\-\(\)\+\.]`),
 		numberPattern: regexp.MustCompile(`[\$€£¥₹,\s]`),
 	}
@@ -53,21 +43,22 @@ func (fn *FormatNormalizer) NormalizeValue(value string) string {
 		return normalized
 	}
 
+	// Text values compare accent- and case-insensitively
+	value = textnorm.Fold(value)
+
 	// Try name normalization
 	if normalized := fn.normalizeName(value); normalized != "" {
 		return normalized
 	}
 
-	// Return lowercase trimmed as fallback
-	return strings.ToLower(strings.TrimSpace(value))
+	// Return folded trimmed as fallback
+	return strings.TrimSpace(value)
 }
 
 // normalizeDate tries to parse and normalize dates to ISO format
 func (fn *FormatNormalizer) normalizeDate(value string) string {
-	for _, format := range fn.dateFormats {
-		if t, err := time.Parse(format, value); err == nil {
-			return t.Format("2006-01-02")
-		}
+	if t, _, ok := dateformat.Parse(value); ok {
+		return t.Format("2006-01-02")
 	}
 	return ""
 }
@@ -0,0 +1,394 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"math"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Format transform kinds
+const (
+	TransformDate   = "date"   // Parse both sides with their date format
+	TransformPhone  = "phone"  // Keep digits only
+	TransformNumber = "number" // Strip currency symbols and separators
+	TransformText   = "text"   // Trim and uppercase
+)
+
+// formatTransformMinConfidence is the confidence a match needs before a
+// transform is suggested for it
+const formatTransformMinConfidence = 50.0
+
+var (
+	nonDigits  = regexp.MustCompile(`[^0-9]`)
+	nonNumeric = regexp.MustCompile(`[^0-9.\-]`)
+)
+
+// SuggestFormatTransform suggests the transform that makes two columns of
+// the loaded files compare equal (nil when none is needed or known)
+func (s *EnhancedSimilarityService) SuggestFormatTransform(df1, df2 *state.DataFrame, col1, col2 string) *models.ColumnTransform {
+	idx1, idx2 := columnIndex(df1, col1), columnIndex(df2, col2)
+	if idx1 < 0 || idx2 < 0 {
+		return nil
+	}
+	p1 := s.ProfileColumns(df1)[idx1]
+	p2 := s.ProfileColumns(df2)[idx2]
+	_, formatType := profileFormatTransformation(p1, p2, profileNormalizedMatch(p1, p2))
+	if t := suggestFormatTransform(df1, df2, p1, p2, formatType); t != nil || !p1.IsNumeric || !p2.IsNumeric {
+		return t
+	}
+
+	// Numeric pairs may differ by a unit; compare them on the approved keys
+	keys := [][2]string{}
+	for _, k := range GetMappingStore().Get(DatasetPairScope(df1, df2)).JoinKeys() {
+		if k.File1Column != col1 || k.File2Column != col2 {
+			keys = append(keys, [2]string{k.File1Column, k.File2Column})
+		}
+	}
+	t := scaleTransform(df1, df2, idx1, idx2, overlappingRows(df1, df2, keys))
+	if t != nil {
+		fillTransformExamples(t, p1.Name, p2.Name)
+	}
+	return t
+}
+
+// suggestFormatTransform builds the transform for a column pair; formatType
+// is the shared format from profileFormatTransformation ("" when none)
+func suggestFormatTransform(df1, df2 *state.DataFrame, p1, p2 *ColumnProfile, formatType string) *models.ColumnTransform {
+	var t *models.ColumnTransform
+	switch formatType {
+	case "date":
+		if p1.DateFormat == "" || p2.DateFormat == "" || p1.DateFormat == p2.DateFormat {
+			return nil
+		}
+		t = &models.ColumnTransform{Kind: TransformDate, File1Format: p1.DateFormat, File2Format: p2.DateFormat}
+	case "phone":
+		if !transformHelps(df1, df2, p1.Index, p2.Index, normalizeDigits) {
+			return nil
+		}
+		t = &models.ColumnTransform{Kind: TransformPhone}
+	case "number":
+		if !transformHelps(df1, df2, p1.Index, p2.Index, normalizeNumeric) {
+			return nil
+		}
+		t = &models.ColumnTransform{Kind: TransformNumber}
+	default:
+		if p1.IsNumeric || p2.IsNumeric || !transformHelps(df1, df2, p1.Index, p2.Index, normalizeText) {
+			return nil
+		}
+		t = &models.ColumnTransform{Kind: TransformText}
+	}
+
+	fillTransformExamples(t, p1.Name, p2.Name)
+	return t
+}
+
+// fillTransformExamples sets the Postgres and pandas expressions of a
+// transform over the bare column names
+func fillTransformExamples(t *models.ColumnTransform, col1, col2 string) {
+	pg := sqlDialects[SQLDialectPostgres]
+	t.File1SQL = sqlTransform(SQLDialectPostgres, t, 1, pg.quoteIdent(col1))
+	t.File2SQL = sqlTransform(SQLDialectPostgres, t, 2, pg.quoteIdent(col2))
+	t.File1Python = pythonTransform(PythonEnginePandas, t, 1, "df1["+pyString(col1)+"]")
+	t.File2Python = pythonTransform(PythonEnginePandas, t, 2, "df2["+pyString(col2)+"]")
+}
+
+func normalizeDigits(v string) string  { return nonDigits.ReplaceAllString(v, "") }
+func normalizeNumeric(v string) string { return nonNumeric.ReplaceAllString(v, "") }
+func normalizeText(v string) string    { return strings.ToUpper(strings.TrimSpace(v)) }
+
+// transformHelps reports whether normalizing the leading values of both
+// columns makes them overlap clearly better than the raw values do
+func transformHelps(df1, df2 *state.DataFrame, idx1, idx2 int, normalize func(string) string) bool {
+	raw1, norm1 := leadingValueSets(df1, idx1, normalize)
+	raw2, norm2 := leadingValueSets(df2, idx2, normalize)
+	normalized := jaccardSets(norm1, norm2)
+	return normalized >= 0.5 && normalized > jaccardSets(raw1, raw2)+0.1
+}
+
+// leadingValueSets returns the distinct raw and normalized non-empty values
+// of the leading rows of a column
+func leadingValueSets(df *state.DataFrame, colIdx int, normalize func(string) string) (map[string]bool, map[string]bool) {
+	raw := make(map[string]bool)
+	normalized := make(map[string]bool)
+	for i := 0; i < len(df.Rows) && i < profileNormalizedSample; i++ {
+		if colIdx >= len(df.Rows[i]) || df.Rows[i][colIdx] == "" {
+			continue
+		}
+		raw[df.Rows[i][colIdx]] = true
+		if n := normalize(df.Rows[i][colIdx]); n != "" {
+			normalized[n] = true
+		}
+	}
+	return raw, normalized
+}
+
+var strftimePattern = regexp.MustCompile(`^(%[YmdHMSbB]|[^%'"\\])+$`)
+
+// ValidateFormatTransform checks a transform sent by a client before it's
+// stored with a mapping and rendered into exports
+func ValidateFormatTransform(t *models.ColumnTransform) error {
+	switch t.Kind {
+	case TransformPhone, TransformNumber, TransformText:
+		return nil
+	case TransformScale:
+		if !(t.Factor > 0) || math.IsInf(t.Factor, 0) {
+			return fmt.Errorf("scale transform needs a positive factor")
+		}
+		return nil
+	case TransformDate:
+		for _, f := range []string{t.File1Format, t.File2Format} {
+			if !strftimePattern.MatchString(f) {
+				return fmt.Errorf("invalid date format %q", f)
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("unknown transform kind %q (use date, phone, number, text or scale)", t.Kind)
+}
+
+// goLayoutTokens maps Go layout elements to strftime, longest first
+var goLayoutTokens = []struct{ layout, strftime string }{
+	{"January", "%B"}, {"Jan", "%b"}, {"2006", "%Y"},
+	{"01", "%m"}, {"02", "%d"}, {"15", "%H"}, {"04", "%M"}, {"05", "%S"},
+	{"1", "%m"}, {"2", "%d"},
+}
+
+// strftimeFormat converts a Go date layout to strftime; layouts with
+// weekdays, zones or fractional seconds have no portable equivalent
+func strftimeFormat(layout string) (string, bool) {
+	var sb strings.Builder
+	for i := 0; i < len(layout); {
+		matched := false
+		for _, tok := range goLayoutTokens {
+			if strings.HasPrefix(layout[i:], tok.layout) {
+				sb.WriteString(tok.strftime)
+				i += len(tok.layout)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		c := rune(layout[i])
+		if unicode.IsDigit(c) || (unicode.IsLetter(c) && c != 'T') || strings.ContainsRune(`%'"\\`, c) {
+			return "", false
+		}
+		sb.WriteByte(layout[i])
+		i++
+	}
+	return sb.String(), true
+}
+
+// convertStrftime rewrites a strftime format with another family's
+// elements; literal letters go through quote
+func convertStrftime(format string, elements map[byte]string, quote func(string) string) string {
+	var sb strings.Builder
+	for i := 0; i < len(format); i++ {
+		c := format[i]
+		if c == '%' && i+1 < len(format) {
+			i++
+			sb.WriteString(elements[format[i]])
+			continue
+		}
+		if unicode.IsLetter(rune(c)) {
+			sb.WriteString(quote(string(c)))
+			continue
+		}
+		sb.WriteByte(c)
+	}
+	return sb.String()
+}
+
+// Date format elements per family
+var (
+	postgresDateElements  = map[byte]string{'Y': "YYYY", 'm': "MM", 'd': "DD", 'H': "HH24", 'M': "MI", 'S': "SS", 'b': "Mon", 'B': "Month"}
+	snowflakeDateElements = map[byte]string{'Y': "YYYY", 'm': "MM", 'd': "DD", 'H': "HH24", 'M': "MI", 'S': "SS", 'b': "MON", 'B': "MMMM"}
+	mysqlDateElements     = map[byte]string{'Y': "%Y", 'm': "%m", 'd': "%d", 'H': "%H", 'M': "%i", 'S': "%s", 'b': "%b", 'B': "%M"}
+	sparkDateElements     = map[byte]string{'Y': "yyyy", 'm': "M", 'd': "d", 'H': "H", 'M': "m", 'S': "s", 'b': "MMM", 'B': "MMMM"}
+)
+
+// tsqlDateStyles maps strftime formats to CONVERT styles
+var tsqlDateStyles = map[string]int{
+	"%m/%d/%Y": 101, "%Y.%m.%d": 102, "%d/%m/%Y": 103, "%d.%m.%Y": 104,
+	"%d-%m-%Y": 105, "%d %b %Y": 106, "%b %d, %Y": 107, "%m-%d-%Y": 110,
+	"%Y/%m/%d": 111, "%Y-%m-%d": 23, "%Y-%m-%d %H:%M:%S": 120, "%Y-%m-%dT%H:%M:%S": 126,
+}
+
+func doubleQuoted(s string) string { return `"` + s + `"` }
+func singleQuoted(s string) string { return "'" + s + "'" }
+func verbatim(s string) string     { return s }
+
+// sqlTransform renders a transform over col (an already quoted column
+// reference) of file 1 or 2 in a dialect
+func sqlTransform(dialect string, t *models.ColumnTransform, file int, col string) string {
+	d := sqlDialects[dialect]
+	text := fmt.Sprintf("CAST(%s AS %s)", col, d.textType)
+
+	switch t.Kind {
+	case TransformDate:
+		format := t.File1Format
+		if file == 2 {
+			format = t.File2Format
+		}
+		hasTime := strings.Contains(format, "%H")
+		switch dialect {
+		case SQLDialectMySQL:
+			return fmt.Sprintf("DATE(STR_TO_DATE(%s, '%s'))", col, convertStrftime(format, mysqlDateElements, verbatim))
+		case SQLDialectBigQuery:
+			if hasTime {
+				return fmt.Sprintf("DATE(PARSE_DATETIME('%s', %s))", format, col)
+			}
+			return fmt.Sprintf("PARSE_DATE('%s', %s)", format, col)
+		case SQLDialectTSQL:
+			if style, ok := tsqlDateStyles[format]; ok {
+				return fmt.Sprintf("TRY_CONVERT(date, %s, %d)", col, style)
+			}
+			return fmt.Sprintf("TRY_CAST(%s AS date)", col)
+		case SQLDialectSnowflake:
+			pattern := convertStrftime(format, snowflakeDateElements, doubleQuoted)
+			if hasTime {
+				return fmt.Sprintf("TO_DATE(TO_TIMESTAMP(%s, '%s'))", col, pattern)
+			}
+			return fmt.Sprintf("TO_DATE(%s, '%s')", col, pattern)
+		default:
+			pattern := convertStrftime(format, postgresDateElements, doubleQuoted)
+			if hasTime {
+				return fmt.Sprintf("CAST(TO_TIMESTAMP(%s, '%s') AS DATE)", col, pattern)
+			}
+			return fmt.Sprintf("TO_DATE(%s, '%s')", col, pattern)
+		}
+
+	case TransformPhone:
+		switch dialect {
+		case SQLDialectPostgres:
+			return fmt.Sprintf("REGEXP_REPLACE(%s, '[^0-9]', '', 'g')", text)
+		case SQLDialectBigQuery:
+			return fmt.Sprintf("REGEXP_REPLACE(%s, r'[^0-9]', '')", text)
+		case SQLDialectTSQL:
+			// No regex support: strip the separators the normalizer knows
+			expr := text
+			for _, sep := range []string{" ", "-", "(", ")", "+", "."} {
+				expr = fmt.Sprintf("REPLACE(%s, '%s', '')", expr, sep)
+			}
+			return expr
+		default:
+			return fmt.Sprintf("REGEXP_REPLACE(%s, '[^0-9]', '')", text)
+		}
+
+	case TransformNumber:
+		switch dialect {
+		case SQLDialectPostgres:
+			return fmt.Sprintf("CAST(NULLIF(REGEXP_REPLACE(%s, '[^0-9.-]', '', 'g'), '') AS NUMERIC)", text)
+		case SQLDialectMySQL:
+			return fmt.Sprintf("CAST(REGEXP_REPLACE(%s, '[^0-9.-]', '') AS DECIMAL(38, 10))", text)
+		case SQLDialectSnowflake:
+			return fmt.Sprintf("TRY_TO_NUMBER(REGEXP_REPLACE(%s, '[^0-9.-]', ''), 38, 10)", text)
+		case SQLDialectBigQuery:
+			return fmt.Sprintf("SAFE_CAST(REGEXP_REPLACE(%s, r'[^0-9.-]', '') AS NUMERIC)", text)
+		default:
+			expr := text
+			for _, sep := range []string{",", "$", "€", "£", " "} {
+				expr = fmt.Sprintf("REPLACE(%s, N'%s', '')", expr, sep)
+			}
+			return fmt.Sprintf("TRY_CAST(%s AS DECIMAL(38, 10))", expr)
+		}
+
+	case TransformScale:
+		// Rounded so float noise doesn't break equality
+		if file == 1 {
+			return fmt.Sprintf("ROUND(%s, 6)", scaleExpr(col, t.Factor))
+		}
+		return fmt.Sprintf("ROUND(%s, 6)", col)
+	}
+	return fmt.Sprintf("UPPER(TRIM(%s))", col)
+}
+
+// pythonTransform renders a transform over col (a column expression of the
+// engine, e.g. df1['a'] or pl.col('a')) of file 1 or 2
+func pythonTransform(engine string, t *models.ColumnTransform, file int, col string) string {
+	switch t.Kind {
+	case TransformDate:
+		format := t.File1Format
+		if file == 2 {
+			format = t.File2Format
+		}
+		switch engine {
+		case PythonEnginePySpark:
+			return fmt.Sprintf("F.to_date(%s, %s)", col, pyString(convertStrftime(format, sparkDateElements, singleQuoted)))
+		case PythonEnginePolars:
+			if strings.Contains(format, "%H") {
+				return fmt.Sprintf("%s.cast(pl.Utf8).str.to_datetime(%s, strict=False).dt.date()", col, pyString(format))
+			}
+			return fmt.Sprintf("%s.cast(pl.Utf8).str.to_date(%s, strict=False)", col, pyString(format))
+		default:
+			return fmt.Sprintf("pd.to_datetime(%s, format=%s, errors='coerce').dt.normalize()", col, pyString(format))
+		}
+
+	case TransformPhone:
+		switch engine {
+		case PythonEnginePySpark:
+			return fmt.Sprintf("F.regexp_replace(%s.cast('string'), '[^0-9]', '')", col)
+		case PythonEnginePolars:
+			return fmt.Sprintf("%s.cast(pl.Utf8).str.replace_all(r'[^0-9]', '')", col)
+		default:
+			return fmt.Sprintf("%s.astype(str).str.replace(r'[^0-9]', '', regex=True)", col)
+		}
+
+	case TransformNumber:
+		switch engine {
+		case PythonEnginePySpark:
+			return fmt.Sprintf("F.regexp_replace(%s.cast('string'), '[^0-9.-]', '').cast('double')", col)
+		case PythonEnginePolars:
+			return fmt.Sprintf("%s.cast(pl.Utf8).str.replace_all(r'[^0-9.-]', '').cast(pl.Float64, strict=False)", col)
+		default:
+			return fmt.Sprintf("pd.to_numeric(%s.astype(str).str.replace(r'[^0-9.-]', '', regex=True), errors='coerce')", col)
+		}
+
+	case TransformScale:
+		if file == 1 {
+			col = "(" + scaleExpr(col, t.Factor) + ")"
+		}
+		if engine == PythonEnginePySpark {
+			return fmt.Sprintf("F.round(%s, 6)", col)
+		}
+		return col + ".round(6)"
+	}
+
+	switch engine {
+	case PythonEnginePySpark:
+		return fmt.Sprintf("F.upper(F.trim(%s))", col)
+	case PythonEnginePolars:
+		return fmt.Sprintf("%s.cast(pl.Utf8).str.strip_chars().str.to_uppercase()", col)
+	default:
+		return fmt.Sprintf("%s.astype(str).str.strip().str.upper()", col)
+	}
+}
+
+// joinTransforms returns the format transforms of the join pairs: the one
+// stored with an accepted mapping, otherwise the graph's suggestion
+func joinTransforms(graph *models.SimilarityGraph, mapping *ApprovedMapping, pairs [][2]string) map[[2]string]*models.ColumnTransform {
+	suggested := make(map[[2]string]*models.ColumnTransform)
+	for _, sim := range graph.Similarities {
+		if sim.FormatTransform != nil {
+			suggested[[2]string{sim.File1Column, sim.File2Column}] = sim.FormatTransform
+		}
+	}
+	for _, m := range mapping.Accepted() {
+		if m.FormatTransform != nil {
+			suggested[[2]string{m.File1Column, m.File2Column}] = m.FormatTransform
+		}
+	}
+
+	transforms := make(map[[2]string]*models.ColumnTransform)
+	for _, pair := range pairs {
+		if t, ok := suggested[pair]; ok {
+			transforms[pair] = t
+		}
+	}
+	return transforms
+}
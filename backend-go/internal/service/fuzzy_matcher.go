@@ -1,71 +1,94 @@
 package service
 
 import (
+	"backend-go/internal/textnorm"
 	"hash/fnv"
 	"math"
 	"strings"
 	"unicode"
 )
 
+// LSH parameters: 16 bands of 4 rows give a ~50% Jaccard threshold for
+// becoming a candidate pair (probability 1-(1-j^4)^16)
+const (
+	lshBands       = 16
+	lshRowsPerBand = 4
+	lshSignature   = lshBands * lshRowsPerBand
+)
+
 // FuzzyMatcher provides fast approximate string matching
 type FuzzyMatcher struct {
-	lshBuckets   map[uint64][]string // LSH buckets for fast lookup
-	soundexCache map[string]string   // Cache for soundex codes
+	lsh          *LSHIndex         // Banded MinHash index for fast lookup
+	soundexCache map[string]string // Cache for soundex codes
 }
 
 // NewFuzzyMatcher creates a new fuzzy matcher
 func NewFuzzyMatcher() *FuzzyMatcher {
 	return &FuzzyMatcher{
-		lshBuckets:   make(map[uint64][]string),
+		lsh:          NewLSHIndex(),
 		soundexCache: make(map[string]string),
 	}
 }
 
-// LSHMatch performs locality-sensitive hashing for fast approximate matching
+// LSHMatch performs locality-sensitive hashing for fast approximate matching.
+// Candidates are indexed first when nothing has been indexed with IndexForLSH.
 func (fm *FuzzyMatcher) LSHMatch(query string, candidates []string, threshold float64) []string {
-	results := []string{}
-
-	// Generate MinHash signature for query
-	queryHash := fm.minHash(query)
-
-	// Find candidates in same LSH bucket
-	bucket := fm.lshBuckets[queryHash]
-
-	// For each candidate in bucket, verify with actual similarity
-	for _, candidate := range bucket {
-		sim := fm.jaccardSimilarity(query, candidate)
-		if sim >= threshold {
-			results = append(results, candidate)
-		}
+	if fm.lsh.Len() == 0 {
+		fm.IndexForLSH(candidates)
 	}
 
+	results := []string{}
+	matches, _ := fm.lsh.Query(query, threshold)
+	for _, match := range matches {
+		results = append(results, match.Value)
+	}
 	return results
 }
 
-// minHash generates a MinHash signature for LSH
+// minHash returns the first slot of the MinHash signature. A single hash is
+// only useful as a coarse blocking key; use LSHIndex for approximate lookup.
 func (fm *FuzzyMatcher) minHash(s string) uint64 {
-	// Generate character 3-grams
-	grams := fm.generateNGrams(s, 3)
+	return fm.minHashSignature(s)[0]
+}
 
-	// Hash each gram and take minimum
-	minHashVal := uint64(math.MaxUint64)
+// minHashSignature computes a MinHash signature over character 3-grams
+func (fm *FuzzyMatcher) minHashSignature(s string) []uint64 {
+	sig := make([]uint64, lshSignature)
+	for i := range sig {
+		sig[i] = math.MaxUint64
+	}
 
-	for _, gram := range grams {
+	for _, gram := range fm.generateNGrams(s, 3) {
 		h := fnv.New64a()
 		h.Write([]byte(gram))
-		hashVal := h.Sum64()
-
-		if hashVal < minHashVal {
-			minHashVal = hashVal
+		base := mixHash(h.Sum64())
+		step := mixHash(base^0x9e3779b97f4a7c15) | 1
+
+		// Derive the k hash functions by double hashing
+		for i := range sig {
+			v := mixHash(base + uint64(i)*step)
+			if v < sig[i] {
+				sig[i] = v
+			}
 		}
 	}
 
-	return minHashVal
+	return sig
+}
+
+// mixHash is the splitmix64 finalizer
+func mixHash(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
 }
 
 // generateNGrams creates character n-grams
 func (fm *FuzzyMatcher) generateNGrams(s string, n int) []string {
-	s = strings.ToLower(s)
+	s = textnorm.Fold(s)
 	grams := []string{}
 
 	if len(s) < n {
@@ -304,10 +327,8 @@ func (fm *FuzzyMatcher) FastFuzzyMatch(s1, s2 string, threshold float64) float64
 
 // IndexForLSH indexes strings into LSH buckets for fast lookup
 func (fm *FuzzyMatcher) IndexForLSH(strings []string) {
-	fm.lshBuckets = make(map[uint64][]string)
-
+	fm.lsh = NewLSHIndex()
 	for _, s := range strings {
-		hash := fm.minHash(s)
-		fm.lshBuckets[hash] = append(fm.lshBuckets[hash], s)
+		fm.lsh.Add(s)
 	}
 }
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/llm"
+	"backend-go/internal/logging"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var glossaryLog = logging.Component("glossary")
+
+const glossaryFile = "glossary.json"
+
+// glossaryMatchSimilarity is the name similarity of two names of the same
+// term, just under an exact match
+const glossaryMatchSimilarity = 0.95
+
+// maxGlossaryPromptTerms caps the glossary terms added to an LLM prompt
+const maxGlossaryPromptTerms = 40
+
+// GlossaryTerm is a business term with the names it goes by
+type GlossaryTerm struct {
+	Term       string    `json:"term"`
+	Definition string    `json:"definition,omitempty"`
+	Synonyms   []string  `json:"synonyms,omitempty"`
+	Steward    string    `json:"steward,omitempty"` // Who owns the definition
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// glossaryIndex resolves column names to terms. It is rebuilt on every
+// change and never modified, so readers can use it without the lock.
+type glossaryIndex struct {
+	names  map[string]string   // normalized term or synonym -> term
+	tokens map[string][]string // single-token name -> the other single-token names of its term
+}
+
+// Glossary keeps the business glossary that augments the synonym map
+type Glossary struct {
+	terms map[string]GlossaryTerm // keyed by normalized term
+	index *glossaryIndex
+	mutex sync.RWMutex
+}
+
+var (
+	glossary     *Glossary
+	glossaryOnce sync.Once
+)
+
+// GetGlossary returns the singleton business glossary
+func GetGlossary() *Glossary {
+	glossaryOnce.Do(func() {
+		glossary = &Glossary{
+			terms: make(map[string]GlossaryTerm),
+			index: &glossaryIndex{},
+		}
+		glossary.load()
+	})
+	return glossary
+}
+
+// load loads the glossary from file
+func (g *Glossary) load() {
+	data, err := os.ReadFile(config.DataPath(glossaryFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			glossaryLog.Error("Error loading glossary", "error", err)
+		}
+		return
+	}
+
+	var saved []GlossaryTerm
+	if err := json.Unmarshal(data, &saved); err != nil {
+		glossaryLog.Error("Error parsing glossary", "error", err)
+		return
+	}
+	for _, t := range saved {
+		g.terms[normalize(t.Term)] = t
+	}
+	index, err := buildGlossaryIndex(g.terms)
+	if err != nil {
+		glossaryLog.Error("Error indexing glossary", "error", err)
+		return
+	}
+	g.index = index
+	glossaryLog.Info("Loaded glossary", "terms", len(saved))
+}
+
+// save persists the glossary to file (must hold lock)
+func (g *Glossary) save() error {
+	data, err := json.MarshalIndent(g.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(glossaryFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(glossaryFile), data, 0644)
+}
+
+// sorted lists the terms alphabetically (must hold lock)
+func (g *Glossary) sorted() []GlossaryTerm {
+	terms := make([]GlossaryTerm, 0, len(g.terms))
+	for _, t := range g.terms {
+		terms = append(terms, t)
+	}
+	sort.Slice(terms, func(i, j int) bool { return strings.ToLower(terms[i].Term) < strings.ToLower(terms[j].Term) })
+	return terms
+}
+
+// List returns the terms alphabetically
+func (g *Glossary) List() []GlossaryTerm {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.sorted()
+}
+
+// Get looks up a term, ignoring case and separators
+func (g *Glossary) Get(term string) (GlossaryTerm, bool) {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	t, ok := g.terms[normalize(term)]
+	return t, ok
+}
+
+// Import adds terms to the glossary, replacing terms of the same name. With
+// replace true the imported terms become the whole glossary. Nothing
+// changes when a term is invalid or a name would resolve to two terms.
+func (g *Glossary) Import(terms []GlossaryTerm, replace bool) (int, error) {
+	if len(terms) == 0 {
+		return 0, fmt.Errorf("no terms to import")
+	}
+	now := time.Now()
+	for i := range terms {
+		if err := terms[i].normalize(); err != nil {
+			return 0, fmt.Errorf("terms[%d]: %w", i, err)
+		}
+		terms[i].UpdatedAt = now
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	merged := make(map[string]GlossaryTerm, len(g.terms)+len(terms))
+	if !replace {
+		for key, t := range g.terms {
+			merged[key] = t
+		}
+	}
+	for _, t := range terms {
+		merged[normalize(t.Term)] = t
+	}
+	index, err := buildGlossaryIndex(merged)
+	if err != nil {
+		return 0, err
+	}
+
+	g.terms, g.index = merged, index
+	glossaryLog.Info("Imported glossary terms", "imported", len(terms), "terms", len(merged), "replace", replace)
+	return len(terms), g.save()
+}
+
+// Delete removes a term
+func (g *Glossary) Delete(term string) error {
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+
+	key := normalize(term)
+	if _, ok := g.terms[key]; !ok {
+		return fmt.Errorf("term %q not found", term)
+	}
+	delete(g.terms, key)
+	// Removing a term cannot introduce a conflict
+	g.index, _ = buildGlossaryIndex(g.terms)
+	glossaryLog.Info("Deleted glossary term", "term", term)
+	return g.save()
+}
+
+// currentIndex returns the index for lookups
+func (g *Glossary) currentIndex() *glossaryIndex {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+	return g.index
+}
+
+// Resolve returns the term a column name stands for: the term itself or
+// one of its synonyms, ignoring case and separators
+func (g *Glossary) Resolve(column string) (string, bool) {
+	term, ok := g.currentIndex().names[normalize(column)]
+	return term, ok
+}
+
+// PairTerm returns the term both columns of a pair stand for, empty when
+// they stand for none or for different terms
+func (g *Glossary) PairTerm(col1, col2 string) string {
+	index := g.currentIndex()
+	if term, ok := index.names[normalize(col1)]; ok && index.names[normalize(col2)] == term {
+		return term
+	}
+	return ""
+}
+
+// TokenSynonyms returns the single-token names that share a term with a
+// name token, extending the built-in synonym map
+func (g *Glossary) TokenSynonyms(token string) []string {
+	return g.currentIndex().tokens[token]
+}
+
+// PromptHints returns the terms the given columns resolve to, for the LLM
+func (g *Glossary) PromptHints(columns ...[]string) []llm.GlossaryHint {
+	g.mutex.RLock()
+	defer g.mutex.RUnlock()
+
+	seen := map[string]bool{}
+	hints := []llm.GlossaryHint{}
+	for _, cols := range columns {
+		for _, col := range cols {
+			term, ok := g.index.names[normalize(col)]
+			if !ok || seen[term] || len(hints) >= maxGlossaryPromptTerms {
+				continue
+			}
+			seen[term] = true
+			t := g.terms[normalize(term)]
+			hints = append(hints, llm.GlossaryHint{Term: t.Term, Definition: t.Definition, Synonyms: t.Synonyms})
+		}
+	}
+	return hints
+}
+
+// buildGlossaryIndex indexes the names of the terms, rejecting a name that
+// belongs to two terms
+func buildGlossaryIndex(terms map[string]GlossaryTerm) (*glossaryIndex, error) {
+	index := &glossaryIndex{names: map[string]string{}, tokens: map[string][]string{}}
+	keys := make([]string, 0, len(terms))
+	for key := range terms {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		t := terms[key]
+		singles := []string{}
+		for _, name := range append([]string{t.Term}, t.Synonyms...) {
+			n := normalize(name)
+			if other, ok := index.names[n]; ok && other != t.Term {
+				return nil, fmt.Errorf("%q of term %q already stands for term %q", name, t.Term, other)
+			}
+			index.names[n] = t.Term
+			if tokens := tokenize(name); len(tokens) == 1 {
+				singles = append(singles, tokens[0])
+			}
+		}
+		for _, token := range singles {
+			for _, other := range singles {
+				if other != token {
+					index.tokens[token] = append(index.tokens[token], other)
+				}
+			}
+		}
+	}
+	return index, nil
+}
+
+// normalize trims the term and drops empty and duplicate synonyms
+func (t *GlossaryTerm) normalize() error {
+	t.Term = strings.TrimSpace(t.Term)
+	if t.Term == "" {
+		return fmt.Errorf("term is required")
+	}
+	if strings.ContainsAny(t.Term, "/?#") {
+		return fmt.Errorf("term must not contain / ? or #")
+	}
+	t.Definition = strings.TrimSpace(t.Definition)
+	t.Steward = strings.TrimSpace(t.Steward)
+
+	seen := map[string]bool{normalize(t.Term): true}
+	synonyms := []string{}
+	for _, syn := range t.Synonyms {
+		syn = strings.TrimSpace(syn)
+		if syn == "" || seen[normalize(syn)] {
+			continue
+		}
+		seen[normalize(syn)] = true
+		synonyms = append(synonyms, syn)
+	}
+	t.Synonyms = synonyms
+	return nil
+}
+
+// ParseGlossaryCSV reads glossary terms from CSV with a header row naming
+// the term, definition, synonyms and steward columns; only term is
+// required. Synonyms are separated by ; or |.
+func ParseGlossaryCSV(r io.Reader) ([]GlossaryTerm, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading header: %w", err)
+	}
+	cols := map[string]int{}
+	for i, name := range header {
+		cols[strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))] = i
+	}
+	if _, ok := cols["term"]; !ok {
+		return nil, fmt.Errorf("the header has no term column")
+	}
+	field := func(record []string, name string) string {
+		if i, ok := cols[name]; ok && i < len(record) {
+			return record[i]
+		}
+		return ""
+	}
+
+	terms := []GlossaryTerm{}
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		if strings.TrimSpace(field(record, "term")) == "" {
+			continue
+		}
+		terms = append(terms, GlossaryTerm{
+			Term:       field(record, "term"),
+			Definition: field(record, "definition"),
+			Synonyms: strings.FieldsFunc(field(record, "synonyms"), func(r rune) bool {
+				return r == ';' || r == '|'
+			}),
+			Steward: field(record, "steward"),
+		})
+	}
+	return terms, nil
+}
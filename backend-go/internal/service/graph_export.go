@@ -0,0 +1,149 @@
+package service
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strconv"
+)
+
+// Similarity graph export formats
+const (
+	GraphFormatGraphML   = "graphml"   // GraphML XML, for Gephi, yEd and NetworkX
+	GraphFormatCytoscape = "cytoscape" // Cytoscape.js JSON, for Cytoscape and Cytoscape.js
+)
+
+// graphMLDoc is a GraphML document
+type graphMLDoc struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   graphMLGraph `xml:"graph"`
+}
+
+type graphMLKey struct {
+	ID       string `xml:"id,attr"`
+	For      string `xml:"for,attr"`
+	AttrName string `xml:"attr.name,attr"`
+	AttrType string `xml:"attr.type,attr"`
+}
+
+type graphMLGraph struct {
+	ID          string        `xml:"id,attr"`
+	EdgeDefault string        `xml:"edgedefault,attr"`
+	Desc        string        `xml:"desc,omitempty"`
+	Nodes       []graphMLItem `xml:"node"`
+	Edges       []graphMLItem `xml:"edge"`
+}
+
+// graphMLItem is a node or an edge with its data
+type graphMLItem struct {
+	ID     string        `xml:"id,attr"`
+	Source string        `xml:"source,attr,omitempty"`
+	Target string        `xml:"target,attr,omitempty"`
+	Data   []graphMLData `xml:"data"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+// RenderGraphML renders an enriched similarity graph as GraphML, with the
+// label, file, community and centrality of each column and the weight of
+// each match
+func RenderGraphML(graph *SchemaGraph, file1Name, file2Name string) ([]byte, error) {
+	doc := graphMLDoc{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			{ID: "label", For: "node", AttrName: "label", AttrType: "string"},
+			{ID: "file", For: "node", AttrName: "file", AttrType: "string"},
+			{ID: "community", For: "node", AttrName: "community", AttrType: "int"},
+			{ID: "centrality", For: "node", AttrName: "centrality", AttrType: "double"},
+			{ID: "weight", For: "edge", AttrName: "weight", AttrType: "double"},
+		},
+		Graph: graphMLGraph{
+			ID:          "similarity",
+			EdgeDefault: "undirected",
+			Desc:        fmt.Sprintf("Column similarity of %s (file1) and %s (file2)", file1Name, file2Name),
+			Nodes:       []graphMLItem{},
+			Edges:       []graphMLItem{},
+		},
+	}
+	for _, n := range graph.Nodes {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLItem{
+			ID: n.ID,
+			Data: []graphMLData{
+				{Key: "label", Value: n.Label},
+				{Key: "file", Value: n.File},
+				{Key: "community", Value: strconv.Itoa(n.Community)},
+				{Key: "centrality", Value: strconv.FormatFloat(n.Centrality, 'g', 6, 64)},
+			},
+		})
+	}
+	for i, e := range graph.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLItem{
+			ID:     "e" + strconv.Itoa(i),
+			Source: e.Source,
+			Target: e.Target,
+			Data:   []graphMLData{{Key: "weight", Value: strconv.FormatFloat(e.Weight, 'g', 6, 64)}},
+		})
+	}
+
+	out, err := xml.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append([]byte(xml.Header), out...), nil
+}
+
+// CytoscapeElement is a Cytoscape.js node or edge
+type CytoscapeElement struct {
+	Data map[string]interface{} `json:"data"`
+}
+
+// CytoscapeGraph is a graph in Cytoscape.js JSON, which Cytoscape desktop
+// imports as a network
+type CytoscapeGraph struct {
+	FormatVersion string                 `json:"format_version"`
+	GeneratedBy   string                 `json:"generated_by"`
+	Data          map[string]interface{} `json:"data"`
+	Elements      struct {
+		Nodes []CytoscapeElement `json:"nodes"`
+		Edges []CytoscapeElement `json:"edges"`
+	} `json:"elements"`
+}
+
+// BuildCytoscapeGraph converts an enriched similarity graph to Cytoscape.js
+// JSON, keeping the same node and edge attributes as GraphML
+func BuildCytoscapeGraph(graph *SchemaGraph, file1Name, file2Name string) *CytoscapeGraph {
+	out := &CytoscapeGraph{
+		FormatVersion: "1.0",
+		GeneratedBy:   "project-euler",
+		Data: map[string]interface{}{
+			"name":  fmt.Sprintf("Column similarity of %s and %s", file1Name, file2Name),
+			"file1": file1Name,
+			"file2": file2Name,
+		},
+	}
+	out.Elements.Nodes = []CytoscapeElement{}
+	out.Elements.Edges = []CytoscapeElement{}
+	for _, n := range graph.Nodes {
+		out.Elements.Nodes = append(out.Elements.Nodes, CytoscapeElement{Data: map[string]interface{}{
+			"id":         n.ID,
+			"name":       n.Label,
+			"file":       n.File,
+			"community":  n.Community,
+			"centrality": n.Centrality,
+		}})
+	}
+	for i, e := range graph.Edges {
+		out.Elements.Edges = append(out.Elements.Edges, CytoscapeElement{Data: map[string]interface{}{
+			"id":          "e" + strconv.Itoa(i),
+			"source":      e.Source,
+			"target":      e.Target,
+			"weight":      e.Weight,
+			"interaction": "similar_to",
+		}})
+	}
+	return out
+}
@@ -0,0 +1,417 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/remote"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var hotFolderLog = logging.Component("hotfolder")
+
+const hotFolderFile = "hotfolder.json"
+
+// hotFolderMaxResults is how many check results are kept, newest first
+const hotFolderMaxResults = 100
+
+// Hot folder check statuses
+const (
+	HotFolderPassed   = "passed"   // Enough reference columns matched
+	HotFolderRejected = "rejected" // Too few reference columns matched
+	HotFolderError    = "error"    // The file couldn't be read or compared
+)
+
+// HotFolderFile is a file found in the hot folder
+type HotFolderFile struct {
+	Name       string    `json:"name"`
+	Source     string    `json:"source"` // Local path or s3:// URI
+	Size       int64     `json:"size"`
+	ModifiedAt time.Time `json:"modified_at"`
+	Version    string    `json:"version,omitempty"` // ETag of s3 objects
+}
+
+// fingerprint changes whenever the file is replaced
+func (f HotFolderFile) fingerprint() string {
+	if f.Version != "" {
+		return f.Version
+	}
+	return strconv.FormatInt(f.Size, 10) + "@" + strconv.FormatInt(f.ModifiedAt.UnixNano(), 10)
+}
+
+// HotFolderResult is the check of one file against the reference
+type HotFolderResult struct {
+	File           string       `json:"file"`
+	Source         string       `json:"source"`
+	Upload         string       `json:"upload,omitempty"` // Stored upload the file was ingested as
+	Size           int64        `json:"size"`
+	CheckedAt      time.Time    `json:"checked_at"`
+	Status         string       `json:"status"`
+	Coverage       float64      `json:"coverage"` // Matched share of the reference columns, 0-1
+	Columns        int          `json:"columns"`  // Of the reference
+	MissingColumns []string     `json:"missing_columns,omitempty"`
+	ExtraColumns   []string     `json:"extra_columns,omitempty"`
+	Matches        []BatchMatch `json:"matches,omitempty"`
+	Error          string       `json:"error,omitempty"`
+	DurationMs     int64        `json:"duration_ms"`
+}
+
+// HotFolderStatus is the configuration and recent checks of the hot folder
+type HotFolderStatus struct {
+	Enabled     bool              `json:"enabled"`
+	Dir         string            `json:"dir,omitempty"`
+	Reference   string            `json:"reference,omitempty"`
+	Interval    string            `json:"interval,omitempty"`
+	Profile     string            `json:"profile,omitempty"`
+	MinCoverage int               `json:"min_coverage"`
+	LastPoll    *time.Time        `json:"last_poll,omitempty"`
+	LastError   string            `json:"last_error,omitempty"` // Of the last listing
+	Pending     int               `json:"pending"`              // Files waiting to stop changing
+	Results     []HotFolderResult `json:"results"`
+}
+
+// HotFolderRunner ingests a hot folder file and compares it, as file 2,
+// with the reference, as file 1. The result's File2 names the stored upload.
+type HotFolderRunner func(ctx context.Context, file HotFolderFile, profile MatchingProfile) BatchPairResult
+
+// HotFolder polls a directory or s3 prefix and checks each new file against
+// the reference file
+type HotFolder struct {
+	seen    map[string]string // Source -> fingerprint of the checked version
+	pending map[string]string // Local files seen changing at the last poll
+	results []HotFolderResult
+	runner  HotFolderRunner
+	accepts func(name string) bool
+
+	lastPoll  *time.Time
+	lastError string
+
+	scanMutex sync.Mutex // Serializes polls
+	mutex     sync.Mutex
+}
+
+// hotFolderState is what is persisted of the hot folder
+type hotFolderState struct {
+	Seen    map[string]string `json:"seen"`
+	Results []HotFolderResult `json:"results"`
+}
+
+var (
+	hotFolder     *HotFolder
+	hotFolderOnce sync.Once
+)
+
+// GetHotFolder returns the singleton hot folder
+func GetHotFolder() *HotFolder {
+	hotFolderOnce.Do(func() {
+		hotFolder = &HotFolder{
+			seen:    make(map[string]string),
+			pending: make(map[string]string),
+			results: []HotFolderResult{},
+		}
+		hotFolder.load()
+	})
+	return hotFolder
+}
+
+// load loads the checked files and results from file
+func (h *HotFolder) load() {
+	data, err := os.ReadFile(config.DataPath(hotFolderFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			hotFolderLog.Error("Error loading hot folder state", "error", err)
+		}
+		return
+	}
+
+	var st hotFolderState
+	if err := json.Unmarshal(data, &st); err != nil {
+		hotFolderLog.Error("Error parsing hot folder state", "error", err)
+		return
+	}
+	if st.Seen != nil {
+		h.seen = st.Seen
+	}
+	if st.Results != nil {
+		h.results = st.Results
+	}
+	hotFolderLog.Info("Loaded hot folder state", "checked", len(h.seen))
+}
+
+// save persists the checked files and results to file (must hold lock)
+func (h *HotFolder) save() error {
+	data, err := json.MarshalIndent(hotFolderState{Seen: h.seen, Results: h.results}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(hotFolderFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(hotFolderFile), data, 0644)
+}
+
+// SetRunner installs the function that checks files; accepts tells the
+// files it can ingest from the others in the folder
+func (h *HotFolder) SetRunner(runner HotFolderRunner, accepts func(name string) bool) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+	h.runner = runner
+	h.accepts = accepts
+}
+
+// Start polls the hot folder every watch.interval, when one is configured
+func (h *HotFolder) Start() {
+	cfg := config.Get().Watch
+	if !cfg.Enabled() {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(cfg.PollPeriod())
+		defer ticker.Stop()
+		for {
+			if _, err := h.Scan(context.Background()); err != nil {
+				hotFolderLog.Warn("Hot folder poll failed", "dir", cfg.Dir, "error", err)
+			}
+			<-ticker.C
+		}
+	}()
+	hotFolderLog.Info("Watching hot folder", "dir", cfg.Dir, "reference", cfg.Reference, "interval", cfg.Interval)
+}
+
+// Scan polls the hot folder once and checks the new and replaced files,
+// returning how many were checked. Local files are checked once they are
+// unchanged between two polls, so files still being written are left alone.
+func (h *HotFolder) Scan(ctx context.Context) (int, error) {
+	cfg := config.Get().Watch
+	if !cfg.Enabled() {
+		return 0, fmt.Errorf("no hot folder is configured (watch.dir)")
+	}
+	h.scanMutex.Lock()
+	defer h.scanMutex.Unlock()
+
+	h.mutex.Lock()
+	runner, accepts := h.runner, h.accepts
+	h.mutex.Unlock()
+	if runner == nil {
+		return 0, fmt.Errorf("the hot folder can't be checked yet")
+	}
+
+	files, err := listHotFolder(ctx, cfg)
+	now := time.Now()
+	h.mutex.Lock()
+	h.lastPoll = &now
+	h.lastError = ""
+	if err != nil {
+		h.lastError = err.Error()
+	}
+	h.mutex.Unlock()
+	if err != nil {
+		return 0, err
+	}
+
+	local := !strings.HasPrefix(cfg.Dir, remote.SchemeS3+"://")
+	ready := []HotFolderFile{}
+	h.mutex.Lock()
+	present := map[string]bool{}
+	for _, f := range files {
+		if !hotFolderCandidate(f.Name) || (accepts != nil && !accepts(f.Name)) {
+			continue
+		}
+		present[f.Source] = true
+		fp := f.fingerprint()
+		if h.seen[f.Source] == fp {
+			continue
+		}
+		if local && h.pending[f.Source] != fp {
+			h.pending[f.Source] = fp // Check it if it hasn't changed by the next poll
+			continue
+		}
+		delete(h.pending, f.Source)
+		ready = append(ready, f)
+	}
+	for src := range h.pending {
+		if !present[src] {
+			delete(h.pending, src)
+		}
+	}
+	h.mutex.Unlock()
+
+	for _, f := range ready {
+		h.check(ctx, cfg, runner, f)
+	}
+	return len(ready), nil
+}
+
+// hotFolderCandidate skips hidden files and the partial files of copies
+// and downloads in progress
+func hotFolderCandidate(name string) bool {
+	lower := strings.ToLower(name)
+	if strings.HasPrefix(name, ".") || strings.HasPrefix(name, "~") {
+		return false
+	}
+	for _, suffix := range []string{".part", ".tmp", ".crdownload", ".partial"} {
+		if strings.HasSuffix(lower, suffix) {
+			return false
+		}
+	}
+	return true
+}
+
+// listHotFolder lists the files of a local directory or s3 prefix
+func listHotFolder(ctx context.Context, cfg config.WatchConfig) ([]HotFolderFile, error) {
+	if strings.HasPrefix(cfg.Dir, remote.SchemeS3+"://") {
+		objects, err := remote.List(ctx, cfg.Dir, cfg.CredentialsRef)
+		if err != nil {
+			return nil, err
+		}
+		files := make([]HotFolderFile, len(objects))
+		for i, o := range objects {
+			files[i] = HotFolderFile{Name: o.Name, Source: o.URI, Size: o.Size, ModifiedAt: o.ModifiedAt, Version: o.Version}
+		}
+		return files, nil
+	}
+
+	entries, err := os.ReadDir(cfg.Dir)
+	if err != nil {
+		return nil, err
+	}
+	files := []HotFolderFile{}
+	for _, e := range entries {
+		if !e.Type().IsRegular() {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue // Removed since listing
+		}
+		files = append(files, HotFolderFile{
+			Name:       e.Name(),
+			Source:     filepath.Join(cfg.Dir, e.Name()),
+			Size:       info.Size(),
+			ModifiedAt: info.ModTime(),
+		})
+	}
+	return files, nil
+}
+
+// check compares a file with the reference, records the result and
+// notifies the webhooks
+func (h *HotFolder) check(ctx context.Context, cfg config.WatchConfig, runner HotFolderRunner, f HotFolderFile) {
+	start := time.Now()
+	result := HotFolderResult{File: f.Name, Source: f.Source, Size: f.Size, CheckedAt: start}
+
+	if profile, ok := GetMatchingProfileStore().Get(cfg.Profile); !ok {
+		result.Status = HotFolderError
+		result.Error = "unknown matching profile " + cfg.Profile
+	} else {
+		pair := runner(ctx, f, profile)
+		result.Upload = pair.File2.Upload
+		result.fromPair(pair, profile, cfg.MinCoverage)
+	}
+	result.DurationMs = time.Since(start).Milliseconds()
+
+	h.mutex.Lock()
+	h.seen[f.Source] = f.fingerprint()
+	h.results = append([]HotFolderResult{result}, h.results...)
+	if len(h.results) > hotFolderMaxResults {
+		h.results = h.results[:hotFolderMaxResults]
+	}
+	if err := h.save(); err != nil {
+		hotFolderLog.Error("Error saving hot folder state", "error", err)
+	}
+	h.mutex.Unlock()
+
+	hotFolderLog.Info("Checked hot folder file", "file", f.Name, "status", result.Status,
+		"coverage", result.Coverage, "duration_ms", result.DurationMs)
+	notifyHotFolderCheck(result)
+}
+
+// fromPair fills the result from the comparison with the reference. A
+// reference column counts as present when matched at the profile's match
+// threshold; files with at least minCoverage percent present pass.
+func (r *HotFolderResult) fromPair(pair BatchPairResult, profile MatchingProfile, minCoverage int) {
+	if pair.Error != "" {
+		r.Status = HotFolderError
+		r.Error = pair.Error
+		return
+	}
+	matched1, matched2 := map[string]bool{}, map[string]bool{}
+	for _, m := range pair.Matches {
+		if m.Confidence >= profile.MatchThreshold {
+			r.Matches = append(r.Matches, m)
+			matched1[m.File1Column], matched2[m.File2Column] = true, true
+		}
+	}
+	r.MissingColumns = append([]string{}, pair.UnmatchedFile1...)
+	r.ExtraColumns = append([]string{}, pair.UnmatchedFile2...)
+	for _, m := range pair.Matches {
+		if !matched1[m.File1Column] {
+			r.MissingColumns = append(r.MissingColumns, m.File1Column)
+			matched1[m.File1Column] = true // Listed once
+		}
+		if !matched2[m.File2Column] {
+			r.ExtraColumns = append(r.ExtraColumns, m.File2Column)
+			matched2[m.File2Column] = true
+		}
+	}
+
+	if ref := pair.File1Summary; ref != nil && ref.Columns > 0 {
+		r.Columns = ref.Columns
+		r.Coverage = float64(ref.Columns-len(r.MissingColumns)) / float64(ref.Columns)
+	}
+	if r.Coverage*100 >= float64(minCoverage) {
+		r.Status = HotFolderPassed
+	} else {
+		r.Status = HotFolderRejected
+	}
+}
+
+// notifyHotFolderCheck sends the hotfolder.checked webhook event
+func notifyHotFolderCheck(r HotFolderResult) {
+	var summary string
+	switch r.Status {
+	case HotFolderError:
+		summary = fmt.Sprintf("%s could not be checked: %s", r.File, r.Error)
+	default:
+		summary = fmt.Sprintf("%s %s: %d of %d reference columns matched", r.File, r.Status, r.Columns-len(r.MissingColumns), r.Columns)
+		if len(r.MissingColumns) > 0 {
+			summary += "; missing " + strings.Join(r.MissingColumns, ", ")
+		}
+	}
+	GetWebhookStore().Notify(WebhookEvent{
+		Event:   EventHotFolderChecked,
+		Summary: summary,
+		Data:    r,
+	})
+}
+
+// Status returns the hot folder configuration and its recent checks
+func (h *HotFolder) Status() HotFolderStatus {
+	cfg := config.Get().Watch
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	st := HotFolderStatus{
+		Enabled: cfg.Enabled(),
+		Results: append([]HotFolderResult{}, h.results...),
+	}
+	if st.Enabled {
+		st.Dir = cfg.Dir
+		st.Reference = cfg.Reference
+		st.Interval = cfg.Interval
+		st.Profile = cfg.Profile
+		st.MinCoverage = cfg.MinCoverage
+		st.LastPoll = h.lastPoll
+		st.LastError = h.lastError
+		st.Pending = len(h.pending)
+	}
+	return st
+}
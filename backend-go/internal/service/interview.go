@@ -0,0 +1,391 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxInterviews caps the interviews kept; they live in memory only and the
+// least recently updated are evicted
+const maxInterviews = 50
+
+// InterviewAnswer is the answer to one interview question, or its skip
+type InterviewAnswer struct {
+	QuestionID string      `json:"question_id"`
+	FileIndex  int         `json:"file_index"` // 0 for questions about both files
+	Answer     interface{} `json:"answer,omitempty"`
+	Skipped    bool        `json:"skipped,omitempty"`
+	AnsweredAt time.Time   `json:"answered_at"`
+}
+
+// InterviewProgress counts the questions of an interview. The total changes
+// as answers add follow-ups or make questions moot.
+type InterviewProgress struct {
+	Answered  int     `json:"answered"`
+	Skipped   int     `json:"skipped"`
+	Remaining int     `json:"remaining"`
+	Total     int     `json:"total"`
+	Percent   float64 `json:"percent"`
+	Complete  bool    `json:"complete"`
+}
+
+// Interview is an adaptive context interview over both loaded files: each
+// answer is stored in the files' context and decides the questions left
+type Interview struct {
+	ID        string            `json:"id"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt time.Time         `json:"updated_at"`
+	Locale    string            `json:"locale"` // Language of the questions
+	Answers   []InterviewAnswer `json:"answers"`
+
+	analyses [2]models.DataAnalysisResult
+	headers  [2][]string
+}
+
+// InterviewStore keeps the interviews in progress
+type InterviewStore struct {
+	interviews map[string]*Interview
+	mutex      sync.Mutex
+}
+
+var (
+	interviewStore     *InterviewStore
+	interviewStoreOnce sync.Once
+)
+
+// GetInterviewStore returns the singleton interview store
+func GetInterviewStore() *InterviewStore {
+	interviewStoreOnce.Do(func() {
+		interviewStore = &InterviewStore{interviews: make(map[string]*Interview)}
+	})
+	return interviewStore
+}
+
+// Start opens an interview of two files from their analyses and headers,
+// asking its questions in locale
+func (s *InterviewStore) Start(analysis1, analysis2 models.DataAnalysisResult, headers1, headers2 []string, locale string) *Interview {
+	now := time.Now()
+	iv := &Interview{
+		ID:        newInterviewID(),
+		CreatedAt: now,
+		UpdatedAt: now,
+		Locale:    locale,
+		Answers:   []InterviewAnswer{},
+		analyses:  [2]models.DataAnalysisResult{analysis1, analysis2},
+		headers:   [2][]string{headers1, headers2},
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.interviews[iv.ID] = iv
+	for len(s.interviews) > maxInterviews {
+		var oldest *Interview
+		for _, other := range s.interviews {
+			if oldest == nil || other.UpdatedAt.Before(oldest.UpdatedAt) {
+				oldest = other
+			}
+		}
+		delete(s.interviews, oldest.ID)
+	}
+	return iv.copy()
+}
+
+// Get returns a copy of an interview
+func (s *InterviewStore) Get(id string) (*Interview, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	iv, ok := s.interviews[id]
+	if !ok {
+		return nil, false
+	}
+	return iv.copy(), true
+}
+
+// Record stores an answer, replacing an earlier answer to the same question
+func (s *InterviewStore) Record(id string, answer InterviewAnswer) (*Interview, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	iv, ok := s.interviews[id]
+	if !ok {
+		return nil, fmt.Errorf("interview %q not found", id)
+	}
+	answer.AnsweredAt = time.Now()
+	for i, a := range iv.Answers {
+		if a.QuestionID == answer.QuestionID {
+			iv.Answers = append(iv.Answers[:i], iv.Answers[i+1:]...)
+			break
+		}
+	}
+	iv.Answers = append(iv.Answers, answer)
+	iv.UpdatedAt = answer.AnsweredAt
+	return iv.copy(), nil
+}
+
+// Delete ends an interview; it reports whether the interview existed
+func (s *InterviewStore) Delete(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.interviews[id]
+	delete(s.interviews, id)
+	return ok
+}
+
+func (iv *Interview) copy() *Interview {
+	copied := *iv
+	copied.Answers = append([]InterviewAnswer(nil), iv.Answers...)
+	return &copied
+}
+
+// answer returns the answer given to a question
+func (iv *Interview) answer(questionID string) (InterviewAnswer, bool) {
+	for _, a := range iv.Answers {
+		if a.QuestionID == questionID {
+			return a, true
+		}
+	}
+	return InterviewAnswer{}, false
+}
+
+// Questions lists the questions of the interview in the order they are
+// asked, given what is known so far. A question whose answer the context
+// already holds from elsewhere (a template, a draft, an earlier submit) is
+// left out unless it was answered in this interview. The business domain
+// adds follow-ups, and join keys already declared or approved leave out the
+// join key question.
+func (iv *Interview) Questions(ctx1, ctx2 *models.Context, mapping *ApprovedMapping) []models.Question {
+	questions := []models.Question{}
+	add := func(q models.Question, fileIndex int, known bool) {
+		if _, answered := iv.answer(q.ID); answered || !known {
+			if q.Metadata == nil {
+				q.Metadata = map[string]interface{}{}
+			}
+			q.Metadata["file_index"] = fileIndex
+			questions = append(questions, q)
+		}
+	}
+
+	generator := &QuestionGenerator{}
+	for i, ctx := range []*models.Context{ctx1, ctx2} {
+		fileIndex := i + 1
+		if ctx == nil {
+			ctx = models.NewContext()
+		}
+		analysis := iv.analyses[i]
+
+		domainQ := domainQuestion(fileIndex, iv.Locale)
+		add(purposeQuestion(fileIndex, iv.Locale), fileIndex, ctx.DatasetPurpose != "")
+		add(domainQ, fileIndex, ctx.BusinessDomain != "")
+
+		domain := ctx.BusinessDomain
+		if a, ok := iv.answer(domainQ.ID); ok && !a.Skipped {
+			domain, _ = a.Answer.(string)
+			if option, ok := matchOption(domainQ, domain); ok {
+				domain = option
+			}
+		}
+		for _, q := range domainFollowUps(domain, fileIndex, iv.Locale) {
+			_, known := ctx.DomainDetails[q.Metadata["detail"].(string)]
+			add(q, fileIndex, known)
+		}
+
+		for _, q := range generator.heuristicQuestions(analysis, fileIndex, iv.Locale) {
+			known := false
+			switch q.Type {
+			case models.QuestionTypeKeyEntities:
+				known = len(ctx.KeyEntities) > 0
+			case models.QuestionTypeTemporalContext:
+				known = ctx.TemporalContext != ""
+			case models.QuestionTypeColumnSemantic:
+				known = true
+				for _, col := range q.Metadata["columns"].([]string) {
+					if ctx.ColumnDescriptions[col] == "" {
+						known = false
+					}
+				}
+			}
+			add(q, fileIndex, known)
+		}
+		add(exclusionsQuestion(analysis, fileIndex, iv.Locale), fileIndex, len(ctx.Exclusions) > 0)
+	}
+
+	relType, keys := DeclaredRelationship(ctx1, ctx2)
+	for _, q := range RelationshipQuestions(iv.headers[0], iv.headers[1], iv.Locale) {
+		known := relType != ""
+		if q.Type == models.QuestionTypeCustomMappings {
+			known = len(keys) > 0 || len(mapping.JoinKeys()) > 0
+		}
+		add(q, 0, known)
+	}
+	return questions
+}
+
+// Next returns the first question neither answered nor skipped (nil when
+// the interview is complete) and the progress
+func (iv *Interview) Next(ctx1, ctx2 *models.Context, mapping *ApprovedMapping) (*models.Question, InterviewProgress) {
+	var next *models.Question
+	progress := InterviewProgress{}
+	for _, q := range iv.Questions(ctx1, ctx2, mapping) {
+		a, ok := iv.answer(q.ID)
+		switch {
+		case ok && a.Skipped:
+			progress.Skipped++
+		case ok:
+			progress.Answered++
+		default:
+			progress.Remaining++
+			if next == nil {
+				q := q
+				next = &q
+			}
+		}
+	}
+	progress.Total = progress.Answered + progress.Skipped + progress.Remaining
+	progress.Complete = progress.Remaining == 0
+	if progress.Total > 0 {
+		progress.Percent = float64(progress.Answered+progress.Skipped) / float64(progress.Total) * 100
+	}
+	return next, progress
+}
+
+// domainFollowUps are the questions a business domain adds, in a locale.
+// Their answers are kept in the context's domain details under the "detail"
+// metadata key.
+func domainFollowUps(domain string, fileIndex int, locale string) []models.Question {
+	followUp := func(detail string, options []string, answerType string) models.Question {
+		return withOptionLabels(models.Question{
+			ID:         fmt.Sprintf("f%d_%s", fileIndex, detail),
+			Type:       models.QuestionTypeDomainDetail,
+			AnswerType: answerType,
+			Text:       message(locale, detail+".text"),
+			Options:    options,
+			Required:   false,
+			Metadata:   map[string]interface{}{"detail": detail, "input_type": answerType},
+		}, locale)
+	}
+
+	d := strings.ToLower(domain)
+	switch {
+	case strings.Contains(d, "health"):
+		return []models.Question{
+			followUp("coding_systems", []string{"ICD-10", "ICD-9", "CPT", "HCPCS", "LOINC", "SNOMED CT", "NDC", "Other"}, models.AnswerTypeMultiSelect),
+		}
+	case strings.Contains(d, "finance") || strings.Contains(d, "accounting"):
+		return []models.Question{
+			followUp("currency", []string{"USD", "EUR", "GBP", "JPY", "INR", "Mixed"}, models.AnswerTypeSelect),
+			followUp("fiscal_year_start", []string{"January", "April", "July", "October", "Other"}, models.AnswerTypeSelect),
+		}
+	case strings.Contains(d, "commerce") || strings.Contains(d, "sales"):
+		return []models.Question{
+			followUp("amount_basis", []string{"Gross", "Net of tax", "Net of discounts", "Net of tax and discounts", "Mixed"}, models.AnswerTypeSelect),
+		}
+	case strings.Contains(d, "human resources") || d == "hr":
+		return []models.Question{
+			followUp("workforce_scope", []string{"Active employees", "Active and former employees", "Employees and contractors"}, models.AnswerTypeSelect),
+		}
+	}
+	return nil
+}
+
+// ApplyContextAnswer stores a valid answer to a question in ctx; check it
+// with ValidateAnswer first. Options are stored as spelled in the question,
+// and join keys are resolved against the headers of both files.
+func ApplyContextAnswer(ctx *models.Context, q models.Question, answer interface{}, headers1, headers2 []string) error {
+	text, _ := answer.(string)
+	text = strings.TrimSpace(text)
+	if option, ok := matchOption(q, text); ok && answerType(q) == models.AnswerTypeSelect {
+		text = option
+	}
+	values := AnswerList(answer)
+	if answerType(q) == models.AnswerTypeMultiSelect {
+		for i, v := range values {
+			if option, ok := matchOption(q, v); ok {
+				values[i] = option
+			}
+		}
+	}
+
+	switch q.Type {
+	case models.QuestionTypeDatasetPurpose:
+		ctx.DatasetPurpose = text
+	case models.QuestionTypeBusinessDomain:
+		ctx.BusinessDomain = text
+	case models.QuestionTypeTemporalContext:
+		ctx.TemporalContext = text
+	case models.QuestionTypeKeyEntities:
+		ctx.KeyEntities = values
+	case models.QuestionTypeExclusions:
+		ctx.Exclusions = values
+	case models.QuestionTypeColumnSemantic:
+		descriptions, _ := answer.(map[string]interface{})
+		if ctx.ColumnDescriptions == nil {
+			ctx.ColumnDescriptions = make(map[string]string)
+		}
+		for col, desc := range descriptions {
+			if s, ok := desc.(string); ok {
+				ctx.ColumnDescriptions[col] = strings.TrimSpace(s)
+			}
+		}
+	case models.QuestionTypeDomainDetail:
+		if answerType(q) != models.AnswerTypeMultiSelect {
+			values = []string{text}
+		}
+		if ctx.DomainDetails == nil {
+			ctx.DomainDetails = make(map[string]string)
+		}
+		ctx.DomainDetails[q.Metadata["detail"].(string)] = strings.Join(values, ", ")
+	case models.QuestionTypeRelationships:
+		relType, err := ParseRelationshipType(text)
+		if err != nil {
+			return err
+		}
+		ctx.RelationshipType = relType
+	case models.QuestionTypeCustomMappings:
+		keys, err := ParseJoinKeys(values, headers1, headers2)
+		if err != nil {
+			return err
+		}
+		ctx.JoinKeys = keys
+	default:
+		return fmt.Errorf("questions of type %q are not stored in the context", q.Type)
+	}
+	ctx.UpdatedAt = time.Now().Format(time.RFC3339)
+	return nil
+}
+
+// AnswerList reads a multi-valued answer, either a list of strings or one
+// comma-separated string
+func AnswerList(answer interface{}) []string {
+	values := []string{}
+	switch a := answer.(type) {
+	case string:
+		for _, v := range strings.Split(a, ",") {
+			if v = strings.TrimSpace(v); v != "" {
+				values = append(values, v)
+			}
+		}
+	case []interface{}:
+		for _, e := range a {
+			if v, ok := e.(string); ok && strings.TrimSpace(v) != "" {
+				values = append(values, strings.TrimSpace(v))
+			}
+		}
+	}
+	return values
+}
+
+func newInterviewID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "iv_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "iv_" + hex.EncodeToString(b)
+}
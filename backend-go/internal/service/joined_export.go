@@ -0,0 +1,280 @@
+package service
+
+import (
+	"backend-go/internal/state"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Join types supported by JoinDataFrames
+const (
+	JoinInner = "inner"
+	JoinLeft  = "left"
+	JoinRight = "right"
+	JoinOuter = "outer"
+)
+
+// Conflict resolution when both files supply a mapped attribute
+const (
+	ConflictKeepBoth    = "keep_both"    // File 1 column plus a file2_ prefixed copy
+	ConflictPreferFile1 = "prefer_file1" // File 1 value, even when empty
+	ConflictPreferFile2 = "prefer_file2" // File 2 value, even when empty
+	ConflictCoalesce    = "coalesce"     // File 1 value, or file 2 when file 1 is empty
+)
+
+// Joined data output formats
+const (
+	DataFormatCSV     = "csv"
+	DataFormatParquet = "parquet"
+)
+
+// JoinOptions control JoinDataFrames
+type JoinOptions struct {
+	JoinType string   `json:"join_type"` // JoinInner (default), JoinLeft, JoinRight or JoinOuter
+	Conflict string   `json:"conflict"`  // ConflictKeepBoth (default), ConflictPreferFile1, ConflictPreferFile2 or ConflictCoalesce
+	Columns  []string `json:"columns"`   // Output columns to keep, in order; empty = all
+}
+
+// Validate fills defaults and checks option values
+func (o *JoinOptions) Validate() error {
+	switch o.JoinType {
+	case "":
+		o.JoinType = JoinInner
+	case JoinInner, JoinLeft, JoinRight, JoinOuter:
+	default:
+		return fmt.Errorf("unknown join_type %q (use inner, left, right or outer)", o.JoinType)
+	}
+	switch o.Conflict {
+	case "":
+		o.Conflict = ConflictKeepBoth
+	case ConflictKeepBoth, ConflictPreferFile1, ConflictPreferFile2, ConflictCoalesce:
+	default:
+		return fmt.Errorf("unknown conflict %q (use keep_both, prefer_file1, prefer_file2 or coalesce)", o.Conflict)
+	}
+	return nil
+}
+
+// joinedColumn is one output column: a file 1 column, a file 2 column, or
+// both when the mapping says they hold the same attribute
+type joinedColumn struct {
+	name       string
+	idx1, idx2 int  // -1 when the file doesn't supply the column
+	key        bool // Join keys are always coalesced
+}
+
+// JoinDataFrames joins df1 and df2 on the approved join keys (values compared
+// trimmed and case-insensitively) and calls emit for every output row, in
+// file 1 order followed by unmatched file 2 rows for right and outer joins.
+// Accepted non-key mappings are merged into one column per opts.Conflict.
+// onHeaders receives the output headers before the first row.
+func JoinDataFrames(df1, df2 *state.DataFrame, mapping *ApprovedMapping, opts JoinOptions, onHeaders func([]string) error, emit func([]string) error) error {
+	if df1 == nil || df2 == nil {
+		return fmt.Errorf("both files must be loaded")
+	}
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	keys := mapping.JoinKeys()
+	if len(keys) == 0 {
+		return fmt.Errorf("no approved join keys; accept a mapping first")
+	}
+
+	idx1 := make([]int, len(keys))
+	idx2 := make([]int, len(keys))
+	isKey := make(map[[2]string]bool)
+	for i, k := range keys {
+		idx1[i] = columnIndex(df1, k.File1Column)
+		idx2[i] = columnIndex(df2, k.File2Column)
+		if idx1[i] < 0 || idx2[i] < 0 {
+			return fmt.Errorf("mapping %s -> %s doesn't match the loaded files", k.File1Column, k.File2Column)
+		}
+		isKey[[2]string{k.File1Column, k.File2Column}] = true
+	}
+
+	columns := joinedColumns(df1, df2, mapping.Accepted(), isKey, opts.Conflict)
+	if len(opts.Columns) > 0 {
+		byName := make(map[string]joinedColumn, len(columns))
+		for _, c := range columns {
+			byName[c.name] = c
+		}
+		selected := make([]joinedColumn, 0, len(opts.Columns))
+		for _, name := range opts.Columns {
+			c, ok := byName[name]
+			if !ok {
+				return fmt.Errorf("column %q is not in the joined output", name)
+			}
+			selected = append(selected, c)
+		}
+		columns = selected
+	}
+
+	headers := make([]string, len(columns))
+	for i, c := range columns {
+		headers[i] = c.name
+	}
+	if err := onHeaders(headers); err != nil {
+		return err
+	}
+
+	// Index file 2 rows by key
+	index := make(map[string][]int)
+	for r, row := range df2.Rows {
+		if key, ok := joinKey(row, idx2); ok {
+			index[key] = append(index[key], r)
+		}
+	}
+
+	keepLeft := opts.JoinType == JoinLeft || opts.JoinType == JoinOuter
+	keepRight := opts.JoinType == JoinRight || opts.JoinType == JoinOuter
+	matched2 := make([]bool, len(df2.Rows))
+
+	for _, row1 := range df1.Rows {
+		var matches []int
+		if key, ok := joinKey(row1, idx1); ok {
+			matches = index[key]
+		}
+		if len(matches) == 0 {
+			if keepLeft {
+				if err := emit(joinRow(columns, row1, nil, opts.Conflict)); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		for _, r := range matches {
+			matched2[r] = true
+			if err := emit(joinRow(columns, row1, df2.Rows[r], opts.Conflict)); err != nil {
+				return err
+			}
+		}
+	}
+
+	if keepRight {
+		for r, row2 := range df2.Rows {
+			if matched2[r] {
+				continue
+			}
+			if err := emit(joinRow(columns, nil, row2, opts.Conflict)); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// ErrJoinTooLarge is returned by JoinedFrame when the join has more rows than allowed
+var ErrJoinTooLarge = errors.New("joined data is too large")
+
+// JoinedFrame joins df1 and df2 like JoinDataFrames into an in-memory frame.
+// A key matching several rows in the other file repeats rows, so the join can
+// be far larger than either file; beyond maxRows rows (0 = no limit) it stops
+// with ErrJoinTooLarge.
+func JoinedFrame(df1, df2 *state.DataFrame, mapping *ApprovedMapping, opts JoinOptions, maxRows int) (*state.DataFrame, error) {
+	joined := &state.DataFrame{FileName: "joined"}
+	err := JoinDataFrames(df1, df2, mapping, opts,
+		func(hs []string) error { joined.Headers = hs; return nil },
+		func(row []string) error {
+			if maxRows > 0 && len(joined.Rows) >= maxRows {
+				return fmt.Errorf("%w: more than %d rows", ErrJoinTooLarge, maxRows)
+			}
+			joined.Rows = append(joined.Rows, row)
+			return nil
+		})
+	if err != nil {
+		return nil, err
+	}
+	joined.BuildColumns()
+	return joined, nil
+}
+
+// joinedColumns lays out the output: file 1 columns (merged with their mapped
+// file 2 column), then the unmapped file 2 columns. File 2 names that clash
+// get a file2_ prefix, as in the SQL export.
+func joinedColumns(df1, df2 *state.DataFrame, accepted []ColumnMapping, isKey map[[2]string]bool, conflict string) []joinedColumn {
+	mappedTo := make(map[string]string) // File 1 column -> file 2 column
+	mapped2 := make(map[string]bool)
+	for _, m := range accepted {
+		if _, dup := mappedTo[m.File1Column]; dup || mapped2[m.File2Column] {
+			continue
+		}
+		mappedTo[m.File1Column] = m.File2Column
+		mapped2[m.File2Column] = true
+	}
+
+	taken := make(map[string]bool)
+	columns := []joinedColumn{}
+	for i, h := range df1.Headers {
+		taken[h] = true
+		c := joinedColumn{name: h, idx1: i, idx2: -1}
+		col2, ok := mappedTo[h]
+		if ok {
+			c.idx2 = columnIndex(df2, col2)
+			c.key = isKey[[2]string{h, col2}]
+		}
+		if ok && !c.key && conflict == ConflictKeepBoth && c.idx2 >= 0 {
+			// File 1 values here, the file 2 copy right after
+			columns = append(columns, joinedColumn{name: h, idx1: i, idx2: -1})
+			columns = append(columns, joinedColumn{name: "file2_" + col2, idx1: -1, idx2: c.idx2})
+			continue
+		}
+		columns = append(columns, c)
+	}
+	for i, h := range df2.Headers {
+		if mapped2[h] {
+			continue
+		}
+		name := h
+		if taken[name] {
+			name = "file2_" + h
+		}
+		taken[name] = true
+		columns = append(columns, joinedColumn{name: name, idx1: -1, idx2: i})
+	}
+
+	// Keep-both copies may clash with file 1 names too
+	seen := make(map[string]int)
+	for i := range columns {
+		seen[columns[i].name]++
+		if n := seen[columns[i].name]; n > 1 {
+			columns[i].name = fmt.Sprintf("%s_%d", columns[i].name, n)
+		}
+	}
+	return columns
+}
+
+// joinRow builds one output row; row1 or row2 is nil for unmatched rows
+func joinRow(columns []joinedColumn, row1, row2 []string, conflict string) []string {
+	out := make([]string, len(columns))
+	for i, c := range columns {
+		v1, ok1 := cellAt(row1, c.idx1)
+		v2, ok2 := cellAt(row2, c.idx2)
+		switch {
+		case !ok2:
+			out[i] = v1
+		case !ok1:
+			out[i] = v2
+		case c.key || conflict == ConflictCoalesce:
+			out[i] = v1
+			if strings.TrimSpace(v1) == "" {
+				out[i] = v2
+			}
+		case conflict == ConflictPreferFile2:
+			out[i] = v2
+		default:
+			out[i] = v1
+		}
+	}
+	return out
+}
+
+// cellAt returns row[idx]; ok is false when the row or column is absent
+func cellAt(row []string, idx int) (string, bool) {
+	if row == nil || idx < 0 {
+		return "", false
+	}
+	if idx >= len(row) {
+		return "", true
+	}
+	return row[idx], true
+}
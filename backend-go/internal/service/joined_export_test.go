@@ -0,0 +1,30 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"errors"
+	"testing"
+)
+
+func TestJoinedFrameMaxRows(t *testing.T) {
+	df1 := &state.DataFrame{Headers: []string{"id", "amount"}, Rows: [][]string{{"1", "10"}, {"2", "20"}}}
+	df2 := &state.DataFrame{Headers: []string{"id", "tag"}, Rows: [][]string{{"1", "a"}, {"1", "b"}, {"1", "c"}, {"2", "d"}}}
+	mapping := &ApprovedMapping{Mappings: []ColumnMapping{
+		{File1Column: "id", File2Column: "id", Status: models.MappingAccepted, JoinKey: true},
+	}}
+	opts := JoinOptions{JoinType: JoinLeft}
+
+	// Key 1 matches three file 2 rows, so file 1's first row appears three times
+	joined, err := JoinedFrame(df1, df2, mapping, opts, 4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(joined.Rows) != 4 {
+		t.Errorf("joined rows = %d, want 4", len(joined.Rows))
+	}
+
+	if _, err := JoinedFrame(df1, df2, mapping, opts, 3); !errors.Is(err, ErrJoinTooLarge) {
+		t.Errorf("join over the limit: err = %v, want ErrJoinTooLarge", err)
+	}
+}
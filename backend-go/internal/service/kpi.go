@@ -0,0 +1,310 @@
+package service
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var kpiLog = logging.Component("kpis")
+
+const kpiConfigFile = "kpis.json"
+
+// DefaultWorkspace holds the KPI definitions of requests that don't name a workspace
+const DefaultWorkspace = "default"
+
+// KPI display formats
+const (
+	KPIFormatNumber   = "number"   // Two decimals
+	KPIFormatInteger  = "integer"  // Rounded
+	KPIFormatPercent  = "percent"  // Ratio shown as a percentage
+	KPIFormatCurrency = "currency" // Two decimals and the currency code
+)
+
+// KPIDefinition describes one KPI: an aggregation of a column over the rows
+// passing the filters
+type KPIDefinition struct {
+	Name        string                 `json:"name"` // Unique within the workspace
+	Label       string                 `json:"label,omitempty"`
+	Column      string                 `json:"column,omitempty"` // May be empty for count
+	Aggregation string                 `json:"aggregation"`      // count, distinct, sum, mean, min or max
+	Format      string                 `json:"format,omitempty"` // number (default), integer, percent or currency
+	Currency    string                 `json:"currency,omitempty"`
+	Filters     []analysis.QueryFilter `json:"filters,omitempty"`
+	FileIndex   int                    `json:"file_index,omitempty"` // 1 or 2; 0 = whichever file is requested
+}
+
+// KPIStore keeps KPI definitions per workspace
+type KPIStore struct {
+	workspaces map[string][]KPIDefinition
+	mutex      sync.RWMutex
+}
+
+var (
+	kpiStore     *KPIStore
+	kpiStoreOnce sync.Once
+)
+
+// GetKPIStore returns the singleton KPI store
+func GetKPIStore() *KPIStore {
+	kpiStoreOnce.Do(func() {
+		kpiStore = &KPIStore{
+			workspaces: make(map[string][]KPIDefinition),
+		}
+		kpiStore.load()
+	})
+	return kpiStore
+}
+
+// load loads KPI definitions from file
+func (s *KPIStore) load() {
+	data, err := os.ReadFile(config.DataPath(kpiConfigFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			kpiLog.Error("Error loading KPI definitions", "error", err)
+		}
+		return
+	}
+
+	var saved map[string][]KPIDefinition
+	if err := json.Unmarshal(data, &saved); err != nil {
+		kpiLog.Error("Error parsing KPI definitions", "error", err)
+		return
+	}
+	s.mutex.Lock()
+	for ws, defs := range saved {
+		s.workspaces[ws] = defs
+	}
+	s.mutex.Unlock()
+
+	kpiLog.Info("Loaded KPI definitions", "workspaces", len(saved))
+}
+
+// save persists KPI definitions to file
+func (s *KPIStore) save() error {
+	s.mutex.RLock()
+	data, err := json.MarshalIndent(s.workspaces, "", "  ")
+	s.mutex.RUnlock()
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(kpiConfigFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(kpiConfigFile), data, 0644)
+}
+
+// Get returns the KPI definitions of a workspace; ok is false when none are configured
+func (s *KPIStore) Get(workspace string) ([]KPIDefinition, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	defs, ok := s.workspaces[workspaceName(workspace)]
+	return append([]KPIDefinition(nil), defs...), ok
+}
+
+// Set validates and replaces the KPI definitions of a workspace; nil
+// definitions remove the configuration, restoring the defaults
+func (s *KPIStore) Set(workspace string, defs []KPIDefinition) ([]KPIDefinition, error) {
+	workspace = workspaceName(workspace)
+	seen := make(map[string]bool)
+	for i := range defs {
+		d := &defs[i]
+		d.Name = strings.TrimSpace(d.Name)
+		if d.Name == "" {
+			return nil, fmt.Errorf("KPI %d: name is required", i+1)
+		}
+		if seen[d.Name] {
+			return nil, fmt.Errorf("KPI %q is defined twice", d.Name)
+		}
+		seen[d.Name] = true
+		if d.Aggregation == "" {
+			d.Aggregation = analysis.AggSum
+		}
+		switch d.Aggregation {
+		case analysis.AggCount:
+		case analysis.AggDistinct, analysis.AggSum, analysis.AggMean, analysis.AggMin, analysis.AggMax:
+			if d.Column == "" {
+				return nil, fmt.Errorf("KPI %q: aggregation %s needs a column", d.Name, d.Aggregation)
+			}
+		default:
+			return nil, fmt.Errorf("KPI %q: unknown aggregation %q", d.Name, d.Aggregation)
+		}
+		switch d.Format {
+		case "":
+			d.Format = KPIFormatNumber
+		case KPIFormatNumber, KPIFormatInteger, KPIFormatPercent, KPIFormatCurrency:
+		default:
+			return nil, fmt.Errorf("KPI %q: unknown format %q", d.Name, d.Format)
+		}
+		if d.FileIndex < 0 || d.FileIndex > 2 {
+			return nil, fmt.Errorf("KPI %q: file_index must be 1 or 2", d.Name)
+		}
+	}
+
+	s.mutex.Lock()
+	if defs == nil {
+		delete(s.workspaces, workspace)
+		kpiLog.Info("Reset KPI definitions", "workspace", workspace)
+	} else {
+		s.workspaces[workspace] = defs
+		kpiLog.Info("Saved KPI definitions", "workspace", workspace, "count", len(defs))
+	}
+	s.mutex.Unlock()
+
+	return defs, s.save()
+}
+
+// Workspaces lists the workspaces with KPI definitions
+func (s *KPIStore) Workspaces() []string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	names := make([]string, 0, len(s.workspaces))
+	for ws := range s.workspaces {
+		names = append(names, ws)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func workspaceName(workspace string) string {
+	if workspace = strings.TrimSpace(workspace); workspace == "" {
+		return DefaultWorkspace
+	}
+	return workspace
+}
+
+// Column names of identifiers, codes and calendar parts
+var (
+	nonMeasureName = regexp.MustCompile(`(?i)(^id$|_id$|^id_|uuid|key$|code|zip|postal|phone|year|month|^day$|week|quarter|number$|_no$|^no$)`)
+	camelCaseID    = regexp.MustCompile(`[a-z](Id|ID)$`)
+)
+
+// DefaultKPIs sums the measure-like numeric columns of a file, skipping
+// identifiers, codes and years where a sum means nothing
+func DefaultKPIs(df *state.DataFrame) []KPIDefinition {
+	defs := []KPIDefinition{}
+	for _, col := range df.Columns() {
+		if col.Type != state.ColumnFloat || col.FloatCount == 0 || !isMeasureColumn(col) {
+			continue
+		}
+		defs = append(defs, KPIDefinition{Name: col.Name, Column: col.Name, Aggregation: analysis.AggSum, Format: KPIFormatNumber})
+	}
+	return defs
+}
+
+// isMeasureColumn rejects columns named like identifiers, integer columns
+// whose values are all distinct (keys) and integer columns in the year range
+func isMeasureColumn(col *state.Column) bool {
+	if nonMeasureName.MatchString(col.Name) || camelCaseID.MatchString(col.Name) {
+		return false
+	}
+	values := col.FloatValues()
+	integers, years := true, true
+	distinct := make(map[float64]bool, len(values))
+	for _, v := range values {
+		if v != math.Trunc(v) {
+			integers, years = false, false
+			break
+		}
+		if v < 1900 || v > 2100 {
+			years = false
+		}
+		distinct[v] = true
+	}
+	if integers && (years || len(values) > 1 && len(distinct) == len(values)) {
+		return false
+	}
+	return true
+}
+
+// EvaluateKPIs computes the KPI definitions over a file; definitions bound
+// to the other file are skipped, and ones that don't fit the file report an error
+func EvaluateKPIs(df *state.DataFrame, fileIndex int, defs []KPIDefinition) []models.KPI {
+	kpis := []models.KPI{}
+	for _, d := range defs {
+		if d.FileIndex != 0 && d.FileIndex != fileIndex {
+			continue
+		}
+		kpi := models.KPI{Name: d.Name, Label: d.Label, Type: d.Aggregation, Format: d.Format}
+		if kpi.Label == "" {
+			kpi.Label = d.Name
+		}
+
+		plan := analysis.QueryPlan{
+			Filters:      d.Filters,
+			Aggregations: []analysis.QueryAggregation{{Column: d.Column, Function: d.Aggregation}},
+		}
+		if d.Column != "" {
+			plan.Aggregations = append(plan.Aggregations, analysis.QueryAggregation{Column: d.Column, Function: analysis.AggMean})
+		}
+		result, err := analysis.ExecutePlan(df.Headers, df.Rows, &plan)
+		if err != nil {
+			kpi.Error = err.Error()
+			kpis = append(kpis, kpi)
+			continue
+		}
+		row := result.Rows[0]
+		if v, ok := row[plan.Aggregations[0].Name()].(float64); ok {
+			kpi.Value = v
+		}
+		if len(plan.Aggregations) > 1 {
+			if v, ok := row[plan.Aggregations[1].Name()].(float64); ok {
+				kpi.Avg = v
+			}
+		}
+		kpi.Display = formatKPI(kpi.Value, d.Format, d.Currency)
+		kpis = append(kpis, kpi)
+	}
+	return kpis
+}
+
+// formatKPI renders a KPI value for display
+func formatKPI(v float64, format, currency string) string {
+	switch format {
+	case KPIFormatInteger:
+		return groupThousands(strconv.FormatFloat(math.Round(v), 'f', 0, 64))
+	case KPIFormatPercent:
+		return strconv.FormatFloat(v*100, 'f', 1, 64) + "%"
+	case KPIFormatCurrency:
+		if currency == "" {
+			currency = "USD"
+		}
+		return groupThousands(strconv.FormatFloat(v, 'f', 2, 64)) + " " + strings.ToUpper(currency)
+	}
+	return groupThousands(strconv.FormatFloat(v, 'f', 2, 64))
+}
+
+// groupThousands inserts commas into the integer part of a formatted number
+func groupThousands(s string) string {
+	sign := ""
+	if strings.HasPrefix(s, "-") {
+		sign, s = "-", s[1:]
+	}
+	intPart, frac := s, ""
+	if i := strings.IndexByte(s, '.'); i >= 0 {
+		intPart, frac = s[:i], s[i:]
+	}
+	var b strings.Builder
+	for i, c := range intPart {
+		if i > 0 && (len(intPart)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	return sign + b.String() + frac
+}
@@ -0,0 +1,194 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+var learningLog = logging.Component("learning")
+
+// Merge strategies for importing learned knowledge
+const (
+	MergeStrategyMerge     = "merge"      // Pool evidence from both sides
+	MergeStrategyReplace   = "replace"    // Incoming bundle overwrites local state
+	MergeStrategyKeepLocal = "keep_local" // Only add items that don't exist locally
+)
+
+// LearningBundleVersion is bumped when the bundle format changes
+const LearningBundleVersion = 1
+
+// LearningBundle holds everything the learning systems know, for sharing between instances
+type LearningBundle struct {
+	Version            int                     `json:"version"`
+	ExportedAt         time.Time               `json:"exported_at"`
+	Feedback           FeedbackData            `json:"feedback"`
+	PatternRules       []PatternRule           `json:"pattern_rules"`
+	TokenMappings      map[string]TokenMapping `json:"token_mappings"`
+	AdaptiveWeights    AdaptiveWeights         `json:"adaptive_weights"`
+	TrainingHistory    []TrainingHistoryEntry  `json:"training_history"`
+	CalibrationBuckets []CalibrationBucket     `json:"calibration_buckets"`
+}
+
+// ImportSummary reports what an import changed
+type ImportSummary struct {
+	Strategy           string `json:"strategy"`
+	BundleID           string `json:"bundle_id"`
+	AlreadyImported    bool   `json:"already_imported"` // Merged before; nothing changed
+	FeedbackAdded      int    `json:"feedback_added"`
+	PatternRulesAdded  int    `json:"pattern_rules_added"`
+	TokenMappingsAdded int    `json:"token_mappings_added"`
+	WeightsUpdated     bool   `json:"weights_updated"`
+	CalibrationUpdated bool   `json:"calibration_updated"`
+}
+
+// ExportLearningBundle collects the state of all learning systems
+func ExportLearningBundle() *LearningBundle {
+	adaptiveLearner := GetAdaptiveLearner()
+
+	return &LearningBundle{
+		Version:            LearningBundleVersion,
+		ExportedAt:         time.Now(),
+		Feedback:           GetFeedbackSystem().Snapshot(),
+		PatternRules:       GetPatternLearner().GetPatterns(),
+		TokenMappings:      GetPatternLearner().GetTokenMappings(),
+		AdaptiveWeights:    adaptiveLearner.GetWeights(),
+		TrainingHistory:    adaptiveLearner.GetTrainingHistory(),
+		CalibrationBuckets: GetConfidenceCalibrator().GetBuckets(),
+	}
+}
+
+// ImportLearningBundle merges a bundle into the local learning systems
+func ImportLearningBundle(bundle *LearningBundle, strategy string) (*ImportSummary, error) {
+	if bundle == nil {
+		return nil, fmt.Errorf("empty bundle")
+	}
+	if bundle.Version > LearningBundleVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d (max %d)", bundle.Version, LearningBundleVersion)
+	}
+
+	switch strategy {
+	case "":
+		strategy = MergeStrategyMerge
+	case MergeStrategyMerge, MergeStrategyReplace, MergeStrategyKeepLocal:
+	default:
+		return nil, fmt.Errorf("unknown strategy %q (use merge, replace or keep_local)", strategy)
+	}
+
+	// Check everything up front so a bad bundle leaves every learner untouched.
+	// All-zero weights mean the bundle carries none.
+	n := len(bundle.CalibrationBuckets)
+	if n > 0 && n != 10 {
+		return nil, fmt.Errorf("expected 10 calibration buckets, got %d", n)
+	}
+	hasWeights := bundle.AdaptiveWeights != AdaptiveWeights{}
+	if hasWeights {
+		if _, err := NormalizeWeights(bundle.AdaptiveWeights); err != nil {
+			return nil, fmt.Errorf("invalid adaptive weights: %w", err)
+		}
+	}
+
+	id, err := bundleID(bundle)
+	if err != nil {
+		return nil, err
+	}
+	importedBundlesMutex.Lock()
+	defer importedBundlesMutex.Unlock()
+	imported := loadImportedBundles()
+
+	summary := &ImportSummary{Strategy: strategy, BundleID: id}
+	// Merging pools counts, so a bundle merged before would count twice;
+	// replace and keep_local are repeatable as they are
+	if strategy == MergeStrategyMerge && imported[id] {
+		summary.AlreadyImported = true
+		return summary, nil
+	}
+
+	if len(bundle.CalibrationBuckets) > 0 {
+		if err := GetConfidenceCalibrator().Merge(bundle.CalibrationBuckets, strategy); err != nil {
+			return nil, err
+		}
+		summary.CalibrationUpdated = strategy != MergeStrategyKeepLocal
+	}
+
+	summary.FeedbackAdded = GetFeedbackSystem().Merge(bundle.Feedback, strategy)
+	summary.PatternRulesAdded, summary.TokenMappingsAdded = GetPatternLearner().Merge(
+		bundle.PatternRules, bundle.TokenMappings, strategy)
+
+	if hasWeights {
+		if err := GetAdaptiveLearner().Merge(bundle.AdaptiveWeights, bundle.TrainingHistory, strategy); err != nil {
+			return nil, err
+		}
+		summary.WeightsUpdated = strategy != MergeStrategyKeepLocal
+	}
+
+	imported[id] = true
+	if err := saveImportedBundles(imported); err != nil {
+		learningLog.Error("Error saving imported bundle IDs", "error", err)
+	}
+	return summary, nil
+}
+
+// importedBundlesFile holds the IDs of the bundles imported
+const importedBundlesFile = "imported_bundles.json"
+
+var importedBundlesMutex sync.Mutex
+
+// bundleID identifies a bundle by its content, leaving out the export
+// time, so exports of the same state share an ID
+func bundleID(bundle *LearningBundle) (string, error) {
+	content := *bundle
+	content.ExportedAt = time.Time{}
+	data, err := json.Marshal(content)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// loadImportedBundles returns the set of imported bundle IDs (must hold
+// importedBundlesMutex)
+func loadImportedBundles() map[string]bool {
+	imported := map[string]bool{}
+	data, err := os.ReadFile(config.DataPath(importedBundlesFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			learningLog.Error("Error loading imported bundle IDs", "error", err)
+		}
+		return imported
+	}
+	var ids []string
+	if err := json.Unmarshal(data, &ids); err != nil {
+		learningLog.Error("Error parsing imported bundle IDs", "error", err)
+		return imported
+	}
+	for _, id := range ids {
+		imported[id] = true
+	}
+	return imported
+}
+
+// saveImportedBundles writes the imported bundle IDs (must hold
+// importedBundlesMutex)
+func saveImportedBundles(imported map[string]bool) error {
+	ids := make([]string, 0, len(imported))
+	for id := range imported {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	data, err := json.MarshalIndent(ids, "", "  ")
+	if err != nil {
+		return err
+	}
+	os.MkdirAll(filepath.Dir(config.DataPath(importedBundlesFile)), 0755)
+	return os.WriteFile(config.DataPath(importedBundlesFile), data, 0644)
+}
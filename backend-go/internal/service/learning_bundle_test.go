@@ -0,0 +1,81 @@
+package service
+
+import "testing"
+
+func TestImportLearningBundleTwice(t *testing.T) {
+	buckets := initializeBuckets()
+	buckets[7].TotalCount, buckets[7].CorrectCount = 10, 8
+	bundle := &LearningBundle{
+		Version: LearningBundleVersion,
+		Feedback: FeedbackData{
+			Corrections: map[string]Correction{
+				"cust_id": {Suggested: "customer_name", Correct: "customer_id", Count: 3},
+			},
+		},
+		CalibrationBuckets: buckets,
+	}
+
+	first, err := ImportLearningBundle(bundle, MergeStrategyMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first.AlreadyImported {
+		t.Fatal("first import reported as a repeat")
+	}
+	calibration := GetConfidenceCalibrator().GetBuckets()[7]
+	correction := GetFeedbackSystem().Snapshot().Corrections["cust_id"]
+
+	second, err := ImportLearningBundle(bundle, MergeStrategyMerge)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.AlreadyImported || second.BundleID != first.BundleID {
+		t.Errorf("second import: already_imported=%v, bundle_id %s, want true and %s", second.AlreadyImported, second.BundleID, first.BundleID)
+	}
+	if got := GetConfidenceCalibrator().GetBuckets()[7]; got.TotalCount != calibration.TotalCount || got.CorrectCount != calibration.CorrectCount {
+		t.Errorf("calibration counts after a repeat import: %d/%d, want %d/%d", got.CorrectCount, got.TotalCount, calibration.CorrectCount, calibration.TotalCount)
+	}
+	if got := GetFeedbackSystem().Snapshot().Corrections["cust_id"].Count; got != correction.Count {
+		t.Errorf("correction count after a repeat import: %d, want %d", got, correction.Count)
+	}
+
+	// A later export of the same state is the same bundle
+	later := *bundle
+	later.ExportedAt = later.ExportedAt.AddDate(0, 0, 1)
+	if again, _ := ImportLearningBundle(&later, MergeStrategyMerge); !again.AlreadyImported {
+		t.Error("re-export with another time was merged again")
+	}
+}
+
+func TestImportLearningBundleRejectsBadWeights(t *testing.T) {
+	buckets := initializeBuckets()
+	buckets[2].TotalCount, buckets[2].CorrectCount = 40, 1
+	for _, w := range []AdaptiveWeights{
+		{Name: -0.5, Data: 1, Pattern: 0.3, LLM: 0.2},
+		{Name: -1, Data: 1},
+	} {
+		before := ExportLearningBundle()
+		bundle := &LearningBundle{
+			Version: LearningBundleVersion,
+			Feedback: FeedbackData{
+				Corrections: map[string]Correction{
+					"bad_weights_col": {Suggested: "x", Correct: "y", Count: 1},
+				},
+			},
+			CalibrationBuckets: buckets,
+			AdaptiveWeights:    w,
+		}
+		if _, err := ImportLearningBundle(bundle, MergeStrategyReplace); err == nil {
+			t.Fatalf("weights %+v were accepted", w)
+		}
+		if got := GetAdaptiveLearner().GetWeights(); got != before.AdaptiveWeights {
+			t.Errorf("weights changed to %+v by a rejected bundle", got)
+		}
+		if got := GetConfidenceCalibrator().GetBuckets()[2]; got.TotalCount != before.CalibrationBuckets[2].TotalCount {
+			t.Errorf("calibration changed by a rejected bundle: %d, want %d", got.TotalCount, before.CalibrationBuckets[2].TotalCount)
+		}
+		if _, ok := GetFeedbackSystem().Snapshot().Corrections["bad_weights_col"]; ok {
+			t.Error("feedback merged from a rejected bundle")
+		}
+	}
+}
@@ -0,0 +1,262 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"crypto/rand"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// Lineage targets, one per kind of generated export
+const (
+	LineageTargetSQL    = "sql"
+	LineageTargetPython = "python"
+)
+
+// OpenLineage namespace of jobs and datasets, and the producer URI
+const (
+	lineageNamespace = "project-euler"
+	lineageProducer  = "urn:project-euler:backend-go"
+)
+
+// ExportLineage is the column lineage of the last generated export of a target
+type ExportLineage struct {
+	Target      string                 `json:"target"`  // LineageTargetSQL or LineageTargetPython
+	Variant     string                 `json:"variant"` // SQL dialect or Python engine
+	Output      string                 `json:"output"`  // View, query or dataframe the columns belong to
+	GeneratedAt time.Time              `json:"generated_at"`
+	Columns     []models.ColumnLineage `json:"columns"`
+}
+
+// lineageLayout describes how an engine names the columns of a join
+type lineageLayout struct {
+	mergeEqualKeys bool   // Key pairs with the same name become one column
+	dropRightKeys  bool   // File 2 key columns aren't in the output
+	leftSuffix     string // Added to clashing file 1 names
+	rightSuffix    string // Added to clashing file 2 names
+	rightPrefix    string // Added to clashing file 2 names
+}
+
+var lineageLayouts = map[string]lineageLayout{
+	PythonEnginePandas:  {mergeEqualKeys: true, leftSuffix: "_x", rightSuffix: "_y"},
+	PythonEnginePySpark: {},
+	PythonEnginePolars:  {dropRightKeys: true, rightSuffix: "_file2"},
+}
+
+// exportHeaders returns the column names of one file: from the loaded file
+// when available, otherwise from the graph nodes
+func exportHeaders(graph *models.SimilarityGraph, df *state.DataFrame, fileIndex int) []string {
+	if df != nil {
+		return df.Headers
+	}
+	group := fmt.Sprintf("File %d", fileIndex)
+	headers := []string{}
+	for _, n := range graph.Nodes {
+		if n.Group == group {
+			headers = append(headers, n.Label)
+		}
+	}
+	return headers
+}
+
+// buildLineage traces every output column of a join of headers1 and headers2
+// on pairs back to its source columns. Pairs joined through a format
+// transform keep both columns, whatever the layout.
+func buildLineage(headers1, headers2 []string, pairs [][2]string, normalized map[[2]string]*models.ColumnTransform, mapping *ApprovedMapping, layout lineageLayout) []models.ColumnLineage {
+	transforms := make(map[string]string)
+	for _, m := range mapping.Accepted() {
+		if m.Transform != "" {
+			transforms[m.File1Column] = m.Transform
+		}
+	}
+	key1 := make(map[string]string) // File 1 key -> file 2 key
+	key2 := make(map[string]bool)
+	kept := make(map[string]bool) // File 1 keys whose file 2 key survives
+	for _, p := range pairs {
+		key1[p[0]] = p[1]
+		key2[p[1]] = true
+		if normalized[p] != nil {
+			kept[p[0]] = true
+		}
+	}
+
+	keptRight := make(map[string]bool)
+	for h := range kept {
+		keptRight[key1[h]] = true
+	}
+
+	// Columns of file 2 that survive the join, and the names both sides use
+	merged := make(map[string]bool) // File 2 keys folded into the file 1 column
+	right := []string{}
+	names1 := make(map[string]bool)
+	for _, h := range headers1 {
+		names1[h] = true
+		if k, ok := key1[h]; ok && layout.mergeEqualKeys && k == h && !kept[h] {
+			merged[k] = true
+		}
+	}
+	names2 := make(map[string]bool)
+	for _, h := range headers2 {
+		if merged[h] || (layout.dropRightKeys && key2[h] && !keptRight[h]) {
+			continue
+		}
+		right = append(right, h)
+		names2[h] = true
+	}
+
+	columns := make([]models.ColumnLineage, 0, len(headers1)+len(right))
+	for _, h := range headers1 {
+		name := h
+		if names2[h] && !merged[h] {
+			name += layout.leftSuffix
+		}
+		col := models.ColumnLineage{
+			Column:    name,
+			Sources:   []models.LineageSource{{FileIndex: 1, Column: h}},
+			Transform: transforms[h],
+		}
+		if k, ok := key1[h]; ok {
+			col.JoinKey = true
+			if merged[k] || (layout.dropRightKeys && !kept[h]) {
+				col.Sources = append(col.Sources, models.LineageSource{FileIndex: 2, Column: k})
+			}
+		}
+		columns = append(columns, col)
+	}
+	for _, h := range right {
+		name := h
+		if names1[h] {
+			name = layout.rightPrefix + h + layout.rightSuffix
+		}
+		columns = append(columns, models.ColumnLineage{
+			Column:  name,
+			Sources: []models.LineageSource{{FileIndex: 2, Column: h}},
+			JoinKey: key2[h],
+		})
+	}
+	return columns
+}
+
+// recordLineage keeps the lineage of the last export of its target
+func (s *ExportService) recordLineage(l *ExportLineage) {
+	l.GeneratedAt = time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lineage[l.Target] = l
+}
+
+// Lineage returns the lineage of the last generated export of each target,
+// sorted by target
+func (s *ExportService) Lineage() []*ExportLineage {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]*ExportLineage, 0, len(s.lineage))
+	for _, l := range s.lineage {
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Target < out[j].Target })
+	return out
+}
+
+// OpenLineage run event types, trimmed to what the exports need
+type (
+	OpenLineageEvent struct {
+		EventType string               `json:"eventType"`
+		EventTime string               `json:"eventTime"`
+		Producer  string               `json:"producer"`
+		SchemaURL string               `json:"schemaURL"`
+		Run       OpenLineageRun       `json:"run"`
+		Job       OpenLineageJob       `json:"job"`
+		Inputs    []OpenLineageDataset `json:"inputs"`
+		Outputs   []OpenLineageDataset `json:"outputs"`
+	}
+	OpenLineageRun struct {
+		RunID string `json:"runId"`
+	}
+	OpenLineageJob struct {
+		Namespace string `json:"namespace"`
+		Name      string `json:"name"`
+	}
+	OpenLineageDataset struct {
+		Namespace string                 `json:"namespace"`
+		Name      string                 `json:"name"`
+		Facets    map[string]interface{} `json:"facets,omitempty"`
+	}
+	openLineageField struct {
+		InputFields []openLineageInputField `json:"inputFields"`
+	}
+	openLineageInputField struct {
+		Namespace       string                      `json:"namespace"`
+		Name            string                      `json:"name"`
+		Field           string                      `json:"field"`
+		Transformations []openLineageTransformation `json:"transformations"`
+	}
+	openLineageTransformation struct {
+		Type        string `json:"type"`
+		Subtype     string `json:"subtype"`
+		Description string `json:"description,omitempty"`
+	}
+)
+
+// OpenLineage renders the lineage as an OpenLineage run event with a
+// columnLineage facet on the output. file1 and file2 name the input datasets.
+func (l *ExportLineage) OpenLineage(file1, file2 string) OpenLineageEvent {
+	inputs := map[int]string{1: file1, 2: file2}
+
+	fields := make(map[string]openLineageField, len(l.Columns))
+	for _, c := range l.Columns {
+		f := openLineageField{InputFields: []openLineageInputField{}}
+		for _, src := range c.Sources {
+			t := openLineageTransformation{Type: "DIRECT", Subtype: "IDENTITY"}
+			if src.FileIndex == 1 && c.Transform != "" {
+				t = openLineageTransformation{Type: "DIRECT", Subtype: "TRANSFORMATION", Description: c.Transform}
+			}
+			transformations := []openLineageTransformation{t}
+			if c.JoinKey {
+				transformations = append(transformations, openLineageTransformation{Type: "INDIRECT", Subtype: "JOIN"})
+			}
+			f.InputFields = append(f.InputFields, openLineageInputField{
+				Namespace:       lineageNamespace,
+				Name:            inputs[src.FileIndex],
+				Field:           src.Column,
+				Transformations: transformations,
+			})
+		}
+		fields[c.Column] = f
+	}
+
+	return OpenLineageEvent{
+		EventType: "COMPLETE",
+		EventTime: l.GeneratedAt.UTC().Format(time.RFC3339),
+		Producer:  lineageProducer,
+		SchemaURL: "https://openlineage.io/spec/2-0-2/OpenLineage.json#/$defs/RunEvent",
+		Run:       OpenLineageRun{RunID: newRunID()},
+		Job:       OpenLineageJob{Namespace: lineageNamespace, Name: l.Target + "_export"},
+		Inputs: []OpenLineageDataset{
+			{Namespace: lineageNamespace, Name: file1},
+			{Namespace: lineageNamespace, Name: file2},
+		},
+		Outputs: []OpenLineageDataset{{
+			Namespace: lineageNamespace,
+			Name:      l.Output,
+			Facets: map[string]interface{}{
+				"columnLineage": map[string]interface{}{
+					"_producer":  lineageProducer,
+					"_schemaURL": "https://openlineage.io/spec/facets/1-2-0/ColumnLineageDatasetFacet.json",
+					"fields":     fields,
+				},
+			},
+		}},
+	}
+}
+
+// newRunID returns a random (version 4) UUID
+func newRunID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:])
+}
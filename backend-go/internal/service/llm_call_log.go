@@ -0,0 +1,378 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/llm"
+	"backend-go/internal/logging"
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var llmCallLog = logging.Component("llm_calls")
+
+const llmCallsFile = "llm_calls.jsonl"
+
+// llmCallMaxCalls are the calls kept, newest first; the file is compacted
+// to them once it holds twice as many
+const llmCallMaxCalls = 1000
+
+// Modes of the LLM call log
+const (
+	LLMCallLogFull     = "full"     // Prompts and responses are kept
+	LLMCallLogRedacted = "redacted" // Only sizes, hashes, latency and outcome are kept
+	LLMCallLogOff      = "off"
+)
+
+// LLMCallFilter selects logged calls; zero fields match all
+type LLMCallFilter struct {
+	Task    string
+	Model   string
+	Outcome string
+	Since   time.Time
+	Until   time.Time
+	Limit   int
+	Offset  int
+}
+
+func (f LLMCallFilter) matches(c llm.Call) bool {
+	switch {
+	case f.Task != "" && c.Task != f.Task:
+		return false
+	case f.Model != "" && c.Model != f.Model:
+		return false
+	case f.Outcome != "" && c.Outcome != f.Outcome:
+		return false
+	case !f.Since.IsZero() && c.Time.Before(f.Since):
+		return false
+	case !f.Until.IsZero() && !c.Time.Before(f.Until):
+		return false
+	}
+	return true
+}
+
+// LLMCallStats totals a group of logged calls
+type LLMCallStats struct {
+	Calls          int            `json:"calls"`
+	Outcomes       map[string]int `json:"outcomes"`
+	PromptTokens   int            `json:"prompt_tokens"`
+	ResponseTokens int            `json:"response_tokens"`
+	MeanLatencyMS  int64          `json:"mean_latency_ms"`
+	P95LatencyMS   int64          `json:"p95_latency_ms"`
+	MaxLatencyMS   int64          `json:"max_latency_ms"`
+	EstimatedCost  float64        `json:"estimated_cost"` // At the prices asked for
+
+	latencies []int64
+}
+
+// LLMCallSummary totals the logged calls matching a filter, overall, per
+// task and per model
+type LLMCallSummary struct {
+	Since         *time.Time               `json:"since,omitempty"` // Oldest call counted
+	PromptPrice   float64                  `json:"prompt_price"`    // Per million prompt tokens
+	ResponsePrice float64                  `json:"response_price"`  // Per million response tokens
+	Total         *LLMCallStats            `json:"total"`
+	ByTask        map[string]*LLMCallStats `json:"by_task"`
+	ByModel       map[string]*LLMCallStats `json:"by_model"`
+}
+
+// LLMCallStore logs the LLM calls of the server, with their latency,
+// estimated tokens and outcome, to debug AI answers and estimate what a
+// hosted model would cost. Calls are appended to llm_calls.jsonl in the
+// data directory.
+type LLMCallStore struct {
+	calls []llm.Call // Oldest first
+	lines int        // Calls in the file
+	mode  string
+	mutex sync.RWMutex
+}
+
+var (
+	llmCallStore     *LLMCallStore
+	llmCallStoreOnce sync.Once
+)
+
+// GetLLMCallStore returns the singleton LLM call store
+func GetLLMCallStore() *LLMCallStore {
+	llmCallStoreOnce.Do(func() {
+		llmCallStore = &LLMCallStore{calls: []llm.Call{}, mode: LLMCallLogFull}
+		llmCallStore.load()
+	})
+	return llmCallStore
+}
+
+// load loads the newest logged calls from file
+func (s *LLMCallStore) load() {
+	data, err := os.ReadFile(config.DataPath(llmCallsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			llmCallLog.Error("Error loading LLM calls", "error", err)
+		}
+		return
+	}
+
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 0, 64*1024), 16<<20)
+	for scanner.Scan() {
+		var call llm.Call
+		if err := json.Unmarshal(scanner.Bytes(), &call); err != nil {
+			llmCallLog.Warn("Skipping unreadable LLM call", "line", s.lines+1, "error", err)
+			continue
+		}
+		s.calls = append(s.calls, call)
+		s.lines++
+	}
+	if len(s.calls) > llmCallMaxCalls {
+		s.calls = s.calls[len(s.calls)-llmCallMaxCalls:]
+	}
+	llmCallLog.Info("Loaded LLM calls", "calls", len(s.calls))
+}
+
+// rewrite replaces the file with the kept calls (must hold lock)
+func (s *LLMCallStore) rewrite() error {
+	var buf bytes.Buffer
+	for _, call := range s.calls {
+		line, err := json.Marshal(call)
+		if err != nil {
+			return err
+		}
+		buf.Write(append(line, '\n'))
+	}
+
+	dir := filepath.Dir(config.DataPath(llmCallsFile))
+	os.MkdirAll(dir, 0755)
+
+	if err := os.WriteFile(config.DataPath(llmCallsFile), buf.Bytes(), 0600); err != nil {
+		return err
+	}
+	s.lines = len(s.calls)
+	return nil
+}
+
+// append adds a call to the file, compacting it once it holds twice the
+// calls kept (must hold lock)
+func (s *LLMCallStore) append(call llm.Call) error {
+	if s.lines >= 2*llmCallMaxCalls {
+		return s.rewrite()
+	}
+	line, err := json.Marshal(call)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(llmCallsFile))
+	os.MkdirAll(dir, 0755)
+
+	f, err := os.OpenFile(config.DataPath(llmCallsFile), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	if _, err := f.Write(append(line, '\n')); err != nil {
+		return err
+	}
+	s.lines++
+	return nil
+}
+
+// SetMode sets whether calls are logged in full, redacted or not at all;
+// calls already logged are left as they are
+func (s *LLMCallStore) SetMode(mode string) error {
+	switch mode {
+	case LLMCallLogFull, LLMCallLogRedacted, LLMCallLogOff:
+	default:
+		return fmt.Errorf("LLM call log mode %q must be %s, %s or %s", mode, LLMCallLogFull, LLMCallLogRedacted, LLMCallLogOff)
+	}
+	s.mutex.Lock()
+	s.mode = mode
+	s.mutex.Unlock()
+	return nil
+}
+
+// Mode returns whether calls are logged in full, redacted or not at all
+func (s *LLMCallStore) Mode() string {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.mode
+}
+
+// RecordCall logs a call, without its prompt and response when the log is
+// redacted; it implements llm.CallRecorder
+func (s *LLMCallStore) RecordCall(call llm.Call) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.mode == LLMCallLogOff {
+		return
+	}
+	call.ID = newLLMCallID()
+	if s.mode == LLMCallLogRedacted {
+		redactLLMCall(&call)
+	}
+	s.calls = append(s.calls, call)
+	if len(s.calls) > llmCallMaxCalls {
+		s.calls = s.calls[len(s.calls)-llmCallMaxCalls:]
+	}
+	if err := s.append(call); err != nil {
+		llmCallLog.Error("Error writing LLM call", "call", call.ID, "error", err)
+	}
+}
+
+// List returns the calls matching a filter, newest first, without their
+// prompts and responses, and how many matched before the limit
+func (s *LLMCallStore) List(filter LLMCallFilter) ([]llm.Call, int) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	calls, matched := []llm.Call{}, 0
+	for i := len(s.calls) - 1; i >= 0; i-- {
+		call := s.calls[i]
+		if !filter.matches(call) {
+			continue
+		}
+		matched++
+		if matched <= filter.Offset || (filter.Limit > 0 && len(calls) >= filter.Limit) {
+			continue
+		}
+		call.Prompt, call.Response = "", ""
+		calls = append(calls, call)
+	}
+	return calls, matched
+}
+
+// Get returns a call by ID with its prompt and response
+func (s *LLMCallStore) Get(id string) (llm.Call, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, call := range s.calls {
+		if call.ID == id {
+			return call, true
+		}
+	}
+	return llm.Call{}, false
+}
+
+// Summary totals the calls matching a filter, ignoring its limit and
+// offset. Costs are estimated at the given prices per million tokens.
+func (s *LLMCallStore) Summary(filter LLMCallFilter, promptPrice, responsePrice float64) *LLMCallSummary {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	summary := &LLMCallSummary{
+		PromptPrice:   promptPrice,
+		ResponsePrice: responsePrice,
+		Total:         newLLMCallStats(),
+		ByTask:        map[string]*LLMCallStats{},
+		ByModel:       map[string]*LLMCallStats{},
+	}
+	for _, call := range s.calls {
+		if !filter.matches(call) {
+			continue
+		}
+		if summary.Since == nil {
+			summary.Since = &call.Time
+		}
+		if summary.ByTask[call.Task] == nil {
+			summary.ByTask[call.Task] = newLLMCallStats()
+		}
+		if summary.ByModel[call.Model] == nil {
+			summary.ByModel[call.Model] = newLLMCallStats()
+		}
+		for _, stats := range []*LLMCallStats{summary.Total, summary.ByTask[call.Task], summary.ByModel[call.Model]} {
+			stats.add(call)
+		}
+	}
+	for _, stats := range append([]*LLMCallStats{summary.Total}, statsValues(summary.ByTask, summary.ByModel)...) {
+		stats.finish(promptPrice, responsePrice)
+	}
+	return summary
+}
+
+// Redact removes the prompts and responses of the logged calls, in memory
+// and on file, and returns how many had any
+func (s *LLMCallStore) Redact() (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	redacted := 0
+	for i := range s.calls {
+		if !s.calls[i].Redacted {
+			redactLLMCall(&s.calls[i])
+			redacted++
+		}
+	}
+	return redacted, s.rewrite()
+}
+
+// Clear removes all logged calls
+func (s *LLMCallStore) Clear() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.calls = []llm.Call{}
+	return s.rewrite()
+}
+
+// redactLLMCall drops the prompt and response of a call, keeping their
+// token estimates and the prompt's hash
+func redactLLMCall(call *llm.Call) {
+	call.Prompt, call.Response = "", ""
+	call.Redacted = true
+}
+
+func newLLMCallStats() *LLMCallStats {
+	return &LLMCallStats{Outcomes: map[string]int{}}
+}
+
+func (st *LLMCallStats) add(call llm.Call) {
+	st.Calls++
+	st.Outcomes[call.Outcome]++
+	st.PromptTokens += call.PromptTokens
+	st.ResponseTokens += call.ResponseTokens
+	st.latencies = append(st.latencies, call.LatencyMS)
+}
+
+// finish computes the latencies and cost once all calls are added
+func (st *LLMCallStats) finish(promptPrice, responsePrice float64) {
+	st.EstimatedCost = (float64(st.PromptTokens)*promptPrice + float64(st.ResponseTokens)*responsePrice) / 1e6
+	if len(st.latencies) == 0 {
+		return
+	}
+	sort.Slice(st.latencies, func(i, j int) bool { return st.latencies[i] < st.latencies[j] })
+	var sum int64
+	for _, l := range st.latencies {
+		sum += l
+	}
+	st.MeanLatencyMS = sum / int64(len(st.latencies))
+	st.P95LatencyMS = st.latencies[(len(st.latencies)*95+99)/100-1]
+	st.MaxLatencyMS = st.latencies[len(st.latencies)-1]
+	st.latencies = nil
+}
+
+// statsValues lists the stats of the groups
+func statsValues(groups ...map[string]*LLMCallStats) []*LLMCallStats {
+	values := []*LLMCallStats{}
+	for _, group := range groups {
+		for _, stats := range group {
+			values = append(values, stats)
+		}
+	}
+	return values
+}
+
+func newLLMCallID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "llm_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "llm_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,110 @@
+package service
+
+import (
+	"backend-go/internal/textnorm"
+	"sort"
+)
+
+// LSHMatch is an approximate match returned by an LSH query
+type LSHMatch struct {
+	ID         int     `json:"id"` // Insertion order of the distinct value
+	Value      string  `json:"value"`
+	Similarity float64 `json:"similarity"` // 3-gram Jaccard similarity
+}
+
+// LSHIndex is a banded MinHash index over strings. Values whose signatures
+// agree on every row of at least one band become candidates, which are then
+// verified with exact 3-gram Jaccard similarity.
+type LSHIndex struct {
+	fuzzy   *FuzzyMatcher
+	values  []string
+	ids     map[string]int
+	buckets []map[uint64][]int // One bucket table per band
+}
+
+// NewLSHIndex creates an empty index
+func NewLSHIndex() *LSHIndex {
+	idx := &LSHIndex{
+		fuzzy:   &FuzzyMatcher{soundexCache: make(map[string]string)},
+		ids:     make(map[string]int),
+		buckets: make([]map[uint64][]int, lshBands),
+	}
+	for b := range idx.buckets {
+		idx.buckets[b] = make(map[uint64][]int)
+	}
+	return idx
+}
+
+// Add indexes a value and returns its id. Values are matched case-insensitively
+// and duplicates share an id.
+func (idx *LSHIndex) Add(value string) int {
+	key := textnorm.Fold(value)
+	if id, ok := idx.ids[key]; ok {
+		return id
+	}
+
+	id := len(idx.values)
+	idx.values = append(idx.values, value)
+	idx.ids[key] = id
+
+	sig := idx.fuzzy.minHashSignature(key)
+	for b := range idx.buckets {
+		band := bandKey(sig, b)
+		idx.buckets[b][band] = append(idx.buckets[b][band], id)
+	}
+	return id
+}
+
+// Len returns the number of distinct values indexed
+func (idx *LSHIndex) Len() int {
+	return len(idx.values)
+}
+
+// Value returns the indexed value for an id
+func (idx *LSHIndex) Value(id int) string {
+	return idx.values[id]
+}
+
+// Candidates returns the ids sharing at least one band with the query
+func (idx *LSHIndex) Candidates(query string) []int {
+	sig := idx.fuzzy.minHashSignature(textnorm.Fold(query))
+
+	seen := make(map[int]bool)
+	ids := []int{}
+	for b := range idx.buckets {
+		for _, id := range idx.buckets[b][bandKey(sig, b)] {
+			if !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	return ids
+}
+
+// Query returns indexed values with similarity >= threshold, best first,
+// and the number of candidates that were verified
+func (idx *LSHIndex) Query(query string, threshold float64) ([]LSHMatch, int) {
+	candidates := idx.Candidates(query)
+	matches := []LSHMatch{}
+	for _, id := range candidates {
+		sim := idx.fuzzy.jaccardSimilarity(query, idx.values[id])
+		if sim >= threshold {
+			matches = append(matches, LSHMatch{ID: id, Value: idx.values[id], Similarity: sim})
+		}
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		return matches[i].Similarity > matches[j].Similarity
+	})
+	return matches, len(candidates)
+}
+
+// bandKey hashes the rows of one band of a signature
+func bandKey(sig []uint64, band int) uint64 {
+	key := uint64(band) + 1
+	for _, v := range sig[band*lshRowsPerBand : (band+1)*lshRowsPerBand] {
+		key = mixHash(key ^ v)
+	}
+	return key
+}
@@ -0,0 +1,22 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"os"
+	"testing"
+)
+
+// TestMain points the data directory at a temporary one, so the learning
+// stores the tests touch don't read or write real data
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "service-test")
+	if err != nil {
+		panic(err)
+	}
+	if _, err := config.Load([]string{"-data-dir", dir}); err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
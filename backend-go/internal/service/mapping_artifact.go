@@ -0,0 +1,103 @@
+package service
+
+import (
+	"backend-go/internal/state"
+	"fmt"
+	"time"
+)
+
+// MappingArtifactVersion is bumped when the artifact format changes
+const MappingArtifactVersion = 1
+
+// MappingArtifact is a portable copy of an approved mapping that can be
+// applied to another pair of files with the same schemas
+type MappingArtifact struct {
+	Version         int             `json:"version"`
+	ExportedAt      time.Time       `json:"exported_at"`
+	File1Name       string          `json:"file1_name,omitempty"`
+	File2Name       string          `json:"file2_name,omitempty"`
+	File1Columns    []string        `json:"file1_columns"`
+	File2Columns    []string        `json:"file2_columns"`
+	File1SchemaHash string          `json:"file1_schema_hash"`
+	File2SchemaHash string          `json:"file2_schema_hash"`
+	Mappings        []ColumnMapping `json:"mappings"`
+}
+
+// MappingImportSummary reports how an artifact was applied
+type MappingImportSummary struct {
+	SchemaMatch     bool `json:"schema_match"` // Both schema hashes equal the loaded files'
+	MappingsApplied int  `json:"mappings_applied"`
+	JoinKeys        int  `json:"join_keys"`
+}
+
+// ExportMappingArtifact packages the approved mapping of the loaded pair
+func ExportMappingArtifact(df1, df2 *state.DataFrame, mapping *ApprovedMapping) (*MappingArtifact, error) {
+	if df1 == nil || df2 == nil {
+		return nil, fmt.Errorf("both files must be loaded")
+	}
+	if mapping == nil || len(mapping.Mappings) == 0 {
+		return nil, fmt.Errorf("no approved mapping for the current files")
+	}
+
+	return &MappingArtifact{
+		Version:         MappingArtifactVersion,
+		ExportedAt:      time.Now(),
+		File1Name:       df1.FileName,
+		File2Name:       df2.FileName,
+		File1Columns:    append([]string{}, df1.Headers...),
+		File2Columns:    append([]string{}, df2.Headers...),
+		File1SchemaHash: df1.SchemaHash(),
+		File2SchemaHash: df2.SchemaHash(),
+		Mappings:        mapping.Mappings,
+	}, nil
+}
+
+// ImportMappingArtifact replaces the approved mapping of the loaded pair with
+// the artifact's. Every mapped column must exist in the loaded files; the
+// schemas may otherwise differ (reported as SchemaMatch=false).
+func ImportMappingArtifact(artifact *MappingArtifact, df1, df2 *state.DataFrame) (*MappingImportSummary, error) {
+	if artifact == nil {
+		return nil, fmt.Errorf("empty artifact")
+	}
+	if artifact.Version < 1 || artifact.Version > MappingArtifactVersion {
+		return nil, fmt.Errorf("unsupported artifact version %d (max %d)", artifact.Version, MappingArtifactVersion)
+	}
+	if df1 == nil || df2 == nil {
+		return nil, fmt.Errorf("both files must be loaded")
+	}
+
+	missing := []string{}
+	for _, cm := range artifact.Mappings {
+		if cm.Status != MappingAccepted && cm.Status != MappingRejected {
+			return nil, fmt.Errorf("mapping %s -> %s has invalid status %q", cm.File1Column, cm.File2Column, cm.Status)
+		}
+		if columnIndex(df1, cm.File1Column) < 0 {
+			missing = append(missing, "file1."+cm.File1Column)
+		}
+		if columnIndex(df2, cm.File2Column) < 0 {
+			missing = append(missing, "file2."+cm.File2Column)
+		}
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("columns not found in the loaded files: %v", missing)
+	}
+
+	scope := DatasetPairScope(df1, df2)
+	mapping := &ApprovedMapping{
+		File1Name: df1.FileName,
+		File2Name: df2.FileName,
+		Mappings:  artifact.Mappings,
+	}
+	if mapping.Mappings == nil {
+		mapping.Mappings = []ColumnMapping{}
+	}
+	if err := GetMappingStore().Put(scope, mapping); err != nil {
+		return nil, err
+	}
+
+	return &MappingImportSummary{
+		SchemaMatch:     artifact.File1SchemaHash == df1.SchemaHash() && artifact.File2SchemaHash == df2.SchemaHash(),
+		MappingsApplied: len(artifact.Mappings),
+		JoinKeys:        len(mapping.JoinKeys()),
+	}, nil
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const mappingKnowledgeFile = "mapping_knowledge.json"
+
+// knowledgeMinSchemaSimilarity is the share of columns a file must have in
+// common with a past one for its approved mappings to be suggested
+const knowledgeMinSchemaSimilarity = 0.5
+
+// KnowledgeMapping is an approved column pair kept in the knowledge base
+type KnowledgeMapping struct {
+	File1Column string `json:"file1_column"`
+	File2Column string `json:"file2_column"`
+	JoinKey     bool   `json:"join_key,omitempty"`
+	Transform   string `json:"transform,omitempty"`
+
+	FormatTransform *models.ColumnTransform `json:"format_transform,omitempty"`
+}
+
+// KnowledgeEntry is the approved mapping of one past dataset pair with the
+// schemas it was approved for
+type KnowledgeEntry struct {
+	Scope        string             `json:"scope"`
+	File1Name    string             `json:"file1_name,omitempty"`
+	File2Name    string             `json:"file2_name,omitempty"`
+	File1Schema  string             `json:"file1_schema"` // SchemaHash fingerprints
+	File2Schema  string             `json:"file2_schema"`
+	File1Columns []string           `json:"file1_columns"`
+	File2Columns []string           `json:"file2_columns"`
+	Mappings     []KnowledgeMapping `json:"mappings"`
+	UpdatedAt    time.Time          `json:"updated_at"`
+}
+
+// HistorySuggestion is a column pair suggested because it was approved for
+// files with similar schemas
+type HistorySuggestion struct {
+	File1Column      string  `json:"file1_column"`
+	File2Column      string  `json:"file2_column"`
+	Confidence       float64 `json:"confidence"`        // 0-100, the schema similarity of the closest entry
+	SchemaSimilarity float64 `json:"schema_similarity"` // 0-1
+	Support          int     `json:"support"`           // Past dataset pairs that approved the pair
+	JoinKey          bool    `json:"join_key,omitempty"`
+	Transform        string  `json:"transform,omitempty"`
+	Source           string  `json:"source"` // Scope of the closest entry
+	SourceFiles      string  `json:"source_files,omitempty"`
+
+	FormatTransform *models.ColumnTransform `json:"format_transform,omitempty"`
+}
+
+// MappingKnowledgeBase keeps every approved mapping across projects, so new
+// files with similar schemas start from what was approved before
+type MappingKnowledgeBase struct {
+	entries map[string]KnowledgeEntry // keyed by scope
+	mutex   sync.RWMutex
+}
+
+var (
+	mappingKnowledge     *MappingKnowledgeBase
+	mappingKnowledgeOnce sync.Once
+)
+
+// GetMappingKnowledge returns the singleton knowledge base
+func GetMappingKnowledge() *MappingKnowledgeBase {
+	mappingKnowledgeOnce.Do(func() {
+		mappingKnowledge = &MappingKnowledgeBase{
+			entries: make(map[string]KnowledgeEntry),
+		}
+		mappingKnowledge.load()
+	})
+	return mappingKnowledge
+}
+
+// load loads the knowledge base from file
+func (kb *MappingKnowledgeBase) load() {
+	data, err := os.ReadFile(config.DataPath(mappingKnowledgeFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			mappingsLog.Error("Error loading mapping knowledge base", "error", err)
+		}
+		return
+	}
+
+	var saved []KnowledgeEntry
+	if err := json.Unmarshal(data, &saved); err != nil {
+		mappingsLog.Error("Error parsing mapping knowledge base", "error", err)
+		return
+	}
+	for _, e := range saved {
+		kb.entries[e.Scope] = e
+	}
+	mappingsLog.Info("Loaded mapping knowledge base", "entries", len(saved))
+}
+
+// save persists the knowledge base to file (must hold lock)
+func (kb *MappingKnowledgeBase) save() error {
+	data, err := json.MarshalIndent(kb.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(mappingKnowledgeFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(mappingKnowledgeFile), data, 0644)
+}
+
+// sorted lists the entries, most recently updated first (must hold lock)
+func (kb *MappingKnowledgeBase) sorted() []KnowledgeEntry {
+	entries := make([]KnowledgeEntry, 0, len(kb.entries))
+	for _, e := range kb.entries {
+		entries = append(entries, e)
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].UpdatedAt.After(entries[j].UpdatedAt) })
+	return entries
+}
+
+// Record stores the accepted mappings of an approved mapping document. It
+// takes the schemas from the loaded files, which the document's scope
+// fingerprints; a document whose files are no longer loaded is skipped.
+// Entries outlive cleared documents, so the history is kept.
+func (kb *MappingKnowledgeBase) Record(m *ApprovedMapping) error {
+	df1, df2 := state.State.GetDataFrame(1), state.State.GetDataFrame(2)
+	if m == nil || DatasetPairScope(df1, df2) != m.Scope {
+		return nil
+	}
+
+	mappings := []KnowledgeMapping{}
+	for _, cm := range m.Accepted() {
+		mappings = append(mappings, KnowledgeMapping{
+			File1Column:     cm.File1Column,
+			File2Column:     cm.File2Column,
+			JoinKey:         cm.JoinKey,
+			Transform:       cm.Transform,
+			FormatTransform: cm.FormatTransform,
+		})
+	}
+
+	kb.mutex.Lock()
+	defer kb.mutex.Unlock()
+	if len(mappings) == 0 {
+		if _, ok := kb.entries[m.Scope]; !ok {
+			return nil
+		}
+		delete(kb.entries, m.Scope)
+	} else {
+		kb.entries[m.Scope] = KnowledgeEntry{
+			Scope:        m.Scope,
+			File1Name:    df1.FileName,
+			File2Name:    df2.FileName,
+			File1Schema:  df1.SchemaHash(),
+			File2Schema:  df2.SchemaHash(),
+			File1Columns: append([]string{}, df1.Headers...),
+			File2Columns: append([]string{}, df2.Headers...),
+			Mappings:     mappings,
+			UpdatedAt:    time.Now(),
+		}
+	}
+	return kb.save()
+}
+
+// List returns the entries, most recently updated first. A non-empty query
+// keeps the entries whose file or column names contain it.
+func (kb *MappingKnowledgeBase) List(query string) []KnowledgeEntry {
+	kb.mutex.RLock()
+	defer kb.mutex.RUnlock()
+
+	query = strings.ToLower(strings.TrimSpace(query))
+	entries := []KnowledgeEntry{}
+	for _, e := range kb.sorted() {
+		if query == "" || e.matches(query) {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// matches reports whether a file or column name contains the lowercase query
+func (e KnowledgeEntry) matches(query string) bool {
+	names := append([]string{e.File1Name, e.File2Name}, e.File1Columns...)
+	names = append(names, e.File2Columns...)
+	for _, name := range names {
+		if strings.Contains(strings.ToLower(name), query) {
+			return true
+		}
+	}
+	return false
+}
+
+// Delete removes the entry of a scope
+func (kb *MappingKnowledgeBase) Delete(scope string) error {
+	kb.mutex.Lock()
+	defer kb.mutex.Unlock()
+
+	if _, ok := kb.entries[scope]; !ok {
+		return fmt.Errorf("no knowledge base entry for scope %q", scope)
+	}
+	delete(kb.entries, scope)
+	mappingsLog.Info("Deleted knowledge base entry", "scope", scope)
+	return kb.save()
+}
+
+// Suggest returns the column pairs approved for past files whose schemas
+// are similar to the given ones, matching column names ignoring case and
+// separators. Past pairs in the other file order count too. Pairs are
+// ranked by the schema similarity of the closest entry approving them.
+func (kb *MappingKnowledgeBase) Suggest(df1, df2 *state.DataFrame) []HistorySuggestion {
+	if df1 == nil || df2 == nil {
+		return []HistorySuggestion{}
+	}
+	names1, names2 := normalizedColumns(df1.Headers), normalizedColumns(df2.Headers)
+
+	kb.mutex.RLock()
+	defer kb.mutex.RUnlock()
+
+	byPair := map[[2]string]*HistorySuggestion{}
+	for _, e := range kb.entries {
+		past1, past2 := normalizedColumns(e.File1Columns), normalizedColumns(e.File2Columns)
+		swapped := false
+		similarity := math.Min(columnSetSimilarity(names1, past1), columnSetSimilarity(names2, past2))
+		if reversed := math.Min(columnSetSimilarity(names1, past2), columnSetSimilarity(names2, past1)); reversed > similarity {
+			similarity, swapped = reversed, true
+		}
+		if similarity < knowledgeMinSchemaSimilarity {
+			continue
+		}
+
+		for _, m := range e.Mappings {
+			past1Col, past2Col := m.File1Column, m.File2Column
+			if swapped {
+				past1Col, past2Col = past2Col, past1Col
+			}
+			col1, ok1 := names1[normalize(past1Col)]
+			col2, ok2 := names2[normalize(past2Col)]
+			if !ok1 || !ok2 {
+				continue
+			}
+
+			key := [2]string{col1, col2}
+			s, ok := byPair[key]
+			if !ok {
+				s = &HistorySuggestion{File1Column: col1, File2Column: col2}
+				byPair[key] = s
+			}
+			s.Support++
+			if similarity > s.SchemaSimilarity {
+				s.SchemaSimilarity = similarity
+				s.Confidence = similarity * 100
+				s.JoinKey = m.JoinKey
+				s.Source = e.Scope
+				s.SourceFiles = e.File1Name + " / " + e.File2Name
+				// Transforms are written for file 1 values, so only carry
+				// over in the same file order
+				s.Transform, s.FormatTransform = "", nil
+				if !swapped {
+					s.Transform, s.FormatTransform = m.Transform, m.FormatTransform
+				}
+			}
+		}
+	}
+
+	suggestions := make([]HistorySuggestion, 0, len(byPair))
+	for _, s := range byPair {
+		suggestions = append(suggestions, *s)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		if suggestions[i].Confidence != suggestions[j].Confidence {
+			return suggestions[i].Confidence > suggestions[j].Confidence
+		}
+		if suggestions[i].Support != suggestions[j].Support {
+			return suggestions[i].Support > suggestions[j].Support
+		}
+		return suggestions[i].File1Column < suggestions[j].File1Column
+	})
+	return suggestions
+}
+
+// normalizedColumns maps normalized column names to the columns
+func normalizedColumns(headers []string) map[string]string {
+	names := make(map[string]string, len(headers))
+	for _, h := range headers {
+		names[normalize(h)] = h
+	}
+	return names
+}
+
+// columnSetSimilarity is the Jaccard similarity of two normalized column sets
+func columnSetSimilarity(a, b map[string]string) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for name := range a {
+		if _, ok := b[name]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
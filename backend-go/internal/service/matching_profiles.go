@@ -0,0 +1,302 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+)
+
+var profilesLog = logging.Component("profiles")
+
+const matchingProfilesFile = "matching_profiles.json"
+
+// Assignment modes control how many matches a column may take part in
+const (
+	AssignmentAll           = "all"             // Every pair above the minimum confidence
+	AssignmentBestPerColumn = "best_per_column" // Best file2 match for each file1 column
+	AssignmentOneToOne      = "one_to_one"      // Greedy unique assignment on both sides
+)
+
+// DefaultProfileName is used when a request doesn't name a profile
+const DefaultProfileName = "balanced"
+
+// MatchingProfile bundles scoring weights, thresholds, AI usage and assignment mode
+type MatchingProfile struct {
+	Name           string           `json:"name"`
+	Description    string           `json:"description,omitempty"`
+	Weights        *AdaptiveWeights `json:"weights,omitempty"` // nil = use learned adaptive weights
+	MinConfidence  float64          `json:"min_confidence"`
+	MatchThreshold float64          `json:"match_threshold"`
+	UseAI          bool             `json:"use_ai"`
+	AssignmentMode string           `json:"assignment_mode"`
+	NameAlgorithm  string           `json:"name_algorithm,omitempty"` // "" = DefaultNameAlgorithm
+	BuiltIn        bool             `json:"built_in"`
+}
+
+// ScoringOptions converts the profile into pipeline options
+func (p MatchingProfile) ScoringOptions() ScoringOptions {
+	return ScoringOptions{
+		Weights:       p.Weights,
+		MinConfidence: p.MinConfidence,
+		Blocking:      BlockingAuto,
+		NameAlgorithm: p.NameAlgorithm,
+	}
+}
+
+// builtInProfiles are always available and can't be changed or deleted
+func builtInProfiles() []MatchingProfile {
+	return []MatchingProfile{
+		{
+			Name:           "strict",
+			Description:    "High precision for migrations: data-heavy weights, unique one-to-one assignment",
+			Weights:        &AdaptiveWeights{Name: 0.25, Data: 0.45, Pattern: 0.20, LLM: 0.10},
+			MinConfidence:  40,
+			MatchThreshold: 75,
+			UseAI:          false,
+			AssignmentMode: AssignmentOneToOne,
+			BuiltIn:        true,
+		},
+		{
+			Name:           "balanced",
+			Description:    "Learned weights, best match per column",
+			MinConfidence:  10,
+			MatchThreshold: 50,
+			UseAI:          false,
+			AssignmentMode: AssignmentBestPerColumn,
+			BuiltIn:        true,
+		},
+		{
+			Name:           "lenient",
+			Description:    "High recall for discovery: AI matching, all candidate pairs",
+			MinConfidence:  5,
+			MatchThreshold: 30,
+			UseAI:          true,
+			AssignmentMode: AssignmentAll,
+			BuiltIn:        true,
+		},
+	}
+}
+
+// MatchingProfileStore manages built-in and user-defined matching profiles
+type MatchingProfileStore struct {
+	custom map[string]MatchingProfile
+	mutex  sync.RWMutex
+}
+
+var (
+	profileStore     *MatchingProfileStore
+	profileStoreOnce sync.Once
+)
+
+// GetMatchingProfileStore returns the singleton profile store
+func GetMatchingProfileStore() *MatchingProfileStore {
+	profileStoreOnce.Do(func() {
+		profileStore = &MatchingProfileStore{
+			custom: make(map[string]MatchingProfile),
+		}
+		profileStore.load()
+	})
+	return profileStore
+}
+
+// load loads custom profiles from file
+func (s *MatchingProfileStore) load() {
+	data, err := os.ReadFile(config.DataPath(matchingProfilesFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			profilesLog.Error("Error loading profiles", "error", err)
+		}
+		return
+	}
+
+	var saved []MatchingProfile
+	if err := json.Unmarshal(data, &saved); err != nil {
+		profilesLog.Error("Error parsing profiles", "error", err)
+		return
+	}
+
+	s.mutex.Lock()
+	for _, p := range saved {
+		p.BuiltIn = false
+		s.custom[p.Name] = p
+	}
+	s.mutex.Unlock()
+
+	profilesLog.Info("Loaded custom profiles", "count", len(saved))
+}
+
+// save persists custom profiles to file
+func (s *MatchingProfileStore) save() error {
+	s.mutex.RLock()
+	profiles := make([]MatchingProfile, 0, len(s.custom))
+	for _, p := range s.custom {
+		profiles = append(profiles, p)
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(profiles, func(i, j int) bool { return profiles[i].Name < profiles[j].Name })
+
+	data, err := json.MarshalIndent(profiles, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(matchingProfilesFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(matchingProfilesFile), data, 0644)
+}
+
+// List returns built-in profiles followed by custom ones sorted by name
+func (s *MatchingProfileStore) List() []MatchingProfile {
+	profiles := builtInProfiles()
+
+	s.mutex.RLock()
+	custom := make([]MatchingProfile, 0, len(s.custom))
+	for _, p := range s.custom {
+		custom = append(custom, p)
+	}
+	s.mutex.RUnlock()
+
+	sort.Slice(custom, func(i, j int) bool { return custom[i].Name < custom[j].Name })
+	return append(profiles, custom...)
+}
+
+// Get looks up a profile by name; an empty name returns the default profile
+func (s *MatchingProfileStore) Get(name string) (MatchingProfile, bool) {
+	if name == "" {
+		name = DefaultProfileName
+	}
+	for _, p := range builtInProfiles() {
+		if p.Name == name {
+			return p, true
+		}
+	}
+
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	p, ok := s.custom[name]
+	return p, ok
+}
+
+// Save creates or replaces a custom profile
+func (s *MatchingProfileStore) Save(p MatchingProfile) (MatchingProfile, error) {
+	p.Name = strings.TrimSpace(p.Name)
+	if p.Name == "" {
+		return p, fmt.Errorf("profile name is required")
+	}
+	for _, b := range builtInProfiles() {
+		if b.Name == p.Name {
+			return p, fmt.Errorf("profile %q is built in and can't be modified", p.Name)
+		}
+	}
+
+	if p.Weights != nil {
+		weights, err := NormalizeWeights(*p.Weights)
+		if err != nil {
+			return p, err
+		}
+		p.Weights = &weights
+	}
+	if p.MinConfidence < 0 || p.MinConfidence > 100 || p.MatchThreshold < 0 || p.MatchThreshold > 100 {
+		return p, fmt.Errorf("thresholds must be between 0 and 100")
+	}
+	switch p.AssignmentMode {
+	case "":
+		p.AssignmentMode = AssignmentAll
+	case AssignmentAll, AssignmentBestPerColumn, AssignmentOneToOne:
+	default:
+		return p, fmt.Errorf("unknown assignment mode %q", p.AssignmentMode)
+	}
+	if err := ValidateNameAlgorithm(p.NameAlgorithm); err != nil {
+		return p, err
+	}
+	p.BuiltIn = false
+
+	s.mutex.Lock()
+	s.custom[p.Name] = p
+	s.mutex.Unlock()
+
+	profilesLog.Info("Saved profile", "profile", p.Name)
+	return p, s.save()
+}
+
+// Delete removes a custom profile
+func (s *MatchingProfileStore) Delete(name string) error {
+	for _, b := range builtInProfiles() {
+		if b.Name == name {
+			return fmt.Errorf("profile %q is built in and can't be deleted", name)
+		}
+	}
+
+	s.mutex.Lock()
+	if _, ok := s.custom[name]; !ok {
+		s.mutex.Unlock()
+		return fmt.Errorf("profile %q not found", name)
+	}
+	delete(s.custom, name)
+	s.mutex.Unlock()
+
+	profilesLog.Info("Deleted profile", "profile", name)
+	return s.save()
+}
+
+// AssignmentCandidate is the minimal view of a scored pair needed for assignment
+type AssignmentCandidate struct {
+	File1Column string
+	File2Column string
+	Confidence  float64
+}
+
+// SelectAssignment returns the indices of the candidates kept under the given
+// assignment mode, in descending confidence order
+func SelectAssignment(candidates []AssignmentCandidate, mode string) []int {
+	order := make([]int, len(candidates))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return candidates[order[a]].Confidence > candidates[order[b]].Confidence
+	})
+
+	if mode == "" || mode == AssignmentAll {
+		return order
+	}
+
+	used1 := make(map[string]bool)
+	used2 := make(map[string]bool)
+	kept := []int{}
+	for _, i := range order {
+		c := candidates[i]
+		if used1[c.File1Column] {
+			continue
+		}
+		if mode == AssignmentOneToOne && used2[c.File2Column] {
+			continue
+		}
+		used1[c.File1Column] = true
+		used2[c.File2Column] = true
+		kept = append(kept, i)
+	}
+	return kept
+}
+
+// ApplyAssignment filters similarity results with SelectAssignment
+func ApplyAssignment(results []SimilarityResult, mode string) []SimilarityResult {
+	candidates := make([]AssignmentCandidate, len(results))
+	for i, r := range results {
+		candidates[i] = AssignmentCandidate{File1Column: r.File1Column, File2Column: r.File2Column, Confidence: r.Confidence}
+	}
+
+	kept := []SimilarityResult{}
+	for _, i := range SelectAssignment(candidates, mode) {
+		kept = append(kept, results[i])
+	}
+	return kept
+}
@@ -0,0 +1,224 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// notebookCell is a Jupyter (nbformat 4) cell. Code and markdown cells have
+// different required keys, so cells are plain maps.
+type notebookCell map[string]interface{}
+
+type notebook struct {
+	Cells         []notebookCell         `json:"cells"`
+	Metadata      map[string]interface{} `json:"metadata"`
+	NBFormat      int                    `json:"nbformat"`
+	NBFormatMinor int                    `json:"nbformat_minor"`
+}
+
+// notebookBuilder accumulates cells
+type notebookBuilder struct {
+	cells []notebookCell
+}
+
+func (b *notebookBuilder) markdown(lines ...string) {
+	b.cells = append(b.cells, notebookCell{
+		"cell_type": "markdown",
+		"metadata":  map[string]interface{}{},
+		"source":    cellSource(lines),
+	})
+}
+
+func (b *notebookBuilder) code(lines ...string) {
+	b.cells = append(b.cells, notebookCell{
+		"cell_type":       "code",
+		"metadata":        map[string]interface{}{},
+		"source":          cellSource(lines),
+		"execution_count": nil,
+		"outputs":         []interface{}{},
+	})
+}
+
+// cellSource terminates every line but the last with a newline, as Jupyter stores it
+func cellSource(lines []string) []string {
+	source := make([]string, len(lines))
+	for i, l := range lines {
+		if i < len(lines)-1 {
+			l += "\n"
+		}
+		source[i] = l
+	}
+	return source
+}
+
+// GenerateNotebook builds a pandas notebook that loads both files, applies
+// the approved mapping, joins them and runs validation checks and profiling
+// plots. Without an approved mapping, high-confidence graph matches are used.
+// df1, df2 and mapping may be nil.
+func (s *ExportService) GenerateNotebook(graph *models.SimilarityGraph, mapping *ApprovedMapping, df1, df2 *state.DataFrame) ([]byte, error) {
+	keys := joinColumns(graph, mapping)
+
+	// Mapped (non-key) column pairs to validate after the join
+	mapped := []ColumnMapping{}
+	if accepted := mapping.Accepted(); len(accepted) > 0 {
+		mapped = accepted
+	} else {
+		for _, pair := range keys {
+			mapped = append(mapped, ColumnMapping{File1Column: pair[0], File2Column: pair[1], JoinKey: true})
+		}
+	}
+
+	file1, file2 := "file1.csv", "file2.csv"
+	if df1 != nil && df1.FileName != "" {
+		file1 = df1.FileName
+	}
+	if df2 != nil && df2.FileName != "" {
+		file2 = df2.FileName
+	}
+
+	b := &notebookBuilder{}
+
+	// Overview
+	overview := []string{
+		"# Project Euler mapping notebook",
+		"",
+		fmt.Sprintf("Generated %s for `%s` and `%s`.", time.Now().Format("2006-01-02 15:04"), file1, file2),
+		"",
+	}
+	if len(mapped) > 0 {
+		overview = append(overview,
+			"| File 1 column | File 2 column | Join key | Confidence | Transform | Note |",
+			"|---|---|---|---|---|---|",
+		)
+		for _, m := range mapped {
+			overview = append(overview, fmt.Sprintf("| %s | %s | %s | %.1f | %s | %s |",
+				mdCell(m.File1Column), mdCell(m.File2Column), yesNo(m.JoinKey), m.Confidence, mdCell(m.Transform), mdCell(m.Note)))
+		}
+	} else {
+		overview = append(overview, "_No approved or high-confidence mappings yet; the join below is a cross join._")
+	}
+	b.markdown(overview...)
+
+	// Load
+	b.code(
+		"import pandas as pd",
+		"import matplotlib.pyplot as plt",
+		"",
+		fmt.Sprintf("df1 = pd.read_csv(%s)", pyString(file1)),
+		fmt.Sprintf("df2 = pd.read_csv(%s)", pyString(file2)),
+		"print(df1.shape, df2.shape)",
+	)
+
+	// Mapping application
+	b.markdown("## Apply mapping", "", "Column correspondences from the approved mapping. Transforms are copied as written and must be translated to pandas by hand.")
+	apply := []string{"column_mapping = {  # file 2 column -> file 1 column"}
+	for _, m := range mapped {
+		apply = append(apply, fmt.Sprintf("    %s: %s,", pyString(m.File2Column), pyString(m.File1Column)))
+	}
+	apply = append(apply, "}")
+	for _, m := range mapped {
+		if m.Transform != "" {
+			apply = append(apply, fmt.Sprintf("# TODO transform for %s: %s", m.File1Column, strings.ReplaceAll(m.Transform, "\n", " ")))
+		}
+	}
+	b.code(apply...)
+
+	// Join
+	b.markdown("## Join")
+	if len(keys) == 0 {
+		b.code("merged = pd.merge(df1, df2, how='cross', suffixes=('', '_file2'))", "merged.head()")
+	} else {
+		left := make([]string, len(keys))
+		right := make([]string, len(keys))
+		for i, pair := range keys {
+			left[i] = pyString(pair[0])
+			right[i] = pyString(pair[1])
+		}
+		b.code(
+			fmt.Sprintf("left_keys = [%s]", strings.Join(left, ", ")),
+			fmt.Sprintf("right_keys = [%s]", strings.Join(right, ", ")),
+			"",
+			"merged = pd.merge(df1, df2, left_on=left_keys, right_on=right_keys, how='left', suffixes=('', '_file2'), indicator=True)",
+			"merged.head()",
+		)
+
+		// Validation
+		b.markdown("## Validation checks")
+		b.code(
+			"def file2_col(name):",
+			"    # Columns of file 2 that clash with file 1 get the _file2 suffix",
+			"    return f'{name}_file2' if f'{name}_file2' in merged.columns else name",
+			"",
+			"checks = {",
+			"    'file1_rows': len(df1),",
+			"    'file2_rows': len(df2),",
+			"    'joined_rows': int((merged['_merge'] == 'both').sum()),",
+			"    'file1_match_rate': float(df1.merge(df2[right_keys].drop_duplicates(), left_on=left_keys, right_on=right_keys, how='left', indicator=True)['_merge'].eq('both').mean()),",
+			"    'file1_key_nulls': int(df1[left_keys].isna().any(axis=1).sum()),",
+			"    'file2_key_nulls': int(df2[right_keys].isna().any(axis=1).sum()),",
+			"    'file2_key_duplicates': int(df2.duplicated(subset=right_keys).sum()),",
+			"}",
+			"pd.Series(checks)",
+		)
+		b.code(
+			"# Agreement of mapped (non-key) columns on joined rows",
+			"both = merged[merged['_merge'] == 'both']",
+			"agreement = {}",
+			"for c2, c1 in column_mapping.items():",
+			"    if c1 in left_keys:",
+			"        continue",
+			"    agreement[f'{c1} ~ {c2}'] = float((both[c1].astype(str) == both[file2_col(c2)].astype(str)).mean())",
+			"pd.Series(agreement, dtype=float)",
+		)
+	}
+
+	// Profiling
+	b.markdown("## Profiling")
+	b.code(
+		"fig, axes = plt.subplots(1, 2, figsize=(12, 4))",
+		"df1.isna().mean().sort_values().plot.barh(ax=axes[0], title='File 1 null rate')",
+		"df2.isna().mean().sort_values().plot.barh(ax=axes[1], title='File 2 null rate')",
+		"plt.tight_layout()",
+	)
+	b.code(
+		"# Distributions of numeric mapped columns, file 1 vs file 2",
+		"for c2, c1 in column_mapping.items():",
+		"    if pd.api.types.is_numeric_dtype(df1[c1]) and pd.api.types.is_numeric_dtype(df2[c2]):",
+		"        ax = df1[c1].plot.hist(bins=30, alpha=0.5, label='file1', title=f'{c1} ~ {c2}')",
+		"        df2[c2].plot.hist(bins=30, alpha=0.5, label='file2', ax=ax)",
+		"        ax.legend()",
+		"        plt.show()",
+	)
+
+	nb := notebook{
+		Cells: b.cells,
+		Metadata: map[string]interface{}{
+			"kernelspec": map[string]string{
+				"display_name": "Python 3",
+				"language":     "python",
+				"name":         "python3",
+			},
+			"language_info": map[string]string{"name": "python"},
+		},
+		NBFormat:      4,
+		NBFormatMinor: 4,
+	}
+	return json.MarshalIndent(nb, "", " ")
+}
+
+// mdCell escapes a value for a Markdown table cell
+func mdCell(s string) string {
+	s = strings.ReplaceAll(s, "|", "\\|")
+	return strings.ReplaceAll(s, "\n", " ")
+}
+
+func yesNo(b bool) string {
+	if b {
+		return "yes"
+	}
+	return "no"
+}
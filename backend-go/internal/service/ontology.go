@@ -0,0 +1,238 @@
+package service
+
+import (
+	"backend-go/internal/llm"
+	"math"
+	"strings"
+)
+
+// Concept tag sources
+const (
+	ConceptSourceHeuristic = "heuristic" // Name, synonyms, type and semantic type
+	ConceptSourceLLM       = "llm"
+)
+
+// OntologyConcept is a concept of a standard vocabulary a column can
+// represent; the embedded column drives the matching
+type OntologyConcept struct {
+	ID    string `json:"id"`    // Prefixed name, e.g. schema:givenName
+	Class string `json:"class"` // The class the property belongs to
+	IRI   string `json:"iri,omitempty"`
+	CanonicalColumn
+}
+
+// Vocabulary is a built-in set of target concepts
+type Vocabulary struct {
+	ID           string            `json:"id"`
+	Name         string            `json:"name"`
+	Description  string            `json:"description"`
+	URL          string            `json:"url"`
+	ConceptCount int               `json:"concept_count,omitempty"`
+	Concepts     []OntologyConcept `json:"concepts,omitempty"`
+}
+
+// ConceptTag is the concept proposed for a column
+type ConceptTag struct {
+	ID         string  `json:"id"`
+	Label      string  `json:"label"`
+	Class      string  `json:"class"`
+	IRI        string  `json:"iri,omitempty"`
+	Confidence float64 `json:"confidence"` // 0-100
+	Source     string  `json:"source"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// concept builds a concept; t and semantic may be empty
+func concept(id, class, iri, label, t, semantic, description string, aliases ...string) OntologyConcept {
+	return OntologyConcept{ID: id, Class: class, IRI: iri, CanonicalColumn: CanonicalColumn{
+		Name: label, Type: t, SemanticType: semantic, Description: description, Aliases: aliases,
+	}}
+}
+
+func schemaOrg(class, property, label, t, semantic, description string, aliases ...string) OntologyConcept {
+	return concept("schema:"+property, class, "https://schema.org/"+property, label, t, semantic, description, aliases...)
+}
+
+func fhir(path, label, t, semantic, description string, aliases ...string) OntologyConcept {
+	class, _, _ := strings.Cut(path, ".")
+	return concept("fhir:"+path, class, "", label, t, semantic, description, aliases...)
+}
+
+func gs1(class, property, label, t, semantic, description string, aliases ...string) OntologyConcept {
+	return concept("gs1:"+property, class, "https://ref.gs1.org/voc/"+property, label, t, semantic, description, aliases...)
+}
+
+// builtInVocabularies are the standards columns can be mapped to
+func builtInVocabularies() []Vocabulary {
+	return []Vocabulary{
+		{
+			ID:          "schema.org",
+			Name:        "Schema.org",
+			Description: "People, organizations, addresses, orders and products",
+			URL:         "https://schema.org",
+			Concepts: []OntologyConcept{
+				schemaOrg("Thing", "identifier", "identifier", "", "", "An identifier of the item", "id", "key"),
+				schemaOrg("Thing", "name", "name", "string", "name", "The name of the item", "full_name"),
+				schemaOrg("Person", "givenName", "given_name", "string", "name", "Given name; the first name in the U.S.", "first_name", "forename"),
+				schemaOrg("Person", "familyName", "family_name", "string", "name", "Family name; the last name in the U.S.", "last_name", "surname"),
+				schemaOrg("Person", "email", "email", "string", "email", "Email address", "email_address", "mail"),
+				schemaOrg("Person", "telephone", "telephone", "string", "phone", "The telephone number", "phone", "phone_number", "mobile"),
+				schemaOrg("Person", "birthDate", "birth_date", "time", "date", "Date of birth", "dob", "date_of_birth"),
+				schemaOrg("Person", "gender", "gender", "string", "", "Gender of the person", "sex"),
+				schemaOrg("Organization", "legalName", "legal_name", "string", "", "The official name of the organization", "company", "company_name", "organization"),
+				schemaOrg("PostalAddress", "streetAddress", "street_address", "string", "", "The street address", "address", "address_line", "street"),
+				schemaOrg("PostalAddress", "addressLocality", "address_locality", "string", "", "The locality, e.g. the city", "city", "town"),
+				schemaOrg("PostalAddress", "addressRegion", "address_region", "string", "", "The region, e.g. the state", "state", "province", "region"),
+				schemaOrg("PostalAddress", "postalCode", "postal_code", "", "zipcode", "The postal code", "zip", "zip_code", "postcode"),
+				schemaOrg("PostalAddress", "addressCountry", "address_country", "string", "", "The country", "country", "country_code"),
+				schemaOrg("Thing", "url", "url", "string", "url", "URL of the item", "website", "homepage"),
+				schemaOrg("Order", "orderNumber", "order_number", "", "", "The identifier of the order", "order_id", "order_no"),
+				schemaOrg("Order", "orderDate", "order_date", "time", "date", "Date the order was placed", "ordered_at", "purchase_date"),
+				schemaOrg("Offer", "price", "price", "float", "", "The price of the offer", "amount", "unit_price"),
+				schemaOrg("Offer", "priceCurrency", "price_currency", "string", "", "The currency of the price, ISO 4217", "currency", "currency_code"),
+				schemaOrg("Product", "sku", "sku", "", "", "The Stock Keeping Unit of the product", "product_code", "item_code"),
+			},
+		},
+		{
+			ID:          "fhir",
+			Name:        "HL7 FHIR R4",
+			Description: "Patients, encounters, observations and practitioners",
+			URL:         "https://hl7.org/fhir/R4",
+			Concepts: []OntologyConcept{
+				fhir("Patient.identifier", "patient_identifier", "", "", "An identifier of the patient", "patient_id", "mrn", "medical_record_number"),
+				fhir("Patient.name.given", "given_name", "string", "name", "Given names of the patient", "first_name", "forename"),
+				fhir("Patient.name.family", "family_name", "string", "name", "Family name of the patient", "last_name", "surname"),
+				fhir("Patient.birthDate", "birth_date", "time", "date", "Date of birth of the patient", "dob", "date_of_birth"),
+				fhir("Patient.gender", "gender", "string", "", "Administrative gender: male, female, other or unknown", "sex"),
+				fhir("Patient.telecom", "telecom", "string", "", "A contact detail, e.g. a phone number or email", "phone", "email", "contact"),
+				fhir("Patient.address.line", "address_line", "string", "", "Street name, number and unit", "address", "street"),
+				fhir("Patient.address.city", "address_city", "string", "", "Name of the city or town", "city", "town"),
+				fhir("Patient.address.postalCode", "postal_code", "", "zipcode", "Postal code of the area", "zip", "zip_code"),
+				fhir("Patient.deceasedDateTime", "deceased_date_time", "time", "date", "When the patient died", "date_of_death", "death_date"),
+				fhir("Encounter.identifier", "encounter_identifier", "", "", "An identifier of the encounter", "encounter_id", "visit_id"),
+				fhir("Encounter.period.start", "encounter_start", "time", "date", "Start of the encounter", "admission_date", "admit_date"),
+				fhir("Encounter.period.end", "encounter_end", "time", "date", "End of the encounter", "discharge_date"),
+				fhir("Observation.code", "observation_code", "string", "", "What was observed, e.g. a LOINC code", "test_code", "loinc"),
+				fhir("Observation.valueQuantity.value", "observation_value", "float", "", "Numeric result of the observation", "result", "result_value", "value"),
+				fhir("Observation.valueQuantity.unit", "observation_unit", "string", "", "Unit of the result", "unit", "units"),
+				fhir("Observation.effectiveDateTime", "effective_date_time", "time", "date", "Clinically relevant time of the observation", "observation_date", "collected_at"),
+				fhir("Practitioner.identifier", "practitioner_identifier", "", "", "An identifier of the practitioner, e.g. the NPI", "npi", "provider_id", "doctor_id"),
+			},
+		},
+		{
+			ID:          "gs1",
+			Name:        "GS1 Web Vocabulary",
+			Description: "Product master data, logistics units and locations",
+			URL:         "https://ref.gs1.org/voc",
+			Concepts: []OntologyConcept{
+				gs1("Product", "gtin", "gtin", "", "", "Global Trade Item Number", "ean", "upc", "barcode", "ean13"),
+				gs1("Product", "productName", "product_name", "string", "", "The consumer-facing name of the product", "item_name", "product"),
+				gs1("Product", "brandName", "brand_name", "string", "", "The brand of the product", "brand"),
+				gs1("Product", "productDescription", "product_description", "string", "", "A description of the product", "description", "item_description"),
+				gs1("Product", "gpcCategoryCode", "gpc_category_code", "", "", "GS1 Global Product Classification brick code", "category_code", "gpc"),
+				gs1("Product", "netContent", "net_content", "float", "", "The quantity of the product in its package", "net_quantity", "content"),
+				gs1("Product", "netWeight", "net_weight", "float", "", "Weight of the product without its packaging", "weight"),
+				gs1("Product", "grossWeight", "gross_weight", "float", "", "Weight of the product with its packaging"),
+				gs1("Product", "countryOfOrigin", "country_of_origin", "string", "", "Where the product was produced", "origin", "origin_country"),
+				gs1("Product", "manufacturer", "manufacturer", "string", "", "The party that made the product", "manufacturer_name", "supplier"),
+				gs1("Product", "batchNumber", "batch_number", "string", "", "Batch or lot the product belongs to", "lot", "lot_number", "batch"),
+				gs1("Product", "expirationDate", "expiration_date", "time", "date", "Date after which the product should not be used", "expiry", "expiry_date", "best_before"),
+				gs1("Place", "gln", "gln", "", "", "Global Location Number", "location_number", "global_location_number"),
+				gs1("LogisticUnit", "sscc", "sscc", "", "", "Serial Shipping Container Code", "pallet_id", "shipping_container_code"),
+			},
+		},
+	}
+}
+
+// Vocabularies lists the built-in vocabularies without their concepts
+func Vocabularies() []Vocabulary {
+	list := builtInVocabularies()
+	for i := range list {
+		list[i].ConceptCount = len(list[i].Concepts)
+		list[i].Concepts = nil
+	}
+	return list
+}
+
+// GetVocabulary looks up a built-in vocabulary by ID
+func GetVocabulary(id string) (Vocabulary, bool) {
+	for _, v := range builtInVocabularies() {
+		if v.ID == id {
+			return v, true
+		}
+	}
+	return Vocabulary{}, false
+}
+
+// Concept looks up a concept of the vocabulary by ID
+func (v Vocabulary) Concept(id string) (OntologyConcept, bool) {
+	for _, c := range v.Concepts {
+		if c.ID == id {
+			return c, true
+		}
+	}
+	return OntologyConcept{}, false
+}
+
+// TagConcepts tags each column of a dictionary with the concept of the
+// vocabulary it scores highest against, when that reaches the profile's
+// match threshold. Several columns may share a concept, e.g. two phones.
+func (s *EnhancedSimilarityService) TagConcepts(dict *DataDictionary, vocab Vocabulary, profile MatchingProfile) {
+	dict.Vocabulary = vocab.ID
+	for i := range dict.Columns {
+		col := &dict.Columns[i]
+		col.Concept = nil
+		best := 0.0
+		for _, c := range vocab.Concepts {
+			name := s.schemaNameSimilarity(col.Name, c.CanonicalColumn, profile.NameAlgorithm)
+			confidence := conformanceScore(name, c.CanonicalColumn, *col)
+			if confidence < profile.MatchThreshold || confidence <= best {
+				continue
+			}
+			best = confidence
+			col.Concept = c.tag(confidence, ConceptSourceHeuristic, "")
+		}
+	}
+}
+
+// ConceptOptions lists the concepts of the vocabulary for the LLM
+func (v Vocabulary) ConceptOptions() []llm.ConceptOption {
+	options := make([]llm.ConceptOption, len(v.Concepts))
+	for i, c := range v.Concepts {
+		options[i] = llm.ConceptOption{ID: c.ID, Description: c.Description}
+	}
+	return options
+}
+
+// ApplyConceptProposals replaces the heuristic tags with the LLM's
+// proposals for concepts of the vocabulary; proposals naming unknown
+// columns or concepts are ignored. It returns the number applied.
+func ApplyConceptProposals(dict *DataDictionary, vocab Vocabulary, proposals []llm.ConceptProposal) int {
+	applied := 0
+	for _, p := range proposals {
+		c, ok := vocab.Concept(p.ConceptID)
+		if !ok || p.Confidence < 0.5 {
+			continue
+		}
+		for i := range dict.Columns {
+			if dict.Columns[i].Name == p.Column {
+				dict.Columns[i].Concept = c.tag(p.Confidence*100, ConceptSourceLLM, p.Reason)
+				applied++
+				break
+			}
+		}
+	}
+	return applied
+}
+
+func (c OntologyConcept) tag(confidence float64, source, reason string) *ConceptTag {
+	return &ConceptTag{
+		ID:         c.ID,
+		Label:      c.Name,
+		Class:      c.Class,
+		IRI:        c.IRI,
+		Confidence: math.Round(confidence*10) / 10,
+		Source:     source,
+		Reason:     reason,
+	}
+}
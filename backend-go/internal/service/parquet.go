@@ -0,0 +1,236 @@
+package service
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+)
+
+// Minimal Parquet writer: every column is an optional UTF8 BYTE_ARRAY, PLAIN
+// encoded and uncompressed, in one row group with one data page per column.
+// Empty cells are written as nulls. That's enough for pandas, Spark, DuckDB
+// and friends to read the joined export without a Parquet dependency.
+
+const parquetMagic = "PAR1"
+
+// Parquet enum values (parquet.thrift)
+const (
+	parquetTypeByteArray      = 6
+	parquetRepetitionOptional = 1
+	parquetConvertedUTF8      = 0
+	parquetEncodingPlain      = 0
+	parquetEncodingRLE        = 3
+	parquetCodecUncompressed  = 0
+	parquetPageData           = 0
+)
+
+// WriteParquet writes headers and rows as a Parquet file
+func WriteParquet(w io.Writer, headers []string, rows [][]string) error {
+	var file bytes.Buffer
+	file.WriteString(parquetMagic)
+
+	type chunkInfo struct {
+		offset, size int64
+		numValues    int64
+	}
+	chunks := make([]chunkInfo, len(headers))
+	var totalSize int64
+
+	for c := range headers {
+		page := parquetColumnPage(rows, c)
+
+		header := &thriftWriter{}
+		header.fieldI32(1, parquetPageData)
+		header.fieldI32(2, int32(len(page)))
+		header.fieldI32(3, int32(len(page)))
+		header.fieldStruct(5, func(t *thriftWriter) {
+			t.fieldI32(1, int32(len(rows)))
+			t.fieldI32(2, parquetEncodingPlain)
+			t.fieldI32(3, parquetEncodingRLE)
+			t.fieldI32(4, parquetEncodingRLE)
+		})
+		header.stop()
+
+		chunks[c] = chunkInfo{
+			offset:    int64(file.Len()),
+			size:      int64(header.buf.Len() + len(page)),
+			numValues: int64(len(rows)),
+		}
+		totalSize += chunks[c].size
+		file.Write(header.buf.Bytes())
+		file.Write(page)
+	}
+
+	meta := &thriftWriter{}
+	meta.fieldI32(1, 1) // version
+	meta.fieldList(2, thriftStruct, len(headers)+1, func(t *thriftWriter) {
+		t.structElem(func(t *thriftWriter) {
+			t.fieldBinary(4, "schema")
+			t.fieldI32(5, int32(len(headers)))
+		})
+		for _, h := range headers {
+			t.structElem(func(t *thriftWriter) {
+				t.fieldI32(1, parquetTypeByteArray)
+				t.fieldI32(3, parquetRepetitionOptional)
+				t.fieldBinary(4, h)
+				t.fieldI32(6, parquetConvertedUTF8)
+			})
+		}
+	})
+	meta.fieldI64(3, int64(len(rows)))
+	meta.fieldList(4, thriftStruct, 1, func(t *thriftWriter) {
+		t.structElem(func(t *thriftWriter) {
+			t.fieldList(1, thriftStruct, len(headers), func(t *thriftWriter) {
+				for c, h := range headers {
+					chunk := chunks[c]
+					t.structElem(func(t *thriftWriter) {
+						t.fieldI64(2, chunk.offset)
+						t.fieldStruct(3, func(t *thriftWriter) {
+							t.fieldI32(1, parquetTypeByteArray)
+							t.fieldList(2, thriftI32, 2, func(t *thriftWriter) {
+								t.varint(zigzag(parquetEncodingPlain))
+								t.varint(zigzag(parquetEncodingRLE))
+							})
+							t.fieldList(3, thriftBinary, 1, func(t *thriftWriter) {
+								t.binary(h)
+							})
+							t.fieldI32(4, parquetCodecUncompressed)
+							t.fieldI64(5, chunk.numValues)
+							t.fieldI64(6, chunk.size)
+							t.fieldI64(7, chunk.size)
+							t.fieldI64(9, chunk.offset)
+						})
+					})
+				}
+			})
+			t.fieldI64(2, totalSize)
+			t.fieldI64(3, int64(len(rows)))
+		})
+	})
+	meta.fieldBinary(6, "Project Euler")
+	meta.stop()
+
+	file.Write(meta.buf.Bytes())
+	binary.Write(&file, binary.LittleEndian, uint32(meta.buf.Len()))
+	file.WriteString(parquetMagic)
+
+	_, err := w.Write(file.Bytes())
+	return err
+}
+
+// parquetColumnPage encodes the data page body of column c: definition
+// levels (RLE/bit-packed hybrid, bit width 1) followed by the PLAIN values
+// of the non-null cells
+func parquetColumnPage(rows [][]string, c int) []byte {
+	// Bit-packed run: one bit per row, groups of 8
+	groups := (len(rows) + 7) / 8
+	levels := make([]byte, groups)
+	var values bytes.Buffer
+	for r, row := range rows {
+		if c >= len(row) || row[c] == "" {
+			continue
+		}
+		levels[r/8] |= 1 << (uint(r) % 8)
+		binary.Write(&values, binary.LittleEndian, uint32(len(row[c])))
+		values.WriteString(row[c])
+	}
+
+	run := &thriftWriter{}
+	if groups > 0 {
+		run.varint(uint64(groups)<<1 | 1)
+		run.buf.Write(levels)
+	}
+
+	var page bytes.Buffer
+	binary.Write(&page, binary.LittleEndian, uint32(run.buf.Len()))
+	page.Write(run.buf.Bytes())
+	page.Write(values.Bytes())
+	return page.Bytes()
+}
+
+// Thrift compact protocol types
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes structs with the Thrift compact protocol
+type thriftWriter struct {
+	buf     bytes.Buffer
+	lastIDs []int16 // Last field id per open struct
+	lastID  int16
+}
+
+func zigzag(v int64) uint64 {
+	return uint64((v << 1) ^ (v >> 63))
+}
+
+func (t *thriftWriter) varint(v uint64) {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	t.buf.Write(tmp[:n])
+}
+
+func (t *thriftWriter) fieldHeader(id int16, typ byte) {
+	if delta := id - t.lastID; delta > 0 && delta <= 15 {
+		t.buf.WriteByte(byte(delta)<<4 | typ)
+	} else {
+		t.buf.WriteByte(typ)
+		t.varint(zigzag(int64(id)))
+	}
+	t.lastID = id
+}
+
+func (t *thriftWriter) binary(s string) {
+	t.varint(uint64(len(s)))
+	t.buf.WriteString(s)
+}
+
+func (t *thriftWriter) fieldI32(id int16, v int32) {
+	t.fieldHeader(id, thriftI32)
+	t.varint(zigzag(int64(v)))
+}
+
+func (t *thriftWriter) fieldI64(id int16, v int64) {
+	t.fieldHeader(id, thriftI64)
+	t.varint(zigzag(v))
+}
+
+func (t *thriftWriter) fieldBinary(id int16, s string) {
+	t.fieldHeader(id, thriftBinary)
+	t.binary(s)
+}
+
+func (t *thriftWriter) fieldStruct(id int16, body func(*thriftWriter)) {
+	t.fieldHeader(id, thriftStruct)
+	t.structElem(body)
+}
+
+// fieldList writes a list header; body writes the n elements
+func (t *thriftWriter) fieldList(id int16, elemType byte, n int, body func(*thriftWriter)) {
+	t.fieldHeader(id, thriftList)
+	if n < 15 {
+		t.buf.WriteByte(byte(n)<<4 | elemType)
+	} else {
+		t.buf.WriteByte(0xf0 | elemType)
+		t.varint(uint64(n))
+	}
+	body(t)
+}
+
+// structElem writes a nested struct (fields then stop)
+func (t *thriftWriter) structElem(body func(*thriftWriter)) {
+	t.lastIDs = append(t.lastIDs, t.lastID)
+	t.lastID = 0
+	body(t)
+	t.stop()
+	t.lastID = t.lastIDs[len(t.lastIDs)-1]
+	t.lastIDs = t.lastIDs[:len(t.lastIDs)-1]
+}
+
+func (t *thriftWriter) stop() {
+	t.buf.WriteByte(0)
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"testing"
+
+	"github.com/parquet-go/parquet-go"
+	"github.com/parquet-go/parquet-go/deprecated"
+)
+
+// readParquet reads a file back with parquet-go, an independent reader,
+// into the cells WriteParquet was given, nulls as ""
+func readParquet(t *testing.T, data []byte) (*parquet.File, []string, [][]string) {
+	t.Helper()
+	f, err := parquet.OpenFile(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	headers := []string{}
+	for _, field := range f.Schema().Fields() {
+		headers = append(headers, field.Name())
+	}
+
+	rows := [][]string{}
+	for _, rg := range f.RowGroups() {
+		reader := rg.Rows()
+		buf := make([]parquet.Row, 16)
+		for {
+			n, err := reader.ReadRows(buf)
+			for _, row := range buf[:n] {
+				cells := make([]string, len(headers))
+				for _, v := range row {
+					if !v.IsNull() {
+						cells[v.Column()] = string(v.ByteArray())
+					}
+				}
+				rows = append(rows, cells)
+			}
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("read rows: %v", err)
+			}
+		}
+		reader.Close()
+	}
+	return f, headers, rows
+}
+
+func TestWriteParquetRoundTrip(t *testing.T) {
+	// 20 columns take the long list header form; 19 rows span three groups
+	// of definition level bits
+	headers := []string{"id", "name", "city", "émoji 🎉", "notes"}
+	for i := len(headers); i < 20; i++ {
+		headers = append(headers, fmt.Sprintf("col_%d", i))
+	}
+	rows := [][]string{}
+	for r := 0; r < 19; r++ {
+		row := make([]string, len(headers))
+		for c := range row {
+			if (r+c)%4 != 0 {
+				row[c] = fmt.Sprintf("r%d c%d", r, c)
+			}
+		}
+		rows = append(rows, row)
+	}
+	rows[3][3] = "Zürich 🎉"
+	rows[5] = []string{"short", "row"} // Missing cells are nulls
+	rows[7][4] = string(bytes.Repeat([]byte("x"), 5000))
+
+	var out bytes.Buffer
+	if err := WriteParquet(&out, headers, rows); err != nil {
+		t.Fatal(err)
+	}
+	f, gotHeaders, gotRows := readParquet(t, out.Bytes())
+
+	if fmt.Sprint(gotHeaders) != fmt.Sprint(headers) {
+		t.Fatalf("headers %q, want %q", gotHeaders, headers)
+	}
+	for _, field := range f.Schema().Fields() {
+		if !field.Optional() || field.Type().Kind() != parquet.ByteArray {
+			t.Errorf("column %s: want an optional byte array", field.Name())
+		}
+		if ct := field.Type().ConvertedType(); ct == nil || *ct != deprecated.UTF8 {
+			t.Errorf("column %s: want converted type UTF8", field.Name())
+		}
+	}
+	if f.NumRows() != int64(len(rows)) || len(gotRows) != len(rows) {
+		t.Fatalf("read %d rows (metadata %d), want %d", len(gotRows), f.NumRows(), len(rows))
+	}
+	for r, row := range rows {
+		for c := range headers {
+			want := ""
+			if c < len(row) {
+				want = row[c]
+			}
+			if gotRows[r][c] != want {
+				t.Errorf("row %d column %d: got %q, want %q", r, c, gotRows[r][c], want)
+			}
+		}
+	}
+}
+
+func TestWriteParquetEmpty(t *testing.T) {
+	var out bytes.Buffer
+	if err := WriteParquet(&out, []string{"a", "b"}, nil); err != nil {
+		t.Fatal(err)
+	}
+	f, headers, rows := readParquet(t, out.Bytes())
+	if len(headers) != 2 || len(rows) != 0 || f.NumRows() != 0 {
+		t.Errorf("got headers %q and %d rows, want 2 headers and no rows", headers, len(rows))
+	}
+}
@@ -1,8 +1,11 @@
 package service
 
 import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
 	"encoding/json"
-	"log"
+	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strings"
@@ -10,7 +13,9 @@ import (
 	"time"
 )
 
-const patternLearningFile = "./data/pattern_learning.json"
+var patternLog = logging.Component("pattern_learner")
+
+const patternLearningFile = "pattern_learning.json"
 
 // PatternRule represents a learned pattern transformation
 type PatternRule struct {
@@ -56,13 +61,13 @@ func GetPatternLearner() *PatternLearner {
 
 // load loads patterns from file
 func (p *PatternLearner) load() {
-	dir := filepath.Dir(patternLearningFile)
+	dir := filepath.Dir(config.DataPath(patternLearningFile))
 	os.MkdirAll(dir, 0755)
 
-	data, err := os.ReadFile(patternLearningFile)
+	data, err := os.ReadFile(config.DataPath(patternLearningFile))
 	if err != nil {
 		if !os.IsNotExist(err) {
-			log.Printf("[PatternLearner] Error loading patterns: %v", err)
+			patternLog.Error("Error loading patterns", "error", err)
 		}
 		return
 	}
@@ -72,7 +77,7 @@ func (p *PatternLearner) load() {
 		TokenMappings map[string]TokenMapping `json:"token_mappings"`
 	}
 	if err := json.Unmarshal(data, &saved); err != nil {
-		log.Printf("[PatternLearner] Error parsing patterns: %v", err)
+		patternLog.Error("Error parsing patterns", "error", err)
 		return
 	}
 
@@ -83,8 +88,8 @@ func (p *PatternLearner) load() {
 	}
 	p.mutex.Unlock()
 
-	log.Printf("[PatternLearner] Loaded %d patterns and %d token mappings",
-		len(p.patterns), len(p.tokenMappings))
+	patternLog.Info("Loaded patterns",
+		"patterns", len(p.patterns), "token_mappings", len(p.tokenMappings))
 }
 
 // save persists patterns to file
@@ -100,10 +105,10 @@ func (p *PatternLearner) save() error {
 		return err
 	}
 
-	dir := filepath.Dir(patternLearningFile)
+	dir := filepath.Dir(config.DataPath(patternLearningFile))
 	os.MkdirAll(dir, 0755)
 
-	return os.WriteFile(patternLearningFile, data, 0644)
+	return os.WriteFile(config.DataPath(patternLearningFile), data, 0644)
 }
 
 // LearnFromPositive learns from a confirmed correct match
@@ -111,6 +116,16 @@ func (p *PatternLearner) LearnFromPositive(col1, col2 string) {
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	pattern1, pattern2 := p.learnPositive(col1, col2, time.Now())
+
+	go p.save()
+
+	patternLog.Info("Learned positive pattern",
+		"file1_column", col1, "file2_column", col2, "pattern1", pattern1, "pattern2", pattern2)
+}
+
+// learnPositive updates rules and token mappings for a correct match (must hold lock)
+func (p *PatternLearner) learnPositive(col1, col2 string, at time.Time) (string, string) {
 	// Extract patterns
 	pattern1 := extractPattern(col1)
 	pattern2 := extractPattern(col2)
@@ -122,7 +137,7 @@ func (p *PatternLearner) LearnFromPositive(col1, col2 string) {
 			p.patterns[i].SuccessCount++
 			p.patterns[i].Confidence = calculatePatternConfidence(
 				p.patterns[i].SuccessCount, p.patterns[i].FailCount)
-			p.patterns[i].LastUpdated = time.Now()
+			p.patterns[i].LastUpdated = at
 			found = true
 			break
 		}
@@ -135,7 +150,7 @@ func (p *PatternLearner) LearnFromPositive(col1, col2 string) {
 			Confidence:   0.7, // Initial confidence
 			SuccessCount: 1,
 			FailCount:    0,
-			LastUpdated:  time.Now(),
+			LastUpdated:  at,
 		})
 	}
 
@@ -163,10 +178,7 @@ func (p *PatternLearner) LearnFromPositive(col1, col2 string) {
 		}
 	}
 
-	go p.save()
-
-	log.Printf("[PatternLearner] Learned positive: %s ↔ %s (pattern: %s ↔ %s)",
-		col1, col2, pattern1, pattern2)
+	return pattern1, pattern2
 }
 
 // LearnFromNegative learns from a confirmed incorrect match
@@ -174,6 +186,16 @@ func (p *PatternLearner) LearnFromNegative(col1, col2 string, nameSim, dataSim f
 	p.mutex.Lock()
 	defer p.mutex.Unlock()
 
+	p.learnNegative(col1, col2, time.Now())
+
+	go p.save()
+
+	patternLog.Info("Learned negative pattern",
+		"file1_column", col1, "file2_column", col2, "name_similarity", nameSim, "data_similarity", dataSim)
+}
+
+// learnNegative penalizes rules and token mappings for an incorrect match (must hold lock)
+func (p *PatternLearner) learnNegative(col1, col2 string, at time.Time) {
 	pattern1 := extractPattern(col1)
 	pattern2 := extractPattern(col2)
 
@@ -183,7 +205,7 @@ func (p *PatternLearner) LearnFromNegative(col1, col2 string, nameSim, dataSim f
 			p.patterns[i].FailCount++
 			p.patterns[i].Confidence = calculatePatternConfidence(
 				p.patterns[i].SuccessCount, p.patterns[i].FailCount)
-			p.patterns[i].LastUpdated = time.Now()
+			p.patterns[i].LastUpdated = at
 			break
 		}
 	}
@@ -203,29 +225,125 @@ func (p *PatternLearner) LearnFromNegative(col1, col2 string, nameSim, dataSim f
 			}
 		}
 	}
+}
 
-	go p.save()
+// Revise takes back what one feedback entry taught and, unless updated is
+// nil, learns from its edited version instead. Rules and mappings learned from
+// other feedback or imported are untouched, and deleted ones stay deleted.
+func (p *PatternLearner) Revise(old FeedbackEntry, updated *FeedbackEntry) {
+	p.mutex.Lock()
+	p.unlearn(old.File1Column, old.File2Column, old.IsCorrect)
+	if updated != nil {
+		if updated.IsCorrect {
+			p.learnPositive(updated.File1Column, updated.File2Column, time.Now())
+		} else {
+			p.learnNegative(updated.File1Column, updated.File2Column, time.Now())
+		}
+	}
+	p.mutex.Unlock()
+
+	if err := p.save(); err != nil {
+		patternLog.Error("Error saving patterns", "error", err)
+	}
+}
+
+// unlearn reverses learnPositive or learnNegative for a column pair (must hold lock).
+// A rule left without evidence and a mapping without occurrences are dropped.
+func (p *PatternLearner) unlearn(col1, col2 string, correct bool) {
+	pattern1 := extractPattern(col1)
+	pattern2 := extractPattern(col2)
+
+	for i := range p.patterns {
+		rule := &p.patterns[i]
+		if rule.Pattern1 != pattern1 || rule.Pattern2 != pattern2 {
+			continue
+		}
+		if correct && rule.SuccessCount > 0 {
+			rule.SuccessCount--
+		} else if !correct && rule.FailCount > 0 {
+			rule.FailCount--
+		}
+		if rule.SuccessCount+rule.FailCount == 0 {
+			p.patterns = append(p.patterns[:i], p.patterns[i+1:]...)
+		} else {
+			rule.Confidence = calculatePatternConfidence(rule.SuccessCount, rule.FailCount)
+		}
+		break
+	}
 
-	log.Printf("[PatternLearner] Learned negative: %s ↔ %s (nameSim=%.2f, dataSim=%.2f)",
-		col1, col2, nameSim, dataSim)
+	for _, t1 := range tokenizeColumn(col1) {
+		for _, t2 := range tokenizeColumn(col2) {
+			key := t1 + "|" + t2
+			mapping, exists := p.tokenMappings[key]
+			if !exists {
+				continue
+			}
+			if correct {
+				mapping.Occurrences--
+				if mapping.Occurrences <= 0 {
+					delete(p.tokenMappings, key)
+					continue
+				}
+				mapping.Score = math.Min(mapping.Score, tokenMappingScore(mapping.Occurrences))
+			} else {
+				mapping.Score = math.Min(mapping.Score/0.8, tokenMappingScore(mapping.Occurrences))
+			}
+			p.tokenMappings[key] = mapping
+		}
+	}
+}
+
+// tokenMappingScore is the score learnPositive gives a mapping seen n times
+func tokenMappingScore(n int) float64 {
+	if n <= 1 {
+		return 0.6
+	}
+	return 0.5 + (0.5 * float64(n) / float64(n+5))
+}
+
+// PatternMatchExplanation describes which learned rules apply to a column pair
+type PatternMatchExplanation struct {
+	File1Column   string         `json:"file1_column"`
+	File2Column   string         `json:"file2_column"`
+	Pattern1      string         `json:"pattern1"`
+	Pattern2      string         `json:"pattern2"`
+	Rule          *PatternRule   `json:"rule,omitempty"` // Matching pattern rule, if any
+	RuleFired     bool           `json:"rule_fired"`     // Rule confidence was decisive (>0.7 or <0.3)
+	TokenMappings []TokenMapping `json:"token_mappings"` // Token mappings that matched
+	Boost         float64        `json:"boost"`
 }
 
 // GetPatternBoost returns a confidence boost based on learned patterns
 func (p *PatternLearner) GetPatternBoost(col1, col2 string) float64 {
+	return p.ExplainPatternBoost(col1, col2).Boost
+}
+
+// ExplainPatternBoost evaluates the learned rules for a column pair without
+// changing anything, reporting which rule or token mappings produced the boost
+func (p *PatternLearner) ExplainPatternBoost(col1, col2 string) PatternMatchExplanation {
 	p.mutex.RLock()
 	defer p.mutex.RUnlock()
 
 	pattern1 := extractPattern(col1)
 	pattern2 := extractPattern(col2)
+	explanation := PatternMatchExplanation{
+		File1Column:   col1,
+		File2Column:   col2,
+		Pattern1:      pattern1,
+		Pattern2:      pattern2,
+		TokenMappings: []TokenMapping{},
+	}
 
 	// Check for matching pattern rule
 	for _, rule := range p.patterns {
 		if rule.Pattern1 == pattern1 && rule.Pattern2 == pattern2 {
+			r := rule
+			explanation.Rule = &r
 			// Return boost based on confidence (can be negative for low confidence)
-			if rule.Confidence > 0.7 {
-				return (rule.Confidence - 0.5) * 0.4 // Up to +0.2 boost
-			} else if rule.Confidence < 0.3 {
-				return (rule.Confidence - 0.5) * 0.4 // Up to -0.2 penalty
+			if rule.Confidence > 0.7 || rule.Confidence < 0.3 {
+				explanation.RuleFired = true
+				explanation.Boost = (rule.Confidence - 0.5) * 0.4 // Up to +/- 0.2
+				return explanation
 			}
 		}
 	}
@@ -234,22 +352,58 @@ func (p *PatternLearner) GetPatternBoost(col1, col2 string) float64 {
 	tokens1 := tokenizeColumn(col1)
 	tokens2 := tokenizeColumn(col2)
 	totalScore := 0.0
-	count := 0
 	for _, t1 := range tokens1 {
 		for _, t2 := range tokens2 {
 			key := t1 + "|" + t2
 			if mapping, exists := p.tokenMappings[key]; exists {
 				totalScore += mapping.Score - 0.5 // Centered around 0
-				count++
+				explanation.TokenMappings = append(explanation.TokenMappings, mapping)
 			}
 		}
 	}
 
-	if count > 0 {
-		return (totalScore / float64(count)) * 0.2 // Up to +/- 0.1 boost
+	if count := len(explanation.TokenMappings); count > 0 {
+		explanation.Boost = (totalScore / float64(count)) * 0.2 // Up to +/- 0.1 boost
+	}
+
+	return explanation
+}
+
+// DeletePatternRule removes a learned pattern rule
+func (p *PatternLearner) DeletePatternRule(pattern1, pattern2 string) error {
+	p.mutex.Lock()
+	idx := -1
+	for i, rule := range p.patterns {
+		if rule.Pattern1 == pattern1 && rule.Pattern2 == pattern2 {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		p.mutex.Unlock()
+		return fmt.Errorf("pattern rule %s ↔ %s not found", pattern1, pattern2)
+	}
+	p.patterns = append(p.patterns[:idx], p.patterns[idx+1:]...)
+	p.mutex.Unlock()
+
+	patternLog.Info("Deleted rule", "pattern1", pattern1, "pattern2", pattern2)
+	return p.save()
+}
+
+// DeleteTokenMapping removes a learned token mapping
+func (p *PatternLearner) DeleteTokenMapping(token1, token2 string) error {
+	key := token1 + "|" + token2
+
+	p.mutex.Lock()
+	if _, exists := p.tokenMappings[key]; !exists {
+		p.mutex.Unlock()
+		return fmt.Errorf("token mapping %s ↔ %s not found", token1, token2)
 	}
+	delete(p.tokenMappings, key)
+	p.mutex.Unlock()
 
-	return 0.0
+	patternLog.Info("Deleted token mapping", "token1", token1, "token2", token2)
+	return p.save()
 }
 
 // GetPatterns returns all learned patterns
@@ -340,3 +494,84 @@ func calculatePatternConfidence(success, fail int) float64 {
 	return (float64(success) + 2) / (float64(total) + 4)
 }
 
+
+// GetTokenMappings returns all learned token mappings
+func (p *PatternLearner) GetTokenMappings() map[string]TokenMapping {
+	p.mutex.RLock()
+	defer p.mutex.RUnlock()
+
+	result := make(map[string]TokenMapping, len(p.tokenMappings))
+	for k, v := range p.tokenMappings {
+		result[k] = v
+	}
+	return result
+}
+
+// Merge combines imported rules and token mappings with the local ones.
+// Returns the number of rules and mappings that were new locally.
+func (p *PatternLearner) Merge(patterns []PatternRule, mappings map[string]TokenMapping, strategy string) (int, int) {
+	p.mutex.Lock()
+
+	if strategy == MergeStrategyReplace {
+		p.patterns = append([]PatternRule{}, patterns...)
+		p.tokenMappings = make(map[string]TokenMapping, len(mappings))
+		for k, v := range mappings {
+			p.tokenMappings[k] = v
+		}
+		p.mutex.Unlock()
+		p.save()
+		return len(patterns), len(mappings)
+	}
+
+	newRules := 0
+	for _, remote := range patterns {
+		found := false
+		for i := range p.patterns {
+			local := &p.patterns[i]
+			if local.Pattern1 != remote.Pattern1 || local.Pattern2 != remote.Pattern2 {
+				continue
+			}
+			found = true
+			if strategy == MergeStrategyKeepLocal {
+				break
+			}
+			local.SuccessCount += remote.SuccessCount
+			local.FailCount += remote.FailCount
+			local.Confidence = calculatePatternConfidence(local.SuccessCount, local.FailCount)
+			if remote.LastUpdated.After(local.LastUpdated) {
+				local.LastUpdated = remote.LastUpdated
+			}
+			break
+		}
+		if !found {
+			p.patterns = append(p.patterns, remote)
+			newRules++
+		}
+	}
+
+	newMappings := 0
+	for key, remote := range mappings {
+		local, exists := p.tokenMappings[key]
+		if !exists {
+			p.tokenMappings[key] = remote
+			newMappings++
+			continue
+		}
+		if strategy == MergeStrategyKeepLocal {
+			continue
+		}
+		// Weight the scores by how often each side observed the mapping
+		total := local.Occurrences + remote.Occurrences
+		if total > 0 {
+			local.Score = (local.Score*float64(local.Occurrences) + remote.Score*float64(remote.Occurrences)) / float64(total)
+		}
+		local.Occurrences = total
+		p.tokenMappings[key] = local
+	}
+	p.mutex.Unlock()
+
+	if err := p.save(); err != nil {
+		patternLog.Error("Error saving merged patterns", "error", err)
+	}
+	return newRules, newMappings
+}
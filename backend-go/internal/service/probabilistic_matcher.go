@@ -51,6 +51,20 @@ func (pm *ProbabilisticMatcher) BayesianConfidence(matches, total int) Confidenc
 	}
 }
 
+// ConfidenceBounds returns the 95% interval of a 0-100 match confidence
+// backed by samples observed values, treating the confidence as a share of
+// agreeing values under a uniform Beta prior. Few samples give a wide
+// interval; the interval always contains the confidence itself.
+func (pm *ProbabilisticMatcher) ConfidenceBounds(confidence float64, samples int) (lower, upper float64) {
+	p := math.Max(0, math.Min(1, confidence/100))
+	alpha := p*float64(samples) + 1.0
+	beta := (1-p)*float64(samples) + 1.0
+
+	lower = pm.betaQuantile(alpha, beta, 0.025) * 100
+	upper = pm.betaQuantile(alpha, beta, 0.975) * 100
+	return math.Min(lower, confidence), math.Max(upper, confidence)
+}
+
 // betaQuantile approximates Beta distribution quantile
 func (pm *ProbabilisticMatcher) betaQuantile(alpha, beta, p float64) float64 {
 	// Simple approximation using normal approximation
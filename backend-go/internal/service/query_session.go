@@ -0,0 +1,159 @@
+package service
+
+import (
+	"backend-go/internal/analysis"
+	"crypto/rand"
+	"encoding/hex"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// Query session limits; sessions live in memory only
+const (
+	maxQuerySessions     = 100 // Least recently used sessions are evicted
+	maxQuerySessionTurns = 50  // Oldest turns are dropped
+)
+
+// QueryTurn is one question and answer of a query session
+type QueryTurn struct {
+	Question  string              `json:"question"`
+	FileIndex int                 `json:"file_index"` // 0 for both files joined
+	Answer    string              `json:"answer"`
+	Mode      string              `json:"mode"`
+	Plan      *analysis.QueryPlan `json:"plan,omitempty"`
+	AskedAt   time.Time           `json:"asked_at"`
+}
+
+// QuerySession is the chat history of one conversation with /query
+type QuerySession struct {
+	ID        string      `json:"id"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+	Turns     []QueryTurn `json:"turns"`
+}
+
+// QuerySessionSummary describes a session without its turns
+type QuerySessionSummary struct {
+	ID           string    `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+	Turns        int       `json:"turns"`
+	LastQuestion string    `json:"last_question"`
+}
+
+// QuerySessionStore keeps the conversations of /query
+type QuerySessionStore struct {
+	sessions map[string]*QuerySession
+	mutex    sync.Mutex
+}
+
+var (
+	querySessionStore     *QuerySessionStore
+	querySessionStoreOnce sync.Once
+)
+
+// GetQuerySessionStore returns the singleton session store
+func GetQuerySessionStore() *QuerySessionStore {
+	querySessionStoreOnce.Do(func() {
+		querySessionStore = &QuerySessionStore{
+			sessions: make(map[string]*QuerySession),
+		}
+	})
+	return querySessionStore
+}
+
+// Get returns a copy of a session
+func (s *QuerySessionStore) Get(id string) (*QuerySession, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	session, ok := s.sessions[id]
+	if !ok {
+		return nil, false
+	}
+	copied := *session
+	copied.Turns = append([]QueryTurn(nil), session.Turns...)
+	return &copied, true
+}
+
+// Append records a turn, creating the session when id is empty or unknown,
+// and returns the session ID
+func (s *QuerySessionStore) Append(id string, turn QueryTurn) string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	session, ok := s.sessions[id]
+	if !ok {
+		if id == "" {
+			id = newQuerySessionID()
+		}
+		session = &QuerySession{ID: id, CreatedAt: now, UpdatedAt: now}
+		s.sessions[id] = session
+		s.evict()
+	}
+	turn.AskedAt = now
+	session.Turns = append(session.Turns, turn)
+	if len(session.Turns) > maxQuerySessionTurns {
+		session.Turns = session.Turns[len(session.Turns)-maxQuerySessionTurns:]
+	}
+	session.UpdatedAt = now
+	return id
+}
+
+// List summarizes the sessions, most recently updated first
+func (s *QuerySessionStore) List() []QuerySessionSummary {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := make([]QuerySessionSummary, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		summary := QuerySessionSummary{
+			ID:        session.ID,
+			CreatedAt: session.CreatedAt,
+			UpdatedAt: session.UpdatedAt,
+			Turns:     len(session.Turns),
+		}
+		if n := len(session.Turns); n > 0 {
+			summary.LastQuestion = session.Turns[n-1].Question
+		}
+		list = append(list, summary)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].UpdatedAt.After(list[j].UpdatedAt)
+	})
+	return list
+}
+
+// Clear deletes a session; it reports whether the session existed
+func (s *QuerySessionStore) Clear(id string) bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	_, ok := s.sessions[id]
+	delete(s.sessions, id)
+	return ok
+}
+
+// evict drops the least recently updated sessions over the limit (must hold lock)
+func (s *QuerySessionStore) evict() {
+	for len(s.sessions) > maxQuerySessions {
+		var oldest *QuerySession
+		for _, session := range s.sessions {
+			if oldest == nil || session.UpdatedAt.Before(oldest.UpdatedAt) {
+				oldest = session
+			}
+		}
+		delete(s.sessions, oldest.ID)
+	}
+}
+
+func newQuerySessionID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "qs_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "qs_" + hex.EncodeToString(b)
+}
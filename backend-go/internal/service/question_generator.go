@@ -3,6 +3,7 @@ package service
 import (
 	"backend-go/internal/llm"
 	"backend-go/internal/models"
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -33,95 +34,122 @@ var DomainOptions = []string{
 	"Other",
 }
 
-// GenerateQuestions generates context questions for a dataset
-func (s *QuestionGenerator) GenerateQuestions(analysis models.DataAnalysisResult, fileIndex int) []models.Question {
-	questions := []models.Question{}
-
-	// Q1: Dataset Purpose
-	questions = append(questions, models.Question{
-		ID:       fmt.Sprintf("f%d_purpose", fileIndex),
-		Type:     models.QuestionTypeDatasetPurpose,
-		Text:     fmt.Sprintf("What is the primary purpose of this dataset (File %d)?", fileIndex),
-		Options:  []string{},
-		Required: true,
-		Metadata: map[string]interface{}{"placeholder": "e.g., Customer transaction records, Employee performance data, etc."},
-	})
-
-	// Q2: Business Domain
-	questions = append(questions, models.Question{
-		ID:       fmt.Sprintf("f%d_domain", fileIndex),
-		Type:     models.QuestionTypeBusinessDomain,
-		Text:     "Which business domain does this dataset belong to?",
-		Options:  DomainOptions,
-		Required: true,
-		Metadata: map[string]interface{}{},
-	})
+// GenerateQuestions generates context questions for a dataset in a locale
+// (see Locales)
+func (s *QuestionGenerator) GenerateQuestions(analysis models.DataAnalysisResult, fileIndex int, locale string) []models.Question {
+	questions := []models.Question{
+		purposeQuestion(fileIndex, locale),
+		domainQuestion(fileIndex, locale),
+	}
 
-	// Try AI questions
-	aiQuestions := s.generateAIQuestions(analysis, fileIndex)
+	// Try AI questions, falling back to heuristics
+	aiQuestions := s.generateAIQuestions(analysis, fileIndex, locale)
 	if len(aiQuestions) > 0 {
 		questions = append(questions, aiQuestions...)
 	} else {
-		// Fallback heuristics
+		questions = append(questions, s.heuristicQuestions(analysis, fileIndex, locale)...)
+	}
+
+	return append(questions, exclusionsQuestion(analysis, fileIndex, locale))
+}
+
+// purposeQuestion asks what the dataset is for
+func purposeQuestion(fileIndex int, locale string) models.Question {
+	return models.Question{
+		ID:         fmt.Sprintf("f%d_purpose", fileIndex),
+		Type:       models.QuestionTypeDatasetPurpose,
+		AnswerType: models.AnswerTypeText,
+		Text:       message(locale, "purpose.text", fileIndex),
+		Options:    []string{},
+		Required:   true,
+		Metadata:   map[string]interface{}{"placeholder": message(locale, "purpose.placeholder")},
+	}
+}
+
+// domainQuestion asks which business domain the dataset belongs to
+func domainQuestion(fileIndex int, locale string) models.Question {
+	return withOptionLabels(models.Question{
+		ID:         fmt.Sprintf("f%d_domain", fileIndex),
+		Type:       models.QuestionTypeBusinessDomain,
+		AnswerType: models.AnswerTypeSelect,
+		Text:       message(locale, "domain.text"),
+		Options:    DomainOptions,
+		Required:   true,
+		Metadata:   map[string]interface{}{},
+	}, locale)
+}
+
+// entitiesQuestion asks for the business objects the rows are about
+func entitiesQuestion(fileIndex int, locale string) models.Question {
+	return models.Question{
+		ID:         fmt.Sprintf("f%d_entities", fileIndex),
+		Type:       models.QuestionTypeKeyEntities,
+		AnswerType: models.AnswerTypeTags,
+		Text:       message(locale, "entities.text"),
+		Options:    []string{},
+		Required:   true,
+		Metadata: map[string]interface{}{
+			"placeholder": message(locale, "entities.placeholder"),
+			"input_type":  "tags",
+			"hint":        message(locale, "entities.hint"),
+		},
+	}
+}
+
+// heuristicQuestions asks about entities, the time period and ambiguous
+// columns, judging by the analysis
+func (s *QuestionGenerator) heuristicQuestions(analysis models.DataAnalysisResult, fileIndex int, locale string) []models.Question {
+	questions := []models.Question{entitiesQuestion(fileIndex, locale)}
+
+	if analysis.HasDates {
+		dateCols := strings.Join(takeFirst(analysis.PotentialDates, 3), ", ")
+		questions = append(questions, models.Question{
+			ID:         fmt.Sprintf("f%d_temporal", fileIndex),
+			Type:       models.QuestionTypeTemporalContext,
+			AnswerType: models.AnswerTypeText,
+			Text:       message(locale, "temporal.text", dateCols),
+			Options:    []string{},
+			Required:   false,
+			Metadata:   map[string]interface{}{"placeholder": message(locale, "temporal.placeholder")},
+		})
+	}
+
+	ambiguous := s.findAmbiguousColumns(analysis.ColumnNames)
+	if len(ambiguous) > 0 {
+		colList := strings.Join(takeFirst(ambiguous, 5), ", ")
 		questions = append(questions, models.Question{
-			ID:       fmt.Sprintf("f%d_entities", fileIndex),
-			Type:     models.QuestionTypeKeyEntities,
-			Text:     "What are the main entities or subjects in this dataset?",
-			Options:  []string{},
-			Required: true,
+			ID:         fmt.Sprintf("f%d_column_semantics", fileIndex),
+			Type:       models.QuestionTypeColumnSemantic,
+			AnswerType: models.AnswerTypeColumnMap,
+			Text:       message(locale, "column_semantics.text", colList),
+			Options:    []string{},
+			Required:   false,
 			Metadata: map[string]interface{}{
-				"placeholder": "e.g., Customer, Product, Order",
-				"input_type":  "tags",
-				"hint":        "Enter multiple entities separated by commas",
+				"columns":    takeFirst(ambiguous, 5),
+				"input_type": "column_descriptions",
 			},
 		})
-
-		if analysis.HasDates {
-			dateCols := strings.Join(takeFirst(analysis.PotentialDates, 3), ", ")
-			questions = append(questions, models.Question{
-				ID:       fmt.Sprintf("f%d_temporal", fileIndex),
-				Type:     models.QuestionTypeTemporalContext,
-				Text:     fmt.Sprintf("What time period does this data cover? (Found date columns: %s)", dateCols),
-				Options:  []string{},
-				Required: false,
-				Metadata: map[string]interface{}{"placeholder": "e.g., Q1 2024, Last 12 months"},
-			})
-		}
-
-		ambiguous := s.findAmbiguousColumns(analysis.ColumnNames)
-		if len(ambiguous) > 0 {
-			colList := strings.Join(takeFirst(ambiguous, 5), ", ")
-			questions = append(questions, models.Question{
-				ID:       fmt.Sprintf("f%d_column_semantics", fileIndex),
-				Type:     models.QuestionTypeColumnSemantic,
-				Text:     fmt.Sprintf("Can you briefly describe what these columns represent: %s?", colList),
-				Options:  []string{},
-				Required: false,
-				Metadata: map[string]interface{}{
-					"columns":    takeFirst(ambiguous, 5),
-					"input_type": "column_descriptions",
-				},
-			})
-		}
 	}
+	return questions
+}
 
-	// Exclusions
-	questions = append(questions, models.Question{
-		ID:       fmt.Sprintf("f%d_exclusions", fileIndex),
-		Type:     models.QuestionTypeExclusions,
-		Text:     "Are there any columns that should be excluded from correlation analysis?",
-		Options:  analysis.ColumnNames,
-		Required: false,
+// exclusionsQuestion asks which columns to leave out of matching
+func exclusionsQuestion(analysis models.DataAnalysisResult, fileIndex int, locale string) models.Question {
+	return models.Question{
+		ID:         fmt.Sprintf("f%d_exclusions", fileIndex),
+		Type:       models.QuestionTypeExclusions,
+		AnswerType: models.AnswerTypeColumns,
+		Text:       message(locale, "exclusions.text"),
+		Options:    analysis.ColumnNames,
+		Required:   false,
 		Metadata: map[string]interface{}{
 			"input_type": "multi_select",
-			"hint":       "Select columns like temporary fields, debug data, or irrelevant information",
+			"hint":       message(locale, "exclusions.hint"),
 		},
-	})
-
-	return questions
+	}
 }
 
-func (s *QuestionGenerator) generateAIQuestions(analysis models.DataAnalysisResult, fileIndex int) []models.Question {
+func (s *QuestionGenerator) generateAIQuestions(analysis models.DataAnalysisResult, fileIndex int, locale string) []models.Question {
 	prompt := fmt.Sprintf(`
 Analyze this dataset summary and generate 3 specific questions to understand its business context.
 
@@ -153,21 +181,9 @@ Example JSON:
 		}
 	]
 }
-
+%s
 Return ONLY the JSON.
-`, strings.Join(takeFirst(analysis.ColumnNames, 20), ", "), analysis.NumRows, strings.Join(analysis.PotentialDates, ", "), strings.Join(analysis.PotentialIDs, ", "))
-
-	response, err := s.llmService.CallOllama(prompt)
-	if err != nil || response == "" {
-		return nil
-	}
-
-	// Extract JSON
-	jsonRegex := regexp.MustCompile(`\{[\s\S]*\}`)
-	jsonStr := jsonRegex.FindString(response)
-	if jsonStr == "" {
-		return nil
-	}
+`, strings.Join(takeFirst(analysis.ColumnNames, 20), ", "), analysis.NumRows, strings.Join(analysis.PotentialDates, ", "), strings.Join(analysis.PotentialIDs, ", "), languageInstruction(locale))
 
 	var data struct {
 		Questions []struct {
@@ -177,8 +193,16 @@ Return ONLY the JSON.
 			IdSuffix string   `json:"id_suffix"`
 		} `json:"questions"`
 	}
-
-	if err := json.Unmarshal([]byte(jsonStr), &data); err != nil {
+	err := s.llmService.Generate(context.Background(), llm.TaskQuestions, prompt, func(response string) error {
+		// Extract JSON
+		jsonRegex := regexp.MustCompile(`\{[\s\S]*\}`)
+		jsonStr := jsonRegex.FindString(response)
+		if jsonStr == "" {
+			return fmt.Errorf("no JSON found in response")
+		}
+		return json.Unmarshal([]byte(jsonStr), &data)
+	})
+	if err != nil {
 		return nil
 	}
 
@@ -191,19 +215,27 @@ Return ONLY the JSON.
 
 		qType := models.QuestionTypeColumnSemantic
 		textLower := strings.ToLower(q.Text)
-		if strings.Contains(textLower, "entity") {
+		if strings.Contains(textLower, "entity") || strings.Contains(textLower, "entität") {
 			qType = models.QuestionTypeKeyEntities
-		} else if strings.Contains(textLower, "time") || strings.Contains(textLower, "date") {
+		} else if strings.Contains(textLower, "time") || strings.Contains(textLower, "date") ||
+			strings.Contains(textLower, "zeit") || strings.Contains(textLower, "datum") {
 			qType = models.QuestionTypeTemporalContext
 		}
 
+		// The LLM's answer type when it is one of ours and has options
+		answerType := models.AnswerTypeText
+		if (q.Type == models.AnswerTypeSelect || q.Type == models.AnswerTypeMultiSelect) && len(q.Options) > 0 {
+			answerType = q.Type
+		}
+
 		aiQuestions = append(aiQuestions, models.Question{
-			ID:       qID,
-			Type:     qType,
-			Text:     q.Text,
-			Options:  q.Options,
-			Required: false,
-			Metadata: map[string]interface{}{"generated_by": "ai"},
+			ID:         qID,
+			Type:       qType,
+			AnswerType: answerType,
+			Text:       q.Text,
+			Options:    q.Options,
+			Required:   false,
+			Metadata:   map[string]interface{}{"generated_by": "ai"},
 		})
 	}
 	return aiQuestions
@@ -0,0 +1,184 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"fmt"
+	"strings"
+)
+
+// Locales of the question catalog
+const (
+	LocaleEnglish = "en"
+	LocaleGerman  = "de"
+	DefaultLocale = LocaleEnglish
+)
+
+// localeNames name the locales in LLM instructions
+var localeNames = map[string]string{
+	LocaleEnglish: "English",
+	LocaleGerman:  "German",
+}
+
+// Locales lists the supported locales
+func Locales() []string {
+	return []string{LocaleEnglish, LocaleGerman}
+}
+
+// ParseLocale matches a language tag such as de, de-DE or de_AT to a
+// supported locale, ignoring case; an empty tag is the default locale
+func ParseLocale(tag string) (string, error) {
+	tag = strings.TrimSpace(tag)
+	if tag == "" {
+		return DefaultLocale, nil
+	}
+	lang := strings.ToLower(tag)
+	if i := strings.IndexAny(lang, "-_"); i >= 0 {
+		lang = lang[:i]
+	}
+	if _, ok := localeNames[lang]; ok {
+		return lang, nil
+	}
+	return "", fmt.Errorf("unsupported locale %q (use %s)", tag, strings.Join(Locales(), ", "))
+}
+
+// PreferredLocale picks the first supported locale of an Accept-Language
+// header, in the order listed, or the default locale
+func PreferredLocale(acceptLanguage string) string {
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag := strings.TrimSpace(strings.SplitN(part, ";", 2)[0])
+		if tag == "" || tag == "*" {
+			continue
+		}
+		if locale, err := ParseLocale(tag); err == nil {
+			return locale
+		}
+	}
+	return DefaultLocale
+}
+
+// questionMessages is the question catalog per locale. Option labels are
+// keyed "option." plus the option; options are stored in the context as
+// listed in English whatever the locale.
+var questionMessages = map[string]map[string]string{
+	LocaleEnglish: {
+		"purpose.text":           "What is the primary purpose of this dataset (File %d)?",
+		"purpose.placeholder":    "e.g., Customer transaction records, Employee performance data, etc.",
+		"domain.text":            "Which business domain does this dataset belong to?",
+		"entities.text":          "What are the main entities or subjects in this dataset?",
+		"entities.placeholder":   "e.g., Customer, Product, Order",
+		"entities.hint":          "Enter multiple entities separated by commas",
+		"temporal.text":          "What time period does this data cover? (Found date columns: %s)",
+		"temporal.placeholder":   "e.g., Q1 2024, Last 12 months",
+		"column_semantics.text":  "Can you briefly describe what these columns represent: %s?",
+		"exclusions.text":        "Are there any columns that should be excluded from correlation analysis?",
+		"exclusions.hint":        "Select columns like temporary fields, debug data, or irrelevant information",
+		"rel_type.text":          "How are these two datasets related?",
+		"rel_keys.text":          "Which columns should be used to join these datasets?",
+		"coding_systems.text":    "Which coding systems do the codes in this dataset use?",
+		"currency.text":          "Which currency are the amounts in?",
+		"fiscal_year_start.text": "In which month does the fiscal year start?",
+		"amount_basis.text":      "Are the amounts gross or net of tax and discounts?",
+		"workforce_scope.text":   "Which people does the dataset cover?",
+	},
+	LocaleGerman: {
+		"purpose.text":           "Was ist der Hauptzweck dieses Datensatzes (Datei %d)?",
+		"purpose.placeholder":    "z. B. Kundentransaktionen, Leistungsdaten von Mitarbeitenden usw.",
+		"domain.text":            "Zu welchem Geschäftsbereich gehört dieser Datensatz?",
+		"entities.text":          "Welches sind die wichtigsten Entitäten oder Themen in diesem Datensatz?",
+		"entities.placeholder":   "z. B. Kunde, Produkt, Bestellung",
+		"entities.hint":          "Mehrere Entitäten durch Kommas getrennt eingeben",
+		"temporal.text":          "Welchen Zeitraum decken die Daten ab? (Gefundene Datumsspalten: %s)",
+		"temporal.placeholder":   "z. B. Q1 2024, letzte 12 Monate",
+		"column_semantics.text":  "Können Sie kurz beschreiben, wofür diese Spalten stehen: %s?",
+		"exclusions.text":        "Gibt es Spalten, die von der Korrelationsanalyse ausgeschlossen werden sollen?",
+		"exclusions.hint":        "Wählen Sie Spalten wie temporäre Felder, Debug-Daten oder irrelevante Informationen",
+		"rel_type.text":          "Wie hängen diese beiden Datensätze zusammen?",
+		"rel_keys.text":          "Über welche Spalten sollen diese Datensätze verknüpft werden?",
+		"coding_systems.text":    "Welche Codesysteme verwenden die Codes in diesem Datensatz?",
+		"currency.text":          "In welcher Währung sind die Beträge angegeben?",
+		"fiscal_year_start.text": "In welchem Monat beginnt das Geschäftsjahr?",
+		"amount_basis.text":      "Sind die Beträge brutto oder netto nach Steuern und Rabatten?",
+		"workforce_scope.text":   "Welche Personen umfasst der Datensatz?",
+
+		"option.Sales & Marketing":         "Vertrieb & Marketing",
+		"option.Finance & Accounting":      "Finanzen & Rechnungswesen",
+		"option.Human Resources":           "Personalwesen",
+		"option.Operations & Supply Chain": "Betrieb & Lieferkette",
+		"option.Customer Service":          "Kundenservice",
+		"option.Healthcare":                "Gesundheitswesen",
+		"option.E-commerce":                "E-Commerce",
+		"option.Manufacturing":             "Produktion",
+		"option.Technology & IT":           "Technologie & IT",
+		"option.Education":                 "Bildung",
+		"option.Other":                     "Sonstiges",
+
+		"option.One-to-One":        "Eins-zu-eins",
+		"option.One-to-Many":       "Eins-zu-viele",
+		"option.Many-to-Many":      "Viele-zu-viele",
+		"option.Hierarchical":      "Hierarchisch",
+		"option.Temporal sequence": "Zeitliche Abfolge",
+		"option.Unknown":           "Unbekannt",
+
+		"option.Mixed":                       "Gemischt",
+		"option.January":                     "Januar",
+		"option.July":                        "Juli",
+		"option.October":                     "Oktober",
+		"option.Gross":                       "Brutto",
+		"option.Net of tax":                  "Netto nach Steuern",
+		"option.Net of discounts":            "Netto nach Rabatten",
+		"option.Net of tax and discounts":    "Netto nach Steuern und Rabatten",
+		"option.Active employees":            "Aktive Mitarbeitende",
+		"option.Active and former employees": "Aktive und ehemalige Mitarbeitende",
+		"option.Employees and contractors":   "Mitarbeitende und Auftragnehmer",
+	},
+}
+
+// message formats a catalog message in a locale, falling back to English
+func message(locale, key string, args ...interface{}) string {
+	format, ok := questionMessages[locale][key]
+	if !ok {
+		format = questionMessages[LocaleEnglish][key]
+	}
+	if len(args) == 0 {
+		return format
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+// withOptionLabels adds the labels of a question's options in a locale to
+// its metadata as option_labels, in the order of the options. Options
+// without a translation keep their own spelling. English needs no labels.
+func withOptionLabels(q models.Question, locale string) models.Question {
+	if locale == LocaleEnglish || len(q.Options) == 0 {
+		return q
+	}
+	labels := make([]string, len(q.Options))
+	for i, o := range q.Options {
+		labels[i] = o
+		if label, ok := questionMessages[locale]["option."+o]; ok {
+			labels[i] = label
+		}
+	}
+	if q.Metadata == nil {
+		q.Metadata = map[string]interface{}{}
+	}
+	q.Metadata["option_labels"] = labels
+	return q
+}
+
+// LocaleLanguage names the language of a locale in LLM instructions; it is
+// empty for English, which needs no instruction
+func LocaleLanguage(locale string) string {
+	if locale == LocaleEnglish {
+		return ""
+	}
+	return localeNames[locale]
+}
+
+// languageInstruction tells the LLM which language to write in
+func languageInstruction(locale string) string {
+	if LocaleLanguage(locale) == "" {
+		return ""
+	}
+	return fmt.Sprintf("\nWrite the question texts and options in %s.\n", LocaleLanguage(locale))
+}
@@ -0,0 +1,206 @@
+package service
+
+import (
+	"backend-go/internal/logging"
+	"backend-go/internal/state"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var linkageLog = logging.Component("linkage")
+
+// DefaultLinkageThreshold is the minimum 3-gram similarity for a record link
+const DefaultLinkageThreshold = 0.6
+
+// LinkageIndexInfo describes the key column currently indexed for linkage
+type LinkageIndexInfo struct {
+	FileIndex    int       `json:"file_index"`
+	Column       string    `json:"column"`
+	Rows         int       `json:"rows"`
+	Distinct     int       `json:"distinct_values"`
+	ContentHash  string    `json:"content_hash"`
+	BuiltAt      time.Time `json:"built_at"`
+	BuildMs      int64     `json:"build_ms"`
+	Bands        int       `json:"bands"`
+	RowsPerBand  int       `json:"rows_per_band"`
+	SignatureLen int       `json:"signature_length"`
+}
+
+// RecordLink links one query row to the indexed rows with similar key values
+type RecordLink struct {
+	QueryRow   int           `json:"query_row"`
+	QueryValue string        `json:"query_value"`
+	Matches    []LinkedValue `json:"matches"`
+}
+
+// LinkedValue is an indexed key value and the rows that hold it
+type LinkedValue struct {
+	Value      string  `json:"value"`
+	Rows       []int   `json:"rows"`
+	Similarity float64 `json:"similarity"`
+}
+
+// LinkageStats summarizes a linkage query
+type LinkageStats struct {
+	Queried         int   `json:"queried"`
+	Linked          int   `json:"linked"`
+	CandidatesTried int   `json:"candidates_checked"`
+	DurationMs      int64 `json:"duration_ms"`
+}
+
+// RecordLinker indexes one file's key column with banded MinHash LSH and
+// links values of the other file against it
+type RecordLinker struct {
+	info  *LinkageIndexInfo
+	index *LSHIndex
+	rows  map[int][]int // LSH value id -> row indices
+	mutex sync.RWMutex
+}
+
+var (
+	recordLinker     *RecordLinker
+	recordLinkerOnce sync.Once
+)
+
+// GetRecordLinker returns the singleton record linker
+func GetRecordLinker() *RecordLinker {
+	recordLinkerOnce.Do(func() {
+		recordLinker = &RecordLinker{}
+	})
+	return recordLinker
+}
+
+// BuildIndex indexes the non-empty values of a key column
+func (rl *RecordLinker) BuildIndex(df *state.DataFrame, fileIndex int, column string) (LinkageIndexInfo, error) {
+	colIdx := columnIndex(df, column)
+	if colIdx < 0 {
+		return LinkageIndexInfo{}, fmt.Errorf("column %q not found", column)
+	}
+
+	start := time.Now()
+	index := NewLSHIndex()
+	rows := make(map[int][]int)
+	for rowIdx, row := range df.Rows {
+		if colIdx >= len(row) || strings.TrimSpace(row[colIdx]) == "" {
+			continue
+		}
+		id := index.Add(strings.TrimSpace(row[colIdx]))
+		rows[id] = append(rows[id], rowIdx)
+	}
+
+	info := LinkageIndexInfo{
+		FileIndex:    fileIndex,
+		Column:       column,
+		Rows:         len(df.Rows),
+		Distinct:     index.Len(),
+		ContentHash:  df.ContentHash(),
+		BuiltAt:      time.Now(),
+		BuildMs:      time.Since(start).Milliseconds(),
+		Bands:        lshBands,
+		RowsPerBand:  lshRowsPerBand,
+		SignatureLen: lshSignature,
+	}
+
+	rl.mutex.Lock()
+	rl.info = &info
+	rl.index = index
+	rl.rows = rows
+	rl.mutex.Unlock()
+
+	linkageLog.Info("Indexed column",
+		"file_index", fileIndex, "column", column, "distinct", info.Distinct, "duration_ms", info.BuildMs)
+	return info, nil
+}
+
+// Info returns the current index description, or nil when nothing is indexed
+func (rl *RecordLinker) Info() *LinkageIndexInfo {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+	if rl.info == nil {
+		return nil
+	}
+	info := *rl.info
+	return &info
+}
+
+// IsStale reports whether the indexed file has changed since the index was built
+func (rl *RecordLinker) IsStale(df *state.DataFrame) bool {
+	info := rl.Info()
+	return info == nil || df == nil || info.ContentHash != df.ContentHash()
+}
+
+// Link queries every non-empty value of a column against the index
+func (rl *RecordLinker) Link(df *state.DataFrame, column string, threshold float64, limit int) ([]RecordLink, LinkageStats, error) {
+	colIdx := columnIndex(df, column)
+	if colIdx < 0 {
+		return nil, LinkageStats{}, fmt.Errorf("column %q not found", column)
+	}
+
+	values := make([]string, len(df.Rows))
+	for rowIdx, row := range df.Rows {
+		if colIdx < len(row) {
+			values[rowIdx] = row[colIdx]
+		}
+	}
+	return rl.LinkValues(values, threshold, limit)
+}
+
+// LinkValues queries each value against the index; the position of a value is
+// reported as its query row. At most limit matches are kept per value.
+func (rl *RecordLinker) LinkValues(values []string, threshold float64, limit int) ([]RecordLink, LinkageStats, error) {
+	rl.mutex.RLock()
+	defer rl.mutex.RUnlock()
+
+	if rl.index == nil {
+		return nil, LinkageStats{}, fmt.Errorf("no linkage index built")
+	}
+	if threshold <= 0 {
+		threshold = DefaultLinkageThreshold
+	}
+
+	start := time.Now()
+	stats := LinkageStats{}
+	links := []RecordLink{}
+	for rowIdx, value := range values {
+		value = strings.TrimSpace(value)
+		if value == "" {
+			continue
+		}
+		stats.Queried++
+
+		matches, checked := rl.index.Query(value, threshold)
+		stats.CandidatesTried += checked
+		if len(matches) == 0 {
+			continue
+		}
+		if limit > 0 && len(matches) > limit {
+			matches = matches[:limit]
+		}
+
+		link := RecordLink{QueryRow: rowIdx, QueryValue: value}
+		for _, m := range matches {
+			link.Matches = append(link.Matches, LinkedValue{
+				Value:      m.Value,
+				Rows:       rl.rows[m.ID],
+				Similarity: m.Similarity,
+			})
+		}
+		links = append(links, link)
+		stats.Linked++
+	}
+	stats.DurationMs = time.Since(start).Milliseconds()
+
+	return links, stats, nil
+}
+
+// columnIndex returns the index of a header, or -1
+func columnIndex(df *state.DataFrame, column string) int {
+	for i, h := range df.Headers {
+		if h == column {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,399 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/state"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+var schemasLog = logging.Component("schemas")
+
+const referenceSchemasFile = "reference_schemas.json"
+
+// Conformance column statuses
+const (
+	ConformanceMatched      = "matched"       // Mapped with a compatible type
+	ConformanceTypeMismatch = "type_mismatch" // Mapped, but the file holds another type
+	ConformanceMissing      = "missing"       // No file column maps to it
+)
+
+// Conformance scoring weights; with no semantic type the name and type
+// weights are scaled up to fill its share
+const (
+	conformanceNameWeight     = 0.50
+	conformanceTypeWeight     = 0.15
+	conformanceSemanticWeight = 0.35
+)
+
+// schemaTypeAliases maps the accepted column types to the inferred types
+var schemaTypeAliases = map[string]state.ColumnType{
+	"string": state.ColumnString, "text": state.ColumnString,
+	"float": state.ColumnFloat, "number": state.ColumnFloat, "numeric": state.ColumnFloat,
+	"decimal": state.ColumnFloat, "integer": state.ColumnFloat, "int": state.ColumnFloat,
+	"time": state.ColumnTime, "date": state.ColumnTime, "datetime": state.ColumnTime, "timestamp": state.ColumnTime,
+}
+
+// CanonicalColumn is a column of the canonical model
+type CanonicalColumn struct {
+	Name         string   `json:"name"`
+	Type         string   `json:"type,omitempty"`          // string, float or time; empty accepts any
+	SemanticType string   `json:"semantic_type,omitempty"` // As in the data dictionary, e.g. email
+	Description  string   `json:"description,omitempty"`
+	Required     bool     `json:"required,omitempty"`
+	Aliases      []string `json:"aliases,omitempty"` // Other names the column goes by
+}
+
+// ReferenceSchema is a canonical schema files are scored against
+type ReferenceSchema struct {
+	Name        string            `json:"name"`
+	Description string            `json:"description,omitempty"`
+	Columns     []CanonicalColumn `json:"columns"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
+
+// ReferenceSchemaStore keeps the registered reference schemas
+type ReferenceSchemaStore struct {
+	schemas map[string]ReferenceSchema
+	mutex   sync.RWMutex
+}
+
+var (
+	schemaStore     *ReferenceSchemaStore
+	schemaStoreOnce sync.Once
+)
+
+// GetReferenceSchemaStore returns the singleton schema store
+func GetReferenceSchemaStore() *ReferenceSchemaStore {
+	schemaStoreOnce.Do(func() {
+		schemaStore = &ReferenceSchemaStore{
+			schemas: make(map[string]ReferenceSchema),
+		}
+		schemaStore.load()
+	})
+	return schemaStore
+}
+
+// load loads schemas from file
+func (s *ReferenceSchemaStore) load() {
+	data, err := os.ReadFile(config.DataPath(referenceSchemasFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			schemasLog.Error("Error loading reference schemas", "error", err)
+		}
+		return
+	}
+
+	var saved []ReferenceSchema
+	if err := json.Unmarshal(data, &saved); err != nil {
+		schemasLog.Error("Error parsing reference schemas", "error", err)
+		return
+	}
+	for _, schema := range saved {
+		s.schemas[schema.Name] = schema
+	}
+	schemasLog.Info("Loaded reference schemas", "count", len(saved))
+}
+
+// save persists schemas to file (must hold lock)
+func (s *ReferenceSchemaStore) save() error {
+	data, err := json.MarshalIndent(s.sorted(), "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(referenceSchemasFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(referenceSchemasFile), data, 0644)
+}
+
+// sorted lists the schemas by name (must hold lock)
+func (s *ReferenceSchemaStore) sorted() []ReferenceSchema {
+	schemas := make([]ReferenceSchema, 0, len(s.schemas))
+	for _, schema := range s.schemas {
+		schemas = append(schemas, schema)
+	}
+	sort.Slice(schemas, func(i, j int) bool { return schemas[i].Name < schemas[j].Name })
+	return schemas
+}
+
+// List returns the schemas sorted by name
+func (s *ReferenceSchemaStore) List() []ReferenceSchema {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.sorted()
+}
+
+// Get looks up a schema by name
+func (s *ReferenceSchemaStore) Get(name string) (ReferenceSchema, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	schema, ok := s.schemas[name]
+	return schema, ok
+}
+
+// Save validates and stores a schema. With replace false an existing schema
+// of the same name is an error.
+func (s *ReferenceSchemaStore) Save(schema ReferenceSchema, replace bool) (ReferenceSchema, error) {
+	if err := schema.normalize(); err != nil {
+		return schema, err
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	now := time.Now()
+	schema.CreatedAt, schema.UpdatedAt = now, now
+	if existing, ok := s.schemas[schema.Name]; ok {
+		if !replace {
+			return schema, fmt.Errorf("schema %q already exists", schema.Name)
+		}
+		schema.CreatedAt = existing.CreatedAt
+	}
+	s.schemas[schema.Name] = schema
+	schemasLog.Info("Saved reference schema", "schema", schema.Name, "columns", len(schema.Columns))
+	return schema, s.save()
+}
+
+// Delete removes a schema
+func (s *ReferenceSchemaStore) Delete(name string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.schemas[name]; !ok {
+		return fmt.Errorf("schema %q not found", name)
+	}
+	delete(s.schemas, name)
+	schemasLog.Info("Deleted reference schema", "schema", name)
+	return s.save()
+}
+
+// normalize trims the schema, resolves type aliases and rejects unknown
+// types and duplicate columns
+func (schema *ReferenceSchema) normalize() error {
+	schema.Name = strings.TrimSpace(schema.Name)
+	if schema.Name == "" {
+		return fmt.Errorf("schema name is required")
+	}
+	if strings.ContainsAny(schema.Name, "/?#") {
+		return fmt.Errorf("schema name must not contain / ? or #")
+	}
+	if len(schema.Columns) == 0 {
+		return fmt.Errorf("a schema needs at least one column")
+	}
+
+	seen := map[string]bool{}
+	for i := range schema.Columns {
+		col := &schema.Columns[i]
+		col.Name = strings.TrimSpace(col.Name)
+		if col.Name == "" {
+			return fmt.Errorf("columns[%d]: name is required", i)
+		}
+		key := strings.ToLower(col.Name)
+		if seen[key] {
+			return fmt.Errorf("column %q is listed twice", col.Name)
+		}
+		seen[key] = true
+		if col.Type != "" {
+			t, ok := schemaTypeAliases[strings.ToLower(col.Type)]
+			if !ok {
+				return fmt.Errorf("column %q: unknown type %q (use string, float or time)", col.Name, col.Type)
+			}
+			col.Type = string(t)
+		}
+		if col.SemanticType != "" && !knownSemanticType(col.SemanticType) {
+			return fmt.Errorf("column %q: unknown semantic type %q (use %s, date or name)",
+				col.Name, col.SemanticType, strings.Join(semanticPatternOrder, ", "))
+		}
+	}
+	return nil
+}
+
+func knownSemanticType(t string) bool {
+	if t == "date" || t == "name" {
+		return true
+	}
+	for _, name := range semanticPatternOrder {
+		if name == t {
+			return true
+		}
+	}
+	return false
+}
+
+// SchemaFromDictionary captures the columns of a data dictionary as a
+// reference schema
+func SchemaFromDictionary(name, description string, dict *DataDictionary) ReferenceSchema {
+	schema := ReferenceSchema{Name: name, Description: description, Columns: make([]CanonicalColumn, len(dict.Columns))}
+	for i, c := range dict.Columns {
+		schema.Columns[i] = CanonicalColumn{
+			Name:         c.Name,
+			Type:         c.InferredType,
+			SemanticType: c.SemanticType,
+			Description:  c.Description,
+			Required:     c.NullRate == 0,
+		}
+	}
+	return schema
+}
+
+// ConformanceColumn maps a canonical column to the file column scored
+// closest to it
+type ConformanceColumn struct {
+	CanonicalColumn
+	Status           string  `json:"status"`
+	FileColumn       string  `json:"file_column,omitempty"`
+	Confidence       float64 `json:"confidence,omitempty"` // 0-100
+	NameSimilarity   float64 `json:"name_similarity,omitempty"`
+	FileType         string  `json:"file_type,omitempty"`
+	FileSemanticType string  `json:"file_semantic_type,omitempty"`
+}
+
+// SchemaConformance scores a file against a reference schema
+type SchemaConformance struct {
+	Schema          string              `json:"schema"`
+	FileIndex       int                 `json:"file_index"`
+	FileName        string              `json:"file_name"`
+	Profile         string              `json:"profile"`
+	Conformance     float64             `json:"conformance"` // Percent of the canonical columns matched
+	Matched         int                 `json:"matched"`
+	TypeMismatches  int                 `json:"type_mismatches"`
+	Missing         int                 `json:"missing"`
+	MissingRequired []string            `json:"missing_required"`
+	Columns         []ConformanceColumn `json:"columns"`       // In schema order
+	ExtraColumns    []string            `json:"extra_columns"` // File columns outside the schema
+}
+
+// schemaCandidate is a scored (canonical column, file column) pair
+type schemaCandidate struct {
+	schemaIdx, fileIdx int
+	confidence, name   float64
+}
+
+// ScoreConformance maps the columns of df one-to-one to the canonical
+// columns by name (with synonyms and aliases), type and semantic type. Pairs
+// below the profile's match threshold are left unmapped.
+func (s *EnhancedSimilarityService) ScoreConformance(df *state.DataFrame, fileIndex int, schema ReferenceSchema, profile MatchingProfile) *SchemaConformance {
+	dict := s.BuildDataDictionary(df, fileIndex, nil)
+
+	candidates := []AssignmentCandidate{}
+	scored := []schemaCandidate{}
+	for i, canon := range schema.Columns {
+		for j, col := range dict.Columns {
+			name := s.schemaNameSimilarity(col.Name, canon, profile.NameAlgorithm)
+			confidence := conformanceScore(name, canon, col)
+			if confidence < profile.MatchThreshold {
+				continue
+			}
+			candidates = append(candidates, AssignmentCandidate{File1Column: canon.Name, File2Column: col.Name, Confidence: confidence})
+			scored = append(scored, schemaCandidate{schemaIdx: i, fileIdx: j, confidence: confidence, name: name})
+		}
+	}
+
+	result := &SchemaConformance{
+		Schema:          schema.Name,
+		FileIndex:       fileIndex,
+		FileName:        df.FileName,
+		Profile:         profile.Name,
+		MissingRequired: []string{},
+		Columns:         make([]ConformanceColumn, len(schema.Columns)),
+		ExtraColumns:    []string{},
+	}
+	for i, canon := range schema.Columns {
+		result.Columns[i] = ConformanceColumn{CanonicalColumn: canon, Status: ConformanceMissing}
+	}
+	mapped := map[int]bool{}
+	for _, k := range SelectAssignment(candidates, AssignmentOneToOne) {
+		c := scored[k]
+		col := dict.Columns[c.fileIdx]
+		out := &result.Columns[c.schemaIdx]
+		out.FileColumn = col.Name
+		out.Confidence = math.Round(c.confidence*10) / 10
+		out.NameSimilarity = math.Round(c.name*1000) / 1000
+		out.FileType = col.InferredType
+		out.FileSemanticType = col.SemanticType
+		out.Status = ConformanceMatched
+		if !typeCompatible(out.Type, col.InferredType) {
+			out.Status = ConformanceTypeMismatch
+		}
+		mapped[c.fileIdx] = true
+	}
+
+	for _, c := range result.Columns {
+		switch c.Status {
+		case ConformanceMatched:
+			result.Matched++
+		case ConformanceTypeMismatch:
+			result.TypeMismatches++
+		case ConformanceMissing:
+			result.Missing++
+			if c.Required {
+				result.MissingRequired = append(result.MissingRequired, c.Name)
+			}
+		}
+	}
+	for j, col := range dict.Columns {
+		if !mapped[j] {
+			result.ExtraColumns = append(result.ExtraColumns, col.Name)
+		}
+	}
+	result.Conformance = math.Round(float64(result.Matched)/float64(len(schema.Columns))*1000) / 10
+	return result
+}
+
+// schemaNameSimilarity is the best token similarity (0-1) of a file column
+// to the canonical name or one of its aliases
+func (s *EnhancedSimilarityService) schemaNameSimilarity(fileColumn string, canon CanonicalColumn, algorithm string) float64 {
+	best, _ := s.calculateTokenSimilarity(fileColumn, canon.Name, algorithm)
+	for _, alias := range canon.Aliases {
+		if sim, _ := s.calculateTokenSimilarity(fileColumn, alias, algorithm); sim > best {
+			best = sim
+		}
+	}
+	return best
+}
+
+// conformanceScore combines the name similarity with the type and semantic
+// type agreement into a 0-100 confidence
+func conformanceScore(name float64, canon CanonicalColumn, col DictionaryColumn) float64 {
+	typeScore := 0.0
+	if typeCompatible(canon.Type, col.InferredType) {
+		typeScore = 1
+	}
+	if canon.SemanticType == "" {
+		scale := 1 / (conformanceNameWeight + conformanceTypeWeight)
+		return 100 * scale * (conformanceNameWeight*name + conformanceTypeWeight*typeScore)
+	}
+
+	semantic := 0.0
+	switch {
+	case semanticTypesAgree(canon.SemanticType, col.SemanticType):
+		semantic = 1
+	case col.SemanticType == "":
+		semantic = 0.3 // Not detected, which sparse or unusual values cause too
+	}
+	return 100 * (conformanceNameWeight*name + conformanceTypeWeight*typeScore + conformanceSemanticWeight*semantic)
+}
+
+// typeCompatible reports whether a file column of type actual can hold the
+// canonical type; any column can hold strings
+func typeCompatible(canonical, actual string) bool {
+	return canonical == "" || canonical == string(state.ColumnString) || canonical == actual
+}
+
+// semanticTypesAgree treats the date formats as the date semantic type
+func semanticTypesAgree(canonical, actual string) bool {
+	if canonical == actual {
+		return true
+	}
+	return canonical == "date" && strings.HasPrefix(actual, "date_")
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"strings"
+)
+
+// Relationship cardinalities, File 1 side first
+const (
+	CardinalityOneToOne   = "1:1"
+	CardinalityOneToMany  = "1:N"
+	CardinalityManyToOne  = "N:1"
+	CardinalityManyToMany = "N:M"
+)
+
+// Relationship directions: the file holding the foreign key references the other
+const (
+	DirectionFile1ToFile2 = "file1->file2"
+	DirectionFile2ToFile1 = "file2->file1"
+)
+
+// Relationship inference thresholds
+const (
+	relationshipKeyConfidence = 70.0 // Matches checked, as in the exports
+	relationshipUnique        = 0.95 // Uniqueness ratio of a key side
+	relationshipContained     = 0.95 // Share of values found in the other file
+)
+
+// Relationship describes how the values of two matched columns relate
+type Relationship struct {
+	Cardinality      string  `json:"cardinality"`
+	Direction        string  `json:"direction,omitempty"`
+	FanOut           float64 `json:"fan_out"` // Joined rows per matched File 1 row
+	File1Uniqueness  float64 `json:"file1_uniqueness"`
+	File2Uniqueness  float64 `json:"file2_uniqueness"`
+	File1Containment float64 `json:"file1_containment"` // Share of File 1 values found in File 2
+	File2Containment float64 `json:"file2_containment"`
+}
+
+// InferRelationship infers cardinality and foreign-key direction of two
+// columns from their uniqueness ratios and how much of each side's values
+// the other side contains. ok is false when either column is missing or empty.
+func InferRelationship(df1, df2 *state.DataFrame, col1, col2 string) (Relationship, bool) {
+	var rel Relationship
+	idx1, idx2 := columnIndex(df1, col1), columnIndex(df2, col2)
+	if idx1 < 0 || idx2 < 0 {
+		return rel, false
+	}
+	counts1, rows1 := keyCounts(df1, idx1)
+	counts2, rows2 := keyCounts(df2, idx2)
+	if rows1 == 0 || rows2 == 0 {
+		return rel, false
+	}
+
+	rel.File1Uniqueness = float64(len(counts1)) / float64(rows1)
+	rel.File2Uniqueness = float64(len(counts2)) / float64(rows2)
+	shared, matched1, joined := 0, 0, 0
+	for v, n1 := range counts1 {
+		if n2, ok := counts2[v]; ok {
+			shared++
+			matched1 += n1
+			joined += n1 * n2
+		}
+	}
+	rel.File1Containment = float64(shared) / float64(len(counts1))
+	rel.File2Containment = float64(shared) / float64(len(counts2))
+	if matched1 > 0 {
+		rel.FanOut = float64(joined) / float64(matched1)
+	}
+
+	unique1 := rel.File1Uniqueness >= relationshipUnique
+	unique2 := rel.File2Uniqueness >= relationshipUnique
+	switch {
+	case unique1 && unique2:
+		rel.Cardinality = CardinalityOneToOne
+		// The side whose values all occur in the other references it
+		if rel.File1Containment >= relationshipContained && rel.File2Containment < relationshipContained {
+			rel.Direction = DirectionFile1ToFile2
+		} else if rel.File2Containment >= relationshipContained && rel.File1Containment < relationshipContained {
+			rel.Direction = DirectionFile2ToFile1
+		}
+	case unique1:
+		rel.Cardinality = CardinalityOneToMany
+		rel.Direction = DirectionFile2ToFile1
+	case unique2:
+		rel.Cardinality = CardinalityManyToOne
+		rel.Direction = DirectionFile1ToFile2
+	default:
+		rel.Cardinality = CardinalityManyToMany
+	}
+	return rel, true
+}
+
+// keyCounts counts rows per trimmed, lowercased non-empty value
+func keyCounts(df *state.DataFrame, colIdx int) (map[string]int, int) {
+	counts := make(map[string]int)
+	rows := 0
+	for _, row := range df.Rows {
+		if key, ok := joinKey(row, []int{colIdx}); ok {
+			counts[key]++
+			rows++
+		}
+	}
+	return counts, rows
+}
+
+// AnnotateEdges sets the relationship of the graph edges whose match is
+// confident enough to join on
+func AnnotateEdges(graph *models.SimilarityGraph, df1, df2 *state.DataFrame) {
+	for i := range graph.Edges {
+		e := &graph.Edges[i]
+		col1, ok1 := edgeColumn(graph, e.Source, "File 1")
+		col2, ok2 := edgeColumn(graph, e.Target, "File 2")
+		if !ok1 || !ok2 || e.Similarity < relationshipKeyConfidence {
+			continue
+		}
+		if rel, ok := InferRelationship(df1, df2, col1, col2); ok {
+			e.Cardinality, e.Direction, e.FanOut = rel.Cardinality, rel.Direction, rel.FanOut
+		}
+	}
+}
+
+// edgeColumn returns the column name of a graph node in a file group
+func edgeColumn(graph *models.SimilarityGraph, id, group string) (string, bool) {
+	for _, n := range graph.Nodes {
+		if n.ID == id && n.Group == group {
+			return n.Label, true
+		}
+	}
+	return "", false
+}
+
+// pairRelationship finds the annotated edge of a column pair. Graphs from
+// either similarity endpoint are accepted, so node groups and id prefixes vary.
+func pairRelationship(graph *models.SimilarityGraph, col1, col2 string) (models.Edge, bool) {
+	for _, e := range graph.Edges {
+		if e.Cardinality == "" {
+			continue
+		}
+		if (e.Source == "f1_"+col1 || e.Source == "file1_"+col1) && (e.Target == "f2_"+col2 || e.Target == "file2_"+col2) {
+			return e, true
+		}
+	}
+	return models.Edge{}, false
+}
+
+// relationshipWarnings describes the join granularity of the pairs and warns
+// where a join multiplies rows
+func relationshipWarnings(graph *models.SimilarityGraph, pairs [][2]string) []string {
+	lines := []string{}
+	for _, pair := range pairs {
+		e, ok := pairRelationship(graph, pair[0], pair[1])
+		if !ok {
+			continue
+		}
+		desc := fmt.Sprintf("%s -> %s is %s", pair[0], pair[1], e.Cardinality)
+		switch e.Direction {
+		case DirectionFile1ToFile2:
+			desc += " (File 1 references File 2)"
+		case DirectionFile2ToFile1:
+			desc += " (File 2 references File 1)"
+		}
+		lines = append(lines, desc)
+
+		switch e.Cardinality {
+		case CardinalityOneToMany:
+			lines = append(lines, fmt.Sprintf("Warning: fan-out, each File 1 row matches %.1f File 2 rows on average; the result is at File 2 granularity", e.FanOut))
+		case CardinalityManyToMany:
+			lines = append(lines, fmt.Sprintf("Warning: many-to-many join, File 1 rows repeat %.1f times on average; aggregate or deduplicate one side first", e.FanOut))
+		}
+	}
+	return lines
+}
+
+// pairValidate returns the merge validation argument of a single-pair join
+// for pandas ("one_to_many") or polars ("1:m"), "" when unknown
+func pairValidate(graph *models.SimilarityGraph, pairs [][2]string, engine string) string {
+	if len(pairs) != 1 {
+		return ""
+	}
+	e, ok := pairRelationship(graph, pairs[0][0], pairs[0][1])
+	if !ok {
+		return ""
+	}
+	pandas := map[string]string{
+		CardinalityOneToOne:   "one_to_one",
+		CardinalityOneToMany:  "one_to_many",
+		CardinalityManyToOne:  "many_to_one",
+		CardinalityManyToMany: "many_to_many",
+	}
+	if engine == PythonEnginePolars {
+		return strings.NewReplacer("one", "1", "many", "m", "_to_", ":").Replace(pandas[e.Cardinality])
+	}
+	return pandas[e.Cardinality]
+}
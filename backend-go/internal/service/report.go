@@ -0,0 +1,309 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"bytes"
+	"fmt"
+	"html/template"
+	"math"
+	"sort"
+	"time"
+)
+
+// Report output formats
+const (
+	ReportFormatHTML = "html"
+	ReportFormatPDF  = "pdf"
+)
+
+// DefaultReportTopMatches is the number of matches listed when no limit is given
+const DefaultReportTopMatches = 20
+
+// maxReportCorrelations caps the correlation highlights
+const maxReportCorrelations = 10
+
+// ReportDataset summarizes one loaded file
+type ReportDataset struct {
+	FileIndex   int                  `json:"file_index"`
+	FileName    string               `json:"file_name"`
+	Rows        int                  `json:"rows"`
+	Columns     int                  `json:"columns"`
+	AvgQuality  float64              `json:"avg_quality"` // 0-1
+	AvgNullRate float64              `json:"avg_null_rate"`
+	PrimaryKeys []string             `json:"primary_keys"`
+	Quality     []DataQualityProfile `json:"quality"`
+}
+
+// AnalysisReport is the content of the shareable analysis report
+type AnalysisReport struct {
+	GeneratedAt  time.Time            `json:"generated_at"`
+	Datasets     []ReportDataset      `json:"datasets"`
+	TopMatches   []models.Similarity  `json:"top_matches"`
+	Correlations []models.Correlation `json:"correlations"`
+	Mapping      []ColumnMapping      `json:"mapping"`
+}
+
+// BuildAnalysisReport collects the report content from the loaded files, the
+// similarity graph and the approved mapping. Any argument may be nil.
+func BuildAnalysisReport(graph *models.SimilarityGraph, mapping *ApprovedMapping, df1, df2 *state.DataFrame, topN int) *AnalysisReport {
+	if graph == nil {
+		graph = &models.SimilarityGraph{}
+	}
+	if topN <= 0 {
+		topN = DefaultReportTopMatches
+	}
+
+	report := &AnalysisReport{
+		GeneratedAt:  time.Now(),
+		Datasets:     []ReportDataset{},
+		TopMatches:   []models.Similarity{},
+		Correlations: []models.Correlation{},
+		Mapping:      []ColumnMapping{},
+	}
+
+	profiler := NewDataQualityProfiler()
+	for i, df := range []*state.DataFrame{df1, df2} {
+		if df == nil {
+			continue
+		}
+		ds := ReportDataset{
+			FileIndex:   i + 1,
+			FileName:    df.FileName,
+			Rows:        len(df.Rows),
+			Columns:     len(df.Headers),
+			PrimaryKeys: []string{},
+			Quality:     profiler.ProfileAllColumns(df),
+		}
+		for _, p := range ds.Quality {
+			ds.AvgQuality += p.QualityScore
+			ds.AvgNullRate += p.NullRate
+			if p.IsPrimaryKey {
+				ds.PrimaryKeys = append(ds.PrimaryKeys, p.ColumnName)
+			}
+		}
+		if n := len(ds.Quality); n > 0 {
+			ds.AvgQuality /= float64(n)
+			ds.AvgNullRate /= float64(n)
+		}
+		report.Datasets = append(report.Datasets, ds)
+	}
+
+	matches := make([]models.Similarity, 0, len(graph.Similarities))
+	for _, sim := range graph.Similarities {
+		if !mapping.IsRejected(sim.File1Column, sim.File2Column) {
+			matches = append(matches, sim)
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Confidence > matches[j].Confidence })
+	if len(matches) > topN {
+		matches = matches[:topN]
+	}
+	report.TopMatches = matches
+
+	if accepted := mapping.Accepted(); len(accepted) > 0 {
+		report.Mapping = accepted
+	}
+
+	if len(graph.Correlations) > 0 {
+		report.Correlations = append(report.Correlations, graph.Correlations...)
+	} else {
+		report.Correlations = joinedCorrelations(df1, df2, joinColumns(graph, mapping), report.Mapping, matches)
+	}
+	sort.SliceStable(report.Correlations, func(i, j int) bool {
+		return math.Abs(report.Correlations[i].PearsonCorrelation) > math.Abs(report.Correlations[j].PearsonCorrelation)
+	})
+	if len(report.Correlations) > maxReportCorrelations {
+		report.Correlations = report.Correlations[:maxReportCorrelations]
+	}
+
+	return report
+}
+
+// joinedCorrelations correlates matched numeric column pairs over the rows
+// that join on keys. Without join keys rows can't be paired, so there are no
+// highlights.
+func joinedCorrelations(df1, df2 *state.DataFrame, keys [][2]string, mapped []ColumnMapping, matches []models.Similarity) []models.Correlation {
+	correlations := []models.Correlation{}
+	if df1 == nil || df2 == nil || len(keys) == 0 {
+		return correlations
+	}
+
+	idx1 := make([]int, len(keys))
+	idx2 := make([]int, len(keys))
+	isKey := make(map[[2]string]bool)
+	for i, k := range keys {
+		idx1[i] = columnIndex(df1, k[0])
+		idx2[i] = columnIndex(df2, k[1])
+		if idx1[i] < 0 || idx2[i] < 0 {
+			return correlations
+		}
+		isKey[k] = true
+	}
+
+	// Candidate pairs: the approved mapping, then the top matches
+	seen := make(map[[2]string]bool)
+	pairs := [][2]int{}
+	addPair := func(c1, c2 string) {
+		pair := [2]string{c1, c2}
+		if seen[pair] || isKey[pair] {
+			return
+		}
+		seen[pair] = true
+		i1, i2 := columnIndex(df1, c1), columnIndex(df2, c2)
+		if i1 < 0 || i2 < 0 {
+			return
+		}
+		if col1, col2 := df1.Column(i1), df2.Column(i2); col1 == nil || col2 == nil || col1.Type != state.ColumnFloat || col2.Type != state.ColumnFloat {
+			return
+		}
+		pairs = append(pairs, [2]int{i1, i2})
+	}
+	for _, m := range mapped {
+		addPair(m.File1Column, m.File2Column)
+	}
+	for _, m := range matches {
+		addPair(m.File1Column, m.File2Column)
+	}
+	if len(pairs) == 0 {
+		return correlations
+	}
+
+	// First file 2 row per key
+	rowsByKey := make(map[string]int, len(df2.Rows))
+	for r, row := range df2.Rows {
+		if key, ok := joinKey(row, idx2); ok {
+			if _, exists := rowsByKey[key]; !exists {
+				rowsByKey[key] = r
+			}
+		}
+	}
+
+	xs := make([][]float64, len(pairs))
+	ys := make([][]float64, len(pairs))
+	for r1, row := range df1.Rows {
+		key, ok := joinKey(row, idx1)
+		if !ok {
+			continue
+		}
+		r2, ok := rowsByKey[key]
+		if !ok {
+			continue
+		}
+		for p, pair := range pairs {
+			v1, ok1 := df1.Column(pair[0]).FloatAt(r1)
+			v2, ok2 := df2.Column(pair[1]).FloatAt(r2)
+			if ok1 && ok2 {
+				xs[p] = append(xs[p], v1)
+				ys[p] = append(ys[p], v2)
+			}
+		}
+	}
+
+	for p, pair := range pairs {
+		if len(xs[p]) < 3 {
+			continue
+		}
+		corr := pearsonCorrelation(xs[p], ys[p])
+		var strength string
+		switch abs := math.Abs(corr); {
+		case abs >= 0.7:
+			strength = "Strong"
+		case abs >= 0.4:
+			strength = "Moderate"
+		case abs >= 0.2:
+			strength = "Weak"
+		default:
+			continue
+		}
+		correlations = append(correlations, models.Correlation{
+			File1Column:        df1.Headers[pair[0]],
+			File2Column:        df2.Headers[pair[1]],
+			PearsonCorrelation: corr,
+			Strength:           strength,
+			SampleSize:         len(xs[p]),
+		})
+	}
+	return correlations
+}
+
+var reportFuncs = template.FuncMap{
+	"pct":   pctString,
+	"num":   func(v float64) string { return fmt.Sprintf("%.1f", v) },
+	"corr":  func(v float64) string { return fmt.Sprintf("%+.2f", v) },
+	"yesNo": yesNo,
+}
+
+var reportTemplate = template.Must(template.New("report").Funcs(reportFuncs).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>Project Euler analysis report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #1f2933; margin: 2rem auto; max-width: 1100px; padding: 0 1rem; }
+h1 { margin-bottom: 0.2rem; }
+h2 { border-bottom: 2px solid #e4e7eb; padding-bottom: 0.3rem; margin-top: 2rem; }
+.meta { color: #616e7c; }
+table { border-collapse: collapse; width: 100%; margin: 0.5rem 0 1rem; font-size: 0.9rem; }
+th, td { border: 1px solid #e4e7eb; padding: 0.35rem 0.5rem; text-align: left; vertical-align: top; }
+th { background: #f5f7fa; }
+td.n { text-align: right; font-variant-numeric: tabular-nums; }
+.empty { color: #9aa5b1; font-style: italic; }
+@media print { body { margin: 0; max-width: none; } h2 { page-break-after: avoid; } tr { page-break-inside: avoid; } }
+</style>
+</head>
+<body>
+<h1>Project Euler analysis report</h1>
+<p class="meta">Generated {{.GeneratedAt.Format "2006-01-02 15:04 MST"}}</p>
+
+<h2>Datasets</h2>
+{{if .Datasets}}
+<table>
+<tr><th>File</th><th>Name</th><th>Rows</th><th>Columns</th><th>Avg quality</th><th>Avg null rate</th><th>Likely keys</th></tr>
+{{range .Datasets}}<tr><td>{{.FileIndex}}</td><td>{{.FileName}}</td><td class="n">{{.Rows}}</td><td class="n">{{.Columns}}</td><td class="n">{{pct .AvgQuality}}</td><td class="n">{{pct .AvgNullRate}}</td><td>{{range $i, $k := .PrimaryKeys}}{{if $i}}, {{end}}{{$k}}{{end}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No files loaded.</p>{{end}}
+
+{{range .Datasets}}
+<h2>Quality profile: {{.FileName}}</h2>
+<table>
+<tr><th>Column</th><th>Null rate</th><th>Distinct</th><th>Uniqueness</th><th>Entropy</th><th>Key</th><th>Quality</th></tr>
+{{range .Quality}}<tr><td>{{.ColumnName}}</td><td class="n">{{pct .NullRate}}</td><td class="n">{{.DistinctCount}}</td><td class="n">{{pct .UniquenessRatio}}</td><td class="n">{{printf "%.2f" .Entropy}}</td><td>{{yesNo .IsPrimaryKey}}</td><td class="n">{{pct .QualityScore}}</td></tr>
+{{end}}</table>
+{{end}}
+
+<h2>Top matches</h2>
+{{if .TopMatches}}
+<table>
+<tr><th>File 1 column</th><th>File 2 column</th><th>Confidence</th><th>Name</th><th>Data</th><th>Type</th><th>Reason</th></tr>
+{{range .TopMatches}}<tr><td>{{.File1Column}}</td><td>{{.File2Column}}</td><td class="n">{{num .Confidence}}</td><td class="n">{{num .NameSimilarity}}</td><td class="n">{{num .DataSimilarity}}</td><td>{{.Type}}</td><td>{{.Reason}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No matches in the similarity graph.</p>{{end}}
+
+<h2>Correlation highlights</h2>
+{{if .Correlations}}
+<table>
+<tr><th>File 1 column</th><th>File 2 column</th><th>Pearson</th><th>Strength</th><th>Sample size</th></tr>
+{{range .Correlations}}<tr><td>{{.File1Column}}</td><td>{{.File2Column}}</td><td class="n">{{corr .PearsonCorrelation}}</td><td>{{.Strength}}</td><td class="n">{{.SampleSize}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No notable correlations between matched numeric columns.</p>{{end}}
+
+<h2>Approved mapping</h2>
+{{if .Mapping}}
+<table>
+<tr><th>File 1 column</th><th>File 2 column</th><th>Join key</th><th>Source</th><th>Confidence</th><th>Transform</th><th>Note</th></tr>
+{{range .Mapping}}<tr><td>{{.File1Column}}</td><td>{{.File2Column}}</td><td>{{yesNo .JoinKey}}</td><td>{{.Source}}</td><td class="n">{{num .Confidence}}</td><td>{{.Transform}}</td><td>{{.Note}}</td></tr>
+{{end}}</table>
+{{else}}<p class="empty">No mappings approved yet.</p>{{end}}
+</body>
+</html>
+`))
+
+// RenderReportHTML renders the report as a standalone, print-friendly HTML page
+func RenderReportHTML(report *AnalysisReport) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := reportTemplate.Execute(&buf, report); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,264 @@
+package service
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// PDF page layout (A4, points). The report is set in Courier so tables can
+// be laid out as fixed-width text without font metrics.
+const (
+	pdfPageWidth   = 595
+	pdfPageHeight  = 842
+	pdfMargin      = 40
+	pdfFontSize    = 8
+	pdfLineHeight  = 11
+	pdfLineChars   = 128 // Courier is 0.6em wide: (595 - 2*40) / (0.6*8)
+	pdfPageLines   = (pdfPageHeight - 2*pdfMargin) / pdfLineHeight
+	pdfMaxCellText = 32
+)
+
+// pdfLine is one line of report text
+type pdfLine struct {
+	text    string
+	heading bool
+}
+
+// RenderReportPDF renders the report as a plain-text PDF document
+func RenderReportPDF(report *AnalysisReport) ([]byte, error) {
+	var lines []pdfLine
+	heading := func(s string) {
+		lines = append(lines, pdfLine{}, pdfLine{text: s, heading: true})
+	}
+	text := func(s string) {
+		lines = append(lines, pdfLine{text: s})
+	}
+	table := func(headers []string, rows [][]string) {
+		for _, l := range textTable(headers, rows) {
+			text(l)
+		}
+	}
+
+	lines = append(lines, pdfLine{text: "Project Euler analysis report", heading: true})
+	text("Generated " + report.GeneratedAt.Format("2006-01-02 15:04 MST"))
+
+	heading("Datasets")
+	if len(report.Datasets) == 0 {
+		text("No files loaded.")
+	} else {
+		rows := [][]string{}
+		for _, ds := range report.Datasets {
+			rows = append(rows, []string{
+				fmt.Sprint(ds.FileIndex), ds.FileName, fmt.Sprint(ds.Rows), fmt.Sprint(ds.Columns),
+				pctString(ds.AvgQuality), pctString(ds.AvgNullRate), strings.Join(ds.PrimaryKeys, ", "),
+			})
+		}
+		table([]string{"File", "Name", "Rows", "Columns", "Avg quality", "Avg null rate", "Likely keys"}, rows)
+	}
+
+	for _, ds := range report.Datasets {
+		heading("Quality profile: " + ds.FileName)
+		rows := [][]string{}
+		for _, p := range ds.Quality {
+			rows = append(rows, []string{
+				p.ColumnName, pctString(p.NullRate), fmt.Sprint(p.DistinctCount), pctString(p.UniquenessRatio),
+				fmt.Sprintf("%.2f", p.Entropy), yesNo(p.IsPrimaryKey), pctString(p.QualityScore),
+			})
+		}
+		table([]string{"Column", "Null rate", "Distinct", "Uniqueness", "Entropy", "Key", "Quality"}, rows)
+	}
+
+	heading("Top matches")
+	if len(report.TopMatches) == 0 {
+		text("No matches in the similarity graph.")
+	} else {
+		rows := [][]string{}
+		for _, m := range report.TopMatches {
+			rows = append(rows, []string{
+				m.File1Column, m.File2Column, fmt.Sprintf("%.1f", m.Confidence),
+				fmt.Sprintf("%.1f", m.NameSimilarity), fmt.Sprintf("%.1f", m.DataSimilarity), m.Type, m.Reason,
+			})
+		}
+		table([]string{"File 1 column", "File 2 column", "Confidence", "Name", "Data", "Type", "Reason"}, rows)
+	}
+
+	heading("Correlation highlights")
+	if len(report.Correlations) == 0 {
+		text("No notable correlations between matched numeric columns.")
+	} else {
+		rows := [][]string{}
+		for _, c := range report.Correlations {
+			rows = append(rows, []string{
+				c.File1Column, c.File2Column, fmt.Sprintf("%+.2f", c.PearsonCorrelation), c.Strength, fmt.Sprint(c.SampleSize),
+			})
+		}
+		table([]string{"File 1 column", "File 2 column", "Pearson", "Strength", "Sample size"}, rows)
+	}
+
+	heading("Approved mapping")
+	if len(report.Mapping) == 0 {
+		text("No mappings approved yet.")
+	} else {
+		rows := [][]string{}
+		for _, m := range report.Mapping {
+			rows = append(rows, []string{
+				m.File1Column, m.File2Column, yesNo(m.JoinKey), m.Source, fmt.Sprintf("%.1f", m.Confidence), m.Transform, m.Note,
+			})
+		}
+		table([]string{"File 1 column", "File 2 column", "Join key", "Source", "Confidence", "Transform", "Note"}, rows)
+	}
+
+	return writePDF(lines), nil
+}
+
+func pctString(v float64) string {
+	return fmt.Sprintf("%.1f%%", v*100)
+}
+
+// textTable lays out rows as fixed-width text. Cells are truncated so the
+// table fits pdfLineChars.
+func textTable(headers []string, rows [][]string) []string {
+	widths := make([]int, len(headers))
+	cell := func(s string) string {
+		s = strings.Join(strings.Fields(s), " ")
+		if r := []rune(s); len(r) > pdfMaxCellText {
+			s = string(r[:pdfMaxCellText-1]) + "~"
+		}
+		return s
+	}
+	for i, h := range headers {
+		widths[i] = len([]rune(h))
+	}
+	for _, row := range rows {
+		for i := range headers {
+			if i < len(row) {
+				if n := len([]rune(cell(row[i]))); n > widths[i] {
+					widths[i] = n
+				}
+			}
+		}
+	}
+
+	// Shrink the widest column until the table fits
+	for {
+		total := 2 * (len(widths) - 1)
+		widest := 0
+		for i, w := range widths {
+			total += w
+			if w > widths[widest] {
+				widest = i
+			}
+		}
+		if total <= pdfLineChars || widths[widest] <= 4 {
+			break
+		}
+		widths[widest]--
+	}
+
+	format := func(values []string) string {
+		parts := make([]string, len(widths))
+		for i, w := range widths {
+			v := ""
+			if i < len(values) {
+				v = cell(values[i])
+			}
+			if r := []rune(v); len(r) > w {
+				v = string(r[:w-1]) + "~"
+			}
+			parts[i] = v + strings.Repeat(" ", w-len([]rune(v)))
+		}
+		return strings.TrimRight(strings.Join(parts, "  "), " ")
+	}
+
+	sep := make([]string, len(widths))
+	for i, w := range widths {
+		sep[i] = strings.Repeat("-", w)
+	}
+	lines := []string{format(headers), strings.Join(sep, "  ")}
+	for _, row := range rows {
+		lines = append(lines, format(row))
+	}
+	return lines
+}
+
+// writePDF writes lines as a minimal PDF 1.4 document using the built-in
+// Courier fonts, breaking pages every pdfPageLines lines
+func writePDF(lines []pdfLine) []byte {
+	var pages [][]pdfLine
+	for start := 0; start < len(lines); start += pdfPageLines {
+		end := start + pdfPageLines
+		if end > len(lines) {
+			end = len(lines)
+		}
+		pages = append(pages, lines[start:end])
+	}
+	if len(pages) == 0 {
+		pages = [][]pdfLine{{}}
+	}
+
+	var buf bytes.Buffer
+	offsets := []int{}
+	object := func(body string) {
+		offsets = append(offsets, buf.Len())
+		fmt.Fprintf(&buf, "%d 0 obj\n%s\nendobj\n", len(offsets), body)
+	}
+
+	buf.WriteString("%PDF-1.4\n")
+
+	// 1: catalog, 2: page tree, 3-4: fonts, then a page and its content per page
+	kids := make([]string, len(pages))
+	for i := range pages {
+		kids[i] = fmt.Sprintf("%d 0 R", 5+2*i)
+	}
+	object("<< /Type /Catalog /Pages 2 0 R >>")
+	object(fmt.Sprintf("<< /Type /Pages /Kids [%s] /Count %d >>", strings.Join(kids, " "), len(pages)))
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>")
+	object("<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>")
+
+	for i, page := range pages {
+		var content bytes.Buffer
+		content.WriteString("BT\n")
+		fmt.Fprintf(&content, "%d TL\n%d %d Td\n", pdfLineHeight, pdfMargin, pdfPageHeight-pdfMargin-pdfFontSize)
+		for _, l := range page {
+			font := "F1"
+			if l.heading {
+				font = "F2"
+			}
+			fmt.Fprintf(&content, "/%s %d Tf (%s) Tj T*\n", font, pdfFontSize, pdfEscape(l.text))
+		}
+		fmt.Fprintf(&content, "ET\nBT /F1 %d Tf %d %d Td (Page %d of %d) Tj ET\n", pdfFontSize, pdfMargin, pdfMargin/2, i+1, len(pages))
+
+		object(fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %d %d] /Resources << /Font << /F1 3 0 R /F2 4 0 R >> >> /Contents %d 0 R >>",
+			pdfPageWidth, pdfPageHeight, 6+2*i))
+		object(fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()))
+	}
+
+	xref := buf.Len()
+	fmt.Fprintf(&buf, "xref\n0 %d\n0000000000 65535 f \n", len(offsets)+1)
+	for _, off := range offsets {
+		fmt.Fprintf(&buf, "%010d 00000 n \n", off)
+	}
+	fmt.Fprintf(&buf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(offsets)+1, xref)
+	return buf.Bytes()
+}
+
+// pdfEscape encodes text as a PDF string body: Latin-1, with characters
+// outside it replaced by '?'
+func pdfEscape(s string) string {
+	var sb strings.Builder
+	for _, r := range s {
+		switch {
+		case r == '\\' || r == '(' || r == ')':
+			sb.WriteByte('\\')
+			sb.WriteByte(byte(r))
+		case r < 0x20:
+			sb.WriteByte(' ')
+		case r > 0xff:
+			sb.WriteByte('?')
+		default:
+			sb.WriteByte(byte(r))
+		}
+	}
+	return sb.String()
+}
@@ -0,0 +1,237 @@
+package service
+
+import (
+	"backend-go/internal/state"
+	"fmt"
+	"strings"
+)
+
+// Row change kinds of a row diff
+const (
+	RowAdded   = "added"
+	RowDeleted = "deleted"
+	RowChanged = "changed"
+)
+
+// FieldChange is one differing value of a changed row
+type FieldChange struct {
+	Column string `json:"column"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// RowChange is an added, deleted or changed row. Row numbers are 1-based
+// data rows; values hold the whole row of added and deleted rows.
+type RowChange struct {
+	Kind     string            `json:"kind"`
+	Key      map[string]string `json:"key"`
+	File1Row int               `json:"file1_row,omitempty"`
+	File2Row int               `json:"file2_row,omitempty"`
+	Values   map[string]string `json:"values,omitempty"`
+	Changes  []FieldChange     `json:"changes,omitempty"`
+}
+
+// RowDiffSummary counts rows by outcome
+type RowDiffSummary struct {
+	Added         int `json:"added"`
+	Deleted       int `json:"deleted"`
+	Changed       int `json:"changed"`
+	Unchanged     int `json:"unchanged"`
+	DuplicateKeys int `json:"duplicate_keys"` // Rows skipped because their key repeats
+}
+
+// RowDiff reconciles the rows of two versions of a dataset by key
+type RowDiff struct {
+	KeyColumns []string       `json:"key_columns"`
+	Columns    []string       `json:"columns"` // Compared non-key columns
+	Summary    RowDiffSummary `json:"summary"`
+	Rows       []RowChange    `json:"rows"`
+	Total      int            `json:"total"` // Changes matching the kind filter
+}
+
+// DiffRows compares the rows of df1 (old) and df2 (new) that share a key.
+// keyColumns must exist in both files; when empty the approved join keys of
+// the pair are used, or else the first shared column unique in both files.
+// Only columns of the same name are compared. kind optionally filters the
+// returned changes; offset and limit page them.
+func DiffRows(df1, df2 *state.DataFrame, keyColumns []string, kind string, offset, limit int) (*RowDiff, error) {
+	if len(keyColumns) == 0 {
+		keyColumns = defaultRowKey(df1, df2)
+		if len(keyColumns) == 0 {
+			return nil, fmt.Errorf("no key column found; pass key_columns")
+		}
+	}
+	keyIdx1, keyIdx2 := columnIndices(df1, keyColumns), columnIndices(df2, keyColumns)
+	for i, c := range keyColumns {
+		if keyIdx1[i] < 0 || keyIdx2[i] < 0 {
+			return nil, fmt.Errorf("key column %q not found in both files", c)
+		}
+	}
+
+	isKey := make(map[string]bool, len(keyColumns))
+	for _, c := range keyColumns {
+		isKey[c] = true
+	}
+	diff := &RowDiff{KeyColumns: keyColumns, Columns: []string{}, Rows: []RowChange{}}
+	valueIdx1, valueIdx2 := []int{}, []int{}
+	for i, h := range df1.Headers {
+		if j := columnIndex(df2, h); j >= 0 && !isKey[h] {
+			diff.Columns = append(diff.Columns, h)
+			valueIdx1 = append(valueIdx1, i)
+			valueIdx2 = append(valueIdx2, j)
+		}
+	}
+
+	index2 := make(map[string]int)
+	for r, row := range df2.Rows {
+		key, ok := joinKey(row, keyIdx2)
+		if !ok {
+			continue
+		}
+		if _, dup := index2[key]; dup {
+			diff.Summary.DuplicateKeys++
+			continue
+		}
+		index2[key] = r
+	}
+
+	changes := []RowChange{}
+	seen := make(map[string]bool)
+	for r, row := range df1.Rows {
+		key, ok := joinKey(row, keyIdx1)
+		if !ok {
+			continue
+		}
+		if seen[key] {
+			diff.Summary.DuplicateKeys++
+			continue
+		}
+		seen[key] = true
+
+		r2, found := index2[key]
+		if !found {
+			diff.Summary.Deleted++
+			changes = append(changes, RowChange{
+				Kind:     RowDeleted,
+				Key:      rowValues(df1, row, keyColumns, keyIdx1),
+				File1Row: r + 1,
+				Values:   rowValues(df1, row, df1.Headers, nil),
+			})
+			continue
+		}
+
+		fields := []FieldChange{}
+		for i := range valueIdx1 {
+			if !sameValue(df1, df2, r, r2, valueIdx1[i], valueIdx2[i]) {
+				fields = append(fields, FieldChange{
+					Column: diff.Columns[i],
+					Before: cellValue(row, valueIdx1[i]),
+					After:  cellValue(df2.Rows[r2], valueIdx2[i]),
+				})
+			}
+		}
+		if len(fields) == 0 {
+			diff.Summary.Unchanged++
+			continue
+		}
+		diff.Summary.Changed++
+		changes = append(changes, RowChange{
+			Kind:     RowChanged,
+			Key:      rowValues(df1, row, keyColumns, keyIdx1),
+			File1Row: r + 1,
+			File2Row: r2 + 1,
+			Changes:  fields,
+		})
+	}
+
+	for r, row := range df2.Rows {
+		key, ok := joinKey(row, keyIdx2)
+		if !ok || seen[key] || index2[key] != r {
+			continue
+		}
+		diff.Summary.Added++
+		changes = append(changes, RowChange{
+			Kind:     RowAdded,
+			Key:      rowValues(df2, row, keyColumns, keyIdx2),
+			File2Row: r + 1,
+			Values:   rowValues(df2, row, df2.Headers, nil),
+		})
+	}
+
+	filtered := changes[:0]
+	for _, c := range changes {
+		if kind == "" || c.Kind == kind {
+			filtered = append(filtered, c)
+		}
+	}
+	diff.Total = len(filtered)
+	if offset < diff.Total {
+		end := offset + limit
+		if limit <= 0 || end > diff.Total {
+			end = diff.Total
+		}
+		diff.Rows = filtered[offset:end]
+	}
+	return diff, nil
+}
+
+// defaultRowKey picks the key of a row diff: approved join keys whose
+// columns keep their name, otherwise the first shared column unique in both files
+func defaultRowKey(df1, df2 *state.DataFrame) []string {
+	keys := []string{}
+	for _, k := range GetMappingStore().Get(DatasetPairScope(df1, df2)).JoinKeys() {
+		if k.File1Column == k.File2Column {
+			keys = append(keys, k.File1Column)
+		}
+	}
+	if len(keys) > 0 {
+		return keys
+	}
+	for i, h := range df1.Headers {
+		j := columnIndex(df2, h)
+		if j < 0 {
+			continue
+		}
+		counts1, rows1 := keyCounts(df1, i)
+		counts2, rows2 := keyCounts(df2, j)
+		if rows1 == len(df1.Rows) && rows2 == len(df2.Rows) && len(counts1) == rows1 && len(counts2) == rows2 && rows1 > 0 {
+			return []string{h}
+		}
+	}
+	return nil
+}
+
+// sameValue compares two cells, numerically when both columns are numeric
+// so that "1.0" equals "1"
+func sameValue(df1, df2 *state.DataFrame, r1, r2, colIdx1, colIdx2 int) bool {
+	c1, c2 := df1.Column(colIdx1), df2.Column(colIdx2)
+	if c1 != nil && c2 != nil && c1.Type == state.ColumnFloat && c2.Type == state.ColumnFloat {
+		v1, ok1 := c1.FloatAt(r1)
+		v2, ok2 := c2.FloatAt(r2)
+		if ok1 && ok2 {
+			return v1 == v2
+		}
+	}
+	return cellValue(df1.Rows[r1], colIdx1) == cellValue(df2.Rows[r2], colIdx2)
+}
+
+// cellValue returns the trimmed value of a cell, "" when the row is short
+func cellValue(row []string, colIdx int) string {
+	if colIdx < 0 || colIdx >= len(row) {
+		return ""
+	}
+	return strings.TrimSpace(row[colIdx])
+}
+
+// rowValues maps column names to the cells of a row; idx defaults to the
+// column positions of cols in df
+func rowValues(df *state.DataFrame, row []string, cols []string, idx []int) map[string]string {
+	if idx == nil {
+		idx = columnIndices(df, cols)
+	}
+	values := make(map[string]string, len(cols))
+	for i, c := range cols {
+		values[c] = cellValue(row, idx[i])
+	}
+	return values
+}
@@ -0,0 +1,378 @@
+package service
+
+import (
+	"backend-go/internal/analysis"
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var savedQueryLog = logging.Component("saved_queries")
+
+const savedQueriesFile = "saved_queries.json"
+
+// Saved query limits
+const (
+	savedQueryMaxRuns     = 20          // Stored runs per query, newest kept
+	savedQueryMinSchedule = time.Minute // Shortest schedule interval
+	savedQueryTick        = 30 * time.Second
+)
+
+// Saved query run triggers
+const (
+	RunTriggerManual   = "manual"
+	RunTriggerSchedule = "schedule"
+	RunTriggerUpload   = "upload"
+)
+
+// SavedQuery is a natural-language question or a structured plan kept for
+// re-running against the loaded files
+type SavedQuery struct {
+	ID          string              `json:"id"`
+	Name        string              `json:"name"`
+	Question    string              `json:"question,omitempty"` // Answered like POST /query
+	Plan        *analysis.QueryPlan `json:"plan,omitempty"`     // Executed as is; takes precedence over the question
+	Dataset     string              `json:"dataset,omitempty"`  // "file1", "file2" or "both"; empty detects it like /query
+	Schedule    string              `json:"schedule,omitempty"` // Re-run interval as a duration, e.g. "1h"; empty = not scheduled
+	RunOnUpload bool                `json:"run_on_upload"`      // Re-run when a file it reads is uploaded
+	CreatedAt   time.Time           `json:"created_at"`
+	LastRunAt   *time.Time          `json:"last_run_at,omitempty"`
+	NextRunAt   *time.Time          `json:"next_run_at,omitempty"`
+}
+
+// SavedQueryRun is the stored result of one run of a saved query
+type SavedQueryRun struct {
+	QueryID   string                   `json:"query_id"`
+	Trigger   string                   `json:"trigger"`
+	RanAt     time.Time                `json:"ran_at"`
+	Dataset   string                   `json:"dataset"`
+	Files     []string                 `json:"files"` // Names of the files the run read
+	Mode      string                   `json:"mode,omitempty"`
+	Answer    string                   `json:"answer,omitempty"`
+	Plan      *analysis.QueryPlan      `json:"plan,omitempty"`
+	Rows      []map[string]interface{} `json:"rows,omitempty"`
+	Error     string                   `json:"error,omitempty"`
+	Succeeded bool                     `json:"succeeded"`
+}
+
+// SavedQueryRunner executes a saved query against the loaded files
+type SavedQueryRunner func(q SavedQuery) SavedQueryRun
+
+// SavedQueryStore keeps saved queries and their recent runs, and runs the
+// scheduled ones
+type SavedQueryStore struct {
+	queries map[string]*SavedQuery
+	runs    map[string][]SavedQueryRun // Query ID -> runs, oldest first
+	runner  SavedQueryRunner
+	mutex   sync.Mutex
+}
+
+// savedQueriesData is the file layout of the store
+type savedQueriesData struct {
+	Queries []*SavedQuery              `json:"queries"`
+	Runs    map[string][]SavedQueryRun `json:"runs"`
+}
+
+var (
+	savedQueryStore     *SavedQueryStore
+	savedQueryStoreOnce sync.Once
+)
+
+// GetSavedQueryStore returns the singleton saved query store
+func GetSavedQueryStore() *SavedQueryStore {
+	savedQueryStoreOnce.Do(func() {
+		savedQueryStore = &SavedQueryStore{
+			queries: make(map[string]*SavedQuery),
+			runs:    make(map[string][]SavedQueryRun),
+		}
+		savedQueryStore.load()
+	})
+	return savedQueryStore
+}
+
+// load loads saved queries and runs from file
+func (s *SavedQueryStore) load() {
+	data, err := os.ReadFile(config.DataPath(savedQueriesFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			savedQueryLog.Error("Error loading saved queries", "error", err)
+		}
+		return
+	}
+
+	var saved savedQueriesData
+	if err := json.Unmarshal(data, &saved); err != nil {
+		savedQueryLog.Error("Error parsing saved queries", "error", err)
+		return
+	}
+	for _, q := range saved.Queries {
+		s.queries[q.ID] = q
+	}
+	for id, runs := range saved.Runs {
+		if s.queries[id] != nil {
+			s.runs[id] = runs
+		}
+	}
+	savedQueryLog.Info("Loaded saved queries", "count", len(saved.Queries))
+}
+
+// save persists queries and runs to file (must hold lock)
+func (s *SavedQueryStore) save() error {
+	saved := savedQueriesData{Queries: make([]*SavedQuery, 0, len(s.queries)), Runs: s.runs}
+	for _, q := range s.queries {
+		saved.Queries = append(saved.Queries, q)
+	}
+	sort.Slice(saved.Queries, func(i, j int) bool { return saved.Queries[i].CreatedAt.Before(saved.Queries[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(saved, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(savedQueriesFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(savedQueriesFile), data, 0644)
+}
+
+// List returns the saved queries, oldest first
+func (s *SavedQueryStore) List() []SavedQuery {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := make([]SavedQuery, 0, len(s.queries))
+	for _, q := range s.queries {
+		list = append(list, *q)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// Get looks up a saved query by ID
+func (s *SavedQueryStore) Get(id string) (SavedQuery, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	q, ok := s.queries[id]
+	if !ok {
+		return SavedQuery{}, false
+	}
+	return *q, true
+}
+
+// Save validates and stores a new saved query
+func (s *SavedQueryStore) Save(q SavedQuery) (SavedQuery, error) {
+	q.Name = strings.TrimSpace(q.Name)
+	q.Question = strings.TrimSpace(q.Question)
+	if q.Name == "" {
+		return q, fmt.Errorf("name is required")
+	}
+	if q.Question == "" && q.Plan == nil {
+		return q, fmt.Errorf("question or plan is required")
+	}
+	switch q.Dataset {
+	case "", "file1", "file2", "both":
+	default:
+		return q, fmt.Errorf("dataset must be file1, file2 or both")
+	}
+	now := time.Now()
+	q.ID = newSavedQueryID()
+	q.CreatedAt = now
+	q.LastRunAt = nil
+	q.NextRunAt = nil
+	if q.Schedule != "" {
+		interval, err := time.ParseDuration(q.Schedule)
+		if err != nil {
+			return q, fmt.Errorf("schedule must be a duration such as 30m or 24h")
+		}
+		if interval < savedQueryMinSchedule {
+			return q, fmt.Errorf("schedule must be at least %s", savedQueryMinSchedule)
+		}
+		next := now.Add(interval)
+		q.NextRunAt = &next
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.queries[q.ID] = &q
+	savedQueryLog.Info("Saved query", "id", q.ID, "name", q.Name)
+	return q, s.save()
+}
+
+// Delete removes a saved query and its runs
+func (s *SavedQueryStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.queries[id]; !ok {
+		return fmt.Errorf("saved query %q not found", id)
+	}
+	delete(s.queries, id)
+	delete(s.runs, id)
+	savedQueryLog.Info("Deleted query", "id", id)
+	return s.save()
+}
+
+// Runs returns the stored runs of a query, newest first
+func (s *SavedQueryStore) Runs(id string) []SavedQueryRun {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	runs := make([]SavedQueryRun, 0, len(s.runs[id]))
+	for i := len(s.runs[id]) - 1; i >= 0; i-- {
+		runs = append(runs, s.runs[id][i])
+	}
+	return runs
+}
+
+// SetRunner installs the function that executes saved queries
+func (s *SavedQueryStore) SetRunner(runner SavedQueryRunner) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runner = runner
+}
+
+// Run executes a saved query now and stores the result
+func (s *SavedQueryStore) Run(id, trigger string) (SavedQueryRun, error) {
+	s.mutex.Lock()
+	q, ok := s.queries[id]
+	runner := s.runner
+	var query SavedQuery
+	if ok {
+		query = *q
+	}
+	s.mutex.Unlock()
+	if !ok {
+		return SavedQueryRun{}, fmt.Errorf("saved query %q not found", id)
+	}
+	if runner == nil {
+		return SavedQueryRun{}, fmt.Errorf("saved queries can't be run yet")
+	}
+
+	run := runner(query)
+	run.QueryID = id
+	run.Trigger = trigger
+	run.RanAt = time.Now()
+	run.Succeeded = run.Error == ""
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	q, ok = s.queries[id]
+	if !ok {
+		return run, nil // Deleted while running
+	}
+	s.runs[id] = append(s.runs[id], run)
+	if len(s.runs[id]) > savedQueryMaxRuns {
+		s.runs[id] = s.runs[id][len(s.runs[id])-savedQueryMaxRuns:]
+	}
+	q.LastRunAt = &run.RanAt
+	if interval, err := time.ParseDuration(q.Schedule); err == nil && q.Schedule != "" {
+		next := run.RanAt.Add(interval)
+		q.NextRunAt = &next
+	}
+	if err := s.save(); err != nil {
+		savedQueryLog.Error("Error saving run", "error", err)
+	}
+	notifySavedQueryRun(query, run)
+	return run, nil
+}
+
+// notifySavedQueryRun tells the webhooks a saved query has run
+func notifySavedQueryRun(q SavedQuery, run SavedQueryRun) {
+	summary := fmt.Sprintf("%q (%s run) returned %d rows", q.Name, run.Trigger, len(run.Rows))
+	if answer, _, _ := strings.Cut(run.Answer, "\n"); answer != "" {
+		summary = fmt.Sprintf("%q (%s run): %s", q.Name, run.Trigger, answer)
+	}
+	if !run.Succeeded {
+		summary = fmt.Sprintf("%q (%s run) failed: %s", q.Name, run.Trigger, run.Error)
+	}
+	GetWebhookStore().Notify(WebhookEvent{
+		Event:   EventSavedQueryCompleted,
+		Time:    run.RanAt,
+		Summary: summary,
+		Data: map[string]interface{}{
+			"query_id":  q.ID,
+			"name":      q.Name,
+			"trigger":   run.Trigger,
+			"dataset":   run.Dataset,
+			"files":     run.Files,
+			"succeeded": run.Succeeded,
+			"error":     run.Error,
+			"answer":    run.Answer,
+			"rows":      len(run.Rows),
+		},
+	})
+}
+
+// RunForUpload re-runs, in the background, the queries flagged to run on
+// upload that read the uploaded file
+func (s *SavedQueryStore) RunForUpload(fileIndex int) {
+	s.mutex.Lock()
+	ids := []string{}
+	for id, q := range s.queries {
+		if q.RunOnUpload && (q.Dataset == "" || q.Dataset == "both" || q.Dataset == "file"+strconv.Itoa(fileIndex)) {
+			ids = append(ids, id)
+		}
+	}
+	s.mutex.Unlock()
+	if len(ids) == 0 {
+		return
+	}
+
+	go func() {
+		for _, id := range ids {
+			if _, err := s.Run(id, RunTriggerUpload); err != nil {
+				savedQueryLog.Error("Error running query after upload", "id", id, "error", err)
+			}
+		}
+	}()
+}
+
+// StartScheduler runs due scheduled queries in the background
+func (s *SavedQueryStore) StartScheduler() {
+	go func() {
+		ticker := time.NewTicker(savedQueryTick)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			for _, id := range s.due(now) {
+				if _, err := s.Run(id, RunTriggerSchedule); err != nil {
+					savedQueryLog.Error("Error running scheduled query", "id", id, "error", err)
+				}
+			}
+		}
+	}()
+	savedQueryLog.Info("Scheduler started")
+}
+
+// due lists the scheduled queries whose next run has come
+func (s *SavedQueryStore) due(now time.Time) []string {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	ids := []string{}
+	for id, q := range s.queries {
+		if q.NextRunAt != nil && !q.NextRunAt.After(now) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	return ids
+}
+
+func newSavedQueryID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "sq_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "sq_" + hex.EncodeToString(b)
+}
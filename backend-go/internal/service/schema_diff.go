@@ -0,0 +1,451 @@
+package service
+
+import (
+	"backend-go/internal/state"
+	"math"
+	"sort"
+	"time"
+)
+
+// Drift levels of a column's distribution, from the population stability
+// index (PSI): below 0.1 stable, up to 0.25 moderate, above that major
+const (
+	DriftStable   = "stable"
+	DriftModerate = "moderate"
+	DriftMajor    = "major"
+)
+
+// Schema diff thresholds
+const (
+	renameMinConfidence = 60.0 // Match confidence a rename needs
+	psiBins             = 10   // Quantile bins of numeric and date columns
+	psiCategories       = 20   // Most frequent values binned separately
+	psiEpsilon          = 1e-4 // Share substituted for empty bins
+	driftTopValues      = 5    // Category shifts reported per column
+)
+
+// SchemaColumn is a column of one version with its inferred type
+type SchemaColumn struct {
+	Name string `json:"name"`
+	Type string `json:"type"` // text, number or date
+}
+
+// ColumnRename is a column that was renamed between versions
+type ColumnRename struct {
+	From       string  `json:"from"`
+	To         string  `json:"to"`
+	Confidence float64 `json:"confidence"`
+	Reason     string  `json:"reason,omitempty"`
+}
+
+// ColumnTypeChange is a kept or renamed column whose type changed
+type ColumnTypeChange struct {
+	Column   string `json:"column"`             // Name in the new version
+	Previous string `json:"previous,omitempty"` // Name in the old version, when renamed
+	From     string `json:"from"`
+	To       string `json:"to"`
+}
+
+// SchemaDiff compares the columns of an old (file 1) and new (file 2)
+// version of a dataset
+type SchemaDiff struct {
+	OldRows     int                `json:"old_rows"`
+	NewRows     int                `json:"new_rows"`
+	Added       []SchemaColumn     `json:"added"`
+	Removed     []SchemaColumn     `json:"removed"`
+	Renamed     []ColumnRename     `json:"renamed"`
+	TypeChanges []ColumnTypeChange `json:"type_changes"`
+	Unchanged   int                `json:"unchanged"` // Same name and type
+}
+
+// columnTypeNames are the user-facing names of inferred column types
+var columnTypeNames = map[state.ColumnType]string{
+	state.ColumnString: "text",
+	state.ColumnFloat:  "number",
+	state.ColumnTime:   "date",
+}
+
+// DiffSchema reports added, removed, renamed and retyped columns between
+// df1 (old) and df2 (new). Renames pair removed with added columns through
+// the enhanced matcher.
+func (s *EnhancedSimilarityService) DiffSchema(df1, df2 *state.DataFrame) (*SchemaDiff, error) {
+	diff := &SchemaDiff{
+		OldRows:     len(df1.Rows),
+		NewRows:     len(df2.Rows),
+		Added:       []SchemaColumn{},
+		Removed:     []SchemaColumn{},
+		Renamed:     []ColumnRename{},
+		TypeChanges: []ColumnTypeChange{},
+	}
+
+	renames, err := s.inferRenames(df1, df2)
+	if err != nil {
+		return nil, err
+	}
+	renamedFrom := make(map[string]bool)
+	renamedTo := make(map[string]bool)
+	for _, r := range renames {
+		renamedFrom[r.From] = true
+		renamedTo[r.To] = true
+	}
+	diff.Renamed = renames
+
+	for i, h := range df1.Headers {
+		if columnIndex(df2, h) < 0 && !renamedFrom[h] {
+			diff.Removed = append(diff.Removed, SchemaColumn{Name: h, Type: columnTypeName(df1, i)})
+		}
+	}
+	for i, h := range df2.Headers {
+		if columnIndex(df1, h) < 0 && !renamedTo[h] {
+			diff.Added = append(diff.Added, SchemaColumn{Name: h, Type: columnTypeName(df2, i)})
+		}
+	}
+
+	for _, p := range diffColumnPairs(df1, df2, renames) {
+		from, to := columnTypeName(df1, p.idx1), columnTypeName(df2, p.idx2)
+		switch {
+		case from != to:
+			change := ColumnTypeChange{Column: df2.Headers[p.idx2], From: from, To: to}
+			if p.renamed {
+				change.Previous = df1.Headers[p.idx1]
+			}
+			diff.TypeChanges = append(diff.TypeChanges, change)
+		case !p.renamed:
+			diff.Unchanged++
+		}
+	}
+	return diff, nil
+}
+
+// inferRenames matches columns only in df1 with columns only in df2
+func (s *EnhancedSimilarityService) inferRenames(df1, df2 *state.DataFrame) ([]ColumnRename, error) {
+	removed := make(map[string]bool)
+	for _, h := range df1.Headers {
+		if columnIndex(df2, h) < 0 {
+			removed[h] = true
+		}
+	}
+	added := make(map[string]bool)
+	for _, h := range df2.Headers {
+		if columnIndex(df1, h) < 0 {
+			added[h] = true
+		}
+	}
+	renames := []ColumnRename{}
+	if len(removed) == 0 || len(added) == 0 {
+		return renames, nil
+	}
+
+	candidates := []AssignmentCandidate{}
+	reasons := make(map[[2]string]string)
+	results, err := s.CalculateEnhancedSimilarity(df1, df2, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range results {
+		if removed[r.File1Column] && added[r.File2Column] && r.Confidence >= renameMinConfidence {
+			candidates = append(candidates, AssignmentCandidate{File1Column: r.File1Column, File2Column: r.File2Column, Confidence: r.Confidence})
+			reasons[[2]string{r.File1Column, r.File2Column}] = r.Reason
+		}
+	}
+	for _, i := range SelectAssignment(candidates, AssignmentOneToOne) {
+		c := candidates[i]
+		renames = append(renames, ColumnRename{
+			From:       c.File1Column,
+			To:         c.File2Column,
+			Confidence: c.Confidence,
+			Reason:     reasons[[2]string{c.File1Column, c.File2Column}],
+		})
+	}
+	sort.Slice(renames, func(i, j int) bool { return renames[i].Confidence > renames[j].Confidence })
+	return renames, nil
+}
+
+// diffColumnPair is a column present in both versions
+type diffColumnPair struct {
+	idx1, idx2 int
+	renamed    bool
+}
+
+// diffColumnPairs lists the columns of both versions, by equal name or rename,
+// in df2 order
+func diffColumnPairs(df1, df2 *state.DataFrame, renames []ColumnRename) []diffColumnPair {
+	previous := make(map[string]string)
+	for _, r := range renames {
+		previous[r.To] = r.From
+	}
+	pairs := []diffColumnPair{}
+	for i2, h := range df2.Headers {
+		if i1 := columnIndex(df1, h); i1 >= 0 {
+			pairs = append(pairs, diffColumnPair{idx1: i1, idx2: i2})
+		} else if from, ok := previous[h]; ok {
+			pairs = append(pairs, diffColumnPair{idx1: columnIndex(df1, from), idx2: i2, renamed: true})
+		}
+	}
+	return pairs
+}
+
+func columnTypeName(df *state.DataFrame, colIdx int) string {
+	if col := df.Column(colIdx); col != nil {
+		return columnTypeNames[col.Type]
+	}
+	return ""
+}
+
+// NumericShift compares summary statistics of a numeric or date column
+type NumericShift struct {
+	MeanBefore float64 `json:"mean_before"`
+	MeanAfter  float64 `json:"mean_after"`
+	StdBefore  float64 `json:"std_before"`
+	StdAfter   float64 `json:"std_after"`
+	MinBefore  float64 `json:"min_before"`
+	MinAfter   float64 `json:"min_after"`
+	MaxBefore  float64 `json:"max_before"`
+	MaxAfter   float64 `json:"max_after"`
+}
+
+// DateShift compares the range of a date column
+type DateShift struct {
+	MinBefore string `json:"min_before"`
+	MaxBefore string `json:"max_before"`
+	MinAfter  string `json:"min_after"`
+	MaxAfter  string `json:"max_after"`
+}
+
+// CategoryShift is the change in share of one value
+type CategoryShift struct {
+	Value  string  `json:"value"`
+	Before float64 `json:"before"`
+	After  float64 `json:"after"`
+}
+
+// ColumnDrift describes how the distribution of one column shifted
+type ColumnDrift struct {
+	Column         string          `json:"column"`             // Name in the new version
+	Previous       string          `json:"previous,omitempty"` // Name in the old version, when renamed
+	Type           string          `json:"type"`
+	PSI            float64         `json:"psi"`
+	Drift          string          `json:"drift"`
+	NullRateBefore float64         `json:"null_rate_before"`
+	NullRateAfter  float64         `json:"null_rate_after"`
+	Numeric        *NumericShift   `json:"numeric,omitempty"`
+	Dates          *DateShift      `json:"dates,omitempty"`
+	Categories     []CategoryShift `json:"categories,omitempty"` // Largest changes in share
+}
+
+// DiffDistributions reports the distribution shift of every column present
+// in both versions, largest PSI first. Columns that changed type are
+// compared as text.
+func (s *EnhancedSimilarityService) DiffDistributions(df1, df2 *state.DataFrame) ([]ColumnDrift, error) {
+	renames, err := s.inferRenames(df1, df2)
+	if err != nil {
+		return nil, err
+	}
+	drifts := []ColumnDrift{}
+	for _, p := range diffColumnPairs(df1, df2, renames) {
+		c1, c2 := df1.Column(p.idx1), df2.Column(p.idx2)
+		if c1 == nil || c2 == nil {
+			continue
+		}
+		d := ColumnDrift{
+			Column:         df2.Headers[p.idx2],
+			Type:           columnTypeNames[c2.Type],
+			NullRateBefore: nullRate(c1),
+			NullRateAfter:  nullRate(c2),
+		}
+		if p.renamed {
+			d.Previous = df1.Headers[p.idx1]
+		}
+
+		switch {
+		case c1.Type == state.ColumnFloat && c2.Type == state.ColumnFloat:
+			before, after := c1.FloatValues(), c2.FloatValues()
+			d.PSI = quantilePSI(before, after)
+			d.Numeric = numericShift(before, after)
+		case c1.Type == state.ColumnTime && c2.Type == state.ColumnTime:
+			before, after := timeValues(c1), timeValues(c2)
+			d.PSI = quantilePSI(before, after)
+			d.Dates = &DateShift{
+				MinBefore: unixDate(minOf(before)), MaxBefore: unixDate(maxOf(before)),
+				MinAfter: unixDate(minOf(after)), MaxAfter: unixDate(maxOf(after)),
+			}
+		default:
+			if c1.Type != c2.Type {
+				d.Type = columnTypeNames[state.ColumnString]
+			}
+			d.PSI, d.Categories = categoryPSI(c1, c2)
+		}
+
+		d.Drift = DriftStable
+		if d.PSI >= 0.25 {
+			d.Drift = DriftMajor
+		} else if d.PSI >= 0.1 {
+			d.Drift = DriftModerate
+		}
+		drifts = append(drifts, d)
+	}
+	sort.SliceStable(drifts, func(i, j int) bool { return drifts[i].PSI > drifts[j].PSI })
+	return drifts, nil
+}
+
+func nullRate(c *state.Column) float64 {
+	if c.Len() == 0 {
+		return 0
+	}
+	nulls := 0
+	for i := 0; i < c.Len(); i++ {
+		if c.IsNull(i) {
+			nulls++
+		}
+	}
+	return float64(nulls) / float64(c.Len())
+}
+
+// quantilePSI bins both samples by the quantiles of the old one
+func quantilePSI(before, after []float64) float64 {
+	if len(before) == 0 || len(after) == 0 {
+		return 0
+	}
+	sorted := append([]float64{}, before...)
+	sort.Float64s(sorted)
+	edges := []float64{}
+	for b := 1; b < psiBins; b++ {
+		edge := sorted[b*len(sorted)/psiBins]
+		if len(edges) == 0 || edge > edges[len(edges)-1] {
+			edges = append(edges, edge)
+		}
+	}
+
+	bin := func(v float64) int { return sort.SearchFloat64s(edges, v) }
+	counts1 := make([]float64, len(edges)+1)
+	counts2 := make([]float64, len(edges)+1)
+	for _, v := range before {
+		counts1[bin(v)]++
+	}
+	for _, v := range after {
+		counts2[bin(v)]++
+	}
+	return psi(counts1, counts2)
+}
+
+// categoryPSI bins the most frequent values of both versions separately and
+// the rest together; it also returns the values whose share moved most
+func categoryPSI(c1, c2 *state.Column) (float64, []CategoryShift) {
+	freq1, n1 := valueCounts(c1)
+	freq2, n2 := valueCounts(c2)
+	if n1 == 0 || n2 == 0 {
+		return 0, nil
+	}
+
+	combined := make(map[string]int)
+	for v, n := range freq1 {
+		combined[v] += n
+	}
+	for v, n := range freq2 {
+		combined[v] += n
+	}
+	values := make([]string, 0, len(combined))
+	for v := range combined {
+		values = append(values, v)
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if combined[values[i]] != combined[values[j]] {
+			return combined[values[i]] > combined[values[j]]
+		}
+		return values[i] < values[j]
+	})
+	if len(values) > psiCategories {
+		values = values[:psiCategories]
+	}
+
+	counts1 := make([]float64, len(values)+1)
+	counts2 := make([]float64, len(values)+1)
+	shifts := make([]CategoryShift, len(values))
+	top1, top2 := 0, 0
+	for i, v := range values {
+		counts1[i], counts2[i] = float64(freq1[v]), float64(freq2[v])
+		top1 += freq1[v]
+		top2 += freq2[v]
+		shifts[i] = CategoryShift{Value: v, Before: float64(freq1[v]) / float64(n1), After: float64(freq2[v]) / float64(n2)}
+	}
+	counts1[len(values)] = float64(n1 - top1)
+	counts2[len(values)] = float64(n2 - top2)
+
+	sort.SliceStable(shifts, func(i, j int) bool {
+		return math.Abs(shifts[i].After-shifts[i].Before) > math.Abs(shifts[j].After-shifts[j].Before)
+	})
+	moved := []CategoryShift{}
+	for _, c := range shifts {
+		if c.Before != c.After && len(moved) < driftTopValues {
+			moved = append(moved, c)
+		}
+	}
+	return psi(counts1, counts2), moved
+}
+
+// valueCounts counts the non-empty values of a column
+func valueCounts(c *state.Column) (map[string]int, int) {
+	counts := make(map[string]int)
+	total := 0
+	for i := 0; i < c.Len(); i++ {
+		if c.Present.Has(i) {
+			counts[c.Value(i)]++
+			total++
+		}
+	}
+	return counts, total
+}
+
+// psi is the population stability index of two binned counts
+func psi(counts1, counts2 []float64) float64 {
+	total1, total2 := 0.0, 0.0
+	for i := range counts1 {
+		total1 += counts1[i]
+		total2 += counts2[i]
+	}
+	if total1 == 0 || total2 == 0 {
+		return 0
+	}
+	sum := 0.0
+	for i := range counts1 {
+		e := math.Max(counts1[i]/total1, psiEpsilon)
+		a := math.Max(counts2[i]/total2, psiEpsilon)
+		sum += (a - e) * math.Log(a/e)
+	}
+	return sum
+}
+
+func numericShift(before, after []float64) *NumericShift {
+	shift := &NumericShift{}
+	shift.MeanBefore, shift.StdBefore = meanAndStd(before)
+	shift.MeanAfter, shift.StdAfter = meanAndStd(after)
+	shift.MinBefore, shift.MaxBefore = minMax(before)
+	shift.MinAfter, shift.MaxAfter = minMax(after)
+	return shift
+}
+
+// timeValues returns the parsed times of a column as Unix seconds
+func timeValues(c *state.Column) []float64 {
+	values := []float64{}
+	for i := 0; i < c.Len(); i++ {
+		if t, ok := c.TimeAt(i); ok {
+			values = append(values, float64(t.Unix()))
+		}
+	}
+	return values
+}
+
+func minOf(values []float64) float64 {
+	lo, _ := minMax(values)
+	return lo
+}
+
+func maxOf(values []float64) float64 {
+	_, hi := minMax(values)
+	return hi
+}
+
+// unixDate formats Unix seconds as an ISO date
+func unixDate(sec float64) string {
+	return time.Unix(int64(sec), 0).UTC().Format("2006-01-02")
+}
@@ -1,5 +1,7 @@
 package service
 
+import "sort"
+
 // SchemaGraph represents the correlation graph
 type SchemaGraph struct {
 	Nodes []GraphNode
@@ -22,6 +24,16 @@ type GraphEdge struct {
 	Weight float64 `json:"weight"` // Confidence score
 }
 
+// HasNode reports whether the graph has a node with the ID
+func (g *SchemaGraph) HasNode(id string) bool {
+	for _, node := range g.Nodes {
+		if node.ID == id {
+			return true
+		}
+	}
+	return false
+}
+
 // GraphAnalyzer provides graph-based correlation analysis
 type GraphAnalyzer struct{}
 
@@ -30,6 +42,9 @@ func NewGraphAnalyzer() *GraphAnalyzer {
 	return &GraphAnalyzer{}
 }
 
+// schemaGraphMinConfidence is the confidence a match needs to become an edge
+const schemaGraphMinConfidence = 30
+
 // BuildSchemaGraph creates a graph from correlation results
 func (ga *GraphAnalyzer) BuildSchemaGraph(correlations []SimilarityResult, file1Cols, file2Cols []string) *SchemaGraph {
 	graph := &SchemaGraph{
@@ -55,7 +70,7 @@ func (ga *GraphAnalyzer) BuildSchemaGraph(correlations []SimilarityResult, file1
 
 	// Create edges from correlations
 	for _, corr := range correlations {
-		if corr.Confidence > 30 { // Threshold
+		if corr.Confidence > schemaGraphMinConfidence {
 			graph.Edges = append(graph.Edges, GraphEdge{
 				Source: "f1_" + corr.File1Column,
 				Target: "f2_" + corr.File2Column,
@@ -67,6 +82,9 @@ func (ga *GraphAnalyzer) BuildSchemaGraph(correlations []SimilarityResult, file1
 	return graph
 }
 
+// communityMaxPasses caps the passes of CommunityDetection
+const communityMaxPasses = 50
+
 // CommunityDetection finds groups of related columns using Louvain algorithm
 func (ga *GraphAnalyzer) CommunityDetection(graph *SchemaGraph) {
 	// Simplified Louvain: assign communities based on edge weights
@@ -80,9 +98,10 @@ func (ga *GraphAnalyzer) CommunityDetection(graph *SchemaGraph) {
 		graph.Nodes[i].Community = i
 	}
 
-	// Iteratively merge communities
+	// Iteratively merge communities; the pass cap stops nodes that keep
+	// trading places
 	improved := true
-	for improved {
+	for pass := 0; improved && pass < communityMaxPasses; pass++ {
 		improved = false
 
 		for i := range graph.Nodes {
@@ -93,7 +112,7 @@ func (ga *GraphAnalyzer) CommunityDetection(graph *SchemaGraph) {
 			neighbors := ga.getNeighborCommunities(graph, i)
 			for community, weight := range neighbors {
 				gain := weight
-				if gain > bestGain {
+				if gain > bestGain || (gain == bestGain && gain > 0 && community < bestCommunity) {
 					bestGain = gain
 					bestCommunity = community
 				}
@@ -162,9 +181,12 @@ func (ga *GraphAnalyzer) CalculateCentrality(graph *SchemaGraph) {
 		srcIdx := ga.findNodeIndex(graph, edge.Source)
 		tgtIdx := ga.findNodeIndex(graph, edge.Target)
 
+		// Matches have no direction, so rank flows both ways
 		if srcIdx >= 0 && tgtIdx >= 0 {
 			outgoing[srcIdx] = append(outgoing[srcIdx], tgtIdx)
+			outgoing[tgtIdx] = append(outgoing[tgtIdx], srcIdx)
 			weights[[2]int{srcIdx, tgtIdx}] = edge.Weight
+			weights[[2]int{tgtIdx, srcIdx}] = edge.Weight
 		}
 	}
 
@@ -200,6 +222,10 @@ func (ga *GraphAnalyzer) CalculateCentrality(graph *SchemaGraph) {
 	}
 }
 
+// maxTransitivePaths caps the paths FindTransitivePaths lists, which grow
+// exponentially with the depth on dense graphs
+const maxTransitivePaths = 100
+
 // FindTransitivePaths finds indirect relationships through intermediate columns
 func (ga *GraphAnalyzer) FindTransitivePaths(graph *SchemaGraph, source, target string, maxDepth int) [][]string {
 	paths := [][]string{}
@@ -213,6 +239,9 @@ func (ga *GraphAnalyzer) FindTransitivePaths(graph *SchemaGraph, source, target
 
 // dfsPath performs depth-first search for paths
 func (ga *GraphAnalyzer) dfsPath(graph *SchemaGraph, current, target string, depth int, path []string, visited map[string]bool, paths *[][]string) {
+	if len(*paths) >= maxTransitivePaths {
+		return
+	}
 	if current == target {
 		// Found a path
 		pathCopy := make([]string, len(path))
@@ -246,3 +275,197 @@ func (ga *GraphAnalyzer) dfsPath(graph *SchemaGraph, current, target string, dep
 
 	visited[current] = false
 }
+
+// GraphCommunity is a group of columns more related to each other than to
+// the rest
+type GraphCommunity struct {
+	ID      int      `json:"id"`
+	Columns []string `json:"columns"` // Node IDs
+	File1   int      `json:"file1_columns"`
+	File2   int      `json:"file2_columns"`
+}
+
+// IndirectRelationship links two columns that only relate through others
+type IndirectRelationship struct {
+	Source   string   `json:"source"`
+	Target   string   `json:"target"`
+	Path     []string `json:"path"`     // Node IDs from source to target
+	Strength float64  `json:"strength"` // Product of the edge weights of the path, 0-1
+}
+
+// GraphAnalysis is the similarity graph with each column's community and
+// centrality, and its notable indirect relationships
+type GraphAnalysis struct {
+	Nodes         []GraphNode            `json:"nodes"`
+	Edges         []GraphEdge            `json:"edges"`
+	Communities   []GraphCommunity       `json:"communities"` // With two or more columns, largest first
+	Indirect      []IndirectRelationship `json:"indirect_relationships"`
+	Paths         [][]string             `json:"paths,omitempty"` // Between the requested source and target
+	MaxDepth      int                    `json:"max_depth"`
+	MinConfidence float64                `json:"min_confidence"` // Edges are matches above it, 0-100
+}
+
+// indirectMinStrength is the weakest indirect relationship reported
+const indirectMinStrength = 0.2
+
+// Analyze runs community detection, centrality and transitive path finding
+// on the graph. Column pairs with no direct edge are reported when a path of
+// at most maxDepth edges joins them; the strongest limit pairs are kept.
+func (ga *GraphAnalyzer) Analyze(graph *SchemaGraph, maxDepth, limit int) *GraphAnalysis {
+	communities := ga.Enrich(graph)
+
+	return &GraphAnalysis{
+		Nodes:         graph.Nodes,
+		Edges:         graph.Edges,
+		Communities:   communities,
+		Indirect:      ga.indirectRelationships(graph, maxDepth, limit),
+		MaxDepth:      maxDepth,
+		MinConfidence: schemaGraphMinConfidence,
+	}
+}
+
+// Enrich sets the community and centrality of every node and returns the
+// communities of two or more columns, largest first
+func (ga *GraphAnalyzer) Enrich(graph *SchemaGraph) []GraphCommunity {
+	ga.CommunityDetection(graph)
+	ga.CalculateCentrality(graph)
+
+	// Communities are renumbered by size so their IDs are stable
+	members := map[int][]int{}
+	for i, node := range graph.Nodes {
+		members[node.Community] = append(members[node.Community], i)
+	}
+	groups := make([][]int, 0, len(members))
+	for _, idx := range members {
+		groups = append(groups, idx)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0] < groups[j][0]
+	})
+	communities := []GraphCommunity{}
+	for id, idx := range groups {
+		community := GraphCommunity{ID: id, Columns: []string{}}
+		for _, i := range idx {
+			graph.Nodes[i].Community = id
+			community.Columns = append(community.Columns, graph.Nodes[i].ID)
+			if graph.Nodes[i].File == "file1" {
+				community.File1++
+			} else {
+				community.File2++
+			}
+		}
+		if len(idx) > 1 {
+			communities = append(communities, community)
+		}
+	}
+	return communities
+}
+
+// indirectRelationships finds the column pairs joined by a path but not by
+// an edge, strongest first. The strongest path of each pair comes from a
+// hop-bounded max-product search from every column, which stays fast on
+// dense graphs where listing every path does not.
+func (ga *GraphAnalyzer) indirectRelationships(graph *SchemaGraph, maxDepth, limit int) []IndirectRelationship {
+	weights := map[[2]string]float64{}
+	adjacent := map[string][]string{}
+	for _, e := range graph.Edges {
+		weights[[2]string{e.Source, e.Target}] = e.Weight
+		weights[[2]string{e.Target, e.Source}] = e.Weight
+		adjacent[e.Source] = append(adjacent[e.Source], e.Target)
+		adjacent[e.Target] = append(adjacent[e.Target], e.Source)
+	}
+	order := map[string]int{}
+	for i, node := range graph.Nodes {
+		order[node.ID] = i
+	}
+
+	found := []IndirectRelationship{}
+	for _, source := range graph.Nodes {
+		for target, path := range strongestPaths(source.ID, adjacent, weights, maxDepth) {
+			// Each pair once, and only pairs without a direct edge
+			if order[target] <= order[source.ID] {
+				continue
+			}
+			if _, direct := weights[[2]string{source.ID, target}]; direct {
+				continue
+			}
+			strength := pathStrength(path, weights)
+			if strength >= indirectMinStrength {
+				found = append(found, IndirectRelationship{Source: source.ID, Target: target, Path: path, Strength: strength})
+			}
+		}
+	}
+
+	sort.SliceStable(found, func(i, j int) bool {
+		if found[i].Strength != found[j].Strength {
+			return found[i].Strength > found[j].Strength
+		}
+		if found[i].Source != found[j].Source {
+			return order[found[i].Source] < order[found[j].Source]
+		}
+		return order[found[i].Target] < order[found[j].Target]
+	})
+	if limit > 0 && len(found) > limit {
+		found = found[:limit]
+	}
+	return found
+}
+
+// pathHop is the strongest way found to reach a node
+type pathHop struct {
+	strength float64
+	prev     string // Node before it on the path
+	depth    int    // Edges from the source
+}
+
+// strongestPaths returns, for every node within maxDepth edges of source,
+// the path of at most maxDepth edges with the highest product of weights.
+// Weights are at most 1, so a path revisiting a node is never stronger
+// than the simple path it contains.
+func strongestPaths(source string, adjacent map[string][]string, weights map[[2]string]float64, maxDepth int) map[string][]string {
+	levels := []map[string]pathHop{{source: {strength: 1}}}
+	best := map[string]pathHop{}
+	for depth := 1; depth <= maxDepth; depth++ {
+		level := map[string]pathHop{}
+		for node, hop := range levels[depth-1] {
+			for _, next := range adjacent[node] {
+				if next == source {
+					continue
+				}
+				strength := hop.strength * weights[[2]string{node, next}]
+				if cur, ok := level[next]; !ok || strength > cur.strength || (strength == cur.strength && node < cur.prev) {
+					level[next] = pathHop{strength: strength, prev: node, depth: depth}
+				}
+			}
+		}
+		for node, hop := range level {
+			if b, ok := best[node]; !ok || hop.strength > b.strength {
+				best[node] = hop
+			}
+		}
+		levels = append(levels, level)
+	}
+
+	paths := make(map[string][]string, len(best))
+	for node, b := range best {
+		path := make([]string, b.depth+1)
+		path[b.depth] = node
+		for depth := b.depth; depth > 0; depth-- {
+			path[depth-1] = levels[depth][path[depth]].prev
+		}
+		paths[node] = path
+	}
+	return paths
+}
+
+// pathStrength is the product of the edge weights along a path
+func pathStrength(path []string, weights map[[2]string]float64) float64 {
+	strength := 1.0
+	for k := 1; k < len(path); k++ {
+		strength *= weights[[2]string{path[k-1], path[k]}]
+	}
+	return strength
+}
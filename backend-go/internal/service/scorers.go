@@ -0,0 +1,280 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"math"
+	"sync"
+)
+
+// Scorer produces one similarity signal (0-1) for a column pair.
+// Scorers may also record diagnostic fields on pc.Result.
+type Scorer interface {
+	Name() string
+	Description() string
+	Score(pc *PairContext) float64
+}
+
+// PairContext carries everything scorers need about a column pair. Per-column
+// work lives in the precomputed profiles; per-pair values are memoized here.
+type PairContext struct {
+	DF1, DF2   *state.DataFrame
+	P1, P2     *ColumnProfile
+	Col1, Col2 string
+	Ctx1, Ctx2 *models.Context
+	Scope      string
+	Result     *SimilarityResult
+
+	NameAlgorithm string // Name similarity measure; "" = DefaultNameAlgorithm
+
+	normalizedDone  bool
+	normalizedMatch float64
+}
+
+// Profiles returns the data quality profiles of both columns
+func (pc *PairContext) Profiles() (DataQualityProfile, DataQualityProfile) {
+	return pc.P1.Quality, pc.P2.Quality
+}
+
+// NormalizedMatch returns the format-normalized value match ratio
+func (pc *PairContext) NormalizedMatch() float64 {
+	if !pc.normalizedDone {
+		pc.normalizedMatch = profileNormalizedMatch(pc.P1, pc.P2)
+		pc.normalizedDone = true
+	}
+	return pc.normalizedMatch
+}
+
+// Numeric reports whether each column is numeric
+func (pc *PairContext) Numeric() (bool, bool) {
+	return pc.P1.IsNumeric, pc.P2.IsNumeric
+}
+
+// ScorerInfo describes a registered scorer and its effective weight
+type ScorerInfo struct {
+	Name        string  `json:"name"`
+	Description string  `json:"description"`
+	Weight      float64 `json:"weight"`                 // Effective weight (fraction of 100 points)
+	AdaptiveKey string  `json:"adaptive_key,omitempty"` // Set when the weight comes from the adaptive learner
+	Enabled     bool    `json:"enabled"`
+}
+
+type registeredScorer struct {
+	scorer      Scorer
+	weight      float64
+	adaptiveKey string
+	enabled     bool
+}
+
+// ScorerRegistry holds the ordered set of scorers used by the similarity pipeline
+type ScorerRegistry struct {
+	scorers []registeredScorer
+	mutex   sync.RWMutex
+}
+
+// NewScorerRegistry creates an empty registry
+func NewScorerRegistry() *ScorerRegistry {
+	return &ScorerRegistry{scorers: []registeredScorer{}}
+}
+
+// Register adds a scorer with a fixed weight
+func (r *ScorerRegistry) Register(s Scorer, weight float64) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.scorers = append(r.scorers, registeredScorer{scorer: s, weight: weight, enabled: true})
+}
+
+// RegisterAdaptive adds a scorer whose weight is taken from the adaptive
+// weights ("name", "data", "pattern" or "llm")
+func (r *ScorerRegistry) RegisterAdaptive(s Scorer, key string) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.scorers = append(r.scorers, registeredScorer{scorer: s, adaptiveKey: key, enabled: true})
+}
+
+// SetEnabled turns a scorer on or off by name
+func (r *ScorerRegistry) SetEnabled(name string, enabled bool) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	for i := range r.scorers {
+		if r.scorers[i].scorer.Name() == name {
+			r.scorers[i].enabled = enabled
+			return nil
+		}
+	}
+	return fmt.Errorf("scorer %q not registered", name)
+}
+
+// Describe lists registered scorers with weights resolved against the given adaptive weights
+func (r *ScorerRegistry) Describe(weights AdaptiveWeights) []ScorerInfo {
+	r.mutex.RLock()
+	defer r.mutex.RUnlock()
+
+	infos := make([]ScorerInfo, 0, len(r.scorers))
+	for _, rs := range r.scorers {
+		infos = append(infos, ScorerInfo{
+			Name:        rs.scorer.Name(),
+			Description: rs.scorer.Description(),
+			Weight:      rs.effectiveWeight(weights),
+			AdaptiveKey: rs.adaptiveKey,
+			Enabled:     rs.enabled,
+		})
+	}
+	return infos
+}
+
+// Run evaluates every enabled scorer and returns the signal vector and the
+// weighted confidence contribution (0-100 scale)
+func (r *ScorerRegistry) Run(pc *PairContext, weights AdaptiveWeights) (map[string]float64, float64) {
+	r.mutex.RLock()
+	scorers := make([]registeredScorer, len(r.scorers))
+	copy(scorers, r.scorers)
+	r.mutex.RUnlock()
+
+	signals := make(map[string]float64, len(scorers))
+	confidence := 0.0
+	for _, rs := range scorers {
+		if !rs.enabled {
+			continue
+		}
+		score := rs.scorer.Score(pc)
+		signals[rs.scorer.Name()] = score
+		confidence += score * rs.effectiveWeight(weights) * 100
+	}
+	return signals, confidence
+}
+
+func (rs registeredScorer) effectiveWeight(weights AdaptiveWeights) float64 {
+	switch rs.adaptiveKey {
+	case "name":
+		return weights.Name
+	case "data":
+		return weights.Data
+	case "pattern":
+		return weights.Pattern
+	case "llm":
+		return weights.LLM
+	}
+	return rs.weight
+}
+
+// defaultScorerRegistry wires up the built-in signals
+func defaultScorerRegistry(svc *EnhancedSimilarityService) *ScorerRegistry {
+	r := NewScorerRegistry()
+	r.RegisterAdaptive(&NameScorer{svc: svc}, "name")
+	r.RegisterAdaptive(&ValueOverlapScorer{}, "data")
+	r.RegisterAdaptive(&PatternScorer{}, "pattern")
+	r.RegisterAdaptive(&LLMScorer{}, "llm")
+	r.Register(&QualityScorer{svc: svc}, 0.10)
+	r.Register(&CardinalityScorer{svc: svc}, 0.15)
+	r.Register(&NormalizedValueScorer{}, 0.10)
+	return r
+}
+
+// ============================================================================
+// Built-in scorers
+// ============================================================================
+
+// NameScorer compares tokenized column names with synonym matching
+type NameScorer struct{ svc *EnhancedSimilarityService }
+
+func (s *NameScorer) Name() string { return "name" }
+func (s *NameScorer) Description() string {
+	return "Tokenized column name similarity with synonyms"
+}
+func (s *NameScorer) Score(pc *PairContext) float64 {
+	tokenSim, isSynonym := s.svc.calculateTokenSimilarity(pc.Col1, pc.Col2, pc.NameAlgorithm)
+	pc.Result.TokenSimilarity = tokenSim
+	pc.Result.SynonymMatch = isSynonym
+	pc.Result.NameSimilarity = tokenSim
+	return tokenSim
+}
+
+// ValueOverlapScorer compares value sets (categorical) or distributions (numeric)
+type ValueOverlapScorer struct{}
+
+func (s *ValueOverlapScorer) Name() string { return "value_overlap" }
+func (s *ValueOverlapScorer) Description() string {
+	return "Value overlap for categorical columns, distribution similarity for numeric ones"
+}
+func (s *ValueOverlapScorer) Score(pc *PairContext) float64 {
+	isNum1, isNum2 := pc.Numeric()
+	if isNum1 && isNum2 {
+		// Numeric: distribution similarity
+		pc.Result.DistributionSimilarity = profileDistributionSimilarity(pc.P1, pc.P2)
+		pc.Result.DataSimilarity = pc.Result.DistributionSimilarity
+	} else if !isNum1 && !isNum2 {
+		// Categorical: use normalized match if better than raw overlap
+		rawOverlap := profileValueOverlap(pc.P1, pc.P2)
+		pc.Result.ValueOverlap = math.Max(rawOverlap, pc.NormalizedMatch())
+		pc.Result.DataSimilarity = pc.Result.ValueOverlap
+	}
+	return pc.Result.DataSimilarity
+}
+
+// PatternScorer checks whether both columns hold the same value format
+type PatternScorer struct{}
+
+func (s *PatternScorer) Name() string { return "pattern" }
+func (s *PatternScorer) Description() string {
+	return "Both columns match the same value pattern (email, phone, date, ...)"
+}
+func (s *PatternScorer) Score(pc *PairContext) float64 {
+	pattern1, pattern2 := pc.P1.Pattern, pc.P2.Pattern
+	patternScore := 0.0
+	if pattern1 != "" && pattern1 == pattern2 {
+		patternScore = 0.9
+		pc.Result.PatternMatch = pattern1
+	}
+	pc.Result.JSONConfidence = patternScore
+	return patternScore
+}
+
+// LLMScorer contributes a semantic score supplied by an LLM pass. The
+// heuristic pipeline doesn't call the LLM itself, so this is 0 unless the
+// result was pre-populated.
+type LLMScorer struct{}
+
+func (s *LLMScorer) Name() string { return "llm" }
+func (s *LLMScorer) Description() string {
+	return "LLM semantic score, when one has been supplied"
+}
+func (s *LLMScorer) Score(pc *PairContext) float64 {
+	return pc.Result.LLMSemanticScore
+}
+
+// QualityScorer compares data quality profiles
+type QualityScorer struct{ svc *EnhancedSimilarityService }
+
+func (s *QualityScorer) Name() string { return "quality" }
+func (s *QualityScorer) Description() string {
+	return "Similarity of null rate, uniqueness and entropy profiles"
+}
+func (s *QualityScorer) Score(pc *PairContext) float64 {
+	profile1, profile2 := pc.Profiles()
+	return s.svc.qualityProfiler.CompareQuality(profile1, profile2)
+}
+
+// CardinalityScorer compares distinct-value ratios
+type CardinalityScorer struct{ svc *EnhancedSimilarityService }
+
+func (s *CardinalityScorer) Name() string { return "cardinality" }
+func (s *CardinalityScorer) Description() string {
+	return "Similarity of cardinality (distinct value ratio)"
+}
+func (s *CardinalityScorer) Score(pc *PairContext) float64 {
+	profile1, profile2 := pc.Profiles()
+	return s.svc.normalizedMatcher.CalculateCardinalityMatch(profile1, profile2)
+}
+
+// NormalizedValueScorer matches values after format normalization
+type NormalizedValueScorer struct{}
+
+func (s *NormalizedValueScorer) Name() string { return "normalized_value" }
+func (s *NormalizedValueScorer) Description() string {
+	return "Value overlap after format normalization"
+}
+func (s *NormalizedValueScorer) Score(pc *PairContext) float64 {
+	return pc.NormalizedMatch()
+}
@@ -0,0 +1,333 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"go.starlark.net/starlark"
+	"go.starlark.net/starlarkstruct"
+	"go.starlark.net/syntax"
+)
+
+var scoringScriptLog = logging.Component("scoring_script")
+
+const scoringScriptFile = "scoring_script.json"
+
+// Limits that keep user scripts cheap to evaluate for every column pair
+const (
+	maxScriptBytes = 64 * 1024
+	maxScriptSteps = 100000 // Per call of score, and for the script's top level
+)
+
+// scriptFileOptions leave out while loops, recursion, sets and top-level
+// control flow, so only the step limit stands between a script and a loop
+// over a large range
+var scriptFileOptions = &syntax.FileOptions{}
+
+// ScoringScript is a compiled Starlark scoring script. It defines
+// score(pair), which is called for every column pair as the last step
+// before calibration and returns None to leave the pair alone, a number to
+// add to its confidence, adjust(delta, reason) to do so with a reason, or
+// veto(reason) to drop the pair:
+//
+//	def score(pair):
+//	    if "excluded_legacy" in pair.file1 or "excluded_legacy" in pair.file2:
+//	        return veto("legacy export")
+//	    if pair.name < 0.2 and pair.value_overlap > 0.8:
+//	        return adjust(-15, "values match, names don't")
+//	    return None
+//
+// pair has every scorer signal by name (name, value_overlap, pattern, llm,
+// quality, cardinality, normalized_value, ...), confidence (0-100, before
+// calibration), col1, col2, file1, file2, scope, pattern_match and synonym.
+// getattr(pair, "llm", 0) reads a signal a scorer may not have produced.
+// Scripts can't load modules or reach the file system or network, and each
+// call stops after maxScriptSteps steps.
+type ScoringScript struct {
+	Source string
+	score  *starlark.Function
+}
+
+// ScriptOutcome is the result of running a script against one pair
+type ScriptOutcome struct {
+	Vetoed     bool
+	Adjustment float64
+	Fired      []string // Reason the script gave, if any
+}
+
+// scriptResult is what veto and adjust return to score's caller
+type scriptResult struct {
+	veto   bool
+	adjust float64
+	reason string
+}
+
+func (r *scriptResult) String() string {
+	if r.veto {
+		return fmt.Sprintf("veto(%q)", r.reason)
+	}
+	return fmt.Sprintf("adjust(%g, %q)", r.adjust, r.reason)
+}
+func (r *scriptResult) Type() string          { return "score_result" }
+func (r *scriptResult) Freeze()               {}
+func (r *scriptResult) Truth() starlark.Bool  { return starlark.True }
+func (r *scriptResult) Hash() (uint32, error) { return 0, fmt.Errorf("unhashable: score_result") }
+
+// scriptBuiltins are the functions scripts get besides Starlark's own
+var scriptBuiltins = starlark.StringDict{
+	"veto": starlark.NewBuiltin("veto", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var reason string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "reason?", &reason); err != nil {
+			return nil, err
+		}
+		return &scriptResult{veto: true, reason: reason}, nil
+	}),
+	"adjust": starlark.NewBuiltin("adjust", func(thread *starlark.Thread, fn *starlark.Builtin, args starlark.Tuple, kwargs []starlark.Tuple) (starlark.Value, error) {
+		var delta starlark.Value
+		var reason string
+		if err := starlark.UnpackArgs(fn.Name(), args, kwargs, "delta", &delta, "reason?", &reason); err != nil {
+			return nil, err
+		}
+		f, ok := starlark.AsFloat(delta)
+		if !ok {
+			return nil, fmt.Errorf("adjust: delta must be a number, not %s", delta.Type())
+		}
+		return &scriptResult{adjust: f, reason: reason}, nil
+	}),
+}
+
+// newScriptThread returns a thread without load or print, stopping after
+// maxScriptSteps
+func newScriptThread(name string) *starlark.Thread {
+	thread := &starlark.Thread{
+		Name:  name,
+		Print: func(*starlark.Thread, string) {},
+	}
+	thread.SetMaxExecutionSteps(maxScriptSteps)
+	return thread
+}
+
+// CompileScoringScript parses and runs the script's top level, returning a
+// descriptive error with the line number on failure
+func CompileScoringScript(source string) (*ScoringScript, error) {
+	if len(source) > maxScriptBytes {
+		return nil, fmt.Errorf("script exceeds %d bytes", maxScriptBytes)
+	}
+
+	globals, err := starlark.ExecFileOptions(scriptFileOptions, newScriptThread("compile"), "script", source, scriptBuiltins)
+	if err != nil {
+		return nil, scriptError(err)
+	}
+	score, ok := globals["score"].(*starlark.Function)
+	if !ok {
+		return nil, fmt.Errorf("script must define a function score(pair)")
+	}
+	if score.NumParams() != 1 {
+		return nil, fmt.Errorf("score must take one parameter, the pair")
+	}
+	// Frozen, the function can be called for many pairs at once
+	globals.Freeze()
+	return &ScoringScript{Source: source, score: score}, nil
+}
+
+// Evaluate calls score for the pair
+func (s *ScoringScript) Evaluate(vars map[string]interface{}) (ScriptOutcome, error) {
+	outcome := ScriptOutcome{}
+	pair, err := scriptPair(vars)
+	if err != nil {
+		return outcome, err
+	}
+	result, err := starlark.Call(newScriptThread("score"), s.score, starlark.Tuple{pair}, nil)
+	if err != nil {
+		return outcome, scriptError(err)
+	}
+
+	switch r := result.(type) {
+	case starlark.NoneType:
+	case *scriptResult:
+		outcome.Vetoed = r.veto
+		outcome.Adjustment = r.adjust
+		if r.reason != "" {
+			outcome.Fired = []string{r.reason}
+		}
+	case starlark.Int, starlark.Float:
+		outcome.Adjustment, _ = starlark.AsFloat(r)
+	default:
+		return outcome, fmt.Errorf("score returned %s; want None, a number, adjust() or veto()", result.Type())
+	}
+	return outcome, nil
+}
+
+// scriptPair converts a pair's variables to the struct score receives
+func scriptPair(vars map[string]interface{}) (starlark.Value, error) {
+	fields := make(starlark.StringDict, len(vars))
+	for name, v := range vars {
+		switch v := v.(type) {
+		case nil:
+			fields[name] = starlark.None
+		case bool:
+			fields[name] = starlark.Bool(v)
+		case float64:
+			fields[name] = starlark.Float(v)
+		case int:
+			fields[name] = starlark.MakeInt(v)
+		case string:
+			fields[name] = starlark.String(v)
+		default:
+			return nil, fmt.Errorf("variable %s: unsupported type %T", name, v)
+		}
+	}
+	return starlarkstruct.FromStringDict(starlarkstruct.Default, fields), nil
+}
+
+// scriptError shortens a Starlark runtime error to its message and the
+// line of the script it was raised at; syntax errors carry their position
+func scriptError(err error) error {
+	evalErr, ok := err.(*starlark.EvalError)
+	if !ok {
+		return err
+	}
+	for i := range evalErr.CallStack {
+		if pos := evalErr.CallStack.At(i).Pos; pos.IsValid() {
+			return fmt.Errorf("%s: %s", pos, evalErr.Msg)
+		}
+	}
+	return fmt.Errorf("%s", evalErr.Msg)
+}
+
+// ScriptVariables builds the variable set a script sees for a column pair
+func ScriptVariables(pc *PairContext, confidence float64) map[string]interface{} {
+	vars := map[string]interface{}{
+		"confidence":    confidence,
+		"col1":          pc.Col1,
+		"col2":          pc.Col2,
+		"file1":         pc.DF1.FileName,
+		"file2":         pc.DF2.FileName,
+		"scope":         pc.Scope,
+		"pattern_match": pc.Result.PatternMatch,
+		"synonym":       pc.Result.SynonymMatch,
+	}
+	for name, v := range pc.Result.Signals {
+		vars[name] = v
+	}
+	return vars
+}
+
+// ============================================================================
+// Script store
+// ============================================================================
+
+// ScoringScriptStore holds the active script
+type ScoringScriptStore struct {
+	script    *ScoringScript
+	updatedAt time.Time
+	mutex     sync.RWMutex
+}
+
+var (
+	scoringScriptStore     *ScoringScriptStore
+	scoringScriptStoreOnce sync.Once
+)
+
+// GetScoringScriptStore returns the singleton script store
+func GetScoringScriptStore() *ScoringScriptStore {
+	scoringScriptStoreOnce.Do(func() {
+		scoringScriptStore = &ScoringScriptStore{}
+		scoringScriptStore.load()
+	})
+	return scoringScriptStore
+}
+
+// load loads the saved script from file
+func (s *ScoringScriptStore) load() {
+	data, err := os.ReadFile(config.DataPath(scoringScriptFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			scoringScriptLog.Error("Error loading script", "error", err)
+		}
+		return
+	}
+
+	var saved struct {
+		Source    string    `json:"source"`
+		UpdatedAt time.Time `json:"updated_at"`
+	}
+	if err := json.Unmarshal(data, &saved); err != nil {
+		scoringScriptLog.Error("Error parsing script file", "error", err)
+		return
+	}
+
+	script, err := CompileScoringScript(saved.Source)
+	if err != nil {
+		scoringScriptLog.Error("Saved script no longer compiles", "error", err)
+		return
+	}
+
+	s.mutex.Lock()
+	s.script = script
+	s.updatedAt = saved.UpdatedAt
+	s.mutex.Unlock()
+
+	scoringScriptLog.Info("Loaded script")
+}
+
+// save persists the script source to file
+func (s *ScoringScriptStore) save() error {
+	s.mutex.RLock()
+	source := ""
+	if s.script != nil {
+		source = s.script.Source
+	}
+	data, err := json.MarshalIndent(map[string]interface{}{
+		"source":     source,
+		"updated_at": s.updatedAt,
+	}, "", "  ")
+	s.mutex.RUnlock()
+
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(scoringScriptFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(scoringScriptFile), data, 0644)
+}
+
+// Current returns the active script, or nil if none is set
+func (s *ScoringScriptStore) Current() (*ScoringScript, time.Time) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+	return s.script, s.updatedAt
+}
+
+// Set compiles and activates a script; an empty source clears it
+func (s *ScoringScriptStore) Set(source string) (*ScoringScript, error) {
+	var script *ScoringScript
+	if strings.TrimSpace(source) != "" {
+		compiled, err := CompileScoringScript(source)
+		if err != nil {
+			return nil, err
+		}
+		script = compiled
+	}
+
+	s.mutex.Lock()
+	s.script = script
+	s.updatedAt = time.Now()
+	s.mutex.Unlock()
+
+	if script == nil {
+		scoringScriptLog.Info("Script cleared")
+	} else {
+		scoringScriptLog.Info("Activated script")
+	}
+	return script, s.save()
+}
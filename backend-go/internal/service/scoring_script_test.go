@@ -0,0 +1,142 @@
+package service
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileScoringScriptRejectsMalformed(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+		want   string
+	}{
+		{"syntax error", "def score(pair):\n    return (", "script:2"},
+		{"unterminated string", "def score(pair):\n    return veto(\"legacy)", "script:2"},
+		{"no score", "x = 1", "must define a function score"},
+		{"score not a function", "score = 5", "must define a function score"},
+		{"too many parameters", "def score(pair, other):\n    return None", "one parameter"},
+		{"undefined name", "def score(pair):\n    return missing", "undefined: missing"},
+		{"while loop", "def score(pair):\n    while True:\n        pass", "while"},
+		{"load", "load(\"os\", \"system\")\ndef score(pair):\n    return None", "load"},
+		{"top-level error", "x = 1 // 0\ndef score(pair):\n    return None", "division by zero"},
+		{"too large", strings.Repeat("#", maxScriptBytes+1), "exceeds"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := CompileScoringScript(tt.source)
+			if err == nil {
+				t.Fatalf("compiled %q", tt.source)
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error %q doesn't mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestScoringScriptEvaluate(t *testing.T) {
+	script, err := CompileScoringScript(`
+def score(pair):
+    if "excluded_legacy" in pair.file1 or "excluded_legacy" in pair.file2:
+        return veto("legacy export")
+    if pair.name < 0.2 and pair.value_overlap > 0.8:
+        return adjust(-15, "values match, names don't")
+    if getattr(pair, "llm", 0) > 0.9:
+        return 5
+    return None
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		vars   map[string]interface{}
+		veto   bool
+		adjust float64
+		fired  int
+	}{
+		{"veto", map[string]interface{}{"file1": "excluded_legacy.csv", "file2": "b.csv", "name": 0.9, "value_overlap": 0.9}, true, 0, 1},
+		{"adjust with reason", map[string]interface{}{"file1": "a.csv", "file2": "b.csv", "name": 0.1, "value_overlap": 0.9}, false, -15, 1},
+		{"number", map[string]interface{}{"file1": "a.csv", "file2": "b.csv", "name": 0.5, "value_overlap": 0.5, "llm": 0.95}, false, 5, 0},
+		{"none", map[string]interface{}{"file1": "a.csv", "file2": "b.csv", "name": 0.5, "value_overlap": 0.5}, false, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			outcome, err := script.Evaluate(tt.vars)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if outcome.Vetoed != tt.veto || outcome.Adjustment != tt.adjust || len(outcome.Fired) != tt.fired {
+				t.Errorf("got %+v, want veto %v adjust %v with %d reason(s)", outcome, tt.veto, tt.adjust, tt.fired)
+			}
+		})
+	}
+
+	// A missing variable is a runtime error, not a silent zero
+	if _, err := script.Evaluate(map[string]interface{}{"file1": "a.csv", "file2": "b.csv"}); err == nil || !strings.Contains(err.Error(), "script:5") {
+		t.Errorf("missing variable: got %v, want an error at line 5", err)
+	}
+}
+
+func TestScoringScriptBadResult(t *testing.T) {
+	script, err := CompileScoringScript("def score(pair):\n    return \"veto\"")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := script.Evaluate(map[string]interface{}{}); err == nil {
+		t.Error("a string result was accepted")
+	}
+}
+
+func TestScoringScriptDeepNesting(t *testing.T) {
+	// As deep as the size limit allows: the parser must fail or succeed
+	// without exhausting the stack
+	depth := maxScriptBytes/2 - 100
+	source := "def score(pair):\n    return " + strings.Repeat("(", depth) + "1" + strings.Repeat(")", depth)
+	if script, err := CompileScoringScript(source); err == nil {
+		if outcome, err := script.Evaluate(map[string]interface{}{}); err == nil && outcome.Adjustment != 1 {
+			t.Errorf("got adjustment %v, want 1", outcome.Adjustment)
+		}
+	}
+
+	lists := "def score(pair):\n    x = " + strings.Repeat("[", 5000) + strings.Repeat("]", 5000) + "\n    return None"
+	if _, err := CompileScoringScript(lists); err != nil && !strings.Contains(err.Error(), "script") {
+		t.Errorf("unexpected error %v", err)
+	}
+}
+
+func TestScoringScriptRunaway(t *testing.T) {
+	tests := []struct {
+		name   string
+		source string
+	}{
+		{"long loop", "def score(pair):\n    n = 0\n    for i in range(1000000000):\n        n += i\n    return n"},
+		{"nested loops", "def score(pair):\n    n = 0\n    for i in range(100000):\n        for j in range(100000):\n            n += 1\n    return n"},
+		{"comprehension", "def score(pair):\n    return len([i for i in range(1000000000)])"},
+		{"recursion", "def score(pair):\n    return score(pair)"},
+		{"huge repeat", "def score(pair):\n    return len(\"x\" * (1 << 31))"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			script, err := CompileScoringScript(tt.source)
+			if err != nil {
+				return // Refused outright
+			}
+			start := time.Now()
+			if _, err := script.Evaluate(map[string]interface{}{}); err == nil {
+				t.Error("runaway script finished")
+			}
+			if elapsed := time.Since(start); elapsed > 2*time.Second {
+				t.Errorf("took %v to stop", elapsed)
+			}
+		})
+	}
+
+	// Top-level code is held to the same limit
+	if _, err := CompileScoringScript("x = [i for i in range(1000000000)]\ndef score(pair):\n    return None"); err == nil {
+		t.Error("runaway top level compiled")
+	}
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"backend-go/internal/models"
+	"backend-go/internal/tracing"
+	"context"
 	"fmt"
 	"strings"
 )
@@ -17,7 +19,10 @@ func NewSimilarityService(ctxService *ContextService) *SimilarityService {
 }
 
 // GenerateGraph creates the similarity graph
-func (s *SimilarityService) GenerateGraph(fileIndex1, fileIndex2 int) (*models.SimilarityGraph, error) {
+func (s *SimilarityService) GenerateGraph(ctx context.Context, fileIndex1, fileIndex2 int) (*models.SimilarityGraph, error) {
+	_, span := tracing.Start(ctx, "similarity.graph")
+	defer span.End()
+
 	analysis1 := s.ContextService.GetAnalysis(fileIndex1)
 	analysis2 := s.ContextService.GetAnalysis(fileIndex2)
 
@@ -76,6 +81,7 @@ func (s *SimilarityService) GenerateGraph(fileIndex1, fileIndex2 int) (*models.S
 	}
 
 	graph.TotalRelationships = len(graph.Similarities)
+	span.SetAttr("relationships", graph.TotalRelationships)
 	return graph, nil
 }
 
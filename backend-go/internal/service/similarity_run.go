@@ -0,0 +1,297 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/state"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var similarityRunLog = logging.Component("similarity_runs")
+
+const similarityRunsFile = "similarity_runs.json"
+
+// Similarity run limits
+const (
+	similarityRunMaxRuns   = 100 // Stored runs, newest kept
+	similarityRunMinChange = 0.5 // Confidence points a pair must move to count as changed
+)
+
+// SimilarityRunMatch is one column pair found by a run
+type SimilarityRunMatch struct {
+	File1Column string  `json:"file1_column"`
+	File2Column string  `json:"file2_column"`
+	Confidence  float64 `json:"confidence"`
+	Type        string  `json:"type"`
+}
+
+// SimilarityRunOptions are the request options a run was made with
+type SimilarityRunOptions struct {
+	UseAI         bool   `json:"use_ai"`
+	Profile       string `json:"profile,omitempty"`
+	Blocking      string `json:"blocking,omitempty"`
+	NameAlgorithm string `json:"name_algorithm,omitempty"`
+	History       bool   `json:"history"`
+}
+
+// SimilarityRun is the stored result of one column similarity run
+type SimilarityRun struct {
+	ID                 string               `json:"id"`
+	CreatedAt          time.Time            `json:"created_at"`
+	File1Name          string               `json:"file1_name"`
+	File2Name          string               `json:"file2_name"`
+	File1Hash          string               `json:"file1_hash"` // ContentHash of the inputs
+	File2Hash          string               `json:"file2_hash"`
+	Options            SimilarityRunOptions `json:"options"`
+	TotalRelationships int                  `json:"total_relationships"`
+	Matches            []SimilarityRunMatch `json:"matches,omitempty"`
+}
+
+// SimilarityRunSummary is a run without its matches, for listings
+type SimilarityRunSummary struct {
+	ID                 string               `json:"id"`
+	CreatedAt          time.Time            `json:"created_at"`
+	File1Name          string               `json:"file1_name"`
+	File2Name          string               `json:"file2_name"`
+	File1Hash          string               `json:"file1_hash"`
+	File2Hash          string               `json:"file2_hash"`
+	Options            SimilarityRunOptions `json:"options"`
+	TotalRelationships int                  `json:"total_relationships"`
+}
+
+// SimilarityRunChange is a pair whose confidence or type differs between
+// two runs
+type SimilarityRunChange struct {
+	File1Column    string  `json:"file1_column"`
+	File2Column    string  `json:"file2_column"`
+	FromConfidence float64 `json:"from_confidence"`
+	ToConfidence   float64 `json:"to_confidence"`
+	Delta          float64 `json:"delta"`
+	FromType       string  `json:"from_type"`
+	ToType         string  `json:"to_type"`
+}
+
+// SimilarityRunDiff compares the matches of two runs
+type SimilarityRunDiff struct {
+	From       SimilarityRunSummary  `json:"from"`
+	To         SimilarityRunSummary  `json:"to"`
+	SameInputs bool                  `json:"same_inputs"` // Both runs read the same file contents
+	Appeared   []SimilarityRunMatch  `json:"appeared"`
+	Vanished   []SimilarityRunMatch  `json:"disappeared"`
+	Changed    []SimilarityRunChange `json:"changed"`
+	Unchanged  int                   `json:"unchanged"`
+}
+
+// SimilarityRunStore keeps recent column similarity runs so results can be
+// revisited and compared after feedback or learning
+type SimilarityRunStore struct {
+	runs  []SimilarityRun // Oldest first
+	mutex sync.RWMutex
+}
+
+var (
+	similarityRunStore     *SimilarityRunStore
+	similarityRunStoreOnce sync.Once
+)
+
+// GetSimilarityRunStore returns the singleton similarity run store
+func GetSimilarityRunStore() *SimilarityRunStore {
+	similarityRunStoreOnce.Do(func() {
+		similarityRunStore = &SimilarityRunStore{runs: []SimilarityRun{}}
+		similarityRunStore.load()
+	})
+	return similarityRunStore
+}
+
+// load loads stored runs from file
+func (s *SimilarityRunStore) load() {
+	data, err := os.ReadFile(config.DataPath(similarityRunsFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			similarityRunLog.Error("Error loading similarity runs", "error", err)
+		}
+		return
+	}
+
+	var saved []SimilarityRun
+	if err := json.Unmarshal(data, &saved); err != nil {
+		similarityRunLog.Error("Error parsing similarity runs", "error", err)
+		return
+	}
+	s.runs = saved
+	similarityRunLog.Info("Loaded similarity runs", "runs", len(saved))
+}
+
+// save persists the runs to file (must hold lock)
+func (s *SimilarityRunStore) save() error {
+	data, err := json.Marshal(s.runs)
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(similarityRunsFile))
+	os.MkdirAll(dir, 0755)
+
+	return os.WriteFile(config.DataPath(similarityRunsFile), data, 0644)
+}
+
+// Record stores a run of the given files, dropping the oldest runs beyond
+// the limit, and returns it with its ID
+func (s *SimilarityRunStore) Record(df1, df2 *state.DataFrame, opts SimilarityRunOptions, matches []SimilarityRunMatch, total int) (SimilarityRun, error) {
+	run := SimilarityRun{
+		ID:                 newSimilarityRunID(),
+		CreatedAt:          time.Now(),
+		File1Name:          df1.FileName,
+		File2Name:          df2.FileName,
+		File1Hash:          df1.ContentHash(),
+		File2Hash:          df2.ContentHash(),
+		Options:            opts,
+		TotalRelationships: total,
+		Matches:            matches,
+	}
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.runs = append(s.runs, run)
+	if len(s.runs) > similarityRunMaxRuns {
+		s.runs = s.runs[len(s.runs)-similarityRunMaxRuns:]
+	}
+	return run, s.save()
+}
+
+// List returns the runs without their matches, newest first
+func (s *SimilarityRunStore) List() []SimilarityRunSummary {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	summaries := make([]SimilarityRunSummary, 0, len(s.runs))
+	for i := len(s.runs) - 1; i >= 0; i-- {
+		summaries = append(summaries, s.runs[i].Summary())
+	}
+	return summaries
+}
+
+// Get returns a run by ID
+func (s *SimilarityRunStore) Get(id string) (SimilarityRun, bool) {
+	s.mutex.RLock()
+	defer s.mutex.RUnlock()
+
+	for _, run := range s.runs {
+		if run.ID == id {
+			return run, true
+		}
+	}
+	return SimilarityRun{}, false
+}
+
+// Delete removes a run
+func (s *SimilarityRunStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for i, run := range s.runs {
+		if run.ID == id {
+			s.runs = append(s.runs[:i], s.runs[i+1:]...)
+			return s.save()
+		}
+	}
+	return fmt.Errorf("similarity run %q not found", id)
+}
+
+// Diff compares two stored runs
+func (s *SimilarityRunStore) Diff(fromID, toID string) (*SimilarityRunDiff, error) {
+	from, ok := s.Get(fromID)
+	if !ok {
+		return nil, fmt.Errorf("similarity run %q not found", fromID)
+	}
+	to, ok := s.Get(toID)
+	if !ok {
+		return nil, fmt.Errorf("similarity run %q not found", toID)
+	}
+	return DiffSimilarityRuns(from, to), nil
+}
+
+// Summary returns the run without its matches
+func (run SimilarityRun) Summary() SimilarityRunSummary {
+	return SimilarityRunSummary{
+		ID:                 run.ID,
+		CreatedAt:          run.CreatedAt,
+		File1Name:          run.File1Name,
+		File2Name:          run.File2Name,
+		File1Hash:          run.File1Hash,
+		File2Hash:          run.File2Hash,
+		Options:            run.Options,
+		TotalRelationships: run.TotalRelationships,
+	}
+}
+
+// DiffSimilarityRuns lists the pairs that appeared in or disappeared from
+// the later run, and those whose confidence moved by at least half a point
+// or whose match type changed. Changes are sorted by the size of the move.
+func DiffSimilarityRuns(from, to SimilarityRun) *SimilarityRunDiff {
+	diff := &SimilarityRunDiff{
+		From:       from.Summary(),
+		To:         to.Summary(),
+		SameInputs: from.File1Hash == to.File1Hash && from.File2Hash == to.File2Hash,
+		Appeared:   []SimilarityRunMatch{},
+		Vanished:   []SimilarityRunMatch{},
+		Changed:    []SimilarityRunChange{},
+	}
+
+	before := make(map[[2]string]SimilarityRunMatch, len(from.Matches))
+	for _, m := range from.Matches {
+		before[[2]string{m.File1Column, m.File2Column}] = m
+	}
+	seen := make(map[[2]string]bool, len(to.Matches))
+	for _, m := range to.Matches {
+		key := [2]string{m.File1Column, m.File2Column}
+		seen[key] = true
+		old, ok := before[key]
+		if !ok {
+			diff.Appeared = append(diff.Appeared, m)
+			continue
+		}
+		delta := m.Confidence - old.Confidence
+		if math.Abs(delta) < similarityRunMinChange && m.Type == old.Type {
+			diff.Unchanged++
+			continue
+		}
+		diff.Changed = append(diff.Changed, SimilarityRunChange{
+			File1Column:    m.File1Column,
+			File2Column:    m.File2Column,
+			FromConfidence: old.Confidence,
+			ToConfidence:   m.Confidence,
+			Delta:          delta,
+			FromType:       old.Type,
+			ToType:         m.Type,
+		})
+	}
+	for _, m := range from.Matches {
+		if !seen[[2]string{m.File1Column, m.File2Column}] {
+			diff.Vanished = append(diff.Vanished, m)
+		}
+	}
+
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return math.Abs(diff.Changed[i].Delta) > math.Abs(diff.Changed[j].Delta)
+	})
+	return diff
+}
+
+func newSimilarityRunID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "run_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "run_" + hex.EncodeToString(b)
+}
@@ -0,0 +1,147 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// SQL dialects supported by GenerateSQL
+const (
+	SQLDialectPostgres  = "postgres"
+	SQLDialectMySQL     = "mysql"
+	SQLDialectSnowflake = "snowflake"
+	SQLDialectBigQuery  = "bigquery"
+	SQLDialectTSQL      = "tsql"
+)
+
+// SQL output modes
+const (
+	SQLModeSelect = "select"
+	SQLModeView   = "view"
+)
+
+// DefaultSQLDialect is used when no dialect is requested
+const DefaultSQLDialect = SQLDialectPostgres
+
+// sqlDialect holds the syntax differences between dialects
+type sqlDialect struct {
+	quoteOpen, quoteClose string
+	textType              string // Type used to cast mismatched join columns
+	supportsUsing         bool   // JOIN ... USING (col) when names are equal
+	createView            string // Statement prefix for view mode
+	quoteWholePath        bool   // Quote a.b.c as one identifier (BigQuery)
+}
+
+var sqlDialects = map[string]sqlDialect{
+	SQLDialectPostgres:  {`"`, `"`, "TEXT", true, "CREATE OR REPLACE VIEW", false},
+	SQLDialectMySQL:     {"`", "`", "CHAR", true, "CREATE OR REPLACE VIEW", false},
+	SQLDialectSnowflake: {`"`, `"`, "VARCHAR", true, "CREATE OR REPLACE VIEW", false},
+	SQLDialectBigQuery:  {"`", "`", "STRING", true, "CREATE OR REPLACE VIEW", true},
+	SQLDialectTSQL:      {"[", "]", "NVARCHAR(MAX)", false, "CREATE OR ALTER VIEW", false},
+}
+
+// SQLDialects lists the supported dialect names
+func SQLDialects() []string {
+	return []string{SQLDialectPostgres, SQLDialectMySQL, SQLDialectSnowflake, SQLDialectBigQuery, SQLDialectTSQL}
+}
+
+var tableNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_$-]*(\.[A-Za-z_][A-Za-z0-9_$-]*){0,2}$`)
+
+// SQLOptions control GenerateSQL output
+type SQLOptions struct {
+	Dialect  string // One of SQLDialects(); "" = DefaultSQLDialect
+	Mode     string // SQLModeSelect (default) or SQLModeView
+	ViewName string // View name in view mode
+	Table1   string // Source table of file 1, optionally qualified (db.schema.table)
+	Table2   string // Source table of file 2
+
+	// Declared relationship answers: the type picks the join, the keys are
+	// joined on when the approved mapping names none
+	Relationship string
+	JoinKeys     []models.JoinKeyPair
+
+	// Loaded files, used for explicit select lists and type casts (may be nil)
+	File1 *state.DataFrame
+	File2 *state.DataFrame
+}
+
+// Validate fills defaults and checks names
+func (o *SQLOptions) Validate() error {
+	if o.Dialect == "" {
+		o.Dialect = DefaultSQLDialect
+	}
+	if _, ok := sqlDialects[o.Dialect]; !ok {
+		return fmt.Errorf("unknown dialect %q (use %s)", o.Dialect, strings.Join(SQLDialects(), ", "))
+	}
+
+	switch o.Mode {
+	case "":
+		o.Mode = SQLModeSelect
+	case SQLModeSelect, SQLModeView:
+	default:
+		return fmt.Errorf("unknown mode %q (use select or view)", o.Mode)
+	}
+
+	if o.Table1 == "" {
+		o.Table1 = "table1"
+	}
+	if o.Table2 == "" {
+		o.Table2 = "table2"
+	}
+	if o.Mode == SQLModeView && o.ViewName == "" {
+		o.ViewName = "joined_view"
+	}
+	for _, name := range []string{o.Table1, o.Table2, o.ViewName} {
+		if name != "" && !tableNamePattern.MatchString(name) {
+			return fmt.Errorf("invalid table name %q", name)
+		}
+	}
+	return nil
+}
+
+// quoteIdent quotes a column name, escaping the closing quote character
+func (d sqlDialect) quoteIdent(name string) string {
+	return d.quoteOpen + strings.ReplaceAll(name, d.quoteClose, d.quoteClose+d.quoteClose) + d.quoteClose
+}
+
+// quoteTable quotes a possibly qualified table name
+func (d sqlDialect) quoteTable(name string) string {
+	if d.quoteWholePath {
+		return d.quoteIdent(name)
+	}
+	parts := strings.Split(name, ".")
+	for i, p := range parts {
+		parts[i] = d.quoteIdent(p)
+	}
+	return strings.Join(parts, ".")
+}
+
+// createTableAs returns statements that (re)create target from a SELECT over source
+func (d sqlDialect) createTableAs(dialect, target, source string) string {
+	t := d.quoteTable(target)
+	src := d.quoteTable(source)
+	switch dialect {
+	case SQLDialectSnowflake, SQLDialectBigQuery:
+		return fmt.Sprintf("CREATE OR REPLACE TABLE %s AS SELECT * FROM %s;", t, src)
+	case SQLDialectTSQL:
+		return fmt.Sprintf("DROP TABLE IF EXISTS %s;\nSELECT * INTO %s FROM %s;", t, t, src)
+	}
+	return fmt.Sprintf("DROP TABLE IF EXISTS %s;\nCREATE TABLE %s AS SELECT * FROM %s;", t, t, src)
+}
+
+// columnTypes returns the inferred type of each column of a loaded file
+func columnTypes(df *state.DataFrame) map[string]state.ColumnType {
+	types := make(map[string]state.ColumnType)
+	if df == nil {
+		return types
+	}
+	for i, h := range df.Headers {
+		if col := df.Column(i); col != nil {
+			types[h] = col.Type
+		}
+	}
+	return types
+}
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+)
+
+// Name similarity algorithms selectable for the name component
+const (
+	NameAlgoLevenshtein = "levenshtein"
+	NameAlgoJaroWinkler = "jaro_winkler"
+	NameAlgoTrigram     = "trigram"
+	NameAlgoLCS         = "lcs"
+	NameAlgoEnsemble    = "ensemble"
+)
+
+// DefaultNameAlgorithm keeps the historical Levenshtein behaviour
+const DefaultNameAlgorithm = NameAlgoLevenshtein
+
+// ensembleNameWeights are the voting weights used by NameAlgoEnsemble
+var ensembleNameWeights = map[string]float64{
+	NameAlgoLevenshtein: 0.20,
+	NameAlgoJaroWinkler: 0.35,
+	NameAlgoTrigram:     0.25,
+	NameAlgoLCS:         0.20,
+}
+
+// NameAlgorithmInfo describes a selectable name similarity algorithm
+type NameAlgorithmInfo struct {
+	Name        string             `json:"name"`
+	Description string             `json:"description"`
+	Weights     map[string]float64 `json:"weights,omitempty"` // Ensemble voting weights
+	Default     bool               `json:"default"`
+}
+
+// NameAlgorithms lists the available name similarity algorithms
+func NameAlgorithms() []NameAlgorithmInfo {
+	return []NameAlgorithmInfo{
+		{Name: NameAlgoLevenshtein, Description: "Edit distance ratio", Default: DefaultNameAlgorithm == NameAlgoLevenshtein},
+		{Name: NameAlgoJaroWinkler, Description: "Jaro-Winkler, favours shared prefixes (cust_id vs customer_id)"},
+		{Name: NameAlgoTrigram, Description: "Cosine similarity of character trigram counts"},
+		{Name: NameAlgoLCS, Description: "Longest common substring relative to both names' lengths"},
+		{Name: NameAlgoEnsemble, Description: "Weighted vote of all measures via ProbabilisticMatcher.EnsembleMatch", Weights: ensembleNameWeights},
+	}
+}
+
+// ValidateNameAlgorithm checks an algorithm name; "" selects the default
+func ValidateNameAlgorithm(algorithm string) error {
+	switch algorithm {
+	case "", NameAlgoLevenshtein, NameAlgoJaroWinkler, NameAlgoTrigram, NameAlgoLCS, NameAlgoEnsemble:
+		return nil
+	}
+	return fmt.Errorf("unknown name algorithm %q", algorithm)
+}
+
+// StringSimilarity scores two names (0-1) with the given algorithm.
+// Comparison is case-insensitive.
+func StringSimilarity(algorithm, s1, s2 string) float64 {
+	switch algorithm {
+	case NameAlgoJaroWinkler:
+		return JaroWinkler(s1, s2)
+	case NameAlgoTrigram:
+		return TrigramCosine(s1, s2)
+	case NameAlgoLCS:
+		return LongestCommonSubstringRatio(s1, s2)
+	case NameAlgoEnsemble:
+		return ensembleNameSimilarity(s1, s2)
+	}
+	return LevenshteinRatio(s1, s2)
+}
+
+// ensembleNameSimilarity combines every measure with weighted voting
+func ensembleNameSimilarity(s1, s2 string) float64 {
+	algorithms := make([]string, 0, len(ensembleNameWeights))
+	for algo := range ensembleNameWeights {
+		algorithms = append(algorithms, algo)
+	}
+	sort.Strings(algorithms)
+
+	scores := make([]float64, len(algorithms))
+	weights := make([]float64, len(algorithms))
+	for i, algo := range algorithms {
+		scores[i] = StringSimilarity(algo, s1, s2)
+		weights[i] = ensembleNameWeights[algo]
+	}
+	return NewProbabilisticMatcher().EnsembleMatch(scores, weights)
+}
+
+// JaroWinkler computes the Jaro-Winkler similarity (prefix scale 0.1, up to 4 chars)
+func JaroWinkler(s1, s2 string) float64 {
+	r1 := []rune(strings.ToLower(s1))
+	r2 := []rune(strings.ToLower(s2))
+	if len(r1) == 0 && len(r2) == 0 {
+		return 1.0
+	}
+	if len(r1) == 0 || len(r2) == 0 {
+		return 0
+	}
+
+	window := int(math.Max(float64(len(r1)), float64(len(r2))))/2 - 1
+	if window < 0 {
+		window = 0
+	}
+
+	matched1 := make([]bool, len(r1))
+	matched2 := make([]bool, len(r2))
+	matches := 0
+	for i := range r1 {
+		lo := i - window
+		if lo < 0 {
+			lo = 0
+		}
+		hi := i + window + 1
+		if hi > len(r2) {
+			hi = len(r2)
+		}
+		for j := lo; j < hi; j++ {
+			if !matched2[j] && r1[i] == r2[j] {
+				matched1[i], matched2[j] = true, true
+				matches++
+				break
+			}
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
+
+	// Count transpositions between the matched sequences
+	transpositions := 0
+	j := 0
+	for i := range r1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if r1[i] != r2[j] {
+			transpositions++
+		}
+		j++
+	}
+
+	m := float64(matches)
+	jaro := (m/float64(len(r1)) + m/float64(len(r2)) + (m-float64(transpositions)/2)/m) / 3
+
+	prefix := 0
+	for prefix < len(r1) && prefix < len(r2) && prefix < 4 && r1[prefix] == r2[prefix] {
+		prefix++
+	}
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// TrigramCosine computes the cosine similarity of character trigram counts.
+// Names are padded so short names still produce trigrams.
+func TrigramCosine(s1, s2 string) float64 {
+	v1 := trigramCounts(s1)
+	v2 := trigramCounts(s2)
+	if len(v1) == 0 || len(v2) == 0 {
+		return 0
+	}
+
+	dot, norm1, norm2 := 0.0, 0.0, 0.0
+	for g, c := range v1 {
+		norm1 += float64(c * c)
+		dot += float64(c * v2[g])
+	}
+	for _, c := range v2 {
+		norm2 += float64(c * c)
+	}
+	return dot / (math.Sqrt(norm1) * math.Sqrt(norm2))
+}
+
+func trigramCounts(s string) map[string]int {
+	r := []rune("  " + strings.ToLower(strings.TrimSpace(s)) + " ")
+	counts := make(map[string]int)
+	if len(r) <= 3 {
+		return counts
+	}
+	for i := 0; i+3 <= len(r); i++ {
+		counts[string(r[i:i+3])]++
+	}
+	return counts
+}
+
+// LongestCommonSubstringRatio is twice the length of the longest common
+// substring divided by the total length of both names. Relative to the
+// shorter name alone, a name held whole in a longer one, like id in
+// customer_id, would score 1.
+func LongestCommonSubstringRatio(s1, s2 string) float64 {
+	r1 := []rune(strings.ToLower(s1))
+	r2 := []rune(strings.ToLower(s2))
+	if len(r1) == 0 || len(r2) == 0 {
+		return 0
+	}
+
+	longest := 0
+	prev := make([]int, len(r2)+1)
+	curr := make([]int, len(r2)+1)
+	for i := 1; i <= len(r1); i++ {
+		for j := 1; j <= len(r2); j++ {
+			if r1[i-1] == r2[j-1] {
+				curr[j] = prev[j-1] + 1
+				if curr[j] > longest {
+					longest = curr[j]
+				}
+			} else {
+				curr[j] = 0
+			}
+		}
+		prev, curr = curr, prev
+	}
+	return 2 * float64(longest) / float64(len(r1)+len(r2))
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"math"
+	"testing"
+)
+
+func TestLongestCommonSubstringRatio(t *testing.T) {
+	tests := []struct {
+		s1, s2 string
+		want   float64
+	}{
+		{"customer_id", "customer_id", 1},
+		{"Customer_ID", "customer_id", 1},
+		{"cust_id", "customer_id", 2 * 4.0 / 18}, // "cust"
+		{"id", "customer_id", 2 * 2.0 / 13},
+		{"customer_id", "id", 2 * 2.0 / 13},
+		{"email", "phone", 2 * 1.0 / 10},
+		{"abc", "xyz", 0},
+		{"", "customer_id", 0},
+		{"", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s1+"/"+tt.s2, func(t *testing.T) {
+			if got := LongestCommonSubstringRatio(tt.s1, tt.s2); math.Abs(got-tt.want) > 1e-9 {
+				t.Errorf("got %.4f, want %.4f", got, tt.want)
+			}
+		})
+	}
+
+	// A short name inside a long one is no longer a perfect match
+	if LongestCommonSubstringRatio("id", "customer_id") >= LongestCommonSubstringRatio("cust_id", "customer_id") {
+		t.Error("id scores at least as high as cust_id against customer_id")
+	}
+}
@@ -0,0 +1,209 @@
+package service
+
+import (
+	"backend-go/internal/models"
+	"backend-go/internal/state"
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+)
+
+// TransformScale multiplies file 1 values by a constant factor (cents vs
+// dollars, kg vs lb, s vs ms) to get file 2 units
+const TransformScale = "scale"
+
+// Scale detection thresholds
+const (
+	scaleMinConfidence = 30.0  // Matches below this aren't checked
+	scaleMinRows       = 5     // Overlapping rows needed to trust a ratio
+	scaleMaxRows       = 1000  // Overlapping rows sampled
+	scaleTolerance     = 0.01  // Relative deviation of a consistent ratio
+	scaleConsistency   = 0.9   // Share of ratios within tolerance
+	scaleSnapTolerance = 0.005 // Relative distance to snap to a known factor
+)
+
+// knownScales are unit conversions the detector names; file 2 = file 1 × factor
+var knownScales = []struct {
+	factor float64
+	unit   string
+}{
+	{100, "×100 (e.g. dollars → cents, fraction → percent)"},
+	{0.01, "÷100 (e.g. cents → dollars, percent → fraction)"},
+	{1000, "×1000 (e.g. seconds → milliseconds, kilograms → grams)"},
+	{0.001, "÷1000 (e.g. milliseconds → seconds, grams → kilograms)"},
+	{60, "×60 (e.g. minutes → seconds, hours → minutes)"},
+	{1.0 / 60, "÷60 (e.g. seconds → minutes, minutes → hours)"},
+	{3600, "×3600 (hours → seconds)"},
+	{1.0 / 3600, "÷3600 (seconds → hours)"},
+	{2.20462, "kilograms → pounds"},
+	{0.453592, "pounds → kilograms"},
+	{1.60934, "miles → kilometers"},
+	{0.621371, "kilometers → miles"},
+	{2.54, "inches → centimeters"},
+	{0.393701, "centimeters → inches"},
+	{1024, "×1024 (e.g. kilobytes → bytes)"},
+	{1.0 / 1024, "÷1024 (e.g. bytes → kilobytes)"},
+}
+
+// detectScaleMismatches looks for numeric matches whose values differ by a
+// constant factor on rows that share a key, and attaches the conversion as
+// a scale transform. Keys are the approved join keys of the dataset pair,
+// otherwise the best matching pair of primary-key columns.
+func detectScaleMismatches(df1, df2 *state.DataFrame, profiles1, profiles2 []*ColumnProfile, scope string, results []SimilarityResult) {
+	byName1 := make(map[string]*ColumnProfile, len(profiles1))
+	for _, p := range profiles1 {
+		byName1[p.Name] = p
+	}
+	byName2 := make(map[string]*ColumnProfile, len(profiles2))
+	for _, p := range profiles2 {
+		byName2[p.Name] = p
+	}
+
+	keys := [][2]string{}
+	for _, k := range GetMappingStore().Get(scope).JoinKeys() {
+		keys = append(keys, [2]string{k.File1Column, k.File2Column})
+	}
+	if len(keys) == 0 {
+		for _, r := range results {
+			p1, p2 := byName1[r.File1Column], byName2[r.File2Column]
+			if p1 != nil && p2 != nil && p1.Quality.IsPrimaryKey && p2.Quality.IsPrimaryKey && profileValueOverlap(p1, p2) >= 0.5 {
+				keys = append(keys, [2]string{r.File1Column, r.File2Column})
+				break
+			}
+		}
+	}
+	rows := overlappingRows(df1, df2, keys)
+	if len(rows) < scaleMinRows {
+		return
+	}
+
+	for i := range results {
+		r := &results[i]
+		p1, p2 := byName1[r.File1Column], byName2[r.File2Column]
+		if r.FormatTransform != nil || r.Confidence < scaleMinConfidence || p1 == nil || p2 == nil || !p1.IsNumeric || !p2.IsNumeric {
+			continue
+		}
+		t := scaleTransform(df1, df2, p1.Index, p2.Index, rows)
+		if t == nil {
+			continue
+		}
+		fillTransformExamples(t, p1.Name, p2.Name)
+
+		r.FormatTransform = t
+		r.PatternMatch = TransformScale + "_transform"
+		r.Confidence = math.Min(100, r.Confidence*1.25) // Same boost as format transforms
+		r.Similarity = r.Confidence / 100
+		r.Reason += " | Scale: file 2 = " + scaleExpr("file 1", t.Factor)
+		if t.Unit != "" {
+			r.Reason += " (" + t.Unit + ")"
+		}
+	}
+}
+
+// overlappingRows pairs the row indices of df1 and df2 that share a key
+// value, up to scaleMaxRows; ambiguous keys are skipped
+func overlappingRows(df1, df2 *state.DataFrame, keys [][2]string) [][2]int {
+	if len(keys) == 0 {
+		return nil
+	}
+	idx1 := make([]int, len(keys))
+	idx2 := make([]int, len(keys))
+	for i, k := range keys {
+		idx1[i], idx2[i] = columnIndex(df1, k[0]), columnIndex(df2, k[1])
+		if idx1[i] < 0 || idx2[i] < 0 {
+			return nil
+		}
+	}
+
+	index := make(map[string]int)
+	for r, row := range df2.Rows {
+		if key, ok := joinKey(row, idx2); ok {
+			if _, dup := index[key]; dup {
+				index[key] = -1
+			} else {
+				index[key] = r
+			}
+		}
+	}
+	rows := [][2]int{}
+	for r, row := range df1.Rows {
+		if len(rows) >= scaleMaxRows {
+			break
+		}
+		key, ok := joinKey(row, idx1)
+		if !ok {
+			continue
+		}
+		if r2, found := index[key]; found && r2 >= 0 {
+			rows = append(rows, [2]int{r, r2})
+		}
+	}
+	return rows
+}
+
+// scaleTransform infers the factor between two numeric columns over paired
+// rows; nil unless the ratio is consistent and not 1
+func scaleTransform(df1, df2 *state.DataFrame, colIdx1, colIdx2 int, rows [][2]int) *models.ColumnTransform {
+	c1, c2 := df1.Column(colIdx1), df2.Column(colIdx2)
+	if c1 == nil || c2 == nil {
+		return nil
+	}
+	ratios := make([]float64, 0, len(rows))
+	for _, r := range rows {
+		v1, ok1 := c1.FloatAt(r[0])
+		v2, ok2 := c2.FloatAt(r[1])
+		if ok1 && ok2 && v1 != 0 && v2 != 0 {
+			ratios = append(ratios, v2/v1)
+		}
+	}
+	if len(ratios) < scaleMinRows {
+		return nil
+	}
+
+	sort.Float64s(ratios)
+	median := ratios[len(ratios)/2]
+	if median <= 0 || math.Abs(median-1) <= scaleTolerance {
+		return nil
+	}
+	consistent := 0
+	for _, r := range ratios {
+		if math.Abs(r-median) <= scaleTolerance*median {
+			consistent++
+		}
+	}
+	if float64(consistent) < scaleConsistency*float64(len(ratios)) {
+		return nil
+	}
+
+	t := &models.ColumnTransform{Kind: TransformScale, Factor: roundSignificant(median, 6)}
+	for _, known := range knownScales {
+		if math.Abs(median-known.factor) <= scaleSnapTolerance*known.factor {
+			t.Factor, t.Unit = roundSignificant(known.factor, 6), known.unit
+			break
+		}
+	}
+	return t
+}
+
+// roundSignificant rounds v to n significant digits
+func roundSignificant(v float64, n int) float64 {
+	rounded, _ := strconv.ParseFloat(strconv.FormatFloat(v, 'g', n, 64), 64)
+	return rounded
+}
+
+// scaleExpr multiplies expr by factor, dividing instead when the factor is
+// the inverse of a whole number (÷60 is exact, ×0.0166667 isn't)
+func scaleExpr(expr string, factor float64) string {
+	if factor < 1 {
+		if inverse := math.Round(1 / factor); math.Abs(1/factor-inverse) < 1e-3 {
+			return fmt.Sprintf("%s / %s", expr, formatFactor(inverse))
+		}
+	}
+	return fmt.Sprintf("%s * %s", expr, formatFactor(factor))
+}
+
+// formatFactor renders a factor for SQL, Python and messages
+func formatFactor(f float64) string {
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
@@ -0,0 +1,388 @@
+package service
+
+import (
+	"backend-go/internal/config"
+	"backend-go/internal/logging"
+	"backend-go/internal/remote"
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var webhookLog = logging.Component("webhooks")
+
+const webhooksFile = "webhooks.json"
+
+// Webhook events
+const (
+	EventSimilarityCompleted = "similarity.completed"
+	EventLinkageCompleted    = "linkage.completed"
+	EventSavedQueryCompleted = "saved_query.completed"
+	EventBatchCompleted      = "batch.completed"
+	EventHotFolderChecked    = "hotfolder.checked"
+	EventWebhookTest         = "webhook.test"
+)
+
+// webhookEventTitles are the headings of the events in chat messages
+var webhookEventTitles = map[string]string{
+	EventSimilarityCompleted: "Column similarity completed",
+	EventLinkageCompleted:    "Linkage index built",
+	EventSavedQueryCompleted: "Saved query run completed",
+	EventBatchCompleted:      "Batch comparison completed",
+	EventHotFolderChecked:    "Hot folder file checked",
+	EventWebhookTest:         "Webhook test",
+}
+
+// Webhook payload formats
+const (
+	WebhookFormatJSON  = "json"  // The WebhookEvent as is
+	WebhookFormatSlack = "slack" // Slack incoming webhook message
+	WebhookFormatTeams = "teams" // Microsoft Teams connector card
+)
+
+// Webhook delivery
+const (
+	webhookTimeout  = 10 * time.Second
+	webhookAttempts = 3
+)
+
+// webhookRetryDelay is the wait before the second attempt, doubled after
+var webhookRetryDelay = 2 * time.Second
+
+// Webhook is a URL notified of completed analyses
+type Webhook struct {
+	ID           string           `json:"id"`
+	URL          string           `json:"url"`
+	Events       []string         `json:"events,omitempty"` // Empty = every event
+	Format       string           `json:"format"`
+	Secret       string           `json:"secret,omitempty"` // Signs the payloads; never listed
+	HasSecret    bool             `json:"has_secret"`
+	CreatedAt    time.Time        `json:"created_at"`
+	LastDelivery *WebhookDelivery `json:"last_delivery,omitempty"`
+}
+
+// WebhookDelivery is the outcome of notifying a webhook of an event
+type WebhookDelivery struct {
+	Event     string    `json:"event"`
+	At        time.Time `json:"at"`
+	Attempts  int       `json:"attempts"`
+	Status    int       `json:"status,omitempty"` // HTTP status of the last attempt
+	Error     string    `json:"error,omitempty"`
+	Succeeded bool      `json:"succeeded"`
+}
+
+// WebhookEvent is the payload of the json format
+type WebhookEvent struct {
+	Event   string      `json:"event"`
+	Time    time.Time   `json:"time"`
+	Summary string      `json:"summary"` // One line, the text of chat messages
+	Data    interface{} `json:"data,omitempty"`
+}
+
+// WebhookStore keeps the registered webhooks and delivers events to them
+type WebhookStore struct {
+	webhooks map[string]*Webhook
+	client   *http.Client
+	mutex    sync.Mutex
+}
+
+var (
+	webhookStore     *WebhookStore
+	webhookStoreOnce sync.Once
+)
+
+// GetWebhookStore returns the singleton webhook store
+func GetWebhookStore() *WebhookStore {
+	webhookStoreOnce.Do(func() {
+		webhookStore = &WebhookStore{
+			webhooks: make(map[string]*Webhook),
+			client:   remote.NewGuardedClient(webhookTimeout),
+		}
+		webhookStore.load()
+	})
+	return webhookStore
+}
+
+// load loads webhooks from file
+func (s *WebhookStore) load() {
+	data, err := os.ReadFile(config.DataPath(webhooksFile))
+	if err != nil {
+		if !os.IsNotExist(err) {
+			webhookLog.Error("Error loading webhooks", "error", err)
+		}
+		return
+	}
+
+	var webhooks []*Webhook
+	if err := json.Unmarshal(data, &webhooks); err != nil {
+		webhookLog.Error("Error parsing webhooks", "error", err)
+		return
+	}
+	for _, wh := range webhooks {
+		s.webhooks[wh.ID] = wh
+	}
+	webhookLog.Info("Loaded webhooks", "count", len(webhooks))
+}
+
+// save persists webhooks to file (must hold lock)
+func (s *WebhookStore) save() error {
+	webhooks := make([]*Webhook, 0, len(s.webhooks))
+	for _, wh := range s.webhooks {
+		webhooks = append(webhooks, wh)
+	}
+	sort.Slice(webhooks, func(i, j int) bool { return webhooks[i].CreatedAt.Before(webhooks[j].CreatedAt) })
+
+	data, err := json.MarshalIndent(webhooks, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(config.DataPath(webhooksFile))
+	os.MkdirAll(dir, 0755)
+
+	// The file holds the secrets
+	return os.WriteFile(config.DataPath(webhooksFile), data, 0600)
+}
+
+// List returns the webhooks, oldest first, without their secrets
+func (s *WebhookStore) List() []Webhook {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	list := make([]Webhook, 0, len(s.webhooks))
+	for _, wh := range s.webhooks {
+		list = append(list, wh.public())
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.Before(list[j].CreatedAt) })
+	return list
+}
+
+// public is a copy of the webhook without its secret
+func (wh *Webhook) public() Webhook {
+	c := *wh
+	c.Secret = ""
+	c.Events = append([]string(nil), wh.Events...)
+	return c
+}
+
+// Create validates and stores a new webhook
+func (s *WebhookStore) Create(wh Webhook) (Webhook, error) {
+	u, err := url.Parse(wh.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return wh, fmt.Errorf("url must be an absolute http or https URL")
+	}
+	// Delivery dials through the address guard too; this refuses the
+	// obvious cases up front
+	ctx, cancel := context.WithTimeout(context.Background(), webhookTimeout)
+	defer cancel()
+	if err := remote.CheckHost(ctx, u.Hostname()); err != nil {
+		return wh, fmt.Errorf("url host is not allowed: %v", err)
+	}
+	switch wh.Format {
+	case "":
+		wh.Format = WebhookFormatJSON
+	case WebhookFormatJSON, WebhookFormatSlack, WebhookFormatTeams:
+	default:
+		return wh, fmt.Errorf("format must be json, slack or teams")
+	}
+	for _, event := range wh.Events {
+		if _, ok := webhookEventTitles[event]; !ok || event == EventWebhookTest {
+			return wh, fmt.Errorf("unknown event %q (use %s, %s, %s, %s or %s)", event,
+				EventSimilarityCompleted, EventLinkageCompleted, EventSavedQueryCompleted, EventBatchCompleted, EventHotFolderChecked)
+		}
+	}
+	wh.ID = newWebhookID()
+	wh.HasSecret = wh.Secret != ""
+	wh.CreatedAt = time.Now()
+	wh.LastDelivery = nil
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.webhooks[wh.ID] = &wh
+	webhookLog.Info("Registered webhook", "id", wh.ID, "host", u.Host, "format", wh.Format)
+	return wh.public(), s.save()
+}
+
+// Delete removes a webhook
+func (s *WebhookStore) Delete(id string) error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if _, ok := s.webhooks[id]; !ok {
+		return fmt.Errorf("webhook %q not found", id)
+	}
+	delete(s.webhooks, id)
+	webhookLog.Info("Deleted webhook", "id", id)
+	return s.save()
+}
+
+// Notify delivers an event, in the background, to the webhooks subscribed
+// to it
+func (s *WebhookStore) Notify(event WebhookEvent) {
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	s.mutex.Lock()
+	targets := []Webhook{}
+	for _, wh := range s.webhooks {
+		if wh.subscribed(event.Event) {
+			targets = append(targets, *wh)
+		}
+	}
+	s.mutex.Unlock()
+
+	for _, wh := range targets {
+		go s.deliver(wh, event)
+	}
+}
+
+// Test delivers a test event to a webhook and waits for the outcome
+func (s *WebhookStore) Test(id string) (WebhookDelivery, error) {
+	s.mutex.Lock()
+	wh, ok := s.webhooks[id]
+	var target Webhook
+	if ok {
+		target = *wh
+	}
+	s.mutex.Unlock()
+	if !ok {
+		return WebhookDelivery{}, fmt.Errorf("webhook %q not found", id)
+	}
+
+	return s.deliver(target, WebhookEvent{
+		Event:   EventWebhookTest,
+		Time:    time.Now(),
+		Summary: "Test notification from the correlation server",
+	}), nil
+}
+
+func (wh *Webhook) subscribed(event string) bool {
+	if len(wh.Events) == 0 {
+		return true
+	}
+	for _, e := range wh.Events {
+		if e == event {
+			return true
+		}
+	}
+	return false
+}
+
+// deliver posts an event to a webhook, retrying failed attempts with a
+// growing delay, and records the outcome
+func (s *WebhookStore) deliver(wh Webhook, event WebhookEvent) WebhookDelivery {
+	delivery := WebhookDelivery{Event: event.Event, At: time.Now()}
+	body, err := webhookPayload(wh.Format, event)
+	if err != nil {
+		delivery.Error = err.Error()
+		s.recordDelivery(wh.ID, delivery)
+		return delivery
+	}
+
+	delay := webhookRetryDelay
+	for delivery.Attempts < webhookAttempts {
+		if delivery.Attempts > 0 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+		delivery.Attempts++
+		delivery.Status, err = s.post(wh, event.Event, body)
+		if err == nil {
+			delivery.Error = ""
+			delivery.Succeeded = true
+			break
+		}
+		delivery.Error = err.Error()
+		if delivery.Status >= 400 && delivery.Status < 500 && delivery.Status != http.StatusTooManyRequests {
+			break // The receiver refuses the payload; retrying won't help
+		}
+	}
+
+	if delivery.Succeeded {
+		webhookLog.Info("Delivered webhook", "id", wh.ID, "event", event.Event, "status", delivery.Status, "attempts", delivery.Attempts)
+	} else {
+		webhookLog.Warn("Webhook delivery failed", "id", wh.ID, "event", event.Event, "attempts", delivery.Attempts, "error", delivery.Error)
+	}
+	s.recordDelivery(wh.ID, delivery)
+	return delivery
+}
+
+// post sends one attempt, returning the response status
+func (s *WebhookStore) post(wh Webhook, event string, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, wh.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Webhook-Event", event)
+	if wh.Secret != "" {
+		mac := hmac.New(sha256.New, []byte(wh.Secret))
+		mac.Write(body)
+		req.Header.Set("X-Webhook-Signature", "sha256="+hex.EncodeToString(mac.Sum(nil)))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("receiver answered %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// recordDelivery stores the outcome as the webhook's last delivery
+func (s *WebhookStore) recordDelivery(id string, delivery WebhookDelivery) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	wh, ok := s.webhooks[id]
+	if !ok {
+		return // Deleted while delivering
+	}
+	wh.LastDelivery = &delivery
+	if err := s.save(); err != nil {
+		webhookLog.Error("Error saving webhook delivery", "error", err)
+	}
+}
+
+// webhookPayload renders an event in a webhook's format
+func webhookPayload(format string, event WebhookEvent) ([]byte, error) {
+	title := webhookEventTitles[event.Event]
+	switch format {
+	case WebhookFormatSlack:
+		return json.Marshal(map[string]interface{}{
+			"text": fmt.Sprintf("*%s*\n%s", title, event.Summary),
+		})
+	case WebhookFormatTeams:
+		return json.Marshal(map[string]interface{}{
+			"@type":    "MessageCard",
+			"@context": "https://schema.org/extensions",
+			"summary":  title,
+			"title":    title,
+			"text":     event.Summary,
+		})
+	}
+	return json.Marshal(event)
+}
+
+func newWebhookID() string {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "wh_" + strconv.FormatInt(time.Now().UnixNano(), 36)
+	}
+	return "wh_" + hex.EncodeToString(b)
+}
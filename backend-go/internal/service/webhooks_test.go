@@ -0,0 +1,56 @@
+package service
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"backend-go/internal/remote"
+)
+
+func newTestWebhookStore() *WebhookStore {
+	return &WebhookStore{webhooks: make(map[string]*Webhook), client: remote.NewGuardedClient(webhookTimeout)}
+}
+
+func TestWebhookCreateRefusesInternalHosts(t *testing.T) {
+	s := newTestWebhookStore()
+	for _, u := range []string{
+		"http://127.0.0.1:8080/hook",
+		"http://localhost/hook",
+		"http://[::1]/hook",
+		"http://10.0.0.5/hook",
+		"http://169.254.169.254/latest/meta-data/",
+		"https://100.64.1.1/hook",
+		"http://0.0.0.0/hook",
+	} {
+		if _, err := s.Create(Webhook{URL: u}); err == nil {
+			t.Errorf("%s was registered", u)
+		}
+	}
+	if _, err := s.Create(Webhook{URL: "https://93.184.215.14/hook"}); err != nil {
+		t.Errorf("public address refused: %v", err)
+	}
+}
+
+func TestWebhookDeliveryRefusesInternalAddresses(t *testing.T) {
+	hits := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { hits++ }))
+	defer server.Close()
+
+	// A webhook stored before the check, or whose host now resolves
+	// elsewhere, is still refused when dialing
+	s := newTestWebhookStore()
+	s.webhooks["internal"] = &Webhook{ID: "internal", URL: server.URL, Format: WebhookFormatJSON}
+	saved := webhookRetryDelay
+	webhookRetryDelay = 0
+	defer func() { webhookRetryDelay = saved }()
+
+	delivery, err := s.Test("internal")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if delivery.Succeeded || hits != 0 || !strings.Contains(delivery.Error, "loopback") {
+		t.Errorf("got %+v with %d requests, want a refused delivery", delivery, hits)
+	}
+}
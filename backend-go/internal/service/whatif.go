@@ -0,0 +1,122 @@
+package service
+
+import (
+	"fmt"
+	"math"
+)
+
+// MatchSetChange describes how one column pair moved between two runs
+type MatchSetChange struct {
+	File1Column        string  `json:"file1_column"`
+	File2Column        string  `json:"file2_column"`
+	BaselineConfidence float64 `json:"baseline_confidence"`
+	WhatIfConfidence   float64 `json:"whatif_confidence"`
+	Delta              float64 `json:"delta"`
+	BaselineRank       int     `json:"baseline_rank"` // 1-based, 0 = not ranked
+	WhatIfRank         int     `json:"whatif_rank"`
+}
+
+// MatchSetComparison contrasts the matches of a baseline and a what-if run
+type MatchSetComparison struct {
+	MatchThreshold float64          `json:"match_threshold"`
+	BaselineCount  int              `json:"baseline_count"`
+	WhatIfCount    int              `json:"whatif_count"`
+	Added          []MatchSetChange `json:"added"`   // Matches only in the what-if run
+	Removed        []MatchSetChange `json:"removed"` // Matches only in the baseline run
+	Changed        []MatchSetChange `json:"changed"` // Matches in both whose rank moved
+	Unchanged      int              `json:"unchanged"`
+}
+
+// NormalizeWeights validates caller-supplied weights and scales them to sum to 1
+func NormalizeWeights(w AdaptiveWeights) (AdaptiveWeights, error) {
+	if w.Name < 0 || w.Data < 0 || w.Pattern < 0 || w.LLM < 0 {
+		return w, fmt.Errorf("weights must be non-negative")
+	}
+	sum := w.Name + w.Data + w.Pattern + w.LLM
+	if sum == 0 {
+		return w, fmt.Errorf("at least one weight must be positive")
+	}
+	return AdaptiveWeights{
+		Name:    w.Name / sum,
+		Data:    w.Data / sum,
+		Pattern: w.Pattern / sum,
+		LLM:     w.LLM / sum,
+	}, nil
+}
+
+// CompareMatchSets diffs two ranked result lists, treating pairs at or above
+// threshold as matches
+func CompareMatchSets(baseline, whatIf []SimilarityResult, threshold float64) MatchSetComparison {
+	type ranked struct {
+		result SimilarityResult
+		rank   int
+	}
+	index := func(results []SimilarityResult) (map[string]ranked, int) {
+		m := make(map[string]ranked)
+		matches := 0
+		for i, r := range results {
+			m[r.File1Column+"|"+r.File2Column] = ranked{result: r, rank: i + 1}
+			if r.Confidence >= threshold {
+				matches++
+			}
+		}
+		return m, matches
+	}
+
+	baseIdx, baseCount := index(baseline)
+	whatIdx, whatCount := index(whatIf)
+
+	cmp := MatchSetComparison{
+		MatchThreshold: threshold,
+		BaselineCount:  baseCount,
+		WhatIfCount:    whatCount,
+		Added:          []MatchSetChange{},
+		Removed:        []MatchSetChange{},
+		Changed:        []MatchSetChange{},
+	}
+
+	change := func(key string) MatchSetChange {
+		b, inBase := baseIdx[key]
+		w, inWhat := whatIdx[key]
+		c := MatchSetChange{}
+		if inBase {
+			c.File1Column, c.File2Column = b.result.File1Column, b.result.File2Column
+			c.BaselineConfidence, c.BaselineRank = b.result.Confidence, b.rank
+		}
+		if inWhat {
+			c.File1Column, c.File2Column = w.result.File1Column, w.result.File2Column
+			c.WhatIfConfidence, c.WhatIfRank = w.result.Confidence, w.rank
+		}
+		c.Delta = math.Round((c.WhatIfConfidence-c.BaselineConfidence)*100) / 100
+		return c
+	}
+
+	for _, r := range whatIf {
+		key := r.File1Column + "|" + r.File2Column
+		if r.Confidence < threshold {
+			continue
+		}
+		if b, ok := baseIdx[key]; !ok || b.result.Confidence < threshold {
+			cmp.Added = append(cmp.Added, change(key))
+		}
+	}
+
+	for _, r := range baseline {
+		key := r.File1Column + "|" + r.File2Column
+		if r.Confidence < threshold {
+			continue
+		}
+		w, ok := whatIdx[key]
+		if !ok || w.result.Confidence < threshold {
+			cmp.Removed = append(cmp.Removed, change(key))
+			continue
+		}
+		if w.rank != baseIdx[key].rank {
+			cmp.Changed = append(cmp.Changed, change(key))
+		} else {
+			cmp.Unchanged++
+		}
+	}
+
+	return cmp
+}
@@ -0,0 +1,345 @@
+package state
+
+import (
+	"backend-go/internal/dateformat"
+	"backend-go/internal/nulltoken"
+	"cmp"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ColumnType is the inferred type of a column
+type ColumnType string
+
+const (
+	ColumnString ColumnType = "string"
+	ColumnFloat  ColumnType = "float"
+	ColumnTime   ColumnType = "time"
+)
+
+// Bitmap is a null bitmap: bit i is set when row i holds a value
+type Bitmap []uint64
+
+func newBitmap(n int) Bitmap {
+	return make(Bitmap, (n+63)/64)
+}
+
+func (b Bitmap) set(i int) {
+	b[i/64] |= 1 << (uint(i) % 64)
+}
+
+// Has reports whether bit i is set
+func (b Bitmap) Has(i int) bool {
+	if i < 0 || i/64 >= len(b) {
+		return false
+	}
+	return b[i/64]&(1<<(uint(i)%64)) != 0
+}
+
+// Column is the typed, columnar form of one DataFrame column. It reads its
+// raw values from the DataFrame's rows rather than copying them, and adds
+// the parsed values. Floats holds every cell that parses as a finite number
+// whatever the inferred type, so numeric helpers keep the lenient "skip
+// what doesn't parse" behaviour of row scans.
+type Column struct {
+	Name string
+	Type ColumnType
+
+	rows    [][]string // The DataFrame's rows, shared
+	idx     int        // Index of the column in each row
+	Present Bitmap     // Cells holding a value (not empty or a null token)
+
+	Floats     []float64 // Parsed value per row (0 when not numeric)
+	FloatValid Bitmap    // Cells that parsed as a finite float64
+	FloatCount int
+	NonFinite  int // Cells that parsed as NaN or infinity, not in Floats
+
+	// Parsed time per row as Unix seconds, plus nanoseconds when any time
+	// has a fractional second (only for ColumnTime)
+	times     []int64
+	nanos     []int32
+	TimeValid Bitmap
+
+	Sketch *ColumnSketch // MinHash + HyperLogLog over all non-empty values
+}
+
+// Len returns the number of rows
+func (c *Column) Len() int {
+	return len(c.rows)
+}
+
+// Value returns the raw value of row i, "" for missing cells and null tokens
+func (c *Column) Value(i int) string {
+	if !c.Present.Has(i) {
+		return ""
+	}
+	return c.rows[i][c.idx]
+}
+
+// IsNull reports whether row i is empty or a null token
+func (c *Column) IsNull(i int) bool {
+	return !c.Present.Has(i)
+}
+
+// FloatAt returns the numeric value of row i, if it has one
+func (c *Column) FloatAt(i int) (float64, bool) {
+	if !c.FloatValid.Has(i) {
+		return 0, false
+	}
+	return c.Floats[i], true
+}
+
+// TimeAt returns the time value of row i, in UTC, if it has one
+func (c *Column) TimeAt(i int) (time.Time, bool) {
+	if c.times == nil || !c.TimeValid.Has(i) {
+		return time.Time{}, false
+	}
+	var nsec int64
+	if c.nanos != nil {
+		nsec = int64(c.nanos[i])
+	}
+	return time.Unix(c.times[i], nsec).UTC(), true
+}
+
+// FloatValues returns the numeric values of the column, skipping other cells
+func (c *Column) FloatValues() []float64 {
+	values := make([]float64, 0, c.FloatCount)
+	for i, v := range c.Floats {
+		if c.FloatValid.Has(i) {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// SortIndices sorts row indices by the column's values: numbers numerically,
+// times chronologically, anything else as case-insensitive text. Nulls come
+// last in both directions; ties keep their order.
+func (c *Column) SortIndices(idx []int, desc bool) {
+	text, lowered := make([]string, c.Len()), newBitmap(c.Len())
+	key := func(i int) string {
+		if !lowered.Has(i) {
+			text[i] = strings.ToLower(c.Value(i))
+			lowered.set(i)
+		}
+		return text[i]
+	}
+	compare := func(i, j int) int {
+		switch c.Type {
+		case ColumnTime:
+			ti, okI := c.TimeAt(i)
+			tj, okJ := c.TimeAt(j)
+			if okI && okJ {
+				return ti.Compare(tj)
+			}
+			if okI != okJ {
+				return boolOrder(okI)
+			}
+		case ColumnFloat:
+			fi, okI := c.FloatAt(i)
+			fj, okJ := c.FloatAt(j)
+			if okI && okJ {
+				return cmp.Compare(fi, fj)
+			}
+			if okI != okJ {
+				return boolOrder(okI)
+			}
+		}
+		return strings.Compare(key(i), key(j))
+	}
+	sort.SliceStable(idx, func(a, b int) bool {
+		i, j := idx[a], idx[b]
+		if nullI, nullJ := c.IsNull(i), c.IsNull(j); nullI || nullJ {
+			return !nullI && nullJ
+		}
+		if desc {
+			return compare(i, j) > 0
+		}
+		return compare(i, j) < 0
+	})
+}
+
+// boolOrder sorts typed values (true) before untyped ones
+func boolOrder(typed bool) int {
+	if typed {
+		return -1
+	}
+	return 1
+}
+
+// buildColumn builds the typed view of one column of the rows
+func buildColumn(name string, rows [][]string, colIdx int) *Column {
+	n := len(rows)
+	col := &Column{
+		Name:       name,
+		rows:       rows,
+		idx:        colIdx,
+		Present:    newBitmap(n),
+		Floats:     make([]float64, n),
+		FloatValid: newBitmap(n),
+		Sketch:     NewColumnSketch(),
+	}
+
+	isNull := nulltoken.ForColumn(name)
+	nonEmpty := 0
+	for i, row := range rows {
+		if colIdx >= len(row) || isNull(row[colIdx]) {
+			continue
+		}
+		val := row[colIdx]
+		col.Present.set(i)
+		col.Sketch.Add(val)
+		nonEmpty++
+		if f, err := strconv.ParseFloat(val, 64); err == nil {
+			// "NaN" and "Inf" parse, but no statistic or JSON response can
+			// use them; they keep a numeric column numeric
+			if math.IsNaN(f) || math.IsInf(f, 0) {
+				col.NonFinite++
+				continue
+			}
+			col.Floats[i] = f
+			col.FloatValid.set(i)
+			col.FloatCount++
+		}
+	}
+
+	switch {
+	case col.FloatCount > 0 && col.FloatCount+col.NonFinite == nonEmpty:
+		col.Type = ColumnFloat
+	case nonEmpty > 0 && col.parseTimes():
+		col.Type = ColumnTime
+	default:
+		col.Type = ColumnString
+	}
+
+	// Columns with no numeric cells don't need the float vector
+	if col.FloatCount == 0 {
+		col.Floats = nil
+	}
+	return col
+}
+
+// parseTimes fills the times when every non-empty cell parses with one layout
+func (c *Column) parseTimes() bool {
+	first := -1
+	for i := 0; i < c.Len(); i++ {
+		if c.Present.Has(i) {
+			first = i
+			break
+		}
+	}
+	if first < 0 {
+		return false
+	}
+
+	_, layout, ok := dateformat.Parse(c.Value(first))
+	if !ok {
+		return false
+	}
+
+	times := make([]int64, c.Len())
+	valid := newBitmap(c.Len())
+	var nanos []int32
+	for i := 0; i < c.Len(); i++ {
+		if !c.Present.Has(i) {
+			continue
+		}
+		t, ok := dateformat.ParseWith(layout, c.Value(i))
+		if !ok {
+			return false
+		}
+		times[i] = t.Unix()
+		if ns := t.Nanosecond(); ns != 0 {
+			if nanos == nil {
+				nanos = make([]int32, c.Len())
+			}
+			nanos[i] = int32(ns)
+		}
+		valid.set(i)
+	}
+	c.times, c.nanos = times, nanos
+	c.TimeValid = valid
+	return true
+}
+
+// BuildColumns builds the typed columnar view of the DataFrame. It is called
+// at upload time; Columns builds it lazily for frames created elsewhere.
+func (df *DataFrame) BuildColumns() {
+	df.memoMu.Lock()
+	defer df.memoMu.Unlock()
+	df.buildColumns()
+}
+
+// buildColumns (must hold memoMu)
+func (df *DataFrame) buildColumns() []*Column {
+	columns := make([]*Column, len(df.Headers))
+	for colIdx, name := range df.Headers {
+		columns[colIdx] = buildColumn(name, df.Rows, colIdx)
+	}
+	df.columns.Store(columns)
+	return columns
+}
+
+// builtColumns returns the typed columnar view, nil until built
+func (df *DataFrame) builtColumns() []*Column {
+	columns, _ := df.columns.Load().([]*Column)
+	if len(columns) != len(df.Headers) {
+		return nil
+	}
+	return columns
+}
+
+// Columns returns the typed columnar view, building it on first use. Once
+// built, it is read without locking.
+func (df *DataFrame) Columns() []*Column {
+	if columns := df.builtColumns(); columns != nil {
+		return columns
+	}
+	df.memoMu.Lock()
+	defer df.memoMu.Unlock()
+	if columns := df.builtColumns(); columns != nil {
+		return columns
+	}
+	return df.buildColumns()
+}
+
+// Column returns the typed column at colIdx, or nil when out of range
+func (df *DataFrame) Column(colIdx int) *Column {
+	columns := df.Columns()
+	if colIdx < 0 || colIdx >= len(columns) {
+		return nil
+	}
+	return columns[colIdx]
+}
+
+// FloatValues returns the numeric values of a column, skipping cells that
+// don't parse as numbers
+func (df *DataFrame) FloatValues(colIdx int) []float64 {
+	col := df.Column(colIdx)
+	if col == nil {
+		return []float64{}
+	}
+	return col.FloatValues()
+}
+
+// FloatPairs returns the rows where both columns hold numbers, as two
+// aligned slices (for correlations)
+func (df *DataFrame) FloatPairs(col1Idx, col2Idx int) ([]float64, []float64) {
+	c1, c2 := df.Column(col1Idx), df.Column(col2Idx)
+	vals1, vals2 := []float64{}, []float64{}
+	if c1 == nil || c2 == nil {
+		return vals1, vals2
+	}
+	for i := 0; i < c1.Len() && i < c2.Len(); i++ {
+		v1, ok1 := c1.FloatAt(i)
+		v2, ok2 := c2.FloatAt(i)
+		if ok1 && ok2 {
+			vals1 = append(vals1, v1)
+			vals2 = append(vals2, v2)
+		}
+	}
+	return vals1, vals2
+}
@@ -0,0 +1,34 @@
+package state
+
+import (
+	"math"
+	"testing"
+)
+
+func TestBuildColumnNonFinite(t *testing.T) {
+	df := &DataFrame{
+		Headers: []string{"amount", "label"},
+		Rows: [][]string{
+			{"1.5", "NaN"}, {"NaN", "Inf"}, {"2", "x"}, {"+Inf", "y"}, {"-Infinity", "z"}, {"", ""}, {"3", "w"},
+		},
+	}
+	col := df.Column(0)
+	if col.Type != ColumnFloat {
+		t.Errorf("type %s, want a numeric column despite its NaN and infinite cells", col.Type)
+	}
+	if col.FloatCount != 3 || col.NonFinite != 3 {
+		t.Errorf("got %d numbers and %d non-finite cells, want 3 and 3", col.FloatCount, col.NonFinite)
+	}
+	for i := 0; i < col.Len(); i++ {
+		if v, ok := col.FloatAt(i); ok && (math.IsNaN(v) || math.IsInf(v, 0)) {
+			t.Errorf("row %d: FloatAt returned %v", i, v)
+		}
+	}
+	if got := df.FloatValues(0); len(got) != 3 || got[0] != 1.5 || got[1] != 2 || got[2] != 3 {
+		t.Errorf("FloatValues %v, want [1.5 2 3]", got)
+	}
+
+	if label := df.Column(1); label.Type != ColumnString || label.FloatCount != 0 {
+		t.Errorf("label: type %s with %d numbers, want text", label.Type, label.FloatCount)
+	}
+}
@@ -0,0 +1,46 @@
+package state
+
+import "unsafe"
+
+// MemoryUsage estimates the memory a DataFrame holds
+type MemoryUsage struct {
+	Rows        int   `json:"rows"`
+	Columns     int   `json:"columns"`
+	RowBytes    int64 `json:"row_bytes"`    // Rows and their cell values
+	ColumnBytes int64 `json:"column_bytes"` // Typed columnar view, 0 until built
+}
+
+// TotalBytes is the estimated size of the DataFrame
+func (m MemoryUsage) TotalBytes() int64 {
+	return m.RowBytes + m.ColumnBytes
+}
+
+// Sizes of the headers of the slices and strings counted
+const (
+	sliceHeaderSize  = int64(unsafe.Sizeof([]string(nil)))
+	stringHeaderSize = int64(unsafe.Sizeof(""))
+	sketchSize       = minHashSlots*8 + hllRegisterCount + 2*sliceHeaderSize
+)
+
+// MemoryUsage estimates the bytes held by the rows and, once built, the
+// columnar view. The view reads its raw values from the rows, so only the
+// parsed values, bitmaps and sketches are counted there.
+func (df *DataFrame) MemoryUsage() MemoryUsage {
+	m := MemoryUsage{Rows: len(df.Rows), Columns: len(df.Headers)}
+	m.RowBytes = sliceHeaderSize * int64(len(df.Rows)+1)
+	for _, row := range df.Rows {
+		m.RowBytes += stringHeaderSize * int64(len(row))
+		for _, cell := range row {
+			m.RowBytes += int64(len(cell))
+		}
+	}
+
+	for _, c := range df.builtColumns() {
+		m.ColumnBytes += 8*int64(len(c.Present)+len(c.Floats)+len(c.FloatValid)+len(c.TimeValid)+len(c.times)) +
+			4*int64(len(c.nanos))
+		if c.Sketch != nil {
+			m.ColumnBytes += sketchSize
+		}
+	}
+	return m
+}
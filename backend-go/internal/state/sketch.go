@@ -0,0 +1,139 @@
+package state
+
+import (
+	"backend-go/internal/textnorm"
+	"hash/fnv"
+	"math"
+	"math/bits"
+)
+
+// Sketch sizes. 128 MinHash slots give a Jaccard standard error of about
+// 0.09; 2^12 HLL registers give a cardinality error of about 1.6%.
+const (
+	minHashSlots     = 128
+	hllPrecision     = 12
+	hllRegisterCount = 1 << hllPrecision
+)
+
+// ColumnSketch summarizes the distinct (lowercased) values of a column so
+// overlap and cardinality can be estimated without rescanning rows
+type ColumnSketch struct {
+	MinHash []uint64 // Minimum of each hash function over all values
+	HLL     []uint8  // HyperLogLog registers
+	Values  int      // Non-empty values added (not distinct)
+}
+
+// NewColumnSketch creates an empty sketch
+func NewColumnSketch() *ColumnSketch {
+	s := &ColumnSketch{
+		MinHash: make([]uint64, minHashSlots),
+		HLL:     make([]uint8, hllRegisterCount),
+	}
+	for i := range s.MinHash {
+		s.MinHash[i] = math.MaxUint64
+	}
+	return s
+}
+
+// Add adds a value to the sketch. Values are compared case- and accent-insensitively.
+func (s *ColumnSketch) Add(value string) {
+	h := fnv.New64a()
+	h.Write([]byte(textnorm.Fold(value)))
+	base := mix64(h.Sum64())
+	s.Values++
+
+	// HyperLogLog: leading bits pick the register, the rest give the rank
+	reg := base >> (64 - hllPrecision)
+	rank := uint8(bits.LeadingZeros64(base<<hllPrecision|1<<(hllPrecision-1)) + 1)
+	if rank > s.HLL[reg] {
+		s.HLL[reg] = rank
+	}
+
+	// MinHash: k hash functions derived by double hashing
+	step := mix64(base^0x9e3779b97f4a7c15) | 1
+	for i := range s.MinHash {
+		v := mix64(base + uint64(i)*step)
+		if v < s.MinHash[i] {
+			s.MinHash[i] = v
+		}
+	}
+}
+
+// Empty reports whether no values were added
+func (s *ColumnSketch) Empty() bool {
+	return s == nil || s.Values == 0
+}
+
+// Jaccard estimates |A ∩ B| / |A ∪ B| of the distinct values of two sketches
+func (s *ColumnSketch) Jaccard(other *ColumnSketch) float64 {
+	if s.Empty() || other.Empty() {
+		return 0
+	}
+	equal := 0
+	for i := range s.MinHash {
+		if s.MinHash[i] == other.MinHash[i] {
+			equal++
+		}
+	}
+	return float64(equal) / float64(len(s.MinHash))
+}
+
+// Cardinality estimates the number of distinct values
+func (s *ColumnSketch) Cardinality() float64 {
+	if s.Empty() {
+		return 0
+	}
+	return hllEstimate(s.HLL)
+}
+
+// UnionCardinality estimates the number of distinct values across both sketches
+func (s *ColumnSketch) UnionCardinality(other *ColumnSketch) float64 {
+	if s.Empty() {
+		return other.Cardinality()
+	}
+	if other.Empty() {
+		return s.Cardinality()
+	}
+	merged := make([]uint8, len(s.HLL))
+	for i := range merged {
+		merged[i] = s.HLL[i]
+		if other.HLL[i] > merged[i] {
+			merged[i] = other.HLL[i]
+		}
+	}
+	return hllEstimate(merged)
+}
+
+// IntersectionCardinality estimates the number of distinct values both sketches share
+func (s *ColumnSketch) IntersectionCardinality(other *ColumnSketch) float64 {
+	return s.Jaccard(other) * s.UnionCardinality(other)
+}
+
+// hllEstimate applies the HyperLogLog estimator with linear counting for small ranges
+func hllEstimate(registers []uint8) float64 {
+	m := float64(len(registers))
+	sum := 0.0
+	zeros := 0
+	for _, r := range registers {
+		sum += 1 / float64(uint64(1)<<r)
+		if r == 0 {
+			zeros++
+		}
+	}
+	alpha := 0.7213 / (1 + 1.079/m)
+	estimate := alpha * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 {
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return estimate
+}
+
+// mix64 is the splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
@@ -2,7 +2,13 @@ package state
 
 import (
 	"backend-go/internal/models"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 )
 
 // DataFrame represents a loaded CSV file with its data
@@ -11,6 +17,11 @@ type DataFrame struct {
 	Rows     [][]string
 	FilePath string
 	FileName string
+	Encoding string // Source encoding detected at upload
+
+	memoMu      sync.Mutex // Guards contentHash and building columns
+	contentHash string
+	columns     atomic.Value // []*Column, the typed columnar view of Rows
 }
 
 // AppState holds the global application state
@@ -101,6 +112,18 @@ func (s *AppState) ClearContext(fileIndex *int) {
 	}
 }
 
+// SchemaHash returns a fingerprint of the column names, independent of column order
+func (df *DataFrame) SchemaHash() string {
+	headers := make([]string, len(df.Headers))
+	for i, h := range df.Headers {
+		headers[i] = strings.ToLower(strings.TrimSpace(h))
+	}
+	sort.Strings(headers)
+
+	sum := sha256.Sum256([]byte(strings.Join(headers, "\x1f")))
+	return hex.EncodeToString(sum[:8])
+}
+
 // GetNumericColumnIndices returns indices of numeric columns
 func (df *DataFrame) GetNumericColumnIndices() map[int]bool {
 	if len(df.Rows) == 0 {
@@ -157,3 +180,84 @@ func isNumericString(s string) bool {
 	}
 	return true
 }
+
+// ContentHash returns a fingerprint of headers and rows. It is computed once and
+// memoized; call MarkModified after changing Headers or Rows in place.
+func (df *DataFrame) ContentHash() string {
+	df.memoMu.Lock()
+	defer df.memoMu.Unlock()
+
+	if df.contentHash == "" {
+		h := sha256.New()
+		h.Write([]byte(strings.Join(df.Headers, "\x1f")))
+		for _, row := range df.Rows {
+			h.Write([]byte{'\x1e'})
+			h.Write([]byte(strings.Join(row, "\x1f")))
+		}
+		df.contentHash = hex.EncodeToString(h.Sum(nil)[:16])
+	}
+	return df.contentHash
+}
+
+// RenameColumns renames columns in place (old name -> new name). Names must
+// exist, new names must be non-empty and the result must stay unique.
+func (df *DataFrame) RenameColumns(renames map[string]string) error {
+	headers := append([]string(nil), df.Headers...)
+	for oldName, newName := range renames {
+		newName = strings.TrimSpace(newName)
+		if newName == "" {
+			return fmt.Errorf("new name for column %q is empty", oldName)
+		}
+		idx := -1
+		for i, h := range df.Headers {
+			if h == oldName {
+				idx = i
+				break
+			}
+		}
+		if idx < 0 {
+			return fmt.Errorf("column %q not found", oldName)
+		}
+		headers[idx] = newName
+	}
+
+	seen := make(map[string]bool, len(headers))
+	for _, h := range headers {
+		if seen[h] {
+			return fmt.Errorf("duplicate column name %q", h)
+		}
+		seen[h] = true
+	}
+
+	df.Headers = headers
+	df.MarkModified()
+	return nil
+}
+
+// MarkModified drops the memoized content hash and typed columns after an
+// in-place change
+func (df *DataFrame) MarkModified() {
+	df.memoMu.Lock()
+	df.contentHash = ""
+	df.columns.Store([]*Column(nil))
+	df.memoMu.Unlock()
+}
+
+// Copy returns a DataFrame sharing this one's rows and typed columns, which
+// are never changed in place, with its own headers and file details
+func (df *DataFrame) Copy() *DataFrame {
+	df.memoMu.Lock()
+	defer df.memoMu.Unlock()
+	c := &DataFrame{
+		Headers:     append([]string(nil), df.Headers...),
+		Rows:        df.Rows,
+		FilePath:    df.FilePath,
+		FileName:    df.FileName,
+		Encoding:    df.Encoding,
+		contentHash: df.contentHash,
+	}
+	if columns := df.builtColumns(); columns != nil {
+		c.columns.Store(columns)
+	}
+	return c
+}
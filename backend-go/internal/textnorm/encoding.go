@@ -0,0 +1,136 @@
+package textnorm
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Encodings reported by DetectEncoding
+const (
+	EncodingUTF8        = "utf-8"
+	EncodingUTF8BOM     = "utf-8-bom"
+	EncodingUTF16LE     = "utf-16le"
+	EncodingUTF16BE     = "utf-16be"
+	EncodingWindows1252 = "windows-1252"
+)
+
+// encodingSniffBytes is how much of the input the UTF-16 heuristic inspects
+const encodingSniffBytes = 4096
+
+// DetectEncoding guesses the encoding of raw file content from its BOM, the
+// NUL byte layout typical of UTF-16, and UTF-8 validity. Anything that is not
+// valid UTF-8 is treated as Windows-1252, the usual legacy export encoding.
+func DetectEncoding(data []byte) string {
+	switch {
+	case bytes.HasPrefix(data, []byte{0xEF, 0xBB, 0xBF}):
+		return EncodingUTF8BOM
+	case bytes.HasPrefix(data, []byte{0xFF, 0xFE}):
+		return EncodingUTF16LE
+	case bytes.HasPrefix(data, []byte{0xFE, 0xFF}):
+		return EncodingUTF16BE
+	}
+
+	if enc := sniffUTF16(data); enc != "" {
+		return enc
+	}
+	if utf8.Valid(data) {
+		return EncodingUTF8
+	}
+	return EncodingWindows1252
+}
+
+// sniffUTF16 detects BOM-less UTF-16 of mostly-ASCII text, where every other
+// byte is NUL
+func sniffUTF16(data []byte) string {
+	n := len(data)
+	if n > encodingSniffBytes {
+		n = encodingSniffBytes
+	}
+	n -= n % 2
+	if n < 4 {
+		return ""
+	}
+
+	evenZeros, oddZeros := 0, 0
+	for i := 0; i < n; i += 2 {
+		if data[i] == 0 {
+			evenZeros++
+		}
+		if data[i+1] == 0 {
+			oddZeros++
+		}
+	}
+	pairs := n / 2
+	switch {
+	case oddZeros*10 > pairs*7 && evenZeros*10 < pairs:
+		return EncodingUTF16LE
+	case evenZeros*10 > pairs*7 && oddZeros*10 < pairs:
+		return EncodingUTF16BE
+	}
+	return ""
+}
+
+// ToUTF8 transcodes raw content to UTF-8 (without BOM) and reports the
+// detected source encoding
+func ToUTF8(data []byte) ([]byte, string) {
+	enc := DetectEncoding(data)
+	switch enc {
+	case EncodingUTF8BOM:
+		return data[3:], enc
+	case EncodingUTF16LE, EncodingUTF16BE:
+		return decodeUTF16(data, enc), enc
+	case EncodingWindows1252:
+		return decodeWindows1252(data), enc
+	}
+	return data, enc
+}
+
+func decodeUTF16(data []byte, enc string) []byte {
+	var order binary.ByteOrder = binary.LittleEndian
+	if enc == EncodingUTF16BE {
+		order = binary.BigEndian
+	}
+	if len(data) >= 2 && order.Uint16(data) == 0xFEFF {
+		data = data[2:] // BOM
+	}
+
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = order.Uint16(data[2*i:])
+	}
+
+	var b strings.Builder
+	b.Grow(len(units))
+	for _, r := range utf16.Decode(units) {
+		b.WriteRune(r)
+	}
+	return []byte(b.String())
+}
+
+// windows1252High maps bytes 0x80-0x9F; the rest of the range matches Latin-1.
+// Unassigned bytes map to the matching C1 control, as browsers do.
+var windows1252High = [32]rune{
+	0x20AC, 0x0081, 0x201A, 0x0192, 0x201E, 0x2026, 0x2020, 0x2021,
+	0x02C6, 0x2030, 0x0160, 0x2039, 0x0152, 0x008D, 0x017D, 0x008F,
+	0x0090, 0x2018, 0x2019, 0x201C, 0x201D, 0x2022, 0x2013, 0x2014,
+	0x02DC, 0x2122, 0x0161, 0x203A, 0x0153, 0x009D, 0x017E, 0x0178,
+}
+
+func decodeWindows1252(data []byte) []byte {
+	var b strings.Builder
+	b.Grow(len(data) + len(data)/8)
+	for _, c := range data {
+		switch {
+		case c < 0x80:
+			b.WriteByte(c)
+		case c < 0xA0:
+			b.WriteRune(windows1252High[c-0x80])
+		default:
+			b.WriteRune(rune(c))
+		}
+	}
+	return []byte(b.String())
+}
@@ -0,0 +1,116 @@
+// Package textnorm prepares text for matching: it transcodes legacy file
+// encodings to UTF-8 and folds text (compatibility decomposition, accent
+// stripping and case folding) so "Número_Cliente" and "numero cliente"
+// compare equal.
+//
+// It covers what the matchers need without pulling in golang.org/x/text:
+// precomposed Latin letters (Latin-1 Supplement, Latin Extended-A/B and
+// Vietnamese), common compatibility characters (ligatures, fullwidth forms,
+// superscripts) and any combining marks already present in the input.
+package textnorm
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Fold returns the accent-stripped, case-folded, compatibility-decomposed form of s
+func Fold(s string) string {
+	if isASCII(s) {
+		return strings.ToLower(s)
+	}
+
+	var b strings.Builder
+	b.Grow(len(s))
+	for _, r := range s {
+		// Fullwidth ASCII variants (U+FF01-U+FF5E) map onto ASCII
+		if r >= 0xFF01 && r <= 0xFF5E {
+			r -= 0xFEE0
+		}
+		if r == 0x3000 { // Ideographic space
+			r = ' '
+		}
+		if unicode.Is(unicode.Mn, r) {
+			continue // Combining mark
+		}
+		if rep, ok := decompositions[r]; ok {
+			b.WriteString(rep)
+			continue
+		}
+		b.WriteRune(unicode.ToLower(r))
+	}
+	return b.String()
+}
+
+func isASCII(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return false
+		}
+	}
+	return true
+}
+
+// decompositions maps a character (either case) to its folded base letters
+var decompositions = buildDecompositions()
+
+func buildDecompositions() map[rune]string {
+	m := make(map[rune]string)
+
+	// Precomposed letters grouped by base letter
+	groups := map[string]string{
+		"a": "àáâãäåāăąǎǟǡǻȁȃȧạảấầẩẫậắằẳẵặ",
+		"c": "çćĉċč",
+		"d": "ďđ",
+		"e": "èéêëēĕėęěȅȇȩẹẻẽếềểễệ",
+		"g": "ĝğġģǧǵ",
+		"h": "ĥħȟ",
+		"i": "ìíîïĩīĭįıǐȉȋỉị",
+		"j": "ĵǰ",
+		"k": "ķǩ",
+		"l": "ĺļľŀł",
+		"n": "ñńņňŉǹ",
+		"o": "òóôõöøōŏőơǒǫǭǿȍȏȫȭȯȱọỏốồổỗộớờởỡợ",
+		"r": "ŕŗřȑȓ",
+		"s": "śŝşšș",
+		"t": "ţťŧț",
+		"u": "ùúûüũūŭůűųưǔǖǘǚǜȕȗụủứừửữự",
+		"w": "ŵẁẃẅ",
+		"y": "ýÿŷỳỵỷỹ",
+		"z": "źżžƶ",
+	}
+	for base, letters := range groups {
+		for _, r := range letters {
+			m[r] = base
+			if upper := unicode.ToUpper(r); upper != r {
+				m[upper] = base
+			}
+		}
+	}
+
+	// Letters and compatibility characters that expand
+	for r, rep := range map[rune]string{
+		'ß': "ss", 'ẞ': "ss",
+		'æ': "ae", 'Æ': "ae",
+		'œ': "oe", 'Œ': "oe",
+		'ĳ': "ij", 'Ĳ': "ij",
+		'þ': "th", 'Þ': "th",
+		'ð': "d", 'Ð': "d", 'Đ': "d",
+		'ﬀ': "ff", 'ﬁ': "fi", 'ﬂ': "fl", 'ﬃ': "ffi", 'ﬄ': "ffl", 'ﬅ': "st", 'ﬆ': "st",
+		'¹': "1", '²': "2", '³': "3", '⁰': "0", '⁴': "4", '⁵': "5", '⁶': "6", '⁷': "7", '⁸': "8", '⁹': "9",
+		'ª': "a", 'º': "o",
+		'½': "1/2", '¼': "1/4", '¾': "3/4",
+		'…':      "...",
+		'\u00a0': " ", // No-break space
+		'\u2007': " ", // Figure space
+		'\u202f': " ", // Narrow no-break space
+		'‐':      "-", '‑': "-", '‒': "-", '–': "-", '—': "-",
+		'‘': "'", '’': "'", '“': "\"", '”': "\"",
+		'№': "no",
+		'™': "tm",
+	} {
+		m[r] = rep
+	}
+
+	return m
+}
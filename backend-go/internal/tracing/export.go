@@ -0,0 +1,175 @@
+package tracing
+
+import (
+	"backend-go/internal/logging"
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+var logger = logging.Component("tracing")
+
+// Export batching: spans are sent when a batch fills or the interval passes;
+// spans beyond the queue are dropped rather than slow requests down
+const (
+	exportQueueSize = 2048
+	exportBatchSize = 256
+	exportInterval  = 5 * time.Second
+)
+
+var (
+	queue       chan *Span
+	endpoint    string
+	serviceName string
+	exportOnce  sync.Once
+	dropped     sync.Once
+)
+
+// Enabled reports whether spans are exported
+func Enabled() bool {
+	startExporter()
+	return queue != nil
+}
+
+func startExporter() {
+	exportOnce.Do(func() {
+		endpoint = os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+		if endpoint == "" {
+			if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+				endpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+			}
+		}
+		if endpoint == "" {
+			return
+		}
+		serviceName = os.Getenv("OTEL_SERVICE_NAME")
+		if serviceName == "" {
+			serviceName = "project-euler-backend"
+		}
+		queue = make(chan *Span, exportQueueSize)
+		go exportLoop()
+		logger.Info("Exporting spans", "service", serviceName, "endpoint", endpoint)
+	})
+}
+
+func export(s *Span) {
+	if !Enabled() {
+		return
+	}
+	select {
+	case queue <- s:
+	default:
+		dropped.Do(func() { logger.Warn("Export queue full, dropping spans") })
+	}
+}
+
+func exportLoop() {
+	client := &http.Client{Timeout: 10 * time.Second}
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+
+	batch := make([]*Span, 0, exportBatchSize)
+	flush := func() {
+		if len(batch) == 0 {
+			return
+		}
+		if err := send(client, batch); err != nil {
+			logger.Error("Error exporting spans", "spans", len(batch), "error", err)
+		}
+		batch = batch[:0]
+	}
+	for {
+		select {
+		case s := <-queue:
+			batch = append(batch, s)
+			if len(batch) == exportBatchSize {
+				flush()
+			}
+		case <-ticker.C:
+			flush()
+		}
+	}
+}
+
+// send posts a batch as an OTLP ExportTraceServiceRequest
+func send(client *http.Client, batch []*Span) error {
+	spans := make([]map[string]interface{}, len(batch))
+	for i, s := range batch {
+		spans[i] = otlpSpan(s)
+	}
+	body, err := json.Marshal(map[string]interface{}{
+		"resourceSpans": []interface{}{map[string]interface{}{
+			"resource": map[string]interface{}{
+				"attributes": otlpAttributes(map[string]interface{}{"service.name": serviceName}),
+			},
+			"scopeSpans": []interface{}{map[string]interface{}{
+				"scope": map[string]interface{}{"name": "backend-go/internal/tracing"},
+				"spans": spans,
+			}},
+		}},
+	})
+	if err != nil {
+		return err
+	}
+
+	resp, err := client.Post(endpoint, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("collector returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+func otlpSpan(s *Span) map[string]interface{} {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	span := map[string]interface{}{
+		"traceId":           hex.EncodeToString(s.TraceID[:]),
+		"spanId":            hex.EncodeToString(s.SpanID[:]),
+		"name":              s.name,
+		"kind":              s.kind,
+		"startTimeUnixNano": strconv.FormatInt(s.start.UnixNano(), 10),
+		"endTimeUnixNano":   strconv.FormatInt(s.end.UnixNano(), 10),
+		"attributes":        otlpAttributes(s.attrs),
+	}
+	if s.parentID != [8]byte{} {
+		span["parentSpanId"] = hex.EncodeToString(s.parentID[:])
+	}
+	if s.errMsg != "" {
+		span["status"] = map[string]interface{}{"code": 2, "message": s.errMsg} // STATUS_CODE_ERROR
+	}
+	return span
+}
+
+// otlpAttributes converts attributes to OTLP key/value pairs
+func otlpAttributes(attrs map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(attrs))
+	for k, v := range attrs {
+		var value map[string]interface{}
+		switch v := v.(type) {
+		case bool:
+			value = map[string]interface{}{"boolValue": v}
+		case int:
+			value = map[string]interface{}{"intValue": strconv.Itoa(v)}
+		case int64:
+			value = map[string]interface{}{"intValue": strconv.FormatInt(v, 10)}
+		case float64:
+			value = map[string]interface{}{"doubleValue": v}
+		default:
+			value = map[string]interface{}{"stringValue": fmt.Sprint(v)}
+		}
+		out = append(out, map[string]interface{}{"key": k, "value": value})
+	}
+	return out
+}
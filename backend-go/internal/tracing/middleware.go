@@ -0,0 +1,41 @@
+package tracing
+
+import (
+	"backend-go/internal/logging"
+	"errors"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// Middleware traces each request as a server span named by its route
+// pattern, joining the caller's trace when it sends a traceparent header
+func Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := StartKind(Extract(r.Context(), r.Header), r.Method+" "+r.URL.Path, KindServer)
+		defer span.End()
+		ctx = logging.ContextWith(ctx, "trace_id", span.TraceIDString())
+
+		ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+		next.ServeHTTP(ww, r.WithContext(ctx))
+
+		// The route is known once chi has matched it
+		if rctx := chi.RouteContext(r.Context()); rctx != nil {
+			if pattern := rctx.RoutePattern(); pattern != "" {
+				span.SetName(r.Method + " " + pattern)
+				span.SetAttr("http.route", pattern)
+			}
+		}
+		status := ww.Status()
+		if status == 0 {
+			status = http.StatusOK
+		}
+		span.SetAttr("http.request.method", r.Method)
+		span.SetAttr("url.path", r.URL.Path)
+		span.SetAttr("http.response.status_code", status)
+		if status >= 500 {
+			span.RecordError(errors.New(http.StatusText(status)))
+		}
+	})
+}
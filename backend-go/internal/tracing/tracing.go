@@ -0,0 +1,162 @@
+// Package tracing records OpenTelemetry-compatible spans and exports them
+// over OTLP/HTTP (JSON encoding) to any collector or tracing backend.
+//
+// Traces propagate with the W3C traceparent header: incoming requests join
+// the caller's trace and outgoing calls (Ollama) carry it on. Spans are
+// exported when OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, or
+// OTEL_EXPORTER_OTLP_ENDPOINT plus /v1/traces, is set; OTEL_SERVICE_NAME
+// names the service (default project-euler-backend).
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Span kinds, numbered as in OTLP
+const (
+	KindInternal = 1
+	KindServer   = 2
+	KindClient   = 3
+)
+
+// SpanContext identifies a span within its trace
+type SpanContext struct {
+	TraceID [16]byte
+	SpanID  [8]byte
+}
+
+// IsValid reports whether the trace and span IDs are set
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID != [16]byte{} && sc.SpanID != [8]byte{}
+}
+
+// Span is a timed operation of a trace
+type Span struct {
+	SpanContext
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+
+	mutex  sync.Mutex
+	end    time.Time
+	attrs  map[string]interface{}
+	errMsg string
+	ended  bool
+}
+
+type spanKey struct{}
+type remoteKey struct{}
+
+// Start begins an internal span, a child of the context's span
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	return StartKind(ctx, name, KindInternal)
+}
+
+// StartKind begins a span of the given kind, a child of the context's span
+// or of a remote parent extracted from a request
+func StartKind(ctx context.Context, name string, kind int) (context.Context, *Span) {
+	s := &Span{name: name, kind: kind, start: time.Now(), attrs: map[string]interface{}{}}
+	parent, ok := ctx.Value(spanKey{}).(*Span)
+	switch {
+	case ok:
+		s.TraceID, s.parentID = parent.TraceID, parent.SpanID
+	default:
+		if remote, ok := ctx.Value(remoteKey{}).(SpanContext); ok {
+			s.TraceID, s.parentID = remote.TraceID, remote.SpanID
+		} else {
+			rand.Read(s.TraceID[:])
+		}
+	}
+	rand.Read(s.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// FromContext returns the context's current span, or nil
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// SetName renames the span, e.g. once the operation is better known
+func (s *Span) SetName(name string) {
+	s.mutex.Lock()
+	s.name = name
+	s.mutex.Unlock()
+}
+
+// SetAttr records an attribute of the operation
+func (s *Span) SetAttr(key string, value interface{}) {
+	s.mutex.Lock()
+	s.attrs[key] = value
+	s.mutex.Unlock()
+}
+
+// RecordError marks the span failed; nil errors are ignored
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.mutex.Lock()
+	s.errMsg = err.Error()
+	s.mutex.Unlock()
+}
+
+// End finishes the span and queues it for export; later calls do nothing
+func (s *Span) End() {
+	s.mutex.Lock()
+	if s.ended {
+		s.mutex.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.mutex.Unlock()
+	export(s)
+}
+
+// TraceIDString is the hex trace ID
+func (s *Span) TraceIDString() string {
+	return hex.EncodeToString(s.TraceID[:])
+}
+
+// Extract returns a context carrying the remote parent of a traceparent
+// header, if the request has a valid one
+func Extract(ctx context.Context, h http.Header) context.Context {
+	sc, ok := parseTraceparent(h.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject sets the traceparent header of an outgoing request to the
+// context's span
+func Inject(ctx context.Context, h http.Header) {
+	if s := FromContext(ctx); s != nil {
+		h.Set("traceparent", fmt.Sprintf("00-%x-%x-01", s.TraceID, s.SpanID))
+	}
+}
+
+// parseTraceparent reads "00-<32 hex trace id>-<16 hex span id>-<flags>"
+func parseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	return sc, sc.IsValid()
+}
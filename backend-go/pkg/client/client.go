@@ -0,0 +1,134 @@
+// Package client is a Go client for the correlation server's HTTP API.
+//
+//	c := client.New("http://localhost:8001", client.WithAPIKey(os.Getenv("API_KEY")))
+//	if _, err := c.UploadFile(ctx, 1, "customers.csv", nil); err != nil { ... }
+//	if _, err := c.UploadFile(ctx, 2, "accounts.csv", nil); err != nil { ... }
+//	graph, err := c.ColumnSimilarity(ctx, nil)
+//
+// Requests go to the /api/v1 endpoints. Errors the server reports are
+// returned as *Error, carrying its stable error code.
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// APIPrefix is the path of the API version the client speaks
+const APIPrefix = "/api/v1"
+
+// Client calls the API of one server
+type Client struct {
+	baseURL    string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// Option configures a Client
+type Option func(*Client)
+
+// WithAPIKey authenticates requests with an API key
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+// WithHTTPClient sends requests through the given client instead of one with
+// a 5 minute timeout
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// New returns a client of the server at baseURL, e.g. "http://localhost:8001"
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: 5 * time.Minute},
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// Error is an error response of the API
+type Error struct {
+	StatusCode int                    `json:"-"`
+	Code       string                 `json:"code"` // e.g. "file_not_loaded"; stable to branch on
+	Message    string                 `json:"message"`
+	Details    map[string]interface{} `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s (%d %s)", e.Message, e.StatusCode, e.Code)
+}
+
+// getJSON sends a GET with the query given and decodes the response into out
+func (c *Client) getJSON(ctx context.Context, path string, query url.Values, out interface{}) error {
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return c.do(ctx, http.MethodGet, path, "", nil, out)
+}
+
+// sendJSON sends body as JSON and decodes the response into out, when not nil
+func (c *Client) sendJSON(ctx context.Context, method, path string, body, out interface{}) error {
+	var r io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		r = bytes.NewReader(data)
+	}
+	return c.do(ctx, method, path, "application/json", r, out)
+}
+
+// do sends a request to the API path and decodes a successful response into
+// out, when not nil, or an error response into *Error
+func (c *Client) do(ctx context.Context, method, path, contentType string, body io.Reader, out interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+APIPrefix+path, body)
+	if err != nil {
+		return err
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+	if c.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+		if json.Unmarshal(data, apiErr) != nil || apiErr.Message == "" {
+			apiErr.Code = "http_error"
+			apiErr.Message = strings.TrimSpace(string(data))
+			if apiErr.Message == "" {
+				apiErr.Message = resp.Status
+			}
+		}
+		return apiErr
+	}
+	if out == nil {
+		io.Copy(io.Discard, resp.Body)
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decoding %s %s response: %w", method, path, err)
+	}
+	return nil
+}
@@ -0,0 +1,167 @@
+package client
+
+import (
+	"backend-go/internal/models"
+	"context"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// Status reports which files are loaded
+func (c *Client) Status(ctx context.Context) (*StatusResponse, error) {
+	var resp StatusResponse
+	if err := c.getJSON(ctx, "/status", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Upload loads content as file 1 or 2 of the comparison. name is the file
+// name, whose extension tells CSV from .gz and .zip uploads.
+func (c *Client) Upload(ctx context.Context, fileIndex int, name string, content io.Reader, opts *UploadOptions) (*UploadResponse, error) {
+	if opts == nil {
+		opts = &UploadOptions{}
+	}
+	fields := map[string]string{
+		"file_index": strconv.Itoa(fileIndex),
+		"entry":      opts.Entry,
+		"delimiter":  opts.Delimiter,
+		"quote":      opts.Quote,
+	}
+	if opts.HasHeader != nil {
+		fields["has_header"] = strconv.FormatBool(*opts.HasHeader)
+	}
+
+	// Stream the multipart body rather than buffering the file
+	pr, pw := io.Pipe()
+	mw := multipart.NewWriter(pw)
+	go func() {
+		for k, v := range fields {
+			if v != "" {
+				if err := mw.WriteField(k, v); err != nil {
+					pw.CloseWithError(err)
+					return
+				}
+			}
+		}
+		part, err := mw.CreateFormFile("file", filepath.Base(name))
+		if err == nil {
+			_, err = io.Copy(part, content)
+		}
+		if err == nil {
+			err = mw.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	var resp UploadResponse
+	err := c.do(ctx, http.MethodPost, "/upload", mw.FormDataContentType(), pr, &resp)
+	pr.Close()
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// UploadFile uploads the file at path as file 1 or 2
+func (c *Client) UploadFile(ctx context.Context, fileIndex int, path string, opts *UploadOptions) (*UploadResponse, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return c.Upload(ctx, fileIndex, filepath.Base(path), f, opts)
+}
+
+// ColumnSimilarity matches the columns of the loaded files
+func (c *Client) ColumnSimilarity(ctx context.Context, opts *SimilarityOptions) (*SimilarityGraph, error) {
+	q := url.Values{}
+	if opts != nil {
+		if opts.UseAI {
+			q.Set("use_ai", "true")
+		}
+		for k, v := range map[string]string{"profile": opts.Profile, "blocking": opts.Blocking, "name_algorithm": opts.NameAlgorithm} {
+			if v != "" {
+				q.Set(k, v)
+			}
+		}
+	}
+	var resp SimilarityGraph
+	if err := c.getJSON(ctx, "/column-similarity", q, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Filter filters, sorts and pages the rows of file 1
+func (c *Client) Filter(ctx context.Context, req FilterRequest) (*FilterResponse, error) {
+	var resp FilterResponse
+	if err := c.sendJSON(ctx, http.MethodPost, "/filter", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Query answers a natural-language question about the loaded files
+func (c *Client) Query(ctx context.Context, req QueryRequest) (*QueryResponse, error) {
+	var resp QueryResponse
+	if err := c.sendJSON(ctx, http.MethodPost, "/query", req, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// SubmitContext stores the answers describing a file (dataset_purpose,
+// business_domain, key_entities, temporal_context, exclusions)
+func (c *Client) SubmitContext(ctx context.Context, fileIndex int, data map[string]interface{}) error {
+	req := models.ContextSubmitRequest{FileIndex: fileIndex, ContextData: data}
+	return c.sendJSON(ctx, http.MethodPost, "/context/submit", req, nil)
+}
+
+// ContextStatus reports which files have context
+func (c *Client) ContextStatus(ctx context.Context) (*ContextStatusResponse, error) {
+	var resp ContextStatusResponse
+	if err := c.getJSON(ctx, "/context/status", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ApprovedMapping returns the reviewed column pairs of the loaded files
+func (c *Client) ApprovedMapping(ctx context.Context) (*ApprovedMapping, error) {
+	var resp ApprovedMapping
+	if err := c.getJSON(ctx, "/mappings/approved", nil, &resp); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// AcceptMapping approves a suggested column pair
+func (c *Client) AcceptMapping(ctx context.Context, review MappingReview) (*ApprovedMapping, error) {
+	return c.reviewMapping(ctx, "/mappings/accept", review)
+}
+
+// RejectMapping rejects a suggested column pair
+func (c *Client) RejectMapping(ctx context.Context, review MappingReview) (*ApprovedMapping, error) {
+	return c.reviewMapping(ctx, "/mappings/reject", review)
+}
+
+// CreateManualMapping adds a column pair the matcher didn't suggest
+func (c *Client) CreateManualMapping(ctx context.Context, review MappingReview) (*ApprovedMapping, error) {
+	return c.reviewMapping(ctx, "/mappings/manual", review)
+}
+
+func (c *Client) reviewMapping(ctx context.Context, path string, review MappingReview) (*ApprovedMapping, error) {
+	var resp struct {
+		Mapping ApprovedMapping `json:"mapping"`
+	}
+	if err := c.sendJSON(ctx, http.MethodPost, path, review, &resp); err != nil {
+		return nil, err
+	}
+	return &resp.Mapping, nil
+}
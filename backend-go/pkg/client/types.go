@@ -0,0 +1,47 @@
+package client
+
+import "backend-go/internal/models"
+
+// Types shared with the server, so their JSON stays in step with it
+type (
+	UploadResponse        = models.UploadResponse
+	CSVDialect            = models.CSVDialect
+	StatusResponse        = models.StatusResponse
+	FileStatus            = models.FileStatus
+	SimilarityGraph       = models.SimilarityGraph
+	Node                  = models.Node
+	Edge                  = models.Edge
+	Similarity            = models.Similarity
+	Correlation           = models.Correlation
+	ColumnTransform       = models.ColumnTransform
+	CompositeJoinKey      = models.CompositeJoinKey
+	FilterRequest         = models.FilterRequest
+	FilterGroup           = models.FilterGroup
+	FilterCondition       = models.FilterCondition
+	FilterResponse        = models.FilterResponse
+	ContextStatusResponse = models.ContextStatusResponse
+	ContextStatusItem     = models.ContextStatusItem
+	Context               = models.Context
+	QueryRequest          = models.QueryRequest
+	QueryResponse         = models.QueryResponse
+	QueryPlan             = models.QueryPlan
+	MappingReview         = models.MappingReview
+	ColumnMapping         = models.ColumnMapping
+	ApprovedMapping       = models.ApprovedMapping
+)
+
+// UploadOptions override how an upload is parsed; zero fields are detected
+type UploadOptions struct {
+	Entry     string // File to extract from a multi-entry zip
+	Delimiter string
+	Quote     string
+	HasHeader *bool
+}
+
+// SimilarityOptions select how columns are matched
+type SimilarityOptions struct {
+	UseAI         bool   // Match with the LLM instead of the heuristics
+	Profile       string // Matching profile setting weights, thresholds and assignment
+	Blocking      string
+	NameAlgorithm string
+}